@@ -0,0 +1,112 @@
+package applier
+
+import (
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// tableOptionPolicy indicates which table options should be omitted from
+// generated DDL, according to the table-encryption and page-compression
+// options. These table options often legitimately differ between environments.
+type tableOptionPolicy struct {
+	ignoreEncryption  bool
+	ignoreCompression bool
+}
+
+// tableOptionPolicyForDir returns the tableOptionPolicy configured for dir.
+// The options' values should already have been validated by PlanTarget.
+func tableOptionPolicyForDir(dir *fs.Dir) tableOptionPolicy {
+	return tableOptionPolicy{
+		ignoreEncryption:  strings.EqualFold(dir.Config.Get("table-encryption"), "ignore"),
+		ignoreCompression: strings.EqualFold(dir.Config.Get("page-compression"), "ignore"),
+	}
+}
+
+// createStatement returns stmt, a CREATE TABLE, with any table options
+// omitted by policy removed.
+func (policy tableOptionPolicy) createStatement(stmt string, flavor tengo.Flavor) string {
+	if policy.ignoreEncryption {
+		stmt = util.StripEncryptionOptions(stmt)
+	}
+	if flavor.Vendor == tengo.VendorMariaDB {
+		var names []string
+		for _, name := range mariaDBTableOptionNames {
+			if policy.ignoresMariaDBTableOption(name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			stmt = util.StripMariaDBTableOptions(&tengo.Table{CreateStatement: stmt}, names...).CreateStatement
+		}
+	}
+	return stmt
+}
+
+// alterStatement returns an ALTER TABLE for td, which must be an ALTER diff.
+// Package tengo does not introspect Percona Server's column compression
+// attributes or table encryption options, CHECK constraints on MySQL 8, nor
+// MariaDB's page compression and encryption table options. If either side of
+// td uses any of these, or the sides differ in encryption, the ALTER is
+// computed without them, and then adjusted to include any changes to them,
+// except for table options omitted by policy. Otherwise, this is equivalent to
+// td.Statement(mods).
+func alterStatement(td *tengo.TableDiff, mods tengo.StatementModifiers, policy tableOptionPolicy) (string, error) {
+	percona := mods.Flavor.Vendor == tengo.VendorPercona && hasPerconaAttributes(td)
+	checks := mods.Flavor.MySQLishMinVersion(8, 0) && hasCheckConstraints(td)
+	encryption := hasEncryptionChange(td)
+	mariadb := mods.Flavor.Vendor == tengo.VendorMariaDB && hasMariaDBTableOptions(td)
+	if !percona && !checks && !encryption && !mariadb {
+		return td.Statement(mods)
+	}
+	if mods.IgnoreTable != nil && mods.IgnoreTable.MatchString(td.To.Name) {
+		return "", nil
+	}
+
+	from := util.StripCheckConstraints(util.StripTableEncryption(util.StripPerconaAttributes(td.From)))
+	to := util.StripCheckConstraints(util.StripTableEncryption(util.StripPerconaAttributes(td.To)))
+	if mariadb {
+		from, to = util.StripMariaDBTableOptions(from), util.StripMariaDBTableOptions(to)
+	}
+	prefix := td.From.AlterStatement() + " "
+	stmt, err := tengo.NewAlterTable(from, to).Statement(mods)
+	if err != nil && !tengo.IsForbiddenDiff(err) {
+		return stmt, err
+	}
+	var options, clauses []string
+	if stmt != "" {
+		for _, clause := range splitTopLevelCommas(strings.TrimPrefix(stmt, prefix)) {
+			upper := strings.ToUpper(clause)
+			if strings.HasPrefix(upper, "ALGORITHM=") || strings.HasPrefix(upper, "LOCK=") {
+				options = append(options, clause)
+			} else {
+				clauses = append(clauses, clause)
+			}
+		}
+	} else {
+		if mods.AlgorithmClause != "" {
+			options = append(options, "ALGORITHM="+strings.ToUpper(mods.AlgorithmClause))
+		}
+		if mods.LockClause != "" {
+			options = append(options, "LOCK="+strings.ToUpper(mods.LockClause))
+		}
+	}
+	if percona {
+		clauses = perconaClauses(td, mods, clauses)
+	}
+	if encryption && !policy.ignoreEncryption {
+		clauses = encryptionClauses(td, clauses)
+	}
+	if mariadb {
+		clauses = mariaDBTableOptionClauses(td, policy, clauses)
+	}
+	if checks {
+		clauses = checkConstraintClauses(td, clauses)
+	}
+	if len(clauses) == 0 {
+		return "", err
+	}
+	return prefix + strings.Join(append(options, clauses...), ", "), err
+}
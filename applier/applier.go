@@ -1,21 +1,41 @@
 // Package applier handles execution of generating diffs between schemas, and
 // appropriate application of the generated DDL.
+//
+// Programs embedding Skeema's diff engine should define options using
+// AddCommandOptions and util.AddGlobalOptions, obtain Targets for an fs.Dir
+// using TargetsForDir, and then call PlanTarget to obtain DDL for each Target
+// without any output. Worker and Printer implement the behavior of the
+// `skeema diff` and `skeema push` commands on top of this.
 package applier
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
 // Result stores the overall result of all operations the worker has completed.
 type Result struct {
-	Differences      bool
-	SkipCount        int
-	UnsupportedCount int
+	Differences        bool
+	SkipCount          int
+	UnsupportedCount   int
+	FailedTargets      []*Target // targets which had at least one skipped operation due to error
+	RetryableTargets   []*Target // subset of FailedTargets whose error was transient, see IsRetryableError
+	PushedTargets      []*Target // targets which had at least one statement executed successfully
+	TargetCount        int       // targets processed, including ones without differences
+	SkippedTargetCount int       // targets not processed, due to check-target-state aborting an earlier one on the same instance
+	ObjectDiffCount    int       // objects with differences, excluding ignored objects
+	StatementCount     int       // DDL statements generated
+	ExecutedCount      int       // DDL statements executed successfully; always 0 for dry-run
+	FailedCount        int       // DDL statements which returned an error upon execution
+	UnsafeCount        int       // destructive differences not permitted by the configuration
+	DeclinedCount      int       // DDL statements declined by the operator during interactive review
 }
 
 // Worker reads TargetGroups from the input channel and performs the appropriate
@@ -25,91 +45,161 @@ type Result struct {
 // be called via an errgroup (see golang.org/x/sync/errgroup).
 func Worker(ctx context.Context, targetGroups <-chan TargetGroup, results chan<- Result, printer *Printer) error {
 	var result Result
+TargetGroups:
 	for tg := range targetGroups {
+		checker := &stateChecker{instance: tg[0].Instance}
 	TargetsInGroup:
-		for _, t := range tg { // iterate over each Target in the TargetGroup
+		for n, t := range tg { // iterate over each Target in the TargetGroup
 			// Get schema name from t.SchemaFromDir, NOT t.SchemaFromInstance, since
 			// t.SchemaFromInstance will be nil if the schema doesn't exist yet
 			schemaName := t.SchemaFromDir.Name
 			dryRun := t.Dir.Config.GetBool("dry-run")
 			brief := dryRun && t.Dir.Config.GetBool("brief")
+			logger := log.WithFields(log.Fields{"host": t.Instance.String(), "schema": schemaName})
 
+			source := t.Dir.String() + "/*.sql"
+			if t.Source != "" {
+				source = t.Source
+			}
 			if dryRun {
-				log.Infof("Generating diff of %s %s vs %s/*.sql", t.Instance, schemaName, t.Dir)
+				logger.Infof("Generating diff of %s %s vs %s", t.Instance, schemaName, source)
 			} else {
-				log.Infof("Pushing changes from %s/*.sql to %s %s", t.Dir, t.Instance, schemaName)
+				logger.Infof("Pushing changes from %s to %s %s", source, t.Instance, schemaName)
 			}
-			if len(t.Dir.IgnoredStatements) > 0 {
-				log.Warnf("Ignoring %d unsupported or unparseable statements found in this directory's *.sql files; run `skeema lint` for more info", len(t.Dir.IgnoredStatements))
+			if len(t.Dir.IgnoredStatements) > 0 && t.Source == "" {
+				logger.Warnf("Ignoring %d unsupported or unparseable statements found in this directory's *.sql files; run `skeema lint` for more info", len(t.Dir.IgnoredStatements))
 			}
+			targetSpan := util.Tracing.StartSpan(nil, "target")
+			targetSpan.SetAttribute("db.instance", t.Instance.String())
+			targetSpan.SetAttribute("db.name", schemaName)
+			targetSpan.SetAttribute("skeema.dry_run", dryRun)
 
-			diff := tengo.NewSchemaDiff(t.SchemaFromInstance, t.SchemaFromDir)
-			var targetStmtCount int
-
-			if t.Dir.Config.GetBool("verify") && len(diff.TableDiffs) > 0 && !brief {
-				if err := VerifyDiff(diff, t); err != nil {
+			// Build DDLStatements for each ObjectDiff, handling pre-execution errors
+			// accordingly
+			diffSpan := util.Tracing.StartSpan(targetSpan, "diff")
+			plan, err := PlanTarget(t)
+			diffSpan.End(err)
+			if _, ok := err.(ConfigError); ok {
+				targetSpan.End(err)
+				return err
+			}
+			result.TargetCount++
+			result.ObjectDiffCount += len(plan.ObjectDiffs)
+			result.UnsafeCount += len(plan.Unsafe)
+			if t.Dir.Config.GetBool("verify") && len(plan.Diff.TableDiffs) > 0 && !brief {
+				if err := VerifyDiff(plan.Diff, t); err != nil {
+					targetSpan.End(err)
 					return err
 				}
 			}
-
-			// Obtain StatementModifiers based on the dir's config
-			mods, err := StatementModifiersForDir(t.Dir)
 			if err != nil {
-				return ConfigError(err.Error())
-			}
-			mods.Flavor = t.Instance.Flavor()
-
-			// Build DDLStatements for each ObjectDiff, handling pre-execution errors
-			// accordingly
-			objDiffs := diff.ObjectDiffs()
-			ddls := make([]*DDLStatement, 0, len(objDiffs))
-			for _, objDiff := range objDiffs {
-				ddl, err := NewDDLStatement(objDiff, mods, t)
-				if ddl == nil && err == nil {
-					continue // Skip entirely if mods made the statement a noop
+				result.Differences = true
+				result.SkipCount += len(plan.ObjectDiffs)
+				result.FailedTargets = append(result.FailedTargets, t)
+				if IsRetryableError(err) {
+					result.RetryableTargets = append(result.RetryableTargets, t)
+				}
+				logger.Errorf(err.Error())
+				if len(plan.ObjectDiffs) > 1 {
+					logger.Warnf("Skipping %d additional operations for %s %s due to previous error", len(plan.ObjectDiffs)-1, t.Instance, schemaName)
 				}
-				targetStmtCount++
+				targetSpan.End(err)
+				continue TargetsInGroup
+			}
+			targetStmtCount := plan.StatementCount()
+			if targetStmtCount > 0 {
 				result.Differences = true
-				if err == nil {
-					ddls = append(ddls, ddl)
-				} else if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
-					result.UnsupportedCount++
-					log.Warnf("Skipping %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey)
-					DebugLogUnsupportedDiff(unsupportedErr)
-				} else {
-					result.SkipCount += len(objDiffs)
-					log.Errorf(err.Error())
-					if len(objDiffs) > 1 {
-						log.Warnf("Skipping %d additional operations for %s %s due to previous error", len(objDiffs)-1, t.Instance, schemaName)
-					}
-					continue TargetsInGroup
+			}
+			for _, unsupportedErr := range plan.Unsupported {
+				result.UnsupportedCount++
+				logger.WithField("object", unsupportedErr.ObjectKey.String()).Warnf("Skipping %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey)
+				DebugLogUnsupportedDiff(unsupportedErr)
+			}
+			ddls := plan.Statements
+			result.StatementCount += len(ddls)
+			if !dryRun && printer.reviewer != nil {
+				approved := printer.reviewer.Review(t, ddls)
+				if declined := len(ddls) - len(approved); declined > 0 {
+					result.DeclinedCount += declined
+					logger.Warnf("Skipping %d statement%s for %s %s, declined during review", declined, plural(declined), t.Instance, schemaName)
 				}
+				ddls = approved
 			}
 
-			// Print DDL; if not dry-run, execute it
+			// Print DDL; if not dry-run, execute it. Before each statement, confirm the
+			// instance hasn't failed over or become read-only, if requested. Executed
+			// statements are recorded in the history table, if configured.
+			var history *historyRecorder
+			if !dryRun && len(ddls) > 0 {
+				history = newHistoryRecorder(t)
+			}
+			var targetExecutedCount int
 			for i, ddl := range ddls {
+				if !dryRun && t.Dir.Config.GetBool("check-target-state") {
+					if err := checker.check(); err != nil {
+						logger.Errorf("Aborting operations on %s: %s", t.Instance, err)
+						targetSpan.End(err)
+						history.abort()
+						history.finish()
+						if targetExecutedCount > 0 {
+							result.PushedTargets = append(result.PushedTargets, t)
+						}
+						result.SkipCount += len(ddls) - i
+						result.FailedTargets = append(result.FailedTargets, t)
+						if remaining := tg[n+1:]; len(remaining) > 0 {
+							logger.Warnf("Skipping %d remaining schemas on %s due to previous error", len(remaining), t.Instance)
+							result.SkippedTargetCount += len(remaining)
+							result.FailedTargets = append(result.FailedTargets, remaining...)
+						}
+						continue TargetGroups
+					}
+				}
 				printer.printDDL(ddl)
+				if err := printer.exportDDL(ddl); err != nil {
+					err = fmt.Errorf("Unable to export DDL for %s %s: %s", t.Instance, schemaName, err)
+					targetSpan.End(err)
+					return err
+				}
 				if !dryRun {
-					if err := ddl.Execute(); err != nil {
-						log.Errorf("Error running DDL on %s %s: %s", t.Instance, schemaName, err)
+					stmtSpan := util.Tracing.StartSpan(targetSpan, "execute statement")
+					stmtSpan.SetAttribute("db.statement", ddl.String())
+					err := history.executeDDL(ddl)
+					stmtSpan.End(err)
+					stmtLogger := logger.WithFields(log.Fields{"object": ddl.objectName(), "statement_id": i + 1})
+					if err != nil {
+						targetSpan.End(err)
+						result.FailedCount++
+						stmtLogger.Errorf("Error running DDL on %s %s: %s", t.Instance, schemaName, err)
 						skipped := len(ddls) - i
 						result.SkipCount += skipped
+						result.FailedTargets = append(result.FailedTargets, t)
+						if IsRetryableError(err) {
+							result.RetryableTargets = append(result.RetryableTargets, t)
+						}
 						if skipped > 1 {
-							log.Warnf("Skipping %d remaining operations for %s %s due to previous error", skipped-1, t.Instance, schemaName)
+							logger.Warnf("Skipping %d remaining operations for %s %s due to previous error", skipped-1, t.Instance, schemaName)
 						}
 						break
 					}
+					stmtLogger.Debugf("Executed statement %d of %d", i+1, len(ddls))
+					result.ExecutedCount++
+					targetExecutedCount++
 				}
 			}
+			history.finish()
+			targetSpan.End(nil)
+			if targetExecutedCount > 0 {
+				result.PushedTargets = append(result.PushedTargets, t)
+			}
 
 			if targetStmtCount == 0 {
-				log.Infof("%s %s: No differences found\n", t.Instance, schemaName)
+				logger.Infof("%s %s: No differences found\n", t.Instance, schemaName)
 			} else {
 				verb := "push"
 				if dryRun {
 					verb = "diff"
 				}
-				log.Infof("%s %s: %s complete\n", t.Instance, schemaName, verb)
+				logger.Infof("%s %s: %s complete\n", t.Instance, schemaName, verb)
 			}
 
 			// Exit early if context cancelled
@@ -131,6 +221,17 @@ func SumResults(results []Result) Result {
 		total.Differences = total.Differences || r.Differences
 		total.SkipCount += r.SkipCount
 		total.UnsupportedCount += r.UnsupportedCount
+		total.FailedTargets = append(total.FailedTargets, r.FailedTargets...)
+		total.RetryableTargets = append(total.RetryableTargets, r.RetryableTargets...)
+		total.PushedTargets = append(total.PushedTargets, r.PushedTargets...)
+		total.TargetCount += r.TargetCount
+		total.SkippedTargetCount += r.SkippedTargetCount
+		total.ObjectDiffCount += r.ObjectDiffCount
+		total.StatementCount += r.StatementCount
+		total.ExecutedCount += r.ExecutedCount
+		total.FailedCount += r.FailedCount
+		total.UnsafeCount += r.UnsafeCount
+		total.DeclinedCount += r.DeclinedCount
 	}
 	return total
 }
@@ -152,12 +253,38 @@ func StatementModifiersForDir(dir *fs.Dir) (mods tengo.StatementModifiers, err e
 	if mods.LockClause, err = dir.Config.GetEnum("alter-lock", "NONE", "SHARED", "EXCLUSIVE", "DEFAULT"); err != nil {
 		return
 	}
-	if mods.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+	var ignoreOpts fs.IgnoreOptions
+	if ignoreOpts, err = dir.IgnoreOptions(); err != nil {
 		return
 	}
+	mods.IgnoreTable = ignoreOpts.Table
 	return
 }
 
+// SessionVarsForDir returns the session variables that should be set on
+// connections used for executing DDL, based on the dir's push-session-vars
+// option. These are distinct from connect-options, which apply to all
+// connections, including ones used for workspaces.
+func SessionVarsForDir(dir *fs.Dir) (url.Values, error) {
+	options, err := util.SplitConnectOptions(dir.Config.Get("push-session-vars"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid push-session-vars: %s", err)
+	}
+	v := url.Values{}
+	for name, value := range options {
+		lowerName := strings.ToLower(name)
+		if util.IsDriverParam(lowerName) {
+			return nil, fmt.Errorf("push-session-vars may only contain session variables, but %s is a driver parameter; use connect-options instead", name)
+		} else if lowerName == "foreign_key_checks" {
+			return nil, fmt.Errorf("push-session-vars is not allowed to contain %s; use the foreign-key-checks option instead", name)
+		} else if lowerName == "sql_mode" && strings.Contains(strings.ToLower(value), "ansi") {
+			return nil, fmt.Errorf("Skeema does not support use of the ANSI_QUOTES sql_mode")
+		}
+		v.Set(name, value)
+	}
+	return v, nil
+}
+
 // DebugLogUnsupportedDiff logs (at Debug level) the reason why an object is
 // unsupported for diff/alter operations.
 func DebugLogUnsupportedDiff(err *tengo.UnsupportedDiffError) {
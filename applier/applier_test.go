@@ -3,6 +3,7 @@ package applier
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -26,21 +27,72 @@ func TestSumResults(t *testing.T) {
 			UnsupportedCount: 0,
 		},
 		{
-			Differences:      true,
-			SkipCount:        3,
-			UnsupportedCount: 5,
+			Differences:        true,
+			SkipCount:          3,
+			UnsupportedCount:   5,
+			SkippedTargetCount: 2,
 		},
 	}
 	expectSum := Result{
-		Differences:      true,
-		SkipCount:        4,
-		UnsupportedCount: 5,
+		Differences:        true,
+		SkipCount:          4,
+		UnsupportedCount:   5,
+		SkippedTargetCount: 2,
 	}
-	if actualSum := SumResults(input); actualSum != expectSum {
+	if actualSum := SumResults(input); !reflect.DeepEqual(actualSum, expectSum) {
 		t.Errorf("Unexpected result from SumResults: %+v", actualSum)
 	}
 }
 
+func TestSessionVarsForDir(t *testing.T) {
+	dir := getDir(t, "../testdata/applier/simple", "--push-session-vars=\"lock_wait_timeout=5,sql_log_bin=0\"")
+	v, err := SessionVarsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from SessionVarsForDir: %s", err)
+	}
+	if v.Get("lock_wait_timeout") != "5" || v.Get("sql_log_bin") != "0" || len(v) != 2 {
+		t.Errorf("Unexpected result from SessionVarsForDir: %+v", v)
+	}
+
+	badValues := []string{
+		"lock_wait_timeout",
+		"readTimeout=5s",
+		"foreign_key_checks=1",
+		"sql_mode='ANSI_QUOTES'",
+	}
+	for _, badValue := range badValues {
+		dir := getDir(t, "../testdata/applier/simple", fmt.Sprintf("--push-session-vars=\"%s\"", badValue))
+		if _, err := SessionVarsForDir(dir); err == nil {
+			t.Errorf("Expected error from SessionVarsForDir with push-session-vars=%s, but err was nil", badValue)
+		}
+	}
+}
+
+func TestTargetSessionVars(t *testing.T) {
+	dir := getDir(t, "../testdata/applier/simple", "--push-session-vars=\"lock_wait_timeout=5\"")
+	target := &Target{Dir: dir}
+	v, err := target.sessionVars()
+	if err != nil || v.Get("lock_wait_timeout") != "5" || len(v) != 1 {
+		t.Fatalf("Unexpected result from sessionVars: %+v, %v", v, err)
+	}
+
+	// Modifying the returned value must not affect subsequent calls
+	v.Set("foreign_key_checks", "1")
+	v.Set("lock_wait_timeout", "10")
+	if v, _ := target.sessionVars(); v.Get("lock_wait_timeout") != "5" || len(v) != 1 {
+		t.Errorf("Unexpected result from sessionVars after modifying previous result: %+v", v)
+	}
+
+	// The option is only parsed once per target
+	target.Dir = getDir(t, "../testdata/applier/simple", "--push-session-vars=\"lock_wait_timeout=20\"")
+	if v, _ := target.sessionVars(); v.Get("lock_wait_timeout") != "5" {
+		t.Errorf("Expected sessionVars to return cached value, instead found %+v", v)
+	}
+	if v, _ := (&Target{Dir: target.Dir}).sessionVars(); v.Get("lock_wait_timeout") != "20" {
+		t.Errorf("Expected sessionVars for a new target to reflect current config, instead found %+v", v)
+	}
+}
+
 func TestIntegration(t *testing.T) {
 	images := tengo.SplitEnv("SKEEMA_TEST_IMAGES")
 	if len(images) == 0 {
@@ -0,0 +1,34 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// checkAuroraLimitations returns an error if any statement in plan cannot be
+// run on the supplied Aurora release. Currently this only concerns
+// ALGORITHM=INSTANT, which Aurora releases prior to 3 reject. Statements run
+// via alter-wrapper or ddl-wrapper are not checked, since alter-algorithm is
+// ignored in that situation.
+func checkAuroraLimitations(plan *Plan, aurora util.AuroraVersion) error {
+	if aurora.SupportsInstantDDL() {
+		return nil
+	}
+	for _, ddl := range plan.Statements {
+		td, ok := ddl.diff.(*tengo.TableDiff)
+		if !ok || td.DiffType() != tengo.DiffTypeAlter || ddl.IsShellOut() {
+			continue
+		}
+		if strings.Contains(ddl.stmt, "ALGORITHM=INSTANT") {
+			advice := "remove the alter-algorithm option"
+			if aurora.FastDDL() {
+				advice += " to permit Aurora Fast DDL for eligible ADD COLUMN operations"
+			}
+			return fmt.Errorf("Unable to alter %s: %s does not support ALGORITHM=INSTANT; %s", td.ObjectKey(), aurora, advice)
+		}
+	}
+	return nil
+}
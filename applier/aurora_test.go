@@ -0,0 +1,34 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestCheckAuroraLimitations(t *testing.T) {
+	table := &tengo.Table{Name: "posts"}
+	td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: table, To: table}
+	plan := &Plan{Statements: []*DDLStatement{
+		{stmt: "ALTER TABLE `posts` ADD COLUMN `body` text, ALGORITHM=INSTANT", diff: td},
+	}}
+
+	if err := checkAuroraLimitations(plan, util.ParseAuroraVersion("3.04.0")); err != nil {
+		t.Errorf("Unexpected error for Aurora 3: %v", err)
+	}
+	aurora2 := util.ParseAuroraVersion("2.11.2")
+	if err := checkAuroraLimitations(plan, aurora2); err == nil || !strings.Contains(err.Error(), "Aurora MySQL 2.11.2 does not support ALGORITHM=INSTANT") {
+		t.Errorf("Expected error for Aurora 2, instead found %v", err)
+	}
+	aurora2.LabMode = true
+	if err := checkAuroraLimitations(plan, aurora2); err == nil || !strings.Contains(err.Error(), "Fast DDL") {
+		t.Errorf("Expected error mentioning Fast DDL for Aurora 2 with lab mode, instead found %v", err)
+	}
+
+	plan.Statements[0].stmt = "ALTER TABLE `posts` ADD COLUMN `body` text, ALGORITHM=INPLACE"
+	if err := checkAuroraLimitations(plan, aurora2); err != nil {
+		t.Errorf("Unexpected error for ALGORITHM=INPLACE: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package applier
+
+import (
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// hasCheckConstraints returns true if either side of td has any CHECK
+// constraints.
+func hasCheckConstraints(td *tengo.TableDiff) bool {
+	return len(util.CheckConstraints(td.From)) > 0 || len(util.CheckConstraints(td.To)) > 0
+}
+
+// checkConstraintClauses adjusts clauses, which were generated by package
+// tengo without knowledge of CHECK constraints, to reflect td's changes to
+// them. A constraint whose expression changed is dropped and re-added, while a
+// constraint which only changed between ENFORCED and NOT ENFORCED is altered in
+// place. Constraints are dropped prior to any other clauses, and added after
+// them, so that they never refer to a column which does not exist.
+func checkConstraintClauses(td *tengo.TableDiff, clauses []string) []string {
+	fromChecks, toChecks := util.CheckConstraints(td.From), util.CheckConstraints(td.To)
+	fromByName := make(map[string]util.CheckConstraint, len(fromChecks))
+	for _, cc := range fromChecks {
+		fromByName[cc.Name] = cc
+	}
+	toByName := make(map[string]util.CheckConstraint, len(toChecks))
+	for _, cc := range toChecks {
+		toByName[cc.Name] = cc
+	}
+
+	var drops, adds []string
+	for _, fromCheck := range fromChecks {
+		if toCheck, ok := toByName[fromCheck.Name]; !ok || toCheck.Expression != fromCheck.Expression {
+			drops = append(drops, "DROP CHECK "+tengo.EscapeIdentifier(fromCheck.Name))
+		}
+	}
+	for _, toCheck := range toChecks {
+		fromCheck, ok := fromByName[toCheck.Name]
+		if !ok || fromCheck.Expression != toCheck.Expression {
+			adds = append(adds, toCheck.Definition())
+		} else if fromCheck.Enforced != toCheck.Enforced {
+			clause := "ALTER CHECK " + tengo.EscapeIdentifier(toCheck.Name)
+			if toCheck.Enforced {
+				clause += " ENFORCED"
+			} else {
+				clause += " NOT ENFORCED"
+			}
+			adds = append(adds, clause)
+		}
+	}
+	return append(append(drops, clauses...), adds...)
+}
@@ -0,0 +1,79 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestAlterStatementCheckConstraints(t *testing.T) {
+	// makeTable returns a table with the supplied columns and CHECK constraint
+	// definitions
+	makeTable := func(checks []string, cols ...*tengo.Column) *tengo.Table {
+		table := &tengo.Table{
+			Name:               "products",
+			Engine:             "InnoDB",
+			CharSet:            "utf8mb4",
+			Collation:          "utf8mb4_0900_ai_ci",
+			CollationIsDefault: true,
+			Columns:            cols,
+			PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{cols[0]}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL80)
+		for _, check := range checks {
+			table.CreateStatement = strings.Replace(table.CreateStatement, "\n) ENGINE", ",\n  "+check+"\n) ENGINE", 1)
+		}
+		return table
+	}
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int unsigned", Default: tengo.ColumnDefaultNull}
+	priceCol := &tengo.Column{Name: "price", TypeInDB: "decimal(10,2)", Default: tengo.ColumnDefaultNull}
+	qtyCol := &tengo.Column{Name: "qty", TypeInDB: "int", Default: tengo.ColumnDefaultNull}
+	enforced := "CONSTRAINT `chk_price` CHECK ((`price` > 0))"
+	notEnforced := enforced + " /*!80016 NOT ENFORCED */"
+	mods := tengo.StatementModifiers{Flavor: tengo.FlavorMySQL80}
+
+	cases := []struct {
+		from, to *tengo.Table
+		expected string
+	}{
+		{
+			from:     makeTable([]string{enforced}, idCol, priceCol),
+			to:       makeTable([]string{notEnforced}, idCol, priceCol),
+			expected: "ALTER TABLE `products` ALTER CHECK `chk_price` NOT ENFORCED",
+		},
+		{
+			from:     makeTable([]string{notEnforced}, idCol, priceCol),
+			to:       makeTable([]string{enforced}, idCol, priceCol),
+			expected: "ALTER TABLE `products` ALTER CHECK `chk_price` ENFORCED",
+		},
+		{
+			from:     makeTable([]string{enforced}, idCol, priceCol),
+			to:       makeTable([]string{"CONSTRAINT `chk_price` CHECK ((`price` >= 0))"}, idCol, priceCol),
+			expected: "ALTER TABLE `products` DROP CHECK `chk_price`, ADD CONSTRAINT `chk_price` CHECK ((`price` >= 0))",
+		},
+		{
+			from:     makeTable([]string{enforced}, idCol, priceCol),
+			to:       makeTable([]string{enforced, "CONSTRAINT `chk_qty` CHECK ((`qty` > 0)) /*!80016 NOT ENFORCED */"}, idCol, priceCol, qtyCol),
+			expected: "ALTER TABLE `products` ADD COLUMN `qty` int NOT NULL, ADD CONSTRAINT `chk_qty` CHECK ((`qty` > 0)) NOT ENFORCED",
+		},
+		{
+			from:     makeTable([]string{enforced}, idCol, priceCol),
+			to:       makeTable(nil, idCol, priceCol),
+			expected: "ALTER TABLE `products` DROP CHECK `chk_price`",
+		},
+		{
+			from:     makeTable([]string{enforced}, idCol, priceCol),
+			to:       makeTable([]string{enforced}, idCol, priceCol),
+			expected: "",
+		},
+	}
+	for n, c := range cases {
+		td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: c.from, To: c.to}
+		if stmt, err := alterStatement(td, mods, tableOptionPolicy{}); err != nil {
+			t.Errorf("Case %d: unexpected error from alterStatement: %v", n, err)
+		} else if stmt != c.expected {
+			t.Errorf("Case %d: expected statement:\n%s\ninstead found:\n%s", n, c.expected, stmt)
+		}
+	}
+}
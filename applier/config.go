@@ -0,0 +1,37 @@
+package applier
+
+import (
+	"github.com/skeema/mybase"
+)
+
+// AddCommandOptions adds mybase options affecting diff generation and DDL
+// execution to the supplied mybase.Command. Programs embedding this package
+// should call this, along with util.AddGlobalOptions, on the command used to
+// build configuration for fs.ParseDir, so that every option read by this
+// package is defined.
+func AddCommandOptions(cmd *mybase.Command) {
+	cmd.AddOption(mybase.BoolOption("verify", 0, true, "Test all generated ALTER statements on temp schema to verify correctness"))
+	cmd.AddOption(mybase.BoolOption("allow-unsafe", 0, false, "Permit running ALTER or DROP operations that are potentially destructive"))
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Output DDL but don't run it; equivalent to `skeema diff`"))
+	cmd.AddOption(mybase.BoolOption("first-only", '1', false, "For dirs mapping to multiple instances or schemas, just run against the first per dir"))
+	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Follow *.sql table definitions exactly, even for differences with no functional impact"))
+	cmd.AddOption(mybase.BoolOption("foreign-key-checks", 0, false, "Force the server to check referential integrity of any new foreign key"))
+	cmd.AddOption(mybase.BoolOption("strict-flavor", 0, false, "Skip instances whose actual flavor differs from the configured flavor in ways affecting managed features"))
+	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
+	cmd.AddOption(mybase.BoolOption("brief", 'q', false, "<overridden by diff command>").Hidden())
+	cmd.AddOption(mybase.StringOption("alter-wrapper", 'x', "", "External bin to shell out to for ALTER TABLE; see manual for template vars"))
+	cmd.AddOption(mybase.StringOption("alter-wrapper-min-size", 0, "0", "Ignore --alter-wrapper for tables smaller than this size in bytes"))
+	cmd.AddOption(mybase.StringOption("alter-lock", 0, "", `Apply a LOCK clause to all ALTER TABLEs (valid values: "NONE", "SHARED", "EXCLUSIVE")`))
+	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "INPLACE", "COPY", "INSTANT")`))
+	cmd.AddOption(mybase.StringOption("table-encryption", 0, "MANAGE", `How to handle table ENCRYPTION or MariaDB ENCRYPTED options (valid values: "MANAGE", "IGNORE")`))
+	cmd.AddOption(mybase.StringOption("page-compression", 0, "MANAGE", `How to handle MariaDB PAGE_COMPRESSED and PAGE_COMPRESSION_LEVEL options (valid values: "MANAGE", "IGNORE")`))
+	cmd.AddOption(mybase.StringOption("default-encryption", 0, "", `Schema-level default encryption on MySQL 8.0.16+ (valid values: "Y", "N")`))
+	cmd.AddOption(mybase.StringOption("ddl-wrapper", 'X', "", "Like --alter-wrapper, but applies to all DDL types (CREATE, DROP, ALTER)"))
+	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
+	cmd.AddOption(mybase.StringOption("push-session-vars", 0, "", "Comma-separated session variables to set only on connections used for running DDL"))
+	cmd.AddOption(mybase.BoolOption("check-target-state", 0, true, "Abort operations on an instance if it becomes read-only or fails over mid-push"))
+	cmd.AddOption(mybase.StringOption("rename-table", 0, "", "Comma-separated old_name:new_name pairs of tables to rename rather than drop and recreate"))
+	cmd.AddOption(mybase.StringOption("history-schema", 0, "", "Record each push in a skeema_history table in this schema on the target instance"))
+	cmd.AddOption(mybase.StringOption("snapshot", 0, "", "Use definitions from this named snapshot instead of *.sql files"))
+	cmd.AddOption(mybase.StringOption("snapshot-file", 0, "skeema-snapshots.json", "Path to file storing snapshots recorded by `skeema snapshot`"))
+}
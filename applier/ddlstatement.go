@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
@@ -22,6 +23,11 @@ type DDLStatement struct {
 	instance      *tengo.Instance
 	schemaName    string
 	connectParams string
+
+	// Source of the statement, used for deriving a rollback statement
+	diff   tengo.ObjectDiff
+	mods   tengo.StatementModifiers
+	rename *TableRename
 }
 
 // NewDDLStatement creates and returns a DDLStatement. If the statement ends up
@@ -33,6 +39,7 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 	ddl = &DDLStatement{
 		instance:   target.Instance,
 		schemaName: target.SchemaFromDir.Name,
+		diff:       diff,
 	}
 
 	var tableSize int64
@@ -91,10 +98,23 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		}
 	}
 
-	// Get the raw DDL statement as a string, handling errors and noops correctly
-	if ddl.stmt, err = diff.Statement(mods); tengo.IsForbiddenDiff(err) {
+	// Get the raw DDL statement as a string, handling errors and noops correctly.
+	// ALTER TABLEs may need to account for table attributes not handled by tengo.
+	// Table options ignored via table-encryption or page-compression are omitted
+	// from both CREATE TABLE and ALTER TABLE.
+	ddl.mods = mods
+	policy := tableOptionPolicyForDir(target.Dir)
+	if td, ok := diff.(*tengo.TableDiff); ok && td.DiffType() == tengo.DiffTypeAlter {
+		ddl.stmt, err = alterStatement(td, mods, policy)
+	} else {
+		ddl.stmt, err = diff.Statement(mods)
+		if otype == tengo.ObjectTypeTable && diff.DiffType() == tengo.DiffTypeCreate {
+			ddl.stmt = policy.createStatement(ddl.stmt, mods.Flavor)
+		}
+	}
+	if tengo.IsForbiddenDiff(err) {
 		errorText := fmt.Sprintf("Destructive statement /* %s */ is considered unsafe. Use --allow-unsafe or --safe-below-size to permit this operation; see --help for more information.", ddl.stmt)
-		return nil, errors.New(errorText)
+		return nil, unsafeDiffError(errorText)
 	} else if err != nil {
 		// Leave the error untouched/unwrapped to allow caller to handle appropriately
 		return nil, err
@@ -103,19 +123,29 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		return nil, nil
 	}
 
+	// Apply any session variables from push-session-vars. These only affect
+	// DDL run directly by Skeema, not DDL run via a wrapper.
+	params, err := target.sessionVars()
+	if err != nil {
+		return nil, err
+	}
+
 	// If adding foreign key constraints, use foreign_key_checks=1 if requested
 	if wrapper == "" && otype == tengo.ObjectTypeTable && diff.DiffType() == tengo.DiffTypeAlter &&
 		strings.Contains(ddl.stmt, "ADD CONSTRAINT") &&
 		strings.Contains(ddl.stmt, "FOREIGN KEY") &&
 		target.Dir.Config.GetBool("foreign-key-checks") {
-		ddl.connectParams = "foreign_key_checks=1"
+		params.Set("foreign_key_checks", "1")
 	}
 
 	// If creating a routine, use the server's global sql_mode instead of Skeema's
 	// normal built-in override
 	if wrapper == "" && (otype == tengo.ObjectTypeProc || otype == tengo.ObjectTypeFunc) &&
 		diff.DiffType() == tengo.DiffTypeCreate {
-		ddl.connectParams = "sql_mode=@@GLOBAL.sql_mode"
+		params.Set("sql_mode", "@@GLOBAL.sql_mode")
+	}
+	if wrapper == "" {
+		ddl.connectParams = params.Encode()
 	}
 
 	// Apply wrapper if relevant
@@ -134,8 +164,8 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 			"PORT":        port,
 			"SOCKET":      socket,
 			"SCHEMA":      ddl.schemaName,
-			"USER":        target.Dir.Config.Get("user"),
-			"PASSWORD":    target.Dir.Config.Get("password"),
+			"USER":        ddl.instance.User,
+			"PASSWORD":    ddl.instance.Password,
 			"ENVIRONMENT": target.Dir.Config.Get("environment"),
 			"DDL":         ddl.stmt,
 			"CLAUSES":     "", // filled in below only for tables
@@ -202,9 +232,29 @@ func (ddl *DDLStatement) String() string {
 	return fs.AddDelimiter(ddl.stmt)
 }
 
+// objectName returns a description of the object affected by ddl, such as
+// "table `foo`", for use in structured log fields.
+func (ddl *DDLStatement) objectName() string {
+	if ddl.rename != nil {
+		return tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: ddl.rename.To}.String()
+	} else if ddl.diff == nil {
+		return ""
+	}
+	return ddl.diff.ObjectKey().String()
+}
+
 // Execute runs the DDL statement, either by running a SQL query against a DB,
-// or shelling out to an external program, as appropriate.
-func (ddl *DDLStatement) Execute() error {
+// or shelling out to an external program, as appropriate. Its duration and
+// outcome are recorded in util.Metrics.
+func (ddl *DDLStatement) Execute() (err error) {
+	defer func(start time.Time) {
+		util.Metrics.TimeSince("statement_duration", start)
+		if err == nil {
+			util.Metrics.Count("statements_executed", 1)
+		} else {
+			util.Metrics.Count("statements_failed", 1)
+		}
+	}(time.Now())
 	if ddl.IsShellOut() {
 		return ddl.shellOut.Run()
 	}
@@ -226,3 +276,12 @@ func (ddl *DDLStatement) getTableSize(target *Target, table *tengo.Table) (int64
 	}
 	return target.Instance.TableSize(target.SchemaFromInstance.Name, table.Name)
 }
+
+// unsafeDiffError is returned by NewDDLStatement when a difference requires a
+// destructive statement, which the target's configuration does not permit.
+type unsafeDiffError string
+
+// Error satisfies the builtin error interface.
+func (ude unsafeDiffError) Error() string {
+	return string(ude)
+}
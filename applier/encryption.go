@@ -0,0 +1,87 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// hasEncryptionChange returns true if the ENCRYPTION or ENCRYPTION_KEY_ID
+// table options differ between the two sides of td.
+func hasEncryptionChange(td *tengo.TableDiff) bool {
+	fromEncryption, fromKeyID := util.TableEncryption(td.From)
+	toEncryption, toKeyID := util.TableEncryption(td.To)
+	return fromEncryption != toEncryption || fromKeyID != toKeyID
+}
+
+// encryptionClauses appends table options to clauses, reflecting td's changes
+// to the ENCRYPTION or ENCRYPTION_KEY_ID table options. Removing the
+// ENCRYPTION option entirely is treated as ENCRYPTION='N'.
+func encryptionClauses(td *tengo.TableDiff, clauses []string) []string {
+	fromEncryption, fromKeyID := util.TableEncryption(td.From)
+	toEncryption, toKeyID := util.TableEncryption(td.To)
+	if fromEncryption != toEncryption {
+		if toEncryption == "" {
+			toEncryption = "N"
+		}
+		clauses = append(clauses, fmt.Sprintf("ENCRYPTION='%s'", toEncryption))
+	}
+	if toKeyID != "" && fromKeyID != toKeyID {
+		clauses = append(clauses, "ENCRYPTION_KEY_ID="+toKeyID)
+	}
+	return clauses
+}
+
+// planSchemaEncryption adjusts plan.Statements so that the schema's default
+// encryption matches encryption, which should be "Y" or "N". If the schema is
+// being created, its CREATE DATABASE is given a DEFAULT ENCRYPTION clause.
+// Otherwise, the schema's current default encryption is queried, and an ALTER
+// DATABASE is run before any other DDL if it differs. Schema default
+// encryption requires MySQL 8.0.16+, so on other flavors a warning is logged
+// and plan is left as-is.
+func planSchemaEncryption(plan *Plan, encryption string) error {
+	t := plan.Target
+	if !t.Instance.Flavor().MySQLishMinVersion(8, 0) {
+		log.Warnf("Ignoring default-encryption for %s: schema default encryption requires MySQL 8.0.16+, but %s is running %s", t.SchemaFromDir.Name, t.Instance, t.Instance.Flavor())
+		return nil
+	}
+	clause := fmt.Sprintf("DEFAULT ENCRYPTION='%s'", encryption)
+	if t.SchemaFromInstance == nil {
+		for _, ddl := range plan.Statements {
+			if dd, ok := ddl.diff.(*tengo.DatabaseDiff); ok && dd.DiffType() == tengo.DiffTypeCreate {
+				ddl.stmt += " " + clause
+			}
+		}
+		return nil
+	}
+
+	db, err := t.Instance.Connect("", "")
+	if err != nil {
+		return err
+	}
+	var current string
+	query := "SELECT default_encryption FROM information_schema.schemata WHERE schema_name = ?"
+	if err := db.QueryRow(query, t.SchemaFromInstance.Name).Scan(&current); err != nil {
+		return err
+	}
+	if strings.EqualFold(current, encryption) {
+		return nil
+	}
+	params, err := t.sessionVars()
+	if err != nil {
+		return err
+	}
+	ddl := &DDLStatement{
+		stmt:          fmt.Sprintf("ALTER DATABASE %s %s", tengo.EscapeIdentifier(t.SchemaFromInstance.Name), clause),
+		instance:      t.Instance,
+		connectParams: params.Encode(),
+		// The diff is only used to identify the schema; the schema's character
+		// set and collation are unchanged by this statement
+		diff: &tengo.DatabaseDiff{From: t.SchemaFromInstance, To: t.SchemaFromInstance},
+	}
+	plan.Statements = append([]*DDLStatement{ddl}, plan.Statements...)
+	return nil
+}
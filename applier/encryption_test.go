@@ -0,0 +1,80 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestAlterStatementEncryption(t *testing.T) {
+	// makeTable returns a table with the supplied columns and ENCRYPTION value,
+	// optionally with a CHECK constraint
+	makeTable := func(encryption string, check bool, cols ...*tengo.Column) *tengo.Table {
+		table := &tengo.Table{
+			Name:               "secrets",
+			Engine:             "InnoDB",
+			CharSet:            "utf8mb4",
+			Collation:          "utf8mb4_0900_ai_ci",
+			CollationIsDefault: true,
+			Columns:            cols,
+			PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{cols[0]}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+		if encryption != "" {
+			table.CreateOptions = `ENCRYPTION="` + encryption + `"`
+		}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL80)
+		table.CreateStatement = strings.Replace(table.CreateStatement, `ENCRYPTION="`+encryption+`"`, "ENCRYPTION='"+encryption+"'", 1)
+		if check {
+			table.CreateStatement = strings.Replace(table.CreateStatement, "\n) ENGINE", ",\n  CONSTRAINT `chk_id` CHECK ((`id` > 0))\n) ENGINE", 1)
+		}
+		return table
+	}
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int unsigned", Default: tengo.ColumnDefaultNull}
+	valCol := &tengo.Column{Name: "val", TypeInDB: "int", Default: tengo.ColumnDefaultNull}
+	mods := tengo.StatementModifiers{Flavor: tengo.FlavorMySQL80}
+
+	cases := []struct {
+		from, to         *tengo.Table
+		ignoreEncryption bool
+		expected         string
+	}{
+		{
+			from:     makeTable("", false, idCol),
+			to:       makeTable("Y", false, idCol),
+			expected: "ALTER TABLE `secrets` ENCRYPTION='Y'",
+		},
+		{
+			from:     makeTable("Y", false, idCol),
+			to:       makeTable("", false, idCol),
+			expected: "ALTER TABLE `secrets` ENCRYPTION='N'",
+		},
+		// Encryption changes are retained alongside CHECK constraints
+		{
+			from:     makeTable("", true, idCol),
+			to:       makeTable("Y", true, idCol, valCol),
+			expected: "ALTER TABLE `secrets` ADD COLUMN `val` int NOT NULL, ENCRYPTION='Y'",
+		},
+		// Ignoring encryption omits only the encryption change
+		{
+			from:             makeTable("", false, idCol),
+			to:               makeTable("Y", false, idCol),
+			ignoreEncryption: true,
+			expected:         "",
+		},
+		{
+			from:             makeTable("Y", true, idCol),
+			to:               makeTable("N", true, idCol, valCol),
+			ignoreEncryption: true,
+			expected:         "ALTER TABLE `secrets` ADD COLUMN `val` int NOT NULL",
+		},
+	}
+	for n, c := range cases {
+		td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: c.from, To: c.to}
+		if stmt, err := alterStatement(td, mods, tableOptionPolicy{ignoreEncryption: c.ignoreEncryption}); err != nil {
+			t.Errorf("Case %d: unexpected error from alterStatement: %v", n, err)
+		} else if stmt != c.expected {
+			t.Errorf("Case %d: expected statement:\n%s\ninstead found:\n%s", n, c.expected, stmt)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package applier_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+)
+
+// This example computes the DDL needed to make the production environment
+// match the *.sql files in a directory tree, in the same manner as
+// `skeema diff production`, but returning the statements instead of printing
+// them.
+func ExamplePlanTarget() {
+	cmd := mybase.NewCommand("mytool", "", "", nil)
+	util.AddGlobalOptions(cmd)
+	applier.AddCommandOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	cfg, err := mybase.ParseCLI(cmd, []string{"mytool", "--allow-unsafe", "production"})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	util.AddGlobalConfigFiles(cfg)
+
+	dir, err := fs.ParseDir("/path/to/schemas", cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	targets, skipCount := applier.TargetsForDir(dir, 5)
+	if skipCount > 0 {
+		fmt.Fprintf(os.Stderr, "Unable to process %d dirs\n", skipCount)
+	}
+	for _, t := range targets {
+		plan, err := applier.PlanTarget(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", t.Instance, t.SchemaFromDir.Name, err)
+			continue
+		}
+		for _, stmt := range plan.Statements {
+			fmt.Printf("%s %s: %s\n", t.Instance, t.SchemaFromDir.Name, stmt)
+		}
+	}
+}
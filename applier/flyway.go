@@ -0,0 +1,87 @@
+package applier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Special values of the Flyway version setting. Any other value is used as a
+// literal version number.
+const (
+	FlywayVersionTimestamp = "timestamp" // use the time of the run, as yyyyMMddHHmmss
+	FlywayVersionNext      = "next"      // use one greater than the highest existing version
+)
+
+var (
+	flywayVersionRegexp   = regexp.MustCompile(`^[0-9]+(?:[._][0-9]+)*$`)
+	flywayMigrationRegexp = regexp.MustCompile(`^V([0-9]+)(?:[._][0-9]+)*__.*\.sql$`)
+)
+
+// SetFlywayNaming configures how the printer names Flyway migration files,
+// for use with ExportFormatFlyway. version may be FlywayVersionTimestamp,
+// FlywayVersionNext, or a literal version number such as "3" or "1.2". Spaces
+// in description are replaced with underscores, as Flyway expects. An error
+// is returned if either value is unusable in a migration file name.
+func (p *Printer) SetFlywayNaming(version, description string) error {
+	if version != FlywayVersionTimestamp && version != FlywayVersionNext && !flywayVersionRegexp.MatchString(version) {
+		return fmt.Errorf("Flyway version %q is not valid: must be %q, %q, or a number such as \"3\" or \"1.2\"", version, FlywayVersionTimestamp, FlywayVersionNext)
+	}
+	description = strings.Replace(strings.TrimSpace(description), " ", "_", -1)
+	if description == "" || description != exportFileName(description) || strings.Contains(description, "__") {
+		return fmt.Errorf("Flyway description %q is not valid: must be non-empty, and cannot contain double underscores or characters such as slashes and colons", description)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.flywayVersion = version
+	p.flywayDescription = description
+	return nil
+}
+
+// flywayFilePath returns the path of the migration file to use within the
+// Flyway export dir schemaDir, resolving the configured version if necessary.
+// The caller must hold the printer's lock.
+func (p *Printer) flywayFilePath(schemaDir string) (string, error) {
+	if path, ok := p.flywayPaths[schemaDir]; ok {
+		return path, nil
+	}
+	version := p.flywayVersion
+	switch version {
+	case "", FlywayVersionTimestamp:
+		version = p.runID
+	case FlywayVersionNext:
+		var err error
+		if version, err = nextFlywayVersion(schemaDir); err != nil {
+			return "", err
+		}
+	}
+	description := p.flywayDescription
+	if description == "" {
+		description = "skeema_diff"
+	}
+	path := filepath.Join(schemaDir, "V"+version+"__"+description+".sql")
+	p.flywayPaths[schemaDir] = path
+	return path, nil
+}
+
+// nextFlywayVersion returns one greater than the highest major version number
+// of any versioned migration file in dirPath, or "1" if there are none.
+func nextFlywayVersion(dirPath string) (string, error) {
+	fileInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var highest uint64
+	for _, fi := range fileInfos {
+		if matches := flywayMigrationRegexp.FindStringSubmatch(fi.Name()); matches != nil && !fi.IsDir() {
+			if major, err := strconv.ParseUint(matches[1], 10, 64); err == nil && major > highest {
+				highest = major
+			}
+		}
+	}
+	return strconv.FormatUint(highest+1, 10), nil
+}
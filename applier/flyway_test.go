@@ -0,0 +1,80 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestPrinterSetFlywayNaming(t *testing.T) {
+	p := NewPrinter(true)
+	for _, version := range []string{"timestamp", "next", "3", "1.2", "2_0_1"} {
+		if err := p.SetFlywayNaming(version, "add widgets"); err != nil {
+			t.Errorf("Unexpected error from version %q: %s", version, err)
+		}
+	}
+	if p.flywayDescription != "add_widgets" {
+		t.Errorf("Expected spaces in description to be replaced, instead found %q", p.flywayDescription)
+	}
+	for _, version := range []string{"", "v3", "1.", "latest", "1..2"} {
+		if err := p.SetFlywayNaming(version, "ok"); err == nil {
+			t.Errorf("Expected error from version %q, but err was nil", version)
+		}
+	}
+	for _, description := range []string{"", " ", "a__b", "a/b", "a:b"} {
+		if err := p.SetFlywayNaming("next", description); err == nil {
+			t.Errorf("Expected error from description %q, but err was nil", description)
+		}
+	}
+}
+
+func TestPrinterExportFlyway(t *testing.T) {
+	inst, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	const dirPath = "export-flyway-test"
+	defer os.RemoveAll(dirPath)
+	schemaDir := ExportPath(dirPath, inst, "shard1", ExportFormatFlyway)
+	if expected := filepath.Join(dirPath, "1.2.3.4_3306", "shard1"); schemaDir != expected {
+		t.Errorf("Expected ExportPath to return %q, instead found %q", expected, schemaDir)
+	}
+
+	ddls := []*DDLStatement{
+		{stmt: "ALTER TABLE foo ADD COLUMN bar int", instance: inst, schemaName: "shard1"},
+		{stmt: "CREATE PROCEDURE p1() BEGIN SELECT 1; SELECT 2; END", instance: inst, schemaName: "shard1"},
+	}
+	const expectContents = "ALTER TABLE foo ADD COLUMN bar int;\nDELIMITER //\nCREATE PROCEDURE p1() BEGIN SELECT 1; SELECT 2; END//\nDELIMITER ;\n"
+	export := func(version string) {
+		t.Helper()
+		p := NewPrinter(true)
+		p.runID = "20200102030405"
+		if err := p.SetFlywayNaming(version, "add bar"); err != nil {
+			t.Fatalf("Unexpected error from SetFlywayNaming: %s", err)
+		}
+		p.SetExportDir(dirPath, ExportFormatFlyway)
+		for _, ddl := range ddls {
+			if err := p.exportDDL(ddl); err != nil {
+				t.Fatalf("Unexpected error from exportDDL: %s", err)
+			}
+		}
+	}
+	export("timestamp")
+	export("next")
+	export("7.1")
+	export("next")
+	for _, name := range []string{"V20200102030405__add_bar.sql", "V20200102030406__add_bar.sql", "V7.1__add_bar.sql", "V20200102030407__add_bar.sql"} {
+		path := filepath.Join(schemaDir, name)
+		if contents, err := ioutil.ReadFile(path); err != nil {
+			t.Errorf("Unable to read %s: %s", path, err)
+		} else if string(contents) != expectContents {
+			t.Errorf("Unexpected contents of %s: %q", path, contents)
+		}
+	}
+	if version, err := nextFlywayVersion(schemaDir); err != nil || version != "20200102030408" {
+		t.Errorf("Unexpected return from nextFlywayVersion: %q, %v", version, err)
+	}
+	if version, err := nextFlywayVersion(filepath.Join(dirPath, "does-not-exist")); err != nil || version != "1" {
+		t.Errorf("Unexpected return from nextFlywayVersion on nonexistent dir: %q, %v", version, err)
+	}
+}
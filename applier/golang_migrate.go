@@ -0,0 +1,150 @@
+package applier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Special values of the golang-migrate version setting, which behave the same
+// as their Flyway equivalents. Any other value is used as a literal version
+// number.
+const (
+	GolangMigrateVersionTimestamp = FlywayVersionTimestamp
+	GolangMigrateVersionNext      = FlywayVersionNext
+)
+
+var (
+	golangMigrateVersionRegexp = regexp.MustCompile(`^[0-9]+$`)
+	golangMigrateRegexp        = regexp.MustCompile(`^([0-9]+)_.*\.(?:up|down)\.sql$`)
+)
+
+// golangMigrateFile tracks the up and down migration files written for one
+// schema with ExportFormatGolangMigrate.
+type golangMigrateFile struct {
+	upPath    string
+	downPath  string
+	rollbacks []string // reverse statements so far, in original order
+}
+
+// SetGolangMigrateNaming configures how the printer names golang-migrate
+// migration files, for use with ExportFormatGolangMigrate. version may be
+// GolangMigrateVersionTimestamp, GolangMigrateVersionNext, or a literal integer
+// version such as "3" or "0042". Spaces in description are replaced with
+// underscores. An error is returned if either value is unusable in a migration
+// file name.
+func (p *Printer) SetGolangMigrateNaming(version, description string) error {
+	if version != GolangMigrateVersionTimestamp && version != GolangMigrateVersionNext && !golangMigrateVersionRegexp.MatchString(version) {
+		return fmt.Errorf("golang-migrate version %q is not valid: must be %q, %q, or an integer such as \"3\" or \"0042\"", version, GolangMigrateVersionTimestamp, GolangMigrateVersionNext)
+	}
+	description = strings.Replace(strings.TrimSpace(description), " ", "_", -1)
+	if description == "" || description != exportFileName(description) {
+		return fmt.Errorf("golang-migrate description %q is not valid: must be non-empty, and cannot contain characters such as slashes and colons", description)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.golangMigrateVersion = version
+	p.golangMigrateDescription = description
+	return nil
+}
+
+// exportGolangMigrate appends ddl to the up migration file in schemaDir, and
+// rewrites the down migration file to contain the reverse of every statement
+// so far, in reverse order. Both files are created upon first use. The caller
+// must hold the printer's lock.
+func (p *Printer) exportGolangMigrate(schemaDir string, ddl *DDLStatement) error {
+	mf := p.golangMigrateFiles[schemaDir]
+	if mf == nil {
+		if err := os.MkdirAll(schemaDir, 0777); err != nil {
+			return err
+		}
+		var err error
+		if mf, err = p.newGolangMigrateFile(schemaDir); err != nil {
+			return err
+		}
+		p.golangMigrateFiles[schemaDir] = mf
+	}
+
+	// golang-migrate runs each file as a single multi-statement query, so
+	// DELIMITER commands must not be used
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !p.exportedPaths[mf.upPath] {
+		flags |= os.O_TRUNC
+		p.exportedPaths[mf.upPath] = true
+	}
+	f, err := os.OpenFile(mf.upPath, flags, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(ddl.stmt + ";\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	rollback := ddl.Rollback()
+	if rollback == "" {
+		rollback = fmt.Sprintf("-- Unable to derive reverse of: %s", strings.Replace(ddl.stmt, "\n", "\n-- ", -1))
+	} else {
+		rollback += ";"
+	}
+	mf.rollbacks = append(mf.rollbacks, rollback)
+	var down strings.Builder
+	for n := len(mf.rollbacks) - 1; n >= 0; n-- {
+		down.WriteString(mf.rollbacks[n])
+		down.WriteString("\n")
+	}
+	return ioutil.WriteFile(mf.downPath, []byte(down.String()), 0666)
+}
+
+// newGolangMigrateFile returns the up and down migration files to use within
+// schemaDir, resolving the configured version if necessary.
+func (p *Printer) newGolangMigrateFile(schemaDir string) (*golangMigrateFile, error) {
+	version := p.golangMigrateVersion
+	switch version {
+	case "", GolangMigrateVersionTimestamp:
+		version = p.runID
+	case GolangMigrateVersionNext:
+		var err error
+		if version, err = nextGolangMigrateVersion(schemaDir); err != nil {
+			return nil, err
+		}
+	}
+	description := p.golangMigrateDescription
+	if description == "" {
+		description = "skeema_diff"
+	}
+	base := filepath.Join(schemaDir, version+"_"+description)
+	return &golangMigrateFile{upPath: base + ".up.sql", downPath: base + ".down.sql"}, nil
+}
+
+// nextGolangMigrateVersion returns one greater than the highest version of any
+// migration file in dirPath, or "1" if there are none. Zero-padding of the
+// highest existing version is preserved.
+func nextGolangMigrateVersion(dirPath string) (string, error) {
+	fileInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var highest uint64
+	var width int
+	for _, fi := range fileInfos {
+		if matches := golangMigrateRegexp.FindStringSubmatch(fi.Name()); matches != nil && !fi.IsDir() {
+			if version, err := strconv.ParseUint(matches[1], 10, 64); err == nil && version >= highest {
+				highest = version
+				width = len(matches[1])
+			}
+		}
+	}
+	next := strconv.FormatUint(highest+1, 10)
+	if len(next) < width {
+		next = strings.Repeat("0", width-len(next)) + next
+	}
+	return next, nil
+}
@@ -0,0 +1,79 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestPrinterSetGolangMigrateNaming(t *testing.T) {
+	p := NewPrinter(true)
+	for _, version := range []string{"timestamp", "next", "3", "0042"} {
+		if err := p.SetGolangMigrateNaming(version, "add widgets"); err != nil {
+			t.Errorf("Unexpected error from version %q: %s", version, err)
+		}
+	}
+	if p.golangMigrateDescription != "add_widgets" {
+		t.Errorf("Expected spaces in description to be replaced, instead found %q", p.golangMigrateDescription)
+	}
+	for _, version := range []string{"", "v3", "1.2", "2_0", "latest"} {
+		if err := p.SetGolangMigrateNaming(version, "ok"); err == nil {
+			t.Errorf("Expected error from version %q, but err was nil", version)
+		}
+	}
+	for _, description := range []string{"", " ", "a/b", "a:b"} {
+		if err := p.SetGolangMigrateNaming("next", description); err == nil {
+			t.Errorf("Expected error from description %q, but err was nil", description)
+		}
+	}
+}
+
+func TestPrinterExportGolangMigrate(t *testing.T) {
+	inst, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	const dirPath = "export-golang-migrate-test"
+	defer os.RemoveAll(dirPath)
+	schemaDir := ExportPath(dirPath, inst, "shard1", ExportFormatGolangMigrate)
+	if err := os.MkdirAll(schemaDir, 0777); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(schemaDir, "000041_init.up.sql"), []byte("CREATE TABLE foo (id int);\n"), 0666); err != nil {
+		t.Fatalf("Unable to write file: %s", err)
+	}
+
+	p := NewPrinter(true)
+	if err := p.SetGolangMigrateNaming("next", "add bar"); err != nil {
+		t.Fatalf("Unexpected error from SetGolangMigrateNaming: %s", err)
+	}
+	p.SetExportDir(dirPath, ExportFormatGolangMigrate)
+	ddls := []*DDLStatement{
+		{stmt: "RENAME TABLE `a` TO `b`", instance: inst, schemaName: "shard1", rename: &TableRename{From: "a", To: "b"}},
+		{stmt: "CREATE PROCEDURE p1() BEGIN SELECT 1; SELECT 2; END", instance: inst, schemaName: "shard1"},
+		{stmt: "RENAME TABLE `b` TO `c`", instance: inst, schemaName: "shard1", rename: &TableRename{From: "b", To: "c"}},
+	}
+	for _, ddl := range ddls {
+		if err := p.exportDDL(ddl); err != nil {
+			t.Fatalf("Unexpected error from exportDDL: %s", err)
+		}
+	}
+	expected := map[string]string{
+		"000042_add_bar.up.sql":   "RENAME TABLE `a` TO `b`;\nCREATE PROCEDURE p1() BEGIN SELECT 1; SELECT 2; END;\nRENAME TABLE `b` TO `c`;\n",
+		"000042_add_bar.down.sql": "RENAME TABLE `c` TO `b`;\n-- Unable to derive reverse of: CREATE PROCEDURE p1() BEGIN SELECT 1; SELECT 2; END\nRENAME TABLE `b` TO `a`;\n",
+	}
+	for name, expectContents := range expected {
+		path := filepath.Join(schemaDir, name)
+		if contents, err := ioutil.ReadFile(path); err != nil {
+			t.Errorf("Unable to read %s: %s", path, err)
+		} else if string(contents) != expectContents {
+			t.Errorf("Unexpected contents of %s: %q", path, contents)
+		}
+	}
+	if version, err := nextGolangMigrateVersion(schemaDir); err != nil || version != "000043" {
+		t.Errorf("Unexpected return from nextGolangMigrateVersion: %q, %v", version, err)
+	}
+	if version, err := nextGolangMigrateVersion(filepath.Join(dirPath, "does-not-exist")); err != nil || version != "1" {
+		t.Errorf("Unexpected return from nextGolangMigrateVersion on nonexistent dir: %q, %v", version, err)
+	}
+}
@@ -0,0 +1,286 @@
+package applier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// HistoryTableName is the name of the table used for recording push history,
+// within the schema specified by the history-schema option.
+const HistoryTableName = "skeema_history"
+
+// Outcomes of a push, as recorded in HistoryEntry.Outcome.
+const (
+	HistoryOutcomeSuccess = "success"
+	HistoryOutcomeFailed  = "failed"  // a statement returned an error
+	HistoryOutcomeAborted = "aborted" // check-target-state detected a problem before all statements ran
+)
+
+// HistoryStatement records the execution of one DDL statement in a push.
+type HistoryStatement struct {
+	Statement  string `json:"statement"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HistoryEntry records a push to one schema on one instance.
+type HistoryEntry struct {
+	ID          int64              `json:"id"`
+	StartedAt   time.Time          `json:"started_at"`
+	FinishedAt  time.Time          `json:"finished_at"`
+	SchemaName  string             `json:"schema_name"`
+	Environment string             `json:"environment"`
+	OSUser      string             `json:"os_user"`
+	DBUser      string             `json:"db_user"`
+	ClientHost  string             `json:"client_host"`
+	Source      string             `json:"source"`
+	GitSHA      string             `json:"git_sha,omitempty"`
+	Outcome     string             `json:"outcome"`
+	Statements  []HistoryStatement `json:"statements"`
+}
+
+// Duration returns the total elapsed time of the push.
+func (entry *HistoryEntry) Duration() time.Duration {
+	return entry.FinishedAt.Sub(entry.StartedAt)
+}
+
+// historyRecorder tracks the statements executed for a single Target, so that
+// they can be written to the history table once the push completes.
+type historyRecorder struct {
+	schema string // schema containing the history table
+	target *Target
+	entry  *HistoryEntry
+}
+
+// newHistoryRecorder returns a recorder for t, or nil if t's configuration
+// does not request push history.
+func newHistoryRecorder(t *Target) *historyRecorder {
+	schema := t.Dir.Config.Get("history-schema")
+	if schema == "" {
+		return nil
+	}
+	entry := &HistoryEntry{
+		StartedAt:   time.Now().UTC(),
+		SchemaName:  t.SchemaFromDir.Name,
+		Environment: t.Dir.Config.Get("environment"),
+		DBUser:      t.Instance.User,
+		Source:      t.Dir.Path,
+		Outcome:     HistoryOutcomeSuccess,
+	}
+	if u, err := user.Current(); err == nil {
+		entry.OSUser = u.Username
+	}
+	entry.ClientHost, _ = os.Hostname()
+	if t.Source != "" {
+		entry.Source = t.Source
+	} else {
+		entry.GitSHA = gitSHA(t.Dir.Path)
+	}
+	return &historyRecorder{
+		schema: schema,
+		target: t,
+		entry:  entry,
+	}
+}
+
+var gitSHACache struct {
+	sync.Once
+	sha string
+}
+
+// gitSHA returns the commit SHA of the git checkout containing dirPath, or an
+// empty string if it is not in a git checkout. Since all dirs in a run are
+// normally from the same checkout, git is only run once per process, using the
+// first dirPath supplied.
+func gitSHA(dirPath string) string {
+	gitSHACache.Do(func() {
+		s := &util.ShellOut{Command: "git rev-parse HEAD 2>/dev/null", Dir: dirPath}
+		if sha, err := s.RunCapture(); err == nil {
+			gitSHACache.sha = strings.TrimSpace(sha)
+		}
+	})
+	return gitSHACache.sha
+}
+
+// executeDDL runs ddl, recording its duration and any error. This method is
+// safe to call on a nil recorder, in which case it just runs ddl.
+func (hr *historyRecorder) executeDDL(ddl *DDLStatement) error {
+	if hr == nil {
+		return ddl.Execute()
+	}
+	start := time.Now()
+	err := ddl.Execute()
+	stmt := HistoryStatement{
+		Statement:  strings.TrimSpace(ddl.String()),
+		DurationMs: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+	}
+	if err != nil {
+		stmt.Error = err.Error()
+		hr.entry.Outcome = HistoryOutcomeFailed
+	}
+	hr.entry.Statements = append(hr.entry.Statements, stmt)
+	return err
+}
+
+// abort marks the push as aborted prior to completion. This method is safe to
+// call on a nil recorder.
+func (hr *historyRecorder) abort() {
+	if hr != nil {
+		hr.entry.Outcome = HistoryOutcomeAborted
+	}
+}
+
+// finish writes the history entry to the target instance, if any statements
+// were attempted. Errors are logged, but do not otherwise affect the push.
+// This method is safe to call on a nil recorder.
+func (hr *historyRecorder) finish() {
+	if hr == nil || (len(hr.entry.Statements) == 0 && hr.entry.Outcome != HistoryOutcomeAborted) {
+		return
+	}
+	hr.entry.FinishedAt = time.Now().UTC()
+	if err := WriteHistory(hr.target.Instance, hr.schema, hr.entry); err != nil {
+		log.Warnf("Unable to record push history in %s.%s on %s: %s", hr.schema, HistoryTableName, hr.target.Instance, err)
+	}
+}
+
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// WriteHistory inserts entry into the history table in the supplied schema on
+// inst, creating the schema and table if they do not already exist.
+func WriteHistory(inst *tengo.Instance, schema string, entry *HistoryEntry) error {
+	db, err := inst.Connect("", "")
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS " + tengo.EscapeIdentifier(schema)); err != nil {
+		return err
+	}
+	create := `CREATE TABLE IF NOT EXISTS %s.%s (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  started_at datetime NOT NULL,
+  finished_at datetime NOT NULL,
+  schema_name varchar(64) NOT NULL,
+  environment varchar(255) NOT NULL,
+  os_user varchar(255) NOT NULL,
+  db_user varchar(255) NOT NULL,
+  client_host varchar(255) NOT NULL,
+  source varchar(1024) NOT NULL,
+  git_sha varchar(64) NOT NULL,
+  outcome varchar(20) NOT NULL,
+  statement_count int unsigned NOT NULL,
+  duration_ms bigint unsigned NOT NULL,
+  statements mediumtext NOT NULL,
+  PRIMARY KEY (id),
+  KEY started_at (started_at),
+  KEY schema_name (schema_name, started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+	if _, err := db.Exec(fmt.Sprintf(create, tengo.EscapeIdentifier(schema), tengo.EscapeIdentifier(HistoryTableName))); err != nil {
+		return err
+	}
+	statements, err := json.Marshal(entry.Statements)
+	if err != nil {
+		return err
+	}
+	insert := `INSERT INTO %s.%s
+  (started_at, finished_at, schema_name, environment, os_user, db_user, client_host, source, git_sha, outcome, statement_count, duration_ms, statements)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = db.Exec(fmt.Sprintf(insert, tengo.EscapeIdentifier(schema), tengo.EscapeIdentifier(HistoryTableName)),
+		entry.StartedAt.Format(historyTimeFormat),
+		entry.FinishedAt.Format(historyTimeFormat),
+		entry.SchemaName,
+		entry.Environment,
+		entry.OSUser,
+		entry.DBUser,
+		entry.ClientHost,
+		entry.Source,
+		entry.GitSHA,
+		entry.Outcome,
+		len(entry.Statements),
+		entry.Duration().Nanoseconds()/int64(time.Millisecond),
+		string(statements),
+	)
+	return err
+}
+
+// HistoryFilter restricts which entries are returned by ReadHistory.
+type HistoryFilter struct {
+	Since       time.Time // if non-zero, only include pushes started at or after this time
+	SchemaNames []string  // if non-empty, only include pushes to these schemas
+	Limit       int       // if positive, only include this many of the most recent pushes
+}
+
+// ReadHistory returns entries from the history table in the supplied schema on
+// inst, most recent first. If the history table does not exist, no entries
+// and no error are returned.
+func ReadHistory(inst *tengo.Instance, schema string, filter HistoryFilter) ([]*HistoryEntry, error) {
+	if exists, err := inst.HasSchema(schema); err != nil || !exists {
+		return nil, err
+	}
+	db, err := inst.Connect(schema, "")
+	if err != nil {
+		return nil, err
+	}
+	var tableCount int
+	if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?", schema, HistoryTableName).Scan(&tableCount); err != nil || tableCount == 0 {
+		return nil, err
+	}
+
+	query := `SELECT id, CAST(started_at AS char), CAST(finished_at AS char), schema_name, environment,
+  os_user, db_user, client_host, source, git_sha, outcome, statements
+  FROM ` + tengo.EscapeIdentifier(HistoryTableName)
+	var where []string
+	var args []interface{}
+	if !filter.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, filter.Since.UTC().Format(historyTimeFormat))
+	}
+	if len(filter.SchemaNames) > 0 {
+		where = append(where, "schema_name IN (?"+strings.Repeat(", ?", len(filter.SchemaNames)-1)+")")
+		for _, name := range filter.SchemaNames {
+			args = append(args, name)
+		}
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []*HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var startedAt, finishedAt, statements string
+		err := rows.Scan(&entry.ID, &startedAt, &finishedAt, &entry.SchemaName, &entry.Environment,
+			&entry.OSUser, &entry.DBUser, &entry.ClientHost, &entry.Source, &entry.GitSHA, &entry.Outcome, &statements)
+		if err != nil {
+			return nil, err
+		}
+		if entry.StartedAt, err = time.Parse(historyTimeFormat, startedAt); err != nil {
+			return nil, err
+		}
+		if entry.FinishedAt, err = time.Parse(historyTimeFormat, finishedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(statements), &entry.Statements); err != nil {
+			return nil, fmt.Errorf("Unable to parse statements of history entry %d: %s", entry.ID, err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,58 @@
+package applier
+
+import (
+	"testing"
+	"time"
+)
+
+func (s ApplierIntegrationSuite) TestHistory(t *testing.T) {
+	inst := s.d[0].Instance
+
+	// Reading from a nonexistent history schema should not be an error
+	if entries, err := ReadHistory(inst, "_skeema", HistoryFilter{}); err != nil || len(entries) > 0 {
+		t.Fatalf("Expected no entries or error, instead found %v, %v", entries, err)
+	}
+
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	for n, schemaName := range []string{"product", "analytics", "product"} {
+		entry := &HistoryEntry{
+			StartedAt:   start.Add(time.Duration(n) * time.Hour),
+			FinishedAt:  start.Add(time.Duration(n)*time.Hour + 3*time.Second),
+			SchemaName:  schemaName,
+			Environment: "production",
+			OSUser:      "alice",
+			DBUser:      "root",
+			ClientHost:  "laptop",
+			Source:      "/repo/mydb",
+			GitSHA:      "abc123",
+			Outcome:     HistoryOutcomeSuccess,
+			Statements: []HistoryStatement{
+				{Statement: "ALTER TABLE `widgets` ADD COLUMN `name` varchar(30);", DurationMs: 2500},
+				{Statement: "DROP TABLE `gadgets`;", DurationMs: 10, Error: "table locked"},
+			},
+		}
+		if err := WriteHistory(inst, "_skeema", entry); err != nil {
+			t.Fatalf("Unexpected error from WriteHistory: %s", err)
+		}
+	}
+
+	entries, err := ReadHistory(inst, "_skeema", HistoryFilter{})
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadHistory: %s", err)
+	}
+	if len(entries) != 3 || entries[0].ID != 3 || entries[2].ID != 1 {
+		t.Fatalf("Unexpected entries returned from ReadHistory: %+v", entries)
+	}
+	if e := entries[2]; !e.StartedAt.Equal(start) || e.Duration() != 3*time.Second || e.GitSHA != "abc123" || len(e.Statements) != 2 || e.Statements[1].Error != "table locked" {
+		t.Errorf("Unexpected fields in entry: %+v", e)
+	}
+
+	filter := HistoryFilter{Since: start.Add(30 * time.Minute), SchemaNames: []string{"product"}}
+	if entries, err = ReadHistory(inst, "_skeema", filter); err != nil || len(entries) != 1 || entries[0].ID != 3 {
+		t.Errorf("Unexpected result from ReadHistory with %+v: %+v, %v", filter, entries, err)
+	}
+	filter = HistoryFilter{Limit: 2}
+	if entries, err = ReadHistory(inst, "_skeema", filter); err != nil || len(entries) != 2 || entries[1].ID != 2 {
+		t.Errorf("Unexpected result from ReadHistory with %+v: %+v, %v", filter, entries, err)
+	}
+}
@@ -0,0 +1,206 @@
+package applier
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// Rollback returns a statement which reverses the effect of ddl, or an empty
+// string if no such statement can be derived. Rollbacks can be derived for
+// creating or dropping any object, renaming a table, changing a schema's
+// default character set or collation, and most table alterations. They cannot
+// be derived for an ALTER TABLE which was split into multiple statements due
+// to foreign keys, nor for alterations which cannot be expressed as DDL in the
+// reverse direction. Note that rolling back a DROP or destructive ALTER only
+// restores the object's definition, not its data.
+func (ddl *DDLStatement) Rollback() string {
+	if ddl.rename != nil {
+		return fmt.Sprintf("RENAME TABLE %s TO %s", tengo.EscapeIdentifier(ddl.rename.To), tengo.EscapeIdentifier(ddl.rename.From))
+	}
+	mods := ddl.mods
+	mods.AllowUnsafe = true
+	var reverse tengo.ObjectDiff
+	switch diff := ddl.diff.(type) {
+	case *tengo.DatabaseDiff:
+		if diff.DiffType() == tengo.DiffTypeAlter {
+			reverse = &tengo.DatabaseDiff{From: diff.To, To: diff.From}
+		}
+	case *tengo.TableDiff:
+		switch diff.Type {
+		case tengo.DiffTypeCreate:
+			reverse = tengo.NewDropTable(diff.To)
+		case tengo.DiffTypeDrop:
+			reverse = tengo.NewCreateTable(diff.From)
+		case tengo.DiffTypeAlter:
+			// If ddl only contains part of the changes to the table, its reverse
+			// cannot be computed from the full table definitions
+			if stmt, _ := tengo.NewAlterTable(diff.From, diff.To).Statement(ddl.mods); stmt != ddl.stmt {
+				return ""
+			}
+			if td := tengo.NewAlterTable(diff.To, diff.From); td != nil {
+				reverse = td
+			}
+		}
+	case *tengo.RoutineDiff:
+		if diff.DiffType() == tengo.DiffTypeCreate || diff.DiffType() == tengo.DiffTypeDrop {
+			reverse = &tengo.RoutineDiff{From: diff.To, To: diff.From}
+		}
+	}
+	if reverse == nil {
+		return ""
+	}
+	stmt, err := reverse.Statement(mods)
+	if err != nil {
+		return ""
+	}
+	return stmt
+}
+
+// description returns a short human-readable description of ddl, for use in
+// changeset comments.
+func (ddl *DDLStatement) description() string {
+	if ddl.rename != nil {
+		return fmt.Sprintf("RENAME table %s TO %s", tengo.EscapeIdentifier(ddl.rename.From), tengo.EscapeIdentifier(ddl.rename.To))
+	} else if ddl.diff == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", ddl.diff.DiffType(), ddl.diff.ObjectKey())
+}
+
+// liquibaseChangelog accumulates DDL for a single instance and schema, and
+// writes it as a Liquibase changelog with one changeset per statement.
+type liquibaseChangelog struct {
+	runID      string
+	changeSets []liquibaseChangeSet
+}
+
+type liquibaseChangeSet struct {
+	ID       string
+	Comment  string
+	SQL      string
+	Rollback string
+}
+
+// liquibaseAuthor is used as the author of all generated changesets.
+const liquibaseAuthor = "skeema"
+
+// add appends a changeset for ddl. Statements are always exported in their
+// raw SQL form, even if a wrapper is configured, since Liquibase executes
+// them directly.
+func (cl *liquibaseChangelog) add(ddl *DDLStatement) {
+	comment := ddl.description()
+	rollback := ddl.Rollback()
+	if rollback == "" {
+		comment += "; rollback could not be derived"
+	}
+	cl.changeSets = append(cl.changeSets, liquibaseChangeSet{
+		ID:       fmt.Sprintf("%s-%d", cl.runID, len(cl.changeSets)+1),
+		Comment:  strings.TrimPrefix(comment, "; "),
+		SQL:      ddl.stmt,
+		Rollback: rollback,
+	})
+}
+
+// marshal returns the changelog in the supplied format.
+func (cl *liquibaseChangelog) marshal(format ExportFormat) ([]byte, error) {
+	if format == ExportFormatLiquibaseYAML {
+		return cl.marshalYAML(), nil
+	}
+	return cl.marshalXML()
+}
+
+type liquibaseXMLChangelog struct {
+	XMLName        xml.Name                `xml:"databaseChangeLog"`
+	Namespace      string                  `xml:"xmlns,attr"`
+	XSINamespace   string                  `xml:"xmlns:xsi,attr"`
+	SchemaLocation string                  `xml:"xsi:schemaLocation,attr"`
+	ChangeSets     []liquibaseXMLChangeSet `xml:"changeSet"`
+}
+
+type liquibaseXMLChangeSet struct {
+	ID       string            `xml:"id,attr"`
+	Author   string            `xml:"author,attr"`
+	Comment  string            `xml:"comment,omitempty"`
+	SQL      liquibaseXMLSQL   `xml:"sql"`
+	Rollback *liquibaseXMLRoll `xml:"rollback,omitempty"`
+}
+
+type liquibaseXMLRoll struct {
+	SQL liquibaseXMLSQL `xml:"sql"`
+}
+
+type liquibaseXMLSQL struct {
+	SplitStatements bool   `xml:"splitStatements,attr"`
+	StripComments   bool   `xml:"stripComments,attr"`
+	Text            string `xml:",chardata"`
+}
+
+func (cl *liquibaseChangelog) marshalXML() ([]byte, error) {
+	doc := liquibaseXMLChangelog{
+		Namespace:      "http://www.liquibase.org/xml/ns/dbchangelog",
+		XSINamespace:   "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.liquibase.org/xml/ns/dbchangelog http://www.liquibase.org/xml/ns/dbchangelog/dbchangelog-3.8.xsd",
+	}
+	for _, cs := range cl.changeSets {
+		xcs := liquibaseXMLChangeSet{
+			ID:      cs.ID,
+			Author:  liquibaseAuthor,
+			Comment: cs.Comment,
+			SQL:     liquibaseXMLSQL{Text: cs.SQL},
+		}
+		if cs.Rollback != "" {
+			xcs.Rollback = &liquibaseXMLRoll{SQL: liquibaseXMLSQL{Text: cs.Rollback}}
+		}
+		doc.ChangeSets = append(doc.ChangeSets, xcs)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func (cl *liquibaseChangelog) marshalYAML() []byte {
+	var b bytes.Buffer
+	b.WriteString("databaseChangeLog:\n")
+	writeSQL := func(indent, stmt string) {
+		fmt.Fprintf(&b, "%s- sql:\n", indent)
+		fmt.Fprintf(&b, "%s    splitStatements: false\n", indent)
+		fmt.Fprintf(&b, "%s    stripComments: false\n", indent)
+		fmt.Fprintf(&b, "%s    sql: |-\n", indent)
+		for _, line := range strings.Split(stmt, "\n") {
+			fmt.Fprintf(&b, "%s      %s\n", indent, line)
+		}
+	}
+	for _, cs := range cl.changeSets {
+		b.WriteString("- changeSet:\n")
+		fmt.Fprintf(&b, "    id: %s\n", strconv.Quote(cs.ID))
+		fmt.Fprintf(&b, "    author: %s\n", strconv.Quote(liquibaseAuthor))
+		if cs.Comment != "" {
+			fmt.Fprintf(&b, "    comment: %s\n", strconv.Quote(cs.Comment))
+		}
+		b.WriteString("    changes:\n")
+		writeSQL("    ", cs.SQL)
+		if cs.Rollback != "" {
+			b.WriteString("    rollback:\n")
+			writeSQL("    ", cs.Rollback)
+		}
+	}
+	return b.Bytes()
+}
+
+// write marshals the changelog and writes it to path, replacing any previous
+// contents.
+func (cl *liquibaseChangelog) write(path string, format ExportFormat) error {
+	data, err := cl.marshal(format)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0666)
+}
@@ -0,0 +1,113 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestDDLStatementRollback(t *testing.T) {
+	table := func(name, comment string) *tengo.Table {
+		return &tengo.Table{
+			Name:            name,
+			Engine:          "InnoDB",
+			CharSet:         "latin1",
+			Comment:         comment,
+			CreateStatement: "CREATE TABLE `" + name + "` (`id` int) ENGINE=InnoDB DEFAULT CHARSET=latin1 COMMENT='" + comment + "'",
+		}
+	}
+	proc := &tengo.Routine{
+		Name:            "proc1",
+		Type:            tengo.ObjectTypeProc,
+		Body:            "SELECT 1",
+		CreateStatement: "CREATE PROCEDURE proc1() SELECT 1",
+	}
+	newDDL := func(diff tengo.ObjectDiff) *DDLStatement {
+		mods := tengo.StatementModifiers{AllowUnsafe: true}
+		stmt, err := diff.Statement(mods)
+		if err != nil {
+			t.Fatalf("Unexpected error from Statement: %s", err)
+		}
+		return &DDLStatement{stmt: stmt, diff: diff, mods: mods}
+	}
+	alter := newDDL(tengo.NewAlterTable(table("widgets", ""), table("widgets", "new comment")))
+	partialAlter := newDDL(tengo.NewAlterTable(table("widgets", ""), table("widgets", "new comment")))
+	partialAlter.stmt = "ALTER TABLE `widgets` ADD CONSTRAINT fk FOREIGN KEY (id) REFERENCES other (id)"
+
+	cases := []struct {
+		ddl      *DDLStatement
+		expected string
+	}{
+		{newDDL(tengo.NewCreateTable(table("widgets", ""))), "DROP TABLE `widgets`"},
+		{newDDL(tengo.NewDropTable(table("widgets", "old"))), table("widgets", "old").CreateStatement},
+		{alter, "ALTER TABLE `widgets` COMMENT ''"},
+		{partialAlter, ""},
+		{newDDL(&tengo.RoutineDiff{To: proc}), "DROP PROCEDURE `proc1`"},
+		{newDDL(&tengo.RoutineDiff{From: proc}), proc.CreateStatement},
+		{&DDLStatement{stmt: "RENAME TABLE `a` TO `b`", rename: &TableRename{From: "a", To: "b"}}, "RENAME TABLE `b` TO `a`"},
+		{&DDLStatement{stmt: "SELECT 1"}, ""},
+	}
+	for n, c := range cases {
+		if actual := c.ddl.Rollback(); actual != c.expected {
+			t.Errorf("cases[%d]: Expected rollback of %q to be %q, instead found %q", n, c.ddl.stmt, c.expected, actual)
+		}
+	}
+}
+
+func TestPrinterExportLiquibase(t *testing.T) {
+	inst, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	const dirPath = "export-liquibase-test"
+	defer os.RemoveAll(dirPath)
+
+	ddls := []*DDLStatement{
+		{stmt: "RENAME TABLE `a` TO `b`", instance: inst, schemaName: "shard1", rename: &TableRename{From: "a", To: "b"}},
+		{stmt: "ALTER TABLE `b`\n  ADD COLUMN `c` int", instance: inst, schemaName: "shard1"},
+	}
+	for _, format := range []ExportFormat{ExportFormatLiquibaseXML, ExportFormatLiquibaseYAML} {
+		p := NewPrinter(true)
+		p.runID = "20200102030405"
+		p.SetExportDir(dirPath, format)
+		for _, ddl := range ddls {
+			if err := p.exportDDL(ddl); err != nil {
+				t.Fatalf("Unexpected error from exportDDL: %s", err)
+			}
+		}
+		path := ExportPath(dirPath, inst, "shard1", format)
+		if !strings.HasSuffix(path, format.Extension()) {
+			t.Errorf("Unexpected path for format %s: %s", format, path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Unable to read %s: %s", path, err)
+		}
+		contents := string(data)
+		var expected []string
+		if format == ExportFormatLiquibaseXML {
+			expected = []string{
+				`<changeSet id="20200102030405-1" author="skeema">`,
+				`<comment>RENAME table ` + "`a` TO `b`" + `</comment>`,
+				`<rollback>`,
+				`<sql splitStatements="false" stripComments="false">RENAME TABLE ` + "`b` TO `a`" + `</sql>`,
+				`<changeSet id="20200102030405-2" author="skeema">`,
+				`<comment>rollback could not be derived</comment>`,
+			}
+		} else {
+			expected = []string{
+				"- changeSet:\n    id: \"20200102030405-1\"\n    author: \"skeema\"\n",
+				"    rollback:\n    - sql:\n        splitStatements: false\n        stripComments: false\n        sql: |-\n          RENAME TABLE `b` TO `a`\n",
+				"        sql: |-\n          ALTER TABLE `b`\n            ADD COLUMN `c` int\n",
+			}
+		}
+		for _, substr := range expected {
+			if !strings.Contains(contents, substr) {
+				t.Errorf("Expected %s to contain %q, but it did not. Contents:\n%s", path, substr, contents)
+			}
+		}
+		if count := strings.Count(contents, "RENAME TABLE `b` TO `a`"); count != 1 {
+			t.Errorf("Expected exactly one rollback in %s, instead found %d", path, count)
+		}
+	}
+}
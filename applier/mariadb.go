@@ -0,0 +1,54 @@
+package applier
+
+import (
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// mariaDBTableOptionNames lists MariaDB's page compression and encryption
+// table options, in the order their clauses are generated.
+var mariaDBTableOptionNames = []string{
+	util.MariaDBPageCompressed,
+	util.MariaDBPageCompressionLevel,
+	util.MariaDBEncrypted,
+	util.MariaDBEncryptionKeyID,
+}
+
+// hasMariaDBTableOptions returns true if either side of td has any MariaDB
+// page compression or encryption table options.
+func hasMariaDBTableOptions(td *tengo.TableDiff) bool {
+	return len(util.MariaDBTableOptions(td.From)) > 0 || len(util.MariaDBTableOptions(td.To)) > 0
+}
+
+// mariaDBTableOptionClauses appends table options to clauses, reflecting td's
+// changes to MariaDB's page compression and encryption table options. Options
+// omitted by policy are not included. Removing an option entirely resets it to
+// its default.
+func mariaDBTableOptionClauses(td *tengo.TableDiff, policy tableOptionPolicy, clauses []string) []string {
+	fromOpts, toOpts := util.MariaDBTableOptions(td.From), util.MariaDBTableOptions(td.To)
+	for _, name := range mariaDBTableOptionNames {
+		fromValue, toValue := fromOpts[name], toOpts[name]
+		if fromValue == toValue || policy.ignoresMariaDBTableOption(name) {
+			continue
+		} else if toValue == "" {
+			toValue = "DEFAULT"
+		} else if toValue == "ON" {
+			toValue = "1"
+		} else if toValue == "OFF" {
+			toValue = "0"
+		}
+		clauses = append(clauses, name+"="+toValue)
+	}
+	return clauses
+}
+
+// ignoresMariaDBTableOption returns true if policy omits the named MariaDB
+// table option from generated DDL.
+func (policy tableOptionPolicy) ignoresMariaDBTableOption(name string) bool {
+	switch name {
+	case util.MariaDBPageCompressed, util.MariaDBPageCompressionLevel:
+		return policy.ignoreCompression
+	default:
+		return policy.ignoreEncryption
+	}
+}
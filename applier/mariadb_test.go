@@ -0,0 +1,79 @@
+package applier
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestAlterStatementMariaDBTableOptions(t *testing.T) {
+	// makeTable returns a table with the supplied MariaDB table options, as
+	// they appear in SHOW CREATE TABLE
+	makeTable := func(options string) *tengo.Table {
+		idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+		table := &tengo.Table{
+			Name:               "logs",
+			Engine:             "InnoDB",
+			CharSet:            "latin1",
+			Collation:          "latin1_swedish_ci",
+			CollationIsDefault: true,
+			Columns:            []*tengo.Column{idCol},
+			PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMariaDB103)
+		if options != "" {
+			table.CreateStatement += " " + options
+		}
+		return table
+	}
+	mods := tengo.StatementModifiers{Flavor: tengo.FlavorMariaDB103}
+
+	cases := []struct {
+		from, to string
+		policy   tableOptionPolicy
+		expected string
+	}{
+		{
+			from:     "",
+			to:       "`PAGE_COMPRESSED`='ON' `PAGE_COMPRESSION_LEVEL`=6",
+			expected: "ALTER TABLE `logs` PAGE_COMPRESSED=1, PAGE_COMPRESSION_LEVEL=6",
+		},
+		{
+			from:     "`PAGE_COMPRESSED`=1 `ENCRYPTED`=YES",
+			to:       "`PAGE_COMPRESSED`='ON' `ENCRYPTED`=YES `ENCRYPTION_KEY_ID`=3",
+			expected: "ALTER TABLE `logs` ENCRYPTION_KEY_ID=3",
+		},
+		{
+			from:     "`PAGE_COMPRESSED`='ON' `ENCRYPTED`=YES",
+			to:       "",
+			expected: "ALTER TABLE `logs` PAGE_COMPRESSED=DEFAULT, ENCRYPTED=DEFAULT",
+		},
+		{
+			from:     "`PAGE_COMPRESSED`='ON' `ENCRYPTED`=YES",
+			to:       "`ENCRYPTED`=NO",
+			policy:   tableOptionPolicy{ignoreCompression: true},
+			expected: "ALTER TABLE `logs` ENCRYPTED=NO",
+		},
+		{
+			from:     "`PAGE_COMPRESSED`='ON' `ENCRYPTED`=YES",
+			to:       "`ENCRYPTED`=NO",
+			policy:   tableOptionPolicy{ignoreCompression: true, ignoreEncryption: true},
+			expected: "",
+		},
+	}
+	for n, c := range cases {
+		td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: makeTable(c.from), To: makeTable(c.to)}
+		if stmt, err := alterStatement(td, mods, c.policy); err != nil {
+			t.Errorf("Case %d: unexpected error from alterStatement: %v", n, err)
+		} else if stmt != c.expected {
+			t.Errorf("Case %d: expected statement:\n%s\ninstead found:\n%s", n, c.expected, stmt)
+		}
+	}
+
+	// CREATE TABLE statements omit ignored options
+	create := makeTable("`PAGE_COMPRESSED`='ON' `ENCRYPTED`=YES").CreateStatement
+	expected := makeTable("`ENCRYPTED`=YES").CreateStatement
+	if actual := (tableOptionPolicy{ignoreCompression: true}).createStatement(create, mods.Flavor); actual != expected {
+		t.Errorf("Unexpected result from createStatement:\n%s", actual)
+	}
+}
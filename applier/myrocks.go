@@ -0,0 +1,81 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// checkRocksDBLimitations returns an error if any statement in plan affects a
+// MyRocks table in a way which MyRocks does not support: MyRocks tables cannot
+// have foreign keys; ALGORITHM=INSTANT is not supported; and ALGORITHM=INPLACE
+// is only supported for adding or dropping secondary indexes. Statements run
+// via alter-wrapper or ddl-wrapper are not checked for ALGORITHM clauses, since
+// alter-algorithm is ignored in that situation. A warning is logged for any
+// change to the column family of a primary key, since this rebuilds the table.
+func checkRocksDBLimitations(plan *Plan) error {
+	for _, ddl := range plan.Statements {
+		td, ok := ddl.diff.(*tengo.TableDiff)
+		if !ok || !util.IsRocksDB(td.To) {
+			continue
+		}
+		if len(td.To.ForeignKeys) > 0 {
+			return fmt.Errorf("Unable to %s %s: MyRocks tables do not support foreign keys", strings.ToLower(td.DiffType().String()), td.ObjectKey())
+		}
+		if td.DiffType() != tengo.DiffTypeAlter || !util.IsRocksDB(td.From) {
+			continue
+		}
+		if td.From.PrimaryKey != nil && td.To.PrimaryKey != nil {
+			fromName, fromReverse := util.RocksDBColumnFamily(td.From.PrimaryKey)
+			toName, toReverse := util.RocksDBColumnFamily(td.To.PrimaryKey)
+			if fromName != toName || fromReverse != toReverse {
+				log.Warnf("%s: changing the column family of the primary key will rebuild the table", td.ObjectKey())
+			}
+		}
+		if ddl.IsShellOut() {
+			continue
+		}
+		if strings.Contains(ddl.stmt, "ALGORITHM=INSTANT") {
+			return fmt.Errorf("Unable to alter %s: MyRocks does not support ALGORITHM=INSTANT; remove the alter-algorithm option", td.ObjectKey())
+		}
+		if strings.Contains(ddl.stmt, "ALGORITHM=INPLACE") {
+			for _, clause := range alterClauses(ddl.stmt, td) {
+				if !rocksDBInplaceClause(clause) {
+					return fmt.Errorf("Unable to alter %s: MyRocks only supports ALGORITHM=INPLACE for adding or dropping secondary indexes, but this ALTER includes %s; remove the alter-algorithm option or use alter-wrapper", td.ObjectKey(), clause)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// alterClauses returns the clauses of stmt, which must be an ALTER TABLE
+// generated from td, excluding any ALGORITHM or LOCK clauses.
+func alterClauses(stmt string, td *tengo.TableDiff) (clauses []string) {
+	prefix := td.From.AlterStatement() + " "
+	if !strings.HasPrefix(stmt, prefix) {
+		return nil
+	}
+	for _, clause := range splitTopLevelCommas(stmt[len(prefix):]) {
+		upper := strings.ToUpper(clause)
+		if !strings.HasPrefix(upper, "ALGORITHM=") && !strings.HasPrefix(upper, "LOCK=") {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+// rocksDBInplaceClause returns true if MyRocks can run an ALTER TABLE clause
+// with ALGORITHM=INPLACE.
+func rocksDBInplaceClause(clause string) bool {
+	upper := strings.ToUpper(clause)
+	for _, prefix := range []string{"ADD KEY ", "ADD UNIQUE KEY ", "ADD INDEX ", "ADD UNIQUE INDEX ", "DROP KEY ", "DROP INDEX "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestCheckRocksDBLimitations(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20) unsigned"}
+	from := &tengo.Table{
+		Name:       "events",
+		Engine:     "ROCKSDB",
+		Columns:    []*tengo.Column{idCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+	}
+	to := *from
+	td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: from, To: &to}
+	plan := &Plan{Statements: []*DDLStatement{
+		{stmt: "ALTER TABLE `events` ADD KEY `created_at` (`created_at`), DROP KEY `old`, ALGORITHM=INPLACE, LOCK=NONE", diff: td},
+	}}
+	if err := checkRocksDBLimitations(plan); err != nil {
+		t.Errorf("Unexpected error for inplace index changes: %v", err)
+	}
+
+	plan.Statements[0].stmt = "ALTER TABLE `events` ADD KEY `created_at` (`created_at`), ADD COLUMN `body` text, ALGORITHM=INPLACE"
+	if err := checkRocksDBLimitations(plan); err == nil || !strings.Contains(err.Error(), "ADD COLUMN `body` text") {
+		t.Errorf("Expected error for inplace column addition, instead found %v", err)
+	}
+	plan.Statements[0].stmt = "ALTER TABLE `events` ADD COLUMN `body` text"
+	if err := checkRocksDBLimitations(plan); err != nil {
+		t.Errorf("Unexpected error without ALGORITHM clause: %v", err)
+	}
+	plan.Statements[0].stmt = "ALTER TABLE `events` ADD COLUMN `body` text, ALGORITHM=INSTANT"
+	if err := checkRocksDBLimitations(plan); err == nil || !strings.Contains(err.Error(), "ALGORITHM=INSTANT") {
+		t.Errorf("Expected error for ALGORITHM=INSTANT, instead found %v", err)
+	}
+	plan.Statements[0].shellOut = &util.ShellOut{}
+	if err := checkRocksDBLimitations(plan); err != nil {
+		t.Errorf("Unexpected error for statement run via alter-wrapper: %v", err)
+	}
+
+	// Foreign keys are rejected, both when altering and creating tables
+	to.ForeignKeys = []*tengo.ForeignKey{{Name: "events_user", Columns: []*tengo.Column{idCol}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}}}
+	if err := checkRocksDBLimitations(plan); err == nil || !strings.Contains(err.Error(), "Unable to alter") {
+		t.Errorf("Expected error for foreign key on altered table, instead found %v", err)
+	}
+	plan.Statements[0].diff = &tengo.TableDiff{Type: tengo.DiffTypeCreate, To: &to}
+	if err := checkRocksDBLimitations(plan); err == nil || !strings.Contains(err.Error(), "Unable to create") {
+		t.Errorf("Expected error for foreign key on new table, instead found %v", err)
+	}
+
+	// InnoDB tables are not affected
+	to.Engine = "InnoDB"
+	if err := checkRocksDBLimitations(plan); err != nil {
+		t.Errorf("Unexpected error for InnoDB table: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package applier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+var (
+	alterColumnClauseRegexp   = regexp.MustCompile("^(?:(?:ADD|MODIFY) COLUMN |CHANGE COLUMN `(?:[^`]|``)+` )`((?:[^`]|``)+)` ")
+	alterColumnPositionRegexp = regexp.MustCompile("( FIRST| AFTER `(?:[^`]|``)+`)$")
+)
+
+// hasPerconaAttributes returns true if either side of td has Percona Server
+// column compression attributes.
+func hasPerconaAttributes(td *tengo.TableDiff) bool {
+	return len(util.PerconaColumnAttributes(td.From)) > 0 || len(util.PerconaColumnAttributes(td.To)) > 0
+}
+
+// perconaClauses adjusts clauses, which were generated by package tengo
+// without knowledge of Percona Server's column compression attributes, to
+// reflect td's changes to these. Compression attributes are re-applied to
+// columns being added or modified, and changes in only these attributes result
+// in additional MODIFY COLUMN clauses.
+func perconaClauses(td *tengo.TableDiff, mods tengo.StatementModifiers, clauses []string) []string {
+	fromAttrs, toAttrs := util.PerconaColumnAttributes(td.From), util.PerconaColumnAttributes(td.To)
+
+	modified := make(map[string]bool)
+	for n, clause := range clauses {
+		if matches := alterColumnClauseRegexp.FindStringSubmatch(clause); matches != nil {
+			colName := strings.Replace(matches[1], "``", "`", -1)
+			modified[colName] = true
+			if attr := toAttrs[colName]; attr != "" {
+				clauses[n] = insertColumnAttribute(clause, attr)
+			}
+		}
+	}
+	fromColumns := td.From.ColumnsByName()
+	for _, col := range td.To.Columns {
+		if modified[col.Name] || fromColumns[col.Name] == nil || fromAttrs[col.Name] == toAttrs[col.Name] {
+			continue
+		}
+		attr := toAttrs[col.Name]
+		if attr == "" {
+			attr = "COLUMN_FORMAT DEFAULT"
+		}
+		clauses = append(clauses, fmt.Sprintf("MODIFY COLUMN %s %s", col.Definition(mods.Flavor, td.To), attr))
+	}
+	return clauses
+}
+
+// insertColumnAttribute adds attr to the end of the column definition in an
+// ADD, MODIFY, or CHANGE COLUMN clause, prior to any FIRST or AFTER.
+func insertColumnAttribute(clause, attr string) string {
+	if loc := alterColumnPositionRegexp.FindStringIndex(clause); loc != nil {
+		return clause[:loc[0]] + " " + attr + clause[loc[0]:]
+	}
+	return clause + " " + attr
+}
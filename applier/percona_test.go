@@ -0,0 +1,76 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestPerconaAlterStatement(t *testing.T) {
+	// makeTable returns a table with the supplied columns, where each column
+	// named in compressed has a Percona column compression attribute
+	makeTable := func(encryption string, compressed []string, cols ...*tengo.Column) *tengo.Table {
+		table := &tengo.Table{
+			Name:               "docs",
+			Engine:             "InnoDB",
+			CharSet:            "latin1",
+			Collation:          "latin1_swedish_ci",
+			CollationIsDefault: true,
+			Columns:            cols,
+			PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{cols[0]}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorPercona57)
+		for _, colName := range compressed {
+			def := table.ColumnsByName()[colName].Definition(tengo.FlavorPercona57, table)
+			table.CreateStatement = strings.Replace(table.CreateStatement, def, def+" /*!50633 COLUMN_FORMAT COMPRESSED */", 1)
+		}
+		if encryption != "" {
+			table.CreateStatement += " ENCRYPTION='" + encryption + "'"
+		}
+		return table
+	}
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+	docCol := &tengo.Column{Name: "doc", TypeInDB: "text", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true}
+	bodyCol := &tengo.Column{Name: "body", TypeInDB: "text", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true}
+	mods := tengo.StatementModifiers{Flavor: tengo.FlavorPercona57}
+	docDef := docCol.Definition(tengo.FlavorPercona57, makeTable("", nil, idCol, docCol))
+
+	cases := []struct {
+		from, to *tengo.Table
+		expected string
+	}{
+		// Changing only compression of an existing column
+		{
+			from:     makeTable("", nil, idCol, docCol),
+			to:       makeTable("", []string{"doc"}, idCol, docCol),
+			expected: "ALTER TABLE `docs` MODIFY COLUMN " + docDef + " /*!50633 COLUMN_FORMAT COMPRESSED */",
+		},
+		{
+			from:     makeTable("", []string{"doc"}, idCol, docCol),
+			to:       makeTable("", nil, idCol, docCol),
+			expected: "ALTER TABLE `docs` MODIFY COLUMN " + docDef + " COLUMN_FORMAT DEFAULT",
+		},
+		// Adding a compressed column retains its attribute, and table encryption
+		// changes are included
+		{
+			from:     makeTable("", []string{"doc"}, idCol, docCol),
+			to:       makeTable("Y", []string{"doc", "body"}, idCol, bodyCol, docCol),
+			expected: "ALTER TABLE `docs` ADD COLUMN `body` text /*!50633 COLUMN_FORMAT COMPRESSED */ AFTER `id`, ENCRYPTION='Y'",
+		},
+		// Identical Percona attributes: no statement
+		{
+			from:     makeTable("Y", []string{"doc"}, idCol, docCol),
+			to:       makeTable("Y", []string{"doc"}, idCol, docCol),
+			expected: "",
+		},
+	}
+	for n, c := range cases {
+		td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: c.from, To: c.to}
+		if stmt, err := alterStatement(td, mods, tableOptionPolicy{}); err != nil {
+			t.Errorf("Case %d: unexpected error from alterStatement: %v", n, err)
+		} else if stmt != c.expected {
+			t.Errorf("Case %d: expected statement:\n%s\ninstead found:\n%s", n, c.expected, stmt)
+		}
+	}
+}
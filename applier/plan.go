@@ -0,0 +1,143 @@
+package applier
+
+import (
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// Plan describes the DDL required to make a Target's live schema match its
+// desired state, without printing or executing anything.
+type Plan struct {
+	Target      *Target
+	Diff        *tengo.SchemaDiff
+	ObjectDiffs []tengo.ObjectDiff            // differences not excluded by ignore-table or similar options
+	Statements  []*DDLStatement               // DDL for each supported difference; noops are omitted
+	Unsupported []*tengo.UnsupportedDiffError // differences which cannot be expressed as DDL
+	Unsafe      []tengo.ObjectDiff            // destructive differences not permitted by the configuration
+}
+
+// PlanTarget computes the DDL needed for t, based on the configuration of
+// t.Dir. A ConfigError is returned if the configuration is invalid. Any other
+// error indicates that DDL could not be generated for one of the differences,
+// for example due to --allow-unsafe not being enabled; in this case the
+// returned Plan is incomplete, and should not be executed. All destructive
+// differences are collected in plan.Unsafe before such an error is returned,
+// but any other error is returned immediately.
+//
+// Any applicable table renames from the rename-table option come first in
+// plan.Statements, and plan.Diff is computed as if they had already occurred.
+//
+// If t.Instance is TiDB, statements are adjusted or rejected according to that
+// TiDB release's schema change limitations. If t.Instance is Aurora, an error
+// is returned for any statement which that Aurora release does not support.
+// If the vitess option is enabled,
+// tables used internally by Vitess are ignored, and statements which VTGate
+// rejects are omitted.
+//
+// PlanTarget does not run VerifyDiff; callers may do so separately using
+// plan.Diff if desired.
+func PlanTarget(t *Target) (*Plan, error) {
+	plan := &Plan{Target: t}
+
+	// Obtain StatementModifiers based on the dir's config
+	mods, err := StatementModifiersForDir(t.Dir)
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	mods.Flavor = t.Instance.Flavor()
+	if _, err := t.sessionVars(); err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	ignoreOpts, err := t.Dir.IgnoreOptions()
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	renames, err := RenamesForDir(t.Dir)
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	vitess, err := util.VitessModeForConfig(t.Dir.Config)
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	tableEncryption, err := t.Dir.Config.GetEnum("table-encryption", "MANAGE", "IGNORE")
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	schemaEncryption, err := t.Dir.Config.GetEnum("default-encryption", "Y", "N", "")
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	if _, err := t.Dir.Config.GetEnum("page-compression", "MANAGE", "IGNORE"); err != nil {
+		return nil, ConfigError(err.Error())
+	}
+
+	// Any renames are run first, and the diff is computed as if they had already
+	// occurred
+	from, renameStatements, err := planRenames(t, renames, ignoreOpts)
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	// Tables explicitly using the server's default row format are equivalent to
+	// ones which omit ROW_FORMAT, so they are diffed as such
+	rowFormat := util.InstanceDefaultRowFormat(t.Instance)
+	plan.Diff = tengo.NewSchemaDiff(util.WithoutDefaultRowFormat(from, rowFormat), util.WithoutDefaultRowFormat(t.SchemaFromDir, rowFormat))
+	plan.Statements = renameStatements
+
+	for _, objDiff := range plan.Diff.ObjectDiffs() {
+		key := objDiff.ObjectKey()
+		if vitess.Enabled() && key.Type == tengo.ObjectTypeTable && util.IsVitessInternalTable(key.Name) {
+			continue
+		}
+		if !ignoreOpts.ShouldIgnore(key) {
+			plan.ObjectDiffs = append(plan.ObjectDiffs, objDiff)
+		}
+	}
+	var unsafeErr error
+	for _, objDiff := range plan.ObjectDiffs {
+		ddl, err := NewDDLStatement(objDiff, mods, t)
+		if ddl == nil && err == nil {
+			continue // Skip entirely if mods made the statement a noop
+		}
+		if err == nil {
+			plan.Statements = append(plan.Statements, ddl)
+		} else if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
+			plan.Unsupported = append(plan.Unsupported, unsupportedErr)
+		} else if _, ok := err.(unsafeDiffError); ok {
+			plan.Unsafe = append(plan.Unsafe, objDiff)
+			if unsafeErr == nil {
+				unsafeErr = err
+			}
+		} else {
+			return plan, err
+		}
+	}
+	if schemaEncryption != "" && tableEncryption != "IGNORE" {
+		if err := planSchemaEncryption(plan, schemaEncryption); err != nil {
+			return plan, err
+		}
+	}
+	if tidb := util.InstanceTiDBFlavor(t.Instance); tidb.Known() {
+		if err := applyTiDBLimitations(plan, tidb); err != nil {
+			return plan, err
+		}
+	}
+	if aurora := util.InstanceAuroraVersion(t.Instance); aurora.Known() {
+		if err := checkAuroraLimitations(plan, aurora); err != nil {
+			return plan, err
+		}
+	}
+	if err := checkRocksDBLimitations(plan); err != nil {
+		return plan, err
+	}
+	if vitess.Enabled() {
+		applyVitessLimitations(plan, vitess)
+	}
+	return plan, unsafeErr
+}
+
+// StatementCount returns the number of differences in the plan which are
+// either expressed as DDL, or unsupported.
+func (plan *Plan) StatementCount() int {
+	return len(plan.Statements) + len(plan.Unsupported)
+}
@@ -2,18 +2,35 @@ package applier
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/skeema/skeema/fs"
 	"github.com/skeema/tengo"
 )
 
 // Printer is capable of sending output to STDOUT in a readable manner despite
 // being called from multiple pushworker goroutines.
 type Printer struct {
-	briefOutput        bool
-	lastStdoutInstance string
-	lastStdoutSchema   string
-	seenInstance       map[string]bool
+	briefOutput              bool
+	lastStdoutInstance       string
+	lastStdoutSchema         string
+	seenInstance             map[string]bool
+	exportDir                string
+	exportFormat             ExportFormat
+	exportedPaths            map[string]bool
+	changelogs               map[string]*liquibaseChangelog
+	flywayPaths              map[string]string
+	flywayVersion            string
+	flywayDescription        string
+	golangMigrateFiles       map[string]*golangMigrateFile
+	golangMigrateVersion     string
+	golangMigrateDescription string
+	runID                    string
+	reviewer                 *Reviewer
 	*sync.Mutex
 }
 
@@ -23,12 +40,146 @@ type Printer struct {
 // used to print any arbitrary output specific to an instance and schema.
 func NewPrinter(briefMode bool) *Printer {
 	return &Printer{
-		briefOutput:  briefMode,
-		seenInstance: make(map[string]bool),
-		Mutex:        new(sync.Mutex),
+		briefOutput:        briefMode,
+		seenInstance:       make(map[string]bool),
+		exportedPaths:      make(map[string]bool),
+		changelogs:         make(map[string]*liquibaseChangelog),
+		flywayPaths:        make(map[string]string),
+		golangMigrateFiles: make(map[string]*golangMigrateFile),
+		runID:              time.Now().UTC().Format("20060102150405"),
+		Mutex:              new(sync.Mutex),
 	}
 }
 
+// ExportFormat enumerates the file formats for exporting DDL.
+type ExportFormat string
+
+// Constants for the supported export formats
+const (
+	ExportFormatSQL           ExportFormat = "sql"            // statements in the same form as STDOUT
+	ExportFormatLiquibaseXML  ExportFormat = "liquibase-xml"  // Liquibase XML changelog
+	ExportFormatLiquibaseYAML ExportFormat = "liquibase-yaml" // Liquibase YAML changelog
+	ExportFormatFlyway        ExportFormat = "flyway"         // Flyway versioned migration
+	ExportFormatGolangMigrate ExportFormat = "golang-migrate" // golang-migrate up and down migrations
+)
+
+// Extension returns the file extension used for the export format. For
+// migration tool formats, this is blank, since each schema has a dir of
+// migration files instead of a single file.
+func (format ExportFormat) Extension() string {
+	switch format {
+	case ExportFormatFlyway, ExportFormatGolangMigrate:
+		return ""
+	case ExportFormatLiquibaseXML:
+		return ".xml"
+	case ExportFormatLiquibaseYAML:
+		return ".yaml"
+	default:
+		return ".sql"
+	}
+}
+
+// SetExportDir configures the printer to additionally write each DDLStatement
+// to a file in dirPath, with one file per instance and schema. See ExportPath
+// for the naming scheme. Files are only written for targets with at least one
+// statement. A blank dirPath disables exporting.
+//
+// With a Liquibase format, each file is a changelog containing one changeset
+// per statement, along with a rollback if one can be derived. Changeset IDs
+// are prefixed with the printer's creation time, so that changelogs from
+// separate runs do not conflict.
+func (p *Printer) SetExportDir(dirPath string, format ExportFormat) {
+	p.Lock()
+	defer p.Unlock()
+	p.exportDir = dirPath
+	p.exportFormat = format
+}
+
+// SetReviewer configures the printer's workers to prompt for approval of each
+// DDLStatement using reviewer, prior to executing any statements for a
+// target. Declined statements are neither printed nor executed. Review does
+// not occur in dry-run mode. A nil reviewer disables review.
+func (p *Printer) SetReviewer(reviewer *Reviewer) {
+	p.Lock()
+	defer p.Unlock()
+	p.reviewer = reviewer
+}
+
+// ExportPath returns the path of the file in dirPath used for exporting the
+// DDL of the supplied instance and schema: a subdir named after the instance,
+// containing a file named after the schema with the format's extension.
+// Characters which are problematic in file names, such as colons and slashes,
+// are replaced with underscores. For ExportFormatFlyway and
+// ExportFormatGolangMigrate, the returned path is instead a dir named after the
+// schema, which contains migration files named according to SetFlywayNaming or
+// SetGolangMigrateNaming respectively.
+func ExportPath(dirPath string, instance *tengo.Instance, schemaName string, format ExportFormat) string {
+	return filepath.Join(dirPath, exportFileName(instance.String()), exportFileName(schemaName)+format.Extension())
+}
+
+func exportFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// exportDDL appends ddl to its export file, if an export dir is configured.
+// The file is truncated upon its first use by this printer, so that files
+// from a previous run are overwritten rather than appended to. Flyway and
+// golang-migrate migrations always contain raw SQL, even if a wrapper is
+// configured, since those tools execute them directly.
+func (p *Printer) exportDDL(ddl *DDLStatement) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.exportDir == "" {
+		return nil
+	}
+	path := ExportPath(p.exportDir, ddl.instance, ddl.schemaName, p.exportFormat)
+	if p.exportFormat == ExportFormatLiquibaseXML || p.exportFormat == ExportFormatLiquibaseYAML {
+		changelog := p.changelogs[path]
+		if changelog == nil {
+			if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+				return err
+			}
+			changelog = &liquibaseChangelog{runID: p.runID}
+			p.changelogs[path] = changelog
+		}
+		changelog.add(ddl)
+		return changelog.write(path, p.exportFormat)
+	}
+	if p.exportFormat == ExportFormatGolangMigrate {
+		return p.exportGolangMigrate(path, ddl)
+	}
+	text := ddl.String()
+	if p.exportFormat == ExportFormatFlyway {
+		var err error
+		if path, err = p.flywayFilePath(path); err != nil {
+			return err
+		}
+		text = fs.AddDelimiter(ddl.stmt)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !p.exportedPaths[path] {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			return err
+		}
+		flags |= os.O_TRUNC
+		p.exportedPaths[path] = true
+	}
+	f, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // printDDL outputs DDLStatement values to STDOUT in a way that prevents
 // interleaving of output from multiple workers.
 // TODO: buffer output from external commands and also prevent interleaving there
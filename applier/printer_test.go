@@ -0,0 +1,61 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestPrinterExportDDL(t *testing.T) {
+	inst1, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	inst2, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.5:3307)/")
+	if actual, expected := ExportPath("out", inst1, "shard1", ExportFormatSQL), filepath.Join("out", "1.2.3.4_3306", "shard1.sql"); actual != expected {
+		t.Errorf("Expected ExportPath to return %q, instead found %q", expected, actual)
+	}
+
+	const dirPath = "export-test"
+	defer os.RemoveAll(dirPath)
+	path1 := ExportPath(dirPath, inst1, "shard1", ExportFormatSQL)
+	if err := os.MkdirAll(filepath.Dir(path1), 0777); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	if err := ioutil.WriteFile(path1, []byte("stale content from a previous run\n"), 0666); err != nil {
+		t.Fatalf("Unable to write file: %s", err)
+	}
+
+	p := NewPrinter(true) // brief mode, to avoid cluttering test output
+	ddls := []*DDLStatement{
+		{stmt: "ALTER TABLE foo ADD COLUMN bar int", instance: inst1, schemaName: "shard1"},
+		{stmt: "DROP TABLE baz", instance: inst2, schemaName: "shard1"},
+		{stmt: "CREATE TABLE baz (id int)", instance: inst1, schemaName: "shard1"},
+	}
+	for _, ddl := range ddls {
+		if err := p.exportDDL(ddl); err != nil {
+			t.Fatalf("Unexpected error from exportDDL with no export dir: %s", err)
+		}
+	}
+	if _, err := os.Stat(ExportPath(dirPath, inst2, "shard1", ExportFormatSQL)); err == nil {
+		t.Fatal("Expected no file to be written without an export dir, but one was")
+	}
+
+	p.SetExportDir(dirPath, ExportFormatSQL)
+	for _, ddl := range ddls {
+		if err := p.exportDDL(ddl); err != nil {
+			t.Fatalf("Unexpected error from exportDDL: %s", err)
+		}
+	}
+	expected := map[string]string{
+		path1: "ALTER TABLE foo ADD COLUMN bar int;\nCREATE TABLE baz (id int);\n",
+		ExportPath(dirPath, inst2, "shard1", ExportFormatSQL): "DROP TABLE baz;\n",
+	}
+	for path, expectContents := range expected {
+		if contents, err := ioutil.ReadFile(path); err != nil {
+			t.Errorf("Unable to read %s: %s", path, err)
+		} else if string(contents) != expectContents {
+			t.Errorf("Unexpected contents of %s: %q", path, contents)
+		}
+	}
+}
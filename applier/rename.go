@@ -0,0 +1,120 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// TableRename represents a table rename recorded in the rename-table option.
+type TableRename struct {
+	From string
+	To   string
+}
+
+// String returns the rename in the format used by the rename-table option.
+func (tr TableRename) String() string {
+	return tr.From + ":" + tr.To
+}
+
+// RenamesForDir returns the table renames configured by dir's rename-table
+// option, in order. An error is returned if the option value is malformed.
+func RenamesForDir(dir *fs.Dir) ([]TableRename, error) {
+	var renames []TableRename
+	for _, entry := range dir.Config.GetSlice("rename-table", ',', true) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == parts[1] {
+			return nil, fmt.Errorf("Invalid value for option rename-table: %q is not of the form old_name:new_name", entry)
+		}
+		renames = append(renames, TableRename{From: parts[0], To: parts[1]})
+	}
+	return renames, nil
+}
+
+// planRenames determines which of the renames configured for t.Dir apply to
+// t. A rename applies if the instance's schema has a table with the old name
+// but not the new name, and the dir does not define a table with the old name.
+// It returns a copy of t.SchemaFromInstance with the applicable renames
+// performed, so that it may be diffed against t.SchemaFromDir, along with the
+// RENAME TABLE statements to run prior to any other DDL. Renames involving
+// ignored tables are skipped.
+func planRenames(t *Target, renames []TableRename, ignoreOpts fs.IgnoreOptions) (*tengo.Schema, []*DDLStatement, error) {
+	from := t.SchemaFromInstance
+	if from == nil || len(renames) == 0 {
+		return from, nil, nil
+	}
+	var statements []*DDLStatement
+	for _, r := range renames {
+		fromKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: r.From}
+		toKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: r.To}
+		if !from.HasTable(r.From) || from.HasTable(r.To) || t.SchemaFromDir.HasTable(r.From) {
+			continue
+		} else if ignoreOpts.ShouldIgnore(fromKey) || ignoreOpts.ShouldIgnore(toKey) {
+			log.Debugf("Skipping rename of %s to %s due to ignore options", fromKey, toKey)
+			continue
+		}
+		params, err := t.sessionVars()
+		if err != nil {
+			return nil, nil, err
+		}
+		statements = append(statements, &DDLStatement{
+			stmt:          fmt.Sprintf("RENAME TABLE %s TO %s", tengo.EscapeIdentifier(r.From), tengo.EscapeIdentifier(r.To)),
+			instance:      t.Instance,
+			schemaName:    t.SchemaFromDir.Name,
+			connectParams: params.Encode(),
+			rename:        &TableRename{From: r.From, To: r.To},
+		})
+		from = renameTable(from, r.From, r.To)
+	}
+	return from, statements, nil
+}
+
+// renameTable returns a copy of schema in which the table named oldName has
+// been renamed to newName. Foreign keys referencing the table are updated
+// accordingly, as occurs automatically upon renaming a table in MySQL. The
+// original schema and its tables are not modified.
+func renameTable(schema *tengo.Schema, oldName, newName string) *tengo.Schema {
+	renamed := *schema
+	renamed.Tables = make([]*tengo.Table, len(schema.Tables))
+	oldRef := fmt.Sprintf("REFERENCES %s (", tengo.EscapeIdentifier(oldName))
+	newRef := fmt.Sprintf("REFERENCES %s (", tengo.EscapeIdentifier(newName))
+	for n, table := range schema.Tables {
+		var modified *tengo.Table
+		var copiedForeignKeys bool
+		if table.Name == oldName {
+			tableCopy := *table
+			modified = &tableCopy
+			modified.Name = newName
+			modified.CreateStatement = strings.Replace(table.CreateStatement,
+				"CREATE TABLE "+tengo.EscapeIdentifier(oldName),
+				"CREATE TABLE "+tengo.EscapeIdentifier(newName), 1)
+		}
+		for i, fk := range table.ForeignKeys {
+			if fk.ReferencedTableName != oldName || (fk.ReferencedSchemaName != "" && fk.ReferencedSchemaName != schema.Name) {
+				continue
+			}
+			if modified == nil {
+				tableCopy := *table
+				modified = &tableCopy
+			}
+			if !copiedForeignKeys {
+				modified.ForeignKeys = make([]*tengo.ForeignKey, len(table.ForeignKeys))
+				copy(modified.ForeignKeys, table.ForeignKeys)
+				copiedForeignKeys = true
+			}
+			fkCopy := *fk
+			fkCopy.ReferencedTableName = newName
+			modified.ForeignKeys[i] = &fkCopy
+			modified.CreateStatement = strings.Replace(modified.CreateStatement, oldRef, newRef, -1)
+		}
+		if modified != nil {
+			renamed.Tables[n] = modified
+		} else {
+			renamed.Tables[n] = table
+		}
+	}
+	return &renamed
+}
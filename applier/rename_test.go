@@ -0,0 +1,87 @@
+package applier
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestRenamesForDir(t *testing.T) {
+	dir := getDir(t, ".", "--rename-table='orders:purchases, items:lines'")
+	renames, err := RenamesForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from RenamesForDir: %s", err)
+	}
+	if len(renames) != 2 || renames[0].String() != "orders:purchases" || renames[1] != (TableRename{From: "items", To: "lines"}) {
+		t.Errorf("Unexpected renames returned: %+v", renames)
+	}
+	for _, bad := range []string{"orders", "orders:", ":purchases", "a:b:c", "orders:orders"} {
+		dir = getDir(t, ".", "--rename-table="+bad)
+		if _, err := RenamesForDir(dir); err == nil {
+			t.Errorf("Expected error from rename-table=%s, but err was nil", bad)
+		}
+	}
+}
+
+func TestPlanRenames(t *testing.T) {
+	inst, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	makeTable := func(name, refName string) *tengo.Table {
+		table := &tengo.Table{
+			Name:            name,
+			CreateStatement: "CREATE TABLE " + tengo.EscapeIdentifier(name) + " (\n  `id` int(11) NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1",
+		}
+		if refName != "" {
+			table.ForeignKeys = []*tengo.ForeignKey{{Name: "fk", ReferencedTableName: refName}}
+			table.CreateStatement += " /* REFERENCES " + tengo.EscapeIdentifier(refName) + " (`id`) */"
+		}
+		return table
+	}
+	orders, items := makeTable("orders", ""), makeTable("items", "orders")
+	target := &Target{
+		Instance:           inst,
+		Dir:                getDir(t, ".", ""),
+		SchemaFromInstance: &tengo.Schema{Name: "shop", Tables: []*tengo.Table{orders, items, makeTable("misc", "")}},
+		SchemaFromDir:      &tengo.Schema{Name: "shop", Tables: []*tengo.Table{makeTable("purchases", ""), makeTable("lines", "purchases")}},
+	}
+	renames := []TableRename{
+		{From: "orders", To: "purchases"},
+		{From: "items", To: "lines"},
+		{From: "misc", To: "other"},      // not applicable: new name not in dir, but still runs
+		{From: "missing", To: "nowhere"}, // not applicable: old name not on instance
+	}
+	from, statements, err := planRenames(target, renames, fs.IgnoreOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error from planRenames: %s", err)
+	}
+	expected := []string{"RENAME TABLE `orders` TO `purchases`", "RENAME TABLE `items` TO `lines`", "RENAME TABLE `misc` TO `other`"}
+	if len(statements) != len(expected) {
+		t.Fatalf("Expected %d statements, instead found %d", len(expected), len(statements))
+	}
+	for n := range expected {
+		if statements[n].stmt != expected[n] || statements[n].schemaName != "shop" {
+			t.Errorf("Unexpected statement[%d]: %+v", n, statements[n])
+		}
+	}
+
+	// The returned schema should reflect the renames, including the foreign key,
+	// without modifying the original
+	lines := from.Table("lines")
+	if lines == nil || !from.HasTable("purchases") || from.HasTable("orders") || from.HasTable("items") {
+		t.Fatalf("Renamed schema does not have expected tables")
+	}
+	if lines.ForeignKeys[0].ReferencedTableName != "purchases" || lines.CreateStatement != target.SchemaFromDir.Table("lines").CreateStatement {
+		t.Errorf("Foreign key was not renamed as expected: %s", lines.CreateStatement)
+	}
+	if orders.Name != "orders" || items.ForeignKeys[0].ReferencedTableName != "orders" || !target.SchemaFromInstance.HasTable("orders") {
+		t.Error("planRenames unexpectedly modified the original schema")
+	}
+
+	// Renames shouldn't apply if the dir still has the old name, or the instance
+	// already has the new name
+	target.SchemaFromDir.Tables = append(target.SchemaFromDir.Tables, makeTable("misc", ""))
+	target.SchemaFromInstance.Tables = append(target.SchemaFromInstance.Tables, makeTable("lines", ""))
+	if _, statements, _ := planRenames(target, renames, fs.IgnoreOptions{}); len(statements) != 1 {
+		t.Errorf("Expected 1 statement, instead found %d", len(statements))
+	}
+}
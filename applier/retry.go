@@ -0,0 +1,219 @@
+package applier
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/go-sql-driver/mysql"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// RetryEntry identifies a single combination of directory, instance, and
+// schema name that could not be fully pushed. Entries for targets that were
+// skipped before their schema name (or instance) was known have a blank Schema
+// (or Instance), which matches any value.
+type RetryEntry struct {
+	Dir       string `json:"dir"`
+	Instance  string `json:"instance"`
+	Schema    string `json:"schema"`
+	Retryable bool   `json:"retryable"` // true if the failure was transient, see IsRetryableError
+}
+
+// newSkipEntry returns a RetryEntry for dir, which was skipped due to an error
+// with inst, or with dir itself if inst is nil.
+func newSkipEntry(dir *fs.Dir, inst *tengo.Instance) RetryEntry {
+	entry := RetryEntry{Dir: dir.Path}
+	if inst != nil {
+		entry.Instance = inst.String()
+	}
+	return entry
+}
+
+// newSkipEntries returns a RetryEntry for each combination of dir and the
+// supplied instances.
+func newSkipEntries(dir *fs.Dir, instances []*tengo.Instance) RetryList {
+	rl := make(RetryList, 0, len(instances))
+	for _, inst := range instances {
+		rl = append(rl, newSkipEntry(dir, inst))
+	}
+	return rl
+}
+
+// matches returns true if entry refers to the supplied dir path, instance, and
+// schema name. Blank fields of entry match any value.
+func (entry RetryEntry) matches(dirPath, instance, schema string) bool {
+	return entry.Dir == dirPath && (entry.Instance == "" || entry.Instance == instance) && (entry.Schema == "" || entry.Schema == schema)
+}
+
+// overlaps returns true if some target could match both entry and other.
+func (entry RetryEntry) overlaps(other RetryEntry) bool {
+	return entry.Dir == other.Dir &&
+		(entry.Instance == "" || other.Instance == "" || entry.Instance == other.Instance) &&
+		(entry.Schema == "" || other.Schema == "" || entry.Schema == other.Schema)
+}
+
+// RetryList is a list of RetryEntry, suitable for persisting to a file so that
+// a subsequent push can target just the entries that previously failed.
+type RetryList []RetryEntry
+
+// NewRetryList returns a RetryList with one entry per supplied failed target,
+// followed by the supplied skipped entries. Entries for targets which are also
+// present in retryable are flagged as such.
+func NewRetryList(failed, retryable []*Target, skipped RetryList) RetryList {
+	rl := make(RetryList, 0, len(failed)+len(skipped))
+	for _, t := range failed {
+		entry := RetryEntry{
+			Dir:      t.Dir.Path,
+			Instance: t.Instance.String(),
+			Schema:   t.SchemaFromDir.Name,
+		}
+		for _, rt := range retryable {
+			if rt == t {
+				entry.Retryable = true
+			}
+		}
+		rl = append(rl, entry)
+	}
+	for _, entry := range skipped {
+		if !rl.has(entry) {
+			rl = append(rl, entry)
+		}
+	}
+	return rl
+}
+
+// CarryForward returns rl, plus any entries of previous which were not
+// processed successfully by a push limited to previous's entries. An entry of
+// previous is considered processed successfully if it matches some target
+// which was attempted (present in attempted) without failing (absent from
+// failed). This way, entries for targets which were skipped or filtered out
+// entirely are not lost when the retry-file is rewritten.
+func (rl RetryList) CarryForward(previous RetryList, attempted, failed []*Target) RetryList {
+	succeeded := make([]*Target, 0, len(attempted))
+	for _, t := range attempted {
+		var didFail bool
+		for _, ft := range failed {
+			didFail = didFail || ft == t
+		}
+		if !didFail {
+			succeeded = append(succeeded, t)
+		}
+	}
+	for _, entry := range previous {
+		var processed bool
+		for _, t := range succeeded {
+			processed = processed || entry.matches(t.Dir.Path, t.Instance.String(), t.SchemaFromDir.Name)
+		}
+		if !processed && !rl.has(entry) {
+			rl = append(rl, entry)
+		}
+	}
+	return rl
+}
+
+// has returns true if rl already contains an entry identical to entry, aside
+// from its Retryable flag.
+func (rl RetryList) has(entry RetryEntry) bool {
+	for _, existing := range rl {
+		if existing.Dir == entry.Dir && existing.Instance == entry.Instance && existing.Schema == entry.Schema {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable returns the number of entries in the list which failed due to a
+// transient error.
+func (rl RetryList) Retryable() (count int) {
+	for _, entry := range rl {
+		if entry.Retryable {
+			count++
+		}
+	}
+	return count
+}
+
+// IsRetryableError returns true if err indicates a transient failure, which
+// may succeed if the same operation is simply attempted again later: a lock
+// wait timeout, a deadlock, or the connection being lost while the statement
+// was running.
+func IsRetryableError(err error) bool {
+	switch err {
+	case mysql.ErrInvalidConn, driver.ErrBadConn, io.ErrUnexpectedEOF:
+		return true
+	}
+	// 2006 and 2013 are the client-side CR_SERVER_GONE_ERROR and CR_SERVER_LOST,
+	// which some proxies relay as server errors
+	return tengo.IsDatabaseError(err, mysqlerr.ER_LOCK_WAIT_TIMEOUT, mysqlerr.ER_LOCK_DEADLOCK, 2006, 2013)
+}
+
+// ReadRetryFile parses a file previously written by RetryList.Write.
+func ReadRetryFile(filePath string) (RetryList, error) {
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var rl RetryList
+	if err := json.Unmarshal(contents, &rl); err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+// Write persists the list to the supplied file path, overwriting any existing
+// file. If the list is empty, any existing file is removed instead, so that a
+// successful run does not leave behind a stale list.
+func (rl RetryList) Write(filePath string) error {
+	if len(rl) == 0 {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	contents, err := json.MarshalIndent(rl, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(contents, '\n'), 0666)
+}
+
+// Includes returns true if the list contains an entry matching t.
+func (rl RetryList) Includes(t *Target) bool {
+	for _, entry := range rl {
+		if entry.matches(t.Dir.Path, t.Instance.String(), t.SchemaFromDir.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the subset of targets which are included in the list.
+func (rl RetryList) Filter(targets []*Target) []*Target {
+	result := make([]*Target, 0, len(rl))
+	for _, t := range targets {
+		if rl.Includes(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// FilterEntries returns the subset of entries which overlap with some entry in
+// the list.
+func (rl RetryList) FilterEntries(entries RetryList) RetryList {
+	var result RetryList
+	for _, entry := range entries {
+		for _, existing := range rl {
+			if existing.overlaps(entry) {
+				result = append(result, entry)
+				break
+			}
+		}
+	}
+	return result
+}
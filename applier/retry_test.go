@@ -0,0 +1,125 @@
+package applier
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/go-sql-driver/mysql"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestRetryList(t *testing.T) {
+	inst1, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	inst2, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.5:3306)/")
+	dir := &fs.Dir{Path: "/var/schemas/product"}
+	makeTarget := func(inst *tengo.Instance, schemaName string) *Target {
+		return &Target{
+			Instance:      inst,
+			Dir:           dir,
+			SchemaFromDir: &tengo.Schema{Name: schemaName},
+		}
+	}
+	targets := []*Target{
+		makeTarget(inst1, "shard1"),
+		makeTarget(inst1, "shard2"),
+		makeTarget(inst2, "shard3"),
+		makeTarget(inst2, "shard4"),
+	}
+
+	failed := NewRetryList([]*Target{targets[1], targets[2]}, []*Target{targets[2]}, nil)
+	if failed[0].Retryable || !failed[1].Retryable || failed.Retryable() != 1 {
+		t.Errorf("Retryable flags not set as expected: %+v", failed)
+	}
+	const filePath = "retry-test.json"
+	if err := failed.Write(filePath); err != nil {
+		t.Fatalf("Unexpected error from Write: %s", err)
+	}
+	defer os.Remove(filePath)
+
+	rl, err := ReadRetryFile(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadRetryFile: %s", err)
+	}
+	if !reflect.DeepEqual(rl, failed) {
+		t.Errorf("Expected ReadRetryFile to return %+v, instead found %+v", failed, rl)
+	}
+	filtered := rl.Filter(targets)
+	if len(filtered) != 2 || filtered[0] != targets[1] || filtered[1] != targets[2] {
+		t.Errorf("Unexpected result from Filter: %+v", filtered)
+	}
+
+	// Skipped entries with a blank instance or schema match any value, and are
+	// only added once
+	skipped := RetryList{
+		{Dir: dir.Path, Instance: inst2.String()},
+		{Dir: dir.Path, Instance: inst2.String()},
+		{Dir: "/var/schemas/other"},
+	}
+	withSkips := NewRetryList([]*Target{targets[1]}, nil, skipped)
+	if len(withSkips) != 3 {
+		t.Errorf("Unexpected result from NewRetryList: %+v", withSkips)
+	}
+	filtered = withSkips.Filter(targets)
+	if len(filtered) != 3 || filtered[0] != targets[1] || filtered[1] != targets[2] || filtered[2] != targets[3] {
+		t.Errorf("Unexpected result from Filter: %+v", filtered)
+	}
+	overlapping := failed.FilterEntries(skipped)
+	if len(overlapping) != 2 || overlapping[0] != skipped[0] {
+		t.Errorf("Unexpected result from FilterEntries: %+v", overlapping)
+	}
+
+	// Simulate a retry of withSkips in which inst2 is still down, so its targets
+	// are never attempted, and targets[1] fails again. The inst2 entry and the
+	// entry for the other dir must be carried forward.
+	next := NewRetryList([]*Target{targets[1]}, nil, nil).CarryForward(withSkips, []*Target{targets[1]}, []*Target{targets[1]})
+	if !reflect.DeepEqual(next, withSkips) {
+		t.Errorf("Expected CarryForward to return %+v, instead found %+v", withSkips, next)
+	}
+	// Once everything succeeds, nothing should be carried forward, aside from
+	// the unrelated dir which still was not attempted
+	next = NewRetryList(nil, nil, nil).CarryForward(withSkips, targets[1:], nil)
+	if len(next) != 1 || next[0].Dir != "/var/schemas/other" {
+		t.Errorf("Unexpected result from CarryForward: %+v", next)
+	}
+
+	// Writing an empty list should remove the file
+	if err := NewRetryList(nil, nil, nil).Write(filePath); err != nil {
+		t.Fatalf("Unexpected error from Write: %s", err)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, but stat returned %v", filePath, err)
+	}
+	if _, err := ReadRetryFile(filePath); err == nil {
+		t.Error("Expected error from ReadRetryFile on nonexistent file, but err was nil")
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{&mysql.MySQLError{Number: mysqlerr.ER_LOCK_WAIT_TIMEOUT, Message: "Lock wait timeout exceeded; try restarting transaction"}, true},
+		{&mysql.MySQLError{Number: mysqlerr.ER_LOCK_DEADLOCK, Message: "Deadlock found when trying to get lock; try restarting transaction"}, true},
+		{&mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"}, true},
+		{&mysql.MySQLError{Number: 2006, Message: "MySQL server has gone away"}, true},
+		{mysql.ErrInvalidConn, true},
+		{driver.ErrBadConn, true},
+		{io.ErrUnexpectedEOF, true},
+		{&mysql.MySQLError{Number: mysqlerr.ER_DUP_ENTRY, Message: "Duplicate entry '1' for key 'PRIMARY'"}, false},
+		{&mysql.MySQLError{Number: mysqlerr.ER_PARSE_ERROR, Message: "You have an error in your SQL syntax"}, false},
+		{errors.New("exit status 1"), false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if actual := IsRetryableError(c.err); actual != c.retryable {
+			t.Errorf("Expected IsRetryableError(%v) to return %t, instead found %t", c.err, c.retryable, actual)
+		}
+	}
+}
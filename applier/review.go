@@ -0,0 +1,179 @@
+package applier
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/skeema/tengo"
+)
+
+// Reviewer interactively prompts an operator to approve or decline the DDL
+// for each changed object before it is executed. Prompts from multiple workers
+// are serialized, but operators will generally want to use a single worker to
+// avoid interleaving prompts with other output.
+type Reviewer struct {
+	in         *bufio.Reader
+	out        io.Writer
+	approveAll bool // operator chose to approve all remaining objects
+	declineAll bool // operator chose to quit, declining all remaining objects
+	*sync.Mutex
+}
+
+// NewReviewer returns a pointer to a new Reviewer, which reads responses from
+// in and writes prompts to out.
+func NewReviewer(in io.Reader, out io.Writer) *Reviewer {
+	return &Reviewer{
+		in:    bufio.NewReader(in),
+		out:   out,
+		Mutex: new(sync.Mutex),
+	}
+}
+
+const reviewHelp = `y - run the statements for this object
+n - skip the statements for this object
+e - expand the statements for this object to show their full text
+a - run the statements for this object and all remaining objects, without prompting
+q - skip the statements for this object and all remaining objects, without prompting
+`
+
+// reviewGroup is the set of statements affecting a single object, which are
+// approved or declined together.
+type reviewGroup struct {
+	name string // object name, or empty if the statements aren't tied to an object
+	ddls []*DDLStatement
+}
+
+// groupByObject returns ddls grouped by the object they affect, in order of
+// each object's first statement. An object may have multiple statements that
+// aren't adjacent, for example when foreign keys are added in a separate
+// ALTER TABLE after all other changes. Statements without an object each form
+// a group of their own.
+func groupByObject(ddls []*DDLStatement) []*reviewGroup {
+	var groups []*reviewGroup
+	byName := make(map[string]*reviewGroup)
+	for _, ddl := range ddls {
+		name := ddl.objectName()
+		if g := byName[name]; g != nil && name != "" {
+			g.ddls = append(g.ddls, ddl)
+			continue
+		}
+		g := &reviewGroup{name: name, ddls: []*DDLStatement{ddl}}
+		byName[name] = g
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// description returns a single-line description of the group for use in
+// prompts.
+func (g *reviewGroup) description() string {
+	if g.name == "" {
+		return g.ddls[0].summary()
+	}
+	return fmt.Sprintf("%d statement%s for %s", len(g.ddls), plural(len(g.ddls)), g.name)
+}
+
+// Review lists the changed objects for the target along with their statements,
+// and then prompts the operator to approve or decline each object's
+// statements as a unit. It returns the approved statements, in their original
+// order. If the input ends or cannot be read, all statements not yet approved
+// are declined.
+func (r *Reviewer) Review(t *Target, ddls []*DDLStatement) (approved []*DDLStatement) {
+	if len(ddls) == 0 {
+		return ddls
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.approveAll {
+		return ddls
+	} else if r.declineAll {
+		return nil
+	}
+
+	schemaName := t.SchemaFromDir.Name
+	groups := groupByObject(ddls)
+	fmt.Fprintf(r.out, "-- %d statement%s planned for %s %s, affecting %d object%s:\n", len(ddls), plural(len(ddls)), t.Instance, tengo.EscapeIdentifier(schemaName), len(groups), plural(len(groups)))
+	for n, g := range groups {
+		if g.name == "" {
+			fmt.Fprintf(r.out, "--   [%d] %s\n", n+1, g.ddls[0].summary())
+			continue
+		}
+		fmt.Fprintf(r.out, "--   [%d] %s\n", n+1, g.name)
+		for _, ddl := range g.ddls {
+			fmt.Fprintf(r.out, "--         %s\n", ddl.summary())
+		}
+	}
+	approvedGroups := make(map[*reviewGroup]bool, len(groups))
+	for n, g := range groups {
+		if r.approveAll {
+			approvedGroups[g] = true
+			continue
+		} else if r.declineAll {
+			break
+		}
+		for answered := false; !answered; {
+			fmt.Fprintf(r.out, "Run [%d/%d] %s on %s %s? [y,n,e,a,q,?] ", n+1, len(groups), g.description(), t.Instance, tengo.EscapeIdentifier(schemaName))
+			line, err := r.in.ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Fprintln(r.out)
+				r.declineAll = true
+				break
+			}
+			answered = true
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				approvedGroups[g] = true
+			case "n", "no":
+			case "a", "all":
+				approvedGroups[g] = true
+				r.approveAll = true
+			case "q", "quit":
+				r.declineAll = true
+			case "e", "expand":
+				for _, ddl := range g.ddls {
+					fmt.Fprint(r.out, ddl.String())
+				}
+				answered = false
+			default:
+				fmt.Fprint(r.out, reviewHelp)
+				answered = false
+			}
+		}
+	}
+
+	approvedDDLs := make(map[*DDLStatement]bool, len(ddls))
+	for g := range approvedGroups {
+		for _, ddl := range g.ddls {
+			approvedDDLs[ddl] = true
+		}
+	}
+	for _, ddl := range ddls {
+		if approvedDDLs[ddl] {
+			approved = append(approved, ddl)
+		}
+	}
+	return approved
+}
+
+// summary returns a single-line abbreviated form of ddl, suitable for listing
+// statements in a review prompt.
+func (ddl *DDLStatement) summary() string {
+	text := strings.TrimSpace(ddl.String())
+	if pos := strings.IndexByte(text, '\n'); pos > -1 {
+		text = strings.TrimSpace(text[:pos]) + " ..."
+	}
+	if len(text) > 100 {
+		text = text[:96] + " ..."
+	}
+	return text
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,84 @@
+package applier
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestReviewerReview(t *testing.T) {
+	inst, _ := tengo.NewInstance("mysql", "root@tcp(1.2.3.4:3306)/")
+	target := &Target{
+		Instance:      inst,
+		SchemaFromDir: &tengo.Schema{Name: "shard1"},
+	}
+	ddls := []*DDLStatement{
+		{stmt: "ALTER TABLE foo ADD COLUMN bar int", instance: inst, schemaName: "shard1"},
+		{stmt: "DROP TABLE baz", instance: inst, schemaName: "shard1"},
+		{stmt: "CREATE TABLE baz (\n  id int\n)", instance: inst, schemaName: "shard1"},
+		{stmt: "DROP TABLE qux", instance: inst, schemaName: "shard1"},
+	}
+
+	// Unknown responses show help, and expand shows the statement without
+	// answering the prompt
+	var out bytes.Buffer
+	r := NewReviewer(strings.NewReader("y\nwhat\nn\ne\nY\nno\n"), &out)
+	approved := r.Review(target, ddls)
+	if len(approved) != 2 || approved[0] != ddls[0] || approved[1] != ddls[2] {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+	output := out.String()
+	if !strings.Contains(output, "[3] CREATE TABLE baz ( ...\n") || !strings.Contains(output, "skip the statements for this object\n") || !strings.Contains(output, "CREATE TABLE baz (\n  id int\n);\n") {
+		t.Errorf("Unexpected output from review:\n%s", output)
+	}
+
+	// Approve-all and quit both carry over to subsequent targets
+	r = NewReviewer(strings.NewReader("n\na\n"), &out)
+	if approved := r.Review(target, ddls); len(approved) != 3 || approved[0] != ddls[1] {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+	if approved := r.Review(target, ddls); len(approved) != len(ddls) {
+		t.Errorf("Expected all statements to be approved after answering a, instead found %d", len(approved))
+	}
+	r = NewReviewer(strings.NewReader("y\nq\ny\n"), &out)
+	if approved := r.Review(target, ddls); len(approved) != 1 {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+	if approved := r.Review(target, ddls); len(approved) != 0 {
+		t.Errorf("Expected no statements to be approved after answering q, instead found %d", len(approved))
+	}
+
+	// Running out of input declines everything remaining
+	r = NewReviewer(strings.NewReader("y"), &out)
+	if approved := r.Review(target, ddls); len(approved) != 1 {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+	if approved := r.Review(target, nil); len(approved) != 0 {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+
+	// Statements for the same object are approved or declined together, even
+	// if not adjacent
+	foo := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: &tengo.Table{Name: "foo"}, To: &tengo.Table{Name: "foo"}}
+	bar := &tengo.TableDiff{Type: tengo.DiffTypeCreate, To: &tengo.Table{Name: "bar"}}
+	ddls = []*DDLStatement{
+		{stmt: "ALTER TABLE foo ADD COLUMN bar_id int", diff: foo, instance: inst, schemaName: "shard1"},
+		{stmt: "CREATE TABLE bar (\n  id int\n)", diff: bar, instance: inst, schemaName: "shard1"},
+		{stmt: "DROP TABLE qux", instance: inst, schemaName: "shard1"},
+		{stmt: "ALTER TABLE foo ADD CONSTRAINT fk FOREIGN KEY (bar_id) REFERENCES bar (id)", diff: foo, instance: inst, schemaName: "shard1"},
+	}
+	out.Reset()
+	r = NewReviewer(strings.NewReader("e\ny\nn\nn\n"), &out)
+	approved = r.Review(target, ddls)
+	if len(approved) != 2 || approved[0] != ddls[0] || approved[1] != ddls[3] {
+		t.Errorf("Unexpected approved statements: %v", approved)
+	}
+	output = out.String()
+	for _, expected := range []string{"affecting 3 objects:\n", "--   [1] table `foo`\n", "Run [1/3] 2 statements for table `foo`", "Run [3/3] DROP TABLE qux", "REFERENCES bar (id);\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, but it did not:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package applier
+
+import (
+	"sort"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// Status summarizes how a target's live schema differs from its directory,
+// without generating any DDL for display.
+type Status struct {
+	Target      *Target
+	Added       []tengo.ObjectKey // objects defined in the dir but missing from the instance
+	Changed     []tengo.ObjectKey // objects whose definitions differ
+	Removed     []tengo.ObjectKey // objects present on the instance but not in the dir
+	Unsupported int               // count of changed objects using unsupported features
+}
+
+// NewStatus computes the Status of the supplied target. Objects matching the
+// dir's ignore options are not included, nor are differences which would not
+// generate any DDL, such as changes only to a table's next auto-increment
+// value.
+func NewStatus(t *Target, mods tengo.StatementModifiers) (*Status, error) {
+	ignoreOpts, err := t.Dir.IgnoreOptions()
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+
+	// Status is read-only, so unsafe changes should still be reported rather than
+	// causing errors
+	mods.AllowUnsafe = true
+	diffTypes := make(map[tengo.ObjectKey]map[tengo.DiffType]bool)
+	unsupported := make(map[tengo.ObjectKey]bool)
+	status := &Status{Target: t}
+	rowFormat := util.InstanceDefaultRowFormat(t.Instance)
+	from, to := util.WithoutDefaultRowFormat(t.SchemaFromInstance, rowFormat), util.WithoutDefaultRowFormat(t.SchemaFromDir, rowFormat)
+	for _, objDiff := range tengo.NewSchemaDiff(from, to).ObjectDiffs() {
+		key := objDiff.ObjectKey()
+		if ignoreOpts.ShouldIgnore(key) {
+			continue
+		}
+		stmt, err := objDiff.Statement(mods)
+		if _, ok := err.(*tengo.UnsupportedDiffError); ok {
+			unsupported[key] = true
+		} else if err != nil {
+			return nil, err
+		} else if stmt == "" {
+			continue
+		}
+		if diffTypes[key] == nil {
+			diffTypes[key] = make(map[tengo.DiffType]bool)
+		}
+		diffTypes[key][objDiff.DiffType()] = true
+	}
+
+	status.Unsupported = len(unsupported)
+
+	// A changed routine is represented by a DROP followed by a CREATE; a changed
+	// table may be represented by multiple ALTERs.
+	for key, types := range diffTypes {
+		if types[tengo.DiffTypeAlter] || (types[tengo.DiffTypeCreate] && types[tengo.DiffTypeDrop]) {
+			status.Changed = append(status.Changed, key)
+		} else if types[tengo.DiffTypeCreate] {
+			status.Added = append(status.Added, key)
+		} else if types[tengo.DiffTypeDrop] {
+			status.Removed = append(status.Removed, key)
+		}
+	}
+	for _, keys := range [][]tengo.ObjectKey{status.Added, status.Changed, status.Removed} {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+	}
+	return status, nil
+}
+
+// Differences returns true if any objects were added, changed, or removed.
+func (s *Status) Differences() bool {
+	return len(s.Added)+len(s.Changed)+len(s.Removed) > 0
+}
@@ -0,0 +1,80 @@
+package applier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestNewStatus(t *testing.T) {
+	dir := getDir(t, "../testdata/applier/simple", "--ignore-table=^_")
+	table := func(name, comment string, nextAutoInc uint64) *tengo.Table {
+		return &tengo.Table{
+			Name:              name,
+			Engine:            "InnoDB",
+			CharSet:           "latin1",
+			Comment:           comment,
+			NextAutoIncrement: nextAutoInc,
+			CreateStatement:   "CREATE TABLE `" + name + "` (`id` int) ENGINE=InnoDB DEFAULT CHARSET=latin1 COMMENT='" + comment + "'",
+		}
+	}
+	proc := func(name, body string) *tengo.Routine {
+		return &tengo.Routine{
+			Name:            name,
+			Type:            tengo.ObjectTypeProc,
+			Body:            body,
+			CreateStatement: "CREATE PROCEDURE " + name + "() " + body,
+		}
+	}
+	instSchema := &tengo.Schema{
+		Name:     "product",
+		CharSet:  "latin1",
+		Tables:   []*tengo.Table{table("dropped", "", 1), table("widgets", "", 1), table("counter", "", 5), table("_scratch", "", 1)},
+		Routines: []*tengo.Routine{proc("same", "SELECT 1"), proc("modified", "SELECT 1")},
+	}
+	dirSchema := &tengo.Schema{
+		Name:     "product",
+		CharSet:  "latin1",
+		Tables:   []*tengo.Table{table("widgets", "new comment", 1), table("counter", "", 1), table("created", "", 1)},
+		Routines: []*tengo.Routine{proc("same", "SELECT 1"), proc("modified", "SELECT 2"), proc("newproc", "SELECT 3")},
+	}
+	target := &Target{Dir: dir, SchemaFromInstance: instSchema, SchemaFromDir: dirSchema}
+	mods := tengo.StatementModifiers{NextAutoInc: tengo.NextAutoIncIfIncreased}
+
+	status, err := NewStatus(target, mods)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewStatus: %v", err)
+	}
+	expectAdded := []tengo.ObjectKey{{Type: tengo.ObjectTypeProc, Name: "newproc"}, {Type: tengo.ObjectTypeTable, Name: "created"}}
+	expectChanged := []tengo.ObjectKey{{Type: tengo.ObjectTypeProc, Name: "modified"}, {Type: tengo.ObjectTypeTable, Name: "widgets"}}
+	expectRemoved := []tengo.ObjectKey{{Type: tengo.ObjectTypeTable, Name: "dropped"}}
+	if !reflect.DeepEqual(status.Added, expectAdded) {
+		t.Errorf("Expected Added to be %v, instead found %v", expectAdded, status.Added)
+	}
+	if !reflect.DeepEqual(status.Changed, expectChanged) {
+		t.Errorf("Expected Changed to be %v, instead found %v", expectChanged, status.Changed)
+	}
+	if !reflect.DeepEqual(status.Removed, expectRemoved) {
+		t.Errorf("Expected Removed to be %v, instead found %v", expectRemoved, status.Removed)
+	}
+	if !status.Differences() || status.Unsupported != 0 {
+		t.Errorf("Unexpected values in status: %+v", status)
+	}
+
+	// Identical schemas should have no differences
+	target.SchemaFromDir = instSchema
+	if status, err = NewStatus(target, mods); err != nil {
+		t.Fatalf("Unexpected error from NewStatus: %v", err)
+	} else if status.Differences() {
+		t.Errorf("Expected no differences, instead found %+v", status)
+	}
+
+	// Missing schema on instance should report the database as added
+	target.SchemaFromInstance = nil
+	if status, err = NewStatus(target, mods); err != nil {
+		t.Fatalf("Unexpected error from NewStatus: %v", err)
+	} else if len(status.Added) == 0 || status.Added[0] != (tengo.ObjectKey{Type: tengo.ObjectTypeDatabase, Name: "product"}) {
+		t.Errorf("Expected database to be reported as added, instead found %v", status.Added)
+	}
+}
@@ -1,10 +1,15 @@
 package applier
 
 import (
+	"database/sql"
+	"fmt"
+	"net/url"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -18,6 +23,28 @@ type Target struct {
 	Dir                *fs.Dir
 	SchemaFromInstance *tengo.Schema
 	SchemaFromDir      *tengo.Schema
+	Source             string // live schema that SchemaFromDir was introspected from, if not from *.sql
+
+	pushSessionVars url.Values // cached result of SessionVarsForDir, see sessionVars
+}
+
+// sessionVars returns the session variables to set on connections used for
+// running DDL against the target, as configured by the push-session-vars
+// option. The option is only parsed once per target; each call returns a new
+// copy, which the caller may freely modify.
+func (t *Target) sessionVars() (url.Values, error) {
+	if t.pushSessionVars == nil {
+		v, err := SessionVarsForDir(t.Dir)
+		if err != nil {
+			return nil, err
+		}
+		t.pushSessionVars = v
+	}
+	v := make(url.Values, len(t.pushSessionVars))
+	for name, values := range t.pushSessionVars {
+		v[name] = append([]string(nil), values...)
+	}
+	return v, nil
 }
 
 // TargetGroup represents a group of Targets that all have the same Instance.
@@ -33,25 +60,36 @@ type TargetGroup []*Target
 // Targets are returned as a slice with no guaranteed ordering. Errors are not
 // fatal; a count of skipped dirs is returned instead.
 func TargetsForDir(dir *fs.Dir, maxDepth int) (targets []*Target, skipCount int) {
-	if dir.Config.Changed("host") && dir.HasSchema() {
-		var instances []*tengo.Instance
-		instances, skipCount = instancesForDir(dir)
+	targets, skipCount, _ = walkTargets(dir, maxDepth, targetsForSingleDir)
+	return
+}
 
-		// For each LogicalSchema, obtain a *tengo.Schema representation and then
-		// create a Target for each instance x schema combination
-		for _, logicalSchema := range dir.LogicalSchemas {
-			thisTargets, thisSkipCount := targetsForLogicalSchema(logicalSchema, dir, instances)
-			targets = append(targets, thisTargets...)
-			skipCount += thisSkipCount
-		}
-	} else if dir.HasSchema() {
-		// If we have a schema defined but no host, display a warning
-		log.Warnf("Skipping %s: no host defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
-	} else if dir.OptionFile != nil && dir.OptionFile.SomeSectionHasOption("schema") {
-		// If we don't have a schema defined, but we would if some other environment
-		// had been selected, display a warning
-		log.Warnf("Skipping %s: no schema defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
-	}
+// TargetsAndSkipsForDir is like TargetsForDir, but also returns a RetryList
+// with an entry for each dir or dir/instance pair that was skipped due to an
+// error, such as an unreachable instance or a workspace failure. Subdirs which
+// could not be read or parsed at all are included in skipCount, but have no
+// entry.
+func TargetsAndSkipsForDir(dir *fs.Dir, maxDepth int) (targets []*Target, skipCount int, skipped RetryList) {
+	return walkTargets(dir, maxDepth, targetsForSingleDir)
+}
+
+// CloneTargetsForDir is like TargetsForDir, but the desired state of each
+// Target is obtained by introspecting a live schema, instead of from the dir's
+// *.sql files. The source schema for each dir is determined by parsing the dir
+// again using sourceConfig, which should select a different environment than
+// dir's configuration.
+func CloneTargetsForDir(dir *fs.Dir, sourceConfig *mybase.Config, maxDepth int) (targets []*Target, skipCount int) {
+	targets, skipCount, _ = walkTargets(dir, maxDepth, func(dir *fs.Dir) ([]*Target, RetryList) {
+		return cloneTargetsForSingleDir(dir, sourceConfig)
+	})
+	return
+}
+
+// walkTargets calls forDir on dir, and then recursively descends through dir's
+// subdirectories to do the same, returning the combined results.
+func walkTargets(dir *fs.Dir, maxDepth int, forDir func(*fs.Dir) ([]*Target, RetryList)) (targets []*Target, skipCount int, skipped RetryList) {
+	targets, skipped = forDir(dir)
+	skipCount = len(skipped)
 
 	subdirs, badSubdirCount, err := dir.Subdirs()
 	skipCount += badSubdirCount
@@ -62,29 +100,152 @@ func TargetsForDir(dir *fs.Dir, maxDepth int) (targets []*Target, skipCount int)
 	} else if len(subdirs) > 0 && maxDepth < 1 {
 		log.Warnf("Skipping subdirs of %s: max depth reached\n", dir)
 		skipCount += len(subdirs)
+		for _, subdir := range subdirs {
+			skipped = append(skipped, newSkipEntry(subdir, nil))
+		}
 		return
 	}
 	for _, subdir := range subdirs {
-		subTargets, subSkipCount := TargetsForDir(subdir, maxDepth-1)
+		subTargets, subSkipCount, subSkipped := walkTargets(subdir, maxDepth-1, forDir)
 		targets = append(targets, subTargets...)
 		skipCount += subSkipCount
+		skipped = append(skipped, subSkipped...)
+	}
+	return
+}
+
+// dirMapsToTargets returns true if dir configures both a host and schema. If
+// not, false is returned, and a warning is logged if the dir appears to be
+// misconfigured.
+func dirMapsToTargets(dir *fs.Dir) bool {
+	if dir.Config.Changed("host") && dir.HasSchema() {
+		return true
+	} else if dir.HasSchema() {
+		// If we have a schema defined but no host, display a warning
+		log.Warnf("Skipping %s: no host defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
+	} else if dir.OptionFile != nil && dir.OptionFile.SomeSectionHasOption("schema") {
+		// If we don't have a schema defined, but we would if some other environment
+		// had been selected, display a warning
+		log.Warnf("Skipping %s: no schema defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
 	}
+	return false
+}
 
+func targetsForSingleDir(dir *fs.Dir) (targets []*Target, skipped RetryList) {
+	if !dirMapsToTargets(dir) {
+		return nil, nil
+	}
+	span := util.Tracing.StartSpan(nil, "resolve config")
+	span.SetAttribute("skeema.dir", dir.Path)
+	logicalSchemas, err := logicalSchemasForDir(dir)
+	if err != nil {
+		span.End(err)
+		log.Warnf("Skipping %s: %s\n", dir, err)
+		return nil, RetryList{newSkipEntry(dir, nil)}
+	}
+	var instances []*tengo.Instance
+	instances, skipped = instancesForDir(dir)
+	span.End(nil)
+
+	// For each LogicalSchema, obtain a *tengo.Schema representation and then
+	// create a Target for each instance x schema combination
+	for _, logicalSchema := range logicalSchemas {
+		thisTargets, thisSkipped := targetsForLogicalSchema(logicalSchema, dir, instances)
+		targets = append(targets, thisTargets...)
+		skipped = append(skipped, thisSkipped...)
+	}
 	return
 }
 
+func cloneTargetsForSingleDir(dir *fs.Dir, sourceConfig *mybase.Config) (targets []*Target, skipped RetryList) {
+	if !dirMapsToTargets(dir) {
+		return nil, nil
+	}
+	sourceInst, sourceSchema, err := sourceSchemaForDir(dir, sourceConfig)
+	if err != nil {
+		log.Warnf("Skipping %s: %s\n", dir, err)
+		return nil, RetryList{newSkipEntry(dir, nil)}
+	}
+	var instances []*tengo.Instance
+	instances, skipped = instancesForDir(dir)
+	thisTargets, thisSkipped := targetsForInstances(dir, instances, sourceSchema, "")
+	for _, t := range thisTargets {
+		t.Source = fmt.Sprintf("%s %s", sourceInst, sourceSchema.Name)
+	}
+	return thisTargets, append(skipped, thisSkipped...)
+}
+
+// sourceSchemaForDir parses dir's path again using sourceConfig, and then
+// introspects the first schema that the resulting dir maps to on its first
+// instance.
+func sourceSchemaForDir(dir *fs.Dir, sourceConfig *mybase.Config) (*tengo.Instance, *tengo.Schema, error) {
+	sourceDir, err := fs.ParseDir(dir.Path, sourceConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := sourceDir.Config.Get("environment")
+	if !sourceDir.Config.Changed("host") || !sourceDir.HasSchema() {
+		return nil, nil, fmt.Errorf("no host or schema defined for source environment \"%s\"", env)
+	}
+	inst, err := sourceDir.FirstInstance()
+	if err != nil {
+		return nil, nil, err
+	} else if inst == nil {
+		return nil, nil, fmt.Errorf("dir maps to an empty list of instances for source environment \"%s\"", env)
+	}
+	schemaNames, err := sourceDir.SchemaNames(inst)
+	if err != nil {
+		return nil, nil, err
+	} else if len(schemaNames) == 0 {
+		return nil, nil, fmt.Errorf("dir does not map to any schema names for source environment \"%s\"", env)
+	} else if len(schemaNames) > 1 {
+		log.Debugf("Using first schema %s of %d on %s as source for %s", schemaNames[0], len(schemaNames), inst, dir)
+	}
+	schema, err := inst.Schema(schemaNames[0])
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("schema %s does not exist on source %s", schemaNames[0], inst)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return inst, schema, nil
+}
+
 // checkInstanceFlavor examines the actual flavor of the supplied instance,
 // and compares to the directory's configured flavor. If both are valid but
-// differ, log a warning. If the instance flavor cannot be detected but the
-// directory has a known flavor, override the instance to use the configured
-// dir flavor.
-func checkInstanceFlavor(instance *tengo.Instance, dir *fs.Dir) {
+// differ, log a warning listing each flavor-dependent behavior affected by the
+// difference. If the strict-flavor option is enabled and any such behavior is
+// affected, an error is returned instead, so that the instance is skipped. If
+// the instance flavor cannot be detected but the directory has a known flavor,
+// override the instance to use the configured dir flavor.
+//
+// TiDB is handled separately, since it reports a MySQL flavor: if the instance
+// is TiDB, its TiDB release is compared to the dir's configured flavor instead.
+func checkInstanceFlavor(instance *tengo.Instance, dir *fs.Dir) error {
 	instFlavor := instance.Flavor()
 	confFlavor := tengo.NewFlavor(dir.Config.Get("flavor"))
+	strict := dir.Config.GetBool("strict-flavor")
+
+	if tidb := util.InstanceTiDBFlavor(instance); tidb.Known() {
+		confTiDB := util.ParseTiDBFlavor(dir.Config.Get("flavor"))
+		if dir.Config.Get("flavor") != "" && confTiDB != tidb {
+			if strict {
+				return fmt.Errorf("actual flavor %s differs from configured flavor %s, and strict-flavor is enabled", tidb, dir.Config.Get("flavor"))
+			}
+			log.Warnf("Instance %s actual flavor %s differs from dir %s configured flavor %s", instance, tidb, dir, dir.Config.Get("flavor"))
+		}
+		return nil
+	}
 
 	if instFlavor.Known() {
 		if confFlavor != tengo.FlavorUnknown && instFlavor != confFlavor {
-			log.Warnf("Instance %s actual flavor %s differs from dir %s configured flavor %s", instance, instFlavor, dir, confFlavor)
+			diffs := util.FlavorDifferences(confFlavor, instFlavor)
+			if len(diffs) == 0 {
+				log.Warnf("Instance %s actual flavor %s differs from dir %s configured flavor %s", instance, instFlavor, dir, confFlavor)
+			} else if strict {
+				return fmt.Errorf("actual flavor %s differs from configured flavor %s, and strict-flavor is enabled. Affected behaviors:\n%s", instFlavor, confFlavor, strings.Join(diffs, "\n"))
+			} else {
+				log.Warnf("Instance %s actual flavor %s differs from dir %s configured flavor %s. Affected behaviors:\n%s", instance, instFlavor, dir, confFlavor, strings.Join(diffs, "\n"))
+			}
 		}
 	} else {
 		if confFlavor == tengo.FlavorUnknown {
@@ -94,45 +255,82 @@ func checkInstanceFlavor(instance *tengo.Instance, dir *fs.Dir) {
 			instance.SetFlavor(confFlavor)
 		}
 	}
+	return nil
 }
 
-func instancesForDir(dir *fs.Dir) (instances []*tengo.Instance, skipCount int) {
+// logicalSchemasForDir returns dir.LogicalSchemas, unless the snapshot option
+// is set, in which case a LogicalSchema is built from that snapshot's record of
+// the dir instead.
+func logicalSchemasForDir(dir *fs.Dir) ([]*fs.LogicalSchema, error) {
+	name := dir.Config.Get("snapshot")
+	if name == "" {
+		return dir.LogicalSchemas, nil
+	}
+	sf, err := fs.ReadSnapshotFile(dir.Config.Get("snapshot-file"))
+	if err != nil {
+		return nil, err
+	}
+	snap := sf.Snapshot(name)
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot %s not found in %s", name, sf.Path)
+	}
+	sd := snap.Dirs[sf.DirKey(dir)]
+	if sd == nil {
+		return nil, fmt.Errorf("snapshot %s does not include this directory", name)
+	}
+	base := &fs.LogicalSchema{}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		if logicalSchema.Name == "" {
+			base = logicalSchema
+		}
+	}
+	return []*fs.LogicalSchema{sd.LogicalSchema(base)}, nil
+}
+
+func instancesForDir(dir *fs.Dir) (instances []*tengo.Instance, skipped RetryList) {
 	if dir.Config.GetBool("first-only") {
 		onlyInstance, err := dir.FirstInstance()
 		if onlyInstance == nil && err == nil {
 			log.Warnf("Skipping %s: dir maps to an empty list of instances\n", dir)
-			return nil, 0
+			return nil, nil
 		} else if err != nil {
 			log.Warnf("Skipping %s: %s\n", dir, err)
-			return nil, 1
+			return nil, RetryList{newSkipEntry(dir, nil)}
 		}
 		// dir.FirstInstance already checks for connectivity, so no need to redo that here
-		checkInstanceFlavor(onlyInstance, dir)
-		return []*tengo.Instance{onlyInstance}, 0
+		if err := checkInstanceFlavor(onlyInstance, dir); err != nil {
+			log.Warnf("Skipping %s for %s: %s", onlyInstance, dir, err)
+			return nil, RetryList{newSkipEntry(dir, onlyInstance)}
+		}
+		return []*tengo.Instance{onlyInstance}, nil
 	}
 
 	rawInstances, err := dir.Instances()
 	if len(rawInstances) == 0 {
 		log.Warnf("Skipping %s: dir maps to an empty list of instances\n", dir)
-		return nil, 0
+		return nil, nil
 	} else if err != nil {
 		log.Warnf("Skipping %s: %s\n", dir, err)
-		return nil, 1
+		return nil, RetryList{newSkipEntry(dir, nil)}
 	}
 	// dir.Instances doesn't pre-check for connectivity problems, so do that now
 	for _, inst := range rawInstances {
 		if ok, err := inst.CanConnect(); !ok {
 			log.Warnf("Skipping %s for %s: %s", inst, dir, err)
-			skipCount++
+			entry := newSkipEntry(dir, inst)
+			entry.Retryable = true
+			skipped = append(skipped, entry)
+		} else if err := checkInstanceFlavor(inst, dir); err != nil {
+			log.Warnf("Skipping %s for %s: %s", inst, dir, err)
+			skipped = append(skipped, newSkipEntry(dir, inst))
 		} else {
-			checkInstanceFlavor(inst, dir)
 			instances = append(instances, inst)
 		}
 	}
 	return
 }
 
-func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, instances []*tengo.Instance) (targets []*Target, skipCount int) {
+func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, instances []*tengo.Instance) (targets []*Target, skipped RetryList) {
 	// If dir mapped to no instances, it generates no targets
 	if len(instances) == 0 {
 		return
@@ -143,12 +341,26 @@ func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, insta
 	opts, err := workspace.OptionsForDir(dir, instances[0])
 	if err != nil {
 		log.Warnf("Skipping %s: %s\n", dir, err)
-		return nil, len(instances)
+		return nil, newSkipEntries(dir, instances)
 	}
+	span := util.Tracing.StartSpan(nil, "workspace setup")
+	span.SetAttribute("skeema.dir", dir.Path)
+	span.SetAttribute("skeema.workspace", dir.Config.Get("workspace"))
 	fsSchema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+	span.End(err)
 	if err != nil {
 		log.Warnf("Skipping %s: %s\n", dir, err)
-		return nil, len(instances)
+		return nil, newSkipEntries(dir, instances)
+	}
+	if opts.Type == workspace.TypeTempSchema && util.InstanceTiDBFlavor(instances[0]).Known() {
+		util.NormalizeTiDBSchema(fsSchema, instances[0].Flavor())
+	}
+	if flavor := instances[0].Flavor(); flavor.Vendor == tengo.VendorPercona {
+		util.NormalizePerconaSchema(fsSchema, flavor)
+	} else if flavor.Vendor == tengo.VendorMariaDB {
+		util.NormalizeMariaDBSchema(fsSchema, flavor)
+	} else if flavor.MySQLishMinVersion(8, 0) {
+		util.NormalizeCheckConstraints(fsSchema, flavor)
 	}
 	for _, stmtErr := range statementErrors {
 		log.Error(stmtErr.Error())
@@ -163,34 +375,65 @@ func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, insta
 			noun = "error"
 		}
 		log.Warnf("Skipping %s due to %d SQL %s\n", dir, len(statementErrors), noun)
-		return nil, len(instances)
+		return nil, newSkipEntries(dir, instances)
 	}
 
 	// Create a Target for each instance x schema combination
+	return targetsForInstances(dir, instances, fsSchema, logicalSchema.Name)
+}
+
+// targetsForInstances returns a Target for each combination of instance x
+// schema name, with each Target using a copy of desired as its SchemaFromDir.
+// If schemaName is blank, the schema names are obtained from the dir's schema
+// option.
+func targetsForInstances(dir *fs.Dir, instances []*tengo.Instance, desired *tengo.Schema, schemaName string) (targets []*Target, skipped RetryList) {
 	for _, inst := range instances {
 		var schemaNames []string
-		if logicalSchema.Name == "" { // blank means use the schema option from dir config
+		if schemaName == "" {
+			var err error
 			schemaNames, err = dir.SchemaNames(inst)
 			if err != nil {
 				log.Warnf("Skipping %s for %s: %s", inst, dir, err)
-				skipCount++
+				skipped = append(skipped, newSkipEntry(dir, inst))
 				continue
 			}
 			if len(schemaNames) > 1 && dir.Config.GetBool("first-only") {
 				schemaNames = schemaNames[0:1]
 			}
 		} else {
-			schemaNames = []string{logicalSchema.Name}
+			schemaNames = []string{schemaName}
 		}
+		span := util.Tracing.StartSpan(nil, "introspection")
+		span.SetAttribute("db.instance", inst.String())
+		span.SetAttribute("skeema.schema_count", len(schemaNames))
 		schemasByName, err := inst.SchemasByName(schemaNames...)
+		span.End(err)
 		if err != nil {
 			log.Warnf("Skipping %s for %s: %s", inst, dir, err)
-			skipCount++
+			entry := newSkipEntry(dir, inst)
+			if len(schemaNames) == 1 {
+				entry.Schema = schemaNames[0]
+			}
+			skipped = append(skipped, entry)
 			continue
 		}
+		if util.InstanceTiDBFlavor(inst).Known() {
+			for _, schema := range schemasByName {
+				util.NormalizeTiDBSchema(schema, inst.Flavor())
+			}
+		}
+		for _, schema := range schemasByName {
+			if flavor := inst.Flavor(); flavor.Vendor == tengo.VendorPercona {
+				util.NormalizePerconaSchema(schema, flavor)
+			} else if flavor.Vendor == tengo.VendorMariaDB {
+				util.NormalizeMariaDBSchema(schema, flavor)
+			} else if flavor.MySQLishMinVersion(8, 0) {
+				util.NormalizeCheckConstraints(schema, flavor)
+			}
+		}
 
 		for _, schemaName := range schemaNames {
-			schemaCopy := *fsSchema
+			schemaCopy := *desired
 			schemaCopy.Name = schemaName
 			t := &Target{
 				Instance:           inst,
@@ -209,6 +452,12 @@ func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, insta
 // fatal errors.
 func TargetGroupChanForDir(dir *fs.Dir) (<-chan TargetGroup, int) {
 	targets, skipCount := TargetsForDir(dir, 5)
+	return TargetGroupChan(targets), skipCount
+}
+
+// TargetGroupChan returns a channel for obtaining TargetGroups for the supplied
+// targets, grouped by instance.
+func TargetGroupChan(targets []*Target) <-chan TargetGroup {
 	groups := make(chan TargetGroup)
 	go func() {
 		byInst := make(map[string]TargetGroup)
@@ -221,5 +470,5 @@ func TargetGroupChanForDir(dir *fs.Dir) (<-chan TargetGroup, int) {
 		}
 		close(groups)
 	}()
-	return groups, skipCount
+	return groups
 }
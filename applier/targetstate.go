@@ -0,0 +1,92 @@
+package applier
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/skeema/tengo"
+)
+
+// instanceState captures properties of a live instance which are not expected
+// to change during the course of a push. A change typically indicates that a
+// failover occurred, meaning the host now points to a different server, or
+// that the server was demoted to a replica.
+type instanceState struct {
+	readOnly      bool
+	superReadOnly bool
+	serverUUID    string
+	serverID      uint64
+	hostname      string
+	port          int
+}
+
+// getInstanceState queries the current state of instance. Variables that do
+// not exist in the instance's flavor (super_read_only prior to MySQL 5.7;
+// server_uuid in MariaDB) are left at their zero values. The server_id,
+// hostname, and port are always queried, for identifying the server in flavors
+// lacking server_uuid.
+func getInstanceState(instance *tengo.Instance) (state instanceState, err error) {
+	db, err := instance.Connect("", "")
+	if err != nil {
+		return
+	}
+	if err = db.QueryRow("SELECT @@global.read_only").Scan(&state.readOnly); err != nil {
+		return
+	}
+	if err = db.QueryRow("SELECT @@global.super_read_only").Scan(&state.superReadOnly); tengo.IsDatabaseError(err, mysqlerr.ER_UNKNOWN_SYSTEM_VARIABLE) {
+		err = nil
+	} else if err != nil {
+		return
+	}
+	var uuid sql.NullString
+	if err = db.QueryRow("SELECT @@global.server_uuid").Scan(&uuid); tengo.IsDatabaseError(err, mysqlerr.ER_UNKNOWN_SYSTEM_VARIABLE) {
+		err = nil
+	}
+	state.serverUUID = uuid.String
+	if err != nil {
+		return
+	}
+	err = db.QueryRow("SELECT @@global.server_id, @@global.hostname, @@global.port").Scan(&state.serverID, &state.hostname, &state.port)
+	return
+}
+
+// stateChecker verifies that an instance's state does not change between
+// calls to check.
+type stateChecker struct {
+	instance *tengo.Instance
+	initial  *instanceState
+}
+
+// check returns a non-nil error if the instance's state has changed since the
+// first call to check, or if the state could not be queried at all. The first
+// call just records the initial state.
+func (sc *stateChecker) check() error {
+	current, err := getInstanceState(sc.instance)
+	if err != nil {
+		return fmt.Errorf("Unable to verify state of %s: %s", sc.instance, err)
+	}
+	if sc.initial == nil {
+		sc.initial = &current
+		return nil
+	}
+	return sc.compare(current)
+}
+
+// compare returns a non-nil error if current differs from the initial state in
+// a way that indicates a failover or demotion. The initial state must already
+// be recorded.
+func (sc *stateChecker) compare(current instanceState) error {
+	if current.serverUUID != sc.initial.serverUUID {
+		return fmt.Errorf("Instance %s server_uuid changed from %s to %s, indicating a failover may have occurred", sc.instance, sc.initial.serverUUID, current.serverUUID)
+	} else if current.serverID != sc.initial.serverID {
+		return fmt.Errorf("Instance %s server_id changed from %d to %d, indicating a failover may have occurred", sc.instance, sc.initial.serverID, current.serverID)
+	} else if current.hostname != sc.initial.hostname || current.port != sc.initial.port {
+		return fmt.Errorf("Instance %s hostname and port changed from %s:%d to %s:%d, indicating a failover may have occurred", sc.instance, sc.initial.hostname, sc.initial.port, current.hostname, current.port)
+	} else if current.readOnly && !sc.initial.readOnly {
+		return fmt.Errorf("Instance %s became read_only, indicating it may have been demoted", sc.instance)
+	} else if current.superReadOnly && !sc.initial.superReadOnly {
+		return fmt.Errorf("Instance %s became super_read_only, indicating it may have been demoted", sc.instance)
+	}
+	return nil
+}
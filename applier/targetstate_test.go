@@ -0,0 +1,83 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateCheckerCompare(t *testing.T) {
+	uuid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	sc := &stateChecker{
+		initial: &instanceState{serverUUID: uuid, serverID: 1, hostname: "db1", port: 3306},
+	}
+	cases := map[instanceState]string{
+		{serverUUID: uuid, serverID: 1, hostname: "db1", port: 3306}:                                   "",
+		{serverUUID: "8a94f357-aab4-11df-86ab-c80aa9429562", serverID: 1, hostname: "db1", port: 3306}: "server_uuid changed",
+		{serverUUID: uuid, serverID: 2, hostname: "db1", port: 3306}:                                   "server_id changed",
+		{serverUUID: uuid, serverID: 1, hostname: "db2", port: 3306}:                                   "hostname and port changed",
+		{serverUUID: uuid, serverID: 1, hostname: "db1", port: 3307}:                                   "hostname and port changed",
+		{serverUUID: uuid, serverID: 1, hostname: "db1", port: 3306, readOnly: true}:                   "became read_only",
+		{serverUUID: uuid, serverID: 1, hostname: "db1", port: 3306, superReadOnly: true}:              "became super_read_only",
+	}
+	for current, expectErr := range cases {
+		err := sc.compare(current)
+		if expectErr == "" && err != nil {
+			t.Errorf("Unexpected error from compare(%+v): %s", current, err)
+		} else if expectErr != "" && (err == nil || !strings.Contains(err.Error(), expectErr)) {
+			t.Errorf("Expected compare(%+v) to return error containing %q, instead found %v", current, expectErr, err)
+		}
+	}
+
+	// An instance which was already read-only at the start is permitted to remain
+	// so, for example when pushing to a replica intentionally
+	sc.initial.readOnly = true
+	if err := sc.compare(*sc.initial); err != nil {
+		t.Errorf("Unexpected error when instance was read_only from the start: %s", err)
+	}
+
+	// Flavors without server_uuid (MariaDB) still detect failovers via server_id
+	sc.initial = &instanceState{serverID: 1, hostname: "db1", port: 3306}
+	if err := sc.compare(instanceState{serverID: 2, hostname: "db2", port: 3306}); err == nil || !strings.Contains(err.Error(), "server_id changed") {
+		t.Errorf("Expected server_id change to be detected without server_uuid, instead found %v", err)
+	}
+}
+
+func (s ApplierIntegrationSuite) TestStateChecker(t *testing.T) {
+	inst := s.d[0].Instance
+	sc := &stateChecker{instance: inst}
+	if err := sc.check(); err != nil {
+		t.Fatalf("Unexpected error from initial check: %s", err)
+	}
+	if err := sc.check(); err != nil {
+		t.Fatalf("Unexpected error from check with no state change: %s", err)
+	}
+
+	db, err := inst.Connect("", "")
+	if err != nil {
+		t.Fatalf("Unable to connect: %s", err)
+	}
+	if _, err := db.Exec("SET GLOBAL read_only = 1"); err != nil {
+		t.Fatalf("Unable to set read_only: %s", err)
+	}
+	defer db.Exec("SET GLOBAL read_only = 0")
+	if err := sc.check(); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("Expected check to return read_only error, instead found %v", err)
+	}
+
+	// Simulate a failover by altering the recorded server_id and server_uuid
+	if _, err := db.Exec("SET GLOBAL read_only = 0"); err != nil {
+		t.Fatalf("Unable to unset read_only: %s", err)
+	}
+	sc.initial.serverID++
+	if err := sc.check(); err == nil || !strings.Contains(err.Error(), "server_id") {
+		t.Errorf("Expected check to return server_id error, instead found %v", err)
+	}
+	sc.initial.serverID--
+	if sc.initial.serverUUID == "" {
+		t.Skip("Flavor does not have server_uuid")
+	}
+	sc.initial.serverUUID = "00000000-0000-0000-0000-000000000000"
+	if err := sc.check(); err == nil || !strings.Contains(err.Error(), "server_uuid") {
+		t.Errorf("Expected check to return server_uuid error, instead found %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// applyTiDBLimitations adjusts plan.Statements to account for schema change
+// limitations of the supplied TiDB release. An error is returned if any ALTER
+// TABLE cannot be run on TiDB at all: the primary key of a table with a
+// clustered index, or an AUTO_RANDOM column, cannot be modified after the
+// table is created. For TiDB releases prior to 6.2, ALTER TABLEs with multiple
+// clauses are split into separate statements, unless they are run via
+// alter-wrapper or ddl-wrapper.
+func applyTiDBLimitations(plan *Plan, tidb util.TiDBFlavor) error {
+	statements := make([]*DDLStatement, 0, len(plan.Statements))
+	for _, ddl := range plan.Statements {
+		td, ok := ddl.diff.(*tengo.TableDiff)
+		if !ok || td.DiffType() != tengo.DiffTypeAlter {
+			statements = append(statements, ddl)
+			continue
+		}
+		if util.TiDBClusteredIndex(td.From) && strings.Contains(ddl.stmt, "PRIMARY KEY") {
+			return fmt.Errorf("Unable to alter %s: %s does not permit changing the primary key of a table with a clustered index", td.ObjectKey(), tidb)
+		}
+		for _, colName := range util.TiDBAutoRandomColumns(td.From) {
+			escapedName := tengo.EscapeIdentifier(colName)
+			for _, verb := range []string{"MODIFY COLUMN ", "CHANGE COLUMN ", "DROP COLUMN "} {
+				if strings.Contains(ddl.stmt, verb+escapedName) {
+					return fmt.Errorf("Unable to alter %s: %s does not permit modifying AUTO_RANDOM column %s", td.ObjectKey(), tidb, escapedName)
+				}
+			}
+		}
+		if !tidb.EnforcesForeignKeys() && strings.Contains(ddl.stmt, "ADD CONSTRAINT") && strings.Contains(ddl.stmt, "FOREIGN KEY") {
+			log.Warnf("%s: foreign keys are not enforced by %s; the new constraint will have no effect until upgrading to TiDB 6.6 or later", td.ObjectKey(), tidb)
+		}
+		if tidb.MultiSchemaChange() || ddl.IsShellOut() {
+			statements = append(statements, ddl)
+		} else {
+			statements = append(statements, ddl.splitAlterClauses(td)...)
+		}
+	}
+	plan.Statements = statements
+	return nil
+}
+
+// splitAlterClauses returns a separate ALTER TABLE for each clause of ddl,
+// which must be an ALTER TABLE generated from td. Any ALGORITHM or LOCK clause
+// is retained in each resulting statement. If ddl only has one clause, it is
+// returned as-is.
+func (ddl *DDLStatement) splitAlterClauses(td *tengo.TableDiff) []*DDLStatement {
+	prefix := td.From.AlterStatement() + " "
+	if !strings.HasPrefix(ddl.stmt, prefix) {
+		return []*DDLStatement{ddl}
+	}
+	var changes, options []string
+	for _, clause := range splitTopLevelCommas(ddl.stmt[len(prefix):]) {
+		upper := strings.ToUpper(clause)
+		if strings.HasPrefix(upper, "ALGORITHM=") || strings.HasPrefix(upper, "LOCK=") {
+			options = append(options, clause)
+		} else {
+			changes = append(changes, clause)
+		}
+	}
+	if len(changes) < 2 {
+		return []*DDLStatement{ddl}
+	}
+	result := make([]*DDLStatement, 0, len(changes))
+	for _, change := range changes {
+		split := *ddl
+		split.stmt = prefix + strings.Join(append([]string{change}, options...), ", ")
+		result = append(result, &split)
+	}
+	return result
+}
+
+// splitTopLevelCommas splits s on commas which are not inside parentheses or
+// quotes, trimming whitespace from each resulting element.
+func splitTopLevelCommas(s string) (result []string) {
+	var depth, start int
+	var quote byte
+	for n := 0; n < len(s); n++ {
+		c := s[n]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				n++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			result = append(result, strings.TrimSpace(s[start:n]))
+			start = n + 1
+		}
+	}
+	return append(result, strings.TrimSpace(s[start:]))
+}
@@ -0,0 +1,74 @@
+package applier
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestSplitTopLevelCommas(t *testing.T) {
+	input := "ADD COLUMN `price` decimal(10,2) NOT NULL, MODIFY COLUMN `status` enum('a,b','c''d') DEFAULT 'x,y', ADD KEY `idx` (`a`,`b`), ALGORITHM=INPLACE"
+	expected := []string{
+		"ADD COLUMN `price` decimal(10,2) NOT NULL",
+		"MODIFY COLUMN `status` enum('a,b','c''d') DEFAULT 'x,y'",
+		"ADD KEY `idx` (`a`,`b`)",
+		"ALGORITHM=INPLACE",
+	}
+	if actual := splitTopLevelCommas(input); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from splitTopLevelCommas:\n%q", actual)
+	}
+}
+
+func TestApplyTiDBLimitations(t *testing.T) {
+	table := &tengo.Table{
+		Name: "posts",
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+			"  `user_id` bigint(20) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+	}
+	td := &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: table, To: table}
+	newPlan := func(stmt string) *Plan {
+		return &Plan{Statements: []*DDLStatement{{stmt: stmt, diff: td}}}
+	}
+	tidb61, tidb65 := util.ParseTiDBFlavor("tidb:6.1"), util.ParseTiDBFlavor("tidb:6.5")
+
+	// Multiple clauses are split prior to 6.2, retaining ALGORITHM and LOCK
+	plan := newPlan("ALTER TABLE `posts` ADD COLUMN `body` text, ADD KEY `user_id` (`user_id`), ALGORITHM=INPLACE")
+	if err := applyTiDBLimitations(plan, tidb61); err != nil {
+		t.Fatalf("Unexpected error from applyTiDBLimitations: %s", err)
+	}
+	var actual []string
+	for _, ddl := range plan.Statements {
+		actual = append(actual, ddl.stmt)
+	}
+	expected := []string{
+		"ALTER TABLE `posts` ADD COLUMN `body` text, ALGORITHM=INPLACE",
+		"ALTER TABLE `posts` ADD KEY `user_id` (`user_id`), ALGORITHM=INPLACE",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected statements after split: %q", actual)
+	}
+	plan = newPlan("ALTER TABLE `posts` ADD COLUMN `body` text, ADD KEY `user_id` (`user_id`)")
+	if err := applyTiDBLimitations(plan, tidb65); err != nil || len(plan.Statements) != 1 {
+		t.Errorf("Expected no split for TiDB 6.5; instead found err=%v, %d statements", err, len(plan.Statements))
+	}
+
+	// Primary key changes on clustered tables, and AUTO_RANDOM changes, are
+	// rejected
+	for _, stmt := range []string{
+		"ALTER TABLE `posts` DROP PRIMARY KEY, ADD PRIMARY KEY (`id`,`user_id`)",
+		"ALTER TABLE `posts` MODIFY COLUMN `id` bigint(20) unsigned NOT NULL",
+	} {
+		if err := applyTiDBLimitations(newPlan(stmt), tidb65); err == nil || !strings.Contains(err.Error(), "tidb:6.5 does not permit") {
+			t.Errorf("Expected error for %s, instead found %v", stmt, err)
+		}
+	}
+	if err := applyTiDBLimitations(newPlan("ALTER TABLE `posts` MODIFY COLUMN `user_id` bigint(20) unsigned NOT NULL"), tidb65); err != nil {
+		t.Errorf("Unexpected error for modifying non-AUTO_RANDOM column: %s", err)
+	}
+}
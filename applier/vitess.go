@@ -0,0 +1,37 @@
+package applier
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// applyVitessLimitations adjusts plan.Statements to account for statements
+// which VTGate rejects. Schema-level DDL and stored routine DDL are omitted
+// with a warning, since VTGate does not permit these to be run against a
+// keyspace. In a sharded keyspace, a warning is also logged for any statement
+// adding a foreign key, since Vitess cannot enforce these across shards.
+// Statements run via alter-wrapper or ddl-wrapper are left as-is, since the
+// external program may bypass VTGate entirely.
+func applyVitessLimitations(plan *Plan, mode util.VitessMode) {
+	statements := make([]*DDLStatement, 0, len(plan.Statements))
+	for _, ddl := range plan.Statements {
+		if ddl.diff == nil || ddl.IsShellOut() {
+			statements = append(statements, ddl)
+			continue
+		}
+		key := ddl.diff.ObjectKey()
+		switch key.Type {
+		case tengo.ObjectTypeDatabase, tengo.ObjectTypeProc, tengo.ObjectTypeFunc:
+			log.Warnf("Skipping %s: VTGate does not permit %s DDL in vitess mode. This change must be made directly on each tablet instead.", key, key.Type)
+			continue
+		}
+		if mode.Sharded() && strings.Contains(ddl.stmt, "FOREIGN KEY") {
+			log.Warnf("%s: Vitess cannot enforce foreign keys in a sharded keyspace; the constraint will only apply to rows within each shard", key)
+		}
+		statements = append(statements, ddl)
+	}
+	plan.Statements = statements
+}
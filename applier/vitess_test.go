@@ -0,0 +1,28 @@
+package applier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestApplyVitessLimitations(t *testing.T) {
+	table := &tengo.Table{Name: "posts"}
+	proc := &tengo.Routine{Name: "archive_posts", Type: tengo.ObjectTypeProc}
+	plan := &Plan{Statements: []*DDLStatement{
+		{stmt: "ALTER DATABASE `product` CHARACTER SET = utf8mb4", diff: &tengo.DatabaseDiff{From: &tengo.Schema{Name: "product"}, To: &tengo.Schema{Name: "product", CharSet: "utf8mb4"}}},
+		{stmt: "ALTER TABLE `posts` ADD COLUMN `body` text", diff: &tengo.TableDiff{Type: tengo.DiffTypeAlter, From: table, To: table}},
+		{stmt: "CREATE PROCEDURE `archive_posts`() BEGIN END", diff: &tengo.RoutineDiff{To: proc}},
+	}}
+	applyVitessLimitations(plan, util.VitessSharded)
+	var actual []string
+	for _, ddl := range plan.Statements {
+		actual = append(actual, ddl.stmt)
+	}
+	expected := []string{"ALTER TABLE `posts` ADD COLUMN `body` text"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected statements after applyVitessLimitations: %q", actual)
+	}
+}
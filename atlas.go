@@ -0,0 +1,568 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// This file converts between tengo's schema model and Atlas-style HCL schema
+// definitions, as used by `skeema export-atlas` and `skeema import-atlas`.
+// Only tables are converted; Atlas HCL has no open-source representation of
+// stored procedures or functions.
+
+var atlasSimpleTypeRegexp = regexp.MustCompile(`^([a-z]+)(?:\(([0-9, ]+)\))?$`)
+
+// atlasIntTypes are the integer types, whose display width is dropped when
+// exporting.
+var atlasIntTypes = map[string]bool{
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+}
+
+// atlasNumericTypes are types whose default values are exported as HCL
+// numbers rather than strings.
+var atlasNumericTypes = map[string]bool{
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+	"decimal": true, "numeric": true, "float": true, "double": true, "real": true, "bool": true,
+}
+
+// hclAttr is a single attribute written by writeAtlasHCL. Value is HCL
+// expression syntax, already quoted or formatted as needed.
+type hclAttr struct {
+	Name  string
+	Value string
+}
+
+// writeHCLBlock writes a block with the supplied header at the supplied
+// indentation depth. Attribute assignments are aligned, as with hclwrite.
+// nested is called to write any nested blocks after the attributes.
+func writeHCLBlock(w io.Writer, depth int, header string, attrs []hclAttr, nested func()) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s {\n", indent, header)
+	var width int
+	for _, attr := range attrs {
+		if len(attr.Name) > width {
+			width = len(attr.Name)
+		}
+	}
+	for _, attr := range attrs {
+		fmt.Fprintf(w, "%s  %-*s = %s\n", indent, width, attr.Name, attr.Value)
+	}
+	if nested != nil {
+		nested()
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+// writeAtlasHCL writes an Atlas-style HCL definition of schemas to w. The
+// schemas' tables should already be filtered to exclude any ignored objects.
+// Tables are sorted by name, so that output is deterministic.
+func writeAtlasHCL(w io.Writer, schemas []*tengo.Schema) {
+	for n, schema := range schemas {
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		tables := make([]*tengo.Table, len(schema.Tables))
+		copy(tables, schema.Tables)
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+		for _, table := range tables {
+			writeAtlasTable(w, schema.Name, table)
+			fmt.Fprintln(w)
+		}
+		var attrs []hclAttr
+		if schema.CharSet != "" {
+			attrs = append(attrs, hclAttr{"charset", hclQuote(schema.CharSet)})
+		}
+		if schema.Collation != "" {
+			attrs = append(attrs, hclAttr{"collate", hclQuote(schema.Collation)})
+		}
+		writeHCLBlock(w, 0, "schema "+hclQuote(schema.Name), attrs, nil)
+	}
+}
+
+func writeAtlasTable(w io.Writer, schemaName string, table *tengo.Table) {
+	attrs := []hclAttr{{"schema", "schema." + schemaName}}
+	if table.Comment != "" {
+		attrs = append(attrs, hclAttr{"comment", hclQuote(table.Comment)})
+	}
+	if table.CharSet != "" {
+		attrs = append(attrs, hclAttr{"charset", hclQuote(table.CharSet)})
+	}
+	if table.Collation != "" {
+		attrs = append(attrs, hclAttr{"collate", hclQuote(table.Collation)})
+	}
+	if table.Engine != "" && !strings.EqualFold(table.Engine, "InnoDB") {
+		attrs = append(attrs, hclAttr{"engine", table.Engine})
+	}
+	writeHCLBlock(w, 0, "table "+hclQuote(table.Name), attrs, func() {
+		for _, col := range table.Columns {
+			writeHCLBlock(w, 1, "column "+hclQuote(col.Name), atlasColumnAttrs(col, table), nil)
+		}
+		if table.PrimaryKey != nil {
+			writeAtlasIndex(w, "primary_key", table.PrimaryKey)
+		}
+		for _, idx := range table.SecondaryIndexes {
+			writeAtlasIndex(w, "index "+hclQuote(idx.Name), idx)
+		}
+		for _, fk := range table.ForeignKeys {
+			attrs := []hclAttr{
+				{"columns", atlasColumnRefs(fk.Columns)},
+				{"ref_columns", atlasRefColumnRefs(fk.ReferencedTableName, fk.ReferencedColumnNames)},
+				{"on_update", atlasReferenceOption(fk.UpdateRule)},
+				{"on_delete", atlasReferenceOption(fk.DeleteRule)},
+			}
+			writeHCLBlock(w, 1, "foreign_key "+hclQuote(fk.Name), attrs, nil)
+		}
+	})
+}
+
+func atlasColumnAttrs(col *tengo.Column, table *tengo.Table) []hclAttr {
+	colType, baseType, unsigned := atlasColumnType(col.TypeInDB)
+	attrs := []hclAttr{
+		{"null", strconv.FormatBool(col.Nullable)},
+		{"type", colType},
+	}
+	if unsigned {
+		attrs = append(attrs, hclAttr{"unsigned", "true"})
+	}
+	if !col.Default.Null && !col.AutoIncrement {
+		if !col.Default.Quoted {
+			attrs = append(attrs, hclAttr{"default", "sql(" + hclQuote(col.Default.Value) + ")"})
+		} else if _, err := strconv.ParseFloat(col.Default.Value, 64); err == nil && atlasNumericTypes[baseType] {
+			attrs = append(attrs, hclAttr{"default", col.Default.Value})
+		} else {
+			attrs = append(attrs, hclAttr{"default", hclQuote(col.Default.Value)})
+		}
+	}
+	if col.AutoIncrement {
+		attrs = append(attrs, hclAttr{"auto_increment", "true"})
+	}
+	if col.OnUpdate != "" {
+		attrs = append(attrs, hclAttr{"on_update", "sql(" + hclQuote(col.OnUpdate) + ")"})
+	}
+	if col.CharSet != "" && (col.CharSet != table.CharSet || col.Collation != table.Collation) {
+		attrs = append(attrs, hclAttr{"charset", hclQuote(col.CharSet)}, hclAttr{"collate", hclQuote(col.Collation)})
+	}
+	if col.Comment != "" {
+		attrs = append(attrs, hclAttr{"comment", hclQuote(col.Comment)})
+	}
+	return attrs
+}
+
+// atlasColumnType converts a column type as reported by the database into
+// Atlas HCL expression syntax. It also returns the base type name, and whether
+// the type is unsigned, which Atlas represents as a separate attribute. Types
+// which cannot be expressed natively are wrapped in sql().
+func atlasColumnType(typeInDB string) (colType, baseType string, unsigned bool) {
+	typeInDB = strings.ToLower(typeInDB)
+	typeInDB = strings.TrimSuffix(typeInDB, " zerofill")
+	if strings.HasSuffix(typeInDB, " unsigned") {
+		typeInDB, unsigned = strings.TrimSuffix(typeInDB, " unsigned"), true
+	}
+	if strings.HasPrefix(typeInDB, "enum(") || strings.HasPrefix(typeInDB, "set(") {
+		baseType = typeInDB[:strings.IndexByte(typeInDB, '(')]
+		values := parseEnumValues(typeInDB[len(baseType):])
+		for n := range values {
+			values[n] = hclQuote(values[n])
+		}
+		return fmt.Sprintf("%s(%s)", baseType, strings.Join(values, ", ")), baseType, unsigned
+	}
+	matches := atlasSimpleTypeRegexp.FindStringSubmatch(typeInDB)
+	if matches == nil {
+		return "sql(" + hclQuote(typeInDB) + ")", typeInDB, unsigned
+	}
+	baseType, args := matches[1], matches[2]
+	if baseType == "tinyint" && args == "1" && !unsigned {
+		return "bool", "bool", false
+	} else if args == "" || atlasIntTypes[baseType] {
+		return baseType, baseType, unsigned
+	}
+	return fmt.Sprintf("%s(%s)", baseType, strings.Replace(args, ",", ", ", -1)), baseType, unsigned
+}
+
+// parseEnumValues returns the values of an enum or set type's value list,
+// which should be supplied in the form ('a','b','c').
+func parseEnumValues(list string) []string {
+	list = strings.TrimSuffix(strings.TrimPrefix(list, "("), ")")
+	var values []string
+	var b strings.Builder
+	var inQuote bool
+	for n := 0; n < len(list); n++ {
+		c := list[n]
+		switch {
+		case c == '\'' && !inQuote:
+			inQuote = true
+		case c == '\'' && n+1 < len(list) && list[n+1] == '\'':
+			b.WriteByte('\'')
+			n++
+		case c == '\'':
+			inQuote = false
+			values = append(values, b.String())
+			b.Reset()
+		case c == '\\' && inQuote && n+1 < len(list):
+			b.WriteByte(list[n+1])
+			n++
+		case inQuote:
+			b.WriteByte(c)
+		}
+	}
+	return values
+}
+
+func writeAtlasIndex(w io.Writer, header string, idx *tengo.Index) {
+	var attrs []hclAttr
+	if idx.Unique && !idx.PrimaryKey {
+		attrs = append(attrs, hclAttr{"unique", "true"})
+	}
+	var hasPrefix bool
+	for _, subPart := range idx.SubParts {
+		if subPart > 0 {
+			hasPrefix = true
+		}
+	}
+	if !hasPrefix {
+		attrs = append(attrs, hclAttr{"columns", atlasColumnRefs(idx.Columns)})
+	}
+	if idx.Comment != "" {
+		attrs = append(attrs, hclAttr{"comment", hclQuote(idx.Comment)})
+	}
+	writeHCLBlock(w, 1, header, attrs, func() {
+		if !hasPrefix {
+			return
+		}
+		for n, col := range idx.Columns {
+			partAttrs := []hclAttr{{"column", "column." + col.Name}}
+			if idx.SubParts[n] > 0 {
+				partAttrs = append(partAttrs, hclAttr{"prefix", strconv.Itoa(int(idx.SubParts[n]))})
+			}
+			writeHCLBlock(w, 2, "on", partAttrs, nil)
+		}
+	})
+}
+
+func atlasColumnRefs(cols []*tengo.Column) string {
+	refs := make([]string, len(cols))
+	for n, col := range cols {
+		refs[n] = "column." + col.Name
+	}
+	return "[" + strings.Join(refs, ", ") + "]"
+}
+
+func atlasRefColumnRefs(tableName string, colNames []string) string {
+	refs := make([]string, len(colNames))
+	for n, colName := range colNames {
+		refs[n] = fmt.Sprintf("table.%s.column.%s", tableName, colName)
+	}
+	return "[" + strings.Join(refs, ", ") + "]"
+}
+
+// atlasReferenceOption converts a foreign key rule, such as "SET NULL", to
+// Atlas's identifier form, such as SET_NULL.
+func atlasReferenceOption(rule string) string {
+	if rule == "" {
+		return "NO_ACTION"
+	}
+	return strings.Replace(strings.ToUpper(rule), " ", "_", -1)
+}
+
+// atlasSchemaFromHCL converts the tables of an Atlas-style HCL definition into
+// a schema named schemaName, with each table's CreateStatement populated. If
+// the HCL defines multiple schemas, only tables in the one named schemaName
+// are converted; if it defines a single schema, its tables are converted
+// regardless of its name.
+func atlasSchemaFromHCL(body *hclBody, schemaName string) (*tengo.Schema, error) {
+	schemaBlocks := body.BlocksOfType("schema")
+	hclSchemaName := schemaName
+	if len(schemaBlocks) == 1 && len(schemaBlocks[0].Labels) > 0 {
+		hclSchemaName = schemaBlocks[0].Labels[0]
+	} else if len(schemaBlocks) > 1 {
+		var found bool
+		for _, block := range schemaBlocks {
+			found = found || (len(block.Labels) > 0 && block.Labels[0] == schemaName)
+		}
+		if !found {
+			return nil, fmt.Errorf("HCL defines %d schemas, none of which is named %s", len(schemaBlocks), schemaName)
+		}
+	}
+
+	schema := &tengo.Schema{Name: schemaName}
+	for _, block := range body.BlocksOfType("table") {
+		if len(block.Labels) != 1 {
+			return nil, fmt.Errorf("line %d: table block must have exactly one label", block.Line)
+		}
+		if ref := block.Body.Attr("schema"); ref != nil && ref.Kind == hclTraversal && len(ref.Path) == 2 && ref.Path[1] != hclSchemaName {
+			continue
+		}
+		create, err := atlasCreateTable(block)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: table %s: %s", block.Line, block.Labels[0], err)
+		}
+		schema.Tables = append(schema.Tables, &tengo.Table{
+			Name:            block.Labels[0],
+			CreateStatement: create,
+		})
+	}
+	return schema, nil
+}
+
+// atlasCreateTable returns a CREATE TABLE statement for an HCL table block.
+func atlasCreateTable(block *hclBlock) (string, error) {
+	var defs []string
+	colNames := make(map[string]bool)
+	for _, colBlock := range block.Body.BlocksOfType("column") {
+		if len(colBlock.Labels) != 1 {
+			return "", fmt.Errorf("line %d: column block must have exactly one label", colBlock.Line)
+		}
+		def, err := atlasColumnDefinition(colBlock)
+		if err != nil {
+			return "", fmt.Errorf("line %d: column %s: %s", colBlock.Line, colBlock.Labels[0], err)
+		}
+		colNames[colBlock.Labels[0]] = true
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		return "", fmt.Errorf("no columns defined")
+	}
+	for _, pkBlock := range block.Body.BlocksOfType("primary_key") {
+		parts, err := atlasIndexParts(pkBlock, colNames)
+		if err != nil {
+			return "", fmt.Errorf("line %d: primary key: %s", pkBlock.Line, err)
+		}
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", parts))
+	}
+	for _, idxBlock := range block.Body.BlocksOfType("index") {
+		if len(idxBlock.Labels) != 1 {
+			return "", fmt.Errorf("line %d: index block must have exactly one label", idxBlock.Line)
+		}
+		parts, err := atlasIndexParts(idxBlock, colNames)
+		if err != nil {
+			return "", fmt.Errorf("line %d: index %s: %s", idxBlock.Line, idxBlock.Labels[0], err)
+		}
+		def := fmt.Sprintf("KEY %s (%s)", tengo.EscapeIdentifier(idxBlock.Labels[0]), parts)
+		if idxBlock.Body.Attr("unique").Bool() {
+			def = "UNIQUE " + def
+		}
+		if comment := idxBlock.Body.Attr("comment").String(); comment != "" {
+			def += fmt.Sprintf(" COMMENT '%s'", tengo.EscapeValueForCreateTable(comment))
+		}
+		defs = append(defs, def)
+	}
+	for _, fkBlock := range block.Body.BlocksOfType("foreign_key") {
+		if len(fkBlock.Labels) != 1 {
+			return "", fmt.Errorf("line %d: foreign_key block must have exactly one label", fkBlock.Line)
+		}
+		def, err := atlasForeignKeyDefinition(fkBlock, block.Labels[0], colNames)
+		if err != nil {
+			return "", fmt.Errorf("line %d: foreign key %s: %s", fkBlock.Line, fkBlock.Labels[0], err)
+		}
+		defs = append(defs, def)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n  %s\n)", tengo.EscapeIdentifier(block.Labels[0]), strings.Join(defs, ",\n  "))
+	engine := block.Body.Attr("engine").String()
+	if engine == "" {
+		engine = "InnoDB"
+	}
+	fmt.Fprintf(&b, " ENGINE=%s", engine)
+	if charSet := block.Body.Attr("charset").String(); charSet != "" {
+		fmt.Fprintf(&b, " DEFAULT CHARSET=%s", charSet)
+	}
+	if collation := block.Body.Attr("collate").String(); collation != "" {
+		fmt.Fprintf(&b, " COLLATE=%s", collation)
+	}
+	if comment := block.Body.Attr("comment").String(); comment != "" {
+		fmt.Fprintf(&b, " COMMENT='%s'", tengo.EscapeValueForCreateTable(comment))
+	}
+	return b.String(), nil
+}
+
+func atlasColumnDefinition(block *hclBlock) (string, error) {
+	colType, err := atlasTypeFromHCL(block.Body.Attr("type"))
+	if err != nil {
+		return "", err
+	}
+	parts := []string{tengo.EscapeIdentifier(block.Labels[0]), colType}
+	if block.Body.Attr("unsigned").Bool() {
+		parts = append(parts, "unsigned")
+	}
+	if charSet := block.Body.Attr("charset").String(); charSet != "" {
+		parts = append(parts, "CHARACTER SET "+charSet)
+	}
+	if collation := block.Body.Attr("collate").String(); collation != "" {
+		parts = append(parts, "COLLATE "+collation)
+	}
+	nullable := block.Body.Attr("null").Bool()
+	if nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+	if def := block.Body.Attr("default"); def != nil {
+		switch {
+		case def.Kind == hclCall && def.Str == "sql" && len(def.Items) == 1:
+			parts = append(parts, "DEFAULT "+def.Items[0].Str)
+		case def.Kind == hclNull:
+			parts = append(parts, "DEFAULT NULL")
+		case def.Kind == hclBool && def.Bool():
+			parts = append(parts, "DEFAULT '1'")
+		case def.Kind == hclBool:
+			parts = append(parts, "DEFAULT '0'")
+		case def.Kind == hclString || def.Kind == hclNumber:
+			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", tengo.EscapeValueForCreateTable(def.Str)))
+		default:
+			return "", fmt.Errorf("unsupported default value")
+		}
+	} else if nullable {
+		parts = append(parts, "DEFAULT NULL")
+	}
+	if block.Body.Attr("auto_increment").Bool() {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+	if onUpdate := block.Body.Attr("on_update"); onUpdate != nil {
+		if onUpdate.Kind != hclCall || onUpdate.Str != "sql" || len(onUpdate.Items) != 1 {
+			return "", fmt.Errorf("on_update must be a sql() expression")
+		}
+		parts = append(parts, "ON UPDATE "+onUpdate.Items[0].Str)
+	}
+	if comment := block.Body.Attr("comment").String(); comment != "" {
+		parts = append(parts, fmt.Sprintf("COMMENT '%s'", tengo.EscapeValueForCreateTable(comment)))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// atlasTypeFromHCL converts a column type expression, such as varchar(255) or
+// enum("a", "b"), into SQL syntax.
+func atlasTypeFromHCL(v *hclValue) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("type attribute is required")
+	} else if v.Kind == hclTraversal && len(v.Path) == 1 {
+		if v.Path[0] == "bool" || v.Path[0] == "boolean" {
+			return "tinyint(1)", nil
+		}
+		return v.Path[0], nil
+	} else if v.Kind != hclCall {
+		return "", fmt.Errorf("unsupported type expression")
+	}
+	if v.Str == "sql" && len(v.Items) == 1 && v.Items[0].Kind == hclString {
+		return v.Items[0].Str, nil
+	}
+	args := make([]string, len(v.Items))
+	for n, arg := range v.Items {
+		if v.Str == "enum" || v.Str == "set" {
+			if arg.Kind != hclString {
+				return "", fmt.Errorf("%s values must be strings", v.Str)
+			}
+			args[n] = "'" + strings.Replace(arg.Str, "'", "''", -1) + "'"
+		} else if arg.Kind == hclNumber {
+			args[n] = arg.Str
+		} else {
+			return "", fmt.Errorf("arguments to type %s must be numbers", v.Str)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", v.Str, strings.Join(args, ",")), nil
+}
+
+// atlasIndexParts returns the SQL column list for a primary_key or index
+// block, which may list columns either in a columns attribute, or in nested
+// on blocks that permit specifying a prefix length.
+func atlasIndexParts(block *hclBlock, colNames map[string]bool) (string, error) {
+	var parts []string
+	if cols := block.Body.Attr("columns"); cols != nil {
+		names, err := atlasColumnNames(cols, colNames)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			parts = append(parts, tengo.EscapeIdentifier(name))
+		}
+	}
+	for _, onBlock := range block.Body.BlocksOfType("on") {
+		col := onBlock.Body.Attr("column")
+		if col == nil || col.Kind != hclTraversal || len(col.Path) != 2 || col.Path[0] != "column" {
+			return "", fmt.Errorf("line %d: on block must have a column reference; expressions are not supported", onBlock.Line)
+		} else if !colNames[col.Path[1]] {
+			return "", fmt.Errorf("line %d: unknown column %s", onBlock.Line, col.Path[1])
+		}
+		part := tengo.EscapeIdentifier(col.Path[1])
+		if prefix := onBlock.Body.Attr("prefix"); prefix != nil {
+			part += fmt.Sprintf("(%s)", prefix.String())
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("no columns specified")
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// atlasColumnNames returns the names of the columns in a list of column
+// references such as [column.a, column.b], verifying that each column exists.
+func atlasColumnNames(list *hclValue, colNames map[string]bool) ([]string, error) {
+	if list.Kind != hclList {
+		return nil, fmt.Errorf("expected a list of column references")
+	}
+	names := make([]string, len(list.Items))
+	for n, item := range list.Items {
+		if item.Kind != hclTraversal || len(item.Path) != 2 || item.Path[0] != "column" {
+			return nil, fmt.Errorf("expected a column reference such as column.name")
+		} else if !colNames[item.Path[1]] {
+			return nil, fmt.Errorf("unknown column %s", item.Path[1])
+		}
+		names[n] = item.Path[1]
+	}
+	return names, nil
+}
+
+func atlasForeignKeyDefinition(block *hclBlock, tableName string, colNames map[string]bool) (string, error) {
+	cols := block.Body.Attr("columns")
+	if cols == nil {
+		return "", fmt.Errorf("columns attribute is required")
+	}
+	names, err := atlasColumnNames(cols, colNames)
+	if err != nil {
+		return "", err
+	}
+	refCols := block.Body.Attr("ref_columns")
+	if refCols == nil || refCols.Kind != hclList || len(refCols.Items) != len(names) {
+		return "", fmt.Errorf("ref_columns must be a list with the same length as columns")
+	}
+	var refTable string
+	refNames := make([]string, len(refCols.Items))
+	for n, item := range refCols.Items {
+		// References are of form table.<name>.column.<name>, or column.<name> for
+		// self-referencing foreign keys
+		var itemTable, itemCol string
+		if item.Kind == hclTraversal && len(item.Path) == 4 && item.Path[0] == "table" && item.Path[2] == "column" {
+			itemTable, itemCol = item.Path[1], item.Path[3]
+		} else if item.Kind == hclTraversal && len(item.Path) == 2 && item.Path[0] == "column" {
+			itemTable, itemCol = tableName, item.Path[1]
+		} else {
+			return "", fmt.Errorf("expected a column reference such as table.name.column.name in ref_columns")
+		}
+		if n > 0 && itemTable != refTable {
+			return "", fmt.Errorf("all ref_columns must reference the same table")
+		}
+		refTable, refNames[n] = itemTable, tengo.EscapeIdentifier(itemCol)
+	}
+	for n := range names {
+		names[n] = tengo.EscapeIdentifier(names[n])
+	}
+	def := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		tengo.EscapeIdentifier(block.Labels[0]),
+		strings.Join(names, ", "),
+		tengo.EscapeIdentifier(refTable),
+		strings.Join(refNames, ", "))
+	for _, rule := range []struct{ attr, clause string }{{"on_delete", "ON DELETE"}, {"on_update", "ON UPDATE"}} {
+		value := strings.ToUpper(strings.Replace(block.Body.Attr(rule.attr).String(), "_", " ", -1))
+		if value != "" && value != "NO ACTION" && value != "RESTRICT" {
+			def += fmt.Sprintf(" %s %s", rule.clause, value)
+		}
+	}
+	return def, nil
+}
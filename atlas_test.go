@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestAtlasColumnType(t *testing.T) {
+	cases := []struct {
+		typeInDB string
+		colType  string
+		unsigned bool
+	}{
+		{"int(10) unsigned", "int", true},
+		{"bigint(20)", "bigint", false},
+		{"tinyint(1)", "bool", false},
+		{"tinyint(1) unsigned", "tinyint", true},
+		{"varchar(40)", "varchar(40)", false},
+		{"decimal(10,2) unsigned zerofill", "decimal(10, 2)", true},
+		{"timestamp(6)", "timestamp(6)", false},
+		{"text", "text", false},
+		{"enum('a','it''s','c\\\\d')", `enum("a", "it's", "c\\d")`, false},
+		{"set('x')", `set("x")`, false},
+		{"double precision", `sql("double precision")`, false},
+	}
+	for _, c := range cases {
+		if colType, _, unsigned := atlasColumnType(c.typeInDB); colType != c.colType || unsigned != c.unsigned {
+			t.Errorf("Expected atlasColumnType(%q) to return %q,%t; instead found %q,%t", c.typeInDB, c.colType, c.unsigned, colType, unsigned)
+		}
+	}
+}
+
+func TestWriteAtlasHCL(t *testing.T) {
+	var buf bytes.Buffer
+	writeAtlasHCL(&buf, []*tengo.Schema{docsTestSchema()})
+	out := buf.String()
+	expected := []string{
+		"table \"posts\" {\n  schema = schema.product\n",
+		"  column \"user_id\" {\n    null     = false\n    type     = int\n    unsigned = true\n    default  = 0\n  }\n",
+		"    default   = sql(\"CURRENT_TIMESTAMP\")\n    on_update = sql(\"CURRENT_TIMESTAMP\")\n",
+		"  primary_key {\n    columns = [column.id]\n  }\n",
+		"  foreign_key \"posts_user\" {\n    columns     = [column.user_id]\n    ref_columns = [table.users.column.id]\n    on_update   = RESTRICT\n    on_delete   = CASCADE\n  }\n",
+		"    auto_increment = true\n",
+		"    comment = \"Display name | shown publicly\"\n",
+		"  index \"name\" {\n    on {\n      column = column.name\n      prefix = 10\n    }\n  }\n",
+		"schema \"product\" {\n  charset = \"utf8mb4\"\n  collate = \"utf8mb4_general_ci\"\n}\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+	if strings.Index(out, "table \"posts\"") > strings.Index(out, "table \"users\"") {
+		t.Error("Expected tables to be sorted by name")
+	}
+}
+
+func TestAtlasRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writeAtlasHCL(&buf, []*tengo.Schema{docsTestSchema()})
+	body, err := parseHCL(buf.String())
+	if err != nil {
+		t.Fatalf("Unexpected error parsing exported HCL: %s\n%s", err, buf.String())
+	}
+	schema, err := atlasSchemaFromHCL(body, "whatever")
+	if err != nil {
+		t.Fatalf("Unexpected error from atlasSchemaFromHCL: %s", err)
+	}
+	if schema.Name != "whatever" || len(schema.Tables) != 2 {
+		t.Fatalf("Unexpected result from atlasSchemaFromHCL: %+v", schema)
+	}
+	expected := map[string]string{
+		"posts": "CREATE TABLE `posts` (\n" +
+			"  `id` bigint unsigned NOT NULL,\n" +
+			"  `user_id` int unsigned NOT NULL DEFAULT '0',\n" +
+			"  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  CONSTRAINT `posts_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n" +
+			") ENGINE=InnoDB",
+		"users": "CREATE TABLE `users` (\n" +
+			"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+			"  `name` varchar(40) NULL DEFAULT NULL COMMENT 'Display name | shown publicly',\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  KEY `name` (`name`(10))\n" +
+			") ENGINE=InnoDB COMMENT='Registered *users*'",
+	}
+	for _, table := range schema.Tables {
+		if table.CreateStatement != expected[table.Name] {
+			t.Errorf("Unexpected CREATE for %s: expected\n%s\nfound\n%s", table.Name, expected[table.Name], table.CreateStatement)
+		}
+		if !fs.CanParse(table.CreateStatement) {
+			t.Errorf("Generated CREATE for %s cannot be parsed", table.Name)
+		}
+	}
+}
+
+func TestAtlasSchemaFromHCL(t *testing.T) {
+	src := `
+# Two schemas; only tables in the one matching the dir are converted
+schema "app" {}
+schema "other" {}
+
+table "widgets" {
+  schema  = schema.app
+  charset = "utf8mb4"
+  column "id" {
+    type = bigint
+    auto_increment = true
+  }
+  column "kind" {
+    type    = enum("big", "it's small")
+    default = "big"
+  }
+  column "parent_id" {
+    type = bigint
+    null = true
+  }
+  column "price" {
+    type    = decimal(10,2)
+    default = 1.5
+  }
+  column "sku" {
+    type    = sql("char(8)")
+    charset = "latin1"
+    collate = "latin1_bin"
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  index "sku" {
+    unique  = true
+    columns = [column.sku, column.kind]
+    comment = "lookup"
+  }
+  foreign_key "widgets_parent" {
+    columns     = [column.parent_id]
+    ref_columns = [column.id]
+    on_delete   = SET_NULL
+    on_update   = NO_ACTION
+  }
+}
+
+table "ignored" {
+  schema = schema.other
+  column "id" {
+    type = int
+  }
+}
+`
+	body, err := parseHCL(src)
+	if err != nil {
+		t.Fatalf("Unexpected error from parseHCL: %s", err)
+	}
+	if _, err := atlasSchemaFromHCL(body, "nope"); err == nil {
+		t.Error("Expected error for schema name not present in multi-schema HCL, but err was nil")
+	}
+	schema, err := atlasSchemaFromHCL(body, "app")
+	if err != nil {
+		t.Fatalf("Unexpected error from atlasSchemaFromHCL: %s", err)
+	}
+	if len(schema.Tables) != 1 {
+		t.Fatalf("Expected 1 table, instead found %d", len(schema.Tables))
+	}
+	expected := "CREATE TABLE `widgets` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `kind` enum('big','it''s small') NOT NULL DEFAULT 'big',\n" +
+		"  `parent_id` bigint NULL DEFAULT NULL,\n" +
+		"  `price` decimal(10,2) NOT NULL DEFAULT '1.5',\n" +
+		"  `sku` char(8) CHARACTER SET latin1 COLLATE latin1_bin NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `sku` (`sku`,`kind`) COMMENT 'lookup',\n" +
+		"  CONSTRAINT `widgets_parent` FOREIGN KEY (`parent_id`) REFERENCES `widgets` (`id`) ON DELETE SET NULL\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	if actual := schema.Tables[0].CreateStatement; actual != expected {
+		t.Errorf("Unexpected CREATE: expected\n%s\nfound\n%s", expected, actual)
+	}
+
+	badCases := []string{
+		`table "t" {`,
+		`table "t" { column "c" { type = int } primary_key { columns = [column.nope] } }`,
+		`table "t" { column "c" { } }`,
+		`table "t" { column "c" { type = varchar("x") } }`,
+		`table "t" { }`,
+		`table "t" { column "c" { type = "unterminated } }`,
+	}
+	for _, src := range badCases {
+		body, err := parseHCL(src)
+		if err == nil {
+			_, err = atlasSchemaFromHCL(body, "app")
+		}
+		if err == nil {
+			t.Errorf("Expected error from HCL %q, but err was nil", src)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// catalogPayload is the body of a request sent to the catalog-webhook URL after
+// a push. Change events use the field names of OpenMetadata's ChangeEvent, so
+// that catalog and lineage tooling can consume them with minimal translation.
+// The full updated schema model uses the same format as `skeema dump-json`.
+type catalogPayload struct {
+	Source       string               `json:"source"`
+	Timestamp    int64                `json:"timestamp"` // milliseconds since epoch
+	Environment  string               `json:"environment"`
+	Instance     string               `json:"instance"`
+	Service      string               `json:"service"`
+	ChangeEvents []catalogChangeEvent `json:"changeEvents"`
+	Schema       *jsonSchema          `json:"schema"`
+}
+
+// catalogChangeEvent describes the creation, alteration, or removal of a
+// single table.
+type catalogChangeEvent struct {
+	EventType                string                    `json:"eventType"` // "entityCreated", "entityUpdated", or "entityDeleted"
+	EntityType               string                    `json:"entityType"`
+	EntityFullyQualifiedName string                    `json:"entityFullyQualifiedName"`
+	Timestamp                int64                     `json:"timestamp"`
+	ChangeDescription        *catalogChangeDescription `json:"changeDescription,omitempty"`
+	Entity                   *jsonTable                `json:"entity,omitempty"` // nil for entityDeleted
+}
+
+// catalogChangeDescription lists the fields of a table which were changed by
+// a push. Each added, updated, or removed column is listed separately, using
+// field name "columns".
+type catalogChangeDescription struct {
+	FieldsAdded   []catalogFieldChange `json:"fieldsAdded"`
+	FieldsUpdated []catalogFieldChange `json:"fieldsUpdated"`
+	FieldsDeleted []catalogFieldChange `json:"fieldsDeleted"`
+}
+
+type catalogFieldChange struct {
+	Name     string      `json:"name"`
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+// catalogChangeEvents compares the state of a schema before and after a push,
+// returning an event for each table which was created, altered, or dropped.
+// before may be nil if the schema did not previously exist.
+func catalogChangeEvents(before, after *tengo.Schema, service string, timestamp int64) []catalogChangeEvent {
+	fqn := func(tableName string) string {
+		return strings.Join([]string{service, after.Name, tableName}, ".")
+	}
+	beforeTables := make(map[string]*jsonTable)
+	if before != nil {
+		for _, table := range before.Tables {
+			beforeTables[table.Name] = newJSONTable(table)
+		}
+	}
+	afterTables := make(map[string]*jsonTable, len(after.Tables))
+	var names []string
+	for _, table := range after.Tables {
+		afterTables[table.Name] = newJSONTable(table)
+		names = append(names, table.Name)
+	}
+	for name := range beforeTables {
+		if afterTables[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var events []catalogChangeEvent
+	for _, name := range names {
+		event := catalogChangeEvent{
+			EntityType:               "table",
+			EntityFullyQualifiedName: fqn(name),
+			Timestamp:                timestamp,
+		}
+		oldTable, newTable := beforeTables[name], afterTables[name]
+		if oldTable == nil {
+			event.EventType = "entityCreated"
+			event.Entity = newTable
+		} else if newTable == nil {
+			event.EventType = "entityDeleted"
+		} else if desc := catalogTableChanges(oldTable, newTable); desc != nil {
+			event.EventType = "entityUpdated"
+			event.ChangeDescription = desc
+			event.Entity = newTable
+		} else {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// catalogTableChanges returns a description of the differences between two
+// versions of a table, or nil if the versions are equivalent.
+func catalogTableChanges(oldTable, newTable *jsonTable) *catalogChangeDescription {
+	desc := &catalogChangeDescription{
+		FieldsAdded:   []catalogFieldChange{},
+		FieldsUpdated: []catalogFieldChange{},
+		FieldsDeleted: []catalogFieldChange{},
+	}
+	oldColumns := make(map[string]*jsonColumn, len(oldTable.Columns))
+	for _, col := range oldTable.Columns {
+		oldColumns[col.Name] = col
+	}
+	newColumns := make(map[string]bool, len(newTable.Columns))
+	for _, col := range newTable.Columns {
+		newColumns[col.Name] = true
+		if oldCol := oldColumns[col.Name]; oldCol == nil {
+			desc.FieldsAdded = append(desc.FieldsAdded, catalogFieldChange{Name: "columns", NewValue: col})
+		} else if !reflect.DeepEqual(oldCol, col) {
+			desc.FieldsUpdated = append(desc.FieldsUpdated, catalogFieldChange{Name: "columns", OldValue: oldCol, NewValue: col})
+		}
+	}
+	for _, col := range oldTable.Columns {
+		if !newColumns[col.Name] {
+			desc.FieldsDeleted = append(desc.FieldsDeleted, catalogFieldChange{Name: "columns", OldValue: col})
+		}
+	}
+	if oldTable.Comment != newTable.Comment {
+		desc.FieldsUpdated = append(desc.FieldsUpdated, catalogFieldChange{Name: "description", OldValue: oldTable.Comment, NewValue: newTable.Comment})
+	}
+	if !reflect.DeepEqual(oldTable.PrimaryKey, newTable.PrimaryKey) || !reflect.DeepEqual(oldTable.Indexes, newTable.Indexes) || !reflect.DeepEqual(oldTable.ForeignKeys, newTable.ForeignKeys) {
+		desc.FieldsUpdated = append(desc.FieldsUpdated, catalogFieldChange{Name: "tableConstraints"})
+	}
+	if len(desc.FieldsAdded)+len(desc.FieldsUpdated)+len(desc.FieldsDeleted) == 0 {
+		return nil
+	}
+	return desc
+}
+
+// filterSchema returns a copy of schema excluding any tables and routines
+// matching ignoreOpts.
+func filterSchema(schema *tengo.Schema, ignoreOpts fs.IgnoreOptions) *tengo.Schema {
+	if schema == nil {
+		return nil
+	}
+	result := &tengo.Schema{
+		Name:      schema.Name,
+		CharSet:   schema.CharSet,
+		Collation: schema.Collation,
+	}
+	for _, table := range schema.Tables {
+		if !ignoreOpts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}) {
+			result.Tables = append(result.Tables, table)
+		}
+	}
+	for _, routine := range schema.Routines {
+		if !ignoreOpts.ShouldIgnore(tengo.ObjectKey{Type: routine.Type, Name: routine.Name}) {
+			result.Routines = append(result.Routines, routine)
+		}
+	}
+	return result
+}
+
+// notifyCatalog sends the updated schema model of each pushed target to the
+// URL in the target's catalog-webhook option, if set. Failures are logged, but
+// do not affect the command's exit code, since the push itself has already
+// completed.
+func notifyCatalog(targets []*applier.Target) {
+	for _, t := range targets {
+		url := t.Dir.Config.Get("catalog-webhook")
+		if url == "" {
+			continue
+		}
+		if err := sendCatalogPayload(url, t); err != nil {
+			log.Errorf("Unable to notify catalog-webhook for %s %s: %s", t.Instance, t.SchemaFromDir.Name, err)
+		} else {
+			log.Infof("Notified catalog-webhook of changes to %s %s", t.Instance, t.SchemaFromDir.Name)
+		}
+	}
+}
+
+func sendCatalogPayload(url string, t *applier.Target) error {
+	ignoreOpts, err := t.Dir.IgnoreOptions()
+	if err != nil {
+		return err
+	}
+	after, err := t.Instance.Schema(t.SchemaFromDir.Name)
+	if err != nil {
+		return err
+	}
+	after = filterSchema(after, ignoreOpts)
+	now := time.Now()
+	timestamp := now.UnixNano() / int64(time.Millisecond)
+	service := t.Dir.Config.Get("catalog-service")
+	payload := catalogPayload{
+		Source:       "skeema",
+		Timestamp:    timestamp,
+		Environment:  t.Dir.Config.Get("environment"),
+		Instance:     t.Instance.String(),
+		Service:      service,
+		ChangeEvents: catalogChangeEvents(filterSchema(t.SchemaFromInstance, ignoreOpts), after, service, timestamp),
+		Schema:       newJSONSchema(t.Dir.RelPath(), after),
+	}
+	if payload.ChangeEvents == nil {
+		payload.ChangeEvents = []catalogChangeEvent{}
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("SKEEMA_CATALOG_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestCatalogChangeEvents(t *testing.T) {
+	before := docsTestSchema()
+	after := docsTestSchema()
+
+	// Drop posts, add tags, and alter users by adding a column, removing a
+	// column, and changing the table comment
+	users := after.Tables[0]
+	users.Columns = []*tengo.Column{users.Columns[0], {Name: "email", TypeInDB: "varchar(100)", Default: tengo.ColumnDefaultNull}}
+	users.SecondaryIndexes = nil
+	users.Comment = "All users"
+	after.Tables = []*tengo.Table{users, {Name: "tags", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int", Default: tengo.ColumnDefaultNull}}}}
+
+	events := catalogChangeEvents(before, after, "mysql", 1234)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, instead found %d: %+v", len(events), events)
+	}
+	if e := events[0]; e.EventType != "entityDeleted" || e.EntityFullyQualifiedName != "mysql.product.posts" || e.Entity != nil {
+		t.Errorf("Unexpected first event: %+v", e)
+	}
+	if e := events[1]; e.EventType != "entityCreated" || e.EntityFullyQualifiedName != "mysql.product.tags" || e.Entity == nil || e.Timestamp != 1234 {
+		t.Errorf("Unexpected second event: %+v", e)
+	}
+	e := events[2]
+	if e.EventType != "entityUpdated" || e.EntityFullyQualifiedName != "mysql.product.users" || e.ChangeDescription == nil {
+		t.Fatalf("Unexpected third event: %+v", e)
+	}
+	desc := e.ChangeDescription
+	if len(desc.FieldsAdded) != 1 || desc.FieldsAdded[0].NewValue.(*jsonColumn).Name != "email" {
+		t.Errorf("Unexpected fieldsAdded: %+v", desc.FieldsAdded)
+	}
+	if len(desc.FieldsDeleted) != 1 || desc.FieldsDeleted[0].OldValue.(*jsonColumn).Name != "name" {
+		t.Errorf("Unexpected fieldsDeleted: %+v", desc.FieldsDeleted)
+	}
+	if len(desc.FieldsUpdated) != 2 || desc.FieldsUpdated[0].Name != "description" || desc.FieldsUpdated[1].Name != "tableConstraints" {
+		t.Errorf("Unexpected fieldsUpdated: %+v", desc.FieldsUpdated)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error from json.Marshal: %s", err)
+	}
+	if out := string(data); !strings.Contains(out, `"fieldsAdded":[{"name":"columns","newValue":{"name":"email"`) {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+
+	// No events if nothing changed; all tables created if schema is new
+	if events := catalogChangeEvents(after, after, "mysql", 1234); len(events) != 0 {
+		t.Errorf("Expected no events for identical schemas, instead found %+v", events)
+	}
+	if events := catalogChangeEvents(nil, after, "mysql", 1234); len(events) != 2 || events[0].EventType != "entityCreated" || events[1].EventType != "entityCreated" {
+		t.Errorf("Expected 2 entityCreated events for new schema, instead found %+v", events)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -21,7 +22,7 @@ host and port, or perhaps a "local" environment pointing at localhost and a
 socket path.`
 
 	cmd := mybase.NewCommand("add-environment", summary, desc, AddEnvHandler)
-	cmd.AddOption(mybase.StringOption("host", 'h', "", "Database hostname or IP address"))
+	cmd.AddOption(mybase.StringOption("host", 'h', "", "Database hostname, IP address, or socket file path"))
 	cmd.AddOption(mybase.StringOption("port", 'P', "3306", "Port to use for database host"))
 	cmd.AddOption(mybase.StringOption("socket", 'S', "/tmp/mysql.sock", "Absolute path to Unix socket file used if host is localhost"))
 	cmd.AddOption(mybase.StringOption("dir", 'd', ".", "Base dir for this host's schemas"))
@@ -77,7 +78,10 @@ func AddEnvHandler(cfg *mybase.Config) error {
 	}
 
 	dir.OptionFile.SetOptionValue(environment, "host", inst.Host)
-	if inst.Host == "localhost" && inst.SocketPath != "" {
+	if util.IsDiscoveryHost(cfg.Get("host")) {
+		// Persist the service discovery reference, rather than its current result
+		dir.OptionFile.SetOptionValue(environment, "host", cfg.Get("host"))
+	} else if inst.Host == "localhost" && inst.SocketPath != "" {
 		dir.OptionFile.SetOptionValue(environment, "socket", inst.SocketPath)
 	} else {
 		dir.OptionFile.SetOptionValue(environment, "port", strconv.Itoa(inst.Port))
@@ -87,7 +91,7 @@ func AddEnvHandler(cfg *mybase.Config) error {
 	} else {
 		dir.OptionFile.SetOptionValue(environment, "flavor", flavor.String())
 	}
-	for _, persistOpt := range []string{"user", "ignore-schema", "ignore-table", "connect-options"} {
+	for _, persistOpt := range []string{"user", "consul-addr", "ignore-schema", "schema-map", "ignore-table", "ignore-proc", "ignore-func", "ignore-object-type", "connect-options"} {
 		if cfg.OnCLI(persistOpt) {
 			dir.OptionFile.SetOptionValue(environment, persistOpt, cfg.Get(persistOpt))
 		}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Convert the filesystem to an Atlas HCL schema definition"
+	desc := `Outputs an Atlas-style HCL definition of each schema in the filesystem
+representation, permitting evaluation of or migration to Atlas without
+rewriting table definitions by hand. Each table's columns, primary key,
+secondary indexes, and foreign keys are converted. Stored procedures and
+functions are not converted, nor are table partitioning clauses or other
+table options besides character set, collation, engine, and comment. Objects
+matching ignore options are excluded.
+
+The output is written to STDOUT, with one schema block per schema directory.
+Tables are sorted by name, so that output is deterministic.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all schemas were converted successfully,
+or 2+ if any error occurred. Schemas which could be processed are still output
+in the latter case.`
+
+	cmd := mybase.NewCommand("export-atlas", summary, desc, ExportAtlasHandler)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+
+	summary = "Populate a schema dir from an Atlas HCL schema definition"
+	desc = `Populates the current schema directory with *.sql files converted from the
+tables of an Atlas-style HCL schema file, permitting migration from Atlas
+without rewriting table definitions by hand. One CREATE TABLE file is written
+per table, as with ` + "`" + `skeema init` + "`" + `.
+
+If the HCL file defines multiple schemas, only tables in the schema matching
+the current directory's schema option are converted.
+
+Only table, column, primary_key, index, and foreign_key blocks are converted;
+other block types are ignored. Column types are written as specified in the
+HCL, so the generated CREATE TABLE statements are not necessarily in the
+canonical format of SHOW CREATE TABLE. Run ` + "`" + `skeema format` + "`" + ` or
+` + "`" + `skeema push` + "`" + ` followed by ` + "`" + `skeema pull` + "`" + ` to normalize them.
+
+The current directory must already be a schema directory, and must not contain
+any *.sql files.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. If no environment
+name is supplied, the default is "production".`
+
+	cmd = mybase.NewCommand("import-atlas", summary, desc, ImportAtlasHandler)
+	cmd.AddArg("hcl-file", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ExportAtlasHandler is the handler method for `skeema export-atlas`
+func ExportAtlasHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	var schemas []*tengo.Schema
+	skipCount := execDirSchemasWalker(dir, 5, &schemas)
+	w := bufio.NewWriter(os.Stdout)
+	writeAtlasHCL(w, schemas)
+	if err := w.Flush(); err != nil {
+		return NewExitValue(CodeFatalError, "Unable to write HCL: %s", err)
+	}
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// ImportAtlasHandler is the handler method for `skeema import-atlas`
+func ImportAtlasHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if !dir.HasSchema() {
+		return NewExitValue(CodeBadConfig, "Dir %s does not define a schema; `skeema import-atlas` must be run in a schema dir", dir)
+	} else if len(dir.SQLFiles) > 0 {
+		return NewExitValue(CodeBadUsage, "Dir %s already contains *.sql files; `skeema import-atlas` must be run in a schema dir without any *.sql files", dir)
+	}
+
+	hclFile := cfg.Get("hcl-file")
+	contents, err := ioutil.ReadFile(hclFile)
+	if err != nil {
+		return NewExitValue(CodeNoInput, "Unable to read %s: %s", hclFile, err)
+	}
+	body, err := parseHCL(string(contents))
+	if err != nil {
+		return NewExitValue(CodeBadInput, "Unable to parse %s: %s", hclFile, err)
+	}
+	schema, err := atlasSchemaFromHCL(body, dir.Config.Get("schema"))
+	if err != nil {
+		return NewExitValue(CodeBadInput, "Unable to convert %s: %s", hclFile, err)
+	} else if len(schema.Tables) == 0 {
+		return NewExitValue(CodeNoInput, "No tables found in %s", hclFile)
+	}
+	log.Infof("Converted %d table%s from %s", len(schema.Tables), plural(len(schema.Tables)), hclFile)
+	return PopulateSchemaDir(schema, dir, false)
+}
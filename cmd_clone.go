@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+)
+
+func init() {
+	summary := "Alter schemas in one environment to match another environment"
+	desc := `Modifies the schemas of one environment to match the live schemas of another
+environment. For example, running ` + "`" + `skeema clone production staging` + "`" + ` will
+introspect the schemas that each directory maps to in the [production] section of
+config files, and then apply the necessary DDL to the schemas that the same
+directories map to in the [staging] section. This is useful for refreshing a
+staging environment to match the structure of production.
+
+By default, the *.sql files are not used or modified at all; only the directory
+structure and configuration are used to determine which schemas correspond to
+each other. With --via-dir, the *.sql files are used as an intermediate instead:
+they are first updated to reflect the source environment, in the same manner as
+` + "`" + `skeema pull` + "`" + `, and then pushed to the target environment. The files are updated
+even if --dry-run is also used.
+
+All options of ` + "`" + `skeema push` + "`" + ` may be used with this command, and apply to the
+target environment. In particular, --dry-run may be used to output the DDL
+without running it, and --allow-unsafe is required for destructive changes.
+
+An exit code of 0 will be returned if the operation was successful, or 2+ if an
+error occurred. With --dry-run, an exit code of 1 indicates that some differences
+were found.`
+
+	cmd := mybase.NewCommand("clone", summary, desc, CloneHandler)
+	cmd.AddOption(mybase.BoolOption("via-dir", 0, false, "Update *.sql files to match source environment, and then push them to target environment"))
+	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "With --via-dir, include starting auto-inc values in new table files, and update in existing files"))
+	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "With --via-dir, reformat SQL statements to match canonical SHOW CREATE").Hidden())
+	cmd.AddOption(mybase.BoolOption("new-schemas", 0, true, "With --via-dir, detect any new schemas and populate new dirs for them"))
+	cmd.AddOption(mybase.StringOption("tables", 0, "", "<pull option, not supported by clone>").Hidden())
+	cmd.AddOption(mybase.BoolOption("only-new", 0, false, "<pull option, not supported by clone>").Hidden())
+	cmd.AddOption(mybase.BoolOption("only-changed", 0, false, "<pull option, not supported by clone>").Hidden())
+	cmd.AddArg("source-environment", "", true)
+	cmd.AddArg("environment", "", true)
+	CommandSuite.AddSubCommand(cmd)
+	clonePushOptionsToClone()
+}
+
+// CloneHandler is the handler method for `skeema clone`
+func CloneHandler(cfg *mybase.Config) error {
+	if cfg.Get("source-environment") == cfg.Get("environment") {
+		return NewExitValue(CodeBadUsage, "Source and target environments must differ")
+	}
+	sourceConfig := sourceEnvironmentConfig(cfg)
+
+	if cfg.GetBool("via-dir") {
+		sourceDir, err := fs.ParseDir(".", sourceConfig)
+		if err != nil {
+			return err
+		}
+		// If any dirs could not be updated, don't push a partial state
+		if _, skipCount, err := pullWalker(sourceDir, 5); err != nil {
+			return err
+		} else if skipCount > 0 {
+			var plural string
+			if skipCount > 1 {
+				plural = "s"
+			}
+			return NewExitValue(CodePartialError, "Skipped %d operation%s due to error%s; not proceeding with push to environment \"%s\"", skipCount, plural, plural, cfg.Get("environment"))
+		}
+	}
+
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	var targets []*applier.Target
+	var skipCount int
+	if cfg.GetBool("via-dir") {
+		targets, skipCount = applier.TargetsForDir(dir, 5)
+	} else {
+		targets, skipCount = applier.CloneTargetsForDir(dir, sourceConfig, 5)
+	}
+	return applyTargets(dir, targets, skipCount, nil)
+}
+
+// sourceEnvironmentConfig returns a config equivalent to cfg, but selecting
+// the source environment instead of the target environment. Global option
+// files are re-read, so that their sections are selected accordingly.
+func sourceEnvironmentConfig(cfg *mybase.Config) *mybase.Config {
+	sourceEnv := cfg.Get("source-environment")
+	return configWithArgs(cfg, sourceEnv, sourceEnv)
+}
+
+// clonePushOptionsToClone copies options from `skeema push` into `skeema clone`
+func clonePushOptionsToClone() {
+	hiddenRewrites := map[string]bool{
+		"snapshot":      true,
+		"snapshot-file": true,
+	}
+	copyPushOptions("clone", nil, hiddenRewrites)
+}
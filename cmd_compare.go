@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Output DDL to transform one directory's schemas into another's"
+	desc := `Compares two filesystem representations of the same schemas, such as two git
+worktrees or checkouts of different branches, and outputs the DDL that would
+transform the schemas declared in from-dir into the ones declared in to-dir.
+This answers "what DDL will this change generate" without needing access to any
+shared database server.
+
+Subdirectories of from-dir and to-dir are matched up by their relative path.
+Each side's *.sql files are loaded into a workspace, in the same manner as
+` + "`" + `skeema lint` + "`" + `, and the resulting schemas are compared. To avoid using any real
+database server, configure workspace=docker along with a flavor; otherwise, a
+temporary schema is used on the first instance that each directory maps to.
+
+Options affecting DDL generation, such as allow-unsafe, alter-algorithm, and
+exact-match, are obtained from the configuration of the to-dir side.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. If no environment
+name is supplied, the default is "production".
+
+An exit code of 0 will be returned if no differences were found, 1 if some
+differences were found, or 2+ if an error occurred.`
+
+	cmd := mybase.NewCommand("compare", summary, desc, CompareHandler)
+	addCompareOptions(cmd)
+	cmd.AddArg("from-dir", "", true)
+	cmd.AddArg("to-dir", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// addCompareOptions adds the options affecting DDL generation to cmd. These
+// are a subset of the options of `skeema diff`, since the generated DDL is
+// only output, never run.
+func addCompareOptions(cmd *mybase.Command) {
+	cmd.AddOption(mybase.BoolOption("allow-unsafe", 0, false, "Permit generating ALTER or DROP operations that are potentially destructive"))
+	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Follow *.sql table definitions exactly, even for differences with no functional impact"))
+	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
+	cmd.AddOption(mybase.StringOption("alter-lock", 0, "", `Apply a LOCK clause to all ALTER TABLEs (valid values: "NONE", "SHARED", "EXCLUSIVE")`))
+	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "INPLACE", "COPY", "INSTANT")`))
+	cmd.AddOption(mybase.BoolOption("brief", 0, false, "<push option, not applicable>").Hidden())
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, true, "<push option, not applicable>").Hidden())
+}
+
+// CompareHandler is the handler method for `skeema compare`
+func CompareHandler(cfg *mybase.Config) error {
+	for _, arg := range []string{"from-dir", "to-dir"} {
+		if fi, err := os.Stat(cfg.Get(arg)); err != nil {
+			return NewExitValue(CodeNoInput, "Unable to use %s %s: %s", arg, cfg.Get(arg), err)
+		} else if !fi.IsDir() {
+			return NewExitValue(CodeNoInput, "Unable to use %s %s: not a directory", arg, cfg.Get(arg))
+		}
+	}
+	fromDir, err := fs.ParseDir(cfg.Get("from-dir"), cfg)
+	if err != nil {
+		return err
+	}
+	toDir, err := fs.ParseDir(cfg.Get("to-dir"), cfg)
+	if err != nil {
+		return err
+	}
+	return compareDirs(fromDir, toDir)
+}
+
+// compareDirs outputs the DDL that transforms the schemas declared in fromDir
+// and its subdirs into the ones declared in toDir and its subdirs, matching up
+// subdirs by relative path. The returned error is suitable for use as the
+// command's exit value.
+func compareDirs(fromDir, toDir *fs.Dir) error {
+	fromDirs, fromSkipCount := compareSchemaDirs(fromDir, 5)
+	toDirs, toSkipCount := compareSchemaDirs(toDir, 5)
+	skipCount := fromSkipCount + toSkipCount
+	relPaths := make([]string, 0, len(toDirs))
+	for relPath := range toDirs {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath := range fromDirs {
+		if toDirs[relPath] == nil {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	sort.Strings(relPaths)
+
+	var differences bool
+	for _, relPath := range relPaths {
+		statements, err := compareDirPair(fromDirs[relPath], toDirs[relPath])
+		if err != nil {
+			log.Errorf("Skipping %s: %s", relPath, err)
+			skipCount++
+			continue
+		}
+		if len(statements) == 0 {
+			log.Infof("%s: No differences found", relPath)
+			continue
+		}
+		differences = true
+		fmt.Printf("-- dir: %s\n", relPath)
+		for _, stmt := range statements {
+			fmt.Print(fs.AddDelimiter(stmt))
+		}
+	}
+
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	} else if differences {
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
+
+// compareSchemaDirs returns dir and its subdirs, up to maxDepth levels deep,
+// which define a schema. The result is keyed by path relative to dir. The
+// second return value is the number of dirs which could not be processed due
+// to errors.
+func compareSchemaDirs(dir *fs.Dir, maxDepth int) (dirs map[string]*fs.Dir, skipCount int) {
+	dirs = make(map[string]*fs.Dir)
+	var walk func(d *fs.Dir, depth int)
+	walk = func(d *fs.Dir, depth int) {
+		if d.HasSchema() {
+			relPath, err := filepath.Rel(dir.Path, d.Path)
+			if err != nil {
+				log.Errorf("Skipping %s: %s", d, err)
+				skipCount++
+			} else {
+				dirs[filepath.ToSlash(relPath)] = d
+			}
+		}
+		subdirs, badCount, err := d.Subdirs()
+		if err != nil {
+			log.Errorf("Cannot list subdirs of %s: %s", d, err)
+			skipCount++
+		} else if len(subdirs) > 0 && depth >= maxDepth {
+			log.Warnf("Not walking subdirs of %s: max depth reached", d)
+			skipCount += len(subdirs)
+			return
+		}
+		skipCount += badCount
+		for _, sub := range subdirs {
+			walk(sub, depth+1)
+		}
+	}
+	walk(dir, 0)
+	return dirs, skipCount
+}
+
+// compareDirPair returns the DDL statements that transform the schema declared
+// by fromDir into the one declared by toDir. Either dir may be nil, indicating
+// the schema does not exist on that side.
+func compareDirPair(fromDir, toDir *fs.Dir) ([]string, error) {
+	fromSchema, err := compareWorkspaceSchema(fromDir)
+	if err != nil {
+		return nil, err
+	}
+	toSchema, err := compareWorkspaceSchema(toDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options affecting DDL generation come from the to-dir side, unless the dir
+	// only exists on the from-dir side
+	configDir := toDir
+	if configDir == nil {
+		configDir = fromDir
+	}
+	return compareStatements(fromSchema, toSchema, configDir, tengo.NewFlavor(configDir.Config.Get("flavor")))
+}
+
+// compareStatements returns the DDL statements that transform from into to,
+// using dir's configuration to determine statement modifiers and ignored
+// objects. Either schema may be nil. Objects with unsupported differences are
+// logged and omitted. Destructive statements result in an error unless
+// allow-unsafe is enabled.
+func compareStatements(from, to *tengo.Schema, dir *fs.Dir, flavor tengo.Flavor) ([]string, error) {
+	mods, err := applier.StatementModifiersForDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	mods.Flavor = flavor
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	for _, objDiff := range tengo.NewSchemaDiff(from, to).ObjectDiffs() {
+		if ignoreOpts.ShouldIgnore(objDiff.ObjectKey()) {
+			continue
+		}
+		stmt, err := objDiff.Statement(mods)
+		if tengo.IsForbiddenDiff(err) {
+			return nil, fmt.Errorf("Destructive statement /* %s */ is considered unsafe. Use --allow-unsafe to permit this operation.", stmt)
+		} else if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
+			log.Warnf("Skipping %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey)
+			applier.DebugLogUnsupportedDiff(unsupportedErr)
+		} else if err != nil {
+			return nil, err
+		} else if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements, nil
+}
+
+// compareWorkspaceSchema executes dir's *.sql files in a workspace, returning
+// the introspected schema. If dir is nil, or has no *.sql files, a nil schema
+// is returned. Any errors in the *.sql files are considered fatal, since they
+// would cause the comparison to be inaccurate.
+func compareWorkspaceSchema(dir *fs.Dir) (*tengo.Schema, error) {
+	if dir == nil {
+		return nil, nil
+	}
+	var logicalSchema *fs.LogicalSchema
+	for _, ls := range dir.LogicalSchemas {
+		if ls.Name == "" {
+			logicalSchema = ls
+		}
+	}
+	if logicalSchema == nil {
+		return nil, nil
+	}
+
+	opts, err := standaloneWorkspaceOptions(dir)
+	if err != nil {
+		return nil, err
+	}
+	opts.LoadSeeds = false
+	schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmtErr := range statementErrors {
+		log.Error(stmtErr.Error())
+	}
+	if len(statementErrors) > 0 {
+		return nil, fmt.Errorf("%d statement%s in %s could not be executed", len(statementErrors), plural(len(statementErrors)), dir)
+	}
+	return schema, nil
+}
+
+// standaloneWorkspaceOptions returns workspace options for dir, for use by
+// commands which need a workspace but do not otherwise interact with a
+// database instance. The workspace uses the dir's first defined instance,
+// unless configured to use local Docker with a specific flavor.
+func standaloneWorkspaceOptions(dir *fs.Dir) (workspace.Options, error) {
+	var inst *tengo.Instance
+	var err error
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		if inst, err = dir.FirstInstance(); err != nil {
+			return workspace.Options{}, err
+		} else if inst == nil {
+			return workspace.Options{}, fmt.Errorf("No instance defined for %s, and workspace=docker is not configured", dir)
+		}
+	}
+	return workspace.OptionsForDir(dir, inst)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skeema/mybase"
+)
+
+func init() {
+	summary := "Output a shell completion script"
+	desc := `Outputs a script which enables tab-completion of skeema commands, option names,
+and environment names in the supplied shell, which must be one of bash, zsh, or
+fish. For example, add ` + "`" + `source <(skeema completion bash)` + "`" + ` to ~/.bashrc, or
+` + "`" + `source <(skeema completion zsh)` + "`" + ` to ~/.zshrc after compinit. For fish, run
+` + "`" + `skeema completion fish > ~/.config/fish/completions/skeema.fish` + "`" + `.
+
+Environment names are completed dynamically, based on the sections of the
+.skeema files in the current directory and its subdirectories at the time of
+completion.`
+
+	cmd := mybase.NewCommand("completion", summary, desc, CompletionHandler)
+	cmd.AddOption(mybase.BoolOption("list-environments", 0, false, "Output names of environments defined in option files, instead of a script").Hidden())
+	cmd.AddArg("shell", "", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// CompletionHandler is the handler method for `skeema completion`
+func CompletionHandler(cfg *mybase.Config) error {
+	if cfg.GetBool("list-environments") {
+		// Option files may contain options of any command, so they must all be
+		// resolvable in order to parse them.
+		adoptAllOptions(cfg.CLI.Command, CommandSuite)
+		cfg.MarkDirty()
+		for _, environment := range completionEnvironments(".", cfg) {
+			fmt.Println(environment)
+		}
+		return nil
+	}
+
+	script, err := completionScript(cfg.Get("shell"), CommandSuite)
+	if err != nil {
+		return NewExitValue(CodeBadUsage, err.Error())
+	}
+	fmt.Print(script)
+	return nil
+}
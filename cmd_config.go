@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Inspect or validate configuration"
+	desc := `Commands for inspecting or validating the .skeema option files of a schema repo.`
+	suite := mybase.NewCommandSuite("config", summary, desc)
+
+	summary = "Validate option files without connecting to any database server"
+	desc = `Parses the .skeema files of the current directory and all of its
+subdirectories, and reports any configuration problems: unknown options, invalid
+or conflicting option values, and environments which cannot be used in some
+directories because no host is defined for them. The effective option values for
+each directory are also displayed, along with which source set each one.
+
+No database server is contacted, and no external commands (such as
+password-command or host-wrapper) are executed.
+
+You may optionally pass an environment name as a CLI arg. This affects which
+section of .skeema config files is used for displaying effective option values.
+For example, ` + "`" + `skeema config check staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production". Problems affecting other environments defined in the option files
+are reported as well.
+
+An exit code of 0 will be returned if no problems were found, 1 if only
+warnings were found, or 2+ if errors were found.`
+
+	cmd := mybase.NewCommand("check", summary, desc, ConfigCheckHandler)
+	cmd.AddArg("environment", "production", false)
+	suite.AddSubCommand(cmd)
+	CommandSuite.AddSubCommand(suite)
+}
+
+// ConfigCheckHandler is the handler method for `skeema config check`
+func ConfigCheckHandler(cfg *mybase.Config) error {
+	// Option files may contain options of any command, so they must all be
+	// resolvable in order to validate and display effective values.
+	adoptAllOptions(cfg.CLI.Command, CommandSuite)
+	cfg.MarkDirty()
+
+	// Querying the OS keyring may shell out to an external command, so keyring
+	// references are validated syntactically but never resolved
+	util.DisableKeyringExpansion()
+
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	parentFiles, err := fs.ParentOptionFiles(dir.Path, cfg)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	cc := &configChecker{
+		cfg:          cfg,
+		environments: make(map[string]bool),
+	}
+	for _, f := range parentFiles {
+		cc.addEnvironments(f)
+	}
+	cc.checkDir(dir)
+
+	// Check whether each other environment defined in any option file can be
+	// used in every dir that maps to a schema
+	environments := make([]string, 0, len(cc.environments))
+	for environment := range cc.environments {
+		if environment != cfg.Get("environment") {
+			environments = append(environments, environment)
+		}
+	}
+	sort.Strings(environments)
+	for _, environment := range environments {
+		cc.checkEnvironment(environment)
+	}
+
+	if cc.errCount > 0 {
+		return NewExitValue(CodeBadConfig, "Found %d configuration error%s and %d warning%s", cc.errCount, plural(cc.errCount), cc.warnCount, plural(cc.warnCount))
+	} else if cc.warnCount > 0 {
+		return NewExitValue(CodeDifferencesFound, "Found %d configuration warning%s", cc.warnCount, plural(cc.warnCount))
+	}
+	log.Info("No configuration problems found")
+	return nil
+}
+
+// adoptAllOptions adds to cmd any option defined by some other command in the
+// suite, so that cmd's Config can look up any option that may be set in an
+// option file.
+func adoptAllOptions(cmd, suite *mybase.Command) {
+	existing := cmd.Options()
+	for _, sub := range suite.SubCommands {
+		for name, opt := range sub.Options() {
+			if _, already := existing[name]; !already {
+				cmd.AddOption(opt)
+				existing[name] = opt
+			}
+		}
+		adoptAllOptions(cmd, sub)
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// configChecker tracks state while walking a directory tree, validating each
+// dir's configuration.
+type configChecker struct {
+	cfg          *mybase.Config
+	environments map[string]bool // names of all sections seen in any option file
+	schemaDirs   []string        // paths of dirs that map to a schema in the checked environment
+	errCount     int
+	warnCount    int
+}
+
+func (cc *configChecker) errorf(dir *fs.Dir, format string, a ...interface{}) {
+	log.Errorf("%s: %s", dir.RelPath(), fmt.Sprintf(format, a...))
+	cc.errCount++
+}
+
+func (cc *configChecker) warnf(dir *fs.Dir, format string, a ...interface{}) {
+	log.Warnf("%s: %s", dir.RelPath(), fmt.Sprintf(format, a...))
+	cc.warnCount++
+}
+
+// addEnvironments records the names of all non-default sections in f.
+func (cc *configChecker) addEnvironments(f *mybase.File) {
+	for name := range cc.cfg.CLI.Command.Options() {
+		for _, section := range f.SectionsWithOption(name) {
+			if section != "" {
+				cc.environments[section] = true
+			}
+		}
+	}
+}
+
+// checkDir validates the configuration of dir, outputs its effective option
+// values, and then recursively checks its subdirectories. Subdirectories which
+// cannot be parsed are reported as errors, and not descended into.
+func (cc *configChecker) checkDir(dir *fs.Dir) {
+	if dir.OptionFile != nil {
+		cc.addEnvironments(dir.OptionFile)
+	}
+	cc.printEffectiveOptions(dir)
+	cc.validate(dir)
+	if dir.HasSchema() {
+		cc.schemaDirs = append(cc.schemaDirs, dir.Path)
+		if !dir.Config.Changed("host") {
+			cc.warnf(dir, "Dir maps to a schema, but no host is defined for environment [%s]", dir.Config.Get("environment"))
+		}
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir.Path)
+	if err != nil {
+		cc.errorf(dir, "Unable to read dir: %s", err)
+		return
+	}
+	for _, fi := range fileInfos {
+		if !fi.IsDir() || fi.Name()[0] == '.' || dir.OwnsSubdir(fi.Name()) {
+			continue
+		}
+		subPath := filepath.Join(dir.Path, fi.Name())
+		sub, err := fs.ParseDir(subPath, cc.cfg)
+		if err != nil {
+			log.Errorf("%s: %s", subPath, err)
+			cc.errCount++
+			continue
+		}
+		cc.checkDir(sub)
+	}
+}
+
+// validate performs all checks of dir's option values which can be done
+// without connecting to a database server.
+func (cc *configChecker) validate(dir *fs.Dir) {
+	params, err := dir.InstanceDefaultParams()
+	if err != nil {
+		cc.errorf(dir, "Invalid connection options: %s", err)
+	}
+	if tlsOpts, err := util.TLSOptionsForConfig(dir.Config); err != nil {
+		cc.errorf(dir, "Invalid TLS options: %s", err)
+	} else if v, _ := url.ParseQuery(params); tlsOpts.Mode != "" && v.Get("tls") != "" {
+		cc.errorf(dir, "Invalid TLS options: connect-options tls cannot be combined with ssl-mode, ssl-ca, or ssl-cert")
+	}
+	if _, err := dir.IgnoreOptions(); err != nil {
+		cc.errorf(dir, "%s", err)
+	}
+	if _, err := dir.MappedSubdirName(""); err != nil {
+		cc.errorf(dir, "%s", err)
+	}
+	if _, err := linter.OptionsForDir(dir); err != nil {
+		cc.errorf(dir, "Invalid linter options: %s", err)
+	}
+	if _, err := workspace.OptionsForDir(dir, nil); err != nil {
+		cc.errorf(dir, "Invalid workspace options: %s", err)
+	}
+	if _, err := applier.StatementModifiersForDir(dir); err != nil {
+		cc.errorf(dir, "%s", err)
+	}
+	if _, err := applier.SessionVarsForDir(dir); err != nil {
+		cc.errorf(dir, "%s", err)
+	}
+
+	// Ports supplied inside of host values must not conflict with the port option
+	if dir.Config.Changed("host") && !dir.Config.Changed("host-wrapper") {
+		portValue := dir.Config.GetIntOrDefault("port")
+		for _, host := range dir.Config.GetSlice("host", ',', true) {
+			if filepath.IsAbs(host) || util.IsCloudSQLConnectionName(host) || util.IsDiscoveryHost(host) {
+				continue
+			}
+			if _, splitPort, err := tengo.SplitHostOptionalPort(host); err != nil {
+				cc.errorf(dir, "%s", err)
+			} else if splitPort > 0 && dir.Config.Changed("port") && splitPort != portValue {
+				cc.errorf(dir, "Port was supplied as %d inside hostname %s but as %d in option file", splitPort, host, portValue)
+			}
+		}
+	}
+
+	// Only one source of credentials is used; any others are ignored. These are
+	// listed in order of precedence.
+	var credentialOptions []string
+	for _, name := range []string{"password", "vault-path", "aws-secret", "aws-iam-auth", "cloudsql-iam-auth", "password-command"} {
+		if dir.Config.Changed(name) {
+			credentialOptions = append(credentialOptions, name)
+		}
+	}
+	if len(credentialOptions) > 1 {
+		cc.warnf(dir, "Conflicting credential options %s are all set; only %s will be used", strings.Join(credentialOptions, ", "), credentialOptions[0])
+	}
+	if dir.Config.Changed("aws-iam-auth") && dir.Config.Changed("cloudsql-iam-auth") {
+		cc.errorf(dir, "Options aws-iam-auth and cloudsql-iam-auth cannot be combined")
+	}
+}
+
+// printEffectiveOptions outputs the values of all options which differ from
+// their defaults in dir, along with the source of each value. Passwords are
+// masked.
+func (cc *configChecker) printEffectiveOptions(dir *fs.Dir) {
+	names := make([]string, 0)
+	for name := range dir.Config.CLI.Command.Options() {
+		if dir.Config.Changed(name) && name != "environment" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fmt.Printf("-- %s [%s]\n", dir.RelPath(), dir.Config.Get("environment"))
+	for _, name := range names {
+		value := dir.Config.GetRaw(name)
+		if name == "password" && value != "" {
+			value = "*****"
+		} else if f, ok := dir.Config.Source(name).(*util.EnvExpandedFile); ok {
+			// Don't display secrets obtained from the OS keyring
+			if rawValue, _ := f.File.OptionValue(name); strings.Contains(rawValue, "${keyring:") {
+				value = rawValue
+			}
+		}
+		var source string
+		switch s := dir.Config.Source(name).(type) {
+		case *mybase.CommandLine:
+			source = "command-line"
+		case fmt.Stringer:
+			source = s.String()
+		default:
+			source = "unknown source"
+		}
+		fmt.Printf("%s=%s  # %s\n", name, value, source)
+	}
+	fmt.Println()
+}
+
+// checkEnvironment verifies that every dir which maps to a schema in the
+// checked environment also has a host defined when using environment.
+func (cc *configChecker) checkEnvironment(environment string) {
+	cli := *cc.cfg.CLI
+	cli.ArgValues = []string{environment}
+	envCfg := mybase.NewConfig(&cli)
+	envCfg.IsTest = cc.cfg.IsTest
+	util.AddGlobalConfigFiles(envCfg)
+	for _, dirPath := range cc.schemaDirs {
+		dir, err := fs.ParseDir(dirPath, envCfg)
+		if err != nil {
+			log.Errorf("%s: %s", dirPath, err)
+			cc.errCount++
+		} else if dir.HasSchema() && !dir.Config.Changed("host") {
+			cc.warnf(dir, "Environment [%s] is defined in an option file, but has no host for this dir", environment)
+		}
+	}
+}
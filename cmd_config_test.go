@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/util"
+)
+
+func TestConfigCheckHandler(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+
+	write := func(relPath, contents string) {
+		t.Helper()
+		path := filepath.Join(repoDir, relPath)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := ioutil.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write %s: %s", path, err)
+		}
+	}
+	check := func(expectedExitCode int) {
+		t.Helper()
+		if err := os.Chdir(repoDir); err != nil {
+			t.Fatalf("Unable to cd to %s: %s", repoDir, err)
+		}
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema config check")
+		util.AddGlobalConfigFiles(cfg)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d, instead found %d", expectedExitCode, actual)
+		}
+	}
+
+	os.MkdirAll(filepath.Join(repoDir, ".git"), 0777)
+	write(".skeema", "user=root\n[production]\nhost=db1.example.com\n[staging]\nhost=db2.example.com:3307\n")
+	write("product/.skeema", "schema=product\nallow-charset=utf8mb4\n")
+	write("product/users.sql", "CREATE TABLE users (id int unsigned NOT NULL, PRIMARY KEY (id));\n")
+	check(CodeSuccess)
+
+	// Environment without a host for the schema dir should be a warning
+	write("product/.skeema", "schema=product\n[development]\nallow-charset=latin1\n")
+	check(CodeDifferencesFound)
+
+	// Conflicting credential sources should be a warning
+	write("product/.skeema", "schema=product\npassword=foo\npassword-command=echo bar\n")
+	check(CodeDifferencesFound)
+
+	// Invalid option values, conflicting values, or unknown options are errors
+	badContents := []string{
+		"schema=product\ntemporal-type=bogus\n",
+		"schema=product\nworkspace=bogus\n",
+		"schema=product\nhost=db3.example.com:3307\nport=3308\n",
+		"schema=product\nssl-mode=REQUIRED\nconnect-options=\"tls=true\"\n",
+		"schema=product\nnot-a-real-option=1\n",
+	}
+	for _, contents := range badContents {
+		write("product/.skeema", contents)
+		check(CodeBadConfig)
+	}
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Plan a migration of utf8 tables and columns to a new collation"
+	desc := `Plans a migration of every schema in the filesystem representation from
+legacy utf8 character sets and collations to the supplied collation, for
+example utf8mb4_0900_ai_ci. Tables and columns are converted if their character
+set or collation is listed in --convert-from; by default, this covers utf8
+(utf8mb3) and utf8mb4_general_ci.
+
+For each schema directory, the conversion is first tested by running ALTER
+TABLE ... CONVERT TO CHARACTER SET in a workspace. Any table which cannot be
+converted, for example due to an index exceeding the maximum key length, is
+reported as an error. The *.sql files of converted tables are then rewritten,
+and the directory's default-character-set and default-collation are updated in
+its .skeema file if they were also being converted. Use --dry-run to skip
+rewriting any files.
+
+The sequence of ALTER TABLE statements is written to STDOUT, with a comment
+listing each table's affected indexes and foreign keys. Tables involved in
+foreign keys are converted last, with foreign_key_checks disabled, since both
+sides of a foreign key must be converted before the constraint is consistent
+again. Warnings are logged for indexes which will exceed InnoDB's maximum key
+length, and for foreign keys where only one side is being converted.
+
+Converting a table rebuilds it, and many of these ALTERs are not online
+operations. You may run them via an external online schema change tool, or
+use ` + "`" + `skeema push` + "`" + ` with --alter-wrapper after the files have been rewritten.
+
+This command relies on accessing database instances to test the conversion.
+All DDL will be run against a temporary schema, with no impact on the real
+schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all schemas were processed successfully,
+or 2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("convert-collation", summary, desc, ConvertCollationHandler)
+	cmd.AddOption(mybase.StringOption("convert-from", 0, "utf8,utf8mb3,utf8mb4_general_ci", "Comma-separated character sets and/or collations to convert"))
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Output the conversion plan but don't rewrite any files"))
+	cmd.AddArg("collation", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ConvertCollationHandler is the handler method for `skeema convert-collation`
+func ConvertCollationHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	target := strings.ToLower(cfg.Get("collation"))
+	if !strings.Contains(target, "_") {
+		return NewExitValue(CodeBadUsage, "%s is not a valid collation name", target)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	skipCount := convertCollationWalker(dir, w, target, 5)
+	if err := w.Flush(); err != nil {
+		return NewExitValue(CodeFatalError, "Unable to write conversion plan: %s", err)
+	}
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// convertCollationWalker calls convertDirCollation on dir, and recursively
+// calls itself on any subdirs. It returns the number of dirs which could not
+// be processed due to errors.
+func convertCollationWalker(dir *fs.Dir, w io.Writer, target string, maxDepth int) (skipCount int) {
+	if dir.HasSchema() {
+		if err := convertDirCollation(dir, w, target); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+	}
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += convertCollationWalker(sub, w, target, maxDepth-1)
+		}
+	}
+	return skipCount
+}
+
+// convertDirCollation plans and tests the conversion of dir's tables to the
+// target collation, writes the plan to w, and then rewrites dir's files unless
+// the dry-run option is enabled.
+func convertDirCollation(dir *fs.Dir, w io.Writer, target string) error {
+	var logicalSchema *fs.LogicalSchema
+	for _, ls := range dir.LogicalSchemas {
+		if ls.Name == "" {
+			logicalSchema = ls
+		}
+	}
+	if logicalSchema == nil {
+		return nil
+	}
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return err
+	}
+	opts, err := dirWorkspaceOptions(dir)
+	if err != nil {
+		return err
+	}
+	schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+	if err != nil {
+		return err
+	}
+	for _, stmtErr := range statementErrors {
+		log.Error(stmtErr.Error())
+	}
+	if len(statementErrors) > 0 {
+		return fmt.Errorf("%d statements could not be executed", len(statementErrors))
+	}
+
+	cp := newCollationPlan(schema, strings.Split(dir.Config.Get("convert-from"), ","), target)
+	var conversions []*collationConversion
+	for _, conv := range cp.conversions {
+		if !ignoreOpts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: conv.table.Name}) {
+			conversions = append(conversions, conv)
+		}
+	}
+	cp.conversions = conversions
+	convertSchemaDefault := cp.matches(logicalSchema.CharSet, logicalSchema.Collation)
+	if len(cp.conversions) == 0 && !convertSchemaDefault {
+		log.Infof("%s: no tables require conversion to %s", dir, target)
+		return nil
+	}
+	for _, warning := range cp.warnings() {
+		log.Warnf("%s: %s", dir, warning)
+	}
+
+	// Test the conversion by running the ALTERs in a workspace after the dir's
+	// own statements
+	testSchema := *logicalSchema
+	if convertSchemaDefault {
+		testSchema.CharSet, testSchema.Collation = cp.charSet, cp.collation
+	}
+	testSchema.Alters = append([]*fs.Statement{}, logicalSchema.Alters...)
+	for _, conv := range cp.conversions {
+		testSchema.Alters = append(testSchema.Alters, &fs.Statement{
+			Text:       cp.alterStatement(conv.table),
+			Type:       fs.StatementTypeAlter,
+			ObjectType: tengo.ObjectTypeTable,
+			ObjectName: conv.table.Name,
+		})
+	}
+	converted, statementErrors, err := workspace.ExecLogicalSchema(&testSchema, opts)
+	if err != nil {
+		return err
+	}
+	for _, stmtErr := range statementErrors {
+		log.Errorf("Conversion failed for table %s: %s", tengo.EscapeIdentifier(stmtErr.ObjectName), stmtErr.Err)
+	}
+	if len(statementErrors) > 0 {
+		return fmt.Errorf("%d table%s could not be converted", len(statementErrors), plural(len(statementErrors)))
+	}
+
+	fmt.Fprintf(w, "-- %s\n", dir)
+	cp.writeStatements(w)
+	if dir.Config.GetBool("dry-run") {
+		return nil
+	}
+
+	// Rewrite files of converted tables using their definitions from the test
+	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
+	definitions := converted.ObjectDefinitions()
+	for _, conv := range cp.conversions {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: conv.table.Name}
+		stmt := logicalSchema.Creates[key]
+		if stmt == nil || definitions[key] == "" {
+			continue
+		}
+		_, suffix := stmt.SplitTextBody()
+		stmt.Text = definitions[key] + suffix
+		filesToRewrite[stmt.FromFile] = true
+	}
+	for file := range filesToRewrite {
+		if _, err := file.Rewrite(); err != nil {
+			return fmt.Errorf("Unable to write %s: %s", file.Path(), err)
+		}
+		log.Infof("Wrote %s", file.Path())
+	}
+	if convertSchemaDefault {
+		dir.OptionFile.SetOptionValue("", "default-character-set", cp.charSet)
+		dir.OptionFile.SetOptionValue("", "default-collation", cp.collation)
+		if err := dir.OptionFile.Write(true); err != nil {
+			return fmt.Errorf("Unable to write %s: %s", dir.OptionFile.Path(), err)
+		}
+		log.Infof("Wrote %s -- updated schema-level default-character-set and default-collation", dir.OptionFile.Path())
+	}
+	return nil
+}
+
+// collationConversion describes the conversion of a single table.
+type collationConversion struct {
+	table       *tengo.Table
+	columns     []*tengo.Column // string columns using a converted collation
+	indexes     []*tengo.Index  // indexes including any of columns
+	foreignKeys []string        // foreign keys involving columns, on either side
+}
+
+// collationPlan describes the conversion of a schema's tables to a new
+// collation.
+type collationPlan struct {
+	charSet     string
+	collation   string
+	from        map[string]bool
+	conversions []*collationConversion
+	oneSided    []string // descriptions of foreign keys with only one side converted
+}
+
+// newCollationPlan returns a plan for converting any tables and columns of
+// schema which use a character set or collation in from, to use the target
+// collation instead. Tables not involved in foreign keys are ordered first,
+// followed by tables involved in foreign keys; each group is sorted by name.
+func newCollationPlan(schema *tengo.Schema, from []string, target string) *collationPlan {
+	cp := &collationPlan{
+		charSet:   strings.SplitN(target, "_", 2)[0],
+		collation: target,
+		from:      make(map[string]bool, len(from)),
+	}
+	for _, name := range from {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			cp.from[name] = true
+		}
+	}
+
+	// Determine which columns of each table are converted
+	convertedCols := make(map[string]map[string]bool)
+	byTable := make(map[string]*collationConversion)
+	for _, table := range schema.Tables {
+		conv := &collationConversion{table: table}
+		cols := make(map[string]bool)
+		for _, col := range table.Columns {
+			if col.CharSet != "" && cp.matches(col.CharSet, col.Collation) {
+				conv.columns = append(conv.columns, col)
+				cols[col.Name] = true
+			}
+		}
+		if len(cols) == 0 && !cp.matches(table.CharSet, table.Collation) {
+			continue
+		}
+		indexes := table.SecondaryIndexes
+		if table.PrimaryKey != nil {
+			indexes = append([]*tengo.Index{table.PrimaryKey}, indexes...)
+		}
+		for _, idx := range indexes {
+			for _, col := range idx.Columns {
+				if cols[col.Name] {
+					conv.indexes = append(conv.indexes, idx)
+					break
+				}
+			}
+		}
+		convertedCols[table.Name] = cols
+		byTable[table.Name] = conv
+		cp.conversions = append(cp.conversions, conv)
+	}
+
+	// Find foreign keys involving converted columns on either side
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			if fk.ReferencedSchemaName != "" {
+				continue
+			}
+			var local, remote bool
+			for n, col := range fk.Columns {
+				local = local || convertedCols[table.Name][col.Name]
+				remote = remote || convertedCols[fk.ReferencedTableName][fk.ReferencedColumnNames[n]]
+			}
+			if !local && !remote {
+				continue
+			}
+			desc := fmt.Sprintf("%s on %s", tengo.EscapeIdentifier(fk.Name), tengo.EscapeIdentifier(table.Name))
+			for _, name := range []string{table.Name, fk.ReferencedTableName} {
+				if conv := byTable[name]; conv != nil {
+					conv.foreignKeys = append(conv.foreignKeys, desc)
+				}
+			}
+			if local != remote {
+				cp.oneSided = append(cp.oneSided, fmt.Sprintf("Foreign key %s references %s, but only one side of it uses a collation being converted", desc, tengo.EscapeIdentifier(fk.ReferencedTableName)))
+			}
+		}
+	}
+
+	sort.SliceStable(cp.conversions, func(i, j int) bool {
+		iFK, jFK := len(cp.conversions[i].foreignKeys) > 0, len(cp.conversions[j].foreignKeys) > 0
+		if iFK != jFK {
+			return jFK
+		}
+		return cp.conversions[i].table.Name < cp.conversions[j].table.Name
+	})
+	return cp
+}
+
+// matches returns true if charSet or collation is being converted. Objects
+// already using the target collation are never converted.
+func (cp *collationPlan) matches(charSet, collation string) bool {
+	charSet, collation = strings.ToLower(charSet), strings.ToLower(collation)
+	if collation == cp.collation {
+		return false
+	}
+	return cp.from[charSet] || cp.from[collation]
+}
+
+// alterStatement returns the statement to convert table.
+func (cp *collationPlan) alterStatement(table *tengo.Table) string {
+	return fmt.Sprintf("%s CONVERT TO CHARACTER SET %s COLLATE %s", table.AlterStatement(), cp.charSet, cp.collation)
+}
+
+// warnings returns descriptions of potential problems with the plan: indexes
+// which exceed InnoDB's maximum key length once converted, and foreign keys
+// with only one side converted.
+func (cp *collationPlan) warnings() []string {
+	var warnings []string
+	for _, conv := range cp.conversions {
+		limit := maxIndexKeyBytes(conv.table)
+		for _, idx := range conv.indexes {
+			if bytes := cp.indexKeyBytes(idx); bytes > limit {
+				warnings = append(warnings, fmt.Sprintf("Index %s of table %s will require up to %d bytes per key once converted to %s, exceeding the maximum of %d bytes for its row format; shorten the indexed columns or use prefix lengths", tengo.EscapeIdentifier(idx.Name), tengo.EscapeIdentifier(conv.table.Name), bytes, cp.charSet, limit))
+			}
+		}
+	}
+	return append(warnings, cp.oneSided...)
+}
+
+// writeStatements writes the plan's ALTER TABLEs to w, each preceded by a
+// comment describing its affected columns, indexes, and foreign keys. Tables
+// involved in foreign keys are converted with foreign_key_checks disabled.
+func (cp *collationPlan) writeStatements(w io.Writer) {
+	var fkChecksOff bool
+	for _, conv := range cp.conversions {
+		if len(conv.foreignKeys) > 0 && !fkChecksOff {
+			fmt.Fprintln(w, "SET foreign_key_checks=0;")
+			fkChecksOff = true
+		}
+		colNames := make([]string, len(conv.columns))
+		for n, col := range conv.columns {
+			colNames[n] = tengo.EscapeIdentifier(col.Name)
+		}
+		idxNames := make([]string, len(conv.indexes))
+		for n, idx := range conv.indexes {
+			idxNames[n] = tengo.EscapeIdentifier(idx.Name)
+		}
+		fmt.Fprintf(w, "-- Table %s: columns %s", tengo.EscapeIdentifier(conv.table.Name), listOrNone(colNames))
+		fmt.Fprintf(w, "; indexes %s; foreign keys %s\n", listOrNone(idxNames), listOrNone(conv.foreignKeys))
+		fmt.Fprintf(w, "%s;\n", cp.alterStatement(conv.table))
+	}
+	if fkChecksOff {
+		fmt.Fprintln(w, "SET foreign_key_checks=1;")
+	}
+	fmt.Fprintln(w)
+}
+
+var stringLengthRegexp = regexp.MustCompile(`^(?:var)?char\((\d+)\)`)
+
+// indexKeyBytes returns the maximum number of bytes used by converted string
+// columns in each key of idx, once converted to the plan's character set.
+func (cp *collationPlan) indexKeyBytes(idx *tengo.Index) (total int) {
+	for n, col := range idx.Columns {
+		if col.CharSet == "" || !cp.matches(col.CharSet, col.Collation) {
+			continue
+		}
+		chars := int(idx.SubParts[n])
+		if matches := stringLengthRegexp.FindStringSubmatch(col.TypeInDB); chars == 0 && matches != nil {
+			chars, _ = strconv.Atoi(matches[1])
+		}
+		total += chars * charSetMaxBytes(cp.charSet)
+	}
+	return total
+}
+
+// maxIndexKeyBytes returns InnoDB's maximum key length for indexes of table,
+// which depends on its row format.
+func maxIndexKeyBytes(table *tengo.Table) int {
+	options := strings.ToLower(table.CreateOptions)
+	if strings.Contains(options, "row_format=compact") || strings.Contains(options, "row_format=redundant") {
+		return 767
+	}
+	return 3072
+}
+
+// charSetMaxBytes returns the maximum number of bytes per character of the
+// supplied character set.
+func charSetMaxBytes(charSet string) int {
+	switch strings.ToLower(charSet) {
+	case "utf8mb4", "utf16", "utf16le", "utf32":
+		return 4
+	case "utf8", "utf8mb3", "ujis", "eucjpms":
+		return 3
+	case "big5", "cp932", "euckr", "gb2312", "gbk", "sjis", "ucs2":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// listOrNone returns a comma-separated list of items, or "none" if items is
+// empty.
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
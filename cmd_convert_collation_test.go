@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestCollationPlan(t *testing.T) {
+	utf8Col := func(name, typ string) *tengo.Column {
+		return &tengo.Column{Name: name, TypeInDB: typ, CharSet: "utf8", Collation: "utf8_general_ci"}
+	}
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int unsigned"}
+	codeCol := utf8Col("code", "varchar(20)")
+	users := &tengo.Table{
+		Name:      "users",
+		CharSet:   "utf8",
+		Collation: "utf8_general_ci",
+		Columns:   []*tengo.Column{idCol, codeCol, utf8Col("email", "varchar(255)")},
+	}
+	users.PrimaryKey = &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true}
+	users.SecondaryIndexes = []*tengo.Index{
+		{Name: "code", Columns: []*tengo.Column{codeCol}, SubParts: []uint16{0}, Unique: true},
+		{Name: "email", Columns: []*tengo.Column{users.Columns[2]}, SubParts: []uint16{0}},
+	}
+	users.CreateOptions = "row_format=COMPACT"
+
+	userCodeCol := utf8Col("user_code", "varchar(20)")
+	orders := &tengo.Table{
+		Name:      "orders",
+		CharSet:   "utf8",
+		Collation: "utf8_general_ci",
+		Columns:   []*tengo.Column{idCol, userCodeCol},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "orders_user", Columns: []*tengo.Column{userCodeCol}, ReferencedTableName: "users", ReferencedColumnNames: []string{"code"}},
+		},
+	}
+	legacyCol := &tengo.Column{Name: "user_code", TypeInDB: "varchar(20)", CharSet: "latin1", Collation: "latin1_swedish_ci"}
+	legacy := &tengo.Table{
+		Name:      "legacy",
+		CharSet:   "latin1",
+		Collation: "latin1_swedish_ci",
+		Columns:   []*tengo.Column{idCol, legacyCol},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "legacy_user", Columns: []*tengo.Column{legacyCol}, ReferencedTableName: "users", ReferencedColumnNames: []string{"code"}},
+		},
+	}
+	already := &tengo.Table{
+		Name:      "already",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_0900_ai_ci",
+		Columns:   []*tengo.Column{idCol, {Name: "name", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"}},
+	}
+	misc := &tengo.Table{
+		Name:      "misc",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_general_ci",
+		Columns:   []*tengo.Column{idCol},
+	}
+	schema := &tengo.Schema{Name: "shop", Tables: []*tengo.Table{users, orders, legacy, already, misc}}
+	cp := newCollationPlan(schema, []string{"utf8", "utf8mb3", " utf8mb4_general_ci"}, "utf8mb4_0900_ai_ci")
+
+	if cp.charSet != "utf8mb4" || cp.collation != "utf8mb4_0900_ai_ci" {
+		t.Errorf("Unexpected target character set %q or collation %q", cp.charSet, cp.collation)
+	}
+	var names []string
+	for _, conv := range cp.conversions {
+		names = append(names, conv.table.Name)
+	}
+	if actual := strings.Join(names, ","); actual != "misc,orders,users" {
+		t.Errorf("Unexpected conversion order: %s", actual)
+	}
+	if users := cp.conversions[2]; len(users.columns) != 2 || len(users.indexes) != 2 || len(users.foreignKeys) != 2 {
+		t.Errorf("Unexpected conversion of users: %d columns, %d indexes, %d foreign keys", len(users.columns), len(users.indexes), len(users.foreignKeys))
+	}
+
+	// The email index exceeds the COMPACT row format's limit once converted, and
+	// the legacy foreign key only has one side converted
+	warnings := cp.warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, instead found %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], "Index `email` of table `users` will require up to 1020 bytes") {
+		t.Errorf("Unexpected index warning: %s", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "`legacy_user` on `legacy`") {
+		t.Errorf("Unexpected foreign key warning: %s", warnings[1])
+	}
+
+	var b bytes.Buffer
+	cp.writeStatements(&b)
+	expected := "-- Table `misc`: columns none; indexes none; foreign keys none\n" +
+		"ALTER TABLE `misc` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci;\n" +
+		"SET foreign_key_checks=0;\n" +
+		"-- Table `orders`: columns `user_code`; indexes none; foreign keys `orders_user` on `orders`\n" +
+		"ALTER TABLE `orders` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci;\n" +
+		"-- Table `users`: columns `code`, `email`; indexes `code`, `email`; foreign keys `orders_user` on `orders`, `legacy_user` on `legacy`\n" +
+		"ALTER TABLE `users` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci;\n" +
+		"SET foreign_key_checks=1;\n\n"
+	if b.String() != expected {
+		t.Errorf("Unexpected output from writeStatements:\n%s", b.String())
+	}
+}
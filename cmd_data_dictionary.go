@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Output a flat data dictionary of all columns in the filesystem"
+	desc := `Outputs a flat data dictionary listing every column of every table in the
+filesystem representation, one row per column, with its schema, table, column
+name, type, nullability, default, and comment. This is intended for audit and
+compliance reporting; for browsable per-schema documentation, use ` + "`" + `skeema docs` + "`" + `
+instead.
+
+Output is a single Markdown table by default, or CSV (with a header row) with
+--format=csv. It is written to STDOUT. Rows are sorted by schema directory,
+then table name, then column position, so that output is deterministic and
+successive exports may be compared. Objects matching ignore options are
+excluded.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all schemas were processed successfully,
+or 2+ if any error occurred. Schemas which could be processed are still output
+in the latter case.`
+
+	cmd := mybase.NewCommand("data-dictionary", summary, desc, DataDictionaryHandler)
+	cmd.AddOption(mybase.StringOption("format", 0, "MARKDOWN", `Output format for data dictionary (valid values: "MARKDOWN", "CSV")`))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DataDictionaryHandler is the handler method for `skeema data-dictionary`
+func DataDictionaryHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	format, err := dir.Config.GetEnum("format", "markdown", "csv")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	var schemas []*tengo.Schema
+	skipCount := execDirSchemasWalker(dir, 5, &schemas)
+	w := bufio.NewWriter(os.Stdout)
+	if format == "csv" {
+		err = writeDataDictionaryCSV(w, schemas)
+	} else {
+		err = writeDataDictionaryMarkdown(w, schemas)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return NewExitValue(CodeFatalError, "Unable to write data dictionary: %s", err)
+	}
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
 )
 
 func init() {
@@ -18,12 +19,22 @@ running ` + "`" + `skeema diff staging` + "`" + ` will apply config directives f
 top of the file. If no environment name is supplied, the default is
 "production".
 
+To process several environments in one invocation, supply a comma-separated
+list, for example ` + "`" + `skeema diff staging,production` + "`" + `. Each environment is
+processed in turn, and the exit code is the highest one from any environment.
+
 The ` + "`" + `skeema diff` + "`" + ` command is equivalent to ` + "`" + `skeema push --dry-run` + "`" + `.
 
+With the git-base option, no database instance's schemas are examined.
+Instead, the *.sql files as of the supplied git ref are compared to the ones in
+the working tree, by loading both into workspaces in the same manner as
+` + "`" + `skeema compare` + "`" + `. The output is the DDL introduced by the working tree's changes.
+
 An exit code of 0 will be returned if no differences were found, 1 if some
 differences were found, or 2+ if an error occurred.`
 
-	cmd := mybase.NewCommand("diff", summary, desc, DiffHandler)
+	cmd := mybase.NewCommand("diff", summary, desc, multiEnvironmentHandler(DiffHandler, "summary-json"))
+	cmd.AddOption(mybase.StringOption("git-base", 0, "", "Compare *.sql files to their contents at this git ref, instead of to DB instances"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 	clonePushOptionsToDiff()
@@ -34,19 +45,36 @@ func DiffHandler(cfg *mybase.Config) error {
 	// We just delegate to PushHandler, forcing dry-run to be enabled
 	cfg.CLI.OptionValues["dry-run"] = "1"
 	cfg.MarkDirty()
+	if ref := cfg.Get("git-base"); ref != "" {
+		return diffGitBase(cfg, ref)
+	}
 	return PushHandler(cfg)
 }
 
-// clonePushOptionsToDiff copies options from `skeema push` into `skeema diff`
-func clonePushOptionsToDiff() {
-	// Logic relies on init() having been called in both cmd_push.go AND
-	// cmd_diff.go, so we call it from both places, but only one will succeed
-	diff, ok1 := CommandSuite.SubCommands["diff"]
-	push, ok2 := CommandSuite.SubCommands["push"]
-	if !ok1 || !ok2 {
-		return
+// diffGitBase outputs the DDL that transforms the schemas declared in the
+// current dir and its subdirs as of git ref into the ones currently declared
+// in the working tree.
+func diffGitBase(cfg *mybase.Config, ref string) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	base, err := newGitBase(dir.Path, ref, cfg)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	defer base.cleanup()
+	baseDir, err := base.parseDir(dir.Path)
+	if err != nil {
+		return err
+	} else if baseDir == nil {
+		return NewExitValue(CodeNoInput, "Dir %s does not exist as of git ref %s", dir, ref)
 	}
+	return compareDirs(baseDir, dir)
+}
 
+// clonePushOptionsToDiff copies options from `skeema push` into `skeema diff`
+func clonePushOptionsToDiff() {
 	descRewrites := map[string]string{
 		"allow-unsafe":    "Permit generating ALTER or DROP operations that are potentially destructive",
 		"alter-wrapper":   "Output ALTER TABLEs as shell commands rather than just raw DDL; see manual for template vars",
@@ -55,24 +83,17 @@ func clonePushOptionsToDiff() {
 	}
 	hiddenRewrites := map[string]bool{
 		"brief":              false,
+		"catalog-service":    true,
+		"catalog-webhook":    true,
+		"check-target-state": true,
 		"dry-run":            true,
 		"foreign-key-checks": true,
+		"history-schema":     true,
+		"interactive":        true,
+		"notify-events":      true,
+		"notify-format":      true,
+		"notify-webhook":     true,
+		"push-session-vars":  true,
 	}
-
-	diffOptions := diff.Options()
-	pushOptions := push.Options()
-
-	for name, pushOpt := range pushOptions {
-		if _, already := diffOptions[name]; already {
-			continue
-		}
-		diffOpt := *pushOpt
-		if newDesc, ok := descRewrites[name]; ok {
-			diffOpt.Description = newDesc
-		}
-		if newHiddenStatus, ok := hiddenRewrites[name]; ok {
-			diffOpt.HiddenOnCLI = newHiddenStatus
-		}
-		diff.AddOption(&diffOpt)
-	}
+	copyPushOptions("diff", descRewrites, hiddenRewrites)
 }
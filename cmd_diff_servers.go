@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Compare the live schemas of two DB instances directly"
+	desc := `Compares the schemas on two database instances directly, without using any
+*.sql files, and outputs the DDL that would make the target's schemas match the
+source's. For example, ` + "`" + `skeema diff-servers prod-db1 staging-db1` + "`" + ` outputs the DDL
+needed to bring staging-db1's schemas in line with prod-db1's. The DDL is only
+output, never run.
+
+Each of source and target has the form host[:port][/schema]. If a schema name
+is supplied on only one side, the same name is used for the other side. If no
+schema name is supplied on either side, every schema on the source is compared
+to the schema of the same name on the target, subject to the ignore-schema
+option; schemas missing from the target result in a CREATE DATABASE.
+
+Connection options, such as user and password, apply to both instances. They
+may be supplied on the command-line, or in a .skeema file in the current
+directory or its parents, or in a global option file.
+
+An exit code of 0 will be returned if no differences were found, 1 if some
+differences were found, or 2+ if an error occurred.`
+
+	cmd := mybase.NewCommand("diff-servers", summary, desc, DiffServersHandler)
+	addCompareOptions(cmd)
+	cmd.AddArg("source", "", true)
+	cmd.AddArg("target", "", true)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DiffServersHandler is the handler method for `skeema diff-servers`
+func DiffServersHandler(cfg *mybase.Config) error {
+	sourceDir, sourceSchemaName, err := serverSpecDir(cfg, cfg.Get("source"))
+	if err != nil {
+		return NewExitValue(CodeBadUsage, "Invalid source: %s", err)
+	}
+	targetDir, targetSchemaName, err := serverSpecDir(cfg, cfg.Get("target"))
+	if err != nil {
+		return NewExitValue(CodeBadUsage, "Invalid target: %s", err)
+	}
+	if sourceSchemaName == "" {
+		sourceSchemaName = targetSchemaName
+	} else if targetSchemaName == "" {
+		targetSchemaName = sourceSchemaName
+	}
+
+	source, err := sourceDir.FirstInstance()
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	target, err := targetDir.FirstInstance()
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if source.String() == target.String() && sourceSchemaName == targetSchemaName {
+		return NewExitValue(CodeBadUsage, "Source and target must differ")
+	}
+
+	// Build the list of schema names to compare, as pairs of source and target
+	var pairs [][2]string
+	if sourceSchemaName != "" {
+		pairs = append(pairs, [2]string{sourceSchemaName, targetSchemaName})
+	} else {
+		names, err := source.SchemaNames()
+		if err != nil {
+			return NewExitValue(CodeFatalError, "Cannot examine schemas on %s: %s", source, err)
+		}
+		ignoreSchema, err := targetDir.Config.GetRegexp("ignore-schema")
+		if err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		for _, name := range names {
+			if ignoreSchema != nil && ignoreSchema.MatchString(name) {
+				log.Debugf("Skipping schema %s because ignore-schema='%s'", name, ignoreSchema)
+			} else if name != targetDir.Config.Get("temp-schema") {
+				pairs = append(pairs, [2]string{name, name})
+			}
+		}
+	}
+
+	var differences bool
+	var skipCount int
+	fmt.Printf("-- target: %s\n", target)
+	for _, pair := range pairs {
+		statements, err := diffServerSchemas(source, pair[0], target, pair[1], targetDir)
+		if err != nil {
+			log.Errorf("Skipping %s %s: %s", source, pair[0], err)
+			skipCount++
+			continue
+		}
+		if len(statements) == 0 {
+			log.Infof("%s %s vs %s %s: No differences found", source, pair[0], target, pair[1])
+			continue
+		}
+		differences = true
+		for _, stmt := range statements {
+			fmt.Print(stmt)
+		}
+	}
+
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d schema%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	} else if differences {
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
+
+// diffServerSchemas returns the output needed to make targetSchemaName on
+// target match sourceSchemaName on source: any database-level DDL, followed by
+// a USE command and the DDL for objects in the schema. Each returned string
+// includes a delimiter.
+func diffServerSchemas(source *tengo.Instance, sourceSchemaName string, target *tengo.Instance, targetSchemaName string, dir *fs.Dir) (output []string, err error) {
+	from, err := target.Schema(targetSchemaName)
+	if err == sql.ErrNoRows {
+		from, err = nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	to, err := source.Schema(sourceSchemaName)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("Schema %s does not exist", sourceSchemaName)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Database-level DDL uses the target's schema name
+	if from == nil {
+		toDatabase := *to
+		toDatabase.Name = targetSchemaName
+		output = append(output, fs.AddDelimiter(toDatabase.CreateStatement()))
+	} else if stmt := from.AlterStatement(to.CharSet, to.Collation); stmt != "" {
+		output = append(output, fs.AddDelimiter(stmt))
+	}
+	statements, err := compareStatements(from, to, dir, target.Flavor())
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) > 0 {
+		output = append(output, fmt.Sprintf("USE %s;\n", tengo.EscapeIdentifier(targetSchemaName)))
+		for _, stmt := range statements {
+			output = append(output, fs.AddDelimiter(stmt))
+		}
+	}
+	return output, nil
+}
+
+// parseServerSpec splits a server spec of the form host[:port][/schema] into
+// its components. port and schemaName are blank if not supplied.
+func parseServerSpec(spec string) (host, port, schemaName string, err error) {
+	hostPort := spec
+	if pos := strings.IndexByte(spec, '/'); pos > -1 {
+		hostPort, schemaName = spec[:pos], spec[pos+1:]
+	}
+	host = hostPort
+	if pos := strings.LastIndexByte(hostPort, ':'); pos > -1 {
+		if _, err := strconv.Atoi(hostPort[pos+1:]); err != nil {
+			return "", "", "", fmt.Errorf("%q does not specify a valid port", spec)
+		}
+		host, port = hostPort[:pos], hostPort[pos+1:]
+	}
+	if host == "" {
+		return "", "", "", fmt.Errorf("%q does not specify a host", spec)
+	}
+	return host, port, schemaName, nil
+}
+
+// serverSpecDir returns a dir for the current directory, configured to use the
+// host and port of the supplied server spec, along with the spec's schema name
+// (if any).
+func serverSpecDir(cfg *mybase.Config, spec string) (*fs.Dir, string, error) {
+	host, port, schemaName, err := parseServerSpec(spec)
+	if err != nil {
+		return nil, "", err
+	}
+	cli := *cfg.CLI
+	cli.OptionValues = make(map[string]string, len(cfg.CLI.OptionValues)+2)
+	for name, value := range cfg.CLI.OptionValues {
+		cli.OptionValues[name] = value
+	}
+	cli.OptionValues["host"] = host
+	if port != "" {
+		cli.OptionValues["port"] = port
+	}
+	specConfig := mybase.NewConfig(&cli)
+	specConfig.IsTest = cfg.IsTest
+	util.AddGlobalConfigFiles(specConfig)
+	dir, err := fs.ParseDir(".", specConfig)
+	return dir, schemaName, err
+}
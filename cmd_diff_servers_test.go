@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseServerSpec(t *testing.T) {
+	cases := map[string][3]string{
+		"db1":                          {"db1", "", ""},
+		"db1:3307":                     {"db1", "3307", ""},
+		"db1/product":                  {"db1", "", "product"},
+		"db1.example.com:3307/product": {"db1.example.com", "3307", "product"},
+		"127.0.0.1/":                   {"127.0.0.1", "", ""},
+	}
+	for spec, expected := range cases {
+		host, port, schemaName, err := parseServerSpec(spec)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", spec, err)
+		} else if actual := [3]string{host, port, schemaName}; actual != expected {
+			t.Errorf("Expected %q to parse to %v, instead found %v", spec, expected, actual)
+		}
+	}
+	for _, spec := range []string{"", ":3306", "/product", "db1:abc", "db1:/product"} {
+		if _, _, _, err := parseServerSpec(spec); err == nil {
+			t.Errorf("Expected error parsing %q, but err was nil", spec)
+		}
+	}
+}
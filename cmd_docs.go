@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Generate data dictionary documentation from the filesystem"
+	desc := `Generates documentation for each schema in the filesystem representation,
+listing its tables with their columns, indexes, and foreign keys, as well as any
+stored procedures and functions. Foreign keys are cross-referenced in both
+directions. Column, index, table, and routine comments are included, so these
+serve as the primary means of adding descriptions to the generated docs.
+
+Output is in Markdown format by default, or HTML with --format=html. With
+--output-dir, one file is written per schema directory, mirroring the
+directory structure; otherwise, docs are written to STDOUT.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all docs were generated successfully, or
+2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("docs", summary, desc, DocsHandler)
+	cmd.AddOption(mybase.StringOption("format", 0, "MARKDOWN", `Output format for docs (valid values: "MARKDOWN", "HTML")`))
+	cmd.AddOption(mybase.StringOption("output-dir", 0, "", "Write one file per schema to this directory, instead of STDOUT"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DocsHandler is the handler method for `skeema docs`
+func DocsHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := dir.Config.GetEnum("format", "markdown", "html"); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	if skipCount := docsWalker(dir, dir, 5); skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural, plural)
+	}
+	return nil
+}
+
+// docsWalker generates docs for dir, and recursively calls itself on any
+// subdirs. topDir is the dir that the command was invoked from, which is used
+// for determining output file paths. It returns the number of dirs which could
+// not be processed due to errors.
+func docsWalker(dir, topDir *fs.Dir, maxDepth int) (skipCount int) {
+	if dir.HasSchema() {
+		if err := docsDir(dir, topDir); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += docsWalker(sub, topDir, maxDepth-1)
+		}
+	}
+	return skipCount
+}
+
+// docsDir generates docs for a single directory, without recursing into
+// subdirs.
+func docsDir(dir, topDir *fs.Dir) error {
+	schema, err := execDirSchema(dir)
+	if err != nil || schema == nil {
+		return err
+	}
+	return writeDocsOutput(dir, topDir, schema)
+}
+
+// execDirSchema converts dir's *.sql files into a *tengo.Schema, by executing
+// them in a workspace. The returned schema uses the name from dirSchemaName
+// rather than the workspace's temporary name, and excludes any objects matching
+// the dir's ignore options. A nil schema is returned if the dir does not define
+// any objects in its unnamed logical schema.
+func execDirSchema(dir *fs.Dir) (*tengo.Schema, error) {
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := dirWorkspaceOptions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, logicalSchema := range dir.LogicalSchemas {
+		// Multiple explicitly-named schemas per dir are not supported, consistent
+		// with `skeema pull`
+		if logicalSchema.Name != "" {
+			log.Warnf("Ignoring schema %s from directory %s -- multiple schemas per dir not supported yet", logicalSchema.Name, dir)
+			continue
+		}
+		schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, stmtErr := range statementErrors {
+			log.Error(stmtErr.Error())
+		}
+		if len(statementErrors) > 0 {
+			return nil, fmt.Errorf("%d statements could not be executed", len(statementErrors))
+		}
+
+		result := &tengo.Schema{
+			Name:      dirSchemaName(dir),
+			CharSet:   schema.CharSet,
+			Collation: schema.Collation,
+		}
+		for _, table := range schema.Tables {
+			if !ignoreOpts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}) {
+				result.Tables = append(result.Tables, table)
+			}
+		}
+		for _, routine := range schema.Routines {
+			if !ignoreOpts.ShouldIgnore(tengo.ObjectKey{Type: routine.Type, Name: routine.Name}) {
+				result.Routines = append(result.Routines, routine)
+			}
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
+// dirWorkspaceOptions returns workspace options for dir, connecting to its
+// first defined instance unless configured to use local Docker.
+func dirWorkspaceOptions(dir *fs.Dir) (workspace.Options, error) {
+	var inst *tengo.Instance
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		var err error
+		if inst, err = dir.FirstInstance(); err != nil {
+			return workspace.Options{}, err
+		}
+	}
+	return workspace.OptionsForDir(dir, inst)
+}
+
+// execDirSchemasWalker appends the result of execDirSchema for dir to schemas,
+// and recursively calls itself on any subdirs. It returns the number of dirs
+// which could not be processed due to errors.
+func execDirSchemasWalker(dir *fs.Dir, maxDepth int, schemas *[]*tengo.Schema) (skipCount int) {
+	if dir.HasSchema() {
+		if schema, err := execDirSchema(dir); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		} else if schema != nil {
+			*schemas = append(*schemas, schema)
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += execDirSchemasWalker(sub, maxDepth-1, schemas)
+		}
+	}
+	return skipCount
+}
+
+// dirSchemaName returns the schema name to use for dir in generated output. If
+// the dir's schema option refers to a single schema by name, that name is used;
+// otherwise, since the actual schema names may vary by instance, the dir's
+// base name is used instead.
+func dirSchemaName(dir *fs.Dir) string {
+	schemaName := dir.Config.Get("schema")
+	if schemaName == "" || strings.ContainsAny(schemaName, "*,`") {
+		return dir.BaseName()
+	}
+	return schemaName
+}
+
+// writeDocsOutput renders schema in the format specified by dir's
+// configuration, and writes the result to STDOUT or to a file in the output
+// directory.
+func writeDocsOutput(dir, topDir *fs.Dir, schema *tengo.Schema) error {
+	var f docsFormatter = markdownDocsFormatter{}
+	ext := ".md"
+	if format, _ := dir.Config.GetEnum("format", "markdown", "html"); format == "html" {
+		f = htmlDocsFormatter{}
+		ext = ".html"
+	}
+	outputDir := dir.Config.Get("output-dir")
+	if outputDir == "" {
+		return writeSchemaDocs(os.Stdout, schema, f)
+	}
+
+	// Mirror the dir structure below the top dir, so that output file names
+	// cannot conflict
+	rel, err := filepath.Rel(topDir.Path, dir.Path)
+	if err != nil || rel == "." {
+		rel = dir.BaseName()
+	}
+	filePath := filepath.Join(outputDir, rel+ext)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := writeSchemaDocs(&buf, schema, f); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filePath, buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	log.Infof("Wrote %s (%d bytes) -- docs for %s", filePath, buf.Len(), dir)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func init() {
+	summary := "Output a JSON model of each schema in the filesystem"
+	desc := `Outputs a machine-readable JSON model of each schema in the filesystem
+representation, for consumption by code generation, data catalog, or other
+tooling. The model includes each table's columns with their types, defaults,
+and comments, along with its indexes, foreign keys, and partitioning clause,
+as well as any stored procedures and functions. Objects matching ignore options
+are excluded.
+
+The output is a single JSON object written to STDOUT, with a "schemas" array
+containing one element per schema directory. Tables and routines are sorted by
+name, so that output is deterministic.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all schemas were output successfully, or
+2+ if any error occurred. Schemas which could be processed are still output
+in the latter case.`
+
+	cmd := mybase.NewCommand("dump-json", summary, desc, DumpJSONHandler)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DumpJSONHandler is the handler method for `skeema dump-json`
+func DumpJSONHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	output := struct {
+		Schemas []*jsonSchema `json:"schemas"`
+	}{Schemas: []*jsonSchema{}}
+	skipCount := dumpJSONWalker(dir, dir, 5, &output.Schemas)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return NewExitValue(CodeFatalError, "Unable to write JSON: %s", err)
+	}
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// dumpJSONWalker appends the JSON model of dir's schema to schemas, and
+// recursively calls itself on any subdirs. topDir is the dir that the command
+// was invoked from, which is used for determining relative dir paths. It
+// returns the number of dirs which could not be processed due to errors.
+func dumpJSONWalker(dir, topDir *fs.Dir, maxDepth int, schemas *[]*jsonSchema) (skipCount int) {
+	if dir.HasSchema() {
+		if schema, err := execDirSchema(dir); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		} else if schema != nil {
+			relPath, err := filepath.Rel(topDir.Path, dir.Path)
+			if err != nil {
+				relPath = dir.Path
+			}
+			*schemas = append(*schemas, newJSONSchema(filepath.ToSlash(relPath), schema))
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += dumpJSONWalker(sub, topDir, maxDepth-1, schemas)
+		}
+	}
+	return skipCount
+}
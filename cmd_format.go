@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Normalize format of filesystem representation of database objects"
+	desc := `Reformats the filesystem representation of tables and routines to match the
+canonical format of SHOW CREATE, without performing any of the other checks of
+` + "`" + `skeema lint` + "`" + `.
+
+With --split, files which define multiple objects are also split up, so that each
+object is stored in its own file, named for the object. This keeps changes in
+code review mapped cleanly to individual objects. If the type-subdirs option is
+enabled, objects are moved into the subdir for their type as well.
+
+Normalization relies on accessing database instances to test the SQL DDL. All
+DDL will be run against a temporary schema, with no impact on the real schema.
+Use --skip-normalize to only split files, without accessing any database.
+
+With --offline, tables are instead normalized using purely syntactic rewrites,
+without accessing any database: keyword case, identifier quoting, indentation,
+and ordering of clauses and indexes are adjusted to match SHOW CREATE TABLE.
+This cannot fill in server defaults, and routines are left as-is.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to test the SQL DDL against. For example, running ` + "`" + `skeema format staging` + "`" + `
+will apply config directives from the [staging] section of config files, as well
+as any sectionless directives at the top of the file. If no environment name is
+supplied, the default is "production".
+
+An exit code of 0 will be returned if all files were already formatted properly,
+1 if some files were reformatted or split, or 2+ if any errors occurred.`
+
+	cmd := mybase.NewCommand("format", summary, desc, FormatHandler)
+	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "Reformat SQL statements to match canonical SHOW CREATE"))
+	cmd.AddOption(mybase.BoolOption("split", 0, false, "Split files defining multiple objects into one file per object"))
+	cmd.AddOption(mybase.BoolOption("offline", 0, false, "Normalize table syntax without accessing any database; see manual"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// FormatHandler is the handler method for `skeema format`
+func FormatHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	changeCount, skipCount := formatWalker(dir, 5)
+	if skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d operation%s due to error%s", skipCount, plural, plural)
+	} else if changeCount > 0 {
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
+
+// formatWalker processes dir, and recursively calls itself on any subdirs. It
+// returns the number of file writes, and the number of dirs which could not be
+// fully processed due to errors.
+func formatWalker(dir *fs.Dir, maxDepth int) (changeCount, skipCount int) {
+	if len(dir.LogicalSchemas) > 0 {
+		var err error
+		if changeCount, err = formatDir(dir); err != nil {
+			log.Errorf("%s: %s", dir, err)
+			skipCount++
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			subChangeCount, subSkipCount := formatWalker(sub, maxDepth-1)
+			changeCount += subChangeCount
+			skipCount += subSkipCount
+		}
+	}
+	return changeCount, skipCount
+}
+
+// formatDir normalizes and/or splits the *.sql files of a single directory,
+// without recursing into subdirs. It returns the number of file writes, along
+// with an error if the dir could not be fully processed.
+func formatDir(dir *fs.Dir) (int, error) {
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Formatting %s", dir)
+	var opts workspace.Options
+	if dir.Config.GetBool("normalize") && !dir.Config.GetBool("offline") {
+		// Connect to first defined instance, unless configured to use local Docker
+		var inst *tengo.Instance
+		if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+			if inst, err = dir.FirstInstance(); err != nil {
+				return 0, err
+			}
+		}
+		if opts, err = workspace.OptionsForDir(dir, inst); err != nil {
+			return 0, err
+		}
+	}
+
+	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
+	var moves []*fs.Statement
+	var errCount int
+	for _, logicalSchema := range dir.LogicalSchemas {
+		// Multiple explicitly-named schemas per dir are not supported, consistent
+		// with `skeema pull`
+		if logicalSchema.Name != "" {
+			log.Warnf("Ignoring schema %s from directory %s -- multiple schemas per dir not supported yet", logicalSchema.Name, dir)
+			continue
+		}
+		if dir.Config.GetBool("normalize") && dir.Config.GetBool("offline") {
+			flavor := tengo.NewFlavor(dir.Config.Get("flavor"))
+			for key, stmt := range logicalSchema.Creates {
+				if key.Type != tengo.ObjectTypeTable || ignoreOpts.ShouldIgnore(key) {
+					continue
+				}
+				fsBody, fsSuffix := stmt.SplitTextBody()
+				formatted, err := fs.FormatCreateTable(fsBody, flavor)
+				if err != nil {
+					log.Warnf("%s: Unable to normalize %s offline, leaving as-is: %s", stmt.Location(), key, err)
+				} else if formatted != fsBody {
+					stmt.Text = fmt.Sprintf("%s%s", formatted, fsSuffix)
+					filesToRewrite[stmt.FromFile] = true
+				}
+			}
+		} else if dir.Config.GetBool("normalize") {
+			schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+			if err != nil {
+				return 0, err
+			}
+			for _, stmtErr := range statementErrors {
+				log.Error(stmtErr.Error())
+			}
+			errCount += len(statementErrors)
+			for key, instCreateText := range schema.ObjectDefinitions() {
+				if ignoreOpts.ShouldIgnore(key) {
+					continue
+				}
+				stmt := logicalSchema.Creates[key]
+				fsBody, fsSuffix := stmt.SplitTextBody()
+				if instCreateText != fsBody {
+					stmt.Text = fmt.Sprintf("%s%s", instCreateText, fsSuffix)
+					filesToRewrite[stmt.FromFile] = true
+				}
+			}
+		}
+		if dir.Config.GetBool("split") {
+			for key, stmt := range logicalSchema.Creates {
+				// Files shared with other dirs via symlink are left as-is, since
+				// splitting them would only affect this dir
+				if stmt.File != fs.PathForObjectKey(dir.Path, key, dir.UsesTypeSubdirs()) && dir.LinkedPath(stmt.FromFile.SQLFile) == "" {
+					stmt.Remove()
+					filesToRewrite[stmt.FromFile] = true
+					moves = append(moves, stmt)
+				}
+			}
+		}
+	}
+
+	// Rewrite modified files first, and then append moved statements to their
+	// new files, since a moved statement's destination may be one of the
+	// modified files.
+	var writeCount int
+	for file := range filesToRewrite {
+		if bytesWritten, err := file.Rewrite(); err != nil {
+			return writeCount, err
+		} else if bytesWritten == 0 {
+			log.Infof("Deleted %s -- all objects moved to other files", file)
+		} else {
+			log.Infof("Wrote %s (%d bytes) -- updated file format", file, bytesWritten)
+		}
+		writeCount++
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].ObjectKey().String() < moves[j].ObjectKey().String()
+	})
+	for _, stmt := range moves {
+		body, _ := stmt.SplitTextBody()
+		filePath := fs.PathForObjectKey(dir.Path, stmt.ObjectKey(), dir.UsesTypeSubdirs())
+		bytesWritten, _, err := fs.AppendToFile(filePath, fs.AddDelimiter(body))
+		if err != nil {
+			return writeCount, err
+		}
+		log.Infof("Wrote %s (%d bytes) -- moved %s from %s", filePath, bytesWritten, stmt.ObjectKey(), stmt.File)
+		writeCount++
+	}
+	if errCount > 0 {
+		return writeCount, fmt.Errorf("%d statements returned errors, and could not be reformatted", errCount)
+	}
+	return writeCount, nil
+}
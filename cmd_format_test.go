@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+)
+
+func TestFormatHandlerSplit(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("Unable to cd to %s: %s", repoDir, err)
+	}
+	os.MkdirAll(".git", 0777)
+	fs.WriteTestFile(t, ".skeema", "schema=product\n")
+	fs.WriteTestFile(t, "multi.sql", "CREATE TABLE users (id int);\nCREATE TABLE posts (id int);\nDELIMITER //\nCREATE PROCEDURE doit() BEGIN SELECT 1; SELECT 2; END//\nDELIMITER ;\n")
+	fs.WriteTestFile(t, "comments.sql", "CREATE TABLE comments (id int);\n")
+
+	format := func(commandLine string, expectedExitCode int) {
+		t.Helper()
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, commandLine)
+		util.AddGlobalConfigFiles(cfg)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d from `%s`, instead found %d", expectedExitCode, commandLine, actual)
+		}
+	}
+	format("skeema format --skip-normalize --split", CodeDifferencesFound)
+	expected := map[string]string{
+		"comments.sql": "CREATE TABLE comments (id int);\n",
+		"doit.sql":     "DELIMITER //\nCREATE PROCEDURE doit() BEGIN SELECT 1; SELECT 2; END//\nDELIMITER ;\n",
+		"posts.sql":    "CREATE TABLE posts (id int);\n",
+		"users.sql":    "CREATE TABLE users (id int);\n",
+	}
+	files, _ := filepath.Glob("*.sql")
+	if len(files) != len(expected) {
+		t.Errorf("Expected %d *.sql files, instead found %v", len(expected), files)
+	}
+	for name, contents := range expected {
+		if actual := fs.ReadTestFile(t, name); actual != contents {
+			t.Errorf("Unexpected contents of %s: %q", name, actual)
+		}
+	}
+
+	// Running again should be a no-op
+	format("skeema format --skip-normalize --split", CodeSuccess)
+
+	// With type-subdirs, files should be moved into subdirs
+	fs.WriteTestFile(t, ".skeema", "schema=product\ntype-subdirs\n")
+	format("skeema format --skip-normalize --split", CodeDifferencesFound)
+	for _, name := range []string{"tables/comments.sql", "tables/posts.sql", "tables/users.sql", "procedures/doit.sql"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s to exist, but stat returned %s", name, err)
+		}
+	}
+	if files, _ := filepath.Glob("*.sql"); len(files) > 0 {
+		t.Errorf("Expected no *.sql files in top-level dir, instead found %v", files)
+	}
+}
+
+func TestFormatHandlerOffline(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("Unable to cd to %s: %s", repoDir, err)
+	}
+	os.MkdirAll(".git", 0777)
+	fs.WriteTestFile(t, ".skeema", "schema=product\n")
+	fs.WriteTestFile(t, "users.sql", "create table users (id int not null, name varchar(20), primary key (id)) engine=innodb;\n")
+	fs.WriteTestFile(t, "parts.sql", "CREATE TABLE parts (id int) PARTITION BY HASH (id);\n")
+	fs.WriteTestFile(t, "doit.sql", "DELIMITER //\ncreate procedure doit() BEGIN SELECT 1; END//\nDELIMITER ;\n")
+
+	format := func(commandLine string, expectedExitCode int) {
+		t.Helper()
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, commandLine)
+		util.AddGlobalConfigFiles(cfg)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d from `%s`, instead found %d", expectedExitCode, commandLine, actual)
+		}
+	}
+	format("skeema format --offline", CodeDifferencesFound)
+	expected := map[string]string{
+		"users.sql": "CREATE TABLE `users` (\n  `id` int NOT NULL,\n  `name` varchar(20) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;\n",
+		"parts.sql": "CREATE TABLE parts (id int) PARTITION BY HASH (id);\n",
+		"doit.sql":  "DELIMITER //\ncreate procedure doit() BEGIN SELECT 1; END//\nDELIMITER ;\n",
+	}
+	for name, contents := range expected {
+		if actual := fs.ReadTestFile(t, name); actual != contents {
+			t.Errorf("Unexpected contents of %s: %q", name, actual)
+		}
+	}
+
+	// Running again should be a no-op
+	format("skeema format --offline", CodeSuccess)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func init() {
+	summary := "Generate Go struct definitions from the filesystem"
+	desc := `Generates Go source code declaring a struct for each table in the filesystem
+representation, with one field per column. Each field has db and json struct
+tags containing the column name, for use with database/sql scanning libraries
+and encoding/json. Table and column comments are included as Go comments. This
+permits application code and DDL to be generated from the same source.
+
+Nullable columns are represented using database/sql types such as
+sql.NullString by default, or using pointers with --nullable-types=pointer.
+Objects matching ignore options are excluded.
+
+With --output-dir, one .go file is written per schema directory, mirroring the
+directory structure; otherwise, code is written to STDOUT.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all code was generated successfully, or
+2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("gen-go", summary, desc, GenGoHandler)
+	cmd.AddOption(mybase.StringOption("go-package", 0, "models", "Package name to use in generated Go code"))
+	cmd.AddOption(mybase.StringOption("nullable-types", 0, "sql", `Go representation of nullable columns (valid values: "sql", "pointer")`))
+	cmd.AddOption(mybase.StringOption("output-dir", 0, "", "Write one file per schema to this directory, instead of STDOUT"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// GenGoHandler is the handler method for `skeema gen-go`
+func GenGoHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := dir.Config.GetEnum("nullable-types", string(goNullableSQL), string(goNullablePointer)); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	} else if pkg := dir.Config.Get("go-package"); !token.IsIdentifier(pkg) || pkg == "_" {
+		return NewExitValue(CodeBadConfig, "Option go-package must be a valid Go package name")
+	}
+
+	if skipCount := genGoWalker(dir, dir, 5); skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// genGoWalker generates Go code for dir, and recursively calls itself on any
+// subdirs. topDir is the dir that the command was invoked from, which is used
+// for determining output file paths. It returns the number of dirs which could
+// not be processed due to errors.
+func genGoWalker(dir, topDir *fs.Dir, maxDepth int) (skipCount int) {
+	if dir.HasSchema() {
+		if err := genGoDir(dir, topDir); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += genGoWalker(sub, topDir, maxDepth-1)
+		}
+	}
+	return skipCount
+}
+
+// genGoDir generates Go code for a single directory, without recursing into
+// subdirs.
+func genGoDir(dir, topDir *fs.Dir) error {
+	schema, err := execDirSchema(dir)
+	if err != nil || schema == nil {
+		return err
+	}
+	nullable, _ := dir.Config.GetEnum("nullable-types", string(goNullableSQL), string(goNullablePointer))
+	code, err := writeGoStructs(schema, dir.Config.Get("go-package"), goNullableTypes(nullable))
+	if err != nil {
+		return err
+	}
+	outputDir := dir.Config.Get("output-dir")
+	if outputDir == "" {
+		_, err := os.Stdout.Write(code)
+		return err
+	}
+
+	// Mirror the dir structure below the top dir, so that output file names
+	// cannot conflict
+	rel, err := filepath.Rel(topDir.Path, dir.Path)
+	if err != nil || rel == "." {
+		rel = dir.BaseName()
+	}
+	filePath := filepath.Join(outputDir, rel+".go")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filePath, code, 0666); err != nil {
+		return err
+	}
+	log.Infof("Wrote %s (%d bytes) -- Go structs for %s", filePath, len(code), dir)
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func init() {
+	summary := "Generate protobuf message definitions from the filesystem"
+	desc := `Generates a proto3 file declaring a message for each table in the filesystem
+representation, with one field per column. This is useful for mirroring
+database rows onto a message bus using protobuf. Table and column comments are
+included as protobuf comments. Objects matching ignore options are excluded.
+
+Column types are mapped to protobuf types using a default set of mappings, which
+may be overridden using the proto-type-map option. Nullable columns of scalar
+types are declared as optional.
+
+With --output-dir, one .proto file is written per schema directory, mirroring
+the directory structure; otherwise, definitions are written to STDOUT. When an
+output file already exists, field numbers are kept stable across runs: existing
+fields retain their previous numbers, new columns receive new numbers, and the
+numbers and names of removed columns are reserved so that they are never
+reused. Numbering stability is therefore only possible with --output-dir.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all definitions were generated
+successfully, or 2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("gen-proto", summary, desc, GenProtoHandler)
+	cmd.AddOption(mybase.StringOption("proto-package", 0, "", "Package name to use in generated .proto files; omit to use schema name"))
+	cmd.AddOption(mybase.StringOption("proto-type-map", 0, "", "Comma-separated list of mysql_type:proto_type overrides for column type mappings"))
+	cmd.AddOption(mybase.StringOption("output-dir", 0, "", "Write one file per schema to this directory, instead of STDOUT"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// GenProtoHandler is the handler method for `skeema gen-proto`
+func GenProtoHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := parseProtoTypeMap(dir.Config.GetSlice("proto-type-map", ',', true)); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	} else if pkg := dir.Config.Get("proto-package"); pkg != "" && !protoTypeRegexp.MatchString(pkg) {
+		return NewExitValue(CodeBadConfig, "Option proto-package must be a valid protobuf package name")
+	}
+
+	if skipCount := genProtoWalker(dir, dir, 5); skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// genProtoWalker generates protobuf definitions for dir, and recursively calls
+// itself on any subdirs. topDir is the dir that the command was invoked from,
+// which is used for determining output file paths. It returns the number of
+// dirs which could not be processed due to errors.
+func genProtoWalker(dir, topDir *fs.Dir, maxDepth int) (skipCount int) {
+	if dir.HasSchema() {
+		if err := genProtoDir(dir, topDir); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += genProtoWalker(sub, topDir, maxDepth-1)
+		}
+	}
+	return skipCount
+}
+
+// genProtoDir generates protobuf definitions for a single directory, without
+// recursing into subdirs.
+func genProtoDir(dir, topDir *fs.Dir) error {
+	typeMap, err := parseProtoTypeMap(dir.Config.GetSlice("proto-type-map", ',', true))
+	if err != nil {
+		return err
+	}
+	schema, err := execDirSchema(dir)
+	if err != nil || schema == nil {
+		return err
+	}
+	pkg := dir.Config.Get("proto-package")
+	if pkg == "" {
+		pkg = protoFieldName(schema.Name)
+	}
+	outputDir := dir.Config.Get("output-dir")
+	if outputDir == "" {
+		_, err := os.Stdout.WriteString(writeProtoMessages(schema, pkg, typeMap, nil))
+		return err
+	}
+
+	// Mirror the dir structure below the top dir, so that output file names
+	// cannot conflict
+	rel, err := filepath.Rel(topDir.Path, dir.Path)
+	if err != nil || rel == "." {
+		rel = dir.BaseName()
+	}
+	filePath := filepath.Join(outputDir, rel+".proto")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return err
+	}
+	var prev map[string]*protoMessageNumbering
+	if contents, err := ioutil.ReadFile(filePath); err == nil {
+		prev = parseProtoNumbering(string(contents))
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	contents := writeProtoMessages(schema, pkg, typeMap, prev)
+	if err := ioutil.WriteFile(filePath, []byte(contents), 0666); err != nil {
+		return err
+	}
+	log.Infof("Wrote %s (%d bytes) -- protobuf messages for %s", filePath, len(contents), dir)
+	return nil
+}
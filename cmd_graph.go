@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Export an entity-relationship diagram of the filesystem representation"
+	desc := `Outputs the relationship graph of the tables in the filesystem representation,
+as derived from foreign keys. With --infer-relations, relationships are also
+inferred from column naming conventions: a column such as user_id is assumed to
+reference a table named user or users in the same schema.
+
+Output is in Graphviz DOT format by default, or a Mermaid entity-relationship
+diagram with --format=mermaid. With --format=dbml, output is in DBML format, as
+used by dbdiagram.io and dbdocs.io; this also includes each table's columns,
+indexes, and comments. Without --output-dir, a single graph covering all
+schema directories at or below the current directory is written to STDOUT. With
+--output-dir, one file is written per schema directory instead, mirroring the
+directory structure.
+
+This command relies on accessing database instances to convert the *.sql files
+into a full representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if the graph was generated successfully, or
+2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("graph", summary, desc, GraphHandler)
+	cmd.AddOption(mybase.StringOption("format", 0, "DOT", `Output format for graph (valid values: "DOT", "MERMAID", "DBML")`))
+	cmd.AddOption(mybase.StringOption("output-dir", 0, "", "Write one file per schema to this directory, instead of a single graph to STDOUT"))
+	cmd.AddOption(mybase.BoolOption("infer-relations", 0, false, "Also infer relationships from column names, such as user_id referencing users"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// GraphHandler is the handler method for `skeema graph`
+func GraphHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := dir.Config.GetEnum("format", "dot", "mermaid", "dbml"); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	// Without an output dir, all schemas are collected into a single graph
+	var schemas []*tengo.Schema
+	perDir := (dir.Config.Get("output-dir") != "")
+	skipCount := graphWalker(dir, dir, 5, perDir, &schemas)
+	if !perDir {
+		g := buildSchemaGraph(schemas, dir.Config.GetBool("infer-relations"))
+		if err := writeGraph(os.Stdout, dir, g); err != nil {
+			return err
+		}
+	}
+	if skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural, plural)
+	}
+	return nil
+}
+
+// graphWalker obtains the schema for dir, and recursively calls itself on any
+// subdirs. If perDir is true, a graph file is written for each dir; otherwise
+// the schemas are appended to *schemas. It returns the number of dirs which
+// could not be processed due to errors.
+func graphWalker(dir, topDir *fs.Dir, maxDepth int, perDir bool, schemas *[]*tengo.Schema) (skipCount int) {
+	if dir.HasSchema() {
+		schema, err := execDirSchema(dir)
+		if err == nil && schema != nil {
+			if perDir {
+				err = writeGraphFile(dir, topDir, schema)
+			} else {
+				*schemas = append(*schemas, schema)
+			}
+		}
+		if err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += graphWalker(sub, topDir, maxDepth-1, perDir, schemas)
+		}
+	}
+	return skipCount
+}
+
+// writeGraph writes g to w, in the format specified by dir's configuration.
+func writeGraph(w io.Writer, dir *fs.Dir, g *schemaGraph) error {
+	switch format, _ := dir.Config.GetEnum("format", "dot", "mermaid", "dbml"); format {
+	case "mermaid":
+		return writeGraphMermaid(w, g)
+	case "dbml":
+		return writeGraphDBML(w, g)
+	}
+	return writeGraphDOT(w, g)
+}
+
+// writeGraphFile writes the graph of a single schema to a file in the output
+// directory.
+func writeGraphFile(dir, topDir *fs.Dir, schema *tengo.Schema) error {
+	ext := ".dot"
+	switch format, _ := dir.Config.GetEnum("format", "dot", "mermaid", "dbml"); format {
+	case "mermaid":
+		ext = ".mmd"
+	case "dbml":
+		ext = ".dbml"
+	}
+	rel, err := filepath.Rel(topDir.Path, dir.Path)
+	if err != nil || rel == "." {
+		rel = dir.BaseName()
+	}
+	filePath := filepath.Join(dir.Config.Get("output-dir"), rel+ext)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	g := buildSchemaGraph([]*tengo.Schema{schema}, dir.Config.GetBool("infer-relations"))
+	if err := writeGraph(&buf, dir, g); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filePath, buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	log.Infof("Wrote %s (%d bytes) -- graph for %s", filePath, buf.Len(), dir)
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Show pushes previously recorded on DB instances"
+	desc := `Displays the history of ` + "`" + `skeema push` + "`" + ` operations recorded on the database
+instance(s) that the current directory and its subdirectories map to. History is
+only recorded if the history-schema option was set at the time of each push; in
+this case, each push to a schema inserts a row into the skeema_history table of
+that schema on the instance, describing who ran the push and when, the git
+commit of the directory, each statement executed along with its duration, and
+the outcome.
+
+The history-schema option should be configured the same way for this command as
+for ` + "`" + `skeema push` + "`" + `, typically in a top-level .skeema file.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for determining which instances to
+query. If no environment name is supplied, the default is "production".`
+
+	cmd := mybase.NewCommand("history", summary, desc, HistoryHandler)
+	cmd.AddOption(mybase.StringOption("history-schema", 0, "", "Schema containing the skeema_history table to query"))
+	cmd.AddOption(mybase.StringOption("since", 0, "", "Only show pushes since this duration ago (such as 24h) or date (YYYY-MM-DD)"))
+	cmd.AddOption(mybase.StringOption("limit", 0, "20", "Maximum number of pushes to show per instance; 0 for no limit"))
+	cmd.AddOption(mybase.StringOption("format", 0, "TEXT", `Output format (valid values: "TEXT", "JSON")`))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// historyInstance is a database instance to query for push history, along with
+// the schema containing its history table.
+type historyInstance struct {
+	Instance      *tengo.Instance         `json:"-"`
+	Name          string                  `json:"instance"`
+	HistorySchema string                  `json:"history_schema"`
+	Entries       []*applier.HistoryEntry `json:"pushes"`
+}
+
+// HistoryHandler is the handler method for `skeema history`
+func HistoryHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	format, err := dir.Config.GetEnum("format", "text", "json")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	var filter applier.HistoryFilter
+	if filter.Limit, err = dir.Config.GetInt("limit"); err == nil && filter.Limit < 0 {
+		err = fmt.Errorf("limit cannot be negative")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if filter.Since, err = parseHistorySince(dir.Config.Get("since"), time.Now()); err != nil {
+		return NewExitValue(CodeBadConfig, "Invalid value for option since: %s", err)
+	}
+
+	instances, skipCount := historyInstances(dir, 5)
+	if len(instances) == 0 && skipCount == 0 {
+		return NewExitValue(CodeBadConfig, "No instances with history-schema configured were found for environment %q", dir.Config.Get("environment"))
+	}
+	for _, hi := range instances {
+		if hi.Entries, err = applier.ReadHistory(hi.Instance, hi.HistorySchema, filter); err != nil {
+			log.Errorf("Unable to read push history from %s: %s", hi.Instance, err)
+			skipCount++
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(instances); err != nil {
+			return err
+		}
+	} else {
+		for _, hi := range instances {
+			printHistory(hi)
+		}
+	}
+
+	if skipCount > 0 {
+		return NewExitValue(CodePartialError, "Skipped %d operation%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	}
+	return nil
+}
+
+// historyInstances returns the distinct instances, and corresponding history
+// schemas, of dir and its subdirs up to maxDepth levels deep. Dirs without a
+// history-schema configured are ignored. The second return value is the
+// number of dirs which could not be processed due to errors.
+func historyInstances(dir *fs.Dir, maxDepth int) (instances []*historyInstance, skipCount int) {
+	seen := make(map[string]bool)
+	var walk func(dir *fs.Dir, depth int)
+	walk = func(dir *fs.Dir, depth int) {
+		if historySchema := dir.Config.Get("history-schema"); historySchema != "" && dir.Config.Changed("host") {
+			dirInstances, err := dir.Instances()
+			if err != nil {
+				log.Errorf("Skipping %s: %s", dir, err)
+				skipCount++
+			}
+			for _, inst := range dirInstances {
+				key := inst.String() + " " + historySchema
+				if !seen[key] {
+					seen[key] = true
+					instances = append(instances, &historyInstance{
+						Instance:      inst,
+						Name:          inst.String(),
+						HistorySchema: historySchema,
+					})
+				}
+			}
+		}
+		if depth >= maxDepth {
+			return
+		}
+		subdirs, badCount, err := dir.Subdirs()
+		if err != nil {
+			log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+			skipCount++
+		}
+		skipCount += badCount
+		for _, sub := range subdirs {
+			walk(sub, depth+1)
+		}
+	}
+	walk(dir, 0)
+	return instances, skipCount
+}
+
+// parseHistorySince converts the value of the since option, relative to now,
+// into a time. A blank value returns the zero time.
+func parseHistorySince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration cannot be negative")
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration (such as 24h) or date (YYYY-MM-DD)", value)
+}
+
+// printHistory outputs the recorded pushes of hi to STDOUT, along with each
+// statement executed.
+func printHistory(hi *historyInstance) {
+	if len(hi.Entries) == 0 {
+		fmt.Printf("%s: no pushes recorded\n", hi.Name)
+		return
+	}
+	for _, entry := range hi.Entries {
+		who := entry.OSUser
+		if entry.ClientHost != "" {
+			who += "@" + entry.ClientHost
+		}
+		if entry.DBUser != "" {
+			who += " as " + entry.DBUser
+		}
+		fmt.Printf("%s %s %s: %s by %s, %d statement%s in %s (#%d)\n",
+			entry.StartedAt.Format("2006-01-02 15:04:05 UTC"),
+			hi.Name,
+			tengo.EscapeIdentifier(entry.SchemaName),
+			entry.Outcome,
+			who,
+			len(entry.Statements), plural(len(entry.Statements)),
+			entry.Duration(),
+			entry.ID)
+		source := entry.Source
+		if entry.GitSHA != "" {
+			source += " @ " + entry.GitSHA
+		}
+		fmt.Printf("  environment %s, from %s\n", entry.Environment, source)
+		for _, stmt := range entry.Statements {
+			fmt.Printf("  [%s] %s\n", time.Duration(stmt.DurationMs)*time.Millisecond, strings.Replace(stmt.Statement, "\n", "\n    ", -1))
+			if stmt.Error != "" {
+				fmt.Printf("    Error: %s\n", stmt.Error)
+			}
+		}
+	}
+}
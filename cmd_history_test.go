@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHistorySince(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":           {},
+		"24h":        now.Add(-24 * time.Hour),
+		"90m":        now.Add(-90 * time.Minute),
+		"2020-04-15": time.Date(2020, 4, 15, 0, 0, 0, 0, time.Local),
+	}
+	for input, expected := range cases {
+		if actual, err := parseHistorySince(input, now); err != nil || !actual.Equal(expected) {
+			t.Errorf("Unexpected result from parseHistorySince(%q): %v, %v", input, actual, err)
+		}
+	}
+	for _, input := range []string{"-5h", "yesterday", "2020-13-01"} {
+		if _, err := parseHistorySince(input, now); err == nil {
+			t.Errorf("Expected error from parseHistorySince(%q), but err was nil", input)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+)
+
+func init() {
+	summary := "Replay a directory of migrations to populate a schema dir"
+	desc := `Populates the current schema directory with *.sql files representing the final
+state of an existing directory of imperative migrations. This permits adopting
+Skeema's declarative approach for a schema previously managed using a
+migration tool, without needing a database instance reflecting the result of
+all migrations.
+
+The migration dir may use Flyway naming (V<version>__<description>.sql, with
+repeatable R__<description>.sql migrations run after all versioned ones) or
+golang-migrate naming (<version>_<description>.up.sql; down migrations are
+ignored). Versioned migrations are run in version order, in a single workspace,
+stopping at the first error. The resulting schema is then introspected and
+written to the current directory, one file per object, as with ` + "`" + `skeema init` + "`" + `.
+
+The workspace is configured by the current directory's workspace options. Any
+USE commands in migrations are skipped, since all migrations are run in the
+workspace schema. Rows inserted by migrations are discarded.
+
+The current directory must already be a schema directory, and must not contain
+any *.sql files.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. If no environment
+name is supplied, the default is "production".`
+
+	cmd := mybase.NewCommand("import-migrations", summary, desc, ImportMigrationsHandler)
+	cmd.AddArg("migration-dir", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+var (
+	flywayVersionedRegexp      = regexp.MustCompile(`^V(\d+(?:[._]\d+)*)__.*\.sql$`)
+	flywayRepeatableRegexp     = regexp.MustCompile(`^R__.*\.sql$`)
+	flywayUndoRegexp           = regexp.MustCompile(`^U\d+(?:[._]\d+)*__.*\.sql$`)
+	golangMigrateUpRegexp      = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+	golangMigrateDownRegexp    = regexp.MustCompile(`^\d+_.*\.down\.sql$`)
+	migrationVersionPartRegexp = regexp.MustCompile(`[._]`)
+)
+
+// ImportMigrationsHandler is the handler method for `skeema import-migrations`
+func ImportMigrationsHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if !dir.HasSchema() {
+		return NewExitValue(CodeBadConfig, "Dir %s does not define a schema; `skeema import-migrations` must be run in a schema dir", dir)
+	} else if len(dir.SQLFiles) > 0 {
+		return NewExitValue(CodeBadUsage, "Dir %s already contains *.sql files; `skeema import-migrations` must be run in a schema dir without any *.sql files", dir)
+	}
+
+	migrationDir := cfg.Get("migration-dir")
+	fileNames, err := orderedMigrationFiles(migrationDir)
+	if err != nil {
+		return NewExitValue(CodeBadInput, err.Error())
+	} else if len(fileNames) == 0 {
+		return NewExitValue(CodeNoInput, "No Flyway or golang-migrate migration files found in %s", migrationDir)
+	}
+	var statements []*fs.Statement
+	for _, fileName := range fileNames {
+		sqlFile := fs.SQLFile{Dir: migrationDir, FileName: fileName}
+		tokenizedFile, err := sqlFile.Tokenize()
+		if err != nil {
+			return NewExitValue(CodeBadInput, "Unable to read %s: %s", sqlFile.Path(), err)
+		}
+		for _, stmt := range tokenizedFile.Statements {
+			if stmt.Type == fs.StatementTypeNoop {
+				continue
+			} else if stmt.Type == fs.StatementTypeCommand {
+				if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(stmt.Text)), "delimiter") {
+					log.Warnf("Skipping command at %s: %s", stmt.Location(), strings.TrimSpace(stmt.Text))
+				}
+				continue
+			}
+			statements = append(statements, stmt)
+		}
+	}
+	log.Infof("Replaying %d statement%s from %d migration file%s in %s", len(statements), plural(len(statements)), len(fileNames), plural(len(fileNames)), migrationDir)
+
+	opts, err := standaloneWorkspaceOptions(dir)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	opts.LoadSeeds = false
+	schema, err := workspace.ExecStatements(statements, opts)
+	if stmtErr, ok := err.(*workspace.StatementError); ok {
+		return NewExitValue(CodeBadInput, "Unable to replay migrations: %s", stmtErr)
+	} else if err != nil {
+		return NewExitValue(CodeFatalError, err.Error())
+	}
+
+	// The introspected schema has the workspace's name, which must be replaced
+	// for PopulateSchemaDir to accept it
+	schema.Name = dir.Config.Get("schema")
+	return PopulateSchemaDir(schema, dir, false)
+}
+
+// orderedMigrationFiles returns the names of the migration files in dirPath
+// which should be replayed, in the order they should be run. All migrations in
+// the dir must use the same naming scheme, either Flyway or golang-migrate.
+// Files not matching either naming scheme are skipped, with a warning.
+func orderedMigrationFiles(dirPath string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	type versionedFile struct {
+		name    string
+		version []uint64
+	}
+	var versioned []versionedFile
+	var repeatable []string
+	var flyway, golangMigrate bool
+	for _, fi := range fileInfos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		var version string
+		if matches := flywayVersionedRegexp.FindStringSubmatch(name); matches != nil {
+			flyway, version = true, matches[1]
+		} else if matches := golangMigrateUpRegexp.FindStringSubmatch(name); matches != nil {
+			golangMigrate, version = true, matches[1]
+		} else if flywayRepeatableRegexp.MatchString(name) {
+			flyway = true
+			repeatable = append(repeatable, name)
+			continue
+		} else if flywayUndoRegexp.MatchString(name) || golangMigrateDownRegexp.MatchString(name) {
+			continue
+		} else {
+			log.Warnf("Skipping %s: file name does not follow Flyway or golang-migrate naming", filepath.Join(dirPath, name))
+			continue
+		}
+		vf := versionedFile{name: name}
+		for _, part := range migrationVersionPartRegexp.Split(version, -1) {
+			n, err := strconv.ParseUint(part, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid migration version in %s: %s", filepath.Join(dirPath, name), err)
+			}
+			vf.version = append(vf.version, n)
+		}
+		versioned = append(versioned, vf)
+	}
+	if flyway && golangMigrate {
+		return nil, fmt.Errorf("Dir %s mixes Flyway and golang-migrate naming", dirPath)
+	}
+
+	compare := func(a, b []uint64) int {
+		for n := 0; n < len(a) || n < len(b); n++ {
+			var x, y uint64
+			if n < len(a) {
+				x = a[n]
+			}
+			if n < len(b) {
+				y = b[n]
+			}
+			if x < y {
+				return -1
+			} else if x > y {
+				return 1
+			}
+		}
+		return 0
+	}
+	sort.SliceStable(versioned, func(i, j int) bool {
+		return compare(versioned[i].version, versioned[j].version) < 0
+	})
+	fileNames := make([]string, 0, len(versioned)+len(repeatable))
+	for n, vf := range versioned {
+		if n > 0 && compare(versioned[n-1].version, vf.version) == 0 {
+			return nil, fmt.Errorf("Files %s and %s in %s have the same migration version", versioned[n-1].name, vf.name, dirPath)
+		}
+		fileNames = append(fileNames, vf.name)
+	}
+	sort.Strings(repeatable)
+	return append(fileNames, repeatable...), nil
+}
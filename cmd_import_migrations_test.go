@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func TestOrderedMigrationFiles(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+	write := func(names ...string) {
+		t.Helper()
+		for _, name := range names {
+			fs.WriteTestFile(t, filepath.Join(dirPath, name), "CREATE TABLE foo (id int);\n")
+		}
+	}
+	reset := func() {
+		t.Helper()
+		os.RemoveAll(dirPath)
+		if err := os.Mkdir(dirPath, 0777); err != nil {
+			t.Fatalf("Unable to recreate %s: %s", dirPath, err)
+		}
+	}
+
+	// Flyway: versions compared numerically by part, repeatables last, undo and
+	// unrelated files ignored
+	write("V10__c.sql", "V2__b.sql", "V1_1__a2.sql", "V1__a.sql", "R__views.sql", "U2__b.sql", "notes.sql", "README.md")
+	expected := []string{"V1__a.sql", "V1_1__a2.sql", "V2__b.sql", "V10__c.sql", "R__views.sql"}
+	if actual, err := orderedMigrationFiles(dirPath); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, instead found %v", expected, actual)
+	}
+
+	// Duplicate version
+	write("V1.0__dupe.sql")
+	if _, err := orderedMigrationFiles(dirPath); err == nil {
+		t.Error("Expected error from duplicate version, but err was nil")
+	}
+
+	// golang-migrate: down migrations ignored
+	reset()
+	write("20200102000000_b.up.sql", "20200102000000_b.down.sql", "20200101000000_a.up.sql", "20200101000000_a.down.sql")
+	expected = []string{"20200101000000_a.up.sql", "20200102000000_b.up.sql"}
+	if actual, err := orderedMigrationFiles(dirPath); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, instead found %v", expected, actual)
+	}
+
+	// Mixed naming schemes
+	write("V3__c.sql")
+	if _, err := orderedMigrationFiles(dirPath); err == nil {
+		t.Error("Expected error from mixed naming schemes, but err was nil")
+	}
+
+	// Nonexistent dir
+	if _, err := orderedMigrationFiles(filepath.Join(dirPath, "missing")); err == nil {
+		t.Error("Expected error from nonexistent dir, but err was nil")
+	}
+}
+
+func TestImportMigrationsHandlerBadInput(t *testing.T) {
+	schemaDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(schemaDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(schemaDir); err != nil {
+		t.Fatalf("Unable to cd to %s: %s", schemaDir, err)
+	}
+
+	importMigrations := func(args string, expectedExitCode int) {
+		t.Helper()
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema import-migrations "+args)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d from `skeema import-migrations %s`, instead found %d", expectedExitCode, args, actual)
+		}
+	}
+
+	// Not a schema dir yet
+	importMigrations("migrations", CodeBadConfig)
+
+	fs.WriteTestFile(t, ".skeema", "schema=shop\n")
+	if err := os.Mkdir("migrations", 0777); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	importMigrations("missing", CodeBadInput)
+	importMigrations("migrations", CodeNoInput)
+	fs.WriteTestFile(t, "migrations/V1__a.sql", "CREATE TABLE foo (id int);\n")
+	fs.WriteTestFile(t, "migrations/1_a.up.sql", "CREATE TABLE foo (id int);\n")
+	importMigrations("migrations", CodeBadInput)
+
+	// Dir already has *.sql files
+	fs.WriteTestFile(t, "foo.sql", "CREATE TABLE foo (id int);\n")
+	importMigrations("migrations", CodeBadUsage)
+}
@@ -11,6 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -27,17 +28,27 @@ which section of .skeema config files the host and schema names are written to.
 For example, running ` + "`" + `skeema init staging` + "`" + ` will add config directives to the
 [staging] section of config files. If no environment name is supplied, the
 default is "production", so directives will be written to the [production]
-section of the file.`
+section of the file.
+
+Alternatively, if the database server is not directly reachable, use the
+from-dump option to read CREATE statements from a mysqldump file or mydumper
+output directory instead of connecting to a live server. In this case --host is
+optional; if supplied, it is only written to the .skeema file.`
 
 	cmd := mybase.NewCommand("init", summary, desc, InitHandler)
-	cmd.AddOption(mybase.StringOption("host", 'h', "", "Database hostname or IP address"))
+	cmd.AddOption(mybase.StringOption("host", 'h', "", "Database hostname, IP address, or socket file path"))
 	cmd.AddOption(mybase.StringOption("port", 'P', "3306", "Port to use for database host"))
 	cmd.AddOption(mybase.StringOption("socket", 'S', "/tmp/mysql.sock", "Absolute path to Unix socket file used if host is localhost"))
 	cmd.AddOption(mybase.StringOption("dir", 'd', "<hostname>", "Base dir to use for this host's schemas"))
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Only import the one specified schema; skip creation of subdirs for each schema"))
 	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "Include starting auto-inc values in table files"))
 	cmd.AddOption(mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex"))
+	cmd.AddOption(mybase.StringOption("schema-map", 0, "", "Comma-separated regex=subdir rules for mapping many schemas onto a single dir"))
 	cmd.AddOption(mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex"))
+	cmd.AddOption(mybase.StringOption("ignore-proc", 0, "", "Ignore stored procedures that match regex"))
+	cmd.AddOption(mybase.StringOption("ignore-func", 0, "", "Ignore functions that match regex"))
+	cmd.AddOption(mybase.StringOption("ignore-object-type", 0, "", "Ignore all objects of these types (comma-separated list)"))
+	cmd.AddOption(mybase.StringOption("from-dump", 0, "", "Read schemas from this mysqldump file or mydumper dir instead of connecting to --host"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 }
@@ -54,18 +65,55 @@ func InitHandler(cfg *mybase.Config) error {
 	}
 	separateSchemaSubdir := (onlySchema == "")
 
-	if !cfg.OnCLI("host") {
+	dumpPath := cfg.Get("from-dump")
+	if dumpPath == "" && !cfg.OnCLI("host") {
 		return NewExitValue(CodeBadConfig, "Option --host must be supplied on the command-line")
 	}
 	if !cfg.Changed("dir") { // default for dir is to base it on the hostname
 		port := cfg.GetIntOrDefault("port")
-		if port > 0 && cfg.Changed("port") {
+		if !cfg.OnCLI("host") { // dump file without any host: base it on the dump's name
+			hostDirName = strings.TrimSuffix(path.Base(dumpPath), ".sql")
+		} else if path.IsAbs(cfg.Get("host")) { // host is a socket file path
+			hostDirName = "localhost"
+		} else if util.IsDiscoveryHost(cfg.Get("host")) { // e.g. srv:NAME or consul:SERVICE?tag=TAG
+			hostDirName = strings.SplitN(cfg.Get("host"), "?", 2)[0]
+			hostDirName = hostDirName[strings.IndexByte(hostDirName, ':')+1:]
+		} else if port > 0 && cfg.Changed("port") {
 			hostDirName = fmt.Sprintf("%s:%d", cfg.Get("host"), port)
 		} else {
 			hostDirName = cfg.Get("host")
 		}
 	}
 
+	environment := cfg.Get("environment")
+	if environment == "" || strings.ContainsAny(environment, "[]\n\r") {
+		return NewExitValue(CodeBadConfig, "Environment name \"%s\" is invalid", environment)
+	}
+
+	// Parse the dump before touching the filesystem, so that the dir may still be
+	// re-used after correcting any problems with the dump
+	var schemas []*tengo.Schema
+	var err error
+	if dumpPath != "" {
+		if schemas, err = readDump(dumpPath, onlySchema); err != nil {
+			return NewExitValue(CodeBadConfig, "Unable to read dump: %s", err)
+		}
+		if onlySchema != "" {
+			var found *tengo.Schema
+			for _, s := range schemas {
+				if s.Name == onlySchema {
+					found = s
+				}
+			}
+			if found == nil {
+				return NewExitValue(CodeBadConfig, "Schema %s does not exist in dump %s", onlySchema, dumpPath)
+			}
+			schemas = []*tengo.Schema{found}
+		} else if len(schemas) == 0 {
+			return NewExitValue(CodeBadConfig, "Dump %s does not contain any schemas", dumpPath)
+		}
+	}
+
 	wasNewDir, err := preparePath(hostDirName, cfg)
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
@@ -74,49 +122,70 @@ func InitHandler(cfg *mybase.Config) error {
 	if err != nil {
 		return err
 	}
+	hostOptionFile := mybase.NewFile(hostDir.Path, ".skeema")
 
-	// Validate connection-related options (host, port, socket, user, password) by
-	// testing connection. This is done before writing an option file, so that the
-	// dir may still be re-used after correcting any problems in CLI options
-	inst, err := hostDir.FirstInstance()
-	if err != nil {
-		return err
-	} else if inst == nil {
-		return NewExitValue(CodeBadConfig, "Command line did not specify which instance to connect to")
-	}
+	var nonStrictWarning, source string
+	if dumpPath != "" {
+		source = "dump " + dumpPath
+		// Without a connection, just persist whichever connection options were
+		// supplied, as-is
+		for _, persistOpt := range []string{"host", "port", "socket", "flavor"} {
+			if cfg.OnCLI(persistOpt) {
+				hostOptionFile.SetOptionValue(environment, persistOpt, cfg.Get(persistOpt))
+			}
+		}
+	} else {
+		// Validate connection-related options (host, port, socket, user, password) by
+		// testing connection. This is done before writing an option file, so that the
+		// dir may still be re-used after correcting any problems in CLI options
+		inst, err := hostDir.FirstInstance()
+		if err != nil {
+			return err
+		} else if inst == nil {
+			return NewExitValue(CodeBadConfig, "Command line did not specify which instance to connect to")
+		}
+		source = inst.String()
 
-	environment := cfg.Get("environment")
-	if environment == "" || strings.ContainsAny(environment, "[]\n\r") {
-		return NewExitValue(CodeBadConfig, "Environment name \"%s\" is invalid", environment)
-	}
+		// Build list of schemas
+		schemaNameFilter := []string{}
+		if onlySchema != "" {
+			schemaNameFilter = []string{onlySchema}
+		}
+		schemas, err = inst.Schemas(schemaNameFilter...)
+		if err != nil {
+			return NewExitValue(CodeFatalError, "Cannot examine schemas on %s: %s", inst, err)
+		}
+		if onlySchema != "" && len(schemas) == 0 {
+			return NewExitValue(CodeBadConfig, "Schema %s does not exist on instance %s", onlySchema, inst)
+		}
 
-	// Build list of schemas
-	schemaNameFilter := []string{}
-	if onlySchema != "" {
-		schemaNameFilter = []string{onlySchema}
-	}
-	schemas, err := inst.Schemas(schemaNameFilter...)
-	if err != nil {
-		return NewExitValue(CodeFatalError, "Cannot examine schemas on %s: %s", inst, err)
-	}
-	if onlySchema != "" && len(schemas) == 0 {
-		return NewExitValue(CodeBadConfig, "Schema %s does not exist on instance %s", onlySchema, inst)
-	}
+		// Figure out what needs to go in the hostDir's .skeema file.
+		hostOptionFile.SetOptionValue(environment, "host", inst.Host)
+		if util.IsDiscoveryHost(cfg.Get("host")) {
+			// Persist the service discovery reference, rather than its current result
+			hostOptionFile.SetOptionValue(environment, "host", cfg.Get("host"))
+		} else if inst.Host == "localhost" && inst.SocketPath != "" {
+			hostOptionFile.SetOptionValue(environment, "socket", inst.SocketPath)
+		} else {
+			hostOptionFile.SetOptionValue(environment, "port", strconv.Itoa(inst.Port))
+		}
+		if flavor := inst.Flavor(); !flavor.Known() {
+			log.Warnf("Unable to automatically determine database vendor/version. To set manually, use the \"flavor\" option in %s", hostOptionFile)
+		} else {
+			hostOptionFile.SetOptionValue(environment, "flavor", flavor.String())
+		}
 
-	// Figure out what needs to go in the hostDir's .skeema file.
-	hostOptionFile := mybase.NewFile(hostDir.Path, ".skeema")
-	hostOptionFile.SetOptionValue(environment, "host", inst.Host)
-	if inst.Host == "localhost" && inst.SocketPath != "" {
-		hostOptionFile.SetOptionValue(environment, "socket", inst.SocketPath)
-	} else {
-		hostOptionFile.SetOptionValue(environment, "port", strconv.Itoa(inst.Port))
-	}
-	if flavor := inst.Flavor(); !flavor.Known() {
-		log.Warnf("Unable to automatically determine database vendor/version. To set manually, use the \"flavor\" option in %s", hostOptionFile)
-	} else {
-		hostOptionFile.SetOptionValue(environment, "flavor", flavor.String())
+		// By default, Skeema normally connects using strict sql_mode as well as
+		// innodb_strict_mode=1; see InstanceDefaultParams() in fs/dir.go. If existing
+		// tables aren't recreatable with those settings though, disable them.
+		if !cfg.OnCLI("connect-options") {
+			if compliant, err := inst.StrictModeCompliant(schemas); err == nil && !compliant {
+				nonStrictWarning = fmt.Sprintf("Detected some tables are incompatible with strict-mode; setting relaxed connect-options in %s\n", hostOptionFile)
+				hostOptionFile.SetOptionValue(environment, "connect-options", "innodb_strict_mode=0,sql_mode='ONLY_FULL_GROUP_BY,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION'")
+			}
+		}
 	}
-	for _, persistOpt := range []string{"user", "ignore-schema", "ignore-table", "connect-options"} {
+	for _, persistOpt := range []string{"user", "consul-addr", "ignore-schema", "schema-map", "ignore-table", "ignore-proc", "ignore-func", "ignore-object-type", "connect-options", "type-subdirs"} {
 		if cfg.OnCLI(persistOpt) {
 			hostOptionFile.SetOptionValue(environment, persistOpt, cfg.Get(persistOpt))
 		}
@@ -125,19 +194,7 @@ func InitHandler(cfg *mybase.Config) error {
 		// schema name is placed outside of any named section/environment since the
 		// default assumption is that schema names match between environments
 		hostOptionFile.SetOptionValue("", "schema", onlySchema)
-		hostOptionFile.SetOptionValue("", "default-character-set", schemas[0].CharSet)
-		hostOptionFile.SetOptionValue("", "default-collation", schemas[0].Collation)
-	}
-
-	// By default, Skeema normally connects using strict sql_mode as well as
-	// innodb_strict_mode=1; see InstanceDefaultParams() in fs/dir.go. If existing
-	// tables aren't recreatable with those settings though, disable them.
-	var nonStrictWarning string
-	if !cfg.OnCLI("connect-options") {
-		if compliant, err := inst.StrictModeCompliant(schemas); err == nil && !compliant {
-			nonStrictWarning = fmt.Sprintf("Detected some tables are incompatible with strict-mode; setting relaxed connect-options in %s\n", hostOptionFile)
-			hostOptionFile.SetOptionValue(environment, "connect-options", "innodb_strict_mode=0,sql_mode='ONLY_FULL_GROUP_BY,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION'")
-		}
+		setSchemaCharSetOptions(hostOptionFile, schemas[0])
 	}
 
 	// Write the option file
@@ -156,7 +213,7 @@ func InitHandler(cfg *mybase.Config) error {
 	if nonStrictWarning == "" {
 		suffix += "\n"
 	}
-	log.Infof("%s host dir %s for %s%s", verb, hostDir.Path, inst, suffix)
+	log.Infof("%s host dir %s for %s%s", verb, hostDir.Path, source, suffix)
 	if nonStrictWarning != "" {
 		log.Warn(nonStrictWarning)
 	}
@@ -178,8 +235,8 @@ func InitHandler(cfg *mybase.Config) error {
 // responsibility to ensure its .skeema option file exists and maps to the
 // correct schema name.
 func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) error {
-	// Ignore any attempt to populate a dir for the temp schema
-	if s.Name == parentDir.Config.Get("temp-schema") {
+	// Ignore any attempt to populate a dir for the temp schema or history schema
+	if s.Name == parentDir.Config.Get("temp-schema") || s.Name == parentDir.Config.Get("history-schema") {
 		return nil
 	}
 
@@ -192,7 +249,19 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 
 	var subPath string
 	if makeSubdir {
-		subPath = path.Join(parentDir.Path, s.Name)
+		// Schemas matching a schema-map rule share a single subdir, which maps to
+		// them using schema=*. Only the first such schema is used to populate it.
+		subdirName, schemaValue := s.Name, s.Name
+		if mappedName, err := parentDir.MappedSubdirName(s.Name); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if mappedName != "" {
+			subdirName, schemaValue = mappedName, "*"
+			if _, err := os.Stat(path.Join(parentDir.Path, subdirName, ".skeema")); err == nil {
+				log.Debugf("Skipping schema %s because schema-map already maps it to %s", s.Name, subdirName)
+				return nil
+			}
+		}
+		subPath = path.Join(parentDir.Path, subdirName)
 		if _, err := preparePath(subPath, parentDir.Config); err != nil {
 			return err
 		}
@@ -201,9 +270,8 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 		// any named section/environment since the default assumption is that schema
 		// names match between environments.
 		optionFile := mybase.NewFile(subPath, ".skeema")
-		optionFile.SetOptionValue("", "schema", s.Name)
-		optionFile.SetOptionValue("", "default-character-set", s.CharSet)
-		optionFile.SetOptionValue("", "default-collation", s.Collation)
+		optionFile.SetOptionValue("", "schema", schemaValue)
+		setSchemaCharSetOptions(optionFile, s)
 		if err := optionFile.Write(false); err != nil {
 			return NewExitValue(CodeCantCreate, "Unable to use directory %s for schema %s: Unable to write to %s: %s", subPath, s.Name, optionFile.Path(), err)
 		}
@@ -212,14 +280,14 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 	}
 
 	log.Infof("Populating %s", subPath)
-	ignoreTable, err := parentDir.Config.GetRegexp("ignore-table")
+	ignoreOpts, err := parentDir.IgnoreOptions()
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
 
 	for key, createStmt := range s.ObjectDefinitions() {
-		if key.Type == tengo.ObjectTypeTable && ignoreTable != nil && ignoreTable.MatchString(key.Name) {
-			log.Warnf("Skipping %s because ignore-table matched %s", key, ignoreTable)
+		if ignoreOpts.ShouldIgnore(key) {
+			log.Warnf("Skipping %s because it matches an ignore option", key)
 			continue
 		}
 		if key.Type == tengo.ObjectTypeTable && !parentDir.Config.GetBool("include-auto-inc") {
@@ -232,7 +300,7 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 			continue
 		}
 		createStmt = fs.AddDelimiter(createStmt)
-		filePath := fs.PathForObject(subPath, key.Name)
+		filePath := fs.PathForObjectKey(subPath, key, parentDir.Config.GetBool("type-subdirs"))
 		var bytesWritten int
 		if bytesWritten, _, err = fs.AppendToFile(filePath, createStmt); err != nil {
 			return NewExitValue(CodeCantCreate, "Unable to write to %s: %s", filePath, err)
@@ -244,6 +312,18 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 	return nil
 }
 
+// setSchemaCharSetOptions adds s's default character set and collation to the
+// option file, outside of any named section. Values that are unknown, which is
+// possible for schemas read from a dump, are omitted.
+func setSchemaCharSetOptions(optionFile *mybase.File, s *tengo.Schema) {
+	if s.CharSet != "" {
+		optionFile.SetOptionValue("", "default-character-set", s.CharSet)
+	}
+	if s.Collation != "" {
+		optionFile.SetOptionValue("", "default-collation", s.Collation)
+	}
+}
+
 func preparePath(dirPath string, globalConfig *mybase.Config) (created bool, err error) {
 	fi, err := os.Stat(dirPath)
 	if err == nil && !fi.IsDir() {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
@@ -27,24 +28,104 @@ apply config directives from the [staging] section of config files, as well as
 any sectionless directives at the top of the file. If no environment name is
 supplied, the default is "production".
 
+To process several environments in one invocation, supply a comma-separated
+list, for example ` + "`" + `skeema lint staging,production` + "`" + `. Each environment is
+processed in turn, and the exit code is the highest one from any environment.
+
 An exit code of 0 will be returned if no errors or warnings were emitted and all
 files were already formatted properly; 1 if any warnings were emitted and/or
 some files were reformatted; or 2+ if any errors were emitted for any reason.`
 
-	cmd := mybase.NewCommand("lint", summary, desc, LintHandler)
+	cmd := mybase.NewCommand("lint", summary, desc, multiEnvironmentHandler(LintHandler, "summary-json", "format", "github-check-run", "write-baseline"))
 	linter.AddCommandOptions(cmd)
+	cmd.AddOption(mybase.BoolOption("fix", 0, false, "Rewrite files to correct problems that can be fixed automatically"))
+	cmd.AddOption(mybase.StringOption("baseline", 0, "", "Path to file of known problems to ignore"))
+	cmd.AddOption(mybase.StringOption("git-base", 0, "", "Only lint objects whose definitions differ from the specified git ref, along with their dependents"))
+	cmd.AddOption(mybase.StringOption("changed-since", 0, "", "Only lint objects in files changed since the specified git ref, along with their dependents"))
+	cmd.AddOption(mybase.StringOption("format", 0, "TEXT", `Output format for problems found (valid values: "TEXT", "JSON", "SARIF", "GITHUB")`))
+	cmd.AddOption(mybase.StringOption("github-check-run", 0, "", "Name of GitHub check run to create with lint results, using GITHUB_TOKEN env var"))
+	cmd.AddOption(mybase.BoolOption("write-baseline", 0, false, "Write all current problems to the file specified by baseline"))
+	cmd.AddOption(mybase.StringOption("summary-json", 0, "", "Write a machine-readable summary of the run's outcome to this file"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 }
 
 // LintHandler is the handler method for `skeema lint`
-func LintHandler(cfg *mybase.Config) error {
+func LintHandler(cfg *mybase.Config) (err error) {
 	dir, err := fs.ParseDir(".", cfg)
 	if err != nil {
 		return err
 	}
+	summary := newRunSummary(dir.Config)
+	defer func() {
+		summary.finish(err)
+	}()
+
+	baselinePath := dir.Config.Get("baseline")
+	writeBaseline := dir.Config.GetBool("write-baseline")
+	var baseline linter.Baseline
+	if writeBaseline && baselinePath == "" {
+		return NewExitValue(CodeBadConfig, "Option write-baseline requires option baseline to also be set")
+	} else if baselinePath != "" && !writeBaseline {
+		if baseline, err = linter.ReadBaselineFile(baselinePath); err != nil {
+			return NewExitValue(CodeNoInput, "Unable to read baseline file %s: %s", baselinePath, err)
+		}
+	}
+
+	format, err := dir.Config.GetEnum("format", "text", "json", "sarif", "github")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	var checkRun *githubCheckRun
+	if checkRunName := dir.Config.Get("github-check-run"); checkRunName != "" {
+		if checkRun, err = newGitHubCheckRun(checkRunName); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+	}
+
+	var changed changedFiles
+	if ref := dir.Config.Get("changed-since"); ref != "" {
+		if changed, err = gitChangedFiles(dir.Path, ref); err != nil {
+			return NewExitValue(CodeBadConfig, "Unable to determine files changed since %s: %s", ref, err)
+		}
+		log.Debugf("Found %d files changed since %s", len(changed), ref)
+	}
+
+	var base *gitBase
+	if ref := dir.Config.Get("git-base"); ref != "" {
+		if base, err = newGitBase(dir.Path, ref, cfg); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		defer base.cleanup()
+	}
 
-	result := lintWalker(dir, 5)
+	result := lintWalker(dir, 5, baseline, changed, base)
+	defer summary.setLintResult(result)
+	switch format {
+	case "json":
+		err = writeLintJSON(os.Stdout, result)
+	case "sarif":
+		err = writeLintSARIF(os.Stdout, result)
+	case "github":
+		err = writeLintGitHub(os.Stdout, result)
+	}
+	if err != nil {
+		return err
+	}
+	if checkRun != nil {
+		if err := checkRun.Post(result); err != nil {
+			log.Errorf("Unable to create GitHub check run: %s", err)
+			result.Exceptions = append(result.Exceptions, err)
+		}
+	}
+	if writeBaseline {
+		annotations := append(result.Errors, result.Warnings...)
+		if err := linter.NewBaseline(annotations).Write(baselinePath); err != nil {
+			return NewExitValue(CodeCantCreate, "Unable to write baseline file %s: %s", baselinePath, err)
+		}
+		log.Infof("Wrote %s with %d known problems", baselinePath, len(annotations))
+		result.Errors, result.Warnings = nil, nil
+	}
 	switch {
 	case len(result.Exceptions) > 0:
 		exitCode := CodeFatalError
@@ -58,13 +139,43 @@ func LintHandler(cfg *mybase.Config) error {
 		return NewExitValue(CodeFatalError, "Found %d errors", len(result.Errors))
 	case len(result.Warnings) > 0:
 		return NewExitValue(CodeDifferencesFound, "Found %d warnings", len(result.Warnings))
-	case len(result.FormatNotices) > 0:
+	case len(result.FormatNotices) > 0 || len(result.Fixes) > 0:
 		return NewExitValue(CodeDifferencesFound, "")
 	}
 	return nil
 }
 
-func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
+func lintWalker(dir *fs.Dir, maxDepth int, baseline linter.Baseline, changed changedFiles, base *gitBase) (result *linter.Result) {
+	if changed != nil && !changed.affectsDir(dir) {
+		log.Debugf("Skipping %s: no changed files", dir)
+		result = &linter.Result{}
+	} else {
+		result = lintDir(dir, baseline, changed, base)
+	}
+
+	var subdirErr error
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
+	} else {
+		if badCount > 0 {
+			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+		}
+		for _, sub := range subdirs {
+			result.Merge(lintWalker(sub, maxDepth-1, baseline, changed, base))
+		}
+	}
+	if subdirErr != nil {
+		log.Error(subdirErr)
+		result.Exceptions = append(result.Exceptions, subdirErr)
+	}
+	return result
+}
+
+// lintDir lints a single directory, without recursing into subdirs. Format
+// notices and fixes are applied to files, and remaining problems are logged.
+func lintDir(dir *fs.Dir, baseline linter.Baseline, changed changedFiles, base *gitBase) (result *linter.Result) {
 	log.Infof("Linting %s", dir)
 
 	// Connect to first defined instance, unless configured to use local Docker
@@ -82,16 +193,16 @@ func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
 
 	if result == nil {
 		result = linter.LintDir(dir, opts)
+		if changed != nil {
+			changed.filterResult(result, dir)
+		}
+		if base != nil {
+			base.filterResult(result, dir)
+		}
 	}
 	for _, err := range result.Exceptions {
 		log.Error(fmt.Errorf("Skipping schema in %s due to error: %s", dir.RelPath(), err))
 	}
-	for _, annotation := range result.Errors {
-		log.Error(annotation.MessageWithLocation())
-	}
-	for _, annotation := range result.Warnings {
-		log.Warning(annotation.MessageWithLocation())
-	}
 	for _, annotation := range result.FormatNotices {
 		annotation.Statement.Text = annotation.Message
 		length, err := annotation.Statement.FromFile.Rewrite()
@@ -103,26 +214,58 @@ func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
 			log.Infof("Wrote %s (%d bytes) -- updated file to normalize format", annotation.Statement.File, length)
 		}
 	}
+	if dir.Config.GetBool("fix") {
+		result.Errors = fixAnnotations(result, result.Errors)
+		result.Warnings = fixAnnotations(result, result.Warnings)
+	}
+	if len(baseline) > 0 {
+		var knownErrors, knownWarnings []*linter.Annotation
+		result.Errors, knownErrors = baseline.Partition(result.Errors)
+		result.Warnings, knownWarnings = baseline.Partition(result.Warnings)
+		result.Baselined = append(knownErrors, knownWarnings...)
+		if ignored := len(result.Baselined); ignored > 0 {
+			log.Debugf("Ignoring %d known problems in %s listed in baseline file", ignored, dir.RelPath())
+		}
+	}
+	for _, annotation := range result.Errors {
+		log.Error(annotation.MessageWithLocation())
+	}
+	for _, annotation := range result.Warnings {
+		log.Warning(annotation.MessageWithLocation())
+	}
 	for _, dl := range result.DebugLogs {
 		log.Debug(dl)
 	}
+	return result
+}
 
-	var subdirErr error
-	if subdirs, badCount, err := dir.Subdirs(); err != nil {
-		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
-	} else if len(subdirs) > 0 && maxDepth <= 0 {
-		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
-	} else {
-		if badCount > 0 {
-			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+// fixAnnotations rewrites files to correct any annotations which support being
+// fixed automatically. Annotations whose fix does not alter the statement are
+// left unfixed. Successfully-fixed annotations are appended to
+// result.Fixes; the remaining annotations are returned.
+func fixAnnotations(result *linter.Result, annotations []*linter.Annotation) []*linter.Annotation {
+	remaining := make([]*linter.Annotation, 0, len(annotations))
+	for _, annotation := range annotations {
+		if annotation.Fix == nil || annotation.Statement.FromFile == nil {
+			remaining = append(remaining, annotation)
+			continue
 		}
-		for _, sub := range subdirs {
-			result.Merge(lintWalker(sub, maxDepth-1))
+		fixed := annotation.Fix(annotation.Statement.Text)
+		if fixed == annotation.Statement.Text {
+			remaining = append(remaining, annotation)
+			continue
+		}
+		annotation.Statement.Text = fixed
+		length, err := annotation.Statement.FromFile.Rewrite()
+		if err != nil {
+			writeErr := fmt.Errorf("Unable to write to %s: %s", annotation.Statement.File, err)
+			log.Error(writeErr.Error())
+			result.Exceptions = append(result.Exceptions, writeErr)
+			remaining = append(remaining, annotation)
+		} else {
+			log.Infof("Wrote %s (%d bytes) -- fixed problem %s: %s", annotation.Statement.File, length, annotation.Problem, annotation.DisplayMessage())
+			result.Fixes = append(result.Fixes, annotation)
 		}
 	}
-	if subdirErr != nil {
-		log.Error(subdirErr)
-		result.Exceptions = append(result.Exceptions, subdirErr)
-	}
-	return result
+	return remaining
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+)
+
+func TestFixAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	contents := "CREATE TABLE users (id int);\n"
+	filePath := filepath.Join(dir, "users.sql")
+	fs.WriteTestFile(t, filePath, contents)
+	tsf := &fs.TokenizedSQLFile{SQLFile: fs.SQLFile{Dir: dir, FileName: "users.sql"}}
+	stmt := &fs.Statement{File: filePath, Text: contents, Type: fs.StatementTypeCreate, FromFile: tsf}
+	tsf.Statements = []*fs.Statement{stmt}
+
+	// A fix which leaves the statement unchanged should not rewrite the file, and
+	// the annotation should remain unfixed
+	noop := &linter.Annotation{Statement: stmt, Problem: "noop", Fix: func(text string) string { return text }}
+	result := &linter.Result{}
+	remaining := fixAnnotations(result, []*linter.Annotation{noop})
+	if len(remaining) != 1 || remaining[0] != noop || len(result.Fixes) != 0 {
+		t.Errorf("Expected no-op fix to leave annotation unfixed; instead found remaining=%v fixes=%v", remaining, result.Fixes)
+	}
+
+	upper := &linter.Annotation{Statement: stmt, Problem: "upper", Fix: strings.ToUpper}
+	remaining = fixAnnotations(result, []*linter.Annotation{upper})
+	if len(remaining) != 0 || len(result.Fixes) != 1 || result.Fixes[0] != upper {
+		t.Errorf("Expected fix to succeed; instead found remaining=%v fixes=%v", remaining, result.Fixes)
+	}
+	if b, err := ioutil.ReadFile(filePath); err != nil || string(b) != strings.ToUpper(contents) {
+		t.Errorf("Expected file to be rewritten with fixed text; instead found %q, err=%v", b, err)
+	}
+}
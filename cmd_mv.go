@@ -0,0 +1,182 @@
+package main
+
+import (
+	"os"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Rename a table in the filesystem, and push it as a rename"
+	desc := `Renames a table in the current schema directory. The table's CREATE TABLE
+statement is rewritten to use the new name, as are any foreign keys in the
+directory referencing the table. If the table's *.sql file is named after the
+table and contains no other statements, the file is renamed as well.
+
+The rename is also recorded in the rename-table option of the directory's
+.skeema file. This way, the next ` + "`" + `skeema diff` + "`" + ` or ` + "`" + `skeema push` + "`" + ` outputs or runs a
+RENAME TABLE, rather than dropping the table and creating a new empty one. The
+recorded rename only applies to database instances which still have a table
+with the old name, and no table with the new name. Once the rename has been
+pushed to every environment, the rename-table entry should be removed, since
+a new table later created with the old name would otherwise be renamed
+unexpectedly.
+
+Stored procedures and functions in the directory which reference the table are
+not rewritten; a warning is logged for each one, so that they may be updated
+manually. Views are not tracked in the filesystem, so any views in the
+database which query the table must also be recreated manually after the
+rename is pushed.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. If no environment
+name is supplied, the default is "production".`
+
+	cmd := mybase.NewCommand("mv", summary, desc, MvHandler)
+	cmd.AddArg("from", "", true)
+	cmd.AddArg("to", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+var (
+	createTableNameRegexp = regexp.MustCompile("(?is)(CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?(?:(?:`(?:[^`]|``)+`|[0-9a-z$_]+)\\s*\\.\\s*)?)(`(?:[^`]|``)+`|[0-9a-z$_]+)")
+	referencesNameRegexp  = regexp.MustCompile("(?is)(REFERENCES\\s+)(`(?:[^`]|``)+`|[0-9a-z$_]+)(\\s*\\()")
+)
+
+// MvHandler is the handler method for `skeema mv`
+func MvHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if !dir.HasSchema() {
+		return NewExitValue(CodeBadConfig, "Dir %s does not define a schema; `skeema mv` must be run in a schema dir", dir)
+	}
+	rename := applier.TableRename{From: cfg.Get("from"), To: cfg.Get("to")}
+	if rename.From == rename.To {
+		return NewExitValue(CodeBadUsage, "Old and new table names must differ")
+	}
+	var logicalSchema *fs.LogicalSchema
+	for _, ls := range dir.LogicalSchemas {
+		if ls.Name == "" {
+			logicalSchema = ls
+		}
+	}
+	fromKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: rename.From}
+	toKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: rename.To}
+	if logicalSchema == nil || logicalSchema.Creates[fromKey] == nil {
+		return NewExitValue(CodeBadInput, "%s is not defined in %s", fromKey, dir)
+	} else if stmt := logicalSchema.Creates[toKey]; stmt != nil {
+		return NewExitValue(CodeBadInput, "%s is already defined at %s", toKey, stmt.Location())
+	}
+
+	// Rewrite the table's CREATE, and any foreign keys referencing it, rewriting
+	// each affected file once
+	stmt := logicalSchema.Creates[fromKey]
+	stmt.Text = renameInCreate(stmt.Text, rename)
+	rewriteFiles := map[*fs.TokenizedSQLFile]bool{stmt.FromFile: true}
+	var fkCount int
+	for _, other := range logicalSchema.Creates {
+		if other.ObjectType != tengo.ObjectTypeTable {
+			continue
+		}
+		if newText, count := renameInReferences(other.Text, rename); count > 0 {
+			other.Text = newText
+			fkCount += count
+			rewriteFiles[other.FromFile] = true
+		}
+	}
+	for tsf := range rewriteFiles {
+		if _, err := tsf.Rewrite(); err != nil {
+			return NewExitValue(CodeCantCreate, "Unable to write %s: %s", tsf.Path(), err)
+		}
+		log.Infof("Wrote %s", tsf.Path())
+	}
+	if fkCount > 0 {
+		log.Infof("Updated %d foreign key%s referencing %s", fkCount, plural(fkCount), tengo.EscapeIdentifier(rename.From))
+	}
+	for key, other := range logicalSchema.Creates {
+		if other.ObjectType != tengo.ObjectTypeTable && mentionsIdentifier(other.Text, rename.From) {
+			log.Warnf("%s at %s may reference %s, and must be updated manually", key, other.Location(), tengo.EscapeIdentifier(rename.From))
+		}
+	}
+
+	// Rename the file if it is named for the table and only defines the table
+	oldPath := stmt.File
+	newPath := fs.PathForObjectKey(dir.Path, toKey, dir.UsesTypeSubdirs())
+	if oldPath == fs.PathForObjectKey(dir.Path, fromKey, dir.UsesTypeSubdirs()) && oldPath != newPath && definesOnlyObject(stmt.FromFile) {
+		if _, err := os.Stat(newPath); err == nil {
+			log.Warnf("Not renaming %s, since %s already exists", oldPath, newPath)
+		} else if err := os.Rename(oldPath, newPath); err != nil {
+			return NewExitValue(CodeCantCreate, "Unable to rename %s: %s", oldPath, err)
+		} else {
+			log.Infof("Renamed %s to %s", oldPath, newPath)
+		}
+	}
+
+	// Record the rename in the dir's option file
+	dir.OptionFile.UseSection()
+	value, _ := dir.OptionFile.OptionValue("rename-table")
+	if value != "" {
+		value += ","
+	}
+	dir.OptionFile.SetOptionValue("", "rename-table", value+rename.String())
+	if err := dir.OptionFile.Write(true); err != nil {
+		return NewExitValue(CodeCantCreate, "Unable to write %s: %s", dir.OptionFile.Path(), err)
+	}
+	log.Infof("Recorded rename-table=%s in %s", rename, dir.OptionFile.Path())
+	return nil
+}
+
+// renameInCreate returns text with the table name in its CREATE TABLE changed
+// according to rename.
+func renameInCreate(text string, rename applier.TableRename) string {
+	loc := createTableNameRegexp.FindStringSubmatchIndex(text)
+	if loc == nil || unquoteIdentifier(text[loc[4]:loc[5]]) != rename.From {
+		return text
+	}
+	return text[:loc[4]] + tengo.EscapeIdentifier(rename.To) + text[loc[5]:]
+}
+
+// renameInReferences returns text with any unqualified foreign key references
+// to the table changed according to rename, along with the number of
+// references changed.
+func renameInReferences(text string, rename applier.TableRename) (string, int) {
+	var count int
+	newText := referencesNameRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		parts := referencesNameRegexp.FindStringSubmatch(match)
+		if unquoteIdentifier(parts[2]) != rename.From {
+			return match
+		}
+		count++
+		return parts[1] + tengo.EscapeIdentifier(rename.To) + parts[3]
+	})
+	return newText, count
+}
+
+// mentionsIdentifier returns true if text contains name as an identifier,
+// either backtick-quoted or bare. This may return false positives from string
+// literals or comments, which is acceptable since it is only used for warnings.
+func mentionsIdentifier(text, name string) bool {
+	quoted := regexp.QuoteMeta(tengo.EscapeIdentifier(name))
+	re := regexp.MustCompile("(?i)(?:^|[^0-9a-z$_`])(?:" + quoted + "|" + regexp.QuoteMeta(name) + ")(?:[^0-9a-z$_`]|$)")
+	return re.MatchString(text)
+}
+
+// definesOnlyObject returns true if tsf contains exactly one statement other
+// than comments, whitespace, and commands.
+func definesOnlyObject(tsf *fs.TokenizedSQLFile) bool {
+	var count int
+	for _, stmt := range tsf.Statements {
+		if stmt.Type != fs.StatementTypeNoop && stmt.Type != fs.StatementTypeCommand {
+			count++
+		}
+	}
+	return count == 1
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func TestMvHandler(t *testing.T) {
+	schemaDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(schemaDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(schemaDir); err != nil {
+		t.Fatalf("Unable to cd to %s: %s", schemaDir, err)
+	}
+
+	mv := func(args string, expectedExitCode int) {
+		t.Helper()
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema mv "+args)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d from `skeema mv %s`, instead found %d", expectedExitCode, args, actual)
+		}
+	}
+
+	// Not a schema dir yet
+	mv("orders purchases", CodeBadConfig)
+
+	fs.WriteTestFile(t, ".skeema", "schema=shop\n")
+	fs.WriteTestFile(t, "orders.sql", "CREATE TABLE `orders` (\n  id int NOT NULL,\n  PRIMARY KEY (id)\n);\n")
+	fs.WriteTestFile(t, "items.sql", "CREATE TABLE items (\n  id int NOT NULL,\n  order_id int NOT NULL,\n  PRIMARY KEY (id),\n  CONSTRAINT fk FOREIGN KEY (order_id) REFERENCES orders (id)\n);\n")
+	mv("orders orders", CodeBadUsage)
+	mv("missing purchases", CodeBadInput)
+	mv("orders items", CodeBadInput)
+
+	mv("orders purchases", CodeSuccess)
+	if _, err := os.Stat("orders.sql"); err == nil {
+		t.Error("Expected orders.sql to have been renamed, but it still exists")
+	}
+	if contents := fs.ReadTestFile(t, "purchases.sql"); !strings.HasPrefix(contents, "CREATE TABLE `purchases` (") {
+		t.Errorf("Unexpected contents of purchases.sql:\n%s", contents)
+	}
+	if contents := fs.ReadTestFile(t, "items.sql"); !strings.Contains(contents, "REFERENCES `purchases` (id)") {
+		t.Errorf("Foreign key in items.sql not updated as expected:\n%s", contents)
+	}
+
+	// Files defining multiple objects should be rewritten but not renamed
+	fs.WriteTestFile(t, "items.sql", fs.ReadTestFile(t, "items.sql")+"CREATE TABLE misc (id int);\n")
+	mv("items lines", CodeSuccess)
+	if contents := fs.ReadTestFile(t, "items.sql"); !strings.HasPrefix(contents, "CREATE TABLE `lines` (") {
+		t.Errorf("Unexpected contents of items.sql:\n%s", contents)
+	}
+	if contents := fs.ReadTestFile(t, ".skeema"); !strings.Contains(contents, "rename-table=orders:purchases,items:lines") {
+		t.Errorf("Unexpected contents of .skeema:\n%s", contents)
+	}
+}
+
+func TestMentionsIdentifier(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected bool
+	}{
+		{"SELECT * FROM orders WHERE id = 1", true},
+		{"SELECT * FROM `orders`", true},
+		{"SELECT * FROM shop.orders", true},
+		{"SELECT * FROM ORDERS", true},
+		{"SELECT * FROM orders_archive", false},
+		{"SELECT * FROM `old_orders`", false},
+		{"SELECT order_id FROM items", false},
+	}
+	for _, c := range cases {
+		if actual := mentionsIdentifier(c.text, "orders"); actual != c.expected {
+			t.Errorf("Expected mentionsIdentifier(%q, \"orders\") to return %t, instead found %t", c.text, c.expected, actual)
+		}
+	}
+}
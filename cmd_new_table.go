@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Create a *.sql file for a new table from a template"
+	desc := `Creates a new *.sql file in the current schema directory, containing a CREATE
+TABLE statement for the supplied table name. The statement is generated from a
+template, so that new tables can start out with a standard set of columns,
+storage engine, character set, and comment, in compliance with your lint rules.
+
+If the template option is not set, a built-in template is used, containing an
+auto-increment id primary key, created_at and updated_at timestamp columns, and
+a placeholder table comment. Otherwise, template should be the path to a file
+containing a single CREATE TABLE statement. Templates may contain the following
+variables, which are replaced with their values when generating the file:
+{TABLE}, {SCHEMA}, {ENGINE}, {CHARSET}, and {COLLATION}. The character set and
+collation are obtained from the directory's default-character-set and
+default-collation options.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. If no environment
+name is supplied, the default is "production".`
+
+	cmd := mybase.NewCommand("new-table", summary, desc, NewTableHandler)
+	cmd.AddOption(mybase.StringOption("template", 0, "", "Path to file containing CREATE TABLE template; omit to use built-in template"))
+	cmd.AddOption(mybase.StringOption("engine", 0, "InnoDB", "Storage engine to substitute for {ENGINE} in template"))
+	cmd.AddArg("name", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// defaultNewTableTemplate is the template used by `skeema new-table` if the
+// template option is not set.
+const defaultNewTableTemplate = "CREATE TABLE `{TABLE}` (\n" +
+	"  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+	"  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+	"  PRIMARY KEY (`id`)\n" +
+	") ENGINE={ENGINE} DEFAULT CHARSET={CHARSET}"
+
+// NewTableHandler is the handler method for `skeema new-table`
+func NewTableHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	if !dir.HasSchema() {
+		return NewExitValue(CodeBadConfig, "Dir %s does not define a schema; `skeema new-table` must be run in a schema dir", dir)
+	}
+	tableName := cfg.Get("name")
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: tableName}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		if stmt, ok := logicalSchema.Creates[key]; ok {
+			return NewExitValue(CodeBadInput, "%s is already defined at %s", key, stmt.Location())
+		}
+	}
+
+	template, err := newTableTemplate(dir)
+	if err != nil {
+		return NewExitValue(CodeNoInput, "Unable to read template: %s", err)
+	}
+	create, err := util.InterpolateVariables(template, newTableVariables(dir, tableName))
+	if err != nil {
+		return NewExitValue(CodeBadConfig, "Unable to process template: %s", err)
+	}
+	create = strings.TrimRight(create, "; \t\r\n")
+
+	filePath := fs.PathForObjectKey(dir.Path, key, dir.UsesTypeSubdirs())
+	if _, err := os.Stat(filePath); err == nil {
+		return NewExitValue(CodeCantCreate, "File %s already exists", filePath)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0777); err != nil {
+		return NewExitValue(CodeCantCreate, "Unable to create dir for %s: %s", filePath, err)
+	}
+	if err := ioutil.WriteFile(filePath, []byte(fs.AddDelimiter(create)), 0666); err != nil {
+		return NewExitValue(CodeCantCreate, "Unable to write %s: %s", filePath, err)
+	}
+
+	// Confirm the file contains the new table, so that a bad template doesn't
+	// leave behind a file that would break other commands
+	if err := verifyNewTableFile(filePath, tableName); err != nil {
+		os.Remove(filePath)
+		return NewExitValue(CodeBadInput, "Template did not produce a valid CREATE TABLE: %s", err)
+	}
+	log.Infof("Wrote %s -- new table %s", filePath, tengo.EscapeIdentifier(tableName))
+	return nil
+}
+
+// newTableTemplate returns the contents of the template file configured for
+// dir, or the built-in default template if none is configured.
+func newTableTemplate(dir *fs.Dir) (string, error) {
+	templatePath := dir.Config.Get("template")
+	if templatePath == "" {
+		template := defaultNewTableTemplate
+		if dir.Config.Get("default-collation") != "" {
+			template += " COLLATE={COLLATION}"
+		}
+		return template + " COMMENT='TODO: describe {TABLE}'", nil
+	}
+	contents, err := ioutil.ReadFile(templatePath)
+	return string(contents), err
+}
+
+// newTableVariables returns the variables available for interpolation in a
+// new-table template.
+func newTableVariables(dir *fs.Dir, tableName string) map[string]string {
+	charSet := dir.Config.Get("default-character-set")
+	if charSet == "" {
+		charSet = "utf8mb4"
+	}
+	return map[string]string{
+		"TABLE":     tableName,
+		"SCHEMA":    dir.Config.Get("schema"),
+		"ENGINE":    dir.Config.Get("engine"),
+		"CHARSET":   charSet,
+		"COLLATION": dir.Config.Get("default-collation"),
+	}
+}
+
+// verifyNewTableFile returns an error if the file at filePath does not consist
+// of a single CREATE TABLE statement for tableName.
+func verifyNewTableFile(filePath, tableName string) error {
+	sqlFile := fs.SQLFile{
+		Dir:      filepath.Dir(filePath),
+		FileName: filepath.Base(filePath),
+	}
+	tokenizedFile, err := sqlFile.Tokenize()
+	if err != nil {
+		return err
+	}
+	var creates []*fs.Statement
+	for _, stmt := range tokenizedFile.Statements {
+		if stmt.Type == fs.StatementTypeCreate {
+			creates = append(creates, stmt)
+		} else if stmt.Type != fs.StatementTypeNoop {
+			return fmt.Errorf("unexpected statement at %s", stmt.Location())
+		}
+	}
+	if len(creates) != 1 {
+		return fmt.Errorf("expected 1 CREATE statement, found %d", len(creates))
+	}
+	if creates[0].ObjectType != tengo.ObjectTypeTable || creates[0].ObjectName != tableName || creates[0].ObjectQualifier != "" {
+		return fmt.Errorf("expected CREATE TABLE %s, found CREATE %s %s", tengo.EscapeIdentifier(tableName), creates[0].ObjectType.Caps(), creates[0].ObjectName)
+	}
+	return nil
+}
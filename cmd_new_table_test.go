@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func TestNewTableHandler(t *testing.T) {
+	schemaDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(schemaDir)
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(schemaDir); err != nil {
+		t.Fatalf("Unable to cd to %s: %s", schemaDir, err)
+	}
+
+	newTable := func(args string, expectedExitCode int) {
+		t.Helper()
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema new-table "+args)
+		if actual := ExitCode(cfg.HandleCommand()); actual != expectedExitCode {
+			t.Errorf("Expected exit code %d from `skeema new-table %s`, instead found %d", expectedExitCode, args, actual)
+		}
+	}
+
+	// Not a schema dir yet
+	newTable("orders", CodeBadConfig)
+
+	fs.WriteTestFile(t, ".skeema", "schema=shop\ndefault-character-set=latin1\n")
+	newTable("orders", CodeSuccess)
+	contents := fs.ReadTestFile(t, "orders.sql")
+	if !strings.HasPrefix(contents, "CREATE TABLE `orders` (") || !strings.Contains(contents, "ENGINE=InnoDB DEFAULT CHARSET=latin1 COMMENT='TODO: describe orders';\n") {
+		t.Errorf("Unexpected contents of orders.sql:\n%s", contents)
+	}
+	newTable("orders", CodeBadInput)
+
+	// Custom template, with trailing delimiter which should not be duplicated
+	templatePath := filepath.Join(schemaDir, "template.txt")
+	fs.WriteTestFile(t, templatePath, "CREATE TABLE {table} (\n  id int unsigned NOT NULL,\n  PRIMARY KEY (id)\n) ENGINE={ENGINE} /* {SCHEMA} */;\n")
+	newTable("items --engine=RocksDB --template="+templatePath, CodeSuccess)
+	if contents := fs.ReadTestFile(t, "items.sql"); contents != "CREATE TABLE items (\n  id int unsigned NOT NULL,\n  PRIMARY KEY (id)\n) ENGINE=RocksDB /* shop */;\n" {
+		t.Errorf("Unexpected contents of items.sql:\n%s", contents)
+	}
+
+	// Templates with unknown variables or that create a different table should
+	// not leave behind any file
+	fs.WriteTestFile(t, templatePath, "CREATE TABLE {TABLE} (id int) COMMENT='{OWNER}'")
+	newTable("widgets --template="+templatePath, CodeBadConfig)
+	fs.WriteTestFile(t, templatePath, "CREATE TABLE widgetz (id int)")
+	newTable("widgets --template="+templatePath, CodeBadInput)
+	if _, err := os.Stat("widgets.sql"); err == nil {
+		t.Error("Expected widgets.sql to not exist, but it does")
+	}
+	newTable("widgets --template=does-not-exist.sql", CodeNoInput)
+}
@@ -4,11 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path"
 	"regexp"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -25,12 +27,23 @@ which section of .skeema config files is used for processing. For example,
 running ` + "`" + `skeema pull staging` + "`" + ` will apply config directives from the
 [staging] section of config files, as well as any sectionless directives at the
 top of the file. If no environment name is supplied, the default is
-"production".`
+"production".
+
+To only update some objects, use --tables to supply a comma-separated list of
+table name patterns, such as ` + "`" + `--tables='orders,order_*'` + "`" + `; other tables, along with
+all stored procedures and functions, are left untouched. Use --only-new to only
+write files for objects that don't exist in the filesystem yet, or
+--only-changed to only update definitions of objects already in the filesystem.
+When any of these options are used, no files are deleted, and no schema-level
+changes (such as new schema dirs) are made.`
 
 	cmd := mybase.NewCommand("pull", summary, desc, PullHandler)
 	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "Include starting auto-inc values in new table files, and update in existing files"))
 	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "Reformat SQL statements to match canonical SHOW CREATE"))
 	cmd.AddOption(mybase.BoolOption("new-schemas", 0, true, "Detect any new schemas and populate new dirs for them"))
+	cmd.AddOption(mybase.StringOption("tables", 0, "", "Only update tables whose names match these comma-separated glob patterns"))
+	cmd.AddOption(mybase.BoolOption("only-new", 0, false, "Only write objects that do not exist in the filesystem yet"))
+	cmd.AddOption(mybase.BoolOption("only-changed", 0, false, "Only update objects that already exist in the filesystem"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 }
@@ -61,6 +74,10 @@ func PullHandler(cfg *mybase.Config) error {
 // number of non-fatal failed operations that were skipped for dir and its
 // subdirectories.
 func pullWalker(dir *fs.Dir, maxDepth int) (handledSchemaNames []string, skipCount int, err error) {
+	filter, err := pullFilterForDir(dir)
+	if err != nil {
+		return nil, 0, NewExitValue(CodeBadConfig, err.Error())
+	}
 	var instance *tengo.Instance
 	if dir.Config.Changed("host") {
 		instance, err = dir.FirstInstance()
@@ -91,14 +108,17 @@ func pullWalker(dir *fs.Dir, maxDepth int) (handledSchemaNames []string, skipCou
 			}
 			handledSchemaNames = append(handledSchemaNames, schemaNames...)
 			instSchema, err := instance.Schema(schemaNames[0])
-			if err == sql.ErrNoRows {
+			if err == sql.ErrNoRows && filter.active() {
+				log.Warnf("Skipping %s -- schema %s no longer exists, but not deleting directory since pull is restricted to specific objects", dir, schemaNames[0])
+				continue
+			} else if err == sql.ErrNoRows {
 				log.Infof("Deleted directory %s -- schema %s no longer exists\n", dir, handledSchemaNames[0])
 				// Explicitly return here to prevent later attempt at subdir traversal
 				return nil, skipCount, dir.Delete()
 			} else if err != nil {
 				return nil, skipCount, fmt.Errorf("%s: Unable to fetch schema %s from %s: %s", dir, handledSchemaNames[0], instance, err)
 			}
-			if err = pullSchemaDir(dir, instance, instSchema, logicalSchema); err != nil {
+			if err = pullSchemaDir(dir, instance, instSchema, logicalSchema, filter); err != nil {
 				return nil, skipCount, err
 			}
 		}
@@ -123,7 +143,7 @@ func pullWalker(dir *fs.Dir, maxDepth int) (handledSchemaNames []string, skipCou
 		}
 		if instance != nil && !dir.Config.Changed("schema") {
 			updateFlavor(dir, instance)
-			if dir.Config.GetBool("new-schemas") && badCount == 0 {
+			if dir.Config.GetBool("new-schemas") && badCount == 0 && !filter.active() {
 				err = findNewSchemas(dir, instance, allSubSchemaNames)
 			}
 			return nil, skipCount, err
@@ -133,11 +153,12 @@ func pullWalker(dir *fs.Dir, maxDepth int) (handledSchemaNames []string, skipCou
 }
 
 // pullSchemaDir performs appropriate pull logic on a dir that maps to one or
-// more schemas. Typically these are leaf dirs.
-func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema) error {
+// more schemas. Typically these are leaf dirs. Only objects permitted by
+// filter are updated.
+func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema, filter pullFilter) error {
 	log.Infof("Updating %s to reflect %s %s", dir, instance, instSchema.Name)
 
-	ignoreTable, err := dir.Config.GetRegexp("ignore-table")
+	ignoreOpts, err := dir.IgnoreOptions()
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
@@ -148,19 +169,20 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 	// Workspace and run a diff against it.
 	var inDiff map[tengo.ObjectKey]bool
 	if !dir.Config.GetBool("normalize") {
-		mods := statementModifiersForPull(dir.Config, instance, ignoreTable)
+		mods := statementModifiersForPull(dir.Config, instance, ignoreOpts.Table)
 		opts, err := workspace.OptionsForDir(dir, instance)
 		if err != nil {
 			return NewExitValue(CodeBadConfig, err.Error())
 		}
-		if inDiff, err = objectsInDiff(instSchema, logicalSchema, opts, mods); err != nil {
+		if inDiff, err = objectsInDiff(instSchema, logicalSchema, opts, mods, util.InstanceDefaultRowFormat(instance)); err != nil {
 			return err
 		}
 	}
 
 	// Handle changes in schema's default character set and/or collation by
-	// persisting changes to the dir's option file.
-	if dir.Config.Get("default-character-set") != instSchema.CharSet || dir.Config.Get("default-collation") != instSchema.Collation {
+	// persisting changes to the dir's option file. This is skipped if pull is
+	// restricted to specific objects.
+	if !filter.active() && (dir.Config.Get("default-character-set") != instSchema.CharSet || dir.Config.Get("default-collation") != instSchema.Collation) {
 		dir.OptionFile.SetOptionValue("", "default-character-set", instSchema.CharSet)
 		dir.OptionFile.SetOptionValue("", "default-collation", instSchema.Collation)
 		if err := dir.OptionFile.Write(true); err != nil {
@@ -174,11 +196,11 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
 	instDict := instSchema.ObjectDefinitions()
 	for key, stmt := range logicalSchema.Creates {
-		if key.Type == tengo.ObjectTypeTable && ignoreTable != nil && ignoreTable.MatchString(key.Name) {
+		if ignoreOpts.ShouldIgnore(key) || !filter.includes(key) {
 			continue
 		}
 		if instCreate, stillExists := instDict[key]; stillExists {
-			if !dir.Config.GetBool("normalize") && !inDiff[key] {
+			if filter.onlyNew || (!dir.Config.GetBool("normalize") && !inDiff[key]) {
 				continue
 			}
 			_, fsAutoInc := tengo.ParseCreateAutoInc(stmt.Text)
@@ -194,6 +216,12 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 				stmt.Text = fmt.Sprintf("%s%s", instCreate, fsDelimiter)
 				filesToRewrite[stmt.FromFile] = true
 			}
+		} else if filter.active() {
+			log.Warnf("%s no longer exists on %s, but pull is restricted to specific objects -- leaving it in place", key, instance)
+		} else if linkedPath := dir.LinkedPath(stmt.FromFile.SQLFile); linkedPath != "" {
+			// Other dirs may still use the object, so don't remove it from a file
+			// that is shared via symlink
+			log.Warnf("%s no longer exists on %s, but is defined in shared file %s -- leaving it in place", key, instance, linkedPath)
 		} else {
 			filesToRewrite[stmt.FromFile] = true
 			stmt.Remove()
@@ -206,6 +234,8 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 			return err
 		} else if bytesWritten == 0 {
 			log.Infof("Deleted %s -- no longer exists", file)
+		} else if linkedPath := dir.LinkedPath(file.SQLFile); linkedPath != "" {
+			log.Infof("Wrote %s (%d bytes) -- updated definition in shared file %s", file, bytesWritten, linkedPath)
 		} else {
 			log.Infof("Wrote %s (%d bytes) -- updated definition", file, bytesWritten)
 		}
@@ -214,10 +244,10 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 	// Objects that exist in instSchema, but have no corresponding create statement
 	// in fs: write new files, or append if filename already taken
 	for key, instCreate := range instDict {
-		if logicalSchema.Creates[key] != nil {
+		if logicalSchema.Creates[key] != nil || filter.onlyChanged {
 			continue
 		}
-		if key.Type == tengo.ObjectTypeTable && ignoreTable != nil && ignoreTable.MatchString(key.Name) {
+		if ignoreOpts.ShouldIgnore(key) || !filter.includes(key) {
 			continue
 		}
 		contents := instCreate
@@ -231,7 +261,7 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 			continue
 		}
 		contents = fs.AddDelimiter(contents)
-		filePath := fs.PathForObject(dir.Path, key.Name)
+		filePath := fs.PathForObjectKey(dir.Path, key, dir.UsesTypeSubdirs())
 		if bytesWritten, wasNew, err := fs.AppendToFile(filePath, contents); err != nil {
 			return err
 		} else if wasNew {
@@ -245,6 +275,50 @@ func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Sche
 	return nil
 }
 
+// pullFilter restricts which objects are updated by `skeema pull`.
+type pullFilter struct {
+	tablePatterns []string // if non-empty, only tables matching one of these globs are pulled
+	onlyNew       bool     // only write objects missing from the filesystem
+	onlyChanged   bool     // only update objects already in the filesystem
+}
+
+// pullFilterForDir returns a pullFilter based on the dir's configuration.
+func pullFilterForDir(dir *fs.Dir) (filter pullFilter, err error) {
+	filter.onlyNew = dir.Config.GetBool("only-new")
+	filter.onlyChanged = dir.Config.GetBool("only-changed")
+	if filter.onlyNew && filter.onlyChanged {
+		return filter, fmt.Errorf("Options only-new and only-changed cannot be used together")
+	}
+	for _, pattern := range dir.Config.GetSlice("tables", ',', true) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return filter, fmt.Errorf("Invalid pattern %q in option tables: %s", pattern, err)
+		}
+		filter.tablePatterns = append(filter.tablePatterns, pattern)
+	}
+	return filter, nil
+}
+
+// active returns true if the filter restricts pull to a subset of objects.
+func (filter pullFilter) active() bool {
+	return len(filter.tablePatterns) > 0 || filter.onlyNew || filter.onlyChanged
+}
+
+// includes returns true if the object with the supplied key may be pulled.
+// This does not take into account whether the object is new or changed.
+func (filter pullFilter) includes(key tengo.ObjectKey) bool {
+	if len(filter.tablePatterns) == 0 {
+		return true
+	} else if key.Type != tengo.ObjectTypeTable {
+		return false
+	}
+	for _, pattern := range filter.tablePatterns {
+		if matched, _ := path.Match(pattern, key.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 func statementModifiersForPull(config *mybase.Config, instance *tengo.Instance, ignoreTable *regexp.Regexp) tengo.StatementModifiers {
 	// We're permissive of unsafe operations here since we don't ever actually
 	// execute the generated statement! We just examine its type.
@@ -272,16 +346,24 @@ func statementModifiersForPull(config *mybase.Config, instance *tengo.Instance,
 // have modifications in instSchema that aren't reflected in their filesystem
 // representation yet. This also includes objects whose filesystem Statement has
 // a SQL syntax error. The return value does not include tables whose
-// differences are cosmetic / formatting-related, or are otherwise ignored by
-// mods.
-func objectsInDiff(instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts workspace.Options, mods tengo.StatementModifiers) (map[tengo.ObjectKey]bool, error) {
+// differences are cosmetic / formatting-related, are otherwise ignored by mods,
+// or only consist of explicitly specifying the default rowFormat.
+func objectsInDiff(instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts workspace.Options, mods tengo.StatementModifiers, rowFormat string) (map[tengo.ObjectKey]bool, error) {
 	fsSchema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
 	if err != nil {
 		return nil, fmt.Errorf("Error introspecting filesystem version of schema %s: %s", instSchema.Name, err)
 	}
 
+	if mods.Flavor.Vendor == tengo.VendorMariaDB {
+		util.NormalizeMariaDBSchema(fsSchema, mods.Flavor)
+		util.NormalizeMariaDBSchema(instSchema, mods.Flavor)
+	} else if mods.Flavor.MySQLishMinVersion(8, 0) {
+		util.NormalizeCheckConstraints(fsSchema, mods.Flavor)
+		util.NormalizeCheckConstraints(instSchema, mods.Flavor)
+	}
+
 	// Run a diff, and create a map to track objects in the diff
-	diff := tengo.NewSchemaDiff(fsSchema, instSchema)
+	diff := tengo.NewSchemaDiff(util.WithoutDefaultRowFormat(fsSchema, rowFormat), util.WithoutDefaultRowFormat(instSchema, rowFormat))
 	inDiff := make(map[tengo.ObjectKey]bool)
 	for _, od := range diff.ObjectDiffs() {
 		odStatement, odStatementErr := od.Statement(mods)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestPullFilterIncludes(t *testing.T) {
+	table := func(name string) tengo.ObjectKey {
+		return tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: name}
+	}
+	proc := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "orders"}
+
+	var filter pullFilter
+	if filter.active() || !filter.includes(table("orders")) || !filter.includes(proc) {
+		t.Error("Expected zero-value pullFilter to include all objects")
+	}
+
+	filter = pullFilter{tablePatterns: []string{"orders", "order_*"}}
+	cases := map[tengo.ObjectKey]bool{
+		table("orders"):      true,
+		table("order_items"): true,
+		table("orders_old"):  false,
+		table("customers"):   false,
+		proc:                 false,
+	}
+	for key, expected := range cases {
+		if actual := filter.includes(key); actual != expected {
+			t.Errorf("Expected includes(%s) to return %t, instead found %t", key, expected, actual)
+		}
+	}
+	if !filter.active() {
+		t.Error("Expected filter with table patterns to be active")
+	}
+	if filter = (pullFilter{onlyNew: true}); !filter.active() || !filter.includes(proc) {
+		t.Error("Expected filter with onlyNew to be active, and include all objects")
+	}
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/applier"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -25,37 +30,95 @@ top of the file. If no environment name is supplied, the default is
 "production".`
 
 	cmd := mybase.NewCommand("push", summary, desc, PushHandler)
-	cmd.AddOption(mybase.BoolOption("verify", 0, true, "Test all generated ALTER statements on temp schema to verify correctness"))
-	cmd.AddOption(mybase.BoolOption("allow-unsafe", 0, false, "Permit running ALTER or DROP operations that are potentially destructive"))
-	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Output DDL but don't run it; equivalent to `skeema diff`"))
-	cmd.AddOption(mybase.BoolOption("first-only", '1', false, "For dirs mapping to multiple instances or schemas, just run against the first per dir"))
-	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Follow *.sql table definitions exactly, even for differences with no functional impact"))
-	cmd.AddOption(mybase.BoolOption("foreign-key-checks", 0, false, "Force the server to check referential integrity of any new foreign key"))
-	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
-	cmd.AddOption(mybase.BoolOption("brief", 'q', false, "<overridden by diff command>").Hidden())
-	cmd.AddOption(mybase.StringOption("alter-wrapper", 'x', "", "External bin to shell out to for ALTER TABLE; see manual for template vars"))
-	cmd.AddOption(mybase.StringOption("alter-wrapper-min-size", 0, "0", "Ignore --alter-wrapper for tables smaller than this size in bytes"))
-	cmd.AddOption(mybase.StringOption("alter-lock", 0, "", `Apply a LOCK clause to all ALTER TABLEs (valid values: "NONE", "SHARED", "EXCLUSIVE")`))
-	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "INPLACE", "COPY", "INSTANT")`))
-	cmd.AddOption(mybase.StringOption("ddl-wrapper", 'X', "", "Like --alter-wrapper, but applies to all DDL types (CREATE, DROP, ALTER)"))
-	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
+	applier.AddCommandOptions(cmd)
 	cmd.AddOption(mybase.StringOption("concurrent-instances", 'c', "1", "Perform operations on this number of instances concurrently"))
+	cmd.AddOption(mybase.StringOption("retry-file", 0, "", "Write list of instance/schema pairs with failed operations to this file"))
+	cmd.AddOption(mybase.BoolOption("retry-failed", 0, false, "Only process instance/schema pairs listed in --retry-file from a previous run"))
+	cmd.AddOption(mybase.BoolOption("interactive", 0, false, "Review the changes to each object and confirm or skip them before running"))
+	cmd.AddOption(mybase.StringOption("ddl-export-dir", 0, "", "With --dry-run, also write each instance/schema's DDL to a separate file in this dir"))
+	cmd.AddOption(mybase.StringOption("ddl-export-format", 0, "sql", `Format of files written by --ddl-export-dir (valid values: "sql", "liquibase-xml", "liquibase-yaml", "flyway", "golang-migrate")`))
+	cmd.AddOption(mybase.StringOption("flyway-version", 0, "timestamp", `With --ddl-export-format=flyway, version of migration files (valid values: "timestamp", "next", or a version number)`))
+	cmd.AddOption(mybase.StringOption("flyway-description", 0, "skeema_diff", "With --ddl-export-format=flyway, description used in names of migration files"))
+	cmd.AddOption(mybase.StringOption("golang-migrate-version", 0, "timestamp", `With --ddl-export-format=golang-migrate, version of migration files (valid values: "timestamp", "next", or an integer)`))
+	cmd.AddOption(mybase.StringOption("golang-migrate-description", 0, "skeema_diff", "With --ddl-export-format=golang-migrate, description used in names of migration files"))
+	cmd.AddOption(mybase.StringOption("summary-json", 0, "", "Write a machine-readable summary of the run's outcome to this file"))
+	cmd.AddOption(mybase.StringOption("catalog-webhook", 0, "", "After pushing changes to a schema, POST its updated model to this URL"))
+	cmd.AddOption(mybase.StringOption("catalog-service", 0, "mysql", "Service name prefix for table names in catalog-webhook payloads"))
+	cmd.AddOption(mybase.StringOption("notify-webhook", 0, "", "POST notifications of push progress and outcome to this URL"))
+	cmd.AddOption(mybase.StringOption("notify-format", 0, "json", `Payload format for notify-webhook (valid values: "json", "slack")`))
+	cmd.AddOption(mybase.StringOption("notify-events", 0, "start,success,failure,unsafe", "Comma-separated list of events which trigger notify-webhook"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 	clonePushOptionsToDiff()
+	clonePushOptionsToClone()
+	clonePushOptionsToWatch()
 }
 
 // PushHandler is the handler method for `skeema push`
 func PushHandler(cfg *mybase.Config) error {
+	span := util.Tracing.StartSpan(nil, "resolve config")
+	span.SetAttribute("skeema.environment", cfg.Get("environment"))
 	dir, err := fs.ParseDir(".", cfg)
+	span.End(err)
 	if err != nil {
 		return err
 	}
 
+	targets, skipCount, skipped := applier.TargetsAndSkipsForDir(dir, 5)
+	return applyTargets(dir, targets, skipCount, skipped)
+}
+
+// applyTargets performs the diff/push logic on targets, using dir as the
+// top-level directory for configuration purposes. skipCount should indicate
+// the number of targets which were already skipped due to errors, and skipped
+// may optionally identify them, for inclusion in the retry-file.
+func applyTargets(dir *fs.Dir, targets []*applier.Target, skipCount int, skipped applier.RetryList) (err error) {
+	summary := newRunSummary(dir.Config)
+	defer func() {
+		summary.finish(err)
+	}()
 	briefMode := dir.Config.GetBool("dry-run") && dir.Config.GetBool("brief")
 	printer := applier.NewPrinter(briefMode)
+	if exportDir := dir.Config.Get("ddl-export-dir"); exportDir != "" {
+		if !dir.Config.GetBool("dry-run") {
+			return NewExitValue(CodeBadConfig, "Option ddl-export-dir may only be used with dry-run")
+		}
+		format, err := dir.Config.GetEnum("ddl-export-format", string(applier.ExportFormatSQL), string(applier.ExportFormatLiquibaseXML), string(applier.ExportFormatLiquibaseYAML), string(applier.ExportFormatFlyway), string(applier.ExportFormatGolangMigrate))
+		if err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		if applier.ExportFormat(format) == applier.ExportFormatFlyway {
+			if err := printer.SetFlywayNaming(dir.Config.Get("flyway-version"), dir.Config.Get("flyway-description")); err != nil {
+				return NewExitValue(CodeBadConfig, err.Error())
+			}
+		} else if applier.ExportFormat(format) == applier.ExportFormatGolangMigrate {
+			if err := printer.SetGolangMigrateNaming(dir.Config.Get("golang-migrate-version"), dir.Config.Get("golang-migrate-description")); err != nil {
+				return NewExitValue(CodeBadConfig, err.Error())
+			}
+		}
+		printer.SetExportDir(exportDir, applier.ExportFormat(format))
+	}
+	interactive := dir.Config.GetBool("interactive") && !dir.Config.GetBool("dry-run")
+	if interactive {
+		if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+			return NewExitValue(CodeBadConfig, "Option interactive requires STDIN to be a terminal")
+		}
+		printer.SetReviewer(applier.NewReviewer(os.Stdin, os.Stdout))
+	}
 	g, ctx := errgroup.WithContext(context.Background())
-	tgchan, skipCount := applier.TargetGroupChanForDir(dir)
+	var previousRetryList applier.RetryList
+	if dir.Config.GetBool("retry-failed") {
+		if !dir.Config.Changed("retry-file") {
+			return NewExitValue(CodeBadConfig, "Option retry-failed requires option retry-file to also be set")
+		}
+		if previousRetryList, err = applier.ReadRetryFile(dir.Config.Get("retry-file")); err != nil {
+			return NewExitValue(CodeNoInput, "Unable to read retry-file: %s", err)
+		}
+		targets = previousRetryList.Filter(targets)
+		skipped = previousRetryList.FilterEntries(skipped)
+		log.Infof("Limiting operations to %d instance/schema pairs listed in %s", len(targets), dir.Config.Get("retry-file"))
+	}
+	tgchan := applier.TargetGroupChan(targets)
 	results := make(chan applier.Result)
 
 	workerCount, err := dir.Config.GetInt("concurrent-instances")
@@ -65,6 +128,20 @@ func PushHandler(cfg *mybase.Config) error {
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
+	if interactive && workerCount > 1 {
+		log.Warnf("Ignoring concurrent-instances=%d, since option interactive requires operating on one instance at a time", workerCount)
+		workerCount = 1
+	}
+	warnIgnoredConcurrency(dir, targets)
+	started := time.Now()
+	notifier, err := newPushNotifier(dir.Config, dir.Path)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	notifier.start()
+	defer func() {
+		notifier.finish(err)
+	}()
 	for n := 0; n < workerCount; n++ {
 		g.Go(func() error {
 			return applier.Worker(ctx, tgchan, results, printer)
@@ -87,6 +164,19 @@ func PushHandler(cfg *mybase.Config) error {
 	}
 	sum := applier.SumResults(allResults)
 	sum.SkipCount += skipCount
+	summary.setApplierResult(sum)
+	notifier.setApplierResult(sum)
+	recordApplierMetrics(dir, sum, started)
+	notifyCatalog(sum.PushedTargets)
+	if dir.Config.Changed("retry-file") && !dir.Config.GetBool("dry-run") {
+		retryList := applier.NewRetryList(sum.FailedTargets, sum.RetryableTargets, skipped)
+		retryList = retryList.CarryForward(previousRetryList, targets, sum.FailedTargets)
+		if err := retryList.Write(dir.Config.Get("retry-file")); err != nil {
+			log.Errorf("Unable to write retry-file %s: %s", dir.Config.Get("retry-file"), err)
+		} else if len(retryList) > 0 {
+			log.Infof("Wrote %s -- %d instance/schema pairs to retry with --retry-failed, %d of which failed due to transient errors", dir.Config.Get("retry-file"), len(retryList), retryList.Retryable())
+		}
+	}
 
 	if sum.SkipCount+sum.UnsupportedCount == 0 {
 		if dir.Config.GetBool("dry-run") && sum.Differences {
@@ -107,5 +197,69 @@ func PushHandler(cfg *mybase.Config) error {
 	} else {
 		reason = "unsupported features or error"
 	}
-	return NewExitValue(code, "Skipped %d operation%s due to %s%s", sum.SkipCount+sum.UnsupportedCount, plural, reason, plural)
+	message := fmt.Sprintf("Skipped %d operation%s due to %s%s", sum.SkipCount+sum.UnsupportedCount, plural, reason, plural)
+	if sum.SkippedTargetCount == 1 {
+		message += ", and 1 schema on an instance which failed check-target-state"
+	} else if sum.SkippedTargetCount > 1 {
+		message += fmt.Sprintf(", and %d schemas on instances which failed check-target-state", sum.SkippedTargetCount)
+	}
+	return NewExitValue(code, message)
+}
+
+// warnIgnoredConcurrency logs a warning for any target whose dir configures a
+// different concurrent-instances value than the top-level dir. This option is
+// only evaluated once per run, so subdir values have no effect.
+func warnIgnoredConcurrency(dir *fs.Dir, targets []*applier.Target) {
+	warned := make(map[string]bool)
+	for _, t := range targets {
+		if t.Dir.Config.Get("concurrent-instances") != dir.Config.Get("concurrent-instances") && !warned[t.Dir.Path] {
+			log.Warnf("Ignoring concurrent-instances=%s for %s: this option can only be set for the current working directory, its parents, or the command-line", t.Dir.Config.Get("concurrent-instances"), t.Dir)
+			warned[t.Dir.Path] = true
+		}
+	}
+}
+
+// copyPushOptions copies options from `skeema push` into the named command,
+// for commands which reuse push's logic. Options already present in the other
+// command are left as-is. Descriptions and visibility of copied options may be
+// modified using descRewrites and hiddenRewrites.
+func copyPushOptions(cmdName string, descRewrites map[string]string, hiddenRewrites map[string]bool) {
+	// Logic relies on init() having been called in both cmd_push.go AND the other
+	// command's file, so we call it from both places, but only one will succeed
+	cmd, ok1 := CommandSuite.SubCommands[cmdName]
+	push, ok2 := CommandSuite.SubCommands["push"]
+	if !ok1 || !ok2 {
+		return
+	}
+
+	cmdOptions := cmd.Options()
+	pushOptions := push.Options()
+
+	for name, pushOpt := range pushOptions {
+		if _, already := cmdOptions[name]; already {
+			continue
+		}
+		cmdOpt := *pushOpt
+		if newDesc, ok := descRewrites[name]; ok {
+			cmdOpt.Description = newDesc
+		}
+		if newHiddenStatus, ok := hiddenRewrites[name]; ok {
+			cmdOpt.HiddenOnCLI = newHiddenStatus
+		}
+		cmd.AddOption(&cmdOpt)
+	}
+}
+
+// recordApplierMetrics adds the combined result of diff, push, or clone to
+// util.Metrics. Statement execution counts and durations are recorded
+// separately by the applier package as each statement is run.
+func recordApplierMetrics(dir *fs.Dir, sum applier.Result, started time.Time) {
+	verb := "push"
+	if dir.Config.GetBool("dry-run") {
+		verb = "diff"
+	}
+	util.Metrics.TimeSince(verb+"_duration", started)
+	util.Metrics.Count(verb+"_targets", int64(sum.TargetCount))
+	util.Metrics.Count(verb+"_skipped_operations", int64(sum.SkipCount+sum.UnsupportedCount))
+	util.Metrics.Count(verb+"_unsafe_statements", int64(sum.UnsafeCount))
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+)
+
+func init() {
+	summary := "Run an HTTP API server for triggering lint, diff, and push"
+	desc := `Starts an HTTP server which permits other tools, such as an internal deployment
+UI, to run ` + "`" + `skeema lint` + "`" + `, ` + "`" + `skeema diff` + "`" + `, or ` + "`" + `skeema push` + "`" + ` against the current
+directory, without needing to shell out to skeema themselves.
+
+Every request must include an "Authorization: Bearer <token>" header matching the
+api-token option, or the SKEEMA_API_TOKEN environment variable if that option is
+not set. The server refuses to start if no token is configured.
+
+The following endpoints are supported:
+
+  POST /v1/jobs           Start a job; JSON body specifies "command" (lint,
+                          diff, or push), "environment", and "options" (an
+                          object mapping option names to values)
+  GET  /v1/jobs           List recent jobs, newest first
+  GET  /v1/jobs/<id>      Fetch a job's status; once finished, this includes
+                          its exit code, and its output or lint JSON report
+  GET  /v1/jobs/<id>/log  Stream the job's log output until it finishes
+
+Each job runs as a separate skeema process in the current directory, using the
+same configuration files as if run from the command-line. Only one job may run
+at a time; requests to start another job in the meantime are rejected.
+
+Requests are served over plain HTTP unless tls-cert and tls-key are both set.
+Since requests include the API token, the server refuses to listen on an address
+other than localhost or a loopback IP unless TLS is configured.
+
+With --metrics-addr, counts and durations of jobs are exposed in Prometheus
+format at /metrics on a separate address, which does not require a token.
+
+Press Ctrl-C to shut down the server.`
+
+	cmd := mybase.NewCommand("serve", summary, desc, ServeHandler)
+	cmd.AddOption(mybase.StringOption("listen-addr", 0, "localhost:8080", "Address and port for the HTTP server to listen on"))
+	cmd.AddOption(mybase.StringOption("api-token", 0, "", "Token required in Authorization header of requests; defaults to SKEEMA_API_TOKEN env var"))
+	cmd.AddOption(mybase.StringOption("tls-cert", 0, "", "Path to PEM certificate file for serving HTTPS; requires tls-key"))
+	cmd.AddOption(mybase.StringOption("tls-key", 0, "", "Path to PEM private key file for serving HTTPS; requires tls-cert"))
+	cmd.AddOption(mybase.StringOption("metrics-addr", 0, "", "Expose Prometheus metrics at /metrics on this address and port"))
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ServeHandler is the handler method for `skeema serve`
+func ServeHandler(cfg *mybase.Config) error {
+	token := cfg.Get("api-token")
+	if token == "" {
+		token = os.Getenv("SKEEMA_API_TOKEN")
+	}
+	if token == "" {
+		return NewExitValue(CodeBadConfig, "Option api-token or environment variable SKEEMA_API_TOKEN must be set")
+	}
+	certFile, keyFile := cfg.Get("tls-cert"), cfg.Get("tls-key")
+	if (certFile == "") != (keyFile == "") {
+		return NewExitValue(CodeBadConfig, "Options tls-cert and tls-key must be used together")
+	}
+	listenAddr := cfg.Get("listen-addr")
+	if certFile == "" && !isLoopbackAddr(listenAddr) {
+		return NewExitValue(CodeBadConfig, "Option listen-addr must be a loopback address unless tls-cert and tls-key are set, since otherwise the API token would be sent in cleartext")
+	}
+	dirPath, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	// Jobs run in separate processes, which won't see a password supplied on
+	// this command-line unless it is passed along
+	var env []string
+	if cfg.OnCLI("password") {
+		env = append(env, "MYSQL_PWD="+cfg.Get("password"))
+	}
+
+	if addr := cfg.Get("metrics-addr"); addr != "" {
+		metricsServer, err := startMetricsServer(addr)
+		if err != nil {
+			return NewExitValue(CodeBadConfig, "Unable to serve metrics on metrics-addr %s: %s", addr, err)
+		}
+		defer metricsServer.Close()
+		log.Infof("Serving metrics on http://%s/metrics", addr)
+	}
+
+	server := &http.Server{
+		Addr:    listenAddr,
+		Handler: newJobServer(token, execJobRunner(dirPath, env)),
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	go func() {
+		<-sigs
+		log.Info("Shutting down server")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	log.Infof("Serving API for %s on %s", dirPath, server.Addr)
+	if certFile != "" {
+		err = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return NewExitValue(CodeFatalError, "Unable to run server: %s", err)
+	}
+	return nil
+}
+
+// isLoopbackAddr returns true if addr, in host:port form, refers to localhost
+// or a loopback IP. An empty host, which listens on all interfaces, is not
+// considered loopback.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
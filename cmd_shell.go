@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Open a client session against a workspace loaded from the filesystem"
+	desc := `Loads the current directory's *.sql files into a workspace, and then runs an
+interactive MySQL client connected to it. This permits experimenting with
+queries and DDL against the schema as declared in the filesystem, without
+affecting any real database. Seed data is loaded too, if the seeds option is
+enabled, which requires workspace=docker.
+
+The workspace is determined by the workspace option, in the same manner as
+` + "`" + `skeema lint` + "`" + `: either a temporary schema on the first instance that the directory
+maps to, or a schema on a local Docker container. The workspace is cleaned up
+when the client exits, discarding any changes made in the session.
+
+The client command is determined by the client option. It must accept the same
+connection arguments as the standard mysql client. The password, if any, is
+supplied via the MYSQL_PWD environment variable rather than on the command-line.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+for the workspace. If no environment name is supplied, the default is
+"production".`
+
+	cmd := mybase.NewCommand("shell", summary, desc, ShellHandler)
+	cmd.AddOption(mybase.StringOption("client", 0, "mysql", "MySQL client command to run, which may include additional args"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ShellHandler is the handler method for `skeema shell`
+func ShellHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	var logicalSchema *fs.LogicalSchema
+	for _, ls := range dir.LogicalSchemas {
+		if ls.Name == "" {
+			logicalSchema = ls
+		}
+	}
+	if logicalSchema == nil {
+		return NewExitValue(CodeBadUsage, "No *.sql files found in %s", dir)
+	}
+	client := strings.TrimSpace(dir.Config.Get("client"))
+	if client == "" {
+		return NewExitValue(CodeBadConfig, "Option client cannot be empty")
+	}
+
+	// Connect to first defined instance, unless configured to use local Docker
+	var inst *tengo.Instance
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		if inst, err = dir.FirstInstance(); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if inst == nil {
+			return NewExitValue(CodeBadConfig, "No instance defined for %s, and workspace=docker is not configured", dir)
+		}
+	}
+	opts, err := workspace.OptionsForDir(dir, inst)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	ws, statementErrors, err := workspace.Materialize(logicalSchema, opts)
+	if err != nil {
+		return NewExitValue(CodeFatalError, err.Error())
+	}
+	for _, stmtErr := range statementErrors {
+		log.Warn(stmtErr.Error())
+	}
+	locator, ok := ws.(workspace.Locator)
+	if !ok {
+		ws.Cleanup()
+		return NewExitValue(CodeBadConfig, "Workspace type does not support client connections")
+	}
+
+	log.Infof("Loaded %s into workspace %s on %s. Exit the client to clean up the workspace.", dir, locator.SchemaName(), locator.Instance())
+	if err := runShellClient(client, locator); err != nil {
+		log.Warnf("Client exited with error: %s", err)
+	}
+
+	// Remove any rows added by seeds or during the session, since workspaces
+	// refuse to drop tables containing rows
+	schema, err := ws.IntrospectSchema()
+	if err == nil {
+		err = workspace.EmptyTables(ws, schema)
+	}
+	if cleanupErr := ws.Cleanup(); err == nil {
+		err = cleanupErr
+	}
+	if err != nil {
+		return NewExitValue(CodeFatalError, "Unable to clean up workspace: %s", err)
+	}
+	return nil
+}
+
+// shellClientCommand returns a ShellOut for running a mysql-compatible client
+// against the workspace schema on inst.
+func shellClientCommand(client string, inst *tengo.Instance, schemaName string) (*util.ShellOut, error) {
+	command := client
+	if inst.SocketPath != "" {
+		command += " --socket={SOCKET}"
+	} else {
+		command += " --host={HOST} --port={PORT} --protocol=TCP"
+	}
+	if inst.User != "" {
+		command += " --user={USER}"
+	}
+	command += " --database={SCHEMA}"
+	variables := map[string]string{
+		"HOST":   inst.Host,
+		"PORT":   strconv.Itoa(inst.Port),
+		"SOCKET": inst.SocketPath,
+		"USER":   inst.User,
+		"SCHEMA": schemaName,
+	}
+	return util.NewInterpolatedShellOut(command, variables)
+}
+
+// runShellClient runs the client interactively against the workspace, and
+// blocks until it exits. Interrupt signals are ignored in the meantime, so
+// that they are handled by the client only, and the workspace can still be
+// cleaned up afterwards.
+func runShellClient(client string, locator workspace.Locator) error {
+	inst := locator.Instance()
+	s, err := shellClientCommand(client, inst, locator.SchemaName())
+	if err != nil {
+		return err
+	}
+	log.Debugf("Running %s", s)
+
+	if inst.Password != "" {
+		prevPassword, hadPassword := os.LookupEnv("MYSQL_PWD")
+		os.Setenv("MYSQL_PWD", inst.Password)
+		defer func() {
+			if hadPassword {
+				os.Setenv("MYSQL_PWD", prevPassword)
+			} else {
+				os.Unsetenv("MYSQL_PWD")
+			}
+		}()
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	return s.Run()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestShellClientCommand(t *testing.T) {
+	inst := &tengo.Instance{Host: "127.0.0.1", Port: 3307, User: "root", Password: "secret"}
+	s, err := shellClientCommand("mysql --no-auto-rehash", inst, "_skeema tmp")
+	if err != nil {
+		t.Fatalf("Unexpected error from shellClientCommand: %s", err)
+	}
+	expected := "mysql --no-auto-rehash --host=127.0.0.1 --port=3307 --protocol=TCP --user=root --database='_skeema tmp'"
+	if s.Command != expected {
+		t.Errorf("Unexpected command:\n%s\nExpected:\n%s", s.Command, expected)
+	}
+
+	inst = &tengo.Instance{Host: "localhost", SocketPath: "/var/lib/mysql/mysql.sock"}
+	if s, err = shellClientCommand("mariadb", inst, "_skeema_tmp"); err != nil {
+		t.Fatalf("Unexpected error from shellClientCommand: %s", err)
+	}
+	expected = "mariadb --socket=/var/lib/mysql/mysql.sock --database=_skeema_tmp"
+	if s.Command != expected {
+		t.Errorf("Unexpected command:\n%s\nExpected:\n%s", s.Command, expected)
+	}
+}
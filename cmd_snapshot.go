@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Record a named snapshot of the filesystem representation"
+	desc := `Records a named snapshot of the current directory tree's *.sql files, so that
+later states can be compared against it. Each snapshot stores a checksum and the
+full definition of every object in each schema directory. Snapshots are appended
+to the file specified by --snapshot-file, which may be committed alongside the
+*.sql files. Snapshot names must be unique within the file.
+
+With --live, the snapshot also records checksums of the live definitions of
+each object, from the first instance that each directory maps to in the
+selected environment.
+
+With --compare, no snapshot is recorded. Instead, the current state is compared
+against the named snapshot, reporting which objects have been added, changed,
+or removed since then, without accessing any database (unless --live is also
+used). For example, ` + "`" + `skeema snapshot --compare release-42` + "`" + ` lists all
+changes made since the release-42 snapshot was recorded.
+
+The ` + "`" + `skeema diff` + "`" + ` and ` + "`" + `skeema push` + "`" + ` commands may also reference a snapshot via
+their --snapshot option, which causes the snapshot's definitions to be used in
+place of the *.sql files.
+
+You may optionally pass an environment name as a CLI option after the snapshot
+name. This only affects which instances are used with --live. If no environment
+name is supplied, the default is "production".
+
+With --compare, an exit code of 0 will be returned if no changes were found, 1
+if some changes were found, or 2+ if an error occurred.`
+
+	cmd := mybase.NewCommand("snapshot", summary, desc, SnapshotHandler)
+	cmd.AddOption(mybase.StringOption("snapshot-file", 0, "skeema-snapshots.json", "Path to file storing snapshots"))
+	cmd.AddOption(mybase.BoolOption("live", 0, false, "Also record (or compare) checksums of live object definitions"))
+	cmd.AddOption(mybase.BoolOption("compare", 0, false, "Compare the current state against the named snapshot, instead of recording"))
+	cmd.AddOption(mybase.StringOption("list-limit", 0, "10", "Maximum number of objects to list per dir with --compare; 0 for no limit"))
+	cmd.AddArg("name", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// SnapshotHandler is the handler method for `skeema snapshot`
+func SnapshotHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	sf, err := fs.ReadSnapshotFile(dir.Config.Get("snapshot-file"))
+	if err != nil {
+		return NewExitValue(CodeBadInput, err.Error())
+	}
+	name := dir.Config.Get("name")
+	if dir.Config.GetBool("compare") {
+		return compareSnapshot(dir, sf, name)
+	} else if sf.Snapshot(name) != nil {
+		return NewExitValue(CodeBadUsage, "A snapshot named %s already exists in %s", name, sf.Path)
+	}
+
+	snap := fs.NewSnapshot(name)
+	if skipCount := snapshotWalker(dir, sf, snap, 5); skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Snapshot %s not recorded, due to errors in %d dirs", name, skipCount)
+	}
+	if err := sf.Add(snap); err != nil {
+		return NewExitValue(CodeBadUsage, err.Error())
+	}
+	if err := sf.Write(); err != nil {
+		return NewExitValue(CodeCantCreate, "Unable to write %s: %s", sf.Path, err)
+	}
+	log.Infof("Wrote %s -- recorded snapshot %s of %d dirs", sf.Path, name, len(snap.Dirs))
+	return nil
+}
+
+// snapshotWalker records dir in snap, and recursively calls itself on any
+// subdirs. It returns the number of dirs which could not be processed due to
+// errors.
+func snapshotWalker(dir *fs.Dir, sf *fs.SnapshotFile, snap *fs.Snapshot, maxDepth int) (skipCount int) {
+	if dir.HasSchema() {
+		sd, err := snap.AddDir(sf.DirKey(dir), dir)
+		if err != nil {
+			log.Errorf("%s: %s", dir, err)
+			skipCount++
+		} else if dir.Config.GetBool("live") {
+			if err := snapshotLive(dir, sd); err != nil {
+				log.Errorf("Unable to obtain live state for %s: %s", dir, err)
+				skipCount++
+			}
+		}
+	}
+
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			skipCount += snapshotWalker(sub, sf, snap, maxDepth-1)
+		}
+	}
+	return skipCount
+}
+
+// snapshotLive records the live state of the schemas that dir maps to on its
+// first instance.
+func snapshotLive(dir *fs.Dir, sd *fs.SnapshotDir) error {
+	if !dir.Config.Changed("host") {
+		return fmt.Errorf("no host defined for environment \"%s\"", dir.Config.Get("environment"))
+	}
+	inst, err := dir.FirstInstance()
+	if err != nil {
+		return err
+	} else if inst == nil {
+		return fmt.Errorf("dir maps to an empty list of instances")
+	}
+	schemaNames, err := dir.SchemaNames(inst)
+	if err != nil {
+		return err
+	}
+	schemasByName, err := inst.SchemasByName(schemaNames...)
+	if err != nil {
+		return err
+	}
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return err
+	}
+	for _, schemaName := range schemaNames {
+		schema := schemasByName[schemaName]
+		if schema == nil { // schema does not exist yet, so it has no objects
+			schema = &tengo.Schema{Name: schemaName}
+		}
+		sd.AddLive(inst, schema, ignoreOpts)
+	}
+	return nil
+}
+
+// compareSnapshot reports the changes between the named snapshot and the
+// current state of dir and its subdirs.
+func compareSnapshot(dir *fs.Dir, sf *fs.SnapshotFile, name string) error {
+	listLimit, err := dir.Config.GetInt("list-limit")
+	if err == nil && listLimit < 0 {
+		err = fmt.Errorf("list-limit cannot be negative")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	before := sf.Snapshot(name)
+	if before == nil {
+		return NewExitValue(CodeBadUsage, "No snapshot named %s in %s", name, sf.Path)
+	}
+	after := fs.NewSnapshot("")
+	skipCount := snapshotWalker(dir, sf, after, 5)
+
+	// Only compare dirs at or below the current dir
+	ownKey := sf.DirKey(dir)
+	dirKeys := make(map[string]bool)
+	for _, snap := range []*fs.Snapshot{before, after} {
+		for key := range snap.Dirs {
+			if ownKey == "." || key == ownKey || (len(key) > len(ownKey) && key[:len(ownKey)+1] == ownKey+"/") {
+				dirKeys[key] = true
+			}
+		}
+	}
+	sortedKeys := make([]string, 0, len(dirKeys))
+	for key := range dirKeys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var differences bool
+	for _, key := range sortedKeys {
+		beforeDir, afterDir := before.Dirs[key], after.Dirs[key]
+		if beforeDir == nil {
+			beforeDir = &fs.SnapshotDir{}
+		}
+		if afterDir == nil {
+			afterDir = &fs.SnapshotDir{}
+		}
+		added, changed, removed := fs.CompareSnapshotObjects(beforeDir.Objects, afterDir.Objects)
+		printObjectChanges(key, "", added, changed, removed, listLimit)
+		differences = differences || len(added)+len(changed)+len(removed) > 0
+
+		if !dir.Config.GetBool("live") {
+			continue
+		}
+		liveKeys := make([]string, 0, len(afterDir.Live))
+		for liveKey := range afterDir.Live {
+			liveKeys = append(liveKeys, liveKey)
+		}
+		sort.Strings(liveKeys)
+		for _, liveKey := range liveKeys {
+			beforeLive, ok := beforeDir.Live[liveKey]
+			if !ok {
+				log.Warnf("Snapshot %s does not include live state of %s for %s", name, liveKey, key)
+				continue
+			}
+			added, changed, removed := fs.CompareSnapshotObjects(beforeLive, afterDir.Live[liveKey])
+			printObjectChanges(fmt.Sprintf("%s live %s", key, liveKey), "", added, changed, removed, listLimit)
+			differences = differences || len(added)+len(changed)+len(removed) > 0
+		}
+	}
+
+	if skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural, plural)
+	} else if differences {
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Summarize drift between DB instances and the filesystem"
+	desc := `Reports whether the schemas on database instance(s) match the corresponding
+filesystem representation of them, without displaying any DDL. For each
+instance and schema, the output shows counts of added, changed, and removed
+objects, followed by a short list of the affected objects. The output is
+designed to be concise enough for periodic reports, such as a daily cron email.
+
+"Added" objects are defined in the filesystem but missing from the instance;
+"removed" objects exist on the instance but not in the filesystem. This is the
+same perspective as ` + "`" + `skeema diff` + "`" + `.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema status staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if no differences were found, 1 if some
+differences were found, or 2+ if an error occurred.`
+
+	cmd := mybase.NewCommand("status", summary, desc, StatusHandler)
+	cmd.AddOption(mybase.BoolOption("first-only", '1', false, "For dirs mapping to multiple instances or schemas, just run against the first per dir"))
+	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Follow *.sql table definitions exactly, even for differences with no functional impact"))
+	cmd.AddOption(mybase.BoolOption("strict-flavor", 0, false, "Skip instances whose actual flavor differs from the configured flavor in ways affecting managed features"))
+	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
+	cmd.AddOption(mybase.StringOption("list-limit", 0, "10", "Maximum number of objects to list per schema; 0 for no limit"))
+	cmd.AddOption(mybase.StringOption("snapshot", 0, "", "Use definitions from this named snapshot instead of *.sql files"))
+	cmd.AddOption(mybase.StringOption("snapshot-file", 0, "skeema-snapshots.json", "Path to file storing snapshots recorded by `skeema snapshot`"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// StatusHandler is the handler method for `skeema status`
+func StatusHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	listLimit, err := dir.Config.GetInt("list-limit")
+	if err == nil && listLimit < 0 {
+		err = fmt.Errorf("list-limit cannot be negative")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	targets, skipCount := applier.TargetsForDir(dir, 5)
+	sort.Slice(targets, func(i, j int) bool {
+		ti, tj := targets[i], targets[j]
+		if ti.Instance.String() != tj.Instance.String() {
+			return ti.Instance.String() < tj.Instance.String()
+		} else if ti.SchemaFromDir.Name != tj.SchemaFromDir.Name {
+			return ti.SchemaFromDir.Name < tj.SchemaFromDir.Name
+		}
+		return ti.Dir.Path < tj.Dir.Path
+	})
+
+	var differences bool
+	for _, t := range targets {
+		mods := tengo.StatementModifiers{
+			NextAutoInc:     tengo.NextAutoIncIfIncreased,
+			CompareMetadata: t.Dir.Config.GetBool("compare-metadata"),
+			Flavor:          t.Instance.Flavor(),
+		}
+		if t.Dir.Config.GetBool("exact-match") {
+			mods.StrictIndexOrder = true
+			mods.StrictForeignKeyNaming = true
+		}
+		status, err := applier.NewStatus(t, mods)
+		if err != nil {
+			if _, ok := err.(applier.ConfigError); ok {
+				return NewExitValue(CodeBadConfig, err.Error())
+			}
+			return err
+		}
+		printStatus(status, t.Dir.Config.Get("environment"), listLimit)
+		differences = differences || status.Differences()
+	}
+
+	if skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d operation%s due to error%s", skipCount, plural, plural)
+	} else if differences {
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
+
+// printStatus outputs a summary of status to STDOUT, listing at most
+// listLimit objects (or all objects if listLimit is 0).
+func printStatus(status *applier.Status, environment string, listLimit int) {
+	t := status.Target
+	header := fmt.Sprintf("%s %s %s (%s)", environment, t.Instance, tengo.EscapeIdentifier(t.SchemaFromDir.Name), t.Dir.RelPath())
+	var note string
+	if status.Unsupported > 0 {
+		note = fmt.Sprintf(" (%d unsupported for diff)", status.Unsupported)
+	}
+	printObjectChanges(header, note, status.Added, status.Changed, status.Removed, listLimit)
+}
+
+// printObjectChanges outputs a one-line summary of the supplied changes to
+// STDOUT, followed by a list of at most listLimit affected objects (or all
+// objects if listLimit is 0). Any note is appended to the summary line.
+func printObjectChanges(header, note string, added, changed, removed []tengo.ObjectKey, listLimit int) {
+	total := len(added) + len(changed) + len(removed)
+	if total == 0 {
+		fmt.Printf("%s: in sync\n", header)
+		return
+	}
+	fmt.Printf("%s: %d added, %d changed, %d removed%s\n", header, len(added), len(changed), len(removed), note)
+
+	var listed int
+	for _, group := range []struct {
+		prefix string
+		keys   []tengo.ObjectKey
+	}{{"+", added}, {"~", changed}, {"-", removed}} {
+		for _, key := range group.keys {
+			if listLimit > 0 && listed >= listLimit {
+				break
+			}
+			fmt.Printf("  %s %s\n", group.prefix, key)
+			listed++
+		}
+	}
+	if remaining := total - listed; remaining > 0 {
+		fmt.Printf("  ... and %d more\n", remaining)
+	}
+}
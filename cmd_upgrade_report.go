@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Report on the impact of upgrading to MySQL 8.0"
+	desc := `Analyzes every schema in the filesystem representation against the rules
+of MySQL 8.0, and outputs a pre-upgrade report to STDOUT. This is intended for
+use when planning an upgrade from MySQL 5.7 (or Percona Server 5.7), and
+covers the following categories of issues:
+
+* removed-feature: routines or generated columns using functions or syntax
+  which were removed in MySQL 8.0, such as PASSWORD(), ENCODE(), spatial
+  functions lacking an ST_ prefix, SQL_CACHE, or GROUP BY ... ASC/DESC; as
+  well as removed sql_mode values in connect-options
+* charset-default: schemas and tables relying on the server's default character
+  set or collation, which change to utf8mb4 and utf8mb4_0900_ai_ci in MySQL
+  8.0; and tables or columns using the deprecated utf8 (utf8mb3) character set
+* reserved-word: tables, columns, and routines named after words which became
+  reserved in MySQL 8.0
+* partitioning: partitioned tables using a storage engine other than InnoDB,
+  which MySQL 8.0 does not support
+
+With --fix, issues which can be resolved mechanically are fixed by rewriting
+the *.sql files and .skeema files: implicit schema and table character sets
+and collations are made explicit, using their current values; and partitioned
+tables are converted to InnoDB. All other issues must be resolved manually.
+
+This command relies on accessing database instances to introspect the
+filesystem representation of each schema. All DDL will be run against a
+temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to run the DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if no issues were found, or if all issues
+were fixed; 1 if any issues remain; or 2+ if any error occurred.`
+
+	cmd := mybase.NewCommand("upgrade-report", summary, desc, UpgradeReportHandler)
+	cmd.AddOption(mybase.BoolOption("fix", 0, false, "Rewrite files to fix issues which can be resolved automatically"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// UpgradeReportHandler is the handler method for `skeema upgrade-report`
+func UpgradeReportHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	issueCount, skipCount := upgradeReportWalker(dir, w, 5)
+	if err := w.Flush(); err != nil {
+		return NewExitValue(CodeFatalError, "Unable to write upgrade report: %s", err)
+	}
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %d dir%s due to error%s", skipCount, plural(skipCount), plural(skipCount))
+	} else if issueCount > 0 {
+		return NewExitValue(CodeDifferencesFound, "Found %d upgrade issue%s", issueCount, plural(issueCount))
+	}
+	return nil
+}
+
+// upgradeReportWalker calls upgradeReportDir on dir, and recursively calls
+// itself on any subdirs. It returns the number of unfixed issues found, and
+// the number of dirs which could not be processed due to errors.
+func upgradeReportWalker(dir *fs.Dir, w io.Writer, maxDepth int) (issueCount, skipCount int) {
+	if dir.HasSchema() {
+		if count, err := upgradeReportDir(dir, w); err != nil {
+			log.Errorf("Skipping %s: %s", dir, err)
+			skipCount++
+		} else {
+			issueCount += count
+		}
+	}
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		skipCount++
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Warnf("Not walking subdirs of %s: max depth reached", dir)
+		skipCount += len(subdirs)
+	} else {
+		skipCount += badCount
+		for _, sub := range subdirs {
+			subIssues, subSkips := upgradeReportWalker(sub, w, maxDepth-1)
+			issueCount += subIssues
+			skipCount += subSkips
+		}
+	}
+	return issueCount, skipCount
+}
+
+// upgradeReportDir analyzes dir's schema, writes its issues to w, and then
+// fixes issues in dir's files if the fix option is enabled. It returns the
+// number of issues which were not fixed.
+func upgradeReportDir(dir *fs.Dir, w io.Writer) (int, error) {
+	var logicalSchema *fs.LogicalSchema
+	for _, ls := range dir.LogicalSchemas {
+		if ls.Name == "" {
+			logicalSchema = ls
+		}
+	}
+	if logicalSchema == nil {
+		return 0, nil
+	}
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return 0, err
+	}
+	opts, err := dirWorkspaceOptions(dir)
+	if err != nil {
+		return 0, err
+	}
+	flavor := opts.Flavor
+	if !flavor.Known() && opts.Instance != nil {
+		flavor = opts.Instance.Flavor()
+	}
+	if flavor.Vendor == tengo.VendorMariaDB {
+		log.Warnf("%s: skipping upgrade report, since it only applies to MySQL and Percona Server", dir)
+		return 0, nil
+	} else if flavor.MySQLishMinVersion(8, 0) {
+		log.Infof("%s: already using flavor %s; skipping upgrade report", dir, flavor)
+		return 0, nil
+	} else if !flavor.Known() {
+		flavor = tengo.FlavorMySQL57
+	}
+
+	schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+	if err != nil {
+		return 0, err
+	}
+	for _, stmtErr := range statementErrors {
+		log.Error(stmtErr.Error())
+	}
+	if len(statementErrors) > 0 {
+		return 0, fmt.Errorf("%d statements could not be executed", len(statementErrors))
+	}
+
+	var issues []*upgradeIssue
+	for _, issue := range upgradeIssues(schema, logicalSchema, flavor, dir.Config.Get("connect-options")) {
+		if issue.key.Name == "" || !ignoreOpts.ShouldIgnore(issue.key) {
+			issues = append(issues, issue)
+		}
+	}
+	if len(issues) == 0 {
+		log.Infof("%s: no upgrade issues found", dir)
+		return 0, nil
+	}
+
+	fix := dir.Config.GetBool("fix")
+	var unfixed int
+	fmt.Fprintf(w, "-- %s\n", dir)
+	for _, issue := range issues {
+		var status string
+		if issue.fix == upgradeFixNone {
+			unfixed++
+		} else if fix {
+			status = " (fixed)"
+		} else {
+			status = " (fixable with --fix)"
+			unfixed++
+		}
+		fmt.Fprintf(w, "[%s] %s%s\n", issue.category, issue.message, status)
+	}
+	fmt.Fprintln(w)
+	if fix {
+		if err := fixUpgradeIssues(dir, logicalSchema, schema, issues); err != nil {
+			return unfixed, err
+		}
+	}
+	return unfixed, nil
+}
+
+// upgradeFix indicates how an upgradeIssue may be fixed automatically.
+type upgradeFix int
+
+// Constants enumerating valid upgradeFix values
+const (
+	upgradeFixNone          upgradeFix = iota
+	upgradeFixSchemaCharSet            // set default-character-set and default-collation in .skeema
+	upgradeFixTableCharSet             // add explicit DEFAULT CHARSET and COLLATE to CREATE TABLE
+	upgradeFixEngine                   // convert a partitioned table to InnoDB
+)
+
+// upgradeIssue describes a single problem affecting an upgrade to MySQL 8.0.
+type upgradeIssue struct {
+	category string
+	key      tengo.ObjectKey // zero value for schema-level issues
+	message  string
+	fix      upgradeFix
+}
+
+// removedFunctions maps names of functions removed in MySQL 8.0 to their
+// replacement, or an empty string if there is no direct replacement.
+var removedFunctions = map[string]string{
+	"PASSWORD":         "",
+	"ENCODE":           "AES_ENCRYPT",
+	"DECODE":           "AES_DECRYPT",
+	"ENCRYPT":          "SHA2",
+	"DES_ENCRYPT":      "AES_ENCRYPT",
+	"DES_DECRYPT":      "AES_DECRYPT",
+	"GLENGTH":          "ST_LENGTH",
+	"ASTEXT":           "ST_ASTEXT",
+	"ASWKT":            "ST_ASWKT",
+	"ASBINARY":         "ST_ASBINARY",
+	"ASWKB":            "ST_ASWKB",
+	"GEOMFROMTEXT":     "ST_GEOMFROMTEXT",
+	"GEOMETRYFROMTEXT": "ST_GEOMETRYFROMTEXT",
+	"GEOMFROMWKB":      "ST_GEOMFROMWKB",
+	"POINTFROMTEXT":    "ST_POINTFROMTEXT",
+	"LINEFROMTEXT":     "ST_LINEFROMTEXT",
+	"POLYFROMTEXT":     "ST_POLYFROMTEXT",
+	"CENTROID":         "ST_CENTROID",
+	"CONVEXHULL":       "ST_CONVEXHULL",
+	"ENVELOPE":         "ST_ENVELOPE",
+	"NUMPOINTS":        "ST_NUMPOINTS",
+	"STARTPOINT":       "ST_STARTPOINT",
+	"ENDPOINT":         "ST_ENDPOINT",
+	"POINTN":           "ST_POINTN",
+	"EXTERIORRING":     "ST_EXTERIORRING",
+	"INTERIORRINGN":    "ST_INTERIORRINGN",
+	"NUMINTERIORRINGS": "ST_NUMINTERIORRINGS",
+	"NUMGEOMETRIES":    "ST_NUMGEOMETRIES",
+	"GEOMETRYN":        "ST_GEOMETRYN",
+	"ISCLOSED":         "ST_ISCLOSED",
+	"ISSIMPLE":         "ST_ISSIMPLE",
+}
+
+// removedSQLModes lists sql_mode values which were removed in MySQL 8.0.
+var removedSQLModes = []string{
+	"NO_AUTO_CREATE_USER", "DB2", "MAXDB", "MSSQL", "MYSQL323", "MYSQL40", "ORACLE",
+	"POSTGRESQL", "NO_FIELD_OPTIONS", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS",
+}
+
+var (
+	removedFunctionRegexp = func() *regexp.Regexp {
+		names := make([]string, 0, len(removedFunctions))
+		for name := range removedFunctions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return regexp.MustCompile(`(?i)\b(` + strings.Join(names, "|") + `)\s*\(`)
+	}()
+	groupBySortRegexp   = regexp.MustCompile("(?i)\\bGROUP\\s+BY\\s+[\\w.`]+(?:\\s*,\\s*[\\w.`]+)*\\s+(?:ASC|DESC)\\b")
+	sqlCacheRegexp      = regexp.MustCompile(`(?i)\bSQL_CACHE\b`)
+	partitionByRegexp   = regexp.MustCompile(`(?i)(/\*!\d+\s*)?\bPARTITION\s+BY\b`)
+	tableCharSetRegexp  = regexp.MustCompile(`(?i)\b(CHARSET|CHARACTER\s+SET)\b`)
+	tableCollateRegexp  = regexp.MustCompile(`(?i)\bCOLLATE\b`)
+	generatedLineRegexp = regexp.MustCompile(`(?i)\bGENERATED\s+ALWAYS\s+AS\b`)
+)
+
+// upgradeIssues returns all issues affecting an upgrade of schema from flavor
+// to MySQL 8.0. Statements in logicalSchema are examined to determine whether
+// character sets and collations are specified explicitly. connectOptions
+// should be the value of the connect-options option.
+func upgradeIssues(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, flavor tengo.Flavor, connectOptions string) (issues []*upgradeIssue) {
+	add := func(category string, key tengo.ObjectKey, fix upgradeFix, format string, a ...interface{}) {
+		issues = append(issues, &upgradeIssue{
+			category: category,
+			key:      key,
+			message:  fmt.Sprintf(format, a...),
+			fix:      fix,
+		})
+	}
+	target := tengo.Flavor{Vendor: flavor.Vendor, Major: 8, Minor: 0}
+	newReserved := linter.NewReservedWords(flavor, target)
+
+	// Schema-level issues
+	if opts, err := util.SplitConnectOptions(connectOptions); err == nil && opts["sql_mode"] != "" {
+		modes := strings.Split(strings.ToUpper(strings.Trim(opts["sql_mode"], "'")), ",")
+		for _, mode := range modes {
+			for _, removed := range removedSQLModes {
+				if mode == removed {
+					add("removed-feature", tengo.ObjectKey{}, upgradeFixNone, "sql_mode value %s in connect-options was removed in %s", mode, target)
+				}
+			}
+		}
+	}
+	if logicalSchema.CharSet == "" {
+		add("charset-default", tengo.ObjectKey{}, upgradeFixSchemaCharSet, "Schema default character set is not configured, so it depends on the server default, which changes to utf8mb4 in %s", target)
+	} else if strings.HasPrefix(logicalSchema.CharSet, "utf8mb4") && logicalSchema.Collation == "" {
+		add("charset-default", tengo.ObjectKey{}, upgradeFixSchemaCharSet, "Schema default collation is not configured, so the default collation of utf8mb4 changes to utf8mb4_0900_ai_ci in %s", target)
+	}
+
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		name := tengo.EscapeIdentifier(table.Name)
+		if stmt := logicalSchema.Creates[key]; stmt != nil {
+			body, _ := stmt.SplitTextBody()
+			start, end := tableOptionsBounds(body)
+			options := body[start:end]
+			if !tableCharSetRegexp.MatchString(options) {
+				add("charset-default", key, upgradeFixTableCharSet, "Table %s does not specify a character set, so it depends on the schema default", name)
+			} else if table.CharSet == "utf8mb4" && !tableCollateRegexp.MatchString(options) {
+				add("charset-default", key, upgradeFixTableCharSet, "Table %s does not specify a collation, so it will use utf8mb4_0900_ai_ci in %s", name, target)
+			}
+		}
+		if isUTF8MB3(table.CharSet) {
+			add("charset-default", key, upgradeFixNone, "Table %s uses character set %s, which is a deprecated alias for utf8mb3 in %s; see skeema convert-collation", name, table.CharSet, target)
+		}
+		for _, col := range table.Columns {
+			if col.CharSet == table.CharSet {
+				continue
+			}
+			if isUTF8MB3(col.CharSet) {
+				add("charset-default", key, upgradeFixNone, "Column %s of table %s uses character set %s, which is a deprecated alias for utf8mb3 in %s; see skeema convert-collation", tengo.EscapeIdentifier(col.Name), name, col.CharSet, target)
+			} else if col.CharSet == "utf8mb4" && col.CollationIsDefault {
+				add("charset-default", key, upgradeFixNone, "Column %s of table %s does not specify a collation, so it will use utf8mb4_0900_ai_ci in %s", tengo.EscapeIdentifier(col.Name), name, target)
+			}
+		}
+		if newReserved[strings.ToUpper(table.Name)] {
+			add("reserved-word", key, upgradeFixNone, "Table name %s is a reserved word in %s, and must be quoted in queries", name, target)
+		}
+		for _, col := range table.Columns {
+			if newReserved[strings.ToUpper(col.Name)] {
+				add("reserved-word", key, upgradeFixNone, "Column name %s of table %s is a reserved word in %s, and must be quoted in queries", tengo.EscapeIdentifier(col.Name), name, target)
+			}
+		}
+		for _, line := range strings.Split(table.CreateStatement, "\n") {
+			if generatedLineRegexp.MatchString(line) {
+				for _, desc := range removedFunctionUses(line) {
+					add("removed-feature", key, upgradeFixNone, "Generated column in table %s uses %s", name, desc)
+				}
+			}
+		}
+		if partitionByRegexp.MatchString(table.CreateStatement) && !strings.EqualFold(table.Engine, "InnoDB") && !strings.EqualFold(table.Engine, "ndbcluster") {
+			add("partitioning", key, upgradeFixEngine, "Partitioned table %s uses storage engine %s, but %s only supports partitioning with InnoDB", name, table.Engine, target)
+		}
+	}
+
+	for _, routine := range schema.Routines {
+		key := tengo.ObjectKey{Type: routine.Type, Name: routine.Name}
+		desc := fmt.Sprintf("%s %s", routine.Type, tengo.EscapeIdentifier(routine.Name))
+		if newReserved[strings.ToUpper(routine.Name)] {
+			add("reserved-word", key, upgradeFixNone, "Name of %s is a reserved word in %s, and must be quoted in calls", desc, target)
+		}
+		for _, use := range removedFunctionUses(routine.Body) {
+			add("removed-feature", key, upgradeFixNone, "Body of %s uses %s", desc, use)
+		}
+		if groupBySortRegexp.MatchString(routine.Body) {
+			add("removed-feature", key, upgradeFixNone, "Body of %s uses ASC or DESC in a GROUP BY clause, which was removed in %s; use ORDER BY instead", desc, target)
+		}
+		if sqlCacheRegexp.MatchString(routine.Body) {
+			add("removed-feature", key, upgradeFixNone, "Body of %s uses SQL_CACHE, which was removed along with the query cache in %s", desc, target)
+		}
+	}
+	return issues
+}
+
+// removedFunctionUses returns a description of each distinct removed function
+// called in text, in order of first use.
+func removedFunctionUses(text string) (uses []string) {
+	seen := make(map[string]bool)
+	for _, matches := range removedFunctionRegexp.FindAllStringSubmatch(text, -1) {
+		name := strings.ToUpper(matches[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if replacement := removedFunctions[name]; replacement != "" {
+			uses = append(uses, fmt.Sprintf("removed function %s(); use %s() instead", name, replacement))
+		} else {
+			uses = append(uses, fmt.Sprintf("removed function %s(), which has no replacement", name))
+		}
+	}
+	return uses
+}
+
+// isUTF8MB3 returns true if charSet refers to the 3-byte utf8 character set.
+func isUTF8MB3(charSet string) bool {
+	return charSet == "utf8" || charSet == "utf8mb3"
+}
+
+// tableOptionsBounds returns the start and end offsets of the table options
+// clause in body, a CREATE TABLE statement without any trailing delimiter. The
+// clause is located after the closing parenthesis of the column and index
+// definitions, and before any partitioning clause.
+func tableOptionsBounds(body string) (start, end int) {
+	end = len(body)
+	if loc := partitionByRegexp.FindStringIndex(body); loc != nil {
+		end = loc[0]
+	}
+	start = strings.LastIndex(body[:end], ")") + 1
+	for end > start && (body[end-1] == ' ' || body[end-1] == '\n' || body[end-1] == '\t') {
+		end--
+	}
+	return start, end
+}
+
+// explicitTableCharSet returns a modified version of the text of stmt, a CREATE
+// TABLE statement, which specifies the supplied character set and collation in
+// its table options if it did not already.
+func explicitTableCharSet(stmt *fs.Statement, charSet, collation string) string {
+	body, suffix := stmt.SplitTextBody()
+	start, end := tableOptionsBounds(body)
+	options := body[start:end]
+	if !tableCharSetRegexp.MatchString(options) {
+		options += " DEFAULT CHARSET=" + charSet
+	}
+	if !tableCollateRegexp.MatchString(options) {
+		options += " COLLATE=" + collation
+	}
+	return body[:start] + options + body[end:] + suffix
+}
+
+// fixUpgradeIssues rewrites dir's files to fix any issues which may be fixed
+// automatically. schema should be the workspace introspection of
+// logicalSchema, which supplies the current character sets and collations.
+func fixUpgradeIssues(dir *fs.Dir, logicalSchema *fs.LogicalSchema, schema *tengo.Schema, issues []*upgradeIssue) error {
+	tables := schema.TablesByName()
+	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
+	var fixSchema bool
+	for _, issue := range issues {
+		if issue.fix == upgradeFixSchemaCharSet {
+			fixSchema = true
+			continue
+		}
+		stmt, table := logicalSchema.Creates[issue.key], tables[issue.key.Name]
+		if stmt == nil || table == nil {
+			continue
+		}
+		switch issue.fix {
+		case upgradeFixTableCharSet:
+			stmt.Text = explicitTableCharSet(stmt, table.CharSet, table.Collation)
+		case upgradeFixEngine:
+			re := regexp.MustCompile(`(?i)(\bENGINE\s*=\s*)` + regexp.QuoteMeta(table.Engine) + `\b`)
+			stmt.Text = re.ReplaceAllString(stmt.Text, "${1}InnoDB")
+		default:
+			continue
+		}
+		filesToRewrite[stmt.FromFile] = true
+	}
+	for file := range filesToRewrite {
+		if _, err := file.Rewrite(); err != nil {
+			return fmt.Errorf("Unable to write %s: %s", file.Path(), err)
+		}
+		log.Infof("Wrote %s", file.Path())
+	}
+	if fixSchema {
+		dir.OptionFile.SetOptionValue("", "default-character-set", schema.CharSet)
+		dir.OptionFile.SetOptionValue("", "default-collation", schema.Collation)
+		if err := dir.OptionFile.Write(true); err != nil {
+			return fmt.Errorf("Unable to write %s: %s", dir.OptionFile.Path(), err)
+		}
+		log.Infof("Wrote %s -- updated schema-level default-character-set and default-collation", dir.OptionFile.Path())
+	}
+	return nil
+}
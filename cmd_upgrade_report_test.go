@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestUpgradeIssues(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned"}
+	rankCol := &tengo.Column{Name: "rank", TypeInDB: "int(10) unsigned"}
+	nameCol := &tengo.Column{Name: "name", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", CollationIsDefault: true}
+	users := &tengo.Table{
+		Name:            "users",
+		Engine:          "InnoDB",
+		CharSet:         "latin1",
+		Collation:       "latin1_swedish_ci",
+		Columns:         []*tengo.Column{idCol, rankCol, nameCol},
+		CreateStatement: "CREATE TABLE `users` (\n  `id` int(10) unsigned NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	events := &tengo.Table{
+		Name:            "events",
+		Engine:          "MyISAM",
+		CharSet:         "utf8mb4",
+		Collation:       "utf8mb4_general_ci",
+		Columns:         []*tengo.Column{idCol},
+		CreateStatement: "CREATE TABLE `events` (\n  `id` int(10) unsigned NOT NULL\n) ENGINE=MyISAM DEFAULT CHARSET=utf8mb4\n/*!50100 PARTITION BY HASH (id)\nPARTITIONS 4 */",
+	}
+	legacy := &tengo.Table{
+		Name:            "legacy",
+		Engine:          "InnoDB",
+		CharSet:         "utf8",
+		Collation:       "utf8_general_ci",
+		Columns:         []*tengo.Column{idCol},
+		CreateStatement: "CREATE TABLE `legacy` (\n  `id` int(10) unsigned NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	}
+	routine := &tengo.Routine{
+		Name: "window",
+		Type: tengo.ObjectTypeProc,
+		Body: "BEGIN SELECT AsText(g), PASSWORD('x') FROM t GROUP BY a, b DESC; SELECT SQL_CACHE 1; END",
+	}
+	schema := &tengo.Schema{Name: "app", Tables: []*tengo.Table{users, events, legacy}, Routines: []*tengo.Routine{routine}}
+	logicalSchema := &fs.LogicalSchema{
+		CharSet: "utf8mb4",
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeTable, Name: "users"}:  {Text: "CREATE TABLE users (id int unsigned NOT NULL, `rank` int unsigned, name varchar(30) CHARACTER SET utf8mb4)\n"},
+			{Type: tengo.ObjectTypeTable, Name: "events"}: {Text: events.CreateStatement + "\n"},
+			{Type: tengo.ObjectTypeTable, Name: "legacy"}: {Text: legacy.CreateStatement + " COLLATE=utf8_general_ci\n"},
+		},
+	}
+
+	issues := upgradeIssues(schema, logicalSchema, tengo.FlavorMySQL57, "sql_mode='STRICT_ALL_TABLES,NO_AUTO_CREATE_USER'")
+	var actual []string
+	var fixable int
+	for _, issue := range issues {
+		actual = append(actual, issue.category+" "+issue.key.Name)
+		if issue.fix != upgradeFixNone {
+			fixable++
+		}
+	}
+	expected := []string{
+		"removed-feature ",
+		"charset-default ",
+		"charset-default users",
+		"charset-default users",
+		"reserved-word users",
+		"charset-default events",
+		"partitioning events",
+		"charset-default legacy",
+		"reserved-word window",
+		"removed-feature window",
+		"removed-feature window",
+		"removed-feature window",
+		"removed-feature window",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected issues:\n%s", strings.Join(actual, "\n"))
+	}
+	if fixable != 4 {
+		t.Errorf("Expected 4 fixable issues, instead found %d", fixable)
+	}
+	if !strings.Contains(issues[9].message, "ASTEXT(); use ST_ASTEXT() instead") || !strings.Contains(issues[10].message, "PASSWORD(), which has no replacement") {
+		t.Errorf("Unexpected messages for removed functions: %q, %q", issues[9].message, issues[10].message)
+	}
+}
+
+func TestExplicitTableCharSet(t *testing.T) {
+	cases := map[string]string{
+		"CREATE TABLE t (id int)\n":                                                                 "CREATE TABLE t (id int) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci\n",
+		"CREATE TABLE t (id int) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4":                             "CREATE TABLE t (id int) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci",
+		"CREATE TABLE t (id int) CHARSET utf8mb4 COLLATE utf8mb4_bin;":                              "CREATE TABLE t (id int) CHARSET utf8mb4 COLLATE utf8mb4_bin;",
+		"CREATE TABLE t (id int) ENGINE=InnoDB\n/*!50100 PARTITION BY HASH (id)\nPARTITIONS 4 */\n": "CREATE TABLE t (id int) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci\n/*!50100 PARTITION BY HASH (id)\nPARTITIONS 4 */\n",
+	}
+	for input, expected := range cases {
+		if actual := explicitTableCharSet(&fs.Statement{Text: input}, "utf8mb4", "utf8mb4_general_ci"); actual != expected {
+			t.Errorf("Unexpected result from explicitTableCharSet on %q: %q", input, actual)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Verify that all *.sql files execute successfully"
+	desc := `Verifies that every CREATE statement in the filesystem representation executes
+successfully, by running them all in a workspace. Any statement that returns an
+error is reported, along with its file and line number. Statements which cannot
+be parsed are reported as warnings.
+
+Unlike ` + "`" + `skeema lint` + "`" + `, no linter rules are checked and no files are
+reformatted. Unlike ` + "`" + `skeema diff` + "`" + `, no comparison is made against the real
+schemas on any database instance. This makes validate the cheapest possible CI
+check for a schema repo, especially when combined with workspace=docker.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+for the workspace. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all statements executed successfully; 1
+if only warnings were emitted; or 2+ if any statements failed or any other
+error occurred.`
+
+	cmd := mybase.NewCommand("validate", summary, desc, ValidateHandler)
+	cmd.AddOption(mybase.StringOption("format", 0, "TEXT", `Output format for problems found (valid values: "TEXT", "JSON", "SARIF", "GITHUB")`))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ValidateHandler is the handler method for `skeema validate`
+func ValidateHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	format, err := dir.Config.GetEnum("format", "text", "json", "sarif", "github")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	result := validateWalker(dir, 5)
+	switch format {
+	case "json":
+		err = writeLintJSON(os.Stdout, result)
+	case "sarif":
+		err = writeLintSARIF(os.Stdout, result)
+	case "github":
+		err = writeLintGitHub(os.Stdout, result)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(result.Exceptions) > 0:
+		exitCode := CodeFatalError
+		for _, err := range result.Exceptions {
+			if _, ok := err.(linter.ConfigError); ok {
+				exitCode = CodeBadConfig
+			}
+		}
+		return NewExitValue(exitCode, "Skipped %d operations due to fatal errors", len(result.Exceptions))
+	case len(result.Errors) > 0:
+		return NewExitValue(CodeFatalError, "Found %d errors", len(result.Errors))
+	case len(result.Warnings) > 0:
+		return NewExitValue(CodeDifferencesFound, "Found %d warnings", len(result.Warnings))
+	}
+	return nil
+}
+
+func validateWalker(dir *fs.Dir, maxDepth int) *linter.Result {
+	result := validateDir(dir)
+
+	var subdirErr error
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
+	} else {
+		if badCount > 0 {
+			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+		}
+		for _, sub := range subdirs {
+			result.Merge(validateWalker(sub, maxDepth-1))
+		}
+	}
+	if subdirErr != nil {
+		log.Error(subdirErr)
+		result.Exceptions = append(result.Exceptions, subdirErr)
+	}
+	return result
+}
+
+// validateDir executes the statements of a single directory in a workspace,
+// without recursing into subdirs. Problems are logged, and also returned in
+// a *linter.Result for use in machine-readable output formats.
+func validateDir(dir *fs.Dir) *linter.Result {
+	result := &linter.Result{}
+	if len(dir.LogicalSchemas) == 0 && len(dir.IgnoredStatements) == 0 {
+		return result
+	}
+	log.Infof("Validating %s", dir)
+	if err := validateLogicalSchemas(dir, result); err != nil {
+		log.Error(fmt.Errorf("Skipping schema in %s due to error: %s", dir.RelPath(), err))
+		result.Exceptions = append(result.Exceptions, err)
+		return result
+	}
+	for _, stmt := range dir.IgnoredStatements {
+		result.Warnings = append(result.Warnings, &linter.Annotation{
+			Statement: stmt,
+			Summary:   "Unable to parse statement",
+			Message:   "Ignoring unsupported or unparseable SQL statement",
+		})
+	}
+	for _, annotation := range result.Errors {
+		log.Error(annotation.MessageWithLocation())
+	}
+	for _, annotation := range result.Warnings {
+		log.Warning(annotation.MessageWithLocation())
+	}
+	return result
+}
+
+// validateLogicalSchemas executes each of dir's logical schemas in a
+// workspace, adding an error annotation to result for each statement that
+// fails. Statements for objects matching the dir's ignore options are not
+// reported. A non-nil error is returned only for fatal problems; configuration
+// problems are returned as a linter.ConfigError.
+func validateLogicalSchemas(dir *fs.Dir, result *linter.Result) error {
+	if len(dir.LogicalSchemas) == 0 {
+		return nil
+	}
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return linter.ConfigError(err.Error())
+	}
+
+	// Connect to first defined instance, unless configured to use local Docker
+	var inst *tengo.Instance
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		if inst, err = dir.FirstInstance(); err != nil {
+			return linter.ConfigError(err.Error())
+		}
+	}
+	opts, err := workspace.OptionsForDir(dir, inst)
+	if err != nil {
+		return linter.ConfigError(err.Error())
+	}
+
+	for _, logicalSchema := range dir.LogicalSchemas {
+		_, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+		if err != nil {
+			return err
+		}
+		for _, stmtErr := range statementErrors {
+			if ignoreOpts.ShouldIgnore(stmtErr.ObjectKey()) {
+				log.Debugf("Skipping %s because it matches an ignore option", stmtErr.ObjectKey())
+				continue
+			}
+			result.Errors = append(result.Errors, &linter.Annotation{
+				Statement: stmtErr.Statement,
+				Summary:   "SQL statement returned an error",
+				Message:   stmtErr.Err.Error(),
+			})
+		}
+	}
+	return nil
+}
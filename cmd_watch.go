@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+)
+
+func init() {
+	summary := "Re-run lint, and optionally diff, whenever a file changes"
+	desc := `Lints the filesystem representation of schemas in the same manner as
+` + "`" + `skeema lint` + "`" + `, and then continues running, re-linting each time a *.sql or .skeema
+file at or below the current directory is created, modified, or removed. This
+provides a fast feedback loop while editing schema files.
+
+After the first run, only problems which are new since the previous run are
+logged, along with a summary of how many problems were resolved and how many
+remain. As with ` + "`" + `skeema lint` + "`" + `, files are reformatted to match the canonical
+format; these rewrites do not trigger another run.
+
+With --diff, each run also outputs the DDL that ` + "`" + `skeema diff` + "`" + ` would generate to
+make the selected environment match the filesystem. This is most useful against
+a development environment, for example ` + "`" + `skeema watch development --diff` + "`" + `.
+
+Files are checked for changes periodically, as configured by --poll-interval.
+Press Ctrl-C to exit.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+for linting and diffing. If no environment name is supplied, the default is
+"production".`
+
+	cmd := mybase.NewCommand("watch", summary, desc, WatchHandler)
+	linter.AddCommandOptions(cmd)
+	cmd.AddOption(mybase.BoolOption("fix", 0, false, "Rewrite files to correct problems that can be fixed automatically"))
+	cmd.AddOption(mybase.BoolOption("diff", 0, false, "After each lint run, also output DDL to make the environment match the filesystem"))
+	cmd.AddOption(mybase.StringOption("poll-interval", 0, "1s", "How often to check files for changes, as a duration such as 500ms or 2s"))
+	cmd.AddOption(mybase.StringOption("metrics-addr", 0, "", "Expose Prometheus metrics at /metrics on this address and port"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+	clonePushOptionsToWatch()
+}
+
+// WatchHandler is the handler method for `skeema watch`
+func WatchHandler(cfg *mybase.Config) error {
+	interval, err := time.ParseDuration(cfg.Get("poll-interval"))
+	if err == nil && interval <= 0 {
+		err = fmt.Errorf("must be greater than zero")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, "Invalid value for option poll-interval: %s", err)
+	}
+	dirPath, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	if addr := cfg.Get("metrics-addr"); addr != "" {
+		server, err := startMetricsServer(addr)
+		if err != nil {
+			return NewExitValue(CodeBadConfig, "Unable to serve metrics on metrics-addr %s: %s", addr, err)
+		}
+		defer server.Close()
+		log.Infof("Serving metrics on http://%s/metrics", addr)
+	}
+
+	// With --diff, we delegate to the same logic as `skeema diff`, which requires
+	// dry-run to be enabled
+	if cfg.GetBool("diff") {
+		cfg.CLI.OptionValues["dry-run"] = "1"
+		cfg.MarkDirty()
+	}
+
+	// Interrupts are handled between runs, so that any workspace in use can be
+	// cleaned up normally
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	known := watchRun(cfg, nil)
+	files := watchedFiles(dirPath)
+	log.Infof("Watching %s for changes. Press Ctrl-C to exit.", dirPath)
+	for {
+		select {
+		case <-sigs:
+			return nil
+		case <-ticker.C:
+		}
+		newFiles := watchedFiles(dirPath)
+		changed := files.changedPaths(newFiles)
+		if len(changed) == 0 {
+			continue
+		}
+		if len(changed) == 1 {
+			log.Infof("Detected change to %s", changed[0])
+		} else {
+			log.Infof("Detected changes to %s and %d other files", changed[0], len(changed)-1)
+		}
+		known = watchRun(cfg, known)
+
+		// Obtain file state again, so that rewrites from linting are not treated
+		// as new changes
+		files = watchedFiles(dirPath)
+	}
+}
+
+// watchRun lints the current directory and its subdirs, and optionally runs a
+// diff. Problems in known are not logged again. The return value contains all
+// problems found, for use as known in the next run.
+func watchRun(cfg *mybase.Config, known linter.Baseline) linter.Baseline {
+	util.Metrics.Count("watch_runs", 1)
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		log.Error(err)
+		return known
+	}
+
+	result := lintWalker(dir, 5, known, nil, nil)
+	found := append(append(result.Errors, result.Warnings...), result.Baselined...)
+	foundBaseline := linter.NewBaseline(found)
+	stillFound := make(map[linter.BaselineEntry]bool, len(foundBaseline))
+	for _, entry := range foundBaseline {
+		stillFound[entry] = true
+	}
+	var resolvedCount int
+	for _, entry := range known {
+		if !stillFound[entry] {
+			resolvedCount++
+		}
+	}
+	newCount := len(result.Errors) + len(result.Warnings)
+	log.Infof("Lint: %d new problem%s, %d resolved, %d total", newCount, plural(newCount), resolvedCount, len(found))
+	if len(result.Exceptions) > 0 {
+		log.Errorf("Lint: skipped %d operation%s due to fatal errors", len(result.Exceptions), plural(len(result.Exceptions)))
+	}
+
+	if dir.Config.GetBool("diff") {
+		targets, skipCount := applier.TargetsForDir(dir, 5)
+		if err := applyTargets(dir, targets, skipCount, nil); err != nil && err.Error() != "" {
+			log.Error(err)
+		}
+	}
+	return foundBaseline
+}
+
+// watchFileState maps paths of *.sql and .skeema files to a string describing
+// their size and modification time.
+type watchFileState map[string]string
+
+// watchedFiles returns the state of *.sql and .skeema files at or below
+// dirPath. Hidden subdirectories, such as .git, are not examined.
+func watchedFiles(dirPath string) watchFileState {
+	state := make(watchFileState)
+	filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dirPath && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == ".skeema" || strings.HasSuffix(name, ".sql") {
+			state[path] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return state
+}
+
+// changedPaths returns a sorted list of paths which were added, removed, or
+// modified in other compared to state.
+func (state watchFileState) changedPaths(other watchFileState) (paths []string) {
+	for path, value := range other {
+		if state[path] != value {
+			paths = append(paths, path)
+		}
+	}
+	for path := range state {
+		if _, ok := other[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// clonePushOptionsToWatch copies options from `skeema push` into `skeema watch`
+func clonePushOptionsToWatch() {
+	descRewrites := map[string]string{
+		"allow-unsafe":    "With --diff, permit generating ALTER or DROP operations that are potentially destructive",
+		"alter-wrapper":   "With --diff, output ALTER TABLEs as shell commands rather than just raw DDL; see manual for template vars",
+		"safe-below-size": "With --diff, always permit generating destructive operations for tables below this size in bytes",
+	}
+	hiddenRewrites := map[string]bool{
+		"catalog-service":            true,
+		"catalog-webhook":            true,
+		"check-target-state":         true,
+		"ddl-export-dir":             true,
+		"ddl-export-format":          true,
+		"dry-run":                    true,
+		"flyway-description":         true,
+		"flyway-version":             true,
+		"foreign-key-checks":         true,
+		"golang-migrate-description": true,
+		"golang-migrate-version":     true,
+		"history-schema":             true,
+		"interactive":                true,
+		"notify-events":              true,
+		"notify-format":              true,
+		"notify-webhook":             true,
+		"push-session-vars":          true,
+		"retry-failed":               true,
+		"retry-file":                 true,
+		"snapshot":                   true,
+		"snapshot-file":              true,
+		"summary-json":               true,
+	}
+	copyPushOptions("watch", descRewrites, hiddenRewrites)
+}
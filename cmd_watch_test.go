@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skeema/skeema/fs"
+)
+
+func TestWatchedFiles(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "skeema-watch")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+	fs.WriteTestFile(t, filepath.Join(dirPath, ".skeema"), "schema=foo\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "foo.sql"), "CREATE TABLE foo (id int);\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "notes.txt"), "not watched\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, ".git", "bar.sql"), "CREATE TABLE bar (id int);\n")
+
+	state := watchedFiles(dirPath)
+	if len(state) != 2 {
+		t.Errorf("Expected 2 watched files, instead found %v", state)
+	}
+	if changed := state.changedPaths(watchedFiles(dirPath)); len(changed) > 0 {
+		t.Errorf("Expected no changes, instead found %v", changed)
+	}
+
+	// Modifying, adding, and removing files should all be detected, but changes
+	// to unwatched files should not
+	fs.WriteTestFile(t, filepath.Join(dirPath, "foo.sql"), "CREATE TABLE foo (id bigint);\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "sub", "baz.sql"), "CREATE TABLE baz (id int);\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "notes.txt"), "still not watched\n")
+	if err := os.Remove(filepath.Join(dirPath, ".skeema")); err != nil {
+		t.Fatalf("Unable to remove file: %s", err)
+	}
+	expected := []string{
+		filepath.Join(dirPath, ".skeema"),
+		filepath.Join(dirPath, "foo.sql"),
+		filepath.Join(dirPath, "sub", "baz.sql"),
+	}
+	if changed := state.changedPaths(watchedFiles(dirPath)); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected changes %v, instead found %v", expected, changed)
+	}
+
+	// Modification time alone should be detected too
+	state = watchedFiles(dirPath)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dirPath, "foo.sql"), later, later); err != nil {
+		t.Fatalf("Unable to change file times: %s", err)
+	}
+	if changed := state.changedPaths(watchedFiles(dirPath)); len(changed) != 1 {
+		t.Errorf("Expected 1 change, instead found %v", changed)
+	}
+}
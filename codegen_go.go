@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/skeema/tengo"
+)
+
+// goNullableTypes controls how nullable columns are represented in generated
+// Go structs.
+type goNullableTypes string
+
+// Constants for goNullableTypes
+const (
+	goNullableSQL     goNullableTypes = "sql"     // database/sql Null* types
+	goNullablePointer goNullableTypes = "pointer" // pointers to the non-nullable type
+)
+
+// goCommonInitialisms are name parts which are written entirely in upper case
+// in Go identifiers, as per golint conventions.
+var goCommonInitialisms = map[string]bool{
+	"api": true, "ascii": true, "cpu": true, "css": true, "dns": true, "guid": true,
+	"html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sku": true, "sql": true, "ssh": true, "tls": true, "ttl": true, "ui": true,
+	"uid": true, "uri": true, "url": true, "utf8": true, "uuid": true, "xml": true,
+}
+
+// goIdentifier converts a table or column name into an exported Go
+// identifier, for example "user_id" becomes "UserID".
+func goIdentifier(name string) string {
+	var b strings.Builder
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, part := range parts {
+		if goCommonInitialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+		} else {
+			runes := []rune(part)
+			b.WriteString(strings.ToUpper(string(runes[0])) + string(runes[1:]))
+		}
+	}
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// goColumnType returns the Go type used to represent col in a struct field,
+// along with the import path required by the type, if any.
+func goColumnType(col *tengo.Column, nullable goNullableTypes) (goType, importPath string) {
+	colType := strings.ToLower(col.TypeInDB)
+	baseType := colType
+	if pos := strings.IndexAny(baseType, "( "); pos > -1 {
+		baseType = baseType[:pos]
+	}
+	unsigned := strings.Contains(colType, "unsigned")
+	intType := func(size string) string {
+		if unsigned {
+			return "uint" + size
+		}
+		return "int" + size
+	}
+	switch baseType {
+	case "tinyint":
+		if strings.HasPrefix(colType, "tinyint(1)") && !unsigned {
+			goType = "bool"
+		} else {
+			goType = intType("8")
+		}
+	case "smallint", "year":
+		goType = intType("16")
+	case "mediumint", "int", "integer":
+		goType = intType("32")
+	case "bigint":
+		goType = intType("64")
+	case "float":
+		goType = "float32"
+	case "double", "real":
+		goType = "float64"
+	case "date", "datetime", "timestamp":
+		goType, importPath = "time.Time", "time"
+	case "json":
+		goType, importPath = "json.RawMessage", "encoding/json"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		goType = "[]byte"
+	default:
+		// Character types, enum, set, time, and decimal (which cannot be
+		// represented exactly by any builtin numeric type)
+		goType = "string"
+	}
+	if !col.Nullable || strings.HasPrefix(goType, "[]") || goType == "json.RawMessage" {
+		return goType, importPath
+	}
+
+	if nullable == goNullableSQL {
+		sqlTypes := map[string]string{
+			"bool":      "sql.NullBool",
+			"int8":      "sql.NullInt32",
+			"uint8":     "sql.NullInt32",
+			"int16":     "sql.NullInt32",
+			"uint16":    "sql.NullInt32",
+			"int32":     "sql.NullInt32",
+			"uint32":    "sql.NullInt64",
+			"int64":     "sql.NullInt64",
+			"float32":   "sql.NullFloat64",
+			"float64":   "sql.NullFloat64",
+			"string":    "sql.NullString",
+			"time.Time": "sql.NullTime",
+		}
+		// uint64 has no equivalent, so it falls through to using a pointer
+		if sqlType, ok := sqlTypes[goType]; ok {
+			return sqlType, "database/sql"
+		}
+	}
+	return "*" + goType, importPath
+}
+
+// writeGoStructs returns gofmt'ed Go source code declaring a struct for each
+// table in schema, in package pkg. The schema's tables should already be
+// filtered to exclude any ignored objects. Output is deterministic, so that
+// generated files may be tracked in version control.
+func writeGoStructs(schema *tengo.Schema, pkg string, nullable goNullableTypes) ([]byte, error) {
+	tables := make([]*tengo.Table, len(schema.Tables))
+	copy(tables, schema.Tables)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, table := range tables {
+		structName := goIdentifier(table.Name)
+		body.WriteString("\n")
+		if table.Comment != "" {
+			fmt.Fprintf(&body, "// %s represents a row of table %s: %s\n", structName, table.Name, goCommentText(table.Comment))
+		} else {
+			fmt.Fprintf(&body, "// %s represents a row of table %s.\n", structName, table.Name)
+		}
+		fmt.Fprintf(&body, "type %s struct {\n", structName)
+		for _, col := range table.Columns {
+			goType, importPath := goColumnType(col, nullable)
+			if importPath != "" {
+				imports[importPath] = true
+			}
+			if col.Comment != "" {
+				fmt.Fprintf(&body, "\t// %s\n", goCommentText(col.Comment))
+			}
+			fmt.Fprintf(&body, "\t%s %s `db:%q json:%q`\n", goIdentifier(col.Name), goType, col.Name, col.Name)
+		}
+		body.WriteString("}\n")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by skeema gen-go from schema %s. DO NOT EDIT.\n\n", schema.Name)
+	fmt.Fprintf(&b, "package %s\n", pkg)
+	if len(imports) > 0 {
+		importPaths := make([]string, 0, len(imports))
+		for importPath := range imports {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		b.WriteString("\nimport (\n")
+		for _, importPath := range importPaths {
+			fmt.Fprintf(&b, "\t%q\n", importPath)
+		}
+		b.WriteString(")\n")
+	}
+	b.Write(body.Bytes())
+	return format.Source(b.Bytes())
+}
+
+// goCommentText returns a database comment in a form suitable for a single-line
+// Go comment.
+func goCommentText(comment string) string {
+	return strings.Join(strings.Fields(comment), " ")
+}
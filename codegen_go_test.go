@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestGoIdentifier(t *testing.T) {
+	cases := map[string]string{
+		"users":       "Users",
+		"user_id":     "UserID",
+		"api_key_url": "APIKeyURL",
+		"createdAt":   "CreatedAt",
+		"2fa_codes":   "X2faCodes",
+		"a-b c":       "ABC",
+	}
+	for input, expected := range cases {
+		if actual := goIdentifier(input); actual != expected {
+			t.Errorf("Expected goIdentifier(%q) to return %q, instead found %q", input, expected, actual)
+		}
+	}
+}
+
+func TestGoColumnType(t *testing.T) {
+	cases := []struct {
+		typeInDB   string
+		nullable   bool
+		sqlType    string
+		ptrType    string
+		importPath string
+	}{
+		{"int(10) unsigned", false, "uint32", "uint32", ""},
+		{"int(11)", true, "sql.NullInt32", "*int32", "database/sql"},
+		{"bigint(20) unsigned", true, "*uint64", "*uint64", ""},
+		{"tinyint(1)", false, "bool", "bool", ""},
+		{"tinyint(4)", false, "int8", "int8", ""},
+		{"decimal(10,2)", false, "string", "string", ""},
+		{"varchar(40)", true, "sql.NullString", "*string", "database/sql"},
+		{"timestamp", false, "time.Time", "time.Time", "time"},
+		{"datetime(6)", true, "sql.NullTime", "*time.Time", "database/sql"},
+		{"double", false, "float64", "float64", ""},
+		{"varbinary(16)", true, "[]byte", "[]byte", ""},
+		{"json", true, "json.RawMessage", "json.RawMessage", "encoding/json"},
+		{"enum('a','b')", false, "string", "string", ""},
+	}
+	for _, c := range cases {
+		col := &tengo.Column{Name: "c", TypeInDB: c.typeInDB, Nullable: c.nullable}
+		if goType, importPath := goColumnType(col, goNullableSQL); goType != c.sqlType || (c.importPath != "" && importPath != c.importPath) {
+			t.Errorf("Expected %s (nullable=%t) to map to %s from %q, instead found %s from %q", c.typeInDB, c.nullable, c.sqlType, c.importPath, goType, importPath)
+		}
+		if goType, _ := goColumnType(col, goNullablePointer); goType != c.ptrType {
+			t.Errorf("Expected %s (nullable=%t) to map to %s with pointer nullable-types, instead found %s", c.typeInDB, c.nullable, c.ptrType, goType)
+		}
+	}
+}
+
+func TestWriteGoStructs(t *testing.T) {
+	code, err := writeGoStructs(docsTestSchema(), "models", goNullableSQL)
+	if err != nil {
+		t.Fatalf("Unexpected error from writeGoStructs: %s", err)
+	}
+	out := string(code)
+	expected := []string{
+		"// Code generated by skeema gen-go from schema product. DO NOT EDIT.\n\npackage models\n",
+		"import (\n\t\"database/sql\"\n\t\"time\"\n)\n",
+		"// Posts represents a row of table posts.\ntype Posts struct {\n",
+		"\tUserID    uint32    `db:\"user_id\" json:\"user_id\"`\n",
+		"\tUpdatedAt time.Time `db:\"updated_at\" json:\"updated_at\"`\n",
+		"// Users represents a row of table users: Registered *users*\n",
+		"\t// Display name | shown publicly\n\tName sql.NullString `db:\"name\" json:\"name\"`\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+	if strings.Index(out, "type Posts") > strings.Index(out, "type Users") {
+		t.Error("Expected structs to be sorted by table name")
+	}
+}
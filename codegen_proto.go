@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// protoDefaultTypes maps MySQL base column types to protobuf field types.
+// Types not listed here map to string. tinyint(1) is handled separately, and
+// unsigned integer types use the unsigned equivalent of the type listed here.
+var protoDefaultTypes = map[string]string{
+	"tinyint":            "int32",
+	"smallint":           "int32",
+	"mediumint":          "int32",
+	"int":                "int32",
+	"integer":            "int32",
+	"year":               "int32",
+	"bigint":             "int64",
+	"float":              "float",
+	"double":             "double",
+	"real":               "double",
+	"date":               "google.protobuf.Timestamp",
+	"datetime":           "google.protobuf.Timestamp",
+	"timestamp":          "google.protobuf.Timestamp",
+	"binary":             "bytes",
+	"varbinary":          "bytes",
+	"tinyblob":           "bytes",
+	"blob":               "bytes",
+	"mediumblob":         "bytes",
+	"longblob":           "bytes",
+	"bit":                "bytes",
+	"geometry":           "bytes",
+	"point":              "bytes",
+	"linestring":         "bytes",
+	"polygon":            "bytes",
+	"multipoint":         "bytes",
+	"multilinestring":    "bytes",
+	"multipolygon":       "bytes",
+	"geometrycollection": "bytes",
+}
+
+// protoWellKnownImports maps well-known protobuf message types to the file
+// which must be imported to use them.
+var protoWellKnownImports = map[string]string{
+	"google.protobuf.Timestamp":   "google/protobuf/timestamp.proto",
+	"google.protobuf.Duration":    "google/protobuf/duration.proto",
+	"google.protobuf.Struct":      "google/protobuf/struct.proto",
+	"google.protobuf.Value":       "google/protobuf/struct.proto",
+	"google.protobuf.StringValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.BytesValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.BoolValue":   "google/protobuf/wrappers.proto",
+	"google.protobuf.Int32Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int64Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt32Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt64Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.FloatValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.DoubleValue": "google/protobuf/wrappers.proto",
+}
+
+// protoScalarTypes are the protobuf types which require the optional keyword
+// in order to distinguish an unset (NULL) field from the zero value.
+var protoScalarTypes = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true,
+	"uint64": true, "sint32": true, "sint64": true, "fixed32": true, "fixed64": true,
+	"sfixed32": true, "sfixed64": true, "bool": true, "string": true, "bytes": true,
+}
+
+var protoTypeRegexp = regexp.MustCompile(`^\.?[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// parseProtoTypeMap parses the value of the proto-type-map option, a
+// comma-separated list of mysql_type:proto_type overrides, into a map keyed by
+// lowercased MySQL base type.
+func parseProtoTypeMap(entries []string) (map[string]string, error) {
+	typeMap := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 || parts[0] == "" || !protoTypeRegexp.MatchString(parts[1]) {
+			return nil, fmt.Errorf("Invalid value for option proto-type-map: %q is not of the form mysql_type:proto_type", entry)
+		}
+		typeMap[strings.ToLower(strings.TrimSpace(parts[0]))] = parts[1]
+	}
+	return typeMap, nil
+}
+
+// protoColumnType returns the protobuf field type for col. Entries in typeMap
+// take precedence over the default mappings; typeMap keys may be a MySQL base
+// type such as "decimal", or "tinyint(1)" to override the mapping of boolean
+// columns specifically.
+func protoColumnType(col *tengo.Column, typeMap map[string]string) string {
+	colType := strings.ToLower(col.TypeInDB)
+	baseType := colType
+	if pos := strings.IndexAny(baseType, "( "); pos > -1 {
+		baseType = baseType[:pos]
+	}
+	unsigned := strings.Contains(colType, "unsigned")
+	isBool := strings.HasPrefix(colType, "tinyint(1)") && !unsigned
+	if isBool {
+		if protoType, ok := typeMap["tinyint(1)"]; ok {
+			return protoType
+		}
+	}
+	if protoType, ok := typeMap[baseType]; ok {
+		return protoType
+	}
+	if isBool {
+		return "bool"
+	}
+	protoType, ok := protoDefaultTypes[baseType]
+	if !ok {
+		// Character types, enum, set, time, json, and decimal (which cannot be
+		// represented exactly by any protobuf numeric type)
+		return "string"
+	}
+	if unsigned && strings.HasPrefix(protoType, "int") {
+		protoType = "u" + protoType
+	}
+	return protoType
+}
+
+// protoFieldName converts a column name into a protobuf field name, which must
+// consist of letters, digits, and underscores, and begin with a letter. Names
+// are lowercased, as per the protobuf style guide.
+func protoFieldName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	fieldName := b.String()
+	if fieldName == "" || fieldName[0] < 'a' || fieldName[0] > 'z' {
+		fieldName = "x" + fieldName
+	}
+	return fieldName
+}
+
+// protoMessageNumbering tracks the field numbers used by a message in a
+// previously-generated .proto file, so that numbering remains stable across
+// runs.
+type protoMessageNumbering struct {
+	fields        map[string]int // field name -> number
+	reserved      []int
+	reservedNames []string
+}
+
+var (
+	protoMessageRegexp  = regexp.MustCompile(`^\s*message\s+([A-Za-z_][A-Za-z0-9_]*)\s*\{`)
+	protoFieldRegexp    = regexp.MustCompile(`^\s*(?:optional\s+|repeated\s+)?[A-Za-z_.][A-Za-z0-9_.]*\s+([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(\d+)\s*[;\[]`)
+	protoReservedRegexp = regexp.MustCompile(`^\s*reserved\s+(.+);`)
+)
+
+// parseProtoNumbering extracts the field numbers and reservations of each
+// message from the contents of a .proto file previously generated by
+// `skeema gen-proto`. Nested messages and other constructs not generated by
+// Skeema are not supported.
+func parseProtoNumbering(contents string) map[string]*protoMessageNumbering {
+	messages := make(map[string]*protoMessageNumbering)
+	var current *protoMessageNumbering
+	for _, line := range strings.Split(contents, "\n") {
+		if pos := strings.Index(line, "//"); pos > -1 {
+			line = line[:pos]
+		}
+		if matches := protoMessageRegexp.FindStringSubmatch(line); matches != nil {
+			current = &protoMessageNumbering{fields: make(map[string]int)}
+			messages[matches[1]] = current
+		} else if current == nil {
+			continue
+		} else if strings.TrimSpace(line) == "}" {
+			current = nil
+		} else if matches := protoFieldRegexp.FindStringSubmatch(line); matches != nil {
+			current.fields[matches[1]], _ = strconv.Atoi(matches[2])
+		} else if matches := protoReservedRegexp.FindStringSubmatch(line); matches != nil {
+			for _, item := range strings.Split(matches[1], ",") {
+				item = strings.TrimSpace(item)
+				if n, err := strconv.Atoi(item); err == nil {
+					current.reserved = append(current.reserved, n)
+				} else if unquoted, err := strconv.Unquote(item); err == nil {
+					current.reservedNames = append(current.reservedNames, unquoted)
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// protoField is a single field of a generated message.
+type protoField struct {
+	name     string
+	number   int
+	typ      string
+	optional bool
+	comment  string
+}
+
+// protoMessage is a generated message, corresponding to a single table.
+type protoMessage struct {
+	name          string
+	tableName     string
+	comment       string
+	fields        []protoField
+	reserved      []int
+	reservedNames []string
+}
+
+// newProtoMessage generates the message for table. If prev is non-nil, it
+// represents the message's numbering from a previous run: fields keep their
+// previous numbers, new fields are numbered after all previously-used numbers,
+// and the numbers and names of removed fields are reserved so that they are
+// never reused.
+func newProtoMessage(table *tengo.Table, typeMap map[string]string, prev *protoMessageNumbering) *protoMessage {
+	msg := &protoMessage{
+		name:      goIdentifier(table.Name),
+		tableName: table.Name,
+		comment:   table.Comment,
+	}
+	if prev == nil {
+		prev = &protoMessageNumbering{fields: make(map[string]int)}
+	}
+	maxNumber := 0
+	used := make(map[int]bool)
+	for _, n := range prev.fields {
+		used[n] = true
+	}
+	for _, n := range prev.reserved {
+		used[n] = true
+	}
+	for n := range used {
+		if n > maxNumber {
+			maxNumber = n
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, col := range table.Columns {
+		field := protoField{
+			name:    protoFieldName(col.Name),
+			typ:     protoColumnType(col, typeMap),
+			comment: col.Comment,
+		}
+		field.optional = col.Nullable && protoScalarTypes[field.typ]
+		if n, ok := prev.fields[field.name]; ok {
+			field.number = n
+		} else {
+			maxNumber++
+			field.number = maxNumber
+		}
+		seen[field.name] = true
+		msg.fields = append(msg.fields, field)
+	}
+
+	msg.reserved = append(msg.reserved, prev.reserved...)
+	for _, name := range prev.reservedNames {
+		// A column with a previously-removed name gets a new number, so its name no
+		// longer needs to be reserved
+		if !seen[name] {
+			msg.reservedNames = append(msg.reservedNames, name)
+		}
+	}
+	for name, n := range prev.fields {
+		if !seen[name] {
+			msg.reserved = append(msg.reserved, n)
+			msg.reservedNames = append(msg.reservedNames, name)
+		}
+	}
+	sort.Ints(msg.reserved)
+	sort.Strings(msg.reservedNames)
+	return msg
+}
+
+// writeProtoMessages returns the contents of a proto3 file declaring a message
+// for each table in schema, in package pkg. The schema's tables should already
+// be filtered to exclude any ignored objects. prev contains the numbering of
+// any previously-generated version of the file, as returned by
+// parseProtoNumbering, or nil if there is no previous version. Output is
+// deterministic, so that generated files may be tracked in version control.
+func writeProtoMessages(schema *tengo.Schema, pkg string, typeMap map[string]string, prev map[string]*protoMessageNumbering) string {
+	tables := make([]*tengo.Table, len(schema.Tables))
+	copy(tables, schema.Tables)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	imports := make(map[string]bool)
+	var body strings.Builder
+	for _, table := range tables {
+		msg := newProtoMessage(table, typeMap, prev[goIdentifier(table.Name)])
+		body.WriteString("\n")
+		if msg.comment != "" {
+			fmt.Fprintf(&body, "// %s represents a row of table %s: %s\n", msg.name, msg.tableName, goCommentText(msg.comment))
+		} else {
+			fmt.Fprintf(&body, "// %s represents a row of table %s.\n", msg.name, msg.tableName)
+		}
+		fmt.Fprintf(&body, "message %s {\n", msg.name)
+		if len(msg.reserved) > 0 {
+			numbers := make([]string, len(msg.reserved))
+			for n, number := range msg.reserved {
+				numbers[n] = strconv.Itoa(number)
+			}
+			fmt.Fprintf(&body, "  reserved %s;\n", strings.Join(numbers, ", "))
+		}
+		if len(msg.reservedNames) > 0 {
+			names := make([]string, len(msg.reservedNames))
+			for n, name := range msg.reservedNames {
+				names[n] = strconv.Quote(name)
+			}
+			fmt.Fprintf(&body, "  reserved %s;\n", strings.Join(names, ", "))
+		}
+		for _, field := range msg.fields {
+			if importPath, ok := protoWellKnownImports[strings.TrimPrefix(field.typ, ".")]; ok {
+				imports[importPath] = true
+			}
+			if field.comment != "" {
+				fmt.Fprintf(&body, "  // %s\n", goCommentText(field.comment))
+			}
+			var optional string
+			if field.optional {
+				optional = "optional "
+			}
+			fmt.Fprintf(&body, "  %s%s %s = %d;\n", optional, field.typ, field.name, field.number)
+		}
+		body.WriteString("}\n")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by skeema gen-proto from schema %s. DO NOT EDIT.\n\n", schema.Name)
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n", pkg)
+	if len(imports) > 0 {
+		importPaths := make([]string, 0, len(imports))
+		for importPath := range imports {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		b.WriteString("\n")
+		for _, importPath := range importPaths {
+			fmt.Fprintf(&b, "import %q;\n", importPath)
+		}
+	}
+	b.WriteString(body.String())
+	return b.String()
+}
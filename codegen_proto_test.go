@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestParseProtoTypeMap(t *testing.T) {
+	typeMap, err := parseProtoTypeMap([]string{"decimal:double", "DATETIME:int64", "tinyint(1):int32"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := map[string]string{"decimal": "double", "datetime": "int64", "tinyint(1)": "int32"}
+	if !reflect.DeepEqual(typeMap, expected) {
+		t.Errorf("Expected %v, instead found %v", expected, typeMap)
+	}
+	for _, bad := range []string{"decimal", "decimal:", ":double", "decimal:not valid", "a:b:c"} {
+		if _, err := parseProtoTypeMap([]string{bad}); err == nil {
+			t.Errorf("Expected error from %q, but err was nil", bad)
+		}
+	}
+}
+
+func TestProtoColumnType(t *testing.T) {
+	typeMap := map[string]string{"decimal": "double"}
+	cases := map[string]string{
+		"int(10) unsigned":    "uint32",
+		"bigint(20)":          "int64",
+		"bigint(20) unsigned": "uint64",
+		"tinyint(1)":          "bool",
+		"tinyint(3) unsigned": "uint32",
+		"varchar(40)":         "string",
+		"decimal(10,2)":       "double",
+		"timestamp":           "google.protobuf.Timestamp",
+		"varbinary(16)":       "bytes",
+		"json":                "string",
+	}
+	for colType, expected := range cases {
+		col := &tengo.Column{Name: "c", TypeInDB: colType}
+		if actual := protoColumnType(col, typeMap); actual != expected {
+			t.Errorf("Expected %s to map to %s, instead found %s", colType, expected, actual)
+		}
+	}
+	col := &tengo.Column{Name: "c", TypeInDB: "tinyint(1)"}
+	if actual := protoColumnType(col, map[string]string{"tinyint": "int32"}); actual != "int32" {
+		t.Errorf("Expected base type override to apply to tinyint(1), instead found %s", actual)
+	}
+}
+
+func TestWriteProtoMessages(t *testing.T) {
+	schema := docsTestSchema()
+	out := writeProtoMessages(schema, "product", nil, nil)
+	expected := []string{
+		"syntax = \"proto3\";\n\npackage product;\n\nimport \"google/protobuf/timestamp.proto\";\n",
+		"// Posts represents a row of table posts.\nmessage Posts {\n  uint64 id = 1;\n  uint32 user_id = 2;\n  google.protobuf.Timestamp updated_at = 3;\n}\n",
+		"message Users {\n  uint32 id = 1;\n  // Display name | shown publicly\n  optional string name = 2;\n}\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+
+	// Simulate dropping posts.user_id and adding two new columns, one of which
+	// is at the start of the table. Existing numbers must be retained, the new
+	// columns numbered after all previous ones, and the dropped column reserved.
+	prev := parseProtoNumbering(out)
+	posts := schema.Tables[1]
+	posts.Columns = []*tengo.Column{
+		{Name: "tenant_id", TypeInDB: "int(11)"},
+		posts.Columns[0],
+		posts.Columns[2],
+		{Name: "body", TypeInDB: "text", Nullable: true},
+	}
+	out = writeProtoMessages(schema, "product", nil, prev)
+	exp := "message Posts {\n  reserved 2;\n  reserved \"user_id\";\n  int32 tenant_id = 4;\n  uint64 id = 1;\n  google.protobuf.Timestamp updated_at = 3;\n  optional string body = 5;\n}\n"
+	if !strings.Contains(out, exp) {
+		t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+	}
+
+	// Re-adding user_id must not reuse its old number, and its name should no
+	// longer be reserved
+	prev = parseProtoNumbering(out)
+	posts.Columns = append(posts.Columns, &tengo.Column{Name: "user_id", TypeInDB: "int(10) unsigned"})
+	out = writeProtoMessages(schema, "product", nil, prev)
+	exp = "message Posts {\n  reserved 2;\n  int32 tenant_id = 4;\n  uint64 id = 1;\n  google.protobuf.Timestamp updated_at = 3;\n  optional string body = 5;\n  uint32 user_id = 6;\n}\n"
+	if !strings.Contains(out, exp) {
+		t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+	}
+}
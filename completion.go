@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/skeema/mybase"
+)
+
+// completionCommand describes one command, or nested command suite, for
+// purposes of generating shell completion scripts.
+type completionCommand struct {
+	Path        []string // names from the top-level suite, e.g. ["config", "check"]; empty for the top-level suite itself
+	Summary     string
+	Options     []*mybase.Option // sorted by name, excluding hidden options
+	SubCommands []string         // sorted; only non-empty for command suites
+	Environment bool             // true if the command accepts an environment name arg
+}
+
+// Name returns the command's path as it would be typed on the command-line.
+func (cc completionCommand) Name() string {
+	return strings.Join(cc.Path, " ")
+}
+
+// children returns the entries of commands which are direct subcommands of cc.
+func (cc completionCommand) children(commands []completionCommand) (result []completionCommand) {
+	for _, sc := range commands {
+		if len(sc.Path) == len(cc.Path)+1 && strings.Join(sc.Path[:len(cc.Path)], " ") == cc.Name() {
+			result = append(result, sc)
+		}
+	}
+	return result
+}
+
+// completionCommands returns completion information for suite and all of its
+// subcommands, recursively. The suite itself is always first.
+func completionCommands(suite *mybase.Command) []completionCommand {
+	return appendCompletionCommands(nil, suite, nil)
+}
+
+func appendCompletionCommands(commands []completionCommand, cmd *mybase.Command, path []string) []completionCommand {
+	cc := completionCommand{
+		Path:        path,
+		Summary:     cmd.Summary,
+		Environment: cmd.HasArg("environment") || cmd.HasArg("source-environment"),
+	}
+	for _, opt := range cmd.Options() {
+		if !opt.HiddenOnCLI {
+			cc.Options = append(cc.Options, opt)
+		}
+	}
+	sort.Slice(cc.Options, func(i, j int) bool {
+		return cc.Options[i].Name < cc.Options[j].Name
+	})
+	for name := range cmd.SubCommands {
+		cc.SubCommands = append(cc.SubCommands, name)
+	}
+	sort.Strings(cc.SubCommands)
+	commands = append(commands, cc)
+	for _, name := range cc.SubCommands {
+		subPath := append(append([]string{}, path...), name)
+		commands = appendCompletionCommands(commands, cmd.SubCommands[name], subPath)
+	}
+	return commands
+}
+
+// completionScript returns a completion script for the supplied shell, which
+// must be one of "bash", "zsh", or "fish".
+func completionScript(shell string, suite *mybase.Command) (string, error) {
+	commands := completionCommands(suite)
+	switch strings.ToLower(shell) {
+	case "bash":
+		return bashCompletionScript(suite.Name, commands), nil
+	case "zsh":
+		return zshCompletionScript(suite.Name, commands), nil
+	case "fish":
+		return fishCompletionScript(suite.Name, commands), nil
+	case "":
+		return "", fmt.Errorf("A shell name must be supplied; must be one of bash, zsh, fish")
+	}
+	return "", fmt.Errorf("Shell %q is not supported; must be one of bash, zsh, fish", shell)
+}
+
+// valueOptionPattern returns a case pattern matching the long and short forms
+// of every option which requires a value, so that the following word can be
+// skipped when determining which command is being completed.
+func valueOptionPattern(commands []completionCommand) string {
+	seen := make(map[string]bool)
+	var forms []string
+	for _, cc := range commands {
+		for _, opt := range cc.Options {
+			if !opt.RequireValue || seen[opt.Name] {
+				continue
+			}
+			seen[opt.Name] = true
+			forms = append(forms, "--"+opt.Name)
+			if opt.Shorthand != 0 {
+				forms = append(forms, "-"+string(opt.Shorthand))
+			}
+		}
+	}
+	sort.Strings(forms)
+	return strings.Join(forms, "|")
+}
+
+// suitePattern returns a case pattern matching the names of command suites
+// nested below the top-level, for which another command name may follow.
+// An empty string matches the top-level suite itself.
+func suitePattern(commands []completionCommand) string {
+	names := []string{`""`}
+	for _, cc := range commands {
+		if len(cc.Path) > 0 && len(cc.SubCommands) > 0 {
+			names = append(names, `"`+cc.Name()+`"`)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+func optionNames(cc completionCommand) string {
+	names := make([]string, len(cc.Options))
+	for n, opt := range cc.Options {
+		names[n] = "--" + opt.Name
+	}
+	return strings.Join(names, " ")
+}
+
+func bashCompletionScript(prog string, commands []completionCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# bash completion for %s
+# Generated by `+"`%s completion bash`"+`; to use, add this to ~/.bashrc:
+#   source <(%s completion bash)
+
+_%s() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" cmd="" word i
+	for ((i=1; i<COMP_CWORD; i++)); do
+		word="${COMP_WORDS[i]}"
+		case "$word" in
+		%s)
+			if [[ "${COMP_WORDS[i+1]}" == "=" ]]; then ((i+=2)); else ((i++)); fi
+			;;
+		=)
+			((i++))
+			;;
+		-*)
+			;;
+		*)
+			case "$cmd" in
+			%s) cmd="${cmd:+$cmd }$word" ;;
+			esac
+			;;
+		esac
+	done
+
+	local opts="" subs="" envs=""
+	case "$cmd" in
+`, prog, prog, prog, prog, valueOptionPattern(commands), suitePattern(commands))
+	for _, cc := range commands {
+		fmt.Fprintf(&b, "\t\"%s\")\n", cc.Name())
+		fmt.Fprintf(&b, "\t\topts=\"%s\"\n", optionNames(cc))
+		if len(cc.SubCommands) > 0 {
+			fmt.Fprintf(&b, "\t\tsubs=\"%s\"\n", strings.Join(cc.SubCommands, " "))
+		}
+		if cc.Environment {
+			b.WriteString("\t\tenvs=1\n")
+		}
+		b.WriteString("\t\t;;\n")
+	}
+	fmt.Fprintf(&b, `	esac
+
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+	elif [[ -n "$subs" ]]; then
+		COMPREPLY=($(compgen -W "$subs" -- "$cur"))
+	elif [[ -n "$envs" ]]; then
+		COMPREPLY=($(compgen -W "$(%s completion --list-environments 2>/dev/null)" -- "$cur"))
+	fi
+}
+
+complete -o default -F _%s %s
+`, prog, prog, prog)
+	return b.String()
+}
+
+func zshCompletionScript(prog string, commands []completionCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `#compdef %s
+# zsh completion for %s
+# Generated by `+"`%s completion zsh`"+`; to use, add this to ~/.zshrc after compinit:
+#   source <(%s completion zsh)
+
+_%s() {
+	local cmd="" word i
+	for ((i = 2; i < CURRENT; i++)); do
+		word="${words[i]}"
+		case "$word" in
+		(%s)
+			((i++))
+			;;
+		(-*)
+			;;
+		(*)
+			case "$cmd" in
+			(%s) cmd="${cmd:+$cmd }$word" ;;
+			esac
+			;;
+		esac
+	done
+
+	local -a opts subs envnames
+	local envs=""
+	case "$cmd" in
+`, prog, prog, prog, prog, prog, valueOptionPattern(commands), suitePattern(commands))
+	for _, cc := range commands {
+		fmt.Fprintf(&b, "\t(\"%s\")\n", cc.Name())
+		fmt.Fprintf(&b, "\t\topts=(%s)\n", optionNames(cc))
+		if len(cc.SubCommands) > 0 {
+			b.WriteString("\t\tsubs=(\n")
+			for _, sc := range cc.children(commands) {
+				summary := strings.Replace(sc.Summary, ":", `\:`, -1)
+				fmt.Fprintf(&b, "\t\t\t%s\n", zshQuote(sc.Path[len(sc.Path)-1]+":"+summary))
+			}
+			b.WriteString("\t\t)\n")
+		}
+		if cc.Environment {
+			b.WriteString("\t\tenvs=1\n")
+		}
+		b.WriteString("\t\t;;\n")
+	}
+	fmt.Fprintf(&b, `	esac
+
+	if [[ "$PREFIX" == -* ]]; then
+		compadd -a opts
+	elif (( ${#subs} )); then
+		_describe -t commands '%s command' subs
+	elif [[ -n "$envs" ]]; then
+		envnames=(${(f)"$(%s completion --list-environments 2>/dev/null)"})
+		compadd -a envnames
+	else
+		_files
+	fi
+}
+
+compdef _%s %s
+`, prog, prog, prog, prog)
+	return b.String()
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func fishCompletionScript(prog string, commands []completionCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# fish completion for %s
+# Generated by `+"`%s completion fish`"+`; to use, run:
+#   %s completion fish > ~/.config/fish/completions/%s.fish
+
+function __%s_environments
+	%s completion --list-environments 2>/dev/null
+end
+
+complete -c %s -f
+`, prog, prog, prog, prog, prog, prog, prog)
+
+	inherited := make(map[*mybase.Option]bool, len(commands[0].Options))
+	for _, opt := range commands[0].Options {
+		inherited[opt] = true
+	}
+	for _, cc := range commands {
+		// Conditions for a subcommand of cc to be offered, and for cc's own
+		// options and args to be offered
+		var subCond, cond string
+		if len(cc.Path) == 0 {
+			subCond = "__fish_use_subcommand"
+		} else {
+			seen := make([]string, len(cc.Path))
+			for n, name := range cc.Path {
+				seen[n] = "__fish_seen_subcommand_from " + name
+			}
+			cond = strings.Join(seen, "; and ")
+			if len(cc.SubCommands) > 0 {
+				subCond = cond + "; and not __fish_seen_subcommand_from " + strings.Join(cc.SubCommands, " ")
+			}
+		}
+
+		b.WriteString("\n")
+		for _, sc := range cc.children(commands) {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a %s -d %s\n", prog, fishQuote(subCond), sc.Path[len(sc.Path)-1], fishQuote(sc.Summary))
+		}
+		for _, opt := range cc.Options {
+			// Options of the top-level suite are inherited by all commands, so
+			// they're only listed once, without any condition
+			if len(cc.Path) > 0 && inherited[opt] {
+				continue
+			}
+			b.WriteString("complete -c " + prog)
+			if cond != "" {
+				b.WriteString(" -n " + fishQuote(cond))
+			}
+			if opt.Shorthand != 0 {
+				b.WriteString(" -s " + fishQuote(string(opt.Shorthand)))
+			}
+			b.WriteString(" -l " + opt.Name)
+			if opt.RequireValue {
+				b.WriteString(" -rF")
+			}
+			b.WriteString(" -d " + fishQuote(opt.Description) + "\n")
+		}
+		if cc.Environment {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a '(__%s_environments)'\n", prog, fishQuote(cond), prog)
+		}
+	}
+	return b.String()
+}
+
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+// completionEnvironments returns the sorted names of all environments defined
+// in .skeema files at or below dirPath. Hidden subdirectories are not examined,
+// and files which cannot be parsed are skipped.
+func completionEnvironments(dirPath string, cfg *mybase.Config) []string {
+	cc := &configChecker{
+		cfg:          cfg,
+		environments: make(map[string]bool),
+	}
+	filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != dirPath && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == ".skeema" {
+			f := mybase.NewFile(path)
+			f.IgnoreUnknownOptions = true
+			if f.Read() == nil && f.Parse(cfg) == nil {
+				cc.addEnvironments(f)
+			}
+		}
+		return nil
+	})
+	environments := make([]string, 0, len(cc.environments))
+	for environment := range cc.environments {
+		environments = append(environments, environment)
+	}
+	sort.Strings(environments)
+	return environments
+}
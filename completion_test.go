@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func TestCompletionScript(t *testing.T) {
+	suite := mybase.NewCommandSuite("prog", "1.0", "")
+	suite.AddOption(mybase.StringOption("host", 'h', "", "Database hostname"))
+	cmd := mybase.NewCommand("push", "Alter tables", "", nil)
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Don't run DDL"))
+	cmd.AddOption(mybase.BoolOption("secret", 0, false, "Hidden option").Hidden())
+	cmd.AddArg("environment", "production", false)
+	suite.AddSubCommand(cmd)
+	nested := mybase.NewCommandSuite("config", "Inspect configuration", "")
+	nested.AddSubCommand(mybase.NewCommand("check", "Validate option files: it's fast", "", nil))
+	suite.AddSubCommand(nested)
+
+	commands := completionCommands(suite)
+	var names []string
+	for _, cc := range commands {
+		names = append(names, cc.Name())
+	}
+	expectedNames := []string{"", "config", "config check", "config help", "help", "push", "version"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Expected commands %v, instead found %v", expectedNames, names)
+	}
+	if push := commands[5]; !push.Environment || optionNames(push) != "--dry-run --help --host --version" {
+		t.Errorf("Unexpected completion info for push: %+v", push)
+	}
+
+	expected := map[string][]string{
+		"bash": {
+			"--host|-h)",
+			`""|"config") cmd=`,
+			"\t\"config check\")\n",
+			`opts="--dry-run --help --host --version"`,
+			`subs="config help push version"`,
+			"complete -o default -F _prog prog",
+		},
+		"zsh": {
+			"#compdef prog",
+			"(--host|-h)",
+			`'check:Validate option files\: it'\''s fast'`,
+			"compdef _prog prog",
+		},
+		"fish": {
+			"complete -c prog -n '__fish_use_subcommand' -a push -d 'Alter tables'",
+			"complete -c prog -n '__fish_seen_subcommand_from config; and not __fish_seen_subcommand_from check help' -a check -d 'Validate option files: it\\'s fast'",
+			"complete -c prog -s 'h' -l host -rF -d 'Database hostname'",
+			"complete -c prog -n '__fish_seen_subcommand_from push' -l dry-run -d 'Don\\'t run DDL'",
+			"complete -c prog -n '__fish_seen_subcommand_from push' -a '(__prog_environments)'",
+		},
+	}
+	for shell, substrings := range expected {
+		script, err := completionScript(shell, suite)
+		if err != nil {
+			t.Fatalf("Unexpected error from completionScript(%q): %s", shell, err)
+		}
+		for _, substring := range substrings {
+			if !strings.Contains(script, substring) {
+				t.Errorf("Expected %s script to contain %q, but it did not", shell, substring)
+			}
+		}
+		if strings.Contains(script, "secret") {
+			t.Errorf("Expected %s script to omit hidden options, but it did not", shell)
+		}
+	}
+	if _, err := completionScript("powershell", suite); err == nil {
+		t.Error("Expected error for unsupported shell, but err was nil")
+	}
+	if _, err := completionScript("", suite); err == nil {
+		t.Error("Expected error for missing shell, but err was nil")
+	}
+}
+
+func TestCompletionEnvironments(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "skeema-completion")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+	fs.WriteTestFile(t, filepath.Join(dirPath, ".skeema"), "host=db1\n[staging]\nhost=db2\n[production]\nport=3307\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "mydb", ".skeema"), "schema=mydb\n[development]\nunknown-option=1\nschema=dev\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, ".hidden", ".skeema"), "[ignored]\nhost=db3\n")
+
+	cmd := mybase.NewCommand("completiontest", "", "", nil)
+	cmd.AddOption(mybase.StringOption("host", 'h', "", ""))
+	cmd.AddOption(mybase.StringOption("port", 'P', "3306", ""))
+	cmd.AddOption(mybase.StringOption("schema", 0, "", ""))
+	cfg := mybase.ParseFakeCLI(t, cmd, "completiontest")
+
+	expected := []string{"development", "production", "staging"}
+	if actual := completionEnvironments(dirPath, cfg); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected environments %v, instead found %v", expected, actual)
+	}
+}
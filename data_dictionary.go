@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+
+	"github.com/skeema/tengo"
+)
+
+// dataDictionaryHeaders are the column headings of a flat data dictionary.
+var dataDictionaryHeaders = []string{"Schema", "Table", "Column", "Type", "Nullable", "Default", "Comment"}
+
+// dataDictionaryRows returns one row per column of each table in schemas, in
+// the order of dataDictionaryHeaders. Schemas are kept in the supplied order,
+// tables are sorted by name, and columns are in their order within each table,
+// so that output is deterministic and suitable for diffing between exports.
+func dataDictionaryRows(schemas []*tengo.Schema) [][]string {
+	var rows [][]string
+	for _, schema := range schemas {
+		tables := make([]*tengo.Table, len(schema.Tables))
+		copy(tables, schema.Tables)
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+		for _, table := range tables {
+			for _, col := range table.Columns {
+				nullable := "NO"
+				if col.Nullable {
+					nullable = "YES"
+				}
+				rows = append(rows, []string{schema.Name, table.Name, col.Name, col.TypeInDB, nullable, columnDefaultText(col), col.Comment})
+			}
+		}
+	}
+	return rows
+}
+
+// writeDataDictionaryCSV writes the data dictionary for schemas to w in CSV
+// format, with a header row.
+func writeDataDictionaryCSV(w io.Writer, schemas []*tengo.Schema) error {
+	cw := csv.NewWriter(w)
+	cw.Write(dataDictionaryHeaders)
+	cw.WriteAll(dataDictionaryRows(schemas)) // also flushes
+	return cw.Error()
+}
+
+// writeDataDictionaryMarkdown writes the data dictionary for schemas to w as a
+// single Markdown table.
+func writeDataDictionaryMarkdown(w io.Writer, schemas []*tengo.Schema) error {
+	var f markdownDocsFormatter
+	rows := dataDictionaryRows(schemas)
+	for _, row := range rows {
+		for n := 0; n < 4; n++ { // schema, table, column, type
+			row[n] = f.Code(row[n])
+		}
+		if row[5] != "" {
+			row[5] = f.Code(row[5])
+		}
+		row[6] = f.Text(row[6])
+	}
+	_, err := io.WriteString(w, f.Table(dataDictionaryHeaders, rows))
+	return err
+}
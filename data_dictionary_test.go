@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestWriteDataDictionaryCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDataDictionaryCSV(&buf, []*tengo.Schema{docsTestSchema()}); err != nil {
+		t.Fatalf("Unexpected error from writeDataDictionaryCSV: %s", err)
+	}
+	expected := `Schema,Table,Column,Type,Nullable,Default,Comment
+product,posts,id,bigint(20) unsigned,NO,,
+product,posts,user_id,int(10) unsigned,NO,'0',
+product,posts,updated_at,timestamp,NO,CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+product,users,id,int(10) unsigned,NO,AUTO_INCREMENT,
+product,users,name,varchar(40),YES,NULL,Display name | shown publicly
+`
+	if actual := buf.String(); actual != expected {
+		t.Errorf("Unexpected CSV output. Expected:\n%s\nFound:\n%s", expected, actual)
+	}
+}
+
+func TestWriteDataDictionaryMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDataDictionaryMarkdown(&buf, []*tengo.Schema{docsTestSchema()}); err != nil {
+		t.Fatalf("Unexpected error from writeDataDictionaryMarkdown: %s", err)
+	}
+	out := buf.String()
+	expected := []string{
+		"Schema | Table | Column | Type | Nullable | Default | Comment\n--- | --- | --- | --- | --- | --- | --- |\n",
+		"`product` | `posts` | `id` | `bigint(20) unsigned` | NO |  | \n",
+		"`product` | `users` | `name` | `varchar(40)` | YES | `NULL` | Display name \\| shown publicly\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+	if strings.Count(out, "\n") != 8 {
+		t.Errorf("Expected 2 header lines, 5 rows, and a trailing blank line; instead found:\n%s", out)
+	}
+}
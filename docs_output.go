@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// docsFormatter renders the building blocks of a data dictionary document in a
+// specific markup language. Methods returning strings produce inline content
+// which may be passed to the other methods; text arguments to Heading and Code
+// are escaped by the formatter, whereas paragraph, cell, and list item content
+// must already be formatted.
+type docsFormatter interface {
+	Begin(title string) string
+	End() string
+	Heading(level int, text, anchor string) string
+	Paragraph(content string) string
+	Table(headers []string, rows [][]string) string
+	List(items []string) string
+	Text(s string) string
+	Code(s string) string
+	Link(content, anchor string) string
+}
+
+// markdownDocsFormatter renders GitHub-flavored Markdown.
+type markdownDocsFormatter struct{}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "\r\n", " ", "\n", " ",
+)
+
+func (markdownDocsFormatter) Begin(title string) string { return "" }
+func (markdownDocsFormatter) End() string               { return "" }
+
+func (mf markdownDocsFormatter) Heading(level int, text, anchor string) string {
+	var anchorTag string
+	if anchor != "" {
+		anchorTag = fmt.Sprintf("<a id=\"%s\"></a>\n", anchor)
+	}
+	return fmt.Sprintf("%s%s %s\n\n", anchorTag, strings.Repeat("#", level), mf.Text(text))
+}
+
+func (markdownDocsFormatter) Paragraph(content string) string {
+	return content + "\n\n"
+}
+
+func (markdownDocsFormatter) Table(headers []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString(strings.Join(headers, " | ") + "\n")
+	b.WriteString(strings.TrimSuffix(strings.Repeat("--- | ", len(headers)), " ") + "\n")
+	for _, row := range rows {
+		b.WriteString(strings.Join(row, " | ") + "\n")
+	}
+	return b.String() + "\n"
+}
+
+func (markdownDocsFormatter) List(items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString("* " + item + "\n")
+	}
+	return b.String() + "\n"
+}
+
+func (markdownDocsFormatter) Text(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Code wraps s in a code span. Pipes are still escaped, since GitHub-flavored
+// Markdown otherwise treats them as table cell delimiters even in code spans.
+func (markdownDocsFormatter) Code(s string) string {
+	s = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(s)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+func (markdownDocsFormatter) Link(content, anchor string) string {
+	return fmt.Sprintf("[%s](#%s)", content, anchor)
+}
+
+// htmlDocsFormatter renders a standalone HTML document.
+type htmlDocsFormatter struct{}
+
+func (hf htmlDocsFormatter) Begin(title string) string {
+	return fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", hf.Text(title))
+}
+
+func (htmlDocsFormatter) End() string {
+	return "</body>\n</html>\n"
+}
+
+func (hf htmlDocsFormatter) Heading(level int, text, anchor string) string {
+	var idAttr string
+	if anchor != "" {
+		idAttr = fmt.Sprintf(" id=\"%s\"", anchor)
+	}
+	return fmt.Sprintf("<h%d%s>%s</h%d>\n", level, idAttr, hf.Text(text), level)
+}
+
+func (htmlDocsFormatter) Paragraph(content string) string {
+	return "<p>" + content + "</p>\n"
+}
+
+func (htmlDocsFormatter) Table(headers []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString("<table>\n<tr>")
+	for _, header := range headers {
+		b.WriteString("<th>" + header + "</th>")
+	}
+	b.WriteString("</tr>\n")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			b.WriteString("<td>" + cell + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	return b.String() + "</table>\n"
+}
+
+func (htmlDocsFormatter) List(items []string) string {
+	var b strings.Builder
+	b.WriteString("<ul>\n")
+	for _, item := range items {
+		b.WriteString("<li>" + item + "</li>\n")
+	}
+	return b.String() + "</ul>\n"
+}
+
+func (htmlDocsFormatter) Text(s string) string {
+	return html.EscapeString(s)
+}
+
+func (hf htmlDocsFormatter) Code(s string) string {
+	return "<code>" + hf.Text(s) + "</code>"
+}
+
+func (htmlDocsFormatter) Link(content, anchor string) string {
+	return fmt.Sprintf("<a href=\"#%s\">%s</a>", anchor, content)
+}
+
+// docsAnchor returns the anchor used for cross-references to an object.
+func docsAnchor(objType tengo.ObjectType, name string) string {
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	return fmt.Sprintf("%s-%s", objType, name)
+}
+
+// writeSchemaDocs writes a data dictionary for schema to w, describing each of
+// its tables and stored programs. The schema's tables and routines should
+// already be filtered to exclude any ignored objects. Output is deterministic,
+// so that generated files may be tracked in version control.
+func writeSchemaDocs(w io.Writer, schema *tengo.Schema, f docsFormatter) error {
+	tables := make([]*tengo.Table, len(schema.Tables))
+	copy(tables, schema.Tables)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	routines := make([]*tengo.Routine, len(schema.Routines))
+	copy(routines, schema.Routines)
+	sort.Slice(routines, func(i, j int) bool {
+		if routines[i].Type != routines[j].Type {
+			return routines[i].Type > routines[j].Type // procedures before functions
+		}
+		return routines[i].Name < routines[j].Name
+	})
+
+	// Build reverse index of foreign keys, for "referenced by" cross-references
+	referencedBy := make(map[string][]string)
+	for _, table := range tables {
+		for _, fk := range table.ForeignKeys {
+			if fk.ReferencedSchemaName == "" || fk.ReferencedSchemaName == schema.Name {
+				ref := fmt.Sprintf("%s (%s)", f.Link(f.Code(table.Name), docsAnchor(tengo.ObjectTypeTable, table.Name)), f.Code(fk.Name))
+				referencedBy[fk.ReferencedTableName] = append(referencedBy[fk.ReferencedTableName], ref)
+			}
+		}
+	}
+	tableNames := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		tableNames[table.Name] = true
+	}
+
+	title := fmt.Sprintf("Schema %s", schema.Name)
+	var b strings.Builder
+	b.WriteString(f.Begin(title))
+	b.WriteString(f.Heading(1, title, ""))
+	if schema.CharSet != "" {
+		b.WriteString(f.Paragraph(fmt.Sprintf("Default character set %s, collation %s", f.Code(schema.CharSet), f.Code(schema.Collation))))
+	}
+	if len(tables)+len(routines) == 0 {
+		b.WriteString(f.Paragraph(f.Text("This schema does not contain any tables or stored programs.")))
+	}
+	var toc []string
+	for _, table := range tables {
+		toc = append(toc, f.Link(f.Code(table.Name), docsAnchor(tengo.ObjectTypeTable, table.Name))+" (table)")
+	}
+	for _, routine := range routines {
+		toc = append(toc, f.Link(f.Code(routine.Name), docsAnchor(routine.Type, routine.Name))+" ("+string(routine.Type)+")")
+	}
+	if len(toc) > 0 {
+		b.WriteString(f.List(toc))
+	}
+
+	if len(tables) > 0 {
+		b.WriteString(f.Heading(2, "Tables", ""))
+	}
+	for _, table := range tables {
+		writeTableDocs(&b, table, f, tableNames, referencedBy[table.Name])
+	}
+
+	for _, objType := range []tengo.ObjectType{tengo.ObjectTypeProc, tengo.ObjectTypeFunc} {
+		var wroteHeading bool
+		for _, routine := range routines {
+			if routine.Type != objType {
+				continue
+			}
+			if !wroteHeading {
+				b.WriteString(f.Heading(2, strings.Title(string(objType))+"s", ""))
+				wroteHeading = true
+			}
+			writeRoutineDocs(&b, routine, f)
+		}
+	}
+
+	b.WriteString(f.End())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// columnDefaultText describes col's default value for display purposes,
+// including any AUTO_INCREMENT or ON UPDATE behavior. It returns an empty
+// string if the column has no default.
+func columnDefaultText(col *tengo.Column) string {
+	var defaultValue string
+	if col.AutoIncrement {
+		defaultValue = "AUTO_INCREMENT"
+	} else if col.Default.Null {
+		if col.Nullable {
+			defaultValue = "NULL"
+		}
+	} else if col.Default.Quoted {
+		defaultValue = "'" + col.Default.Value + "'"
+	} else {
+		defaultValue = col.Default.Value
+	}
+	if col.OnUpdate != "" {
+		defaultValue = strings.TrimSpace(defaultValue + " ON UPDATE " + col.OnUpdate)
+	}
+	return defaultValue
+}
+
+func writeTableDocs(b *strings.Builder, table *tengo.Table, f docsFormatter, tableNames map[string]bool, referencedBy []string) {
+	b.WriteString(f.Heading(3, table.Name, docsAnchor(tengo.ObjectTypeTable, table.Name)))
+	if table.Comment != "" {
+		b.WriteString(f.Paragraph(f.Text(table.Comment)))
+	}
+	if table.UnsupportedDDL {
+		b.WriteString(f.Paragraph(f.Text("This table uses features not fully supported by Skeema, so some details may be missing.")))
+	}
+
+	rows := make([][]string, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		defaultValue := columnDefaultText(col)
+		if defaultValue != "" {
+			defaultValue = f.Code(defaultValue)
+		}
+		nullable := "NO"
+		if col.Nullable {
+			nullable = "YES"
+		}
+		rows = append(rows, []string{f.Code(col.Name), f.Code(col.TypeInDB), nullable, defaultValue, f.Text(col.Comment)})
+	}
+	b.WriteString(f.Table([]string{"Column", "Type", "Nullable", "Default", "Comment"}, rows))
+
+	indexes := table.SecondaryIndexes
+	if table.PrimaryKey != nil {
+		indexes = append([]*tengo.Index{table.PrimaryKey}, indexes...)
+	}
+	if len(indexes) > 0 {
+		rows = make([][]string, 0, len(indexes))
+		for _, idx := range indexes {
+			cols := make([]string, len(idx.Columns))
+			for n, col := range idx.Columns {
+				if n < len(idx.SubParts) && idx.SubParts[n] > 0 {
+					cols[n] = f.Code(fmt.Sprintf("%s(%d)", col.Name, idx.SubParts[n]))
+				} else {
+					cols[n] = f.Code(col.Name)
+				}
+			}
+			kind := "INDEX"
+			if idx.PrimaryKey {
+				kind = "PRIMARY KEY"
+			} else if idx.Unique {
+				kind = "UNIQUE"
+			}
+			rows = append(rows, []string{f.Code(idx.Name), kind, strings.Join(cols, ", "), f.Text(idx.Comment)})
+		}
+		b.WriteString(f.Paragraph(f.Text("Indexes:")))
+		b.WriteString(f.Table([]string{"Name", "Type", "Columns", "Comment"}, rows))
+	}
+
+	if len(table.ForeignKeys) > 0 {
+		rows = make([][]string, 0, len(table.ForeignKeys))
+		for _, fk := range table.ForeignKeys {
+			cols := make([]string, len(fk.Columns))
+			for n, col := range fk.Columns {
+				cols[n] = f.Code(col.Name)
+			}
+			refCols := make([]string, len(fk.ReferencedColumnNames))
+			for n, colName := range fk.ReferencedColumnNames {
+				refCols[n] = f.Code(colName)
+			}
+			var refTable string
+			if fk.ReferencedSchemaName != "" {
+				refTable = f.Code(fk.ReferencedSchemaName + "." + fk.ReferencedTableName)
+			} else if tableNames[fk.ReferencedTableName] {
+				refTable = f.Link(f.Code(fk.ReferencedTableName), docsAnchor(tengo.ObjectTypeTable, fk.ReferencedTableName))
+			} else {
+				refTable = f.Code(fk.ReferencedTableName)
+			}
+			rows = append(rows, []string{
+				f.Code(fk.Name),
+				strings.Join(cols, ", "),
+				fmt.Sprintf("%s (%s)", refTable, strings.Join(refCols, ", ")),
+				fk.UpdateRule,
+				fk.DeleteRule,
+			})
+		}
+		b.WriteString(f.Paragraph(f.Text("Foreign keys:")))
+		b.WriteString(f.Table([]string{"Name", "Columns", "References", "On update", "On delete"}, rows))
+	}
+
+	if len(referencedBy) > 0 {
+		b.WriteString(f.Paragraph(f.Text("Referenced by foreign keys in:")))
+		b.WriteString(f.List(referencedBy))
+	}
+}
+
+func writeRoutineDocs(b *strings.Builder, routine *tengo.Routine, f docsFormatter) {
+	b.WriteString(f.Heading(3, routine.Name, docsAnchor(routine.Type, routine.Name)))
+	if routine.Comment != "" {
+		b.WriteString(f.Paragraph(f.Text(routine.Comment)))
+	}
+	items := []string{"Parameters: " + f.Code("("+routine.ParamString+")")}
+	if routine.Type == tengo.ObjectTypeFunc {
+		items = append(items, "Returns: "+f.Code(routine.ReturnDataType))
+	}
+	if routine.Deterministic {
+		items = append(items, "Deterministic")
+	}
+	if routine.SQLDataAccess != "" {
+		items = append(items, "SQL data access: "+f.Text(routine.SQLDataAccess))
+	}
+	if routine.SecurityType != "" {
+		items = append(items, "Security: "+f.Text(routine.SecurityType))
+	}
+	b.WriteString(f.List(items))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func docsTestSchema() *tengo.Schema {
+	usersID := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true, Default: tengo.ColumnDefaultNull}
+	usersName := &tengo.Column{Name: "name", TypeInDB: "varchar(40)", Nullable: true, Default: tengo.ColumnDefaultNull, Comment: "Display name | shown publicly"}
+	users := &tengo.Table{
+		Name:       "users",
+		Comment:    "Registered *users*",
+		Columns:    []*tengo.Column{usersID, usersName},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{usersID}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "name", Columns: []*tengo.Column{usersName}, SubParts: []uint16{10}},
+		},
+	}
+	postsID := &tengo.Column{Name: "id", TypeInDB: "bigint(20) unsigned", Default: tengo.ColumnDefaultNull}
+	postsUserID := &tengo.Column{Name: "user_id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultValue("0")}
+	postsUpdated := &tengo.Column{Name: "updated_at", TypeInDB: "timestamp", Default: tengo.ColumnDefaultExpression("CURRENT_TIMESTAMP"), OnUpdate: "CURRENT_TIMESTAMP"}
+	posts := &tengo.Table{
+		Name:       "posts",
+		Columns:    []*tengo.Column{postsID, postsUserID, postsUpdated},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{postsID}, PrimaryKey: true, Unique: true},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "posts_user", Columns: []*tengo.Column{postsUserID}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}, UpdateRule: "RESTRICT", DeleteRule: "CASCADE"},
+		},
+	}
+	return &tengo.Schema{
+		Name:      "product",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_general_ci",
+		Tables:    []*tengo.Table{users, posts},
+		Routines: []*tengo.Routine{
+			{Name: "post_count", Type: tengo.ObjectTypeFunc, ParamString: "uid int", ReturnDataType: "int(11)", Deterministic: true},
+			{Name: "purge_posts", Type: tengo.ObjectTypeProc, ParamString: "", Comment: "Removes <old> posts"},
+		},
+	}
+}
+
+func TestWriteSchemaDocsMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSchemaDocs(&buf, docsTestSchema(), markdownDocsFormatter{}); err != nil {
+		t.Fatalf("Unexpected error from writeSchemaDocs: %s", err)
+	}
+	out := buf.String()
+	expected := []string{
+		"# Schema product\n",
+		"* [`posts`](#table-posts) (table)\n* [`users`](#table-users) (table)\n* [`purge_posts`](#procedure-purge_posts) (procedure)\n* [`post_count`](#function-post_count) (function)\n",
+		"<a id=\"table-users\"></a>\n### users\n\nRegistered \\*users\\*\n",
+		"`id` | `int(10) unsigned` | NO | `AUTO_INCREMENT` | \n",
+		"`name` | `varchar(40)` | YES | `NULL` | Display name \\| shown publicly\n",
+		"`user_id` | `int(10) unsigned` | NO | `'0'` | \n",
+		"`updated_at` | `timestamp` | NO | `CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP` | \n",
+		"`name` | INDEX | `name(10)` | \n",
+		"`posts_user` | `user_id` | [`users`](#table-users) (`id`) | RESTRICT | CASCADE\n",
+		"Referenced by foreign keys in:\n\n* [`posts`](#table-posts) (`posts_user`)\n",
+		"## Procedures\n",
+		"Removes \\<old\\> posts\n",
+		"## Functions\n",
+		"* Returns: `int(11)`\n* Deterministic\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+	if strings.Index(out, "### posts") > strings.Index(out, "### users") {
+		t.Error("Expected tables to be sorted by name")
+	}
+}
+
+func TestWriteSchemaDocsHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSchemaDocs(&buf, docsTestSchema(), htmlDocsFormatter{}); err != nil {
+		t.Fatalf("Unexpected error from writeSchemaDocs: %s", err)
+	}
+	out := buf.String()
+	expected := []string{
+		"<!DOCTYPE html>",
+		"<title>Schema product</title>",
+		"<h3 id=\"table-users\">users</h3>",
+		"<td><code>posts_user</code></td><td><code>user_id</code></td><td><a href=\"#table-users\"><code>users</code></a> (<code>id</code>)</td>",
+		"<p>Removes &lt;old&gt; posts</p>",
+		"</body>\n</html>\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+}
+
+func TestWriteSchemaDocsEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSchemaDocs(&buf, &tengo.Schema{Name: "empty"}, markdownDocsFormatter{}); err != nil {
+		t.Fatalf("Unexpected error from writeSchemaDocs: %s", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "does not contain any tables") || strings.Contains(out, "## Tables") {
+		t.Errorf("Unexpected output for empty schema:\n%s", out)
+	}
+}
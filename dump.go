@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+var (
+	createDatabaseRegexp = regexp.MustCompile("(?is)^CREATE\\s+(?:DATABASE|SCHEMA)\\s+(?:/\\*!\\d+\\s*)?(?:IF\\s+NOT\\s+EXISTS\\s*)?(?:\\*/\\s*)?(`(?:[^`]|``)+`|[0-9a-z$_]+)")
+	dumpCharSetRegexp    = regexp.MustCompile(`(?i)(?:CHARACTER\s+SET|CHARSET)\s*=?\s*([0-9a-z_]+)`)
+	dumpCollationRegexp  = regexp.MustCompile(`(?i)COLLATE\s*=?\s*([0-9a-z_]+)`)
+)
+
+// dumpReader accumulates schemas from the CREATE statements of a logical dump.
+type dumpReader struct {
+	defaultSchema string // schema name for statements without any USE or qualifier
+	schemas       []*tengo.Schema
+	schemasByName map[string]*tengo.Schema
+}
+
+// readDump parses a logical dump, returning a schema for each database that it
+// creates objects in, in the order first encountered. dumpPath may be a single
+// SQL file, such as output by mysqldump, or a directory of per-object files
+// output by mydumper. Statements other than CREATE DATABASE, CREATE TABLE,
+// CREATE PROCEDURE, and CREATE FUNCTION are ignored. System schemas are
+// omitted. defaultSchema, if non-blank, is used as the schema name of objects
+// whose schema cannot otherwise be determined from the dump; if blank, this
+// situation is an error.
+func readDump(dumpPath, defaultSchema string) ([]*tengo.Schema, error) {
+	dr := &dumpReader{
+		defaultSchema: defaultSchema,
+		schemasByName: make(map[string]*tengo.Schema),
+	}
+	fi, err := os.Stat(dumpPath)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		err = dr.readFile(dumpPath, "")
+		return dr.schemas, err
+	}
+
+	// mydumper writes one file per object, with file names indicating the schema
+	fileInfos, err := ioutil.ReadDir(dumpPath)
+	if err != nil {
+		return nil, err
+	}
+	var seenSchemaFile bool
+	for _, fi := range fileInfos {
+		name := fi.Name()
+		var schemaName string
+		switch {
+		case fi.IsDir():
+			continue
+		case strings.HasSuffix(name, "-schema-create.sql"):
+			schemaName = strings.TrimSuffix(name, "-schema-create.sql")
+		case strings.HasSuffix(name, "-schema-post.sql"):
+			schemaName = strings.TrimSuffix(name, "-schema-post.sql")
+		case strings.HasSuffix(name, "-schema.sql"):
+			schemaName = strings.SplitN(name, ".", 2)[0]
+		default:
+			continue // data, metadata, views, and triggers
+		}
+		seenSchemaFile = true
+		if err := dr.readFile(filepath.Join(dumpPath, name), schemaName); err != nil {
+			return nil, err
+		}
+	}
+	if !seenSchemaFile {
+		return nil, fmt.Errorf("Directory %s does not contain any mydumper schema files", dumpPath)
+	}
+	return dr.schemas, nil
+}
+
+// readFile tokenizes the file at filePath and adds its objects to dr.
+// fileSchema, if non-blank, is the schema implied by the file name.
+func (dr *dumpReader) readFile(filePath, fileSchema string) error {
+	sqlFile := fs.SQLFile{
+		Dir:      filepath.Dir(filePath),
+		FileName: filepath.Base(filePath),
+	}
+	tokenizedFile, err := sqlFile.Tokenize()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range tokenizedFile.Statements {
+		if stmt.Type == fs.StatementTypeUnknown {
+			if matches := createDatabaseRegexp.FindStringSubmatch(stmt.Body()); matches != nil {
+				s := dr.schema(unquoteIdentifier(matches[1]))
+				if s == nil {
+					continue
+				}
+				if cs := dumpCharSetRegexp.FindStringSubmatch(stmt.Body()); cs != nil {
+					s.CharSet = strings.ToLower(cs[1])
+				}
+				if coll := dumpCollationRegexp.FindStringSubmatch(stmt.Body()); coll != nil {
+					s.Collation = strings.ToLower(coll[1])
+				}
+			}
+			continue
+		} else if stmt.Type != fs.StatementTypeCreate {
+			continue
+		}
+
+		schemaName := stmt.Schema()
+		if schemaName == "" {
+			schemaName = fileSchema
+		}
+		if schemaName == "" {
+			schemaName = dr.defaultSchema
+		}
+		if schemaName == "" {
+			return fmt.Errorf("%s: Unable to determine which schema %s belongs to, since the dump has no USE command; use the schema option to specify its name", stmt.Location(), stmt.ObjectKey())
+		}
+		s := dr.schema(schemaName)
+		if s == nil {
+			continue
+		}
+		if stmt.ObjectType == tengo.ObjectTypeTable {
+			s.Tables = append(s.Tables, &tengo.Table{
+				Name:            stmt.ObjectName,
+				CreateStatement: stmt.Body(),
+			})
+		} else {
+			s.Routines = append(s.Routines, &tengo.Routine{
+				Name:            stmt.ObjectName,
+				Type:            stmt.ObjectType,
+				CreateStatement: stmt.Body(),
+			})
+		}
+	}
+	return nil
+}
+
+// schema returns the schema with the supplied name, adding it to dr if not
+// seen previously. It returns nil for system schemas.
+func (dr *dumpReader) schema(name string) *tengo.Schema {
+	switch strings.ToLower(name) {
+	case "mysql", "information_schema", "performance_schema", "sys":
+		return nil
+	}
+	s := dr.schemasByName[name]
+	if s == nil {
+		s = &tengo.Schema{Name: name}
+		dr.schemas = append(dr.schemas, s)
+		dr.schemasByName[name] = s
+	}
+	return s
+}
+
+func unquoteIdentifier(name string) string {
+	if len(name) < 2 || name[0] != '`' || name[len(name)-1] != '`' {
+		return name
+	}
+	return strings.Replace(name[1:len(name)-1], "``", "`", -1)
+}
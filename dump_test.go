@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestReadDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skeema-test-dump")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mysqldump := "-- MySQL dump 10.13\n" +
+		"/*!40101 SET NAMES utf8mb4 */;\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `shop` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci */;\n\n" +
+		"USE `shop`;\n" +
+		"DROP TABLE IF EXISTS `orders`;\n" +
+		"/*!40101 SET @saved_cs_client = @@character_set_client */;\n" +
+		"CREATE TABLE `orders` (\n  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  `note` varchar(20) DEFAULT 'a;b',\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4;\n" +
+		"LOCK TABLES `orders` WRITE;\n" +
+		"INSERT INTO `orders` VALUES (1,'x;y');\n" +
+		"UNLOCK TABLES;\n" +
+		"DELIMITER ;;\n" +
+		"CREATE DEFINER=`root`@`localhost` PROCEDURE `cleanup`()\nBEGIN\n  DELETE FROM orders;\nEND ;;\n" +
+		"DELIMITER ;\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `mysql` /*!40100 DEFAULT CHARACTER SET latin1 */;\n" +
+		"USE `mysql`;\n" +
+		"CREATE TABLE `user` (`id` int);\n" +
+		"CREATE DATABASE `empty`;\n"
+	fs.WriteTestFile(t, filepath.Join(dir, "all.sql"), mysqldump)
+
+	schemas, err := readDump(filepath.Join(dir, "all.sql"), "")
+	if err != nil {
+		t.Fatalf("Unexpected error from readDump: %s", err)
+	}
+	if len(schemas) != 2 || schemas[0].Name != "shop" || schemas[1].Name != "empty" {
+		t.Fatalf("Unexpected schemas returned: %+v", schemas)
+	}
+	shop := schemas[0]
+	if shop.CharSet != "utf8mb4" || shop.Collation != "utf8mb4_unicode_ci" {
+		t.Errorf("Unexpected character set %q or collation %q", shop.CharSet, shop.Collation)
+	}
+	defs := shop.ObjectDefinitions()
+	if len(defs) != 2 {
+		t.Fatalf("Expected 2 objects, instead found %d: %v", len(defs), defs)
+	}
+	orders := defs[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "orders"}]
+	if expected := "CREATE TABLE `orders` (\n  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  `note` varchar(20) DEFAULT 'a;b',\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4"; orders != expected {
+		t.Errorf("Unexpected table definition: %q", orders)
+	}
+	if proc := defs[tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "cleanup"}]; proc == "" || !fs.CanParse(proc) {
+		t.Errorf("Unexpected procedure definition: %q", proc)
+	}
+
+	// A dump of a single database lacks USE, so a schema name must be supplied
+	fs.WriteTestFile(t, filepath.Join(dir, "single.sql"), "CREATE TABLE `orders` (`id` int);\n")
+	if _, err := readDump(filepath.Join(dir, "single.sql"), ""); err == nil {
+		t.Error("Expected error from dump without USE and no default schema, but err was nil")
+	}
+	if schemas, err := readDump(filepath.Join(dir, "single.sql"), "shop"); err != nil || len(schemas) != 1 || schemas[0].Name != "shop" || len(schemas[0].Tables) != 1 {
+		t.Errorf("Unexpected result from readDump with default schema: %+v, %v", schemas, err)
+	}
+
+	// mydumper output dir: schema names come from file names
+	mydumper := filepath.Join(dir, "mydumper")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "metadata"), "Started dump at: 2020-01-01 00:00:00\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "shop-schema-create.sql"), "CREATE DATABASE `shop` /*!40100 DEFAULT CHARACTER SET latin1 */;\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "shop.orders-schema.sql"), "/*!40101 SET NAMES binary*/;\nCREATE TABLE `orders` (`id` int);\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "shop.orders.sql"), "INSERT INTO `orders` VALUES (1);\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "shop.totals-schema-view.sql"), "CREATE VIEW `totals` AS SELECT 1;\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "shop-schema-post.sql"), "DELIMITER ;;\nCREATE FUNCTION `one`() RETURNS int\nBEGIN\n  RETURN 1;\nEND ;;\nDELIMITER ;\n")
+	fs.WriteTestFile(t, filepath.Join(mydumper, "other.items-schema.sql"), "CREATE TABLE `items` (`id` int);\n")
+	schemas, err = readDump(mydumper, "")
+	if err != nil {
+		t.Fatalf("Unexpected error from readDump: %s", err)
+	}
+	if len(schemas) != 2 || schemas[0].Name != "other" || schemas[1].Name != "shop" {
+		t.Fatalf("Unexpected schemas returned: %+v", schemas)
+	}
+	if shop := schemas[1]; shop.CharSet != "latin1" || len(shop.Tables) != 1 || len(shop.Routines) != 1 || shop.Routines[0].Type != tengo.ObjectTypeFunc {
+		t.Errorf("Unexpected shop schema: %+v", shop)
+	}
+
+	if _, err := readDump(filepath.Join(dir, "nonexistent.sql"), ""); err == nil {
+		t.Error("Expected error from nonexistent dump, but err was nil")
+	}
+	emptyDir := filepath.Join(dir, "emptydir")
+	fs.WriteTestFile(t, filepath.Join(emptyDir, "readme.txt"), "hello\n")
+	if _, err := readDump(emptyDir, ""); err == nil {
+		t.Error("Expected error from dir without mydumper files, but err was nil")
+	}
+}
@@ -81,6 +81,7 @@ func Exit(err error) {
 	// Gracefully close all connection pools, to avoid aborted connection counter/
 	// logging in some versions of MySQL
 	util.CloseCachedConnectionPools()
+	util.StopVaultRenewals()
 
 	os.Exit(exitCode)
 }
@@ -1,12 +1,20 @@
+// Package fs handles the filesystem representation of schemas: parsing
+// directories of *.sql files and .skeema option files into Dir and
+// LogicalSchema values, and rewriting those files. It is the starting point
+// for programs embedding Skeema's linter or diff engine; see packages linter
+// and applier.
 package fs
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -39,8 +47,14 @@ type LogicalSchema struct {
 	Collation string
 	Creates   map[tengo.ObjectKey]*Statement
 	Alters    []*Statement // Alterations that are run after the Creates
+	Seeds     []*Statement // Seed data statements, from the dir's seeds subdir
 }
 
+// SeedsSubdirName is the name of the subdir of a schema dir containing seed
+// data: *.sql files of INSERT statements, which are loaded into workspaces
+// after the schema's DDL has been run.
+const SeedsSubdirName = "seeds"
+
 // AddStatement adds the supplied statement into the appropriate data structure
 // within the receiver. This is useful when assembling a new logical schema.
 // An error will be returned if a duplicate CREATE object name/type pair is
@@ -83,7 +97,9 @@ func ParseDir(dirPath string, globalConfig *mybase.Config) (*Dir, error) {
 		return nil, err
 	}
 	for _, optionFile := range parentFiles {
-		dir.Config.AddSource(optionFile)
+		if err := util.AddOptionFile(dir.Config, optionFile); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := dir.parseContents(); err != nil {
@@ -132,6 +148,46 @@ func (dir *Dir) HasFile(name string) (bool, error) {
 	return false, err
 }
 
+// UsesTypeSubdirs returns true if dir's *.sql files may be stored in subdirs
+// named for each object type, such as "tables" or "procedures", as controlled
+// by the type-subdirs option. In this case, these subdirs are considered to be
+// part of dir itself, rather than separate directories.
+func (dir *Dir) UsesTypeSubdirs() bool {
+	return dir.Config.GetBool("type-subdirs")
+}
+
+// LinkedPath returns the canonical location of sf, if sf was reached through a
+// symlink somewhere beneath dir: either sf itself is a symlink, or it is
+// located in a symlinked type subdir. Otherwise, an empty string is returned.
+// Files reached through symlinks are typically shared by multiple dirs, for
+// example to store common table definitions for many shards in one place.
+func (dir *Dir) LinkedPath(sf SQLFile) string {
+	realDirPath, err := filepath.EvalSymlinks(dir.Path)
+	if err != nil {
+		return ""
+	}
+	relPath, err := filepath.Rel(dir.Path, sf.Path())
+	if err != nil {
+		return ""
+	}
+	realPath, err := filepath.EvalSymlinks(sf.Path())
+	if err != nil || realPath == filepath.Join(realDirPath, relPath) {
+		return ""
+	}
+	return realPath
+}
+
+// OwnsSubdir returns true if the subdir with the supplied name is considered to
+// be part of dir itself, rather than a separate directory. This is the case
+// for object type subdirs if dir uses the type-subdirs option, as well as for
+// the seeds subdir if dir maps to a schema and the seeds option is enabled.
+func (dir *Dir) OwnsSubdir(name string) bool {
+	if IsTypeSubdirName(name) {
+		return dir.UsesTypeSubdirs()
+	}
+	return name == SeedsSubdirName && dir.HasSchema() && dir.Config.GetBool("seeds")
+}
+
 // Subdirs reads the list of direct, non-hidden subdirectories of dir, parses
 // them (*.sql and .skeema files), and returns them. An error will be returned
 // if there are problems reading dir's the directory list. Otherwise, err is
@@ -146,6 +202,9 @@ func (dir *Dir) Subdirs() ([]*Dir, int, error) {
 	result := make([]*Dir, 0, len(fileInfos))
 	var badSubdirCount int
 	for _, fi := range fileInfos {
+		if dir.OwnsSubdir(fi.Name()) {
+			continue // already handled as part of dir itself
+		}
 		if fi.IsDir() && fi.Name()[0] != '.' {
 			sub := &Dir{
 				Path:   path.Join(dir.Path, fi.Name()),
@@ -177,17 +236,86 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 	}
 
 	// Before looping over hostnames, do a single lookup of user, password,
-	// connect-options, port, socket.
-	var userAndPass string
-	if !dir.Config.Changed("password") {
-		userAndPass = dir.Config.Get("user")
-	} else {
-		userAndPass = fmt.Sprintf("%s:%s", dir.Config.Get("user"), dir.Config.Get("password"))
+	// connect-options, port, socket. If aws-iam-auth or password-command is
+	// used instead of password, vault-path, aws-secret, or cloudsql-iam-auth,
+	// the password is obtained separately for each host below.
+	user := dir.Config.Get("user")
+	userAndPass := user
+	usePasswordCommand := !dir.Config.Changed("password") && dir.Config.Changed("password-command")
+	if dir.Config.Changed("password") {
+		userAndPass = fmt.Sprintf("%s:%s", user, dir.Config.Get("password"))
+	} else if dir.Config.Changed("vault-path") {
+		creds, err := util.VaultCredentialsForPath(dir.Config.Get("vault-addr"), dir.Config.Get("vault-path"))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to obtain credentials from Vault for %s: %s", dir, err)
+		}
+		if creds.Username != "" {
+			user = creds.Username
+		}
+		userAndPass = fmt.Sprintf("%s:%s", user, creds.Password)
+		usePasswordCommand = false
+	} else if dir.Config.Changed("aws-secret") {
+		secretUser, password, err := util.AWSSecretCredentials(dir.Config.Get("aws-secret"), dir.Config.Get("aws-region"))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to obtain credentials from AWS Secrets Manager for %s: %s", dir, err)
+		}
+		if secretUser != "" {
+			user = secretUser
+		}
+		userAndPass = fmt.Sprintf("%s:%s", user, password)
+		usePasswordCommand = false
 	}
+	staticPassword := dir.Config.Changed("password") || dir.Config.Changed("vault-path") || dir.Config.Changed("aws-secret")
+	useIAMAuth := !staticPassword && dir.Config.GetBool("aws-iam-auth")
+	useCloudSQLIAMAuth := !staticPassword && dir.Config.GetBool("cloudsql-iam-auth")
 	params, err := dir.InstanceDefaultParams()
 	if err != nil {
 		return nil, fmt.Errorf("Invalid connection options: %s", err)
 	}
+	tlsOpts, err := util.TLSOptionsForConfig(dir.Config)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid TLS options: %s", err)
+	}
+	if tlsOpts.Mode != "" {
+		if v, _ := url.ParseQuery(params); v.Get("tls") != "" {
+			return nil, errors.New("Invalid TLS options: connect-options tls cannot be combined with ssl-mode, ssl-ca, or ssl-cert")
+		}
+		tlsName, err := tlsOpts.ConfigName()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid TLS options: %s", err)
+		}
+		params += "&tls=" + url.QueryEscape(tlsName)
+	}
+	if useIAMAuth {
+		// RDS IAM auth tokens are sent via the cleartext auth plugin, which
+		// requires TLS
+		usePasswordCommand = false
+		if v, _ := url.ParseQuery(params); v.Get("tls") == "" {
+			params += "&tls=true"
+		}
+	} else if useCloudSQLIAMAuth {
+		// Cloud SQL IAM auth sends an OAuth2 access token as the password, via
+		// the cleartext auth plugin. This is only encrypted by the built-in Cloud
+		// SQL dialer, so other remote hosts must use TLS; see loop below.
+		usePasswordCommand = false
+		token, err := util.GoogleAccessToken()
+		if err != nil {
+			return nil, err
+		}
+		userAndPass = fmt.Sprintf("%s:%s", user, token)
+	}
+	if useIAMAuth || useCloudSQLIAMAuth || dir.Config.GetBool("enable-cleartext-plugin") {
+		if v, _ := url.ParseQuery(params); v.Get("allowCleartextPasswords") == "" {
+			params += "&allowCleartextPasswords=true"
+		}
+	}
+	if dir.Config.Changed("server-public-key") {
+		keyName, err := util.RegisterServerPublicKey(dir.Config.Get("server-public-key"))
+		if err != nil {
+			return nil, err
+		}
+		params += "&serverPubKey=" + url.QueryEscape(keyName)
+	}
 	portValue := dir.Config.GetIntOrDefault("port")
 	portWasSupplied := dir.Config.Supplied("port")
 	portIsntDefault := dir.Config.Changed("port")
@@ -195,8 +323,8 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 	socketWasSupplied := dir.Config.Supplied("socket")
 
 	// Interpret the host value: if host-wrapper is set, use it to interpret the
-	// host list; otherwise assume host is a comma-separated list of literal
-	// hostnames.
+	// host list; otherwise assume host is a comma-separated list of hostnames,
+	// possibly including service discovery references.
 	var hosts []string
 	if dir.Config.Changed("host-wrapper") {
 		variables := map[string]string{
@@ -214,7 +342,15 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 			return nil, err
 		}
 	} else {
-		hosts = dir.Config.GetSlice("host", ',', true)
+		// Hosts may refer to DNS SRV records or Consul services, which are
+		// resolved into one or more addresses
+		for _, host := range dir.Config.GetSlice("host", ',', true) {
+			discovered, err := util.DiscoverHosts(host, dir.Config.Get("consul-addr"))
+			if err != nil {
+				return nil, err
+			}
+			hosts = append(hosts, discovered...)
+		}
 	}
 
 	// For each hostname, construct a DSN and use it to create an Instance
@@ -222,10 +358,15 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 	for _, host := range hosts {
 		var dsn string
 		thisPortValue := portValue
-		// TODO also support cloudsql DSNs
-		if host == "localhost" && (socketWasSupplied || !portWasSupplied) {
-			dsn = fmt.Sprintf("%s@unix(%s)/?%s", userAndPass, socketValue, params)
-		} else {
+		thisSocketValue := socketValue
+		useSocket := (host == "localhost" && (socketWasSupplied || !portWasSupplied))
+		if filepath.IsAbs(host) { // host may be a socket file path, as an alternative to host=localhost + socket
+			useSocket = true
+			thisSocketValue = host
+			host = "localhost"
+		}
+		useCloudSQL := util.IsCloudSQLConnectionName(host)
+		if !useSocket && !useCloudSQL {
 			splitHost, splitPort, err := tengo.SplitHostOptionalPort(host)
 			if err != nil {
 				return nil, err
@@ -237,12 +378,58 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 				host = splitHost
 				thisPortValue = splitPort
 			}
-			dsn = fmt.Sprintf("%s@tcp(%s:%d)/?%s", userAndPass, host, thisPortValue, params)
 		}
-		instance, err := util.NewInstance("mysql", dsn)
+		thisParams := params
+		if useCloudSQLIAMAuth && !useCloudSQL && !useSocket && !isLoopbackHost(host) {
+			switch v, _ := url.ParseQuery(params); v.Get("tls") {
+			case "":
+				thisParams += "&tls=true"
+			case "false", "preferred":
+				return nil, fmt.Errorf("Option cloudsql-iam-auth requires TLS for host %s, unless host is a Cloud SQL instance connection name or a local proxy", host)
+			}
+		}
+		if useIAMAuth {
+			token, err := util.RDSAuthTokenForHost(host, thisPortValue, user, dir.Config.Get("aws-region"))
+			if err != nil {
+				return nil, fmt.Errorf("Unable to generate RDS IAM auth token for %s: %s", host, err)
+			}
+			userAndPass = fmt.Sprintf("%s:%s", user, token)
+		} else if usePasswordCommand {
+			variables := map[string]string{
+				"HOST":        host,
+				"PORT":        strconv.Itoa(thisPortValue),
+				"USER":        user,
+				"ENVIRONMENT": dir.Config.Get("environment"),
+				"SCHEMA":      dir.Config.Get("schema"),
+				"DIRNAME":     dir.BaseName(),
+				"DIRPATH":     dir.Path,
+			}
+			password, err := util.CommandPassword(dir.Config.Get("password-command"), variables)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to obtain password for %s: %s", host, err)
+			}
+			userAndPass = fmt.Sprintf("%s:%s", user, password)
+		}
+		if useSocket {
+			dsn = fmt.Sprintf("%s@unix(%s)/?%s", userAndPass, thisSocketValue, thisParams)
+		} else if useCloudSQL {
+			dsn = fmt.Sprintf("%s@cloudsql(%s)/?%s", userAndPass, host, thisParams)
+		} else {
+			dsn = fmt.Sprintf("%s@tcp(%s:%d)/?%s", userAndPass, host, thisPortValue, thisParams)
+		}
+		driver := "mysql"
+		if useIAMAuth {
+			// The token in the DSN is only valid for 15 minutes, so each new
+			// connection must generate its own
+			driver = util.RDSIAMAuthDriver(dir.Config.Get("aws-region"))
+		} else if useCloudSQLIAMAuth {
+			// Likewise, Google access tokens are only valid for an hour
+			driver = util.CloudSQLIAMAuthDriver()
+		}
+		instance, err := util.NewInstance(driver, dsn)
 		if err != nil || instance == nil {
-			if dir.Config.Changed("password") {
-				safeUserPass := fmt.Sprintf("%s:*****", dir.Config.Get("user"))
+			if userAndPass != user {
+				safeUserPass := fmt.Sprintf("%s:*****", user)
 				dsn = strings.Replace(dsn, userAndPass, safeUserPass, 1)
 			}
 			return nil, fmt.Errorf("Invalid connection information for %s (DSN=%s): %s", dir, dsn, err)
@@ -252,6 +439,12 @@ func (dir *Dir) Instances() ([]*tengo.Instance, error) {
 	return instances, nil
 }
 
+// isLoopbackHost returns true if host is localhost or a loopback IP, meaning
+// that traffic to it does not leave the local machine.
+func isLoopbackHost(host string) bool {
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
 // FirstInstance returns at most one tengo.Instance based on the directory's
 // configuration. If the config maps to multiple instances, only the first will
 // be returned. If the config maps to no instances, nil will be returned. The
@@ -271,10 +464,14 @@ func (dir *Dir) FirstInstance() (*tengo.Instance, error) {
 			return instance, nil
 		}
 	}
+	var hint string
+	if authHint := util.AuthErrorHint(lastErr); authHint != "" {
+		hint = "\n" + authHint
+	}
 	if len(instances) == 1 {
-		return nil, fmt.Errorf("Unable to connect to %s for %s: %s", instances[0], dir, lastErr)
+		return nil, fmt.Errorf("Unable to connect to %s for %s: %s%s", instances[0], dir, lastErr, hint)
 	}
-	return nil, fmt.Errorf("Unable to connect to any of %d instances for %s; last error %s", len(instances), dir, lastErr)
+	return nil, fmt.Errorf("Unable to connect to any of %d instances for %s; last error %s%s", len(instances), dir, lastErr, hint)
 }
 
 // SchemaNames interprets the value of the dir's "schema" option, returning one
@@ -297,8 +494,8 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 		variables := map[string]string{
 			"HOST":        instance.Host,
 			"PORT":        strconv.Itoa(instance.Port),
-			"USER":        dir.Config.Get("user"),
-			"PASSWORD":    dir.Config.Get("password"),
+			"USER":        instance.User,
+			"PASSWORD":    instance.Password,
 			"ENVIRONMENT": dir.Config.Get("environment"),
 			"DIRNAME":     dir.BaseName(),
 			"DIRPATH":     dir.Path,
@@ -315,6 +512,18 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 		if names, err = instance.SchemaNames(); err != nil {
 			return nil, err
 		}
+		// If schema-map is in use, only keep the schemas mapped to this dir
+		if dir.Config.Changed("schema-map") {
+			keepNames := make([]string, 0, len(names))
+			for _, name := range names {
+				if subdirName, err := dir.MappedSubdirName(name); err != nil {
+					return nil, err
+				} else if subdirName == dir.BaseName() {
+					keepNames = append(keepNames, name)
+				}
+			}
+			names = keepNames
+		}
 		// Schema name list must be sorted so that TargetsForDir with
 		// firstOnly==true consistently grabs the alphabetically first schema. (Only
 		// relevant here since in all other cases, we use the order specified by the
@@ -322,9 +531,14 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 		sort.Strings(names)
 	} else {
 		names = dir.Config.GetSlice("schema", ',', true)
+		if strings.Contains(schemaValue, "{") {
+			if names, err = dir.interpolateSchemaNames(names, instance); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// Remove ignored schemas and system schemas. (tengo removes the latter from
+	// Remove ignored schemas, the history schema, and system schemas. (tengo removes the latter from
 	// some operations, but additional protection here is needed to ensure a user
 	// can't manually configure the schema option to a system schema.)
 	ignoreSchema, err := dir.Config.GetRegexp("ignore-schema")
@@ -337,10 +551,13 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 		"sys":                true,
 		"mysql":              true,
 	}
+	historySchema := dir.Config.Get("history-schema")
 	keepNames := make([]string, 0, len(names))
 	for _, name := range names {
 		if ignoreSchema != nil && ignoreSchema.MatchString(name) {
 			log.Debugf("Skipping schema %s because ignore-schema='%s'", name, ignoreSchema)
+		} else if name == historySchema {
+			log.Debugf("Skipping schema %s because it is the history-schema", name)
 		} else if !systemSchemas[name] {
 			keepNames = append(keepNames, name)
 		}
@@ -348,6 +565,69 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 	return keepNames, nil
 }
 
+// MappedSubdirName returns the name of the subdir which schemaName maps to,
+// based on the first matching rule of the schema-map option. Each rule has the
+// form regex=template, where the template may refer to capture groups of the
+// regex using $1, $2, etc. An empty string is returned if no rule matches. An
+// error is returned if the option value is invalid.
+func (dir *Dir) MappedSubdirName(schemaName string) (string, error) {
+	for _, rule := range dir.Config.GetSlice("schema-map", ',', true) {
+		pos := strings.LastIndexByte(rule, '=')
+		if pos < 1 || pos == len(rule)-1 {
+			return "", fmt.Errorf("Option schema-map: rule %q is not in format regex=template", rule)
+		}
+		re, err := regexp.Compile(rule[:pos])
+		if err != nil {
+			return "", fmt.Errorf("Option schema-map: invalid regex in rule %q: %s", rule, err)
+		}
+		match := re.FindStringSubmatchIndex(schemaName)
+		if match == nil {
+			continue
+		}
+		subdirName := string(re.ExpandString(nil, rule[pos+1:], schemaName, match))
+		if subdirName == "" || subdirName[0] == '.' || strings.ContainsAny(subdirName, `/\`) {
+			return "", fmt.Errorf("Option schema-map: rule %q maps schema %s to invalid subdir name %q", rule, schemaName, subdirName)
+		}
+		return subdirName, nil
+	}
+	return "", nil
+}
+
+// interpolateSchemaNames resolves any variable placeholders in names, for
+// setups where the same logical schema has a different physical name in each
+// environment or on each shard. Supported variables are {HOST}, {PORT},
+// {ENVIRONMENT}, {DIRNAME}, {DIRPATH}, and {NUM}. The latter is the numeric
+// suffix of the first label of instance's hostname, for example 3 for host
+// "db3.example.com".
+func (dir *Dir) interpolateSchemaNames(names []string, instance *tengo.Instance) ([]string, error) {
+	variables := map[string]string{
+		"HOST":        instance.Host,
+		"PORT":        strconv.Itoa(instance.Port),
+		"ENVIRONMENT": dir.Config.Get("environment"),
+		"DIRNAME":     dir.BaseName(),
+		"DIRPATH":     dir.Path,
+	}
+	label := strings.SplitN(instance.Host, ".", 2)[0]
+	numStart := len(label)
+	for numStart > 0 && label[numStart-1] >= '0' && label[numStart-1] <= '9' {
+		numStart--
+	}
+	if numStart < len(label) {
+		variables["NUM"] = label[numStart:]
+	}
+	interpolated := make([]string, len(names))
+	for n, name := range names {
+		var err error
+		if interpolated[n], err = util.InterpolateVariables(name, variables); err != nil {
+			if strings.Contains(strings.ToUpper(name), "{NUM}") && variables["NUM"] == "" {
+				err = fmt.Errorf("Schema name template %s requires a numeric suffix in hostname, but host %s has none", name, instance.Host)
+			}
+			return nil, fmt.Errorf("Unable to resolve schema name for %s in %s: %s", instance, dir, err)
+		}
+	}
+	return interpolated, nil
+}
+
 // HasSchema returns true if this dir maps to at least one schema, either by
 // stating a "schema" option in this dir's option file for the current
 // environment, and/or by having *.sql files that explicitly mention a schema
@@ -358,7 +638,8 @@ func (dir *Dir) HasSchema() bool {
 	// parent dirs. This way, users can store arbitrary things in subdirs without
 	// Skeema interpreting them incorrectly.
 	if dir.OptionFile != nil {
-		if val, _ := dir.OptionFile.OptionValue("schema"); val != "" {
+		val, _ := dir.OptionFile.OptionValue("schema")
+		if val, _ = util.ExpandEnv(val); val != "" {
 			return true
 		}
 	}
@@ -370,6 +651,76 @@ func (dir *Dir) HasSchema() bool {
 	return false
 }
 
+// IgnoreOptions tracks which database objects should be left unmanaged, based
+// on the ignore-object-type, ignore-table, ignore-proc, and ignore-func options.
+type IgnoreOptions struct {
+	ObjectTypes map[tengo.ObjectType]bool
+	Table       *regexp.Regexp
+	Proc        *regexp.Regexp
+	Func        *regexp.Regexp
+}
+
+// ShouldIgnore returns true if the supplied key should be ignored, either due
+// to its type or its name.
+func (opts IgnoreOptions) ShouldIgnore(key tengo.ObjectKey) bool {
+	if opts.ObjectTypes[key.Type] {
+		return true
+	}
+	var re *regexp.Regexp
+	switch key.Type {
+	case tengo.ObjectTypeTable:
+		re = opts.Table
+	case tengo.ObjectTypeProc:
+		re = opts.Proc
+	case tengo.ObjectTypeFunc:
+		re = opts.Func
+	}
+	return re != nil && re.MatchString(key.Name)
+}
+
+// historyTableName is the name of the table used for recording push history.
+// It must match applier.HistoryTableName.
+const historyTableName = "skeema_history"
+
+// IgnoreOptions returns the configuration of which objects should be ignored
+// in this dir. If the history-schema option is set, the push history table is
+// always ignored. An error is returned if any of the relevant options have an
+// invalid value.
+func (dir *Dir) IgnoreOptions() (opts IgnoreOptions, err error) {
+	opts.ObjectTypes = make(map[tengo.ObjectType]bool)
+	for _, typeName := range dir.Config.GetSlice("ignore-object-type", ',', true) {
+		switch strings.ToLower(typeName) {
+		case "table", "tables":
+			opts.ObjectTypes[tengo.ObjectTypeTable] = true
+		case "procedure", "procedures", "proc", "procs":
+			opts.ObjectTypes[tengo.ObjectTypeProc] = true
+		case "function", "functions", "func", "funcs":
+			opts.ObjectTypes[tengo.ObjectTypeFunc] = true
+		default:
+			return IgnoreOptions{}, fmt.Errorf("Option ignore-object-type: unsupported object type %s; valid values are table, procedure, function", typeName)
+		}
+	}
+	if opts.Table, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+		return IgnoreOptions{}, err
+	}
+	if dir.Config.Get("history-schema") != "" {
+		// The push history table is managed by Skeema itself, and may live in a
+		// schema which also contains user tables
+		if opts.Table == nil {
+			opts.Table = regexp.MustCompile("^" + historyTableName + "$")
+		} else {
+			opts.Table = regexp.MustCompile("(?:" + opts.Table.String() + ")|^" + historyTableName + "$")
+		}
+	}
+	if opts.Proc, err = dir.Config.GetRegexp("ignore-proc"); err != nil {
+		return IgnoreOptions{}, err
+	}
+	if opts.Func, err = dir.Config.GetRegexp("ignore-func"); err != nil {
+		return IgnoreOptions{}, err
+	}
+	return opts, nil
+}
+
 // InstanceDefaultParams returns a param string for use in constructing a
 // DSN. Any overrides specified in the config for this dir will be taken into
 // account. The returned string will already be in the correct format (HTTP
@@ -448,14 +799,30 @@ func (dir *Dir) parseContents() error {
 		if dir.OptionFile, err = parseOptionFile(dir.Path, dir.Config); err != nil {
 			return err
 		}
-		dir.Config.AddSource(dir.OptionFile)
+		if err := util.AddOptionFile(dir.Config, dir.OptionFile); err != nil {
+			return err
+		}
 	}
 
-	// Tokenize and parse any *.sql files
+	// Tokenize and parse any *.sql files, including ones in subdirs named for
+	// object types if the type-subdirs option is enabled
 	var err error
 	if dir.SQLFiles, err = sqlFiles(dir.Path); err != nil {
 		return err
 	}
+	if dir.UsesTypeSubdirs() {
+		for _, objectType := range []tengo.ObjectType{tengo.ObjectTypeTable, tengo.ObjectTypeProc, tengo.ObjectTypeFunc} {
+			subPath := path.Join(dir.Path, TypeSubdirName(objectType))
+			if fi, err := os.Stat(subPath); err != nil || !fi.IsDir() {
+				continue
+			}
+			subFiles, err := sqlFiles(subPath)
+			if err != nil {
+				return err
+			}
+			dir.SQLFiles = append(dir.SQLFiles, subFiles...)
+		}
+	}
 	for _, sf := range dir.SQLFiles {
 		tokenizedFile, err := sf.Tokenize()
 		if err != nil {
@@ -503,9 +870,56 @@ func (dir *Dir) parseContents() error {
 		ls.Collation = dir.Config.Get("default-collation")
 		dir.LogicalSchemas = append([]*LogicalSchema{ls}, dir.LogicalSchemas...)
 	}
+	return dir.parseSeeds(logicalSchemasByName)
+}
+
+// seedTargetRegexp matches the beginning of an INSERT or REPLACE statement,
+// capturing the table name and whatever follows it.
+var seedTargetRegexp = regexp.MustCompile("(?is)^(?:insert|replace)\\s+(?:(?:low_priority|delayed|high_priority|ignore)\\s+)*(?:into\\s+)?(`(?:[^`]|``)+`|[0-9a-z$_]+)(.*)$")
+
+// parseSeeds tokenizes any *.sql files in dir's seeds subdir, and adds their
+// statements to the Seeds of dir's own LogicalSchema. Only INSERT or REPLACE
+// statements into unqualified table names are permitted, since seed data must
+// never be written anywhere other than the workspace schema; an error is
+// returned upon encountering any other statement.
+func (dir *Dir) parseSeeds(logicalSchemasByName map[string]*LogicalSchema) error {
+	subPath := path.Join(dir.Path, SeedsSubdirName)
+	if fi, err := os.Stat(subPath); err != nil || !fi.IsDir() || !dir.OwnsSubdir(SeedsSubdirName) {
+		return nil
+	}
+	seedFiles, err := sqlFiles(subPath)
+	if err != nil {
+		return err
+	}
+	ls := logicalSchemasByName[""]
+	for _, sf := range seedFiles {
+		tokenizedFile, err := sf.Tokenize()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range tokenizedFile.Statements {
+			if stmt.Type == StatementTypeNoop {
+				continue
+			}
+			if !isValidSeed(stmt) || ls == nil {
+				return fmt.Errorf("%s: seed files may only contain INSERT or REPLACE statements into unqualified table names of %s's schema", stmt.Location(), dir)
+			}
+			ls.Seeds = append(ls.Seeds, stmt)
+		}
+	}
 	return nil
 }
 
+// isValidSeed returns true if stmt is an INSERT or REPLACE into an unqualified
+// table name, without any preceding USE command.
+func isValidSeed(stmt *Statement) bool {
+	if stmt.Type != StatementTypeUnknown || stmt.Schema() != "" {
+		return false
+	}
+	matches := seedTargetRegexp.FindStringSubmatch(stmt.Body())
+	return matches != nil && !strings.HasPrefix(strings.TrimSpace(matches[2]), ".")
+}
+
 // ParentOptionFiles returns a slice of *mybase.File, corresponding to the
 // option files in the specified path's parent dir hierarchy. Evaluation of
 // parent dirs stops once we hit either a directory containing .git, the
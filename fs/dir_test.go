@@ -1,7 +1,10 @@
 package fs
 
 import (
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -104,6 +107,132 @@ func TestDirSubdirs(t *testing.T) {
 	}
 }
 
+func TestDirTypeSubdirs(t *testing.T) {
+	base := "../testdata/.scratch/fs/typesubdirs"
+	defer os.RemoveAll(base)
+	WriteTestFile(t, base+"/.skeema", "schema=product\ntype-subdirs\n")
+	WriteTestFile(t, base+"/tables/users.sql", "CREATE TABLE users (id int);\n")
+	WriteTestFile(t, base+"/procedures/doit.sql", "CREATE PROCEDURE doit() SELECT 1;\n")
+	WriteTestFile(t, base+"/posts.sql", "CREATE TABLE posts (id int);\n")
+	WriteTestFile(t, base+"/other/.skeema", "schema=other\n")
+
+	dir := getDir(t, base)
+	if !dir.UsesTypeSubdirs() {
+		t.Fatal("Expected UsesTypeSubdirs to return true, but it did not")
+	}
+	if len(dir.SQLFiles) != 3 || len(dir.LogicalSchemas) != 1 {
+		t.Fatalf("Unexpected result from parsing dir: %d SQLFiles, %d LogicalSchemas", len(dir.SQLFiles), len(dir.LogicalSchemas))
+	}
+	creates := dir.LogicalSchemas[0].Creates
+	for _, key := range []tengo.ObjectKey{{Type: tengo.ObjectTypeTable, Name: "users"}, {Type: tengo.ObjectTypeTable, Name: "posts"}, {Type: tengo.ObjectTypeProc, Name: "doit"}} {
+		if creates[key] == nil {
+			t.Errorf("Expected to find %s, but did not", key)
+		}
+	}
+	if subs, badCount, err := dir.Subdirs(); err != nil || badCount > 0 || len(subs) != 1 || subs[0].BaseName() != "other" {
+		t.Errorf("Unexpected result from Subdirs(): %v, %d, %v", subs, badCount, err)
+	}
+
+	// Without the option, the type subdirs are just normal subdirs
+	WriteTestFile(t, base+"/.skeema", "schema=product\n")
+	dir = getDir(t, base)
+	if len(dir.SQLFiles) != 1 {
+		t.Errorf("Expected 1 SQLFile, instead found %d", len(dir.SQLFiles))
+	}
+	if subs, _, _ := dir.Subdirs(); len(subs) != 3 {
+		t.Errorf("Expected 3 subdirs, instead found %d", len(subs))
+	}
+}
+
+func TestDirSeeds(t *testing.T) {
+	base := "../testdata/.scratch/fs/seeds"
+	defer os.RemoveAll(base)
+	WriteTestFile(t, base+"/.skeema", "schema=product\nseeds\n")
+	WriteTestFile(t, base+"/users.sql", "CREATE TABLE users (id int, name varchar(30));\n")
+	WriteTestFile(t, base+"/seeds/users.sql", "-- reference rows\nINSERT INTO users VALUES (1, 'a;b');\nREPLACE LOW_PRIORITY `users` (id) VALUES (2);\n")
+
+	dir := getDir(t, base)
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 1 {
+		t.Fatalf("Unexpected result from parsing dir: %+v", dir.LogicalSchemas)
+	}
+	seeds := dir.LogicalSchemas[0].Seeds
+	if len(seeds) != 2 || seeds[0].Body() != "INSERT INTO users VALUES (1, 'a;b')" || seeds[1].LineNo != 3 {
+		t.Errorf("Unexpected seeds: %+v", seeds)
+	}
+	if subs, _, err := dir.Subdirs(); err != nil || len(subs) != 0 {
+		t.Errorf("Expected seeds subdir to not be treated as a separate dir; instead found %v, %v", subs, err)
+	}
+
+	// Anything other than an INSERT or REPLACE into an unqualified table is an
+	// error
+	badSeeds := []string{
+		"INSERT INTO other.foo VALUES (3);\n",
+		"INSERT INTO `other` . `foo` VALUES (3);\n",
+		"USE other;\nINSERT INTO foo VALUES (3);\n",
+		"UPDATE users SET name = 'x';\n",
+		"DROP TABLE users;\n",
+		"CREATE TABLE foo (id int);\n",
+	}
+	for _, contents := range badSeeds {
+		WriteTestFile(t, base+"/seeds/users.sql", contents)
+		if _, err := ParseDir(base, getValidConfig(t)); err == nil {
+			t.Errorf("Expected seed file %q to cause an error, but it did not", contents)
+		}
+	}
+
+	// With seeds disabled, seeds is just a normal subdir
+	WriteTestFile(t, base+"/.skeema", "schema=product\n")
+	dir = getDir(t, base)
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Seeds) != 0 {
+		t.Errorf("Unexpected result from parsing dir: %+v", dir.LogicalSchemas)
+	}
+	if subs, _, _ := dir.Subdirs(); len(subs) != 1 {
+		t.Errorf("Expected 1 subdir, instead found %d", len(subs))
+	}
+
+	// In a dir without a schema, seeds is also just a normal subdir
+	WriteTestFile(t, base+"/.skeema", "seeds\n")
+	dir = getDir(t, base)
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Seeds) != 0 {
+		t.Errorf("Unexpected result from parsing dir: %+v", dir.LogicalSchemas)
+	}
+	if subs, _, _ := dir.Subdirs(); len(subs) != 1 {
+		t.Errorf("Expected 1 subdir, instead found %d", len(subs))
+	}
+}
+
+func TestDirLinkedPath(t *testing.T) {
+	base := "../testdata/.scratch/fs/linked"
+	defer os.RemoveAll(base)
+	WriteTestFile(t, base+"/common/shared.sql", "CREATE TABLE shared (id int);\n")
+	WriteTestFile(t, base+"/common/tables/other.sql", "CREATE TABLE other (id int);\n")
+	WriteTestFile(t, base+"/shard1/.skeema", "schema=shard1\ntype-subdirs\n")
+	WriteTestFile(t, base+"/shard1/own.sql", "CREATE TABLE own (id int);\n")
+	if err := os.Symlink("../common/shared.sql", base+"/shard1/shared.sql"); err != nil {
+		t.Fatalf("Unable to create symlink: %s", err)
+	}
+	if err := os.Symlink("../common/tables", base+"/shard1/tables"); err != nil {
+		t.Fatalf("Unable to create symlink: %s", err)
+	}
+
+	dir := getDir(t, base+"/shard1")
+	if len(dir.SQLFiles) != 3 || len(dir.LogicalSchemas[0].Creates) != 3 {
+		t.Fatalf("Unexpected result from parsing dir: %d SQLFiles, %d creates", len(dir.SQLFiles), len(dir.LogicalSchemas[0].Creates))
+	}
+	commonPath, _ := filepath.Abs(base + "/common")
+	commonPath, _ = filepath.EvalSymlinks(commonPath)
+	expected := map[string]string{
+		"own.sql":    "",
+		"shared.sql": filepath.Join(commonPath, "shared.sql"),
+		"other.sql":  filepath.Join(commonPath, "tables", "other.sql"),
+	}
+	for _, sf := range dir.SQLFiles {
+		if actual := dir.LinkedPath(sf); actual != expected[sf.FileName] {
+			t.Errorf("Expected LinkedPath(%s) to return %q, instead found %q", sf, expected[sf.FileName], actual)
+		}
+	}
+}
+
 func TestDirInstances(t *testing.T) {
 	assertInstances := func(optionValues map[string]string, expectError bool, expectedInstances ...string) []*tengo.Instance {
 		cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
@@ -147,12 +276,19 @@ func TestDirInstances(t *testing.T) {
 	assertInstances(map[string]string{"host": "localhost", "port": "1234"}, false, "localhost:1234")
 	assertInstances(map[string]string{"host": "localhost", "socket": "/var/run/mysql.sock"}, false, "localhost:/var/run/mysql.sock")
 	assertInstances(map[string]string{"host": "localhost", "port": "1234", "socket": "/var/lib/mysql/mysql.sock"}, false, "localhost:/var/lib/mysql/mysql.sock")
+	assertInstances(map[string]string{"host": "/var/run/mysqld/mysqld.sock"}, false, "localhost:/var/run/mysqld/mysqld.sock")
+	assertInstances(map[string]string{"host": "/var/run/mysqld/mysqld.sock", "socket": "/tmp/other.sock", "port": "1234"}, false, "localhost:/var/run/mysqld/mysqld.sock")
+	assertInstances(map[string]string{"host": "/var/run/mysqld/mysqld.sock,some.db.host"}, false, "localhost:/var/run/mysqld/mysqld.sock", "some.db.host:3306")
 
 	// list of static hosts
 	assertInstances(map[string]string{"host": "some.db.host,other.db.host"}, false, "some.db.host:3306", "other.db.host:3306")
 	assertInstances(map[string]string{"host": `"some.db.host, other.db.host"`, "port": "3307"}, false, "some.db.host:3307", "other.db.host:3307")
 	assertInstances(map[string]string{"host": "'some.db.host:3308', 'other.db.host'"}, false, "some.db.host:3308", "other.db.host:3306")
 
+	// Cloud SQL instance connection names
+	assertInstances(map[string]string{"host": "my-project:us-central1:db1"}, false, "my-project:us-central1:db1")
+	assertInstances(map[string]string{"host": "my-project:us-central1:db1,some.db.host"}, false, "my-project:us-central1:db1", "some.db.host:3306")
+
 	// invalid option values or combinations
 	assertInstances(map[string]string{"host": "some.db.host", "connect-options": ","}, true)
 	assertInstances(map[string]string{"host": "some.db.host:3306", "port": "3307"}, true)
@@ -166,6 +302,189 @@ func TestDirInstances(t *testing.T) {
 	assertInstances(map[string]string{"host-wrapper": "/usr/bin/printf 'some.db.host\tother.db.host:3316'", "host": "ignored", "port": "3316"}, false, "some.db.host:3316", "other.db.host:3316")
 	assertInstances(map[string]string{"host-wrapper": "/usr/bin/printf 'localhost,remote.host:3307,other.host'", "host": "ignored", "socket": "/var/lib/mysql/mysql.sock"}, false, "localhost:/var/lib/mysql/mysql.sock", "remote.host:3307", "other.host:3306")
 	assertInstances(map[string]string{"host-wrapper": "/bin/echo -n", "host": "ignored"}, false)
+
+	// password obtained via password-command, separately for each host
+	instances := assertInstances(map[string]string{"host": "some.db.host,other.db.host:3307", "user": "bob", "password-command": "/usr/bin/printf '%s-{USER}-{PORT}\n' {HOST}"}, false, "some.db.host:3306", "other.db.host:3307")
+	if len(instances) == 2 && (instances[0].Password != "some.db.host-bob-3306" || instances[1].Password != "other.db.host-bob-3307") {
+		t.Errorf("password-command did not yield expected passwords; found %q and %q", instances[0].Password, instances[1].Password)
+	}
+	instances = assertInstances(map[string]string{"host": "some.db.host", "password": "explicit", "password-command": "/usr/bin/printf ignored"}, false, "some.db.host:3306")
+	if len(instances) == 1 && instances[0].Password != "explicit" {
+		t.Errorf("Expected password option to take precedence over password-command; found password %q", instances[0].Password)
+	}
+	assertInstances(map[string]string{"host": "some.db.host", "password-command": "/bin/echo -n"}, true)
+	assertInstances(map[string]string{"host": "some.db.host", "password-command": "false"}, true)
+	assertInstances(map[string]string{"host": "some.db.host", "password-command": "/bin/echo {INVALID_VAR}"}, true)
+
+	// password generated via RDS IAM auth
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	instances = assertInstances(map[string]string{"host": "mydb.abc123.us-east-1.rds.amazonaws.com", "user": "bob", "aws-iam-auth": "1"}, false, "mydb.abc123.us-east-1.rds.amazonaws.com:3306")
+	if len(instances) == 1 && !strings.HasPrefix(instances[0].Password, "mydb.abc123.us-east-1.rds.amazonaws.com:3306/?Action=connect&DBUser=bob&") {
+		t.Errorf("Unexpected password with aws-iam-auth: %s", instances[0].Password)
+	}
+	assertInstances(map[string]string{"host": "some.db.host", "aws-iam-auth": "1"}, true) // region cannot be determined
+
+	// Cloud SQL IAM auth tokens may only be sent to remote hosts over TLS
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "ya29.example")
+	defer os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	instances = assertInstances(map[string]string{"host": "some.db.host", "user": "bob", "cloudsql-iam-auth": "1"}, false, "some.db.host:3306")
+	if len(instances) == 1 && instances[0].Password != "ya29.example" {
+		t.Errorf("Unexpected password with cloudsql-iam-auth: %s", instances[0].Password)
+	}
+	assertInstances(map[string]string{"host": "127.0.0.1", "user": "bob", "cloudsql-iam-auth": "1", "ssl-mode": "disabled"}, false, "127.0.0.1:3306")
+	assertInstances(map[string]string{"host": "some.db.host", "user": "bob", "cloudsql-iam-auth": "1", "ssl-mode": "disabled"}, true)
+	assertInstances(map[string]string{"host": "some.db.host", "user": "bob", "cloudsql-iam-auth": "1", "ssl-mode": "preferred"}, true)
+}
+
+func TestDirSchemaNamesTemplate(t *testing.T) {
+	assertSchemaNames := func(schemaValue, host string, expectError bool, expectedNames ...string) {
+		t.Helper()
+		cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
+		cmd.AddArg("environment", "production", false)
+		util.AddGlobalOptions(cmd)
+		cli := &mybase.CommandLine{
+			Command: cmd,
+		}
+		cfg := mybase.NewConfig(cli, mybase.SimpleSource(map[string]string{"schema": schemaValue}))
+		dir := &Dir{
+			Path:   "/tmp/product",
+			Config: cfg,
+		}
+		inst, err := tengo.NewInstance("mysql", fmt.Sprintf("root:@tcp(%s)/", host))
+		if err != nil {
+			t.Fatalf("Unexpected error from NewInstance: %s", err)
+		}
+		names, err := dir.SchemaNames(inst)
+		if expectError && err == nil {
+			t.Errorf("With schema=%s and host %s, expected error to be returned, but it was nil", schemaValue, host)
+		} else if !expectError && err != nil {
+			t.Errorf("With schema=%s and host %s, expected nil error, but found %s", schemaValue, host, err)
+		} else if !expectError && !reflect.DeepEqual(expectedNames, names) {
+			t.Errorf("With schema=%s and host %s, expected names %v, but found %v", schemaValue, host, expectedNames, names)
+		}
+	}
+
+	assertSchemaNames("myapp", "db3.example.com:3306", false, "myapp")
+	assertSchemaNames("myapp_{environment}", "db3.example.com:3306", false, "myapp_production")
+	assertSchemaNames("{DIRNAME}_shard{num}", "db03.example.com:3306", false, "product_shard03")
+	assertSchemaNames("a_{num},b_{port}", "db12:3307", false, "a_12", "b_3307")
+	assertSchemaNames("{dirname}_shard{num}", "db.example.com:3306", true)
+	assertSchemaNames("myapp_{bogus}", "db3.example.com:3306", true)
+
+	// The history schema is never included
+	cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
+	util.AddGlobalOptions(cmd)
+	cfg := mybase.NewConfig(&mybase.CommandLine{Command: cmd}, mybase.SimpleSource(map[string]string{"schema": "myapp,_skeema", "history-schema": "_skeema"}))
+	dir := &Dir{Path: "/tmp/product", Config: cfg}
+	inst, _ := tengo.NewInstance("mysql", "root:@tcp(db3.example.com:3306)/")
+	if names, err := dir.SchemaNames(inst); err != nil || !reflect.DeepEqual(names, []string{"myapp"}) {
+		t.Errorf("Expected history-schema to be excluded from schema names, instead found %v, %v", names, err)
+	}
+}
+
+func TestDirMappedSubdirName(t *testing.T) {
+	getDirWithMap := func(schemaMap string) *Dir {
+		cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
+		util.AddGlobalOptions(cmd)
+		cfg := mybase.NewConfig(&mybase.CommandLine{Command: cmd}, mybase.SimpleSource(map[string]string{"schema-map": schemaMap}))
+		return &Dir{
+			Path:   "/tmp/dummydir",
+			Config: cfg,
+		}
+	}
+
+	dir := getDirWithMap(`'^customer_[0-9]{1,4}$=customers', ^([a-z]+)_archive_[0-9]+$=${1}_archives`)
+	expected := map[string]string{
+		"customer_12":         "customers",
+		"customer_12345":      "",
+		"orders_archive_2019": "orders_archives",
+		"analytics":           "",
+	}
+	for schemaName, expectedSubdir := range expected {
+		if actual, err := dir.MappedSubdirName(schemaName); err != nil {
+			t.Errorf("Unexpected error from MappedSubdirName(%s): %s", schemaName, err)
+		} else if actual != expectedSubdir {
+			t.Errorf("Expected MappedSubdirName(%s) to return %q, instead found %q", schemaName, expectedSubdir, actual)
+		}
+	}
+
+	for _, schemaMap := range []string{"customers", "^customer_=", "=customers", "^(customer_$=customers", "^(.*)$=../$1"} {
+		if _, err := getDirWithMap(schemaMap).MappedSubdirName("customer_1"); err == nil {
+			t.Errorf("Expected schema-map=%s to return an error, but it did not", schemaMap)
+		}
+	}
+}
+
+func TestDirIgnoreOptions(t *testing.T) {
+	getIgnoreOptions := func(optionValues map[string]string) (IgnoreOptions, error) {
+		cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
+		util.AddGlobalOptions(cmd)
+		cfg := mybase.NewConfig(&mybase.CommandLine{Command: cmd}, mybase.SimpleSource(optionValues))
+		dir := &Dir{
+			Path:   "/tmp/dummydir",
+			Config: cfg,
+		}
+		return dir.IgnoreOptions()
+	}
+
+	opts, err := getIgnoreOptions(map[string]string{
+		"ignore-table":       "^_",
+		"ignore-proc":        "^tmp",
+		"ignore-object-type": "Functions",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error from IgnoreOptions: %s", err)
+	}
+	expected := map[tengo.ObjectKey]bool{
+		{Type: tengo.ObjectTypeTable, Name: "_foo"}:   true,
+		{Type: tengo.ObjectTypeTable, Name: "foo"}:    false,
+		{Type: tengo.ObjectTypeProc, Name: "_foo"}:    false,
+		{Type: tengo.ObjectTypeProc, Name: "tmpfoo"}:  true,
+		{Type: tengo.ObjectTypeFunc, Name: "foo"}:     true,
+		{Type: tengo.ObjectTypeTable, Name: "tmpfoo"}: false,
+	}
+	for key, expectIgnore := range expected {
+		if actual := opts.ShouldIgnore(key); actual != expectIgnore {
+			t.Errorf("Expected ShouldIgnore(%s) to return %t, instead found %t", key, expectIgnore, actual)
+		}
+	}
+
+	// Nothing ignored by default
+	if opts, err := getIgnoreOptions(nil); err != nil {
+		t.Errorf("Unexpected error from IgnoreOptions: %s", err)
+	} else if opts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "foo"}) {
+		t.Error("Expected default IgnoreOptions to not ignore anything, but it did")
+	}
+
+	// The history table is always ignored if history-schema is set, in addition
+	// to any ignore-table pattern
+	history := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "skeema_history"}
+	for _, optionValues := range []map[string]string{
+		{"history-schema": "_skeema"},
+		{"history-schema": "_skeema", "ignore-table": "^_"},
+	} {
+		opts, err := getIgnoreOptions(optionValues)
+		if err != nil {
+			t.Errorf("Unexpected error from IgnoreOptions with %v: %s", optionValues, err)
+		} else if !opts.ShouldIgnore(history) || opts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "skeema_history2"}) {
+			t.Errorf("Expected IgnoreOptions with %v to ignore exactly the history table", optionValues)
+		} else if optionValues["ignore-table"] != "" && !opts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "_foo"}) {
+			t.Errorf("Expected IgnoreOptions with %v to still respect ignore-table", optionValues)
+		}
+	}
+
+	// Invalid values
+	for _, optionValues := range []map[string]string{
+		{"ignore-func": "+"},
+		{"ignore-object-type": "table,view"},
+	} {
+		if _, err := getIgnoreOptions(optionValues); err == nil {
+			t.Errorf("Expected error from IgnoreOptions with %v, but it was nil", optionValues)
+		}
+	}
 }
 
 func TestDirInstanceDefaultParams(t *testing.T) {
@@ -231,6 +550,8 @@ func getValidConfig(t *testing.T) *mybase.Config {
 	cmd.AddOption(mybase.StringOption("host", 0, "", "Database hostname or IP address").Hidden())
 	cmd.AddOption(mybase.StringOption("port", 0, "3306", "Port to use for database host").Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
+	cmd.AddOption(mybase.BoolOption("type-subdirs", 0, false, "Store *.sql files in a subdir per object type (tables, procedures, functions) within each schema dir"))
+	cmd.AddOption(mybase.BoolOption("seeds", 0, false, "Load INSERT statements from each schema dir's seeds subdir into workspaces"))
 	cmd.AddArg("environment", "production", false)
 	return mybase.ParseFakeCLI(t, cmd, "fstest")
 }
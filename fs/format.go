@@ -0,0 +1,1246 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// FormatCreateTable rewrites the supplied CREATE TABLE statement (which should
+// not include a trailing delimiter) to match the canonical format of SHOW
+// CREATE TABLE, as closely as possible without access to a database server.
+// Only syntactic normalizations are performed: keyword case, identifier
+// quoting, whitespace, synonym replacement, and ordering of clauses and
+// indexes. Anything which requires knowledge of server defaults, such as the
+// table's default character set or a column's default collation, is left as
+// specified. An error is returned if the statement uses syntax which the
+// formatter does not understand, in which case the statement should be left
+// as-is.
+func FormatCreateTable(statement string, flavor tengo.Flavor) (string, error) {
+	tokens, err := scanFormatTokens(statement)
+	if err != nil {
+		return "", err
+	}
+	ft := &formatTokens{tokens: tokens}
+	if !ft.acceptWords("CREATE", "TABLE") {
+		return "", errors.New("not a CREATE TABLE statement")
+	}
+	ft.acceptWords("IF", "NOT", "EXISTS")
+	t := &formatTable{flavor: flavor}
+	if t.name, err = ft.identifier(); err != nil {
+		return "", err
+	} else if ft.peek().symbol() == "." {
+		return "", errors.New("schema-qualified table names are not supported")
+	}
+	defs, err := ft.parenGroup()
+	if err != nil {
+		return "", err
+	}
+	for _, def := range splitFormatTokens(defs) {
+		if err := t.parseDefinition(&formatTokens{tokens: def}); err != nil {
+			return "", err
+		}
+	}
+	if err := t.parseOptions(ft); err != nil {
+		return "", err
+	}
+	t.addForeignKeyIndexes()
+	return t.String(), nil
+}
+
+///// Tokenization /////////////////////////////////////////////////////////////
+
+type formatTokenType int
+
+const (
+	formatTokenWord   formatTokenType = iota // keyword or unquoted identifier
+	formatTokenIdent                         // backtick-quoted identifier
+	formatTokenString                        // single- or double-quoted string
+	formatTokenNumber                        // numeric literal
+	formatTokenSymbol                        // operator or punctuation
+)
+
+// formatToken is a single token of a statement being formatted. For quoted
+// identifiers and strings, val is the unescaped value without quotes.
+type formatToken struct {
+	typ formatTokenType
+	val string
+}
+
+// is returns true if the token is an unquoted word matching any of the
+// supplied keywords, case-insensitively.
+func (tok formatToken) is(keywords ...string) bool {
+	if tok.typ != formatTokenWord {
+		return false
+	}
+	for _, kw := range keywords {
+		if strings.EqualFold(tok.val, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// symbol returns the token's value if it is a symbol, or an empty string
+// otherwise.
+func (tok formatToken) symbol() string {
+	if tok.typ != formatTokenSymbol {
+		return ""
+	}
+	return tok.val
+}
+
+// String returns the token in the quoting style used by SHOW CREATE.
+func (tok formatToken) String() string {
+	switch tok.typ {
+	case formatTokenIdent:
+		return tengo.EscapeIdentifier(tok.val)
+	case formatTokenString:
+		return quoteFormatString(tok.val)
+	}
+	return tok.val
+}
+
+func quoteFormatString(val string) string {
+	return fmt.Sprintf("'%s'", tengo.EscapeValueForCreateTable(val))
+}
+
+var (
+	formatWordRegexp   = regexp.MustCompile(`^[0-9a-zA-Z$_\x80-\xff]+`)
+	formatNumberRegexp = regexp.MustCompile(`^(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][-+]?[0-9]+)?`)
+	formatBitHexRegexp = regexp.MustCompile(`^[bBxX]'[0-9a-fA-F]*'`)
+	formatSymbols      = []string{"->>", "<=>", "->", "<>", "!=", "<=", ">=", ":=", "||", "&&", "<<", ">>"}
+)
+
+// scanFormatTokens splits a statement into tokens. Comments are not permitted,
+// since they could not be preserved in their original positions.
+func scanFormatTokens(input string) (tokens []formatToken, err error) {
+	for pos := 0; pos < len(input); {
+		rest := input[pos:]
+		c := rest[0]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c == '#' || strings.HasPrefix(rest, "/*") || (strings.HasPrefix(rest, "--") && (len(rest) == 2 || strings.ContainsAny(rest[2:3], " \t\n\r"))):
+			return nil, errors.New("comments are not supported")
+		case c == '`' || c == '\'' || c == '"':
+			val, length, err := unquoteFormatToken(rest)
+			if err != nil {
+				return nil, err
+			}
+			typ := formatTokenString
+			if c == '`' {
+				typ = formatTokenIdent
+			}
+			tokens = append(tokens, formatToken{typ: typ, val: val})
+			pos += length
+		case formatBitHexRegexp.MatchString(rest):
+			match := formatBitHexRegexp.FindString(rest)
+			tokens = append(tokens, formatToken{typ: formatTokenNumber, val: strings.ToLower(match[0:1]) + match[1:]})
+			pos += len(match)
+		default:
+			word := formatWordRegexp.FindString(rest)
+			number := formatNumberRegexp.FindString(rest)
+			if number != "" && len(number) >= len(word) {
+				tokens = append(tokens, formatToken{typ: formatTokenNumber, val: number})
+				pos += len(number)
+			} else if word != "" {
+				tokens = append(tokens, formatToken{typ: formatTokenWord, val: word})
+				pos += len(word)
+			} else {
+				symbol := rest[0:1]
+				for _, sym := range formatSymbols {
+					if strings.HasPrefix(rest, sym) {
+						symbol = sym
+						break
+					}
+				}
+				tokens = append(tokens, formatToken{typ: formatTokenSymbol, val: symbol})
+				pos += len(symbol)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// unquoteFormatToken parses the quoted identifier or string at the beginning
+// of input, returning its unescaped value and its length in input.
+func unquoteFormatToken(input string) (string, int, error) {
+	quote := input[0]
+	var b bytes.Buffer
+	for pos := 1; pos < len(input); pos++ {
+		c := input[pos]
+		if c == quote {
+			if pos+1 < len(input) && input[pos+1] == quote {
+				b.WriteByte(quote)
+				pos++
+				continue
+			}
+			return b.String(), pos + 1, nil
+		} else if c == '\\' && quote != '`' && pos+1 < len(input) {
+			pos++
+			switch input[pos] {
+			case '0':
+				b.WriteByte(0)
+			case 'b':
+				b.WriteByte('\b')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'Z':
+				b.WriteByte(26)
+			case '%', '_':
+				b.WriteByte('\\')
+				b.WriteByte(input[pos])
+			default:
+				b.WriteByte(input[pos])
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, fmt.Errorf("unterminated quoted value %s", input)
+}
+
+// splitFormatTokens splits tokens on commas which are not nested inside any
+// parentheses.
+func splitFormatTokens(tokens []formatToken) (result [][]formatToken) {
+	var depth, start int
+	for n, tok := range tokens {
+		if tok.typ != formatTokenSymbol {
+			continue
+		} else if tok.val == "(" {
+			depth++
+		} else if tok.val == ")" {
+			depth--
+		} else if tok.val == "," && depth == 0 {
+			result = append(result, tokens[start:n])
+			start = n + 1
+		}
+	}
+	return append(result, tokens[start:])
+}
+
+// joinFormatTokens re-joins tokens of an expression, using the spacing style of
+// SHOW CREATE: no spaces inside parens, around commas, or between a function
+// name and its args; single spaces everywhere else.
+func joinFormatTokens(tokens []formatToken) string {
+	var b bytes.Buffer
+	for n, tok := range tokens {
+		if n > 0 {
+			prev := tokens[n-1]
+			prevSym, curSym := prev.symbol(), tok.symbol()
+			if !(prevSym == "(" || prevSym == "," || prevSym == "." || curSym == ")" || curSym == "," || curSym == "." || (curSym == "(" && prev.typ == formatTokenWord)) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok.String())
+	}
+	return b.String()
+}
+
+///// Token stream /////////////////////////////////////////////////////////////
+
+// formatTokens is a stream of tokens being consumed by a parser.
+type formatTokens struct {
+	tokens []formatToken
+	pos    int
+}
+
+func (ft *formatTokens) done() bool {
+	return ft.pos >= len(ft.tokens)
+}
+
+// peek returns the next token without consuming it. If no tokens remain, an
+// empty symbol token is returned.
+func (ft *formatTokens) peek() formatToken {
+	if ft.done() {
+		return formatToken{typ: formatTokenSymbol}
+	}
+	return ft.tokens[ft.pos]
+}
+
+func (ft *formatTokens) next() formatToken {
+	tok := ft.peek()
+	ft.pos++
+	return tok
+}
+
+// acceptWords consumes the supplied sequence of keywords, if the stream's next
+// tokens match them; otherwise, nothing is consumed and false is returned.
+func (ft *formatTokens) acceptWords(keywords ...string) bool {
+	if ft.pos+len(keywords) > len(ft.tokens) {
+		return false
+	}
+	for n, kw := range keywords {
+		if !ft.tokens[ft.pos+n].is(kw) {
+			return false
+		}
+	}
+	ft.pos += len(keywords)
+	return true
+}
+
+// acceptSymbol consumes the supplied symbol if it is the next token.
+func (ft *formatTokens) acceptSymbol(symbol string) bool {
+	if ft.peek().symbol() == symbol && symbol != "" {
+		ft.pos++
+		return true
+	}
+	return false
+}
+
+// identifier consumes a quoted or unquoted identifier, returning its name.
+func (ft *formatTokens) identifier() (string, error) {
+	if tok := ft.next(); tok.typ == formatTokenWord || tok.typ == formatTokenIdent {
+		return tok.val, nil
+	}
+	return "", ft.unexpected()
+}
+
+// stringValue consumes a quoted string, returning its unescaped value.
+func (ft *formatTokens) stringValue() (string, error) {
+	if tok := ft.next(); tok.typ == formatTokenString {
+		return tok.val, nil
+	}
+	return "", ft.unexpected()
+}
+
+// parenGroup consumes a parenthesized group of tokens, returning the tokens
+// inside the outermost parens.
+func (ft *formatTokens) parenGroup() ([]formatToken, error) {
+	if !ft.acceptSymbol("(") {
+		return nil, ft.unexpected()
+	}
+	start := ft.pos
+	for depth := 1; !ft.done(); {
+		tok := ft.next()
+		if tok.typ == formatTokenSymbol && tok.val == "(" {
+			depth++
+		} else if tok.typ == formatTokenSymbol && tok.val == ")" {
+			if depth--; depth == 0 {
+				return ft.tokens[start : ft.pos-1], nil
+			}
+		}
+	}
+	return nil, errors.New("unbalanced parentheses")
+}
+
+// unexpected returns an error describing the token most recently consumed.
+func (ft *formatTokens) unexpected() error {
+	if ft.pos > len(ft.tokens) || len(ft.tokens) == 0 {
+		return errors.New("unexpected end of statement")
+	}
+	pos := ft.pos - 1
+	if pos < 0 {
+		pos = 0
+	}
+	return fmt.Errorf("unsupported syntax at %s", ft.tokens[pos])
+}
+
+///// Table definitions ////////////////////////////////////////////////////////
+
+// formatTable represents a CREATE TABLE statement being formatted.
+type formatTable struct {
+	flavor        tengo.Flavor
+	name          string
+	columns       []*formatColumn
+	indexes       []*formatIndex
+	foreignKeys   []*formatForeignKey
+	charSet       string
+	collation     string
+	options       map[string]string
+	unnamedFKs    int
+	hasPrimaryKey bool
+}
+
+// formatColumn represents a column definition being formatted.
+type formatColumn struct {
+	name          string
+	typ           string
+	charSet       string
+	collation     string
+	generated     string
+	nullable      bool
+	autoIncrement bool
+	defaultValue  string
+	onUpdate      string
+	comment       string
+}
+
+// Index kinds, in the order that SHOW CREATE TABLE displays them
+const (
+	formatIndexPrimary = iota
+	formatIndexUnique
+	formatIndexRegular
+	formatIndexFulltext
+)
+
+// formatIndex represents an index definition being formatted.
+type formatIndex struct {
+	kind         int
+	spatial      bool
+	name         string
+	columns      []string
+	parts        []string
+	partial      bool
+	using        string
+	keyBlockSize string
+	comment      string
+	invisible    bool
+}
+
+// formatForeignKey represents a foreign key constraint being formatted.
+type formatForeignKey struct {
+	name       string
+	autoNamed  bool
+	indexName  string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onDelete   string
+	onUpdate   string
+}
+
+// Data type synonyms, mapped to the type displayed by SHOW CREATE TABLE
+var formatTypeSynonyms = map[string]string{
+	"integer":   "int",
+	"bool":      "tinyint(1)",
+	"boolean":   "tinyint(1)",
+	"dec":       "decimal",
+	"numeric":   "decimal",
+	"fixed":     "decimal",
+	"real":      "double",
+	"character": "char",
+	"int1":      "tinyint",
+	"int2":      "smallint",
+	"int3":      "mediumint",
+	"int4":      "int",
+	"int8":      "bigint",
+	"middleint": "mediumint",
+	"float4":    "float",
+	"float8":    "double",
+}
+
+// Functions which are displayed as CURRENT_TIMESTAMP by SHOW CREATE TABLE
+var formatCurrentTimestampSynonyms = []string{"CURRENT_TIMESTAMP", "NOW", "LOCALTIME", "LOCALTIMESTAMP"}
+
+func (t *formatTable) parseDefinition(ft *formatTokens) error {
+	if ft.done() {
+		return errors.New("empty definition in column list")
+	}
+	if first := ft.peek(); first.is("CONSTRAINT", "PRIMARY", "UNIQUE", "KEY", "INDEX", "FULLTEXT", "SPATIAL", "FOREIGN", "CHECK") {
+		return t.parseIndexOrConstraint(ft)
+	}
+	return t.parseColumn(ft)
+}
+
+func (t *formatTable) parseColumn(ft *formatTokens) (err error) {
+	col := &formatColumn{nullable: true}
+	if col.name, err = ft.identifier(); err != nil {
+		return err
+	}
+	if err = col.parseType(ft); err != nil {
+		return err
+	}
+	t.columns = append(t.columns, col)
+	for !ft.done() {
+		switch {
+		case ft.acceptWords("NOT", "NULL"):
+			col.nullable = false
+		case ft.acceptWords("NULL"):
+			col.nullable = true
+		case ft.acceptWords("DEFAULT"):
+			if col.defaultValue, err = parseFormatDefault(ft); err != nil {
+				return err
+			}
+		case ft.acceptWords("AUTO_INCREMENT"):
+			col.autoIncrement = true
+		case ft.acceptWords("ON", "UPDATE"):
+			if col.onUpdate, err = parseFormatCurrentTimestamp(ft); err != nil {
+				return err
+			}
+		case ft.acceptWords("COMMENT"):
+			if col.comment, err = ft.stringValue(); err != nil {
+				return err
+			}
+		case ft.acceptWords("CHARACTER", "SET"), ft.acceptWords("CHARSET"):
+			if col.charSet, err = ft.identifier(); err != nil {
+				return err
+			}
+			col.charSet = strings.ToLower(col.charSet)
+		case ft.acceptWords("COLLATE"):
+			if col.collation, err = ft.identifier(); err != nil {
+				return err
+			}
+			col.collation = strings.ToLower(col.collation)
+		case ft.acceptWords("GENERATED", "ALWAYS", "AS"), ft.acceptWords("AS"):
+			expr, err := ft.parenGroup()
+			if err != nil {
+				return err
+			}
+			storage := "VIRTUAL"
+			if ft.acceptWords("STORED") || ft.acceptWords("PERSISTENT") {
+				storage = "STORED"
+			} else {
+				ft.acceptWords("VIRTUAL")
+			}
+			col.generated = fmt.Sprintf("GENERATED ALWAYS AS (%s) %s", joinFormatTokens(expr), storage)
+		case ft.acceptWords("PRIMARY", "KEY"), ft.acceptWords("KEY"):
+			col.nullable = false
+			idx := &formatIndex{kind: formatIndexPrimary}
+			idx.addColumn(col.name, "", false)
+			if err := t.addIndex(idx); err != nil {
+				return err
+			}
+		case ft.acceptWords("UNIQUE"):
+			ft.acceptWords("KEY")
+			idx := &formatIndex{kind: formatIndexUnique}
+			idx.addColumn(col.name, "", false)
+			if err := t.addIndex(idx); err != nil {
+				return err
+			}
+		default:
+			ft.next()
+			return ft.unexpected()
+		}
+	}
+	return col.normalize(t.flavor)
+}
+
+// Default display widths of integer types, used by flavors which display them.
+// The first value is for signed types, and the second for unsigned types.
+var formatIntDisplayWidths = map[string][2]int{
+	"tinyint":   {4, 3},
+	"smallint":  {6, 5},
+	"mediumint": {9, 8},
+	"int":       {11, 10},
+	"bigint":    {20, 20},
+}
+
+var formatNumericRegexp = regexp.MustCompile(`^'-?[0-9]+(\.[0-9]+)?'$`)
+
+// normalize adjusts the column's type and default value to match how SHOW
+// CREATE TABLE displays them in flavor. Integer display widths are added in
+// flavors which display them, and removed in MySQL 8.0+ except for tinyint(1)
+// and zerofill columns; if flavor is unknown, they are left as specified.
+// Decimal types always display their precision and scale, and decimal default
+// values are padded to the scale. MariaDB 10.2+ displays numeric default
+// values without quotes, and CURRENT_TIMESTAMP in lowercase with parentheses.
+// An error is returned if the column is NOT NULL but has a default of NULL,
+// since the server rejects this.
+func (col *formatColumn) normalize(flavor tengo.Flavor) error {
+	if !col.nullable && col.defaultValue == "NULL" {
+		return fmt.Errorf("column %s cannot be NOT NULL with DEFAULT NULL", tengo.EscapeIdentifier(col.name))
+	}
+	base, args, modifiers := splitFormatType(col.typ)
+	_, isInt := formatIntDisplayWidths[base]
+	if isInt && flavor.Known() {
+		zerofill := strings.Contains(modifiers, "zerofill")
+		if flavor.MySQLishMinVersion(8, 0) && !zerofill && args != "1" {
+			args = ""
+		} else if args == "" && (zerofill || !flavor.MySQLishMinVersion(8, 0)) {
+			widths := formatIntDisplayWidths[base]
+			if strings.Contains(modifiers, "unsigned") {
+				args = fmt.Sprintf("%d", widths[1])
+			} else {
+				args = fmt.Sprintf("%d", widths[0])
+			}
+		}
+	} else if base == "year" && flavor.Known() {
+		if flavor.MySQLishMinVersion(8, 0) {
+			args = ""
+		} else {
+			args = "4"
+		}
+	} else if base == "decimal" {
+		if args == "" {
+			args = "10,0"
+		} else if !strings.Contains(args, ",") {
+			args += ",0"
+		}
+		if formatNumericRegexp.MatchString(col.defaultValue) {
+			scale := args[strings.Index(args, ",")+1:]
+			padded, err := padFormatDecimal(col.defaultValue[1:len(col.defaultValue)-1], scale)
+			if err != nil {
+				return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(col.name), err)
+			}
+			col.defaultValue = quoteFormatString(padded)
+		}
+	}
+	col.typ = base
+	if args != "" {
+		col.typ += "(" + args + ")"
+	}
+	col.typ += modifiers
+
+	if flavor.AllowDefaultExpression() {
+		if (isInt || base == "decimal" || base == "float" || base == "double") && formatNumericRegexp.MatchString(col.defaultValue) {
+			col.defaultValue = col.defaultValue[1 : len(col.defaultValue)-1]
+		}
+		col.defaultValue = mariaCurrentTimestamp(col.defaultValue)
+		col.onUpdate = mariaCurrentTimestamp(col.onUpdate)
+	}
+	return nil
+}
+
+// splitFormatType splits a normalized column type, such as "int(10) unsigned",
+// into its base type, its args without parentheses, and any trailing modifiers
+// including their leading space.
+func splitFormatType(typ string) (base, args, modifiers string) {
+	base = typ
+	if pos := strings.IndexAny(typ, "( "); pos >= 0 {
+		base, modifiers = typ[:pos], typ[pos:]
+	}
+	if strings.HasPrefix(modifiers, "(") {
+		end := strings.LastIndex(modifiers, ")")
+		args, modifiers = modifiers[1:end], modifiers[end+1:]
+	}
+	return base, args, modifiers
+}
+
+// padFormatDecimal returns value, a decimal number, with exactly scale digits
+// after the decimal point. An error is returned if this would require rounding.
+func padFormatDecimal(value, scale string) (string, error) {
+	digits, err := strconv.Atoi(scale)
+	if err != nil {
+		return "", fmt.Errorf("unsupported decimal scale %s", scale)
+	}
+	whole, frac := value, ""
+	if pos := strings.IndexByte(value, '.'); pos >= 0 {
+		whole, frac = value[:pos], value[pos+1:]
+	}
+	if len(frac) > digits {
+		if strings.Trim(frac[digits:], "0") != "" {
+			return "", fmt.Errorf("default value %s would be rounded to scale %d", quoteFormatString(value), digits)
+		}
+		frac = frac[:digits]
+	}
+	if digits == 0 {
+		return whole, nil
+	}
+	return whole + "." + frac + strings.Repeat("0", digits-len(frac)), nil
+}
+
+// mariaCurrentTimestamp converts CURRENT_TIMESTAMP, with optional precision,
+// into the lowercase function-call form displayed by MariaDB 10.2+. Any other
+// value is returned as-is.
+func mariaCurrentTimestamp(value string) string {
+	if value == "CURRENT_TIMESTAMP" {
+		return "current_timestamp()"
+	} else if strings.HasPrefix(value, "CURRENT_TIMESTAMP(") {
+		return "current_timestamp" + value[len("CURRENT_TIMESTAMP"):]
+	}
+	return value
+}
+
+// parseType parses a column's data type, including any args and numeric
+// modifiers.
+func (col *formatColumn) parseType(ft *formatTokens) error {
+	tok := ft.next()
+	if tok.typ != formatTokenWord {
+		return ft.unexpected()
+	}
+	col.typ = strings.ToLower(tok.val)
+	if col.typ == "double" {
+		ft.acceptWords("PRECISION")
+	} else if col.typ == "character" && ft.acceptWords("VARYING") {
+		col.typ = "varchar"
+	}
+	if synonym, ok := formatTypeSynonyms[col.typ]; ok {
+		col.typ = synonym
+	}
+	if ft.peek().symbol() == "(" {
+		args, err := ft.parenGroup()
+		if err != nil {
+			return err
+		}
+		for _, arg := range args {
+			if arg.typ != formatTokenNumber && arg.typ != formatTokenString && arg.symbol() != "," {
+				return fmt.Errorf("unsupported syntax at %s", arg)
+			}
+		}
+		col.typ = fmt.Sprintf("%s(%s)", col.typ, joinFormatTokens(args))
+	}
+	var unsigned, zerofill bool
+	for {
+		if ft.acceptWords("UNSIGNED") {
+			unsigned = true
+		} else if ft.acceptWords("ZEROFILL") {
+			unsigned, zerofill = true, true
+		} else if !ft.acceptWords("SIGNED") {
+			break
+		}
+	}
+	if unsigned {
+		col.typ += " unsigned"
+	}
+	if zerofill {
+		col.typ += " zerofill"
+	}
+	return nil
+}
+
+// Definition returns the column's definition clause in SHOW CREATE TABLE style.
+func (col *formatColumn) Definition(t *formatTable) string {
+	parts := []string{tengo.EscapeIdentifier(col.name), col.typ}
+	if col.charSet != "" && (col.charSet != t.charSet || col.collation != t.collation) {
+		parts = append(parts, "CHARACTER SET "+col.charSet)
+	}
+	if col.collation != "" {
+		parts = append(parts, "COLLATE "+col.collation)
+	}
+	if col.generated != "" {
+		parts = append(parts, col.generated)
+	}
+	if !col.nullable {
+		parts = append(parts, "NOT NULL")
+	} else if strings.HasPrefix(col.typ, "timestamp") {
+		parts = append(parts, "NULL")
+	}
+	if col.autoIncrement {
+		parts = append(parts, "AUTO_INCREMENT")
+	} else if col.defaultValue != "" {
+		parts = append(parts, "DEFAULT "+col.defaultValue)
+	} else if col.nullable && col.generated == "" && (t.flavor.AllowBlobDefaults() || (!strings.HasSuffix(col.typ, "blob") && !strings.HasSuffix(col.typ, "text"))) {
+		parts = append(parts, "DEFAULT NULL")
+	}
+	if col.onUpdate != "" {
+		parts = append(parts, "ON UPDATE "+col.onUpdate)
+	}
+	if col.comment != "" {
+		parts = append(parts, "COMMENT "+quoteFormatString(col.comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseFormatDefault parses the value of a column's DEFAULT clause.
+func parseFormatDefault(ft *formatTokens) (string, error) {
+	tok := ft.peek()
+	switch {
+	case tok.typ == formatTokenSymbol && tok.val == "(":
+		expr, err := ft.parenGroup()
+		return "(" + joinFormatTokens(expr) + ")", err
+	case tok.typ == formatTokenSymbol && (tok.val == "-" || tok.val == "+"):
+		ft.next()
+		if num := ft.next(); num.typ == formatTokenNumber {
+			return quoteFormatString(strings.TrimPrefix(tok.val, "+") + num.val), nil
+		}
+		return "", ft.unexpected()
+	case tok.typ == formatTokenNumber && strings.Contains(tok.val, "'"): // b'...' or x'...'
+		ft.next()
+		return tok.val, nil
+	case tok.typ == formatTokenNumber || tok.typ == formatTokenString:
+		ft.next()
+		return quoteFormatString(tok.val), nil
+	case tok.is("NULL"):
+		ft.next()
+		return "NULL", nil
+	case tok.is("TRUE"):
+		ft.next()
+		return "'1'", nil
+	case tok.is("FALSE"):
+		ft.next()
+		return "'0'", nil
+	}
+	return parseFormatCurrentTimestamp(ft)
+}
+
+// parseFormatCurrentTimestamp parses CURRENT_TIMESTAMP or any of its synonyms,
+// with optional fractional precision.
+func parseFormatCurrentTimestamp(ft *formatTokens) (string, error) {
+	if tok := ft.next(); !tok.is(formatCurrentTimestampSynonyms...) {
+		return "", ft.unexpected()
+	} else if ft.peek().symbol() != "(" {
+		if tok.is("NOW") {
+			return "", ft.unexpected()
+		}
+		return "CURRENT_TIMESTAMP", nil
+	}
+	args, err := ft.parenGroup()
+	if err != nil {
+		return "", err
+	} else if len(args) == 0 || (len(args) == 1 && args[0].val == "0") {
+		return "CURRENT_TIMESTAMP", nil
+	} else if len(args) == 1 && args[0].typ == formatTokenNumber {
+		return fmt.Sprintf("CURRENT_TIMESTAMP(%s)", args[0].val), nil
+	}
+	return "", fmt.Errorf("unsupported syntax at %s", args[0])
+}
+
+func (t *formatTable) parseIndexOrConstraint(ft *formatTokens) (err error) {
+	var constraintName string
+	if ft.acceptWords("CONSTRAINT") && !ft.peek().is("PRIMARY", "UNIQUE", "FOREIGN", "CHECK") {
+		if constraintName, err = ft.identifier(); err != nil {
+			return err
+		}
+	}
+	idx := &formatIndex{kind: formatIndexRegular}
+	switch {
+	case ft.acceptWords("PRIMARY", "KEY"):
+		idx.kind = formatIndexPrimary
+	case ft.acceptWords("UNIQUE"):
+		idx.kind = formatIndexUnique
+		idx.name = constraintName
+		_ = ft.acceptWords("KEY") || ft.acceptWords("INDEX")
+	case ft.acceptWords("FULLTEXT"), ft.acceptWords("SPATIAL"):
+		idx.kind = formatIndexFulltext
+		if idx.spatial = ft.tokens[ft.pos-1].is("SPATIAL"); idx.spatial {
+			idx.kind = formatIndexRegular
+		}
+		_ = ft.acceptWords("KEY") || ft.acceptWords("INDEX")
+	case ft.acceptWords("KEY"), ft.acceptWords("INDEX"):
+	case ft.acceptWords("FOREIGN", "KEY"):
+		return t.parseForeignKey(ft, constraintName)
+	default:
+		ft.next()
+		return ft.unexpected()
+	}
+
+	// Index name is optional, and ignored for primary keys
+	if next := ft.peek(); next.symbol() != "(" && !next.is("USING") {
+		if idx.name, err = ft.identifier(); err != nil {
+			return err
+		}
+	}
+	if err := idx.parseOptions(ft); err != nil {
+		return err
+	}
+	parts, err := ft.parenGroup()
+	if err != nil {
+		return err
+	}
+	for _, part := range splitFormatTokens(parts) {
+		partTokens := &formatTokens{tokens: part}
+		name, err := partTokens.identifier()
+		if err != nil {
+			return err
+		}
+		var subPart string
+		if partTokens.peek().symbol() == "(" {
+			args, err := partTokens.parenGroup()
+			if err != nil {
+				return err
+			} else if len(args) != 1 || args[0].typ != formatTokenNumber {
+				return errors.New("unsupported index prefix length")
+			}
+			subPart = args[0].val
+		}
+		desc := partTokens.acceptWords("DESC")
+		if !desc {
+			partTokens.acceptWords("ASC")
+		}
+		if !partTokens.done() {
+			partTokens.next()
+			return partTokens.unexpected()
+		}
+		idx.addColumn(name, subPart, desc)
+	}
+	if err := idx.parseOptions(ft); err != nil {
+		return err
+	} else if !ft.done() {
+		ft.next()
+		return ft.unexpected()
+	}
+	return t.addIndex(idx)
+}
+
+// parseOptions parses any index options, which may appear either before or
+// after the index's column list.
+func (idx *formatIndex) parseOptions(ft *formatTokens) (err error) {
+	for {
+		switch {
+		case ft.acceptWords("USING"):
+			tok := ft.next()
+			if !tok.is("BTREE", "HASH") {
+				return ft.unexpected()
+			}
+			idx.using = strings.ToUpper(tok.val)
+		case ft.acceptWords("COMMENT"):
+			if idx.comment, err = ft.stringValue(); err != nil {
+				return err
+			}
+		case ft.acceptWords("KEY_BLOCK_SIZE"):
+			ft.acceptSymbol("=")
+			if tok := ft.next(); tok.typ == formatTokenNumber {
+				idx.keyBlockSize = tok.val
+			} else {
+				return ft.unexpected()
+			}
+		case ft.acceptWords("VISIBLE"):
+			idx.invisible = false
+		case ft.acceptWords("INVISIBLE"):
+			idx.invisible = true
+		default:
+			return nil
+		}
+	}
+}
+
+func (idx *formatIndex) addColumn(name, subPart string, desc bool) {
+	part := tengo.EscapeIdentifier(name)
+	if subPart != "" {
+		part += "(" + subPart + ")"
+		idx.partial = true
+	}
+	if desc {
+		part += " DESC"
+	}
+	idx.columns = append(idx.columns, name)
+	idx.parts = append(idx.parts, part)
+}
+
+// Definition returns the index's definition clause in SHOW CREATE TABLE style.
+func (idx *formatIndex) Definition() string {
+	var def string
+	switch idx.kind {
+	case formatIndexPrimary:
+		def = "PRIMARY KEY"
+	case formatIndexUnique:
+		def = "UNIQUE KEY " + tengo.EscapeIdentifier(idx.name)
+	case formatIndexFulltext:
+		def = "FULLTEXT KEY " + tengo.EscapeIdentifier(idx.name)
+	default:
+		if idx.spatial {
+			def = "SPATIAL "
+		}
+		def += "KEY " + tengo.EscapeIdentifier(idx.name)
+	}
+	def += " (" + strings.Join(idx.parts, ",") + ")"
+	if idx.using != "" {
+		def += " USING " + idx.using
+	}
+	if idx.keyBlockSize != "" {
+		def += " KEY_BLOCK_SIZE=" + idx.keyBlockSize
+	}
+	if idx.comment != "" {
+		def += " COMMENT " + quoteFormatString(idx.comment)
+	}
+	if idx.invisible {
+		def += " /*!80000 INVISIBLE */"
+	}
+	return def
+}
+
+// addIndex adds idx to the table, generating a name for it if needed, in the
+// same manner as MySQL.
+func (t *formatTable) addIndex(idx *formatIndex) error {
+	if idx.kind == formatIndexPrimary {
+		if t.hasPrimaryKey {
+			return errors.New("multiple primary keys defined")
+		}
+		t.hasPrimaryKey = true
+		idx.name = "PRIMARY"
+	} else if idx.name == "" {
+		idx.name = idx.columns[0]
+		for n := 2; t.hasIndexNamed(idx.name); n++ {
+			idx.name = fmt.Sprintf("%s_%d", idx.columns[0], n)
+		}
+	}
+	t.indexes = append(t.indexes, idx)
+	return nil
+}
+
+func (t *formatTable) hasIndexNamed(name string) bool {
+	for _, idx := range t.indexes {
+		if strings.EqualFold(idx.name, name) {
+			return true
+		}
+	}
+	return strings.EqualFold(name, "PRIMARY")
+}
+
+func (t *formatTable) parseForeignKey(ft *formatTokens, constraintName string) (err error) {
+	fk := &formatForeignKey{name: constraintName}
+	if ft.peek().symbol() != "(" {
+		if fk.indexName, err = ft.identifier(); err != nil {
+			return err
+		}
+	}
+	if fk.columns, err = parseFormatIdentList(ft); err != nil {
+		return err
+	}
+	if !ft.acceptWords("REFERENCES") {
+		ft.next()
+		return ft.unexpected()
+	}
+	if fk.refTable, err = ft.identifier(); err != nil {
+		return err
+	}
+	fk.refTable = tengo.EscapeIdentifier(fk.refTable)
+	if ft.acceptSymbol(".") {
+		refTable, err := ft.identifier()
+		if err != nil {
+			return err
+		}
+		fk.refTable += "." + tengo.EscapeIdentifier(refTable)
+	}
+	if fk.refColumns, err = parseFormatIdentList(ft); err != nil {
+		return err
+	} else if len(fk.refColumns) != len(fk.columns) {
+		return errors.New("foreign key column counts do not match")
+	}
+	for !ft.done() {
+		var rule *string
+		if ft.acceptWords("ON", "DELETE") {
+			rule = &fk.onDelete
+		} else if ft.acceptWords("ON", "UPDATE") {
+			rule = &fk.onUpdate
+		} else {
+			ft.next()
+			return ft.unexpected()
+		}
+		switch {
+		case ft.acceptWords("RESTRICT"):
+			*rule = "RESTRICT"
+		case ft.acceptWords("CASCADE"):
+			*rule = "CASCADE"
+		case ft.acceptWords("SET", "NULL"):
+			*rule = "SET NULL"
+		case ft.acceptWords("SET", "DEFAULT"):
+			*rule = "SET DEFAULT"
+		case ft.acceptWords("NO", "ACTION"):
+			*rule = "NO ACTION"
+		default:
+			ft.next()
+			return ft.unexpected()
+		}
+	}
+	if fk.name == "" {
+		fk.autoNamed = true
+		for fk.name == "" || t.hasForeignKeyNamed(fk.name) {
+			t.unnamedFKs++
+			fk.name = fmt.Sprintf("%s_ibfk_%d", t.name, t.unnamedFKs)
+		}
+	}
+	t.foreignKeys = append(t.foreignKeys, fk)
+	return nil
+}
+
+func (t *formatTable) hasForeignKeyNamed(name string) bool {
+	for _, fk := range t.foreignKeys {
+		if strings.EqualFold(fk.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFormatIdentList parses a parenthesized list of identifiers.
+func parseFormatIdentList(ft *formatTokens) (names []string, err error) {
+	tokens, err := ft.parenGroup()
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range splitFormatTokens(tokens) {
+		if len(part) != 1 || (part[0].typ != formatTokenWord && part[0].typ != formatTokenIdent) {
+			return nil, errors.New("unsupported syntax in column list")
+		}
+		names = append(names, part[0].val)
+	}
+	return names, nil
+}
+
+// Definition returns the foreign key's definition clause in SHOW CREATE TABLE
+// style.
+func (fk *formatForeignKey) Definition(flavor tengo.Flavor) string {
+	cols := make([]string, len(fk.columns))
+	for n, col := range fk.columns {
+		cols[n] = tengo.EscapeIdentifier(col)
+	}
+	refCols := make([]string, len(fk.refColumns))
+	for n, col := range fk.refColumns {
+		refCols[n] = tengo.EscapeIdentifier(col)
+	}
+	def := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)", tengo.EscapeIdentifier(fk.name), strings.Join(cols, ", "), fk.refTable, strings.Join(refCols, ", "))
+	// Mirror the display logic in tengo.ForeignKey.Definition
+	for _, rule := range []struct{ clause, value string }{{"ON DELETE", fk.onDelete}, {"ON UPDATE", fk.onUpdate}} {
+		if rule.value != "" && rule.value != "RESTRICT" && (rule.value != "NO ACTION" || !flavor.HasDataDictionary()) {
+			def += fmt.Sprintf(" %s %s", rule.clause, rule.value)
+		}
+	}
+	return def
+}
+
+///// Table options ////////////////////////////////////////////////////////////
+
+// Table options in the order displayed by SHOW CREATE TABLE
+var formatTableOptions = []string{
+	"ENGINE", "AUTO_INCREMENT", "DEFAULT CHARSET", "COLLATE", "MIN_ROWS", "MAX_ROWS",
+	"AVG_ROW_LENGTH", "PACK_KEYS", "STATS_PERSISTENT", "STATS_AUTO_RECALC",
+	"STATS_SAMPLE_PAGES", "CHECKSUM", "DELAY_KEY_WRITE", "ROW_FORMAT",
+	"KEY_BLOCK_SIZE", "COMPRESSION", "ENCRYPTION", "COMMENT",
+}
+
+// Storage engine names, keyed by lowercase name
+var formatEngineNames = map[string]string{
+	"innodb":     "InnoDB",
+	"myisam":     "MyISAM",
+	"memory":     "MEMORY",
+	"heap":       "MEMORY",
+	"csv":        "CSV",
+	"archive":    "ARCHIVE",
+	"blackhole":  "BLACKHOLE",
+	"merge":      "MRG_MYISAM",
+	"mrg_myisam": "MRG_MYISAM",
+	"federated":  "FEDERATED",
+	"aria":       "Aria",
+	"rocksdb":    "ROCKSDB",
+	"tokudb":     "TokuDB",
+}
+
+func (t *formatTable) parseOptions(ft *formatTokens) error {
+	t.options = make(map[string]string)
+	for !ft.done() {
+		if ft.acceptSymbol(",") {
+			continue
+		}
+		ft.acceptWords("DEFAULT")
+		var name string
+		switch {
+		case ft.acceptWords("CHARACTER", "SET"), ft.acceptWords("CHARSET"):
+			name = "DEFAULT CHARSET"
+		case ft.acceptWords("COLLATE"):
+			name = "COLLATE"
+		default:
+			tok := ft.next()
+			if !tok.is(formatTableOptions...) {
+				return ft.unexpected()
+			}
+			name = strings.ToUpper(tok.val)
+		}
+		ft.acceptSymbol("=")
+		tok := ft.next()
+		if tok.typ == formatTokenSymbol {
+			return ft.unexpected()
+		}
+		value := tok.val
+		switch name {
+		case "ENGINE":
+			if engine, ok := formatEngineNames[strings.ToLower(value)]; ok {
+				value = engine
+			}
+		case "DEFAULT CHARSET":
+			t.charSet = strings.ToLower(value)
+			value = t.charSet
+		case "COLLATE":
+			t.collation = strings.ToLower(value)
+			value = t.collation
+		case "COMMENT", "COMPRESSION", "ENCRYPTION":
+			if tok.typ != formatTokenString {
+				return ft.unexpected()
+			}
+			value = quoteFormatString(value)
+		default:
+			value = strings.ToUpper(value)
+		}
+		t.options[name] = fmt.Sprintf("%s=%s", name, value)
+	}
+	return nil
+}
+
+// String returns the full CREATE TABLE statement in SHOW CREATE TABLE style.
+func (t *formatTable) String() string {
+	defs := make([]string, 0, len(t.columns)+len(t.indexes)+len(t.foreignKeys))
+	for _, col := range t.columns {
+		defs = append(defs, col.Definition(t))
+	}
+	sort.SliceStable(t.indexes, func(i, j int) bool {
+		return t.indexRank(t.indexes[i]) < t.indexRank(t.indexes[j])
+	})
+	for _, idx := range t.indexes {
+		defs = append(defs, idx.Definition())
+	}
+	sort.SliceStable(t.foreignKeys, func(i, j int) bool {
+		return t.foreignKeys[i].name < t.foreignKeys[j].name
+	})
+	for _, fk := range t.foreignKeys {
+		defs = append(defs, fk.Definition(t.flavor))
+	}
+	var options string
+	for _, name := range formatTableOptions {
+		if opt, ok := t.options[name]; ok {
+			options += " " + opt
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)%s", tengo.EscapeIdentifier(t.name), strings.Join(defs, ",\n  "), options)
+}
+
+// indexRank returns a sort key for idx, mirroring the order in which MySQL
+// stores indexes: primary key first, then unique indexes (those without
+// nullable columns first, then those without prefix lengths), then regular
+// indexes, and finally fulltext indexes.
+func (t *formatTable) indexRank(idx *formatIndex) int {
+	switch idx.kind {
+	case formatIndexPrimary:
+		return 0
+	case formatIndexUnique:
+		rank := 1
+		for _, colName := range idx.columns {
+			if col := t.column(colName); col == nil || col.nullable {
+				rank += 2
+				break
+			}
+		}
+		if idx.partial {
+			rank++
+		}
+		return rank
+	case formatIndexFulltext:
+		return 6
+	}
+	return 5
+}
+
+func (t *formatTable) column(name string) *formatColumn {
+	for _, col := range t.columns {
+		if strings.EqualFold(col.name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// addForeignKeyIndexes adds an index for each foreign key whose columns are not
+// already the leftmost columns of some other index, since MySQL creates these
+// automatically.
+func (t *formatTable) addForeignKeyIndexes() {
+	for _, fk := range t.foreignKeys {
+		if t.hasIndexCovering(fk.columns) {
+			continue
+		}
+		idx := &formatIndex{kind: formatIndexRegular, name: fk.indexName}
+		if idx.name == "" && !fk.autoNamed {
+			idx.name = fk.name
+		}
+		for _, col := range fk.columns {
+			idx.addColumn(col, "", false)
+		}
+		t.addIndex(idx)
+	}
+}
+
+func (t *formatTable) hasIndexCovering(columns []string) bool {
+Outer:
+	for _, idx := range t.indexes {
+		if idx.kind == formatIndexFulltext || idx.spatial || idx.partial || len(idx.columns) < len(columns) {
+			continue
+		}
+		for n := range columns {
+			if !strings.EqualFold(idx.columns[n], columns[n]) {
+				continue Outer
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestFormatCreateTable(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"create table users (id int)",
+			"CREATE TABLE `users` (\n  `id` int DEFAULT NULL\n)",
+		},
+		{
+			"CREATE TABLE IF NOT EXISTS `users` (\n\tid INTEGER UNSIGNED NOT NULL AUTO_INCREMENT,\n\tname VARCHAR(40) CHARACTER SET utf8mb4 not null default \"it's\",\n\tcreated_at TIMESTAMP DEFAULT NOW() ON UPDATE current_timestamp,\n\tbio text,\n\tscore dec(5,2) zerofill default 1.5 comment 'a \\\\ b',\n\tPRIMARY KEY (id)\n) engine=innodb charset utf8mb4 comment='hi'",
+			"CREATE TABLE `users` (\n  `id` int unsigned NOT NULL AUTO_INCREMENT,\n  `name` varchar(40) NOT NULL DEFAULT 'it''s',\n  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n  `bio` text,\n  `score` decimal(5,2) unsigned zerofill DEFAULT '1.50' COMMENT 'a \\\\ b',\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='hi'",
+		},
+		{
+			// Indexes are reordered: PK, unique without nullable cols, other unique, regular, fulltext
+			"CREATE TABLE t (a int NOT NULL, b int, c varchar(100) NOT NULL, FULLTEXT (c), INDEX idx_b (b, c(10) DESC) USING BTREE COMMENT 'b', UNIQUE (b), UNIQUE KEY (a), KEY (a), a2 bool, id bigint primary key)",
+			"CREATE TABLE `t` (\n  `a` int NOT NULL,\n  `b` int DEFAULT NULL,\n  `c` varchar(100) NOT NULL,\n  `a2` tinyint(1) DEFAULT NULL,\n  `id` bigint NOT NULL,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `a` (`a`),\n  UNIQUE KEY `b` (`b`),\n  KEY `idx_b` (`b`,`c`(10) DESC) USING BTREE COMMENT 'b',\n  KEY `a_2` (`a`),\n  FULLTEXT KEY `c` (`c`)\n)",
+		},
+		{
+			// Foreign keys are sorted by name, and get an index if no existing index covers them
+			"CREATE TABLE posts (id int NOT NULL, user_id int NOT NULL, org_id int, KEY (user_id), CONSTRAINT zz FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE RESTRICT ON UPDATE CASCADE, FOREIGN KEY (org_id) REFERENCES other.orgs (id) ON DELETE SET NULL) ROW_FORMAT=compressed KEY_BLOCK_SIZE 8 DEFAULT COLLATE utf8mb4_BIN",
+			"CREATE TABLE `posts` (\n  `id` int NOT NULL,\n  `user_id` int NOT NULL,\n  `org_id` int DEFAULT NULL,\n  KEY `user_id` (`user_id`),\n  KEY `org_id` (`org_id`),\n  CONSTRAINT `posts_ibfk_1` FOREIGN KEY (`org_id`) REFERENCES `other`.`orgs` (`id`) ON DELETE SET NULL,\n  CONSTRAINT `zz` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE\n) COLLATE=utf8mb4_bin ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8",
+		},
+		{
+			"CREATE TABLE `e` (`v` ENUM(\"a\", 'b''s') NOT NULL DEFAULT 'a', `bits` BIT(4) DEFAULT b'0101', ts DATETIME(3) DEFAULT current_timestamp(3), j json, g int AS (`bits` + 1) STORED)",
+			"CREATE TABLE `e` (\n  `v` enum('a','b''s') NOT NULL DEFAULT 'a',\n  `bits` bit(4) DEFAULT b'0101',\n  `ts` datetime(3) DEFAULT CURRENT_TIMESTAMP(3),\n  `j` json DEFAULT NULL,\n  `g` int GENERATED ALWAYS AS (`bits` + 1) STORED\n)",
+		},
+	}
+	for _, c := range cases {
+		actual, err := FormatCreateTable(c.input, tengo.FlavorUnknown)
+		if err != nil {
+			t.Errorf("Unexpected error formatting %q: %v", c.input, err)
+		} else if actual != c.expected {
+			t.Errorf("Unexpected result formatting %q:\nexpected:\n%s\nfound:\n%s", c.input, c.expected, actual)
+		}
+		// Formatting should be idempotent
+		if again, err := FormatCreateTable(actual, tengo.FlavorUnknown); err != nil || again != actual {
+			t.Errorf("Formatting output of %q again unexpectedly changed it: %q, %v", c.input, again, err)
+		}
+	}
+
+	// NO ACTION is only displayed in flavors without a data dictionary
+	input := "CREATE TABLE t (pid int, CONSTRAINT fk FOREIGN KEY (pid) REFERENCES p (id) ON DELETE NO ACTION)"
+	if actual, _ := FormatCreateTable(input, tengo.FlavorMySQL57); !strings.Contains(actual, "ON DELETE NO ACTION") {
+		t.Errorf("Expected NO ACTION to be displayed with flavor %s, instead found %s", tengo.FlavorMySQL57, actual)
+	}
+	if actual, _ := FormatCreateTable(input, tengo.FlavorMySQL80); strings.Contains(actual, "NO ACTION") {
+		t.Errorf("Expected NO ACTION to be omitted with flavor %s, instead found %s", tengo.FlavorMySQL80, actual)
+	}
+
+	// Column types and defaults are displayed differently depending on flavor
+	input = "CREATE TABLE t (id int unsigned NOT NULL, a int DEFAULT 5, b bigint unsigned, c tinyint(1), d tinyint, e decimal(5) DEFAULT '-2', f text, g timestamp(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3), h int(4) zerofill)"
+	flavorCases := map[tengo.Flavor]string{
+		tengo.FlavorMySQL57: "CREATE TABLE `t` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `a` int(11) DEFAULT '5',\n" +
+			"  `b` bigint(20) unsigned DEFAULT NULL,\n" +
+			"  `c` tinyint(1) DEFAULT NULL,\n" +
+			"  `d` tinyint(4) DEFAULT NULL,\n" +
+			"  `e` decimal(5,0) DEFAULT '-2',\n" +
+			"  `f` text,\n" +
+			"  `g` timestamp(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+			"  `h` int(4) unsigned zerofill DEFAULT NULL\n" +
+			")",
+		tengo.FlavorMySQL80: "CREATE TABLE `t` (\n" +
+			"  `id` int unsigned NOT NULL,\n" +
+			"  `a` int DEFAULT '5',\n" +
+			"  `b` bigint unsigned DEFAULT NULL,\n" +
+			"  `c` tinyint(1) DEFAULT NULL,\n" +
+			"  `d` tinyint DEFAULT NULL,\n" +
+			"  `e` decimal(5,0) DEFAULT '-2',\n" +
+			"  `f` text,\n" +
+			"  `g` timestamp(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+			"  `h` int(4) unsigned zerofill DEFAULT NULL\n" +
+			")",
+		tengo.FlavorMariaDB102: "CREATE TABLE `t` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `a` int(11) DEFAULT 5,\n" +
+			"  `b` bigint(20) unsigned DEFAULT NULL,\n" +
+			"  `c` tinyint(1) DEFAULT NULL,\n" +
+			"  `d` tinyint(4) DEFAULT NULL,\n" +
+			"  `e` decimal(5,0) DEFAULT -2,\n" +
+			"  `f` text DEFAULT NULL,\n" +
+			"  `g` timestamp(3) NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3),\n" +
+			"  `h` int(4) unsigned zerofill DEFAULT NULL\n" +
+			")",
+	}
+	for flavor, expected := range flavorCases {
+		actual, err := FormatCreateTable(input, flavor)
+		if err != nil {
+			t.Errorf("Unexpected error formatting with flavor %s: %v", flavor, err)
+		} else if actual != expected {
+			t.Errorf("Unexpected result formatting with flavor %s:\nexpected:\n%s\nfound:\n%s", flavor, expected, actual)
+		} else if again, err := FormatCreateTable(actual, flavor); err != nil || again != actual {
+			t.Errorf("Formatting with flavor %s is not idempotent: second pass returned %q, %v", flavor, again, err)
+		}
+	}
+
+	// Unsupported syntax should return an error
+	badInputs := []string{
+		"CREATE PROCEDURE p() SELECT 1",
+		"CREATE TABLE t LIKE u",
+		"CREATE TABLE db.t (id int)",
+		"CREATE TABLE t (id int) -- comment",
+		"CREATE TABLE t (id int /* comment */)",
+		"CREATE TABLE t (id int, CHECK (id > 0))",
+		"CREATE TABLE t (id int REFERENCES u (id))",
+		"CREATE TABLE t (id int, PRIMARY KEY (id), PRIMARY KEY (id))",
+		"CREATE TABLE t (id int) PARTITION BY HASH (id)",
+		"CREATE TABLE t (id int DEFAULT foo)",
+		"CREATE TABLE t (name varchar(10) DEFAULT 'oops)",
+		"CREATE TABLE t (id int",
+		"CREATE TABLE t (id int, KEY ((id + 1)))",
+		"CREATE TABLE t (id int, FOREIGN KEY (id) REFERENCES u (a, b))",
+		"CREATE TABLE t (id int DEFAULT NULL NOT NULL)",
+		"CREATE TABLE t (price decimal(5,2) DEFAULT 1.505)",
+	}
+	for _, input := range badInputs {
+		if actual, err := FormatCreateTable(input, tengo.FlavorUnknown); err == nil {
+			t.Errorf("Expected error formatting %q, but instead returned %q", input, actual)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/skeema/tengo"
+)
+
+// SnapshotObject records the state of a single database object at the time a
+// snapshot was taken.
+type SnapshotObject struct {
+	Type      tengo.ObjectType `json:"type"`
+	Name      string           `json:"name"`
+	Checksum  string           `json:"checksum"`
+	Statement string           `json:"statement,omitempty"` // only recorded for objects from *.sql files
+}
+
+// ObjectKey returns a tengo.ObjectKey for the object.
+func (obj SnapshotObject) ObjectKey() tengo.ObjectKey {
+	return tengo.ObjectKey{Type: obj.Type, Name: obj.Name}
+}
+
+// SnapshotDir records the state of a single schema directory, and optionally
+// the live state of the schemas it maps to.
+type SnapshotDir struct {
+	Objects []SnapshotObject            `json:"objects"`
+	Live    map[string][]SnapshotObject `json:"live,omitempty"` // keyed by "instance/schema"
+}
+
+// Snapshot is a named record of the state of a directory tree of schema
+// definitions.
+type Snapshot struct {
+	Name    string                  `json:"name"`
+	Created time.Time               `json:"created"`
+	Dirs    map[string]*SnapshotDir `json:"dirs"` // keyed by dir path relative to the snapshot file's dir
+}
+
+// NewSnapshot returns an empty snapshot with the supplied name.
+func NewSnapshot(name string) *Snapshot {
+	return &Snapshot{
+		Name:    name,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Dirs:    make(map[string]*SnapshotDir),
+	}
+}
+
+// SnapshotFile is a file containing a list of snapshots, in JSON format.
+type SnapshotFile struct {
+	Path      string
+	Snapshots []*Snapshot
+}
+
+// ReadSnapshotFile parses the snapshot file at filePath. If the file does not
+// exist, an empty SnapshotFile is returned, without an error.
+func ReadSnapshotFile(filePath string) (*SnapshotFile, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sf := &SnapshotFile{Path: absPath}
+	contents, err := ioutil.ReadFile(absPath)
+	if os.IsNotExist(err) {
+		return sf, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contents, &sf.Snapshots); err != nil {
+		return nil, fmt.Errorf("Unable to parse snapshot file %s: %s", absPath, err)
+	}
+	return sf, nil
+}
+
+// Snapshot returns the snapshot with the supplied name, or nil if there is no
+// such snapshot in the file.
+func (sf *SnapshotFile) Snapshot(name string) *Snapshot {
+	for _, snap := range sf.Snapshots {
+		if snap.Name == name {
+			return snap
+		}
+	}
+	return nil
+}
+
+// Add appends snap to the file's list of snapshots. The file is not written.
+// An error is returned if a snapshot with the same name already exists, since
+// snapshots are intended to be immutable.
+func (sf *SnapshotFile) Add(snap *Snapshot) error {
+	if sf.Snapshot(snap.Name) != nil {
+		return fmt.Errorf("A snapshot named %s already exists in %s", snap.Name, sf.Path)
+	}
+	sf.Snapshots = append(sf.Snapshots, snap)
+	return nil
+}
+
+// Write persists the file, overwriting any existing contents.
+func (sf *SnapshotFile) Write() error {
+	contents, err := json.MarshalIndent(sf.Snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sf.Path, append(contents, '\n'), 0666)
+}
+
+// DirKey returns the key used for dir in snapshots stored in this file: the
+// dir's path relative to the directory containing the file, using forward
+// slashes on all operating systems.
+func (sf *SnapshotFile) DirKey(dir *Dir) string {
+	rel, err := filepath.Rel(filepath.Dir(sf.Path), dir.Path)
+	if err != nil {
+		return filepath.ToSlash(dir.Path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// AddDir records the object definitions in dir's *.sql files. Only the dir's
+// unnamed logical schema is recorded, consistent with `skeema pull`. Objects
+// matching the dir's ignore options are not recorded.
+func (snap *Snapshot) AddDir(key string, dir *Dir) (*SnapshotDir, error) {
+	ignoreOpts, err := dir.IgnoreOptions()
+	if err != nil {
+		return nil, err
+	}
+	sd := &SnapshotDir{Objects: []SnapshotObject{}}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		if logicalSchema.Name != "" {
+			continue
+		}
+		for objKey, stmt := range logicalSchema.Creates {
+			if ignoreOpts.ShouldIgnore(objKey) {
+				continue
+			}
+			body := stmt.Body()
+			sd.Objects = append(sd.Objects, SnapshotObject{
+				Type:      objKey.Type,
+				Name:      objKey.Name,
+				Checksum:  snapshotChecksum(body),
+				Statement: body,
+			})
+		}
+	}
+	sortSnapshotObjects(sd.Objects)
+	snap.Dirs[key] = sd
+	return sd, nil
+}
+
+// AddLive records checksums of the object definitions in a live schema, as
+// reported by SHOW CREATE. Tables' next auto-increment values are excluded.
+func (sd *SnapshotDir) AddLive(instance *tengo.Instance, schema *tengo.Schema, ignoreOpts IgnoreOptions) {
+	objects := []SnapshotObject{}
+	for objKey, createStatement := range schema.ObjectDefinitions() {
+		if ignoreOpts.ShouldIgnore(objKey) {
+			continue
+		}
+		if objKey.Type == tengo.ObjectTypeTable {
+			createStatement, _ = tengo.ParseCreateAutoInc(createStatement)
+		}
+		objects = append(objects, SnapshotObject{
+			Type:     objKey.Type,
+			Name:     objKey.Name,
+			Checksum: snapshotChecksum(createStatement),
+		})
+	}
+	sortSnapshotObjects(objects)
+	if sd.Live == nil {
+		sd.Live = make(map[string][]SnapshotObject)
+	}
+	sd.Live[fmt.Sprintf("%s/%s", instance, schema.Name)] = objects
+}
+
+// LogicalSchema returns a LogicalSchema containing the snapshot's statements
+// for this dir, in place of the dir's current *.sql files. The schema name,
+// character set, and collation are copied from base, which should be the dir's
+// current unnamed LogicalSchema.
+func (sd *SnapshotDir) LogicalSchema(base *LogicalSchema) *LogicalSchema {
+	logicalSchema := &LogicalSchema{
+		Name:      base.Name,
+		CharSet:   base.CharSet,
+		Collation: base.Collation,
+		Creates:   make(map[tengo.ObjectKey]*Statement, len(sd.Objects)),
+		Seeds:     base.Seeds,
+	}
+	for _, obj := range sd.Objects {
+		logicalSchema.Creates[obj.ObjectKey()] = &Statement{
+			File:       fmt.Sprintf("snapshot:%s", obj.ObjectKey()),
+			Text:       obj.Statement,
+			Type:       StatementTypeCreate,
+			ObjectType: obj.Type,
+			ObjectName: obj.Name,
+		}
+	}
+	return logicalSchema
+}
+
+// CompareSnapshotObjects compares two lists of snapshot objects by checksum,
+// returning the keys of objects only present in after, present in both but
+// with different checksums, and only present in before.
+func CompareSnapshotObjects(before, after []SnapshotObject) (added, changed, removed []tengo.ObjectKey) {
+	beforeChecksums := make(map[tengo.ObjectKey]string, len(before))
+	for _, obj := range before {
+		beforeChecksums[obj.ObjectKey()] = obj.Checksum
+	}
+	for _, obj := range after {
+		key := obj.ObjectKey()
+		if checksum, ok := beforeChecksums[key]; !ok {
+			added = append(added, key)
+		} else if checksum != obj.Checksum {
+			changed = append(changed, key)
+		}
+		delete(beforeChecksums, key)
+	}
+	for key := range beforeChecksums {
+		removed = append(removed, key)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].String() < removed[j].String()
+	})
+	return added, changed, removed
+}
+
+func snapshotChecksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func sortSnapshotObjects(objects []SnapshotObject) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ObjectKey().String() < objects[j].ObjectKey().String()
+	})
+}
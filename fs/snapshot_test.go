@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestSnapshotFileReadWrite(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "skeema-snapshot")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+	filePath := filepath.Join(tempDir, "snapshots.json")
+
+	// Nonexistent file should not be an error
+	sf, err := ReadSnapshotFile(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadSnapshotFile: %s", err)
+	} else if len(sf.Snapshots) > 0 || sf.Snapshot("foo") != nil {
+		t.Fatalf("Expected empty SnapshotFile, instead found %+v", sf)
+	}
+
+	snap := NewSnapshot("release-1")
+	snap.Dirs["mydb/product"] = &SnapshotDir{
+		Objects: []SnapshotObject{
+			{Type: tengo.ObjectTypeTable, Name: "users", Checksum: "abc", Statement: "CREATE TABLE users (id int)"},
+		},
+	}
+	if err := sf.Add(snap); err != nil {
+		t.Fatalf("Unexpected error from Add: %s", err)
+	}
+	if err := sf.Add(NewSnapshot("release-1")); err == nil {
+		t.Error("Expected error adding snapshot with duplicate name, but err was nil")
+	}
+	if err := sf.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %s", err)
+	}
+
+	reread, err := ReadSnapshotFile(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadSnapshotFile: %s", err)
+	} else if len(reread.Snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot, instead found %d", len(reread.Snapshots))
+	}
+	got := reread.Snapshot("release-1")
+	if got == nil || !got.Created.Equal(snap.Created) || !reflect.DeepEqual(got.Dirs, snap.Dirs) {
+		t.Errorf("Snapshot did not round-trip as expected: %+v vs %+v", got, snap)
+	}
+
+	// Invalid file contents should be an error
+	if err := ioutil.WriteFile(filePath, []byte("{not json"), 0666); err != nil {
+		t.Fatalf("Unable to write %s: %s", filePath, err)
+	}
+	if _, err := ReadSnapshotFile(filePath); err == nil {
+		t.Error("Expected error from ReadSnapshotFile on invalid contents, but err was nil")
+	}
+}
+
+func TestSnapshotAddDir(t *testing.T) {
+	cmd := mybase.NewCommand("snapshottest", "", "", nil)
+	util.AddGlobalOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	cfg := mybase.ParseFakeCLI(t, cmd, "snapshottest --ignore-table=^post")
+	dir, err := ParseDir("../testdata/golden/init/mydb/product", cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+
+	sf, err := ReadSnapshotFile("../testdata/golden/init/nonexistent.json")
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadSnapshotFile: %s", err)
+	}
+	key := sf.DirKey(dir)
+	if key != "mydb/product" {
+		t.Errorf("Unexpected result from DirKey: %q", key)
+	}
+
+	snap := NewSnapshot("test")
+	sd, err := snap.AddDir(key, dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from AddDir: %s", err)
+	} else if snap.Dirs[key] != sd {
+		t.Error("AddDir did not store result in snapshot")
+	}
+	var names []string
+	for _, obj := range sd.Objects {
+		names = append(names, obj.Name)
+		if obj.Type != tengo.ObjectTypeTable || obj.Statement == "" || len(obj.Checksum) != 64 {
+			t.Errorf("Unexpected snapshot object %+v", obj)
+		}
+	}
+	if expected := []string{"comments", "subscriptions", "users"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected objects %v, instead found %v", expected, names)
+	}
+
+	// LogicalSchema should reconstruct statements matching the dir's own
+	base := dir.LogicalSchemas[0]
+	logicalSchema := sd.LogicalSchema(base)
+	if len(logicalSchema.Creates) != len(sd.Objects) {
+		t.Fatalf("Expected %d statements, instead found %d", len(sd.Objects), len(logicalSchema.Creates))
+	}
+	for objKey, stmt := range logicalSchema.Creates {
+		if stmt.Body() != base.Creates[objKey].Body() {
+			t.Errorf("Statement mismatch for %s: %q vs %q", objKey, stmt.Body(), base.Creates[objKey].Body())
+		}
+	}
+}
+
+func TestCompareSnapshotObjects(t *testing.T) {
+	obj := func(name, checksum string) SnapshotObject {
+		return SnapshotObject{Type: tengo.ObjectTypeTable, Name: name, Checksum: checksum}
+	}
+	key := func(name string) tengo.ObjectKey {
+		return tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: name}
+	}
+	before := []SnapshotObject{obj("a", "1"), obj("b", "2"), obj("c", "3"), obj("d", "4")}
+	after := []SnapshotObject{obj("a", "1"), obj("b", "5"), obj("e", "6")}
+	added, changed, removed := CompareSnapshotObjects(before, after)
+	if !reflect.DeepEqual(added, []tengo.ObjectKey{key("e")}) {
+		t.Errorf("Unexpected added: %v", added)
+	}
+	if !reflect.DeepEqual(changed, []tengo.ObjectKey{key("b")}) {
+		t.Errorf("Unexpected changed: %v", changed)
+	}
+	if !reflect.DeepEqual(removed, []tengo.ObjectKey{key("c"), key("d")}) {
+		t.Errorf("Unexpected removed: %v", removed)
+	}
+	if added, changed, removed = CompareSnapshotObjects(before, before); len(added)+len(changed)+len(removed) > 0 {
+		t.Errorf("Expected no differences comparing identical lists, instead found %v %v %v", added, changed, removed)
+	}
+}
@@ -167,6 +167,41 @@ func PathForObject(dirPath, objectName string) string {
 	return path.Join(dirPath, fmt.Sprintf("%s.sql", objectName))
 }
 
+// typeSubdirs maps object types to the names of the subdirectories used for
+// them when the type-subdirs option is enabled.
+var typeSubdirs = map[tengo.ObjectType]string{
+	tengo.ObjectTypeTable: "tables",
+	tengo.ObjectTypeProc:  "procedures",
+	tengo.ObjectTypeFunc:  "functions",
+}
+
+// TypeSubdirName returns the name of the subdirectory used for objects of the
+// supplied type when the type-subdirs option is enabled.
+func TypeSubdirName(objectType tengo.ObjectType) string {
+	return typeSubdirs[objectType]
+}
+
+// IsTypeSubdirName returns true if name is one of the subdirectory names used
+// by the type-subdirs option.
+func IsTypeSubdirName(name string) bool {
+	for _, subdirName := range typeSubdirs {
+		if name == subdirName {
+			return true
+		}
+	}
+	return false
+}
+
+// PathForObjectKey is like PathForObject, but if useTypeSubdirs is true, the
+// returned path will be in a subdirectory of dirPath named for the object's
+// type, such as "tables".
+func PathForObjectKey(dirPath string, key tengo.ObjectKey, useTypeSubdirs bool) string {
+	if useTypeSubdirs {
+		dirPath = path.Join(dirPath, TypeSubdirName(key.Type))
+	}
+	return PathForObject(dirPath, key.Name)
+}
+
 func removeSpecialChars(r rune) rune {
 	if unicode.IsSpace(r) {
 		return -1
@@ -188,10 +223,14 @@ func removeSpecialChars(r rune) rune {
 
 // AppendToFile appends the supplied string to the file at the given path. If the
 // file already exists and is not newline-terminated, a newline will be added
-// before contents are appended. If the file does not exist, it will be created.
+// before contents are appended. If the file does not exist, it will be created,
+// along with its parent directory if needed.
 func AppendToFile(filePath, contents string) (bytesWritten int, created bool, err error) {
 	_, err = os.Stat(filePath)
 	if os.IsNotExist(err) {
+		if err = os.MkdirAll(path.Dir(filePath), 0777); err != nil {
+			return 0, false, err
+		}
 		return len(contents), true, ioutil.WriteFile(filePath, []byte(contents), 0666)
 	} else if err != nil {
 		return
@@ -256,6 +256,23 @@ func TestPathForObject(t *testing.T) {
 	}
 }
 
+func TestPathForObjectKey(t *testing.T) {
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "my-proc"}
+	if actual := PathForObjectKey("/var/schemas", key, false); actual != "/var/schemas/myproc.sql" {
+		t.Errorf("Unexpected result from PathForObjectKey: %s", actual)
+	}
+	if actual := PathForObjectKey("/var/schemas", key, true); actual != "/var/schemas/procedures/myproc.sql" {
+		t.Errorf("Unexpected result from PathForObjectKey: %s", actual)
+	}
+	key.Type = tengo.ObjectTypeTable
+	if actual := PathForObjectKey("/var/schemas", key, true); actual != "/var/schemas/tables/myproc.sql" {
+		t.Errorf("Unexpected result from PathForObjectKey: %s", actual)
+	}
+	if !IsTypeSubdirName("functions") || IsTypeSubdirName("views") {
+		t.Error("Unexpected result from IsTypeSubdirName")
+	}
+}
+
 func TestAppendToFile(t *testing.T) {
 	assertAppend := func(filePath, contents string, expectBytes int, expectCreated bool) {
 		t.Helper()
@@ -278,8 +295,11 @@ func TestAppendToFile(t *testing.T) {
 	if contents := ReadTestFile(t, "../testdata/.scratch/fs/append-test2"); contents != "hello world\nhello world" {
 		t.Errorf("Unexpected contents: %s", contents)
 	}
+	assertAppend("../testdata/.scratch/fs/sub/append-test3", "hello world", 11, true) // creates parent dir
 	RemoveTestFile(t, "../testdata/.scratch/fs/append-test1")
 	RemoveTestFile(t, "../testdata/.scratch/fs/append-test2")
+	RemoveTestFile(t, "../testdata/.scratch/fs/sub/append-test3")
+	RemoveTestFile(t, "../testdata/.scratch/fs/sub")
 	RemoveTestFile(t, "../testdata/.scratch/fs")
 }
 
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// gitBase is a temporary copy of a git repo's tree as of a specific ref. It is
+// used by the git-base option to compare the working tree against the
+// contents of the repo at that ref.
+type gitBase struct {
+	ref      string
+	topLevel string         // top-level dir of the repo's working tree
+	path     string         // temp dir containing the repo's tree as of ref
+	cfg      *mybase.Config // global config, used for parsing dirs in the copy
+}
+
+// newGitBase extracts the tree of the git repo containing dirPath, as of ref,
+// into a temporary directory. The caller should call cleanup on the result
+// once finished with it.
+func newGitBase(dirPath, ref string, cfg *mybase.Config) (*gitBase, error) {
+	topLevel, err := gitCapture(dirPath, "git rev-parse --show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to determine git repo location: %s", err)
+	}
+	gb := &gitBase{
+		ref:      ref,
+		topLevel: strings.TrimSpace(topLevel),
+		cfg:      cfg,
+	}
+	verify, err := util.NewInterpolatedShellOut("git rev-parse --quiet --verify {REF}", map[string]string{"REF": ref + "^{tree}"})
+	if err != nil {
+		return nil, err
+	}
+	verify.Dir = gb.topLevel
+	tree, err := verify.RunCapture()
+	if err != nil {
+		return nil, fmt.Errorf("Git ref %s does not exist in %s", ref, gb.topLevel)
+	}
+	if gb.path, err = ioutil.TempDir("", "skeema-git-base"); err != nil {
+		return nil, err
+	}
+	archive, err := util.NewInterpolatedShellOut("git archive --format=tar {TREE} | tar -x -C {PATH}", map[string]string{"TREE": strings.TrimSpace(tree), "PATH": gb.path})
+	if err == nil {
+		archive.Dir = gb.topLevel
+		err = archive.Run()
+	}
+	if err != nil {
+		gb.cleanup()
+		return nil, fmt.Errorf("Unable to extract git ref %s: %s", ref, err)
+	}
+	log.Debugf("Extracted git ref %s of %s to %s", ref, gb.topLevel, gb.path)
+	return gb, nil
+}
+
+// cleanup removes the temporary directory.
+func (gb *gitBase) cleanup() {
+	if err := os.RemoveAll(gb.path); err != nil {
+		log.Warnf("Unable to remove temporary dir %s: %s", gb.path, err)
+	}
+}
+
+// dirPath returns the location in gb corresponding to dirPath in the working
+// tree.
+func (gb *gitBase) dirPath(dirPath string) (string, error) {
+	dirPath, err := filepath.Abs(dirPath)
+	if err == nil {
+		dirPath, err = filepath.EvalSymlinks(dirPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(gb.topLevel, dirPath)
+	if err != nil {
+		return "", err
+	} else if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not within git repo %s", dirPath, gb.topLevel)
+	}
+	return filepath.Join(gb.path, relPath), nil
+}
+
+// parseDir returns the dir in gb corresponding to dirPath in the working tree.
+// If no such dir existed as of gb's ref, a nil dir and nil error are returned.
+func (gb *gitBase) parseDir(dirPath string) (*fs.Dir, error) {
+	basePath, err := gb.dirPath(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(basePath); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory as of git ref %s", dirPath, gb.ref)
+	}
+	return fs.ParseDir(basePath, gb.cfg)
+}
+
+// filterResult removes annotations from result which do not relate to objects
+// in dir that differ from gb's ref. The corresponding dir at gb's ref is
+// loaded into a workspace, and its schema is compared to the one linted for
+// dir; annotations are retained for objects with any differences, as well as
+// tables with foreign keys referencing those objects. Annotations that do not
+// pertain to a specific object are always retained. If the comparison cannot
+// be performed, an exception is added to result, and nothing is removed.
+func (gb *gitBase) filterResult(result *linter.Result, dir *fs.Dir) {
+	schema := result.Schemas[dir.Path]
+	if schema == nil {
+		return
+	}
+	var baseSchema *tengo.Schema
+	baseDir, err := gb.parseDir(dir.Path)
+	if err == nil && baseDir != nil {
+		baseSchema, err = compareWorkspaceSchema(baseDir)
+	}
+	if err != nil {
+		err = fmt.Errorf("Unable to compare %s to git ref %s: %s", dir.RelPath(), gb.ref, err)
+		log.Error(err)
+		result.Exceptions = append(result.Exceptions, err)
+		return
+	}
+
+	changedKeys := make(map[tengo.ObjectKey]bool)
+	for _, objDiff := range tengo.NewSchemaDiff(baseSchema, schema).ObjectDiffs() {
+		changedKeys[objDiff.ObjectKey()] = true
+	}
+	dependents := fkDependents(result, changedKeys)
+	filter := func(annotations []*linter.Annotation) []*linter.Annotation {
+		kept := make([]*linter.Annotation, 0, len(annotations))
+		for _, a := range annotations {
+			key := a.Statement.ObjectKey()
+			if key.Name == "" || changedKeys[key] || dependents[key] {
+				kept = append(kept, a)
+			}
+		}
+		return kept
+	}
+	result.Errors = filter(result.Errors)
+	result.Warnings = filter(result.Warnings)
+	result.FormatNotices = filter(result.FormatNotices)
+}
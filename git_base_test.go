@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/tengo"
+)
+
+func TestGitBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repoDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	repoDir, _ = filepath.EvalSymlinks(repoDir)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s\n%s", args, err, out)
+		}
+	}
+	write := func(relPath, contents string) {
+		t.Helper()
+		path := filepath.Join(repoDir, relPath)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := ioutil.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write %s: %s", path, err)
+		}
+	}
+	git("init", "-q")
+	write(".skeema", "flavor=mysql:8.0\n")
+	write("product/.skeema", "schema=product\n")
+	write("product/posts.sql", "CREATE TABLE posts (id int);\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	write("product/posts.sql", "CREATE TABLE posts (id bigint);\n")
+	write("analytics/.skeema", "schema=analytics\n")
+
+	cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema lint")
+	productPath := filepath.Join(repoDir, "product")
+	if _, err := newGitBase(productPath, "no-such-ref", cfg); err == nil {
+		t.Error("Expected error from nonexistent ref, but err was nil")
+	}
+	gb, err := newGitBase(productPath, "HEAD", cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error from newGitBase: %s", err)
+	}
+
+	if path, err := gb.dirPath(productPath); err != nil || path != filepath.Join(gb.path, "product") {
+		t.Errorf("Unexpected result from dirPath: %q, %v", path, err)
+	}
+	if _, err := gb.dirPath(filepath.Dir(repoDir)); err == nil {
+		t.Error("Expected error from dirPath outside of repo, but err was nil")
+	}
+	dir, err := gb.parseDir(productPath)
+	if err != nil || dir == nil {
+		t.Fatalf("Unexpected result from parseDir: %v, %v", dir, err)
+	}
+	if dir.Config.Get("flavor") != "mysql:8.0" || dir.Config.Get("schema") != "product" {
+		t.Errorf("Dir at git ref did not have expected configuration")
+	}
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"}
+	if stmt := dir.LogicalSchemas[0].Creates[key]; stmt == nil || stmt.Body() != "CREATE TABLE posts (id int)" {
+		t.Errorf("Unexpected statement at git ref: %+v", stmt)
+	}
+	if dir, err := gb.parseDir(filepath.Join(repoDir, "analytics")); dir != nil || err != nil {
+		t.Errorf("Expected nil dir and nil err for dir not existing at git ref, instead found %v, %v", dir, err)
+	}
+
+	gb.cleanup()
+	if _, err := os.Stat(gb.path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed by cleanup, but stat returned %v", gb.path, err)
+	}
+}
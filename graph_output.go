@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// graphNode represents a table in a relationship graph.
+type graphNode struct {
+	Schema string
+	Table  string
+}
+
+func (n graphNode) String() string {
+	return n.Schema + "." + n.Table
+}
+
+// graphEdge represents a relationship from a child table to the parent table
+// that it references.
+type graphEdge struct {
+	From     graphNode
+	To       graphNode
+	Label    string
+	Nullable bool // true if the child's referencing columns are nullable
+	Inferred bool // true if derived from column naming, rather than a foreign key
+
+	FromColumns []string // child's referencing columns
+	ToColumns   []string // parent's referenced columns; may be nil if inferred
+	UpdateRule  string   // empty if inferred
+	DeleteRule  string   // empty if inferred
+}
+
+// schemaGraph is a relationship graph of the tables in one or more schemas.
+type schemaGraph struct {
+	Nodes  []graphNode
+	Edges  []graphEdge
+	Tables map[graphNode]*tengo.Table // nil for parent tables not in any supplied schema
+}
+
+// buildSchemaGraph returns the relationship graph of the supplied schemas.
+// Edges are derived from foreign keys. If inferRelations is true, edges are
+// also inferred from columns named after another table in the same schema,
+// such as user_id referencing table users or user; these are only added for
+// columns not already part of a foreign key.
+func buildSchemaGraph(schemas []*tengo.Schema, inferRelations bool) *schemaGraph {
+	g := &schemaGraph{Tables: make(map[graphNode]*tengo.Table)}
+	seenNodes := make(map[graphNode]bool)
+	addNode := func(n graphNode) {
+		if !seenNodes[n] {
+			seenNodes[n] = true
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			node := graphNode{Schema: schema.Name, Table: table.Name}
+			addNode(node)
+			g.Tables[node] = table
+		}
+	}
+	for _, schema := range schemas {
+		tablesByName := schema.TablesByName()
+		for _, table := range schema.Tables {
+			from := graphNode{Schema: schema.Name, Table: table.Name}
+			fkColumns := make(map[string]bool)
+			for _, fk := range table.ForeignKeys {
+				to := graphNode{Schema: fk.ReferencedSchemaName, Table: fk.ReferencedTableName}
+				if to.Schema == "" {
+					to.Schema = schema.Name
+				}
+				addNode(to) // parent may be in a schema not otherwise included
+				edge := graphEdge{
+					From:       from,
+					To:         to,
+					Label:      fk.Name,
+					ToColumns:  fk.ReferencedColumnNames,
+					UpdateRule: fk.UpdateRule,
+					DeleteRule: fk.DeleteRule,
+				}
+				for _, col := range fk.Columns {
+					fkColumns[col.Name] = true
+					edge.Nullable = edge.Nullable || col.Nullable
+					edge.FromColumns = append(edge.FromColumns, col.Name)
+				}
+				g.Edges = append(g.Edges, edge)
+			}
+			if !inferRelations {
+				continue
+			}
+			for _, col := range table.Columns {
+				if fkColumns[col.Name] || !strings.HasSuffix(strings.ToLower(col.Name), "_id") {
+					continue
+				}
+				if parent := inferredParentTable(col.Name, tablesByName); parent != "" && parent != table.Name {
+					edge := graphEdge{
+						From:        from,
+						To:          graphNode{Schema: schema.Name, Table: parent},
+						Label:       col.Name,
+						Nullable:    col.Nullable,
+						Inferred:    true,
+						FromColumns: []string{col.Name},
+					}
+					if pk := tablesByName[parent].PrimaryKey; pk != nil && len(pk.Columns) == 1 {
+						edge.ToColumns = []string{pk.Columns[0].Name}
+					}
+					g.Edges = append(g.Edges, edge)
+				}
+			}
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].Schema != g.Nodes[j].Schema {
+			return g.Nodes[i].Schema < g.Nodes[j].Schema
+		}
+		return g.Nodes[i].Table < g.Nodes[j].Table
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From.String() < g.Edges[j].From.String()
+		}
+		return g.Edges[i].Label < g.Edges[j].Label
+	})
+	return g
+}
+
+// inferredParentTable returns the name of the table that a column named like
+// "foo_id" refers to, by looking for a table named "foo", "foos", or "fooes".
+// An empty string is returned if no such table exists.
+func inferredParentTable(colName string, tablesByName map[string]*tengo.Table) string {
+	base := colName[:len(colName)-len("_id")]
+	if base == "" {
+		return ""
+	}
+	for _, candidate := range []string{base, base + "s", base + "es"} {
+		if _, ok := tablesByName[candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// multiSchema returns true if the graph's nodes span more than one schema, in
+// which case output must qualify table names with their schema.
+func (g *schemaGraph) multiSchema() bool {
+	for _, n := range g.Nodes {
+		if n.Schema != g.Nodes[0].Schema {
+			return true
+		}
+	}
+	return false
+}
+
+// writeGraphDOT writes g to w in Graphviz DOT format. Tables are grouped into
+// a cluster per schema, and inferred relationships are drawn as dashed lines.
+func writeGraphDOT(w io.Writer, g *schemaGraph) error {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	var b strings.Builder
+	b.WriteString("digraph schema {\n  rankdir=LR;\n  node [shape=box];\n")
+	multi := g.multiSchema()
+	for n, node := range g.Nodes {
+		if multi && (n == 0 || node.Schema != g.Nodes[n-1].Schema) {
+			fmt.Fprintf(&b, "  subgraph %s {\n    label=%s;\n", quote("cluster_"+node.Schema), quote(node.Schema))
+		}
+		if multi {
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "  %s [label=%s];\n", quote(node.String()), quote(node.Table))
+		if multi && (n == len(g.Nodes)-1 || node.Schema != g.Nodes[n+1].Schema) {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, edge := range g.Edges {
+		var style string
+		if edge.Inferred {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", quote(edge.From.String()), quote(edge.To.String()), quote(edge.Label), style)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGraphMermaid writes g to w as a Mermaid entity-relationship diagram.
+// Nullable relationships are shown as optional on the parent side, and
+// inferred relationships are drawn as non-identifying (dashed) lines.
+func writeGraphMermaid(w io.Writer, g *schemaGraph) error {
+	multi := g.multiSchema()
+	entity := func(n graphNode) string {
+		name := n.Table
+		if multi {
+			name = n.Schema + "__" + n.Table
+		}
+		return strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+				return r
+			}
+			return '_'
+		}, name)
+	}
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	hasEdge := make(map[graphNode]bool)
+	for _, edge := range g.Edges {
+		hasEdge[edge.From], hasEdge[edge.To] = true, true
+	}
+	for _, node := range g.Nodes {
+		if !hasEdge[node] {
+			fmt.Fprintf(&b, "  %s\n", entity(node))
+		}
+	}
+	for _, edge := range g.Edges {
+		parent, line := "||", "--"
+		if edge.Nullable {
+			parent = "|o"
+		}
+		if edge.Inferred {
+			line = ".."
+		}
+		fmt.Fprintf(&b, "  %s %s%so{ %s : \"%s\"\n", entity(edge.To), parent, line, entity(edge.From), strings.Replace(edge.Label, `"`, "'", -1))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGraphDBML writes g to w in DBML format, as used by dbdiagram.io and
+// dbdocs.io. Unlike the other graph formats, DBML includes each table's
+// columns and indexes, with table and column comments as notes. Relationships
+// become refs; inferred relationships are only included if the parent table
+// has a single-column primary key, and are marked with a comment.
+func writeGraphDBML(w io.Writer, g *schemaGraph) error {
+	multi := g.multiSchema()
+	quote := func(s string) string {
+		return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+	}
+	str := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(strings.Join(strings.Fields(s), " ")) + "'"
+	}
+	tableName := func(n graphNode) string {
+		if multi {
+			return quote(n.Schema) + "." + quote(n.Table)
+		}
+		return quote(n.Table)
+	}
+	columnList := func(cols []string) string {
+		quoted := make([]string, len(cols))
+		for n, col := range cols {
+			quoted[n] = quote(col)
+		}
+		if len(quoted) == 1 {
+			return quoted[0]
+		}
+		return "(" + strings.Join(quoted, ", ") + ")"
+	}
+
+	var b strings.Builder
+	projectName := "skeema"
+	if len(g.Nodes) > 0 && !multi {
+		projectName = g.Nodes[0].Schema
+	}
+	fmt.Fprintf(&b, "Project %s {\n  database_type: 'MySQL'\n}\n", quote(projectName))
+	for _, node := range g.Nodes {
+		table := g.Tables[node]
+		if table == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\nTable %s {\n", tableName(node))
+		singlePK := table.PrimaryKey != nil && len(table.PrimaryKey.Columns) == 1
+		for _, col := range table.Columns {
+			var settings []string
+			if singlePK && table.PrimaryKey.Columns[0].Name == col.Name {
+				settings = append(settings, "pk")
+			}
+			if col.AutoIncrement {
+				settings = append(settings, "increment")
+			}
+			if !col.Nullable {
+				settings = append(settings, "not null")
+			}
+			if !col.Default.Null {
+				if col.Default.Quoted {
+					settings = append(settings, "default: "+str(col.Default.Value))
+				} else {
+					settings = append(settings, "default: `"+col.Default.Value+"`")
+				}
+			} else if col.Nullable && !col.AutoIncrement {
+				settings = append(settings, "default: null")
+			}
+			if col.Comment != "" {
+				settings = append(settings, "note: "+str(col.Comment))
+			}
+			fmt.Fprintf(&b, "  %s %s", quote(col.Name), quote(col.TypeInDB))
+			if len(settings) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(settings, ", "))
+			}
+			b.WriteString("\n")
+		}
+		if table.Comment != "" {
+			fmt.Fprintf(&b, "\n  Note: %s\n", str(table.Comment))
+		}
+		var indexes []string
+		if table.PrimaryKey != nil && !singlePK {
+			indexes = append(indexes, dbmlIndex(table.PrimaryKey, quote, "pk"))
+		}
+		for _, idx := range table.SecondaryIndexes {
+			settings := []string{"name: " + str(idx.Name)}
+			if idx.Unique {
+				settings = append([]string{"unique"}, settings...)
+			}
+			if idx.Comment != "" {
+				settings = append(settings, "note: "+str(idx.Comment))
+			}
+			indexes = append(indexes, dbmlIndex(idx, quote, strings.Join(settings, ", ")))
+		}
+		if len(indexes) > 0 {
+			b.WriteString("\n  indexes {\n")
+			for _, idx := range indexes {
+				fmt.Fprintf(&b, "    %s\n", idx)
+			}
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	var wroteRef bool
+	for _, edge := range g.Edges {
+		if g.Tables[edge.To] == nil || len(edge.ToColumns) == 0 {
+			continue
+		}
+		if !wroteRef {
+			b.WriteString("\n")
+			wroteRef = true
+		}
+		if edge.Inferred {
+			fmt.Fprintf(&b, "// inferred from column name %s\nRef: ", edge.Label)
+		} else {
+			fmt.Fprintf(&b, "Ref %s: ", quote(edge.Label))
+		}
+		fmt.Fprintf(&b, "%s.%s > %s.%s", tableName(edge.From), columnList(edge.FromColumns), tableName(edge.To), columnList(edge.ToColumns))
+		var settings []string
+		if edge.DeleteRule != "" {
+			settings = append(settings, "delete: "+strings.ToLower(edge.DeleteRule))
+		}
+		if edge.UpdateRule != "" {
+			settings = append(settings, "update: "+strings.ToLower(edge.UpdateRule))
+		}
+		if len(settings) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(settings, ", "))
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dbmlIndex returns the DBML definition of idx, for use in a table's indexes
+// block, with the supplied settings.
+func dbmlIndex(idx *tengo.Index, quote func(string) string, settings string) string {
+	parts := make([]string, len(idx.Columns))
+	for n, col := range idx.Columns {
+		parts[n] = quote(col.Name)
+		if n < len(idx.SubParts) && idx.SubParts[n] > 0 {
+			// DBML has no syntax for prefix lengths, so these use an expression
+			parts[n] = fmt.Sprintf("`%s(%d)`", col.Name, idx.SubParts[n])
+		}
+	}
+	def := "(" + strings.Join(parts, ", ") + ")"
+	if len(parts) == 1 {
+		def = parts[0]
+	}
+	return def + " [" + settings + "]"
+}
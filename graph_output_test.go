@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestBuildSchemaGraph(t *testing.T) {
+	schema := docsTestSchema()
+	postsTagID := &tengo.Column{Name: "tag_id", TypeInDB: "int(10) unsigned", Nullable: true}
+	schema.Tables = append(schema.Tables,
+		&tengo.Table{Name: "tags", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(10) unsigned"}}},
+		&tengo.Table{Name: "post_tags", Columns: []*tengo.Column{{Name: "post_id", TypeInDB: "bigint(20) unsigned"}, postsTagID, {Name: "widget_id", TypeInDB: "int"}}},
+	)
+
+	g := buildSchemaGraph([]*tengo.Schema{schema}, false)
+	if len(g.Nodes) != 4 || g.Nodes[0].Table != "post_tags" || g.Nodes[3].Table != "users" {
+		t.Errorf("Unexpected nodes: %v", g.Nodes)
+	}
+	if len(g.Edges) != 1 || g.Edges[0].From.Table != "posts" || g.Edges[0].To.Table != "users" || g.Edges[0].Inferred {
+		t.Errorf("Unexpected edges: %+v", g.Edges)
+	}
+
+	// With inference, post_tags gains edges to posts and tags, but not to the
+	// nonexistent widgets table; posts.user_id is already covered by its FK
+	g = buildSchemaGraph([]*tengo.Schema{schema}, true)
+	if len(g.Edges) != 3 {
+		t.Fatalf("Expected 3 edges, instead found %+v", g.Edges)
+	}
+	if e := g.Edges[0]; e.From.Table != "post_tags" || e.To.Table != "posts" || e.Label != "post_id" || !e.Inferred || e.Nullable {
+		t.Errorf("Unexpected first edge: %+v", e)
+	}
+	if e := g.Edges[1]; e.To.Table != "tags" || !e.Nullable {
+		t.Errorf("Unexpected second edge: %+v", e)
+	}
+
+	var buf bytes.Buffer
+	if err := writeGraphDOT(&buf, g); err != nil {
+		t.Fatalf("Unexpected error from writeGraphDOT: %s", err)
+	}
+	out := buf.String()
+	for _, exp := range []string{
+		"digraph schema {\n",
+		"  \"product.users\" [label=\"users\"];\n",
+		"  \"product.posts\" -> \"product.users\" [label=\"posts_user\"];\n",
+		"  \"product.post_tags\" -> \"product.tags\" [label=\"tag_id\", style=dashed];\n",
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected DOT output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+	if strings.Contains(out, "subgraph") {
+		t.Errorf("Expected no clusters for a single schema. Full output:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := writeGraphMermaid(&buf, g); err != nil {
+		t.Fatalf("Unexpected error from writeGraphMermaid: %s", err)
+	}
+	expected := "erDiagram\n" +
+		"  posts ||..o{ post_tags : \"post_id\"\n" +
+		"  tags |o..o{ post_tags : \"tag_id\"\n" +
+		"  users ||--o{ posts : \"posts_user\"\n"
+	if out = buf.String(); out != expected {
+		t.Errorf("Unexpected Mermaid output:\n%s\nExpected:\n%s", out, expected)
+	}
+}
+
+func TestBuildSchemaGraphMultiSchema(t *testing.T) {
+	orders := &tengo.Schema{
+		Name: "orders",
+		Tables: []*tengo.Table{
+			{Name: "orders", ForeignKeys: []*tengo.ForeignKey{
+				{Name: "orders_user", ReferencedSchemaName: "product", ReferencedTableName: "users"},
+			}},
+		},
+	}
+	lonely := &tengo.Schema{Name: "misc", Tables: []*tengo.Table{{Name: "settings"}}}
+	g := buildSchemaGraph([]*tengo.Schema{orders, lonely}, false)
+	if len(g.Nodes) != 3 || g.Nodes[2] != (graphNode{Schema: "product", Table: "users"}) {
+		t.Fatalf("Unexpected nodes: %v", g.Nodes)
+	}
+
+	var buf bytes.Buffer
+	if err := writeGraphDOT(&buf, g); err != nil {
+		t.Fatalf("Unexpected error from writeGraphDOT: %s", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "  subgraph \"cluster_misc\" {\n    label=\"misc\";\n    \"misc.settings\" [label=\"settings\"];\n  }\n") {
+		t.Errorf("Expected DOT output to contain cluster per schema. Full output:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := writeGraphMermaid(&buf, g); err != nil {
+		t.Fatalf("Unexpected error from writeGraphMermaid: %s", err)
+	}
+	expected := "erDiagram\n  misc__settings\n  product__users ||--o{ orders__orders : \"orders_user\"\n"
+	if out := buf.String(); out != expected {
+		t.Errorf("Unexpected Mermaid output:\n%s\nExpected:\n%s", out, expected)
+	}
+}
+
+func TestWriteGraphDBML(t *testing.T) {
+	schema := docsTestSchema()
+	schema.Tables = append(schema.Tables,
+		&tengo.Table{Name: "tags", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}}},
+		&tengo.Table{Name: "post_tags", Columns: []*tengo.Column{
+			{Name: "post_id", TypeInDB: "bigint(20) unsigned", Default: tengo.ColumnDefaultNull},
+			{Name: "tag_id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull},
+		}},
+	)
+	postTags := schema.Tables[3]
+	postTags.PrimaryKey = &tengo.Index{Name: "PRIMARY", Columns: postTags.Columns, PrimaryKey: true, Unique: true}
+	g := buildSchemaGraph([]*tengo.Schema{schema}, true)
+
+	var buf bytes.Buffer
+	if err := writeGraphDBML(&buf, g); err != nil {
+		t.Fatalf("Unexpected error from writeGraphDBML: %s", err)
+	}
+	out := buf.String()
+	for _, exp := range []string{
+		"Project \"product\" {\n  database_type: 'MySQL'\n}\n",
+		"Table \"post_tags\" {\n  \"post_id\" \"bigint(20) unsigned\" [not null]\n  \"tag_id\" \"int(10) unsigned\" [not null]\n\n  indexes {\n    (\"post_id\", \"tag_id\") [pk]\n  }\n}\n",
+		"  \"id\" \"int(10) unsigned\" [pk, increment, not null]\n",
+		"  \"name\" \"varchar(40)\" [default: null, note: 'Display name | shown publicly']\n",
+		"  \"updated_at\" \"timestamp\" [not null, default: `CURRENT_TIMESTAMP`]\n",
+		"  \"user_id\" \"int(10) unsigned\" [not null, default: '0']\n",
+		"\n  Note: 'Registered *users*'\n",
+		"    `name(10)` [name: 'name']\n",
+		"// inferred from column name post_id\nRef: \"post_tags\".\"post_id\" > \"posts\".\"id\"\n",
+		"Ref \"posts_user\": \"posts\".\"user_id\" > \"users\".\"id\" [delete: cascade, update: restrict]\n",
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected DBML output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+
+	// tags has no primary key, so the inferred relationship cannot be expressed
+	if strings.Contains(out, "\"tags\".") {
+		t.Errorf("Expected no ref to tags. Full output:\n%s", out)
+	}
+}
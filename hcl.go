@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file contains a minimal parser for the subset of HCL syntax used by
+// Atlas schema files: blocks with string or identifier labels, attributes, and
+// expressions consisting of literals, traversals such as column.id, function
+// calls such as varchar(255) or sql("..."), and lists. Operators, templates,
+// and heredocs are not supported.
+
+// hclBody is the contents of an HCL file or block.
+type hclBody struct {
+	Attributes []*hclAttribute
+	Blocks     []*hclBlock
+}
+
+// Attr returns the value of the attribute with the supplied name, or nil if
+// the body has no such attribute.
+func (body *hclBody) Attr(name string) *hclValue {
+	for _, attr := range body.Attributes {
+		if attr.Name == name {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+// BlocksOfType returns the body's blocks with the supplied type, in order.
+func (body *hclBody) BlocksOfType(blockType string) []*hclBlock {
+	var blocks []*hclBlock
+	for _, block := range body.Blocks {
+		if block.Type == blockType {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+type hclAttribute struct {
+	Name  string
+	Value *hclValue
+	Line  int
+}
+
+type hclBlock struct {
+	Type   string
+	Labels []string
+	Body   *hclBody
+	Line   int
+}
+
+// hclValueKind enumerates the types of expression supported by the parser.
+type hclValueKind int
+
+// Constants for hclValueKind
+const (
+	hclString    hclValueKind = iota
+	hclNumber                 // stored in Str, as written
+	hclBool                   // stored in Str as "true" or "false"
+	hclNull                   // literal null
+	hclTraversal              // stored in Path, e.g. [column id] for column.id
+	hclCall                   // stored in Str (function name) and Items (args)
+	hclList                   // stored in Items
+)
+
+type hclValue struct {
+	Kind  hclValueKind
+	Str   string
+	Path  []string
+	Items []*hclValue
+}
+
+// String returns the value of a string literal, number, bool, or single-part
+// traversal, or an empty string for any other kind of value.
+func (v *hclValue) String() string {
+	if v == nil {
+		return ""
+	} else if v.Kind == hclTraversal && len(v.Path) == 1 {
+		return v.Path[0]
+	} else if v.Kind == hclString || v.Kind == hclNumber || v.Kind == hclBool {
+		return v.Str
+	}
+	return ""
+}
+
+// Bool returns true if v is the literal true.
+func (v *hclValue) Bool() bool {
+	return v != nil && v.Kind == hclBool && v.Str == "true"
+}
+
+type hclToken struct {
+	kind rune // 'i' identifier, 's' string, 'n' number, or the punctuation character itself; 0 for EOF
+	text string
+	line int
+}
+
+type hclParser struct {
+	tokens []hclToken
+	pos    int
+}
+
+// parseHCL parses src, returning its top-level body.
+func parseHCL(src string) (*hclBody, error) {
+	tokens, err := tokenizeHCL(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &hclParser{tokens: tokens}
+	body, err := p.parseBody(false)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func tokenizeHCL(src string) ([]hclToken, error) {
+	var tokens []hclToken
+	runes := []rune(src)
+	line := 1
+	for n := 0; n < len(runes); {
+		r := runes[n]
+		switch {
+		case r == '\n':
+			line++
+			n++
+		case unicode.IsSpace(r):
+			n++
+		case r == '#' || (r == '/' && n+1 < len(runes) && runes[n+1] == '/'):
+			for n < len(runes) && runes[n] != '\n' {
+				n++
+			}
+		case r == '/' && n+1 < len(runes) && runes[n+1] == '*':
+			n += 2
+			for n < len(runes) && !(runes[n] == '*' && n+1 < len(runes) && runes[n+1] == '/') {
+				if runes[n] == '\n' {
+					line++
+				}
+				n++
+			}
+			if n >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			n += 2
+		case r == '"':
+			var b strings.Builder
+			n++
+			for ; n < len(runes) && runes[n] != '"'; n++ {
+				if runes[n] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				} else if runes[n] == '\\' && n+1 < len(runes) {
+					n++
+					switch runes[n] {
+					case 'n':
+						b.WriteRune('\n')
+					case 't':
+						b.WriteRune('\t')
+					case 'r':
+						b.WriteRune('\r')
+					default:
+						b.WriteRune(runes[n])
+					}
+				} else if (runes[n] == '$' || runes[n] == '%') && n+2 < len(runes) && runes[n+1] == runes[n] && runes[n+2] == '{' {
+					b.WriteRune(runes[n])
+					n++
+				} else {
+					b.WriteRune(runes[n])
+				}
+			}
+			if n >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			n++
+			tokens = append(tokens, hclToken{kind: 's', text: b.String(), line: line})
+		case unicode.IsDigit(r) || (r == '-' && n+1 < len(runes) && unicode.IsDigit(runes[n+1])):
+			start := n
+			n++
+			for n < len(runes) && (unicode.IsDigit(runes[n]) || runes[n] == '.') {
+				n++
+			}
+			tokens = append(tokens, hclToken{kind: 'n', text: string(runes[start:n]), line: line})
+		case unicode.IsLetter(r) || r == '_':
+			start := n
+			for n < len(runes) && (unicode.IsLetter(runes[n]) || unicode.IsDigit(runes[n]) || runes[n] == '_' || runes[n] == '-') {
+				n++
+			}
+			tokens = append(tokens, hclToken{kind: 'i', text: string(runes[start:n]), line: line})
+		case strings.ContainsRune("{}[]()=,.", r):
+			tokens = append(tokens, hclToken{kind: r, text: string(r), line: line})
+			n++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, r)
+		}
+	}
+	return append(tokens, hclToken{line: line}), nil
+}
+
+func (p *hclParser) peek() hclToken {
+	return p.tokens[p.pos]
+}
+
+func (p *hclParser) next() hclToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != 0 {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *hclParser) expect(kind rune) (hclToken, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		found := tok.text
+		if tok.kind == 0 {
+			found = "end of file"
+		}
+		return tok, fmt.Errorf("line %d: expected %q, found %q", tok.line, string(kind), found)
+	}
+	return tok, nil
+}
+
+// parseBody parses attributes and blocks until EOF, or until a closing brace
+// if nested is true.
+func (p *hclParser) parseBody(nested bool) (*hclBody, error) {
+	body := &hclBody{}
+	for {
+		tok := p.peek()
+		if tok.kind == 0 && !nested {
+			return body, nil
+		} else if tok.kind == '}' && nested {
+			p.next()
+			return body, nil
+		}
+		name, err := p.expect('i')
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind == '=' {
+			p.next()
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			body.Attributes = append(body.Attributes, &hclAttribute{Name: name.text, Value: value, Line: name.line})
+			continue
+		}
+		block := &hclBlock{Type: name.text, Line: name.line}
+		for p.peek().kind == 's' || p.peek().kind == 'i' {
+			block.Labels = append(block.Labels, p.next().text)
+		}
+		if _, err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		if block.Body, err = p.parseBody(true); err != nil {
+			return nil, err
+		}
+		body.Blocks = append(body.Blocks, block)
+	}
+}
+
+func (p *hclParser) parseExpr() (*hclValue, error) {
+	tok := p.next()
+	switch tok.kind {
+	case 's':
+		return &hclValue{Kind: hclString, Str: tok.text}, nil
+	case 'n':
+		return &hclValue{Kind: hclNumber, Str: tok.text}, nil
+	case '[':
+		list := &hclValue{Kind: hclList}
+		for p.peek().kind != ']' {
+			item, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, item)
+			if p.peek().kind == ',' {
+				p.next()
+			} else if p.peek().kind != ']' {
+				return nil, fmt.Errorf("line %d: expected \",\" or \"]\" in list, found %q", p.peek().line, p.peek().text)
+			}
+		}
+		p.next()
+		return list, nil
+	case 'i':
+		if tok.text == "true" || tok.text == "false" {
+			return &hclValue{Kind: hclBool, Str: tok.text}, nil
+		} else if tok.text == "null" {
+			return &hclValue{Kind: hclNull}, nil
+		}
+		if p.peek().kind == '(' {
+			p.next()
+			call := &hclValue{Kind: hclCall, Str: tok.text}
+			for p.peek().kind != ')' {
+				arg, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				call.Items = append(call.Items, arg)
+				if p.peek().kind == ',' {
+					p.next()
+				} else if p.peek().kind != ')' {
+					return nil, fmt.Errorf("line %d: expected \",\" or \")\" in function call, found %q", p.peek().line, p.peek().text)
+				}
+			}
+			p.next()
+			return call, nil
+		}
+		traversal := &hclValue{Kind: hclTraversal, Path: []string{tok.text}}
+		for p.peek().kind == '.' {
+			p.next()
+			part := p.next()
+			if part.kind != 'i' && part.kind != 'n' {
+				return nil, fmt.Errorf("line %d: expected name after \".\", found %q", part.line, part.text)
+			}
+			traversal.Path = append(traversal.Path, part.text)
+		}
+		return traversal, nil
+	}
+	found := tok.text
+	if tok.kind == 0 {
+		found = "end of file"
+	}
+	return nil, fmt.Errorf("line %d: expected expression, found %q", tok.line, found)
+}
+
+// hclQuote returns s as an HCL string literal. Template sequences are escaped,
+// so that the value is always interpreted literally.
+func hclQuote(s string) string {
+	s = strconv.Quote(s)
+	s = strings.Replace(s, "${", "$${", -1)
+	return strings.Replace(s, "%{", "%%{", -1)
+}
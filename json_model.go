@@ -0,0 +1,219 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/skeema/tengo"
+)
+
+var partitionClauseRegexp = regexp.MustCompile(`(?s)\n(?:/\*!\d+ )?(PARTITION BY .*?)(?: \*/)?$`)
+
+// jsonSchema is the machine-readable model of a schema output by
+// `skeema dump-json`. Field names are stable, so that codegen and data catalog
+// tooling may rely on them.
+type jsonSchema struct {
+	Dir        string         `json:"dir"`
+	Name       string         `json:"name"`
+	CharSet    string         `json:"default_character_set,omitempty"`
+	Collation  string         `json:"default_collation,omitempty"`
+	Tables     []*jsonTable   `json:"tables"`
+	Procedures []*jsonRoutine `json:"procedures"`
+	Functions  []*jsonRoutine `json:"functions"`
+}
+
+type jsonTable struct {
+	Name            string            `json:"name"`
+	Engine          string            `json:"engine"`
+	CharSet         string            `json:"character_set"`
+	Collation       string            `json:"collation"`
+	CreateOptions   string            `json:"create_options,omitempty"`
+	Comment         string            `json:"comment,omitempty"`
+	Columns         []*jsonColumn     `json:"columns"`
+	PrimaryKey      *jsonIndex        `json:"primary_key"`
+	Indexes         []*jsonIndex      `json:"indexes"`
+	ForeignKeys     []*jsonForeignKey `json:"foreign_keys"`
+	Partitioning    string            `json:"partitioning,omitempty"`
+	CreateStatement string            `json:"create_statement,omitempty"`
+}
+
+type jsonColumn struct {
+	Name          string             `json:"name"`
+	Type          string             `json:"type"`
+	Nullable      bool               `json:"nullable"`
+	AutoIncrement bool               `json:"auto_increment,omitempty"`
+	Default       *jsonColumnDefault `json:"default,omitempty"`
+	OnUpdate      string             `json:"on_update,omitempty"`
+	CharSet       string             `json:"character_set,omitempty"`
+	Collation     string             `json:"collation,omitempty"`
+	Comment       string             `json:"comment,omitempty"`
+}
+
+// jsonColumnDefault represents a column's default. Value is nil for a default
+// of NULL. Expression is true if Value is an expression, such as
+// CURRENT_TIMESTAMP, rather than a literal.
+type jsonColumnDefault struct {
+	Value      *string `json:"value"`
+	Expression bool    `json:"expression,omitempty"`
+}
+
+type jsonIndex struct {
+	Name    string          `json:"name"`
+	Unique  bool            `json:"unique"`
+	Columns []jsonIndexPart `json:"columns"`
+	Comment string          `json:"comment,omitempty"`
+}
+
+type jsonIndexPart struct {
+	Name    string `json:"name"`
+	SubPart uint16 `json:"prefix_length,omitempty"`
+}
+
+type jsonForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referenced_schema,omitempty"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	UpdateRule        string   `json:"on_update"`
+	DeleteRule        string   `json:"on_delete"`
+}
+
+type jsonRoutine struct {
+	Name            string `json:"name"`
+	Params          string `json:"params"`
+	Returns         string `json:"returns,omitempty"`
+	Deterministic   bool   `json:"deterministic"`
+	SQLDataAccess   string `json:"sql_data_access,omitempty"`
+	SecurityType    string `json:"security_type,omitempty"`
+	Definer         string `json:"definer,omitempty"`
+	Comment         string `json:"comment,omitempty"`
+	Body            string `json:"body"`
+	CreateStatement string `json:"create_statement,omitempty"`
+}
+
+// newJSONSchema converts schema into its JSON model. The schema's tables and
+// routines should already be filtered to exclude any ignored objects. Tables
+// and routines are sorted by name, so that output is deterministic.
+func newJSONSchema(dirPath string, schema *tengo.Schema) *jsonSchema {
+	js := &jsonSchema{
+		Dir:        dirPath,
+		Name:       schema.Name,
+		CharSet:    schema.CharSet,
+		Collation:  schema.Collation,
+		Tables:     []*jsonTable{},
+		Procedures: []*jsonRoutine{},
+		Functions:  []*jsonRoutine{},
+	}
+	for _, table := range schema.Tables {
+		js.Tables = append(js.Tables, newJSONTable(table))
+	}
+	sort.Slice(js.Tables, func(i, j int) bool { return js.Tables[i].Name < js.Tables[j].Name })
+	for _, routine := range schema.Routines {
+		jr := &jsonRoutine{
+			Name:            routine.Name,
+			Params:          routine.ParamString,
+			Returns:         routine.ReturnDataType,
+			Deterministic:   routine.Deterministic,
+			SQLDataAccess:   routine.SQLDataAccess,
+			SecurityType:    routine.SecurityType,
+			Definer:         routine.Definer,
+			Comment:         routine.Comment,
+			Body:            routine.Body,
+			CreateStatement: routine.CreateStatement,
+		}
+		if routine.Type == tengo.ObjectTypeFunc {
+			js.Functions = append(js.Functions, jr)
+		} else {
+			js.Procedures = append(js.Procedures, jr)
+		}
+	}
+	sort.Slice(js.Procedures, func(i, j int) bool { return js.Procedures[i].Name < js.Procedures[j].Name })
+	sort.Slice(js.Functions, func(i, j int) bool { return js.Functions[i].Name < js.Functions[j].Name })
+	return js
+}
+
+func newJSONTable(table *tengo.Table) *jsonTable {
+	jt := &jsonTable{
+		Name:            table.Name,
+		Engine:          table.Engine,
+		CharSet:         table.CharSet,
+		Collation:       table.Collation,
+		CreateOptions:   table.CreateOptions,
+		Comment:         table.Comment,
+		Columns:         []*jsonColumn{},
+		Indexes:         []*jsonIndex{},
+		ForeignKeys:     []*jsonForeignKey{},
+		Partitioning:    tablePartitioning(table.CreateStatement),
+		CreateStatement: table.CreateStatement,
+	}
+	for _, col := range table.Columns {
+		jc := &jsonColumn{
+			Name:          col.Name,
+			Type:          col.TypeInDB,
+			Nullable:      col.Nullable,
+			AutoIncrement: col.AutoIncrement,
+			OnUpdate:      col.OnUpdate,
+			CharSet:       col.CharSet,
+			Collation:     col.Collation,
+			Comment:       col.Comment,
+		}
+		// A NULL default on a NOT NULL column means the column has no default
+		if !col.Default.Null {
+			value := col.Default.Value
+			jc.Default = &jsonColumnDefault{Value: &value, Expression: !col.Default.Quoted}
+		} else if col.Nullable && !col.AutoIncrement {
+			jc.Default = &jsonColumnDefault{}
+		}
+		jt.Columns = append(jt.Columns, jc)
+	}
+	if table.PrimaryKey != nil {
+		jt.PrimaryKey = newJSONIndex(table.PrimaryKey)
+	}
+	for _, idx := range table.SecondaryIndexes {
+		jt.Indexes = append(jt.Indexes, newJSONIndex(idx))
+	}
+	for _, fk := range table.ForeignKeys {
+		jfk := &jsonForeignKey{
+			Name:              fk.Name,
+			ReferencedSchema:  fk.ReferencedSchemaName,
+			ReferencedTable:   fk.ReferencedTableName,
+			ReferencedColumns: fk.ReferencedColumnNames,
+			UpdateRule:        fk.UpdateRule,
+			DeleteRule:        fk.DeleteRule,
+		}
+		for _, col := range fk.Columns {
+			jfk.Columns = append(jfk.Columns, col.Name)
+		}
+		jt.ForeignKeys = append(jt.ForeignKeys, jfk)
+	}
+	return jt
+}
+
+func newJSONIndex(idx *tengo.Index) *jsonIndex {
+	ji := &jsonIndex{
+		Name:    idx.Name,
+		Unique:  idx.Unique,
+		Comment: idx.Comment,
+	}
+	for n, col := range idx.Columns {
+		part := jsonIndexPart{Name: col.Name}
+		if n < len(idx.SubParts) {
+			part.SubPart = idx.SubParts[n]
+		}
+		ji.Columns = append(ji.Columns, part)
+	}
+	return ji
+}
+
+// tablePartitioning returns the partitioning clause of a CREATE TABLE
+// statement, or an empty string if the table is not partitioned. Partitioning
+// is not otherwise modeled by tengo, so the clause is output as-is, without
+// any version-gated comment wrapper.
+func tablePartitioning(createStatement string) string {
+	matches := partitionClauseRegexp.FindStringSubmatch(createStatement)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewJSONSchema(t *testing.T) {
+	schema := docsTestSchema()
+	schema.Tables[1].CreateStatement = "CREATE TABLE `posts` (\n  `id` bigint(20) unsigned NOT NULL\n) ENGINE=InnoDB\n/*!50100 PARTITION BY RANGE (`id`)\n(PARTITION p0 VALUES LESS THAN (100) ENGINE = InnoDB) */"
+	js := newJSONSchema("product", schema)
+
+	if len(js.Tables) != 2 || js.Tables[0].Name != "posts" || js.Tables[1].Name != "users" {
+		t.Fatalf("Tables not sorted as expected: %+v", js.Tables)
+	}
+	if len(js.Functions) != 1 || js.Functions[0].Returns != "int(11)" || len(js.Procedures) != 1 || js.Procedures[0].Name != "purge_posts" {
+		t.Errorf("Routines not split as expected: functions=%+v procedures=%+v", js.Functions, js.Procedures)
+	}
+	posts, users := js.Tables[0], js.Tables[1]
+	if expected := "PARTITION BY RANGE (`id`)\n(PARTITION p0 VALUES LESS THAN (100) ENGINE = InnoDB)"; posts.Partitioning != expected {
+		t.Errorf("Expected partitioning %q, instead found %q", expected, posts.Partitioning)
+	}
+	if users.Partitioning != "" {
+		t.Errorf("Expected no partitioning, instead found %q", users.Partitioning)
+	}
+
+	// Column defaults: none, NULL, literal, expression
+	if users.Columns[0].Default != nil {
+		t.Errorf("Expected no default for auto-increment column, instead found %+v", users.Columns[0].Default)
+	}
+	if d := users.Columns[1].Default; d == nil || d.Value != nil {
+		t.Errorf("Expected NULL default, instead found %+v", d)
+	}
+	if d := posts.Columns[0].Default; d != nil {
+		t.Errorf("Expected no default for NOT NULL column, instead found %+v", d)
+	}
+	if d := posts.Columns[1].Default; d == nil || d.Value == nil || *d.Value != "0" || d.Expression {
+		t.Errorf("Expected literal default of 0, instead found %+v", d)
+	}
+	if d := posts.Columns[2].Default; d == nil || d.Value == nil || *d.Value != "CURRENT_TIMESTAMP" || !d.Expression {
+		t.Errorf("Expected expression default of CURRENT_TIMESTAMP, instead found %+v", d)
+	}
+
+	// Indexes and foreign keys
+	if users.PrimaryKey == nil || users.PrimaryKey.Columns[0].Name != "id" || !users.PrimaryKey.Unique {
+		t.Errorf("Unexpected primary key: %+v", users.PrimaryKey)
+	}
+	if len(users.Indexes) != 1 || users.Indexes[0].Columns[0].SubPart != 10 {
+		t.Errorf("Unexpected secondary indexes: %+v", users.Indexes)
+	}
+	if len(posts.ForeignKeys) != 1 || posts.ForeignKeys[0].Columns[0] != "user_id" || posts.ForeignKeys[0].ReferencedTable != "users" || posts.ForeignKeys[0].DeleteRule != "CASCADE" {
+		t.Errorf("Unexpected foreign keys: %+v", posts.ForeignKeys)
+	}
+
+	// Confirm JSON field names, including empty slices rather than null
+	data, err := json.Marshal(js)
+	if err != nil {
+		t.Fatalf("Unexpected error from json.Marshal: %s", err)
+	}
+	out := string(data)
+	expected := []string{
+		`"dir":"product"`,
+		`"default_character_set":"utf8mb4"`,
+		`{"name":"name","prefix_length":10}`,
+		`"default":{"value":null}`,
+		`"default":{"value":"CURRENT_TIMESTAMP","expression":true}`,
+		`"referenced_columns":["id"]`,
+		`"indexes":[]`,
+		`"foreign_keys":[]`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected JSON to contain %s, but it did not. Full output: %s", exp, out)
+		}
+	}
+}
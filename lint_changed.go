@@ -0,0 +1,147 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// changedFiles is a set of absolute file paths which differ from a git ref.
+// It is used by `skeema lint --changed-since` to restrict linting to new or
+// modified objects.
+type changedFiles map[string]bool
+
+// gitChangedFiles returns the set of files in the git repo containing dirPath
+// which differ between ref and the working tree, including untracked files.
+// Both the old and new paths of renamed files are included.
+func gitChangedFiles(dirPath, ref string) (changedFiles, error) {
+	topLevel, err := gitCapture(dirPath, "git rev-parse --show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	topLevel = strings.TrimSpace(topLevel)
+	diff, err := util.NewInterpolatedShellOut("git diff --name-only --no-renames {REF} --", map[string]string{"REF": ref})
+	if err != nil {
+		return nil, err
+	}
+	diff.Dir = dirPath
+	tracked, err := diff.RunCapture()
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitCapture(dirPath, "git ls-files --others --exclude-standard --full-name")
+	if err != nil {
+		return nil, err
+	}
+	cf := make(changedFiles)
+	for _, line := range strings.Split(tracked+"\n"+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			cf[filepath.Join(topLevel, filepath.FromSlash(line))] = true
+		}
+	}
+	return cf, nil
+}
+
+func gitCapture(dirPath, command string) (string, error) {
+	s := &util.ShellOut{Command: command, Dir: dirPath}
+	return s.RunCapture()
+}
+
+// affectsDir returns true if any changed file is located directly in dir (or
+// in one of the subdirs it owns, such as object type subdirs or seed data), is
+// shared with dir via symlink, or if dir's configuration may have changed.
+func (cf changedFiles) affectsDir(dir *fs.Dir) bool {
+	for path := range cf.withLinks(dir) {
+		parent := filepath.Dir(path)
+		if parent == dir.Path {
+			return true
+		} else if filepath.Dir(parent) == dir.Path && dir.OwnsSubdir(filepath.Base(parent)) {
+			return true
+		}
+	}
+	return cf.configChanged(dir)
+}
+
+// withLinks returns a copy of cf which additionally includes the paths of any
+// *.sql files in dir that are symlinks to changed files.
+func (cf changedFiles) withLinks(dir *fs.Dir) changedFiles {
+	result := make(changedFiles, len(cf))
+	for path := range cf {
+		result[path] = true
+	}
+	for _, sf := range dir.SQLFiles {
+		if linkedPath := dir.LinkedPath(sf); linkedPath != "" && cf[linkedPath] {
+			result[sf.Path()] = true
+		}
+	}
+	return result
+}
+
+// configChanged returns true if the .skeema file in dir or any of its parent
+// directories has changed.
+func (cf changedFiles) configChanged(dir *fs.Dir) bool {
+	for path := range cf {
+		if filepath.Base(path) != ".skeema" {
+			continue
+		}
+		configDir := filepath.Dir(path)
+		if configDir == dir.Path || strings.HasPrefix(dir.Path, configDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterResult removes annotations from result which do not relate to changed
+// files in dir. Annotations are retained for objects defined in changed files,
+// as well as tables with foreign keys referencing those objects, since changes
+// to a parent table can cause problems in its children. If dir's configuration
+// has changed, nothing is removed.
+func (cf changedFiles) filterResult(result *linter.Result, dir *fs.Dir) {
+	if cf.configChanged(dir) {
+		return
+	}
+	cf = cf.withLinks(dir)
+	changedKeys := make(map[tengo.ObjectKey]bool)
+	for _, logicalSchema := range dir.LogicalSchemas {
+		for key, stmt := range logicalSchema.Creates {
+			if cf[stmt.File] {
+				changedKeys[key] = true
+			}
+		}
+	}
+	dependents := fkDependents(result, changedKeys)
+	filter := func(annotations []*linter.Annotation) []*linter.Annotation {
+		kept := make([]*linter.Annotation, 0, len(annotations))
+		for _, a := range annotations {
+			if cf[a.Statement.File] || dependents[a.Statement.ObjectKey()] {
+				kept = append(kept, a)
+			}
+		}
+		return kept
+	}
+	result.Errors = filter(result.Errors)
+	result.Warnings = filter(result.Warnings)
+	result.FormatNotices = filter(result.FormatNotices)
+}
+
+// fkDependents returns the set of tables in result's schemas which have a
+// foreign key referencing any table in changedKeys.
+func fkDependents(result *linter.Result, changedKeys map[tengo.ObjectKey]bool) map[tengo.ObjectKey]bool {
+	dependents := make(map[tengo.ObjectKey]bool)
+	for _, schema := range result.Schemas {
+		for _, table := range schema.Tables {
+			for _, fk := range table.ForeignKeys {
+				refKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: fk.ReferencedTableName}
+				if (fk.ReferencedSchemaName == "" || fk.ReferencedSchemaName == schema.Name) && changedKeys[refKey] {
+					dependents[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}] = true
+				}
+			}
+		}
+	}
+	return dependents
+}
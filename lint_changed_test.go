@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/tengo"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repoDir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	repoDir, _ = filepath.EvalSymlinks(repoDir)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s\n%s", args, err, out)
+		}
+	}
+	write := func(relPath, contents string) {
+		t.Helper()
+		path := filepath.Join(repoDir, relPath)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := ioutil.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write %s: %s", path, err)
+		}
+	}
+	git("init", "-q")
+	write("product/users.sql", "CREATE TABLE users (id int);\n")
+	write("product/posts.sql", "CREATE TABLE posts (id int);\n")
+	write("analytics/events.sql", "CREATE TABLE events (id int);\n")
+	write("common/shared.sql", "CREATE TABLE shared (id int);\n")
+	if err := os.Symlink("../common/shared.sql", filepath.Join(repoDir, "analytics", "shared.sql")); err != nil {
+		t.Fatalf("Unable to create symlink: %s", err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	write("product/posts.sql", "CREATE TABLE posts (id bigint);\n")
+	write("product/comments.sql", "CREATE TABLE comments (id int);\n")
+	cf, err := gitChangedFiles(filepath.Join(repoDir, "product"), "HEAD")
+	if err != nil {
+		t.Fatalf("Unexpected error from gitChangedFiles: %s", err)
+	}
+	expected := []string{"product/posts.sql", "product/comments.sql"}
+	if len(cf) != len(expected) {
+		t.Errorf("Expected %d changed files, instead found %v", len(expected), cf)
+	}
+	for _, relPath := range expected {
+		if !cf[filepath.Join(repoDir, filepath.FromSlash(relPath))] {
+			t.Errorf("Expected %s to be included in changed files, but it was not: %v", relPath, cf)
+		}
+	}
+	if !cf.affectsDir(&fs.Dir{Path: filepath.Join(repoDir, "product")}) {
+		t.Error("Expected product dir to be affected, but it was not")
+	}
+	if cf.affectsDir(&fs.Dir{Path: filepath.Join(repoDir, "analytics")}) {
+		t.Error("Expected analytics dir to be unaffected, but it was")
+	}
+
+	// A change to a file shared via symlink affects dirs containing the symlink
+	write("common/shared.sql", "CREATE TABLE shared (id bigint);\n")
+	if cf, err = gitChangedFiles(repoDir, "HEAD"); err != nil {
+		t.Fatalf("Unexpected error from gitChangedFiles: %s", err)
+	}
+	analytics := &fs.Dir{
+		Path:     filepath.Join(repoDir, "analytics"),
+		SQLFiles: []fs.SQLFile{{Dir: filepath.Join(repoDir, "analytics"), FileName: "shared.sql"}},
+	}
+	if !cf.affectsDir(analytics) {
+		t.Error("Expected analytics dir to be affected by change to shared file, but it was not")
+	}
+
+	// A config change in a parent dir affects all subdirs
+	write(".skeema", "errors=no-pk\n")
+	if cf, err = gitChangedFiles(repoDir, "HEAD"); err != nil {
+		t.Fatalf("Unexpected error from gitChangedFiles: %s", err)
+	}
+	if !cf.affectsDir(&fs.Dir{Path: filepath.Join(repoDir, "analytics")}) {
+		t.Error("Expected analytics dir to be affected by config change, but it was not")
+	}
+
+	if _, err := gitChangedFiles(repoDir, "no-such-ref"); err == nil {
+		t.Error("Expected error from gitChangedFiles with invalid ref, but err was nil")
+	}
+}
+
+func TestChangedFilesFilterResult(t *testing.T) {
+	dirPath := "/var/schemas/product"
+	stmt := func(name string) *fs.Statement {
+		return &fs.Statement{
+			File:       filepath.Join(dirPath, name+".sql"),
+			Type:       fs.StatementTypeCreate,
+			ObjectType: tengo.ObjectTypeTable,
+			ObjectName: name,
+		}
+	}
+	users, posts, comments := stmt("users"), stmt("posts"), stmt("comments")
+	dir := &fs.Dir{
+		Path: dirPath,
+		LogicalSchemas: []*fs.LogicalSchema{{
+			Creates: map[tengo.ObjectKey]*fs.Statement{
+				users.ObjectKey():    users,
+				posts.ObjectKey():    posts,
+				comments.ObjectKey(): comments,
+			},
+		}},
+	}
+	schema := &tengo.Schema{
+		Name: "product",
+		Tables: []*tengo.Table{
+			{Name: "users"},
+			{Name: "posts", ForeignKeys: []*tengo.ForeignKey{{Name: "posts_ibfk_1", ReferencedTableName: "users"}}},
+			{Name: "comments", ForeignKeys: []*tengo.ForeignKey{{Name: "comments_ibfk_1", ReferencedTableName: "posts"}}},
+		},
+	}
+	result := &linter.Result{
+		Warnings: []*linter.Annotation{
+			{Statement: users, Problem: "no-pk"},
+			{Statement: posts, Problem: "no-pk"},
+			{Statement: comments, Problem: "no-pk"},
+		},
+		FormatNotices: []*linter.Annotation{{Statement: comments}},
+		Schemas:       map[string]*tengo.Schema{dirPath: schema},
+	}
+
+	// Changing users.sql should retain annotations for users, and for posts
+	// since it has a foreign key referencing users
+	cf := changedFiles{users.File: true}
+	cf.filterResult(result, dir)
+	if len(result.Warnings) != 2 || result.Warnings[0].Statement != users || result.Warnings[1].Statement != posts {
+		t.Errorf("Unexpected warnings after filtering: %+v", result.Warnings)
+	}
+	if len(result.FormatNotices) != 0 {
+		t.Errorf("Unexpected format notices after filtering: %+v", result.FormatNotices)
+	}
+}
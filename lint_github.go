@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skeema/skeema/linter"
+)
+
+// githubEscaper and githubPropertyEscaper escape values for use in GitHub
+// Actions workflow commands. See
+// https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+var (
+	githubEscaper         = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// githubRelPath returns the path of a file relative to the root of the GitHub
+// Actions workspace, falling back to the working directory if not running in
+// GitHub Actions.
+func githubRelPath(filePath string) string {
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		if rel, err := filepath.Rel(workspace, filePath); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return lintRelPath(filePath)
+}
+
+// writeLintGitHub writes the errors and warnings in result to w as GitHub
+// Actions workflow commands, which cause annotations to be displayed on the
+// corresponding files and lines of a pull request.
+func writeLintGitHub(w io.Writer, result *linter.Result) error {
+	add := func(annotations []*linter.Annotation, severity linter.Severity) error {
+		for _, a := range annotations {
+			var props []string
+			if a.Statement.File != "" && a.Statement.LineNo > 0 {
+				props = append(props,
+					"file="+githubPropertyEscaper.Replace(githubRelPath(a.Statement.File)),
+					fmt.Sprintf("line=%d", a.Statement.LineNo+a.LineOffset))
+			}
+			props = append(props, "title="+githubPropertyEscaper.Replace(lintRuleID(a, severity)))
+			if _, err := fmt.Fprintf(w, "::%s %s::%s\n", severity, strings.Join(props, ","), githubEscaper.Replace(a.DisplayMessage())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(result.Errors, linter.SeverityError); err != nil {
+		return err
+	}
+	return add(result.Warnings, linter.SeverityWarning)
+}
+
+// githubCheckRun represents a check run to be created via the GitHub Checks
+// API.
+type githubCheckRun struct {
+	Name    string
+	APIURL  string
+	Repo    string // in "owner/name" format
+	HeadSHA string
+	Token   string
+}
+
+// githubMaxAnnotations is the maximum number of annotations that may be
+// supplied in a single request to the Checks API.
+const githubMaxAnnotations = 50
+
+// newGitHubCheckRun returns a githubCheckRun based on the environment variables
+// set by GitHub Actions. The token must be supplied via the GITHUB_TOKEN env
+// var, which is not set automatically by GitHub Actions.
+func newGitHubCheckRun(name string) (*githubCheckRun, error) {
+	cr := &githubCheckRun{
+		Name:    name,
+		APIURL:  os.Getenv("GITHUB_API_URL"),
+		Repo:    os.Getenv("GITHUB_REPOSITORY"),
+		HeadSHA: os.Getenv("GITHUB_SHA"),
+		Token:   os.Getenv("GITHUB_TOKEN"),
+	}
+	if cr.APIURL == "" {
+		cr.APIURL = "https://api.github.com"
+	}
+	if cr.Token == "" {
+		return nil, errors.New("Option github-check-run requires env var GITHUB_TOKEN to be set")
+	} else if cr.Repo == "" {
+		return nil, errors.New("Option github-check-run requires env var GITHUB_REPOSITORY to be set")
+	}
+
+	// For pull_request events, GITHUB_SHA refers to a merge commit, but the check
+	// run must be associated with the head commit of the pull request
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		if contents, err := ioutil.ReadFile(eventPath); err == nil {
+			var event struct {
+				PullRequest struct {
+					Head struct {
+						SHA string `json:"sha"`
+					} `json:"head"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(contents, &event) == nil && event.PullRequest.Head.SHA != "" {
+				cr.HeadSHA = event.PullRequest.Head.SHA
+			}
+		}
+	}
+	if cr.HeadSHA == "" {
+		return nil, errors.New("Option github-check-run requires env var GITHUB_SHA to be set")
+	}
+	return cr, nil
+}
+
+type githubAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type githubCheckOutput struct {
+	Title       string             `json:"title"`
+	Summary     string             `json:"summary"`
+	Annotations []githubAnnotation `json:"annotations"`
+}
+
+// Post creates a completed check run summarizing result. Annotations are
+// supplied for any errors and warnings with a known file location. Since the
+// Checks API limits the number of annotations per request, the check run may
+// be updated multiple times to supply all annotations.
+func (cr *githubCheckRun) Post(result *linter.Result) error {
+	var annotations []githubAnnotation
+	add := func(list []*linter.Annotation, severity linter.Severity, level string) {
+		for _, a := range list {
+			if a.Statement.File == "" || a.Statement.LineNo == 0 {
+				continue
+			}
+			line := a.Statement.LineNo + a.LineOffset
+			annotations = append(annotations, githubAnnotation{
+				Path:            githubRelPath(a.Statement.File),
+				StartLine:       line,
+				EndLine:         line,
+				AnnotationLevel: level,
+				Title:           lintRuleID(a, severity),
+				Message:         a.DisplayMessage(),
+			})
+		}
+	}
+	add(result.Errors, linter.SeverityError, "failure")
+	add(result.Warnings, linter.SeverityWarning, "warning")
+
+	conclusion := "success"
+	if len(result.Errors) > 0 || len(result.Exceptions) > 0 {
+		conclusion = "failure"
+	} else if len(result.Warnings) > 0 {
+		conclusion = "neutral"
+	}
+	output := githubCheckOutput{
+		Title:   fmt.Sprintf("%d errors, %d warnings", len(result.Errors), len(result.Warnings)),
+		Summary: fmt.Sprintf("skeema lint found %d errors and %d warnings. %d directories could not be linted due to fatal errors.", len(result.Errors), len(result.Warnings), len(result.Exceptions)),
+	}
+	nextBatch := func() []githubAnnotation {
+		batch := annotations
+		if len(batch) > githubMaxAnnotations {
+			batch = batch[:githubMaxAnnotations]
+		}
+		annotations = annotations[len(batch):]
+		return batch
+	}
+
+	output.Annotations = nextBatch()
+	body := map[string]interface{}{
+		"name":       cr.Name,
+		"head_sha":   cr.HeadSHA,
+		"status":     "completed",
+		"conclusion": conclusion,
+		"output":     output,
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/check-runs", strings.TrimSuffix(cr.APIURL, "/"), cr.Repo)
+	if err := cr.request("POST", url, body, &created); err != nil {
+		return err
+	}
+	for len(annotations) > 0 {
+		output.Annotations = nextBatch()
+		if err := cr.request("PATCH", fmt.Sprintf("%s/%d", url, created.ID), map[string]interface{}{"output": output}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// request sends a JSON request to the GitHub API, optionally decoding the JSON
+// response into dest.
+func (cr *githubCheckRun) request(method, url string, body interface{}, dest interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+cr.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GitHub API returned HTTP %d for %s %s: %s", resp.StatusCode, method, url, strings.TrimSpace(string(respBody)))
+	}
+	if dest != nil {
+		return json.Unmarshal(respBody, dest)
+	}
+	return nil
+}
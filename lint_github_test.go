@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+)
+
+func TestWriteLintGitHub(t *testing.T) {
+	defer os.Setenv("GITHUB_WORKSPACE", os.Getenv("GITHUB_WORKSPACE"))
+	os.Unsetenv("GITHUB_WORKSPACE")
+	wd, _ := os.Getwd()
+	stmt := &fs.Statement{File: filepath.Join(wd, "mydb", "product", "users.sql"), LineNo: 3}
+	result := &linter.Result{
+		Errors: []*linter.Annotation{
+			{Statement: stmt, LineOffset: 2, Problem: "no-pk", Message: "Table users does not define a PRIMARY KEY"},
+		},
+		Warnings: []*linter.Annotation{
+			{Statement: &fs.Statement{}, Message: "100% unparseable\nstatement"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := writeLintGitHub(&buf, result); err != nil {
+		t.Fatalf("Unexpected error from writeLintGitHub: %s", err)
+	}
+	expected := "::error file=mydb/product/users.sql,line=5,title=no-pk::Table users does not define a PRIMARY KEY\n" +
+		"::warning title=unparseable-sql::100%25 unparseable%0Astatement\n"
+	if actual := buf.String(); actual != expected {
+		t.Errorf("Unexpected output from writeLintGitHub:\n%s", actual)
+	}
+}
+
+func TestGitHubCheckRunPost(t *testing.T) {
+	var requests []string
+	var annotationCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		if r.Header.Get("Authorization") != "token abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Conclusion string            `json:"conclusion"`
+			Output     githubCheckOutput `json:"output"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Method == "POST" && body.Conclusion != "failure" {
+			t.Errorf("Unexpected conclusion %q", body.Conclusion)
+		}
+		annotationCount += len(body.Output.Annotations)
+		w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	result := &linter.Result{}
+	for n := 1; n <= 60; n++ {
+		result.Errors = append(result.Errors, &linter.Annotation{
+			Statement: &fs.Statement{File: "/tmp/foo.sql", LineNo: n},
+			Problem:   "no-pk",
+			Message:   "Table foo does not define a PRIMARY KEY",
+		})
+	}
+	cr := &githubCheckRun{Name: "skeema lint", APIURL: server.URL, Repo: "owner/repo", HeadSHA: "deadbeef", Token: "abc123"}
+	if err := cr.Post(result); err != nil {
+		t.Fatalf("Unexpected error from Post: %s", err)
+	}
+	expectedRequests := []string{"POST /repos/owner/repo/check-runs", "PATCH /repos/owner/repo/check-runs/42"}
+	if fmt.Sprint(requests) != fmt.Sprint(expectedRequests) || annotationCount != 60 {
+		t.Errorf("Unexpected requests %v with %d total annotations", requests, annotationCount)
+	}
+
+	cr.Token = "wrong"
+	if err := cr.Post(result); err == nil {
+		t.Error("Expected error from Post with bad token, but err was nil")
+	}
+}
+
+func TestNewGitHubCheckRun(t *testing.T) {
+	for _, name := range []string{"GITHUB_TOKEN", "GITHUB_REPOSITORY", "GITHUB_SHA", "GITHUB_EVENT_PATH", "GITHUB_API_URL"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	if _, err := newGitHubCheckRun("skeema lint"); err == nil {
+		t.Error("Expected error from newGitHubCheckRun without GITHUB_TOKEN, but err was nil")
+	}
+	os.Setenv("GITHUB_TOKEN", "abc123")
+	os.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	os.Setenv("GITHUB_SHA", "mergesha")
+	eventPath := "github-event-test.json"
+	fs.WriteTestFile(t, eventPath, `{"pull_request": {"head": {"sha": "headsha"}}}`)
+	defer os.Remove(eventPath)
+	os.Setenv("GITHUB_EVENT_PATH", eventPath)
+	cr, err := newGitHubCheckRun("skeema lint")
+	if err != nil {
+		t.Fatalf("Unexpected error from newGitHubCheckRun: %s", err)
+	}
+	if cr.HeadSHA != "headsha" || cr.APIURL != "https://api.github.com" || cr.Repo != "owner/repo" {
+		t.Errorf("Unexpected result from newGitHubCheckRun: %+v", cr)
+	}
+}
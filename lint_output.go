@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/skeema/skeema/linter"
+)
+
+// lintRuleID returns an identifier for the type of problem represented by a.
+// Annotations without a problem name come from invalid or unparseable SQL.
+func lintRuleID(a *linter.Annotation, severity linter.Severity) string {
+	if a.Problem != "" {
+		return a.Problem
+	} else if severity == linter.SeverityError {
+		return "invalid-sql"
+	}
+	return "unparseable-sql"
+}
+
+// lintRelPath returns the path of a file relative to the working directory, if
+// possible, using forward slashes.
+func lintRelPath(filePath string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filePath); err == nil {
+			filePath = rel
+		}
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// Types for representing a subset of the SARIF 2.1.0 format. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeLintSARIF writes the errors and warnings in result to w, using the
+// SARIF format understood by GitHub code scanning and other tools.
+func writeLintSARIF(w io.Writer, result *linter.Result) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "skeema",
+				Version:        version,
+				InformationURI: "https://www.skeema.io",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+	ruleSummaries := make(map[string]string)
+	add := func(annotations []*linter.Annotation, severity linter.Severity) {
+		for _, a := range annotations {
+			ruleID := lintRuleID(a, severity)
+			if _, already := ruleSummaries[ruleID]; !already {
+				ruleSummaries[ruleID] = a.Summary
+			}
+			sr := sarifResult{
+				RuleID:  ruleID,
+				Level:   string(severity),
+				Message: sarifMessage{Text: a.DisplayMessage()},
+			}
+			if a.Statement.File != "" && a.Statement.LineNo > 0 {
+				region := sarifRegion{StartLine: a.Statement.LineNo + a.LineOffset}
+				if a.LineOffset == 0 && a.Statement.CharNo > 1 {
+					region.StartColumn = a.Statement.CharNo
+				}
+				sr.Locations = []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: lintRelPath(a.Statement.File), URIBaseID: "%SRCROOT%"},
+						Region:           region,
+					},
+				}}
+			}
+			run.Results = append(run.Results, sr)
+		}
+	}
+	add(result.Errors, linter.SeverityError)
+	add(result.Warnings, linter.SeverityWarning)
+
+	ruleIDs := make([]string, 0, len(ruleSummaries))
+	for ruleID := range ruleSummaries {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	for _, ruleID := range ruleIDs {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               ruleID,
+			ShortDescription: sarifMessage{Text: ruleSummaries[ruleID]},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// lintJSONFinding represents a single error or warning in JSON output.
+type lintJSONFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Object   string `json:"object,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Message  string `json:"message"`
+}
+
+// lintJSONSummary tallies the outcome of a lint run in JSON output.
+type lintJSONSummary struct {
+	Errors        int `json:"errors"`
+	Warnings      int `json:"warnings"`
+	Reformatted   int `json:"reformatted"`
+	Fixed         int `json:"fixed"`
+	FatalProblems int `json:"fatal_problems"`
+}
+
+type lintJSONReport struct {
+	Findings []lintJSONFinding `json:"findings"`
+	Summary  lintJSONSummary   `json:"summary"`
+}
+
+// writeLintJSON writes the errors and warnings in result to w as a single JSON
+// document, including a summary of the run.
+func writeLintJSON(w io.Writer, result *linter.Result) error {
+	report := lintJSONReport{
+		Findings: []lintJSONFinding{},
+		Summary: lintJSONSummary{
+			Errors:        len(result.Errors),
+			Warnings:      len(result.Warnings),
+			Reformatted:   len(result.FormatNotices),
+			Fixed:         len(result.Fixes),
+			FatalProblems: len(result.Exceptions),
+		},
+	}
+	add := func(annotations []*linter.Annotation, severity linter.Severity) {
+		for _, a := range annotations {
+			finding := lintJSONFinding{
+				Rule:     lintRuleID(a, severity),
+				Severity: string(severity),
+				Summary:  a.Summary,
+				Message:  a.DisplayMessage(),
+			}
+			if a.Statement.ObjectName != "" {
+				finding.Object = a.Statement.ObjectKey().String()
+			}
+			if a.Statement.File != "" {
+				finding.File = lintRelPath(a.Statement.File)
+			}
+			if a.Statement.LineNo > 0 {
+				finding.Line = a.Statement.LineNo + a.LineOffset
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	add(result.Errors, linter.SeverityError)
+	add(result.Warnings, linter.SeverityWarning)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
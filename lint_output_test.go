@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+)
+
+func TestWriteLintSARIF(t *testing.T) {
+	wd, _ := os.Getwd()
+	stmt := &fs.Statement{File: filepath.Join(wd, "mydb", "product", "users.sql"), LineNo: 3, CharNo: 1}
+	result := &linter.Result{
+		Errors: []*linter.Annotation{
+			{Statement: stmt, LineOffset: 2, Problem: "no-pk", Summary: "No primary key", Message: "Table users does not define a PRIMARY KEY"},
+			{Statement: stmt, Message: "Error 1064: You have an error in your SQL syntax"},
+		},
+		Warnings: []*linter.Annotation{
+			{Statement: &fs.Statement{Text: "INSERT INTO foo VALUES (1)"}, Summary: "Unable to parse statement", Message: "Ignoring unsupported or unparseable SQL statement"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := writeLintSARIF(&buf, result); err != nil {
+		t.Fatalf("Unexpected error from writeLintSARIF: %s", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Unable to unmarshal output of writeLintSARIF: %s", err)
+	}
+	if len(log.Runs) != 1 || len(log.Runs[0].Results) != 3 || len(log.Runs[0].Tool.Driver.Rules) != 3 {
+		t.Fatalf("Unexpected SARIF output: %s", buf.String())
+	}
+	res := log.Runs[0].Results
+	if res[0].RuleID != "no-pk" || res[0].Level != "error" || len(res[0].Locations) != 1 {
+		t.Errorf("Unexpected first result: %+v", res[0])
+	} else if loc := res[0].Locations[0].PhysicalLocation; loc.ArtifactLocation.URI != "mydb/product/users.sql" || loc.Region.StartLine != 5 {
+		t.Errorf("Unexpected location in first result: %+v", loc)
+	}
+	if res[1].RuleID != "invalid-sql" || res[2].RuleID != "unparseable-sql" || res[2].Level != "warning" || len(res[2].Locations) != 0 {
+		t.Errorf("Unexpected results: %+v", res)
+	}
+}
+
+func TestWriteLintJSON(t *testing.T) {
+	wd, _ := os.Getwd()
+	stmt := &fs.Statement{File: filepath.Join(wd, "mydb", "product", "users.sql"), LineNo: 3, ObjectType: "table", ObjectName: "users"}
+	result := &linter.Result{
+		Errors: []*linter.Annotation{
+			{Statement: stmt, LineOffset: 2, Problem: "no-pk", Summary: "No primary key", Message: "Table users does not define a PRIMARY KEY"},
+		},
+		Warnings: []*linter.Annotation{
+			{Statement: stmt, Problem: "bad-engine", Message: "Table users is using storage engine MyISAM"},
+		},
+		FormatNotices: []*linter.Annotation{{Statement: stmt}},
+	}
+	var buf bytes.Buffer
+	if err := writeLintJSON(&buf, result); err != nil {
+		t.Fatalf("Unexpected error from writeLintJSON: %s", err)
+	}
+	var report lintJSONReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Unable to unmarshal output of writeLintJSON: %s", err)
+	}
+	expectedSummary := lintJSONSummary{Errors: 1, Warnings: 1, Reformatted: 1}
+	if report.Summary != expectedSummary || len(report.Findings) != 2 {
+		t.Fatalf("Unexpected JSON output: %s", buf.String())
+	}
+	expectedFinding := lintJSONFinding{
+		Rule:     "no-pk",
+		Severity: "error",
+		Object:   "table `users`",
+		File:     "mydb/product/users.sql",
+		Line:     5,
+		Summary:  "No primary key",
+		Message:  "Table users does not define a PRIMARY KEY",
+	}
+	if report.Findings[0] != expectedFinding {
+		t.Errorf("Unexpected first finding: %+v", report.Findings[0])
+	}
+	if report.Findings[1].Rule != "bad-engine" || report.Findings[1].Severity != "warning" {
+		t.Errorf("Unexpected second finding: %+v", report.Findings[1])
+	}
+}
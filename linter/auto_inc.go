@@ -0,0 +1,47 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// autoIncType returns the base integer type of an auto_increment column, along
+// with an " unsigned" suffix if applicable; for example "bigint unsigned".
+// Display widths and zerofill are ignored.
+func autoIncType(col *tengo.Column) string {
+	base, _ := parseColumnType(col.TypeInDB)
+	if strings.Contains(strings.ToLower(col.TypeInDB), "unsigned") {
+		return base + " unsigned"
+	}
+	return base
+}
+
+func autoIncDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		for _, col := range table.Columns {
+			if !col.AutoIncrement {
+				continue
+			}
+			colType := autoIncType(col)
+			if isAllowed(colType, opts.AllowedAutoIncTypes) {
+				continue
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+				Summary:    "Auto-increment column type may be exhausted",
+				Message: fmt.Sprintf(
+					"Column %s of table %s is an auto_increment column using type %s, which is not listed in option allow-auto-inc. Running out of values will cause inserts to fail. Permitted types: %s",
+					col.Name, table.Name, strings.ToUpper(colType), strings.ToUpper(strings.Join(opts.AllowedAutoIncTypes, ", ")),
+				),
+			})
+		}
+	}
+	return results
+}
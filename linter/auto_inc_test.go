@@ -0,0 +1,53 @@
+package linter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestAutoIncDetector(t *testing.T) {
+	makeTable := func(name, colType string) *tengo.Table {
+		return &tengo.Table{
+			Name: name,
+			Columns: []*tengo.Column{
+				{Name: "name", TypeInDB: "varchar(30)"},
+				{Name: "id", TypeInDB: colType, AutoIncrement: true},
+			},
+			CreateStatement: "CREATE TABLE `" + name + "` (\n" +
+				"  `name` varchar(30) NOT NULL,\n" +
+				"  `id` " + colType + " NOT NULL AUTO_INCREMENT,\n" +
+				"  PRIMARY KEY (`id`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+		}
+	}
+	schema, logicalSchema := testSchema(
+		makeTable("signed_int", "int(11)"),
+		makeTable("unsigned_int", "int(10) unsigned"),
+		makeTable("small", "smallint(5) unsigned"),
+		makeTable("big", "bigint(20) unsigned zerofill"),
+	)
+	opts := Options{AllowedAutoIncTypes: []string{"int unsigned", "bigint unsigned"}}
+	annotations := autoIncDetector(schema, logicalSchema, opts)
+	var flagged []string
+	for _, a := range annotations {
+		if a.LineOffset != 2 {
+			t.Errorf("Expected annotation on line offset 2, instead found %d", a.LineOffset)
+		}
+		flagged = append(flagged, a.Statement.ObjectName)
+	}
+	if expected := []string{"signed_int", "small"}; !reflect.DeepEqual(flagged, expected) {
+		t.Errorf("Expected tables %v to be flagged, instead found %v", expected, flagged)
+	}
+	if !strings.Contains(annotations[1].Message, "type SMALLINT UNSIGNED") {
+		t.Errorf("Unexpected message: %s", annotations[1].Message)
+	}
+
+	// Stricter policy only permitting BIGINT UNSIGNED
+	opts.AllowedAutoIncTypes = []string{"bigint unsigned"}
+	if annotations := autoIncDetector(schema, logicalSchema, opts); len(annotations) != 3 {
+		t.Errorf("Expected 3 annotations, instead found %d", len(annotations))
+	}
+}
@@ -0,0 +1,42 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func badCollationDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if !isAllowed(table.Collation, opts.AllowedCollations) {
+			re := regexp.MustCompile(fmt.Sprintf(`(?i)(default)?\s*(character\s+set|charset|collate)\s*=?\s*(%s|%s)`, table.CharSet, table.Collation))
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findLastLineOffset(re, stmt.Text),
+				Summary:    "Collation not permitted",
+				Message:    fmt.Sprintf("Table %s is using default collation %s, which is not listed in option allow-collation", table.Name, table.Collation),
+			})
+		}
+
+		// Columns are checked regardless of the table's default, since a column
+		// may override the default with a different collation
+		for _, col := range table.Columns {
+			if col.Collation == "" || col.Collation == table.Collation || isAllowed(col.Collation, opts.AllowedCollations) {
+				continue
+			}
+			re := identifierRegexp("", col.Name, fmt.Sprintf(`\s.*(character\s+set|charset|collate)\s*(%s|%s)`, col.CharSet, col.Collation))
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(re, stmt.Text),
+				Summary:    "Collation not permitted",
+				Message:    fmt.Sprintf("Column %s of table %s is using collation %s, which is not listed in option allow-collation", col.Name, table.Name, col.Collation),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,45 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestBadCollationDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name:      "posts",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_0900_ai_ci",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "title", TypeInDB: "varchar(80)", CharSet: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"},
+			{Name: "slug", TypeInDB: "varchar(80)", CharSet: "utf8mb4", Collation: "utf8mb4_bin"},
+			{Name: "legacy", TypeInDB: "varchar(80)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+		},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `title` varchar(80) NOT NULL,\n" +
+			"  `slug` varchar(80) COLLATE utf8mb4_bin NOT NULL,\n" +
+			"  `legacy` varchar(80) COLLATE utf8mb4_general_ci NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci",
+	}
+	schema, logicalSchema := testSchema(table)
+	opts := Options{AllowedCollations: []string{"utf8mb4_0900_ai_ci", "utf8mb4_bin"}}
+	annotations := badCollationDetector(schema, logicalSchema, opts)
+	if len(annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, instead found %d", len(annotations))
+	} else if annotations[0].LineOffset != 4 {
+		t.Errorf("Expected annotation to have line offset 4, instead found %d", annotations[0].LineOffset)
+	}
+
+	// Table-level collation not allowed, but columns using the table's default
+	// should not be flagged separately
+	opts.AllowedCollations = []string{"utf8mb4_bin"}
+	annotations = badCollationDetector(schema, logicalSchema, opts)
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, instead found %d", len(annotations))
+	} else if annotations[0].LineOffset != 5 {
+		t.Errorf("Expected table-level annotation to have line offset 5, instead found %d", annotations[0].LineOffset)
+	}
+}
@@ -0,0 +1,67 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// namingPlaceholders lists the placeholders that may be used in naming options.
+// They are replaced with regexp-escaped values before the pattern is compiled.
+var namingPlaceholders = []string{"{TABLE}", "{COLUMNS}", "{REFTABLE}"}
+
+// namingRegexp compiles a naming convention pattern, substituting placeholders
+// with the supplied values. The resulting regexp must match the entire name.
+// Values should be supplied in the same order as namingPlaceholders.
+func namingRegexp(pattern string, values ...string) (*regexp.Regexp, error) {
+	for n, value := range values {
+		pattern = strings.Replace(pattern, namingPlaceholders[n], regexp.QuoteMeta(value), -1)
+	}
+	return regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+}
+
+func badNameDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	check := func(table *tengo.Table, optionName, pattern, objectDesc, name string, values ...string) {
+		if pattern == "" {
+			return
+		}
+		re, err := namingRegexp(pattern, values...)
+		if err != nil || re.MatchString(name) {
+			return // invalid patterns are rejected by OptionsForDir
+		}
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: findFirstLineOffset(identifierRegexp("", name, ""), stmt.Text),
+			Summary:    "Name does not match naming convention",
+			Message:    fmt.Sprintf("%s does not match the naming convention specified by option %s", objectDesc, optionName),
+		})
+	}
+
+	for _, table := range schema.Tables {
+		check(table, "naming-table", opts.NamingTable, fmt.Sprintf("Table %s", table.Name), table.Name, table.Name)
+		for _, col := range table.Columns {
+			check(table, "naming-column", opts.NamingColumn, fmt.Sprintf("Column %s of table %s", col.Name, table.Name), col.Name, table.Name)
+		}
+		for _, idx := range table.SecondaryIndexes {
+			colNames := make([]string, len(idx.Columns))
+			for n, col := range idx.Columns {
+				colNames[n] = col.Name
+			}
+			check(table, "naming-index", opts.NamingIndex, fmt.Sprintf("Index %s of table %s", idx.Name, table.Name), idx.Name, table.Name, strings.Join(colNames, "_"))
+		}
+		for _, fk := range table.ForeignKeys {
+			colNames := make([]string, len(fk.Columns))
+			for n, col := range fk.Columns {
+				colNames[n] = col.Name
+			}
+			check(table, "naming-foreign-key", opts.NamingForeignKey, fmt.Sprintf("Foreign key %s of table %s", fk.Name, table.Name), fk.Name, table.Name, strings.Join(colNames, "_"), fk.ReferencedTableName)
+		}
+	}
+	return results
+}
@@ -0,0 +1,53 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestBadNameDetector(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned"}
+	userIDCol := &tengo.Column{Name: "userID", TypeInDB: "int(10) unsigned"}
+	table := &tengo.Table{
+		Name:             "posts",
+		Columns:          []*tengo.Column{idCol, userIDCol},
+		PrimaryKey:       &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{{Name: "user", Columns: []*tengo.Column{userIDCol}}},
+		ForeignKeys: []*tengo.ForeignKey{{
+			Name:                  "fk_posts_users",
+			Columns:               []*tengo.Column{userIDCol},
+			ReferencedTableName:   "users",
+			ReferencedColumnNames: []string{"id"},
+		}},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `userID` int(10) unsigned NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  KEY `user` (`userID`),\n" +
+			"  CONSTRAINT `fk_posts_users` FOREIGN KEY (`userID`) REFERENCES `users` (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+	opts := Options{
+		NamingTable:      `[a-z_]+[^s]`,
+		NamingColumn:     `[a-z_]+`,
+		NamingIndex:      `idx_{TABLE}_{COLUMNS}`,
+		NamingForeignKey: `fk_{TABLE}_{REFTABLE}`,
+	}
+	annotations := badNameDetector(schema, logicalSchema, opts)
+	expectedOffsets := []int{0, 2, 4} // table, userID column, user index
+	if len(annotations) != len(expectedOffsets) {
+		t.Fatalf("Expected %d annotations, instead found %d", len(expectedOffsets), len(annotations))
+	}
+	for n, a := range annotations {
+		if a.LineOffset != expectedOffsets[n] {
+			t.Errorf("Expected annotation[%d] to have line offset %d, instead found %d: %s", n, expectedOffsets[n], a.LineOffset, a.Message)
+		}
+	}
+
+	// With no options set, nothing should be flagged
+	if annotations := badNameDetector(schema, logicalSchema, Options{}); len(annotations) != 0 {
+		t.Errorf("Expected no annotations, instead found %d", len(annotations))
+	}
+}
@@ -0,0 +1,136 @@
+package linter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// BaselineEntry identifies a single pre-existing lint error or warning. Line
+// numbers are intentionally not tracked, so that unrelated edits to a file do
+// not invalidate the baseline.
+type BaselineEntry struct {
+	File    string `json:"file"`
+	Object  string `json:"object,omitempty"`
+	Problem string `json:"problem,omitempty"`
+	Message string `json:"message"`
+}
+
+// Baseline is a list of BaselineEntry, suitable for persisting to a file so that
+// subsequent runs of the linter can ignore these known annotations. In memory,
+// entries have absolute file paths; in the persisted file, paths are relative
+// to the file's location.
+type Baseline []BaselineEntry
+
+// NewBaseline returns a Baseline with one entry per supplied annotation.
+func NewBaseline(annotations []*Annotation) Baseline {
+	b := make(Baseline, 0, len(annotations))
+	for _, a := range annotations {
+		b = append(b, baselineEntryFor(a))
+	}
+	return b
+}
+
+func baselineEntryFor(a *Annotation) BaselineEntry {
+	entry := BaselineEntry{
+		File:    a.Statement.File,
+		Problem: a.Problem,
+		Message: a.Message,
+	}
+	if a.Statement.ObjectName != "" {
+		entry.Object = a.Statement.ObjectKey().String()
+	}
+	return entry
+}
+
+// ReadBaselineFile parses a file previously written by Baseline.Write.
+func ReadBaselineFile(filePath string) (Baseline, error) {
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(contents, &b); err != nil {
+		return nil, err
+	}
+	baseDir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return nil, err
+	}
+	for n := range b {
+		if b[n].File != "" && !filepath.IsAbs(b[n].File) {
+			b[n].File = filepath.Join(baseDir, filepath.FromSlash(b[n].File))
+		}
+	}
+	return b, nil
+}
+
+// Write persists the baseline to the supplied file path, overwriting any
+// existing file. Entries are sorted, to minimize changes in version control
+// when the baseline is rewritten.
+func (b Baseline) Write(filePath string) error {
+	baseDir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return err
+	}
+	persisted := make(Baseline, len(b))
+	for n, entry := range b {
+		if rel, err := filepath.Rel(baseDir, entry.File); err == nil && entry.File != "" {
+			entry.File = filepath.ToSlash(rel)
+		}
+		persisted[n] = entry
+	}
+	sort.Slice(persisted, func(i, j int) bool {
+		a, b := persisted[i], persisted[j]
+		if a.File != b.File {
+			return a.File < b.File
+		} else if a.Object != b.Object {
+			return a.Object < b.Object
+		} else if a.Problem != b.Problem {
+			return a.Problem < b.Problem
+		}
+		return a.Message < b.Message
+	})
+	contents, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(contents, '\n'), 0666)
+}
+
+// Includes returns true if the baseline contains an entry matching a.
+func (b Baseline) Includes(a *Annotation) bool {
+	target := baselineEntryFor(a)
+	for _, entry := range b {
+		if entry == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the subset of annotations which are not included in the
+// baseline.
+func (b Baseline) Filter(annotations []*Annotation) []*Annotation {
+	unknown, _ := b.Partition(annotations)
+	return unknown
+}
+
+// Partition splits annotations into those which are not included in the
+// baseline, and those which are.
+func (b Baseline) Partition(annotations []*Annotation) (unknown, known []*Annotation) {
+	knownEntries := make(map[BaselineEntry]bool, len(b))
+	for _, entry := range b {
+		knownEntries[entry] = true
+	}
+	unknown = make([]*Annotation, 0, len(annotations))
+	for _, a := range annotations {
+		if knownEntries[baselineEntryFor(a)] {
+			known = append(known, a)
+		} else {
+			unknown = append(unknown, a)
+		}
+	}
+	return unknown, known
+}
@@ -0,0 +1,82 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestBaseline(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Unable to obtain working directory: %s", err)
+	}
+	makeAnnotation := func(fileName, objectName, problem, message string) *Annotation {
+		return &Annotation{
+			Statement: &fs.Statement{
+				File:       filepath.Join(wd, "schemas", fileName),
+				ObjectType: tengo.ObjectTypeTable,
+				ObjectName: objectName,
+			},
+			Problem: problem,
+			Message: message,
+		}
+	}
+	annotations := []*Annotation{
+		makeAnnotation("foo.sql", "foo", "no-pk", "Table foo does not define a PRIMARY KEY"),
+		makeAnnotation("bar.sql", "bar", "bad-engine", "Table bar is using storage engine MyISAM"),
+		makeAnnotation("baz.sql", "", "", "Ignoring unsupported or unparseable SQL statement"),
+	}
+
+	b := NewBaseline(annotations[0:2])
+	const filePath = "baseline-test.json"
+	if err := b.Write(filePath); err != nil {
+		t.Fatalf("Unexpected error from Write: %s", err)
+	}
+	defer os.Remove(filePath)
+
+	// Paths should be persisted relative to the baseline file, but converted
+	// back to absolute paths upon reading
+	if contents := fs.ReadTestFile(t, filePath); strings.Contains(contents, wd) || !strings.Contains(contents, `"schemas/foo.sql"`) {
+		t.Errorf("Expected baseline file to contain relative paths, instead found:\n%s", contents)
+	}
+	rl, err := ReadBaselineFile(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadBaselineFile: %s", err)
+	}
+	expected := Baseline{b[1], b[0]} // sorted by file
+	if !reflect.DeepEqual(rl, expected) {
+		t.Errorf("Expected ReadBaselineFile to return %+v, instead found %+v", expected, rl)
+	}
+
+	// Line numbers should not affect matching
+	annotations[0].LineOffset = 3
+	annotations[0].Statement.LineNo = 20
+	if !rl.Includes(annotations[0]) || rl.Includes(annotations[2]) {
+		t.Error("Unexpected result from Includes")
+	}
+	if filtered := rl.Filter(annotations); len(filtered) != 1 || filtered[0] != annotations[2] {
+		t.Errorf("Unexpected result from Filter: %+v", filtered)
+	}
+	if unknown, known := rl.Partition(annotations); len(unknown) != 1 || len(known) != 2 || known[1] != annotations[1] {
+		t.Errorf("Unexpected result from Partition: %+v, %+v", unknown, known)
+	}
+
+	// Guidance should not affect matching, even if it replaces the message
+	annotations[1].Guidance = "Use InnoDB"
+	annotations[1].OnlyGuidance = true
+	other := makeAnnotation("bar.sql", "bar", "bad-engine", "Table bar is using storage engine MEMORY")
+	other.Guidance, other.OnlyGuidance = annotations[1].Guidance, true
+	if !rl.Includes(annotations[1]) || rl.Includes(other) {
+		t.Error("Unexpected result from Includes with guidance")
+	}
+
+	if _, err := ReadBaselineFile("does-not-exist.json"); err == nil {
+		t.Error("Expected error from ReadBaselineFile on nonexistent file, but err was nil")
+	}
+}
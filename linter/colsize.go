@@ -0,0 +1,194 @@
+package linter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// charsetMaxBytes returns the maximum number of bytes per character in the
+// supplied character set. Unknown character sets are assumed to use up to 4
+// bytes per character.
+func charsetMaxBytes(charSet string) int {
+	switch strings.ToLower(charSet) {
+	case "", "binary", "latin1", "latin2", "latin5", "latin7", "ascii", "cp1250", "cp1251", "cp1256", "cp1257", "cp850", "cp852", "cp866", "dec8", "greek", "hebrew", "hp8", "keybcs2", "koi8r", "koi8u", "macce", "macroman", "swe7", "tis620", "armscii8", "geostd8":
+		return 1
+	case "ucs2", "gbk", "big5", "sjis", "cp932", "euckr", "gb2312":
+		return 2
+	case "utf8", "utf8mb3", "ujis", "eucjpms":
+		return 3
+	default: // utf8mb4, utf16, utf16le, utf32, gb18030, etc
+		return 4
+	}
+}
+
+// parseColumnType splits a column type into its base type name and its
+// parenthesized arguments, if any. For example, "decimal(10,2) unsigned"
+// returns "decimal" and [10, 2].
+func parseColumnType(typeInDB string) (base string, args []int) {
+	base = strings.ToLower(typeInDB)
+	if paren := strings.IndexByte(base, '('); paren > -1 {
+		if end := strings.IndexByte(base[paren:], ')'); end > -1 {
+			for _, arg := range strings.Split(base[paren+1:paren+end], ",") {
+				n, _ := strconv.Atoi(strings.TrimSpace(arg)) // enum/set values parse as 0, which is fine
+				args = append(args, n)
+			}
+		}
+		base = base[:paren]
+	}
+	if space := strings.IndexByte(base, ' '); space > -1 {
+		base = base[:space]
+	}
+	return base, args
+}
+
+// columnStorageBytes returns the maximum number of bytes used to store the
+// column's value within a row, as used by InnoDB's row size limit. For BLOB,
+// TEXT, and JSON columns, which are stored off-page, this only counts the
+// in-row pointer.
+func columnStorageBytes(col *tengo.Column) int {
+	base, args := parseColumnType(col.TypeInDB)
+	arg := func(n int) int {
+		return typeArg(args, n)
+	}
+	switch base {
+	case "tinyint", "year":
+		return 1
+	case "smallint":
+		return 2
+	case "mediumint", "date":
+		return 3
+	case "int", "integer", "float":
+		return 4
+	case "bigint", "double", "real":
+		return 8
+	case "inet4":
+		return 4
+	case "uuid", "inet6": // MariaDB 10.7+ binary-format types
+		return 16
+	case "decimal", "numeric":
+		return decimalBytes(arg(0), arg(1))
+	case "time":
+		return 3 + fractionalBytes(arg(0))
+	case "datetime":
+		return 5 + fractionalBytes(arg(0))
+	case "timestamp":
+		return 4 + fractionalBytes(arg(0))
+	case "bit":
+		return (arg(0) + 7) / 8
+	case "enum":
+		if strings.Count(col.TypeInDB, ",") >= 255 {
+			return 2
+		}
+		return 1
+	case "set":
+		return (strings.Count(col.TypeInDB, ",") + 8) / 8
+	case "char":
+		return arg(0) * charsetMaxBytes(col.CharSet)
+	case "binary":
+		return arg(0)
+	case "varchar":
+		return lengthPrefixed(arg(0) * charsetMaxBytes(col.CharSet))
+	case "varbinary":
+		return lengthPrefixed(arg(0))
+	case "tinyblob", "tinytext":
+		return 9
+	case "blob", "text":
+		return 10
+	case "mediumblob", "mediumtext":
+		return 11
+	case "longblob", "longtext", "json":
+		return 12
+	default: // spatial types are stored like blobs
+		return 12
+	}
+}
+
+// columnInRowBytes returns the minimum number of bytes that InnoDB must store
+// on the row's own page for the column, given the table's row format. Long
+// variable-length values may be moved off-page, leaving only a 20-byte pointer
+// in DYNAMIC or COMPRESSED row formats, or a 768-byte prefix plus pointer in
+// COMPACT or REDUNDANT row formats.
+func columnInRowBytes(col *tengo.Column, rowFormat string) int {
+	size := columnStorageBytes(col)
+	base, _ := parseColumnType(col.TypeInDB)
+	switch base {
+	case "varchar", "varbinary":
+	case "char": // only treated as variable-length when it can exceed 768 bytes
+		if size < 768 {
+			return size
+		}
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "float", "double", "real", "decimal", "numeric",
+		"date", "time", "datetime", "timestamp", "year", "bit", "enum", "set", "binary", "uuid", "inet4", "inet6":
+		return size
+	default: // BLOB, TEXT, JSON, spatial types
+		size = 65535
+	}
+	if rowFormat == "compact" || rowFormat == "redundant" {
+		if size > 768 {
+			return 768 + 20
+		}
+	} else if size > 40 {
+		return 20
+	}
+	return size
+}
+
+// columnIndexBytes returns the number of bytes used by the column within an
+// index key, optionally using a prefix length (subPart) of characters for
+// string types or bytes for binary types.
+func columnIndexBytes(col *tengo.Column, subPart uint16) int {
+	base, args := parseColumnType(col.TypeInDB)
+	mult := charsetMaxBytes(col.CharSet)
+	var length int
+	switch base {
+	case "char", "binary":
+		return columnStorageBytes(col)
+	case "varchar", "varbinary":
+		length = typeArg(args, 0) * mult
+	case "tinyblob", "tinytext":
+		length = 255
+	case "blob", "text", "mediumblob", "mediumtext", "longblob", "longtext":
+		length = 65535
+	default:
+		return columnStorageBytes(col)
+	}
+	if subPart > 0 && int(subPart)*mult < length {
+		length = int(subPart) * mult
+	}
+	return length + 2
+}
+
+// typeArg returns the nth parenthesized argument of a column type, or 0 if
+// not present.
+func typeArg(args []int, n int) int {
+	if len(args) > n {
+		return args[n]
+	}
+	return 0
+}
+
+func lengthPrefixed(length int) int {
+	if length > 255 {
+		return length + 2
+	}
+	return length + 1
+}
+
+// fractionalBytes returns the number of additional bytes needed for temporal
+// types with the supplied fractional seconds precision.
+func fractionalBytes(fsp int) int {
+	return (fsp + 1) / 2
+}
+
+// decimalBytes returns the storage size of a DECIMAL(precision,scale) value,
+// which packs each group of 9 digits into 4 bytes.
+func decimalBytes(precision, scale int) int {
+	if precision == 0 {
+		precision = 10
+	}
+	leftover := []int{0, 1, 1, 2, 2, 3, 3, 4, 4}
+	intDigits, fracDigits := precision-scale, scale
+	return (intDigits/9)*4 + leftover[intDigits%9] + (fracDigits/9)*4 + leftover[fracDigits%9]
+}
@@ -0,0 +1,76 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestColumnStorageBytes(t *testing.T) {
+	cases := map[string]int{
+		"tinyint(1)":                1,
+		"int(10) unsigned":          4,
+		"bigint(20)":                8,
+		"decimal(10,2)":             5,
+		"decimal(18,9)":             8,
+		"datetime(6)":               8,
+		"timestamp":                 4,
+		"bit(9)":                    2,
+		"enum('a','b')":             1,
+		"set('a','b','c')":          1,
+		"char(10)":                  40,
+		"varchar(50)":               201,
+		"varchar(63)":               253,
+		"varchar(64)":               258,
+		"varbinary(300)":            302,
+		"mediumtext":                11,
+		"json":                      12,
+		"uuid":                      16,
+		"inet6":                     16,
+		"int(10) unsigned zerofill": 4,
+	}
+	for typeInDB, expected := range cases {
+		col := &tengo.Column{TypeInDB: typeInDB, CharSet: "utf8mb4"}
+		if actual := columnStorageBytes(col); actual != expected {
+			t.Errorf("Expected columnStorageBytes of %s to be %d, instead found %d", typeInDB, expected, actual)
+		}
+	}
+}
+
+func TestColumnIndexBytes(t *testing.T) {
+	col := &tengo.Column{TypeInDB: "varchar(255)", CharSet: "utf8"}
+	if actual := columnIndexBytes(col, 0); actual != 767 {
+		t.Errorf("Unexpected result from columnIndexBytes: %d", actual)
+	}
+	if actual := columnIndexBytes(col, 10); actual != 32 {
+		t.Errorf("Unexpected result from columnIndexBytes: %d", actual)
+	}
+	col = &tengo.Column{TypeInDB: "blob"}
+	if actual := columnIndexBytes(col, 100); actual != 102 {
+		t.Errorf("Unexpected result from columnIndexBytes: %d", actual)
+	}
+}
+
+func TestColumnInRowBytes(t *testing.T) {
+	cases := []struct {
+		typeInDB  string
+		rowFormat string
+		expected  int
+	}{
+		{"int(11)", "dynamic", 4},
+		{"varchar(5)", "dynamic", 21},
+		{"varchar(100)", "dynamic", 20},
+		{"varchar(100)", "compact", 402},
+		{"varchar(1000)", "compact", 788},
+		{"char(50)", "dynamic", 200},
+		{"char(200)", "dynamic", 20},
+		{"text", "dynamic", 20},
+		{"text", "redundant", 788},
+	}
+	for _, c := range cases {
+		col := &tengo.Column{TypeInDB: c.typeInDB, CharSet: "utf8mb4"}
+		if actual := columnInRowBytes(col, c.rowFormat); actual != c.expected {
+			t.Errorf("Expected columnInRowBytes of %s with %s row format to be %d, instead found %d", c.typeInDB, c.rowFormat, c.expected, actual)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package linter
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -26,15 +28,74 @@ func AddCommandOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("errors", 0, "", "Linter problems to treat as fatal errors; see manual for usage"))
 	cmd.AddOption(mybase.StringOption("allow-charset", 0, "latin1,utf8mb4", "Whitelist of acceptable character sets"))
 	cmd.AddOption(mybase.StringOption("allow-engine", 0, "innodb", "Whitelist of acceptable storage engines"))
+	cmd.AddOption(mybase.StringOption("allow-collation", 0, "", "Whitelist of acceptable collations"))
+	cmd.AddOption(mybase.StringOption("allow-auto-inc", 0, "int unsigned,bigint unsigned", "Whitelist of acceptable column types for auto_increment columns"))
+	cmd.AddOption(mybase.StringOption("naming-table", 0, "", "Regular expression that table names must match"))
+	cmd.AddOption(mybase.StringOption("naming-column", 0, "", "Regular expression that column names must match"))
+	cmd.AddOption(mybase.StringOption("naming-index", 0, "", "Regular expression that secondary index names must match"))
+	cmd.AddOption(mybase.StringOption("naming-foreign-key", 0, "", "Regular expression that foreign key names must match"))
+	cmd.AddOption(mybase.StringOption("nullable-exempt-types", 0, "blob,text,json", "Column types exempt from the nullable-column problem"))
+	cmd.AddOption(mybase.StringOption("comment-scope", 0, "TABLE", `Object types checked by the missing-comment problem (valid values: "TABLE", "COLUMN", "ALL")`))
+	cmd.AddOption(mybase.StringOption("comment-pattern", 0, "", "Regular expression that table and column comments must match"))
+	cmd.AddOption(mybase.StringOption("join-keys", 0, "", "Comma-separated list of table1.col1=table2.col2 join relationships checked by the join-mismatch problem"))
+	cmd.AddOption(mybase.StringOption("join-ignore-columns", 0, "^id$", "Regular expression of column names excluded from same-name comparison by the join-mismatch problem"))
+	cmd.AddOption(mybase.StringOption("temporal-type", 0, "ANY", `Column type required for the temporal-column problem (valid values: "ANY", "DATETIME", "TIMESTAMP")`))
+	cmd.AddOption(mybase.StringOption("temporal-precision", 0, "0", "Minimum fractional seconds precision for the temporal-column problem"))
+	cmd.AddOption(mybase.StringOption("max-columns", 0, "0", "Maximum number of columns per table (0 for no limit)"))
+	cmd.AddOption(mybase.StringOption("max-indexes", 0, "0", "Maximum number of indexes per table (0 for no limit)"))
+	cmd.AddOption(mybase.StringOption("max-index-columns", 0, "0", "Maximum number of columns per index (0 for no limit)"))
+	cmd.AddOption(mybase.StringOption("max-index-bytes", 0, "0", "Maximum key size of each index, in bytes (0 for no limit)"))
+	cmd.AddOption(mybase.StringOption("max-indexed-string-bytes", 0, "255", "Maximum key size of an indexed string column without a prefix length, in bytes"))
+	cmd.AddOption(mybase.StringOption("max-row-bytes", 0, "0", "Maximum row size of each table, in bytes (0 for no limit)"))
+	cmd.AddOption(mybase.StringOption("reserved-word-flavors", 0, "", "Additional flavors to check for reserved words, e.g. when planning an upgrade"))
+	cmd.AddOption(mybase.StringOption("lint-plugins", 0, "", "Comma-separated list of name=command external lint rules"))
+	cmd.AddOption(mybase.StringOption("lint-guidance", 0, "", "Comma-separated list of problem='text' explanations or URLs to include with each problem found"))
+	cmd.AddOption(mybase.BoolOption("lint-default-messages", 0, true, "Include default problem descriptions; if false, only lint-guidance text is shown for problems which have it"))
+
+	// Each problem may also have its severity set individually, overriding
+	// the warnings and errors options
+	for _, name := range allProblemNames() {
+		cmd.AddOption(mybase.StringOption("lint-"+name, 0, "", fmt.Sprintf(`Severity of problem %s, overriding warnings and errors (valid values: "IGNORE", "WARNING", "ERROR")`, name)))
+	}
 }
 
 // Options contains parsed settings controlling linter behavior.
 type Options struct {
-	ProblemSeverity map[string]Severity
-	AllowedCharSets []string
-	AllowedEngines  []string
-	IgnoreSchema    *regexp.Regexp
-	IgnoreTable     *regexp.Regexp
+	ProblemSeverity       map[string]Severity
+	AllowedCharSets       []string
+	AllowedEngines        []string
+	AllowedCollations     []string
+	AllowedAutoIncTypes   []string
+	IgnoreSchema          *regexp.Regexp
+	IgnoreTable           *regexp.Regexp
+	IgnoreProc            *regexp.Regexp
+	IgnoreFunc            *regexp.Regexp
+	IgnoreObjectTypes     map[tengo.ObjectType]bool
+	NamingTable           string
+	NamingColumn          string
+	NamingIndex           string
+	NamingForeignKey      string
+	NullableExemptTypes   []string
+	CommentScope          string
+	CommentPattern        *regexp.Regexp
+	JoinKeys              []JoinKey
+	JoinIgnoreColumns     *regexp.Regexp
+	TemporalType          string
+	TemporalPrecision     int
+	MaxColumns            int
+	MaxIndexes            int
+	MaxIndexColumns       int
+	MaxIndexBytes         int
+	MaxRowBytes           int
+	MaxIndexedStringBytes int
+	Flavor                tengo.Flavor
+	FlavorPatch           int             // patch version of Flavor, or 0 if unknown
+	TiDB                  util.TiDBFlavor // zero value if not TiDB
+	Vitess                util.VitessMode
+	ReservedWordFlavors   []tengo.Flavor
+	Plugins               map[string]string // problem name => external command
+	Guidance              map[string]string // problem name => org-specific explanation or URL
+	DefaultMessages       bool
 }
 
 // ShouldIgnore returns true if the option configuration indicates the supplied
@@ -42,54 +103,208 @@ type Options struct {
 func (opts Options) ShouldIgnore(key tengo.ObjectKey) bool {
 	if key.Type == tengo.ObjectTypeDatabase && opts.IgnoreSchema != nil {
 		return opts.IgnoreSchema.MatchString(key.Name)
-	} else if key.Type == tengo.ObjectTypeTable && opts.IgnoreTable != nil {
-		return opts.IgnoreTable.MatchString(key.Name)
 	}
-	return false
+	ignoreOpts := fs.IgnoreOptions{
+		ObjectTypes: opts.IgnoreObjectTypes,
+		Table:       opts.IgnoreTable,
+		Proc:        opts.IgnoreProc,
+		Func:        opts.IgnoreFunc,
+	}
+	return ignoreOpts.ShouldIgnore(key)
+}
+
+// applyGuidance sets any custom guidance configured for a.Problem on a.
+// Depending on opts.DefaultMessages, the guidance is either appended to the
+// default message, or replaces it, when a.DisplayMessage is called. a.Message
+// is left as-is, so that baseline entries do not depend on guidance.
+func (opts Options) applyGuidance(a *Annotation) {
+	if guidance, ok := opts.Guidance[a.Problem]; ok {
+		a.Guidance = guidance
+		a.OnlyGuidance = !opts.DefaultMessages
+	}
 }
 
 // OptionsForDir returns Options based on the configuration in an fs.Dir,
 // effectively converting between mybase options and linter options.
 func OptionsForDir(dir *fs.Dir) (Options, error) {
 	opts := Options{
-		ProblemSeverity: make(map[string]Severity),
-		AllowedCharSets: dir.Config.GetSlice("allow-charset", ',', true),
-		AllowedEngines:  dir.Config.GetSlice("allow-engine", ',', true),
+		ProblemSeverity:     make(map[string]Severity),
+		AllowedCharSets:     dir.Config.GetSlice("allow-charset", ',', true),
+		AllowedEngines:      dir.Config.GetSlice("allow-engine", ',', true),
+		AllowedCollations:   dir.Config.GetSlice("allow-collation", ',', true),
+		AllowedAutoIncTypes: dir.Config.GetSlice("allow-auto-inc", ',', true),
+		NamingTable:         dir.Config.Get("naming-table"),
+		NamingColumn:        dir.Config.Get("naming-column"),
+		NamingIndex:         dir.Config.Get("naming-index"),
+		NamingForeignKey:    dir.Config.Get("naming-foreign-key"),
+		NullableExemptTypes: dir.Config.GetSlice("nullable-exempt-types", ',', true),
+		Flavor:              tengo.NewFlavor(dir.Config.Get("flavor")),
+		TiDB:                util.ParseTiDBFlavor(dir.Config.Get("flavor")),
+		DefaultMessages:     dir.Config.GetBool("lint-default-messages"),
+	}
+	// Normalize whitespace in multi-word types like "bigint unsigned"
+	for n, val := range opts.AllowedAutoIncTypes {
+		opts.AllowedAutoIncTypes[n] = strings.Join(strings.Fields(val), " ")
+	}
+	limitOptions := map[string]*int{
+		"max-columns":       &opts.MaxColumns,
+		"max-indexes":       &opts.MaxIndexes,
+		"max-index-columns": &opts.MaxIndexColumns,
+		"max-index-bytes":   &opts.MaxIndexBytes,
+		"max-row-bytes":     &opts.MaxRowBytes,
+	}
+	for optionName, dest := range limitOptions {
+		var err error
+		if *dest, err = dir.Config.GetInt(optionName); err != nil || *dest < 0 {
+			return Options{}, ConfigError(fmt.Sprintf("Option %s must be a non-negative integer", optionName))
+		}
 	}
-
 	var err error
+	if opts.CommentScope, err = dir.Config.GetEnum("comment-scope", "table", "column", "all"); err != nil {
+		return Options{}, ConfigError(err.Error())
+	}
+	if opts.CommentPattern, err = dir.Config.GetRegexp("comment-pattern"); err != nil {
+		return Options{}, ConfigError(err.Error())
+	}
+	for _, val := range dir.Config.GetSlice("join-keys", ',', true) {
+		jk, err := parseJoinKey(val)
+		if err != nil {
+			return Options{}, ConfigError(err.Error())
+		}
+		opts.JoinKeys = append(opts.JoinKeys, jk)
+	}
+	if opts.JoinIgnoreColumns, err = dir.Config.GetRegexp("join-ignore-columns"); err != nil {
+		return Options{}, ConfigError(err.Error())
+	}
+	if opts.TemporalType, err = dir.Config.GetEnum("temporal-type", "any", "datetime", "timestamp"); err != nil {
+		return Options{}, ConfigError(err.Error())
+	}
+	if opts.Vitess, err = util.VitessModeForConfig(dir.Config); err != nil {
+		return Options{}, ConfigError(err.Error())
+	}
+	if opts.TemporalPrecision, err = dir.Config.GetInt("temporal-precision"); err != nil || opts.TemporalPrecision < 0 || opts.TemporalPrecision > 6 {
+		return Options{}, ConfigError("Option temporal-precision must be an integer between 0 and 6")
+	}
+	if opts.MaxIndexedStringBytes, err = dir.Config.GetInt("max-indexed-string-bytes"); err != nil || opts.MaxIndexedStringBytes < 1 {
+		return Options{}, ConfigError("Option max-indexed-string-bytes must be a positive integer")
+	}
+	for _, val := range dir.Config.GetSlice("reserved-word-flavors", ',', true) {
+		flavor := tengo.NewFlavor(val)
+		if !flavor.Known() {
+			return Options{}, ConfigError(fmt.Sprintf("Option reserved-word-flavors: unknown flavor %s", val))
+		}
+		opts.ReservedWordFlavors = append(opts.ReservedWordFlavors, flavor)
+	}
+
 	opts.IgnoreSchema, err = dir.Config.GetRegexp("ignore-schema")
 	if err != nil {
 		return Options{}, ConfigError(err.Error())
 	}
-	opts.IgnoreTable, err = dir.Config.GetRegexp("ignore-table")
+	ignoreOpts, err := dir.IgnoreOptions()
 	if err != nil {
 		return Options{}, ConfigError(err.Error())
 	}
+	opts.IgnoreTable = ignoreOpts.Table
+	opts.IgnoreProc = ignoreOpts.Proc
+	opts.IgnoreFunc = ignoreOpts.Func
+	opts.IgnoreObjectTypes = ignoreOpts.ObjectTypes
+
+	// Naming convention patterns may contain placeholders; confirm they compile
+	// once the placeholders are substituted
+	namingOptions := map[string]string{
+		"naming-table":       opts.NamingTable,
+		"naming-column":      opts.NamingColumn,
+		"naming-index":       opts.NamingIndex,
+		"naming-foreign-key": opts.NamingForeignKey,
+	}
+	for optionName, pattern := range namingOptions {
+		if _, err := namingRegexp(pattern, "table", "columns", "reftable"); err != nil {
+			return Options{}, ConfigError(fmt.Sprintf("Invalid regexp for option %s: %s", optionName, pattern))
+		}
+	}
+
+	// Each external plugin defines an additional problem name, which may not
+	// conflict with any built-in problem.
+	plugins, err := problemValues(dir, "lint-plugins")
+	if err != nil {
+		return Options{}, err
+	}
+	allNames := allProblemNames()
+	for name, command := range plugins {
+		if problemExists(name) {
+			return Options{}, ConfigError(fmt.Sprintf("Option lint-plugins: name %s conflicts with a built-in problem", name))
+		} else if command == "" {
+			return Options{}, ConfigError(fmt.Sprintf("Option lint-plugins: no command supplied for %s", name))
+		}
+		if opts.Plugins == nil {
+			opts.Plugins = make(map[string]string, len(plugins))
+		}
+		opts.Plugins[name] = command
+		allNames = append(allNames, name)
+	}
+	sort.Strings(allNames)
+	knownProblem := func(name string) bool {
+		_, isPlugin := opts.Plugins[name]
+		return isPlugin || problemExists(name)
+	}
 
 	// Populate opts.ProblemSeverity from the warnings and errors options (in
 	// that order, so that in case of duplicate entries, errors take precedence).
 	// The values specified in warnings and errors must be valid defined problems.
-	allAllowed := strings.Join(allProblemNames(), ", ")
+	allAllowed := strings.Join(allNames, ", ")
 	for _, val := range dir.Config.GetSlice("warnings", ',', true) {
 		val = strings.ToLower(val)
-		if !problemExists(val) {
+		if !knownProblem(val) {
 			return Options{}, ConfigError(fmt.Sprintf("Option warnings must be a comma-separated list including these values: %s", allAllowed))
 		}
 		opts.ProblemSeverity[val] = SeverityWarning
 	}
 	for _, val := range dir.Config.GetSlice("errors", ',', true) {
 		val = strings.ToLower(val)
-		if !problemExists(val) {
+		if !knownProblem(val) {
 			return Options{}, ConfigError(fmt.Sprintf("Option errors must be a comma-separated list including these values: %s", allAllowed))
 		}
 		opts.ProblemSeverity[val] = SeverityError
 	}
 
+	// Per-problem severity options take precedence over warnings and errors.
+	// Problems registered after the command's options were added won't have a
+	// corresponding option.
+	commandOptions := dir.Config.CLI.Command.Options()
+	for _, name := range allProblemNames() {
+		optionName := "lint-" + name
+		if _, ok := commandOptions[optionName]; !ok {
+			continue
+		}
+		value, err := dir.Config.GetEnum(optionName, "ignore", "warning", "error")
+		if err != nil {
+			return Options{}, ConfigError(err.Error())
+		} else if value == "ignore" {
+			delete(opts.ProblemSeverity, name)
+		} else if value != "" {
+			opts.ProblemSeverity[name] = Severity(value)
+		}
+	}
+
+	// Custom guidance may be attached to any known problem
+	guidance, err := problemValues(dir, "lint-guidance")
+	if err != nil {
+		return Options{}, err
+	}
+	for name := range guidance {
+		if !knownProblem(name) {
+			return Options{}, ConfigError(fmt.Sprintf("Option lint-guidance: unknown problem %s", name))
+		}
+	}
+	if len(guidance) > 0 {
+		opts.Guidance = guidance
+	}
+
 	// For list-based problems, confirm corresponding list is non-empty
 	problemToList := map[string][]string{
-		"bad-charset": opts.AllowedCharSets,
-		"bad-engine":  opts.AllowedEngines,
+		"bad-charset":   opts.AllowedCharSets,
+		"bad-engine":    opts.AllowedEngines,
+		"bad-collation": opts.AllowedCollations,
 	}
 	for problem, listOption := range problemToList {
 		severity, ok := opts.ProblemSeverity[problem]
@@ -103,9 +318,38 @@ func OptionsForDir(dir *fs.Dir) (Options, error) {
 		}
 	}
 
+	if severity, ok := opts.ProblemSeverity["auto-inc"]; ok && len(opts.AllowedAutoIncTypes) == 0 {
+		return Options{}, ConfigError(fmt.Sprintf("With option %ss=auto-inc, corresponding option allow-auto-inc must be non-empty", string(severity)))
+	}
+	if severity, ok := opts.ProblemSeverity["over-limit"]; ok && opts.MaxColumns+opts.MaxIndexes+opts.MaxIndexColumns+opts.MaxIndexBytes == 0 {
+		return Options{}, ConfigError(fmt.Sprintf("With option %ss=over-limit, at least one of options max-columns, max-indexes, max-index-columns, or max-index-bytes must be non-zero", string(severity)))
+	}
+	if severity, ok := opts.ProblemSeverity["bad-name"]; ok && opts.NamingTable+opts.NamingColumn+opts.NamingIndex+opts.NamingForeignKey == "" {
+		return Options{}, ConfigError(fmt.Sprintf("With option %ss=bad-name, at least one of options naming-table, naming-column, naming-index, or naming-foreign-key must be non-empty", string(severity)))
+	}
+
 	return opts, nil
 }
 
+// problemValues parses an option whose value is a comma-separated list of
+// name=value pairs keyed by problem name, such as lint-plugins. Names are
+// lowercased. Values may optionally be wrapped in single quotes, in which case
+// the quotes are removed and any escaped single quotes are unescaped.
+func problemValues(dir *fs.Dir, optionName string) (map[string]string, error) {
+	raw, err := util.SplitConnectOptions(dir.Config.Get(optionName))
+	if err != nil {
+		return nil, ConfigError(fmt.Sprintf("Invalid value for %s: %s", optionName, err))
+	}
+	result := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = strings.Replace(value[1:len(value)-1], "\\'", "'", -1)
+		}
+		result[strings.ToLower(name)] = value
+	}
+	return result, nil
+}
+
 // ConfigError represents a configuration problem encountered at runtime.
 type ConfigError string
 
@@ -4,6 +4,9 @@ import (
 	"reflect"
 	"regexp"
 	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
 )
 
 func TestOptionsForDir(t *testing.T) {
@@ -17,16 +20,34 @@ func TestOptionsForDir(t *testing.T) {
 				"bad-charset": SeverityWarning,
 				"bad-engine":  SeverityWarning,
 			},
-			AllowedCharSets: []string{"utf8mb4"},
-			AllowedEngines:  []string{"innodb", "myisam"},
-			IgnoreSchema:    regexp.MustCompile(`^metadata$`),
-			IgnoreTable:     regexp.MustCompile(`^_`),
+			AllowedCharSets:       []string{"utf8mb4"},
+			AllowedEngines:        []string{"innodb", "myisam"},
+			AllowedCollations:     []string{},
+			AllowedAutoIncTypes:   []string{"int unsigned", "bigint unsigned"},
+			NullableExemptTypes:   []string{"blob", "text", "json"},
+			CommentScope:          "table",
+			DefaultMessages:       true,
+			JoinIgnoreColumns:     regexp.MustCompile(`^id$`),
+			TemporalType:          "any",
+			MaxIndexedStringBytes: 255,
+			Vitess:                util.VitessOff,
+			IgnoreSchema:          regexp.MustCompile(`^metadata$`),
+			IgnoreTable:           regexp.MustCompile(`^_`),
+			IgnoreObjectTypes:     map[tengo.ObjectType]bool{},
 		}
 		if !reflect.DeepEqual(opts, expected) {
 			t.Errorf("OptionsForDir returned %+v, did not match expectation %+v", opts, expected)
 		}
 	}
 
+	// Plugin names should be usable in warnings and errors
+	dir = getDir(t, "../testdata/linter/validcfg", "--lint-plugins=\"House-Rule='/opt/lint/house.sh --strict'\"", "--errors=no-pk,house-rule")
+	if opts, err := OptionsForDir(dir); err != nil {
+		t.Errorf("Unexpected error from OptionsForDir: %s", err)
+	} else if opts.Plugins["house-rule"] != "/opt/lint/house.sh --strict" || opts.ProblemSeverity["house-rule"] != SeverityError {
+		t.Errorf("Unexpected plugin configuration in %+v", opts)
+	}
+
 	// Coverage for error conditions
 	badOptions := []string{
 		"--errors=made-up-problem",
@@ -35,6 +56,28 @@ func TestOptionsForDir(t *testing.T) {
 		"--ignore-schema=+",
 		"--allow-charset=''",
 		"--allow-engine='' --errors=''",
+		"--errors=bad-name",
+		"--warnings=bad-collation",
+		"--errors=auto-inc --allow-auto-inc=''",
+		"--errors=over-limit",
+		"--comment-scope=index",
+		"--comment-pattern=+",
+		"--join-keys=users.id=posts",
+		"--join-ignore-columns=+",
+		"--temporal-type=date",
+		"--temporal-precision=7",
+		"--max-columns=-1",
+		"--max-index-bytes=lots",
+		"--max-row-bytes=-5",
+		"--max-indexed-string-bytes=0",
+		"--reserved-word-flavors=mysql:8.0,postgres:12",
+		"--naming-index='idx_{TABLE}_('",
+		"--lint-plugins=no-pk=/bin/true",
+		"--lint-plugins=house-rule=",
+		"--errors=house-rule --lint-plugins=other-rule=/bin/true",
+		"--lint-no-pk=fatal",
+		"--lint-guidance=made-up-problem='See wiki'",
+		"--lint-bad-collation=warning",
 	}
 	confirmError := func(cliArgs string) {
 		t.Helper()
@@ -56,3 +99,75 @@ func TestOptionsForDir(t *testing.T) {
 		t.Errorf("ConfigError not behaving as expected")
 	}
 }
+
+func TestOptionsForDirProblemSeverity(t *testing.T) {
+	dir := getDir(t, "../testdata/linter/inherit")
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	expected := map[string]Severity{
+		"no-pk":       SeverityError,
+		"bad-engine":  SeverityError,
+		"bad-charset": SeverityWarning,
+		"has-enum":    SeverityError,
+	}
+	if !reflect.DeepEqual(opts.ProblemSeverity, expected) {
+		t.Errorf("Expected ProblemSeverity %v, instead found %v", expected, opts.ProblemSeverity)
+	}
+
+	// Subdir inherits the parent's settings, but may override individual problems
+	dir = getDir(t, "../testdata/linter/inherit/legacy")
+	if opts, err = OptionsForDir(dir); err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	expected = map[string]Severity{
+		"no-pk":       SeverityWarning,
+		"bad-charset": SeverityWarning,
+		"has-enum":    SeverityError,
+		"has-fk":      SeverityWarning,
+	}
+	if !reflect.DeepEqual(opts.ProblemSeverity, expected) {
+		t.Errorf("Expected ProblemSeverity %v, instead found %v", expected, opts.ProblemSeverity)
+	}
+
+	// Command-line overrides take precedence over everything
+	dir = getDir(t, "../testdata/linter/inherit/legacy", "--lint-has-enum=ignore")
+	if opts, err = OptionsForDir(dir); err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	if _, ok := opts.ProblemSeverity["has-enum"]; ok {
+		t.Errorf("Expected has-enum to be ignored, instead found %v", opts.ProblemSeverity)
+	}
+}
+
+func TestOptionsApplyGuidance(t *testing.T) {
+	dir := getDir(t, "../testdata/linter/validcfg", "--lint-guidance=\"No-PK='See https://wiki.example.com/pk, section 2',bad-engine=Use InnoDB\"")
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	a := &Annotation{Problem: "no-pk", Message: "Table foo does not define a PRIMARY KEY"}
+	opts.applyGuidance(a)
+	if expected := "Table foo does not define a PRIMARY KEY. See https://wiki.example.com/pk, section 2"; a.DisplayMessage() != expected {
+		t.Errorf("Expected message %q, instead found %q", expected, a.DisplayMessage())
+	} else if a.Message != "Table foo does not define a PRIMARY KEY" {
+		t.Errorf("Expected original message to be unchanged, instead found %q", a.Message)
+	}
+	a = &Annotation{Problem: "bad-charset", Message: "Table foo is using default character set utf8."}
+	opts.applyGuidance(a)
+	if expected := "Table foo is using default character set utf8."; a.DisplayMessage() != expected {
+		t.Errorf("Expected message %q, instead found %q", expected, a.DisplayMessage())
+	}
+
+	// With default messages disabled, guidance replaces the message
+	dir = getDir(t, "../testdata/linter/validcfg", "--lint-guidance=bad-engine='Use InnoDB'", "--skip-lint-default-messages")
+	if opts, err = OptionsForDir(dir); err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	a = &Annotation{Problem: "bad-engine", Message: "Table foo is using storage engine MyISAM"}
+	opts.applyGuidance(a)
+	if a.DisplayMessage() != "Use InnoDB" || a.Message != "Table foo is using storage engine MyISAM" {
+		t.Errorf("Unexpected messages %q, %q", a.DisplayMessage(), a.Message)
+	}
+}
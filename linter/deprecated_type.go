@@ -0,0 +1,89 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// Display widths and YEAR(4) are checked against the original statement text,
+// rather than the column's type in the database, since older server versions
+// always include them in SHOW CREATE TABLE even if they were never specified.
+var (
+	displayWidthRegexp = regexp.MustCompile(`(?i)\b(tinyint|smallint|mediumint|int|integer|bigint)\s*\(\s*(\d+)\s*\)`)
+	yearWidthRegexp    = regexp.MustCompile(`(?i)\byear\s*\(\s*4\s*\)`)
+)
+
+// isUTF8MB3 returns true if charSet is the deprecated 3-byte utf8 character
+// set, under either of its names.
+func isUTF8MB3(charSet string) bool {
+	charSet = strings.ToLower(charSet)
+	return charSet == "utf8" || charSet == "utf8mb3"
+}
+
+// displayWidthsDeprecated returns true if opts indicates a target of MySQL
+// 8.0.19+, which omits integer display widths from SHOW CREATE TABLE. Display
+// widths are not flagged if the target's patch version is unknown, nor in
+// MariaDB, which has not deprecated them.
+func displayWidthsDeprecated(opts Options) bool {
+	if opts.Flavor.MySQLishMinVersion(8, 1) {
+		return true
+	}
+	return opts.Flavor.MySQLishMinVersion(8, 0) && opts.FlavorPatch >= 19
+}
+
+// deprecatedColumnConstructs returns descriptions of deprecated constructs
+// used in the definition of col. line should be the line of the original
+// statement which defines the column, or an empty string if unknown.
+func deprecatedColumnConstructs(col *tengo.Column, tableCharSet, line string, opts Options) []string {
+	var constructs []string
+	base, args := parseColumnType(col.TypeInDB)
+	if matches := displayWidthRegexp.FindStringSubmatch(line); matches != nil && displayWidthsDeprecated(opts) && !(strings.ToLower(matches[1]) == "tinyint" && matches[2] == "1") {
+		constructs = append(constructs, "an integer display width")
+	}
+	if strings.Contains(strings.ToLower(col.TypeInDB), "zerofill") {
+		constructs = append(constructs, "the ZEROFILL attribute")
+	}
+	if base == "year" && yearWidthRegexp.MatchString(line) {
+		constructs = append(constructs, "YEAR(4) syntax")
+	}
+	if (base == "float" || base == "double" || base == "real") && len(args) == 2 {
+		constructs = append(constructs, fmt.Sprintf("%s(M,D) syntax", strings.ToUpper(base)))
+	}
+	if col.CharSet != "" && isUTF8MB3(col.CharSet) && !isUTF8MB3(tableCharSet) {
+		constructs = append(constructs, fmt.Sprintf("the %s character set, an alias for utf8mb3", col.CharSet))
+	}
+	return constructs
+}
+
+func deprecatedTypeDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if isUTF8MB3(table.CharSet) {
+			re := regexp.MustCompile(`(?i)(character\s+set|charset)\s*=?\s*utf8(mb3)?\b`)
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findLastLineOffset(re, stmt.Text),
+				Summary:    "Deprecated type or syntax",
+				Message:    fmt.Sprintf("Table %s is using default character set %s, an alias for utf8mb3, which is deprecated in MySQL 8.0. Consider utf8mb4 instead.", table.Name, table.CharSet),
+			})
+		}
+		for _, col := range table.Columns {
+			lineOffset, line := findIdentifierLine(col.Name, stmt.Text)
+			for _, construct := range deprecatedColumnConstructs(col, table.CharSet, line, opts) {
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: lineOffset,
+					Summary:    "Deprecated type or syntax",
+					Message:    fmt.Sprintf("Column %s of table %s is using %s, which is deprecated in MySQL 8.0", col.Name, table.Name, construct),
+				})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,91 @@
+package linter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestDeprecatedTypeDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name:    "legacy",
+		CharSet: "utf8",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(11)"},
+			{Name: "flag", TypeInDB: "tinyint(1)"},
+			{Name: "code", TypeInDB: "int(5) unsigned zerofill"},
+			{Name: "yr", TypeInDB: "year(4)"},
+			{Name: "price", TypeInDB: "float(7,2)"},
+			{Name: "ratio", TypeInDB: "double"},
+			{Name: "name", TypeInDB: "varchar(30)", CharSet: "utf8mb3"},
+		},
+		CreateStatement: "CREATE TABLE `legacy` (\n" +
+			"  `id` int NOT NULL,\n" +
+			"  `flag` tinyint(1) NOT NULL,\n" +
+			"  `code` int(5) unsigned zerofill NOT NULL,\n" +
+			"  `yr` year(4) NOT NULL,\n" +
+			"  `price` float(7,2) NOT NULL,\n" +
+			"  `ratio` double NOT NULL,\n" +
+			"  `name` varchar(30) NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	}
+	schema, logicalSchema := testSchema(table)
+	opts := Options{Flavor: tengo.FlavorMySQL80, FlavorPatch: 19}
+	annotations := deprecatedTypeDetector(schema, logicalSchema, opts)
+	expected := []string{
+		"Deprecated type or syntax:8",
+		"Deprecated type or syntax:3",
+		"Deprecated type or syntax:3",
+		"Deprecated type or syntax:4",
+		"Deprecated type or syntax:5",
+	}
+	if actual := annotationSummaries(annotations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+	if len(annotations) == len(expected) && !strings.Contains(annotations[4].Message, "FLOAT(M,D)") {
+		t.Errorf("Unexpected message: %s", annotations[4].Message)
+	}
+
+	// Column-level utf8mb3 is flagged when the table default differs
+	table.CharSet = "utf8mb4"
+	table.CreateStatement = strings.Replace(table.CreateStatement, "CHARSET=utf8", "CHARSET=utf8mb4", 1)
+	schema, logicalSchema = testSchema(table)
+	annotations = deprecatedTypeDetector(schema, logicalSchema, opts)
+	if len(annotations) != 5 || annotations[4].LineOffset != 7 || !strings.Contains(annotations[4].Message, "utf8mb3") {
+		t.Errorf("Unexpected annotations: %v", annotationSummaries(annotations))
+	}
+
+	// Unquoted column names in the original statement must be located as well
+	table.CreateStatement = strings.Replace(table.CreateStatement, "`", "", -1)
+	schema, logicalSchema = testSchema(table)
+	annotations = deprecatedTypeDetector(schema, logicalSchema, opts)
+	expected = []string{
+		"Deprecated type or syntax:3",
+		"Deprecated type or syntax:3",
+		"Deprecated type or syntax:4",
+		"Deprecated type or syntax:5",
+		"Deprecated type or syntax:7",
+	}
+	if actual := annotationSummaries(annotations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+
+	// Display widths are only flagged for MySQL 8.0.19+
+	for _, opts := range []Options{{}, {Flavor: tengo.FlavorMySQL80, FlavorPatch: 18}, {Flavor: tengo.FlavorMySQL57}, {Flavor: tengo.NewFlavor("mariadb:10.5")}} {
+		annotations = deprecatedTypeDetector(schema, logicalSchema, opts)
+		for _, a := range annotations {
+			if strings.Contains(a.Message, "display width") {
+				t.Errorf("Unexpected display width annotation with flavor %s patch %d: %s", opts.Flavor, opts.FlavorPatch, a.Message)
+			}
+		}
+		if len(annotations) != 4 {
+			t.Errorf("Expected 4 annotations with flavor %s patch %d, instead found %v", opts.Flavor, opts.FlavorPatch, annotationSummaries(annotations))
+		}
+	}
+	annotations = deprecatedTypeDetector(schema, logicalSchema, Options{Flavor: tengo.NewFlavor("mysql:8.4")})
+	if len(annotations) != 5 {
+		t.Errorf("Expected 5 annotations with flavor mysql:8.4, instead found %v", annotationSummaries(annotations))
+	}
+}
@@ -0,0 +1,29 @@
+package linter_test
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/tengo"
+)
+
+// This example registers a custom problem which flags tables lacking a
+// created_at column. Once registered, "no-created-at" may be used as a value
+// in the warnings or errors options.
+func ExampleRegisterProblem() {
+	linter.RegisterProblem("no-created-at", func(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ linter.Options) []*linter.Annotation {
+		var results []*linter.Annotation
+		for _, table := range schema.Tables {
+			if _, ok := table.ColumnsByName()["created_at"]; !ok {
+				key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+				results = append(results, &linter.Annotation{
+					Statement: logicalSchema.Creates[key],
+					Summary:   "Missing created_at column",
+					Message:   fmt.Sprintf("Table %s does not have a created_at column", table.Name),
+				})
+			}
+		}
+		return results
+	})
+}
@@ -0,0 +1,132 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// foreignKeyAnnotation returns an annotation for fk in table, located on the
+// line of the CONSTRAINT clause.
+func foreignKeyAnnotation(table *tengo.Table, fk *tengo.ForeignKey, logicalSchema *fs.LogicalSchema, summary, message string) *Annotation {
+	stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+	re := identifierRegexp(`CONSTRAINT\s+`, fk.Name, "")
+	return &Annotation{
+		Statement:  stmt,
+		LineOffset: findFirstLineOffset(re, stmt.Text),
+		Summary:    summary,
+		Message:    message,
+	}
+}
+
+// referencedTable returns the table in schema referenced by fk, or nil if the
+// foreign key refers to another schema or a nonexistent table.
+func referencedTable(schema *tengo.Schema, fk *tengo.ForeignKey) *tengo.Table {
+	if fk.ReferencedSchemaName != "" && fk.ReferencedSchemaName != schema.Name {
+		return nil
+	}
+	return schema.Table(fk.ReferencedTableName)
+}
+
+// columnTypeMismatch returns a description of the difference between col and
+// refCol which would prevent them from being used in a foreign key, or an
+// empty string if they are compatible. Integer display widths and string
+// lengths may differ, but signedness, character set, and collation may not.
+func columnTypeMismatch(col, refCol *tengo.Column) string {
+	base, args := parseColumnType(col.TypeInDB)
+	refBase, refArgs := parseColumnType(refCol.TypeInDB)
+	unsigned := strings.Contains(strings.ToLower(col.TypeInDB), "unsigned")
+	refUnsigned := strings.Contains(strings.ToLower(refCol.TypeInDB), "unsigned")
+	if base != refBase {
+		return fmt.Sprintf("type %s does not match type %s", col.TypeInDB, refCol.TypeInDB)
+	} else if unsigned != refUnsigned {
+		return fmt.Sprintf("signedness of type %s does not match type %s", col.TypeInDB, refCol.TypeInDB)
+	} else if (base == "decimal" || base == "numeric") && (typeArg(args, 0) != typeArg(refArgs, 0) || typeArg(args, 1) != typeArg(refArgs, 1)) {
+		return fmt.Sprintf("precision of type %s does not match type %s", col.TypeInDB, refCol.TypeInDB)
+	} else if col.CharSet != refCol.CharSet {
+		return fmt.Sprintf("character set %s does not match character set %s", col.CharSet, refCol.CharSet)
+	} else if col.Collation != refCol.Collation {
+		return fmt.Sprintf("collation %s does not match collation %s", col.Collation, refCol.Collation)
+	}
+	return ""
+}
+
+func fkMismatchDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			refTable := referencedTable(schema, fk)
+			if refTable == nil {
+				continue
+			}
+			refCols := refTable.ColumnsByName()
+			for n, col := range fk.Columns {
+				refCol := refCols[fk.ReferencedColumnNames[n]]
+				var problem string
+				if refCol == nil {
+					problem = "does not exist"
+				} else {
+					problem = columnTypeMismatch(col, refCol)
+				}
+				if problem != "" {
+					message := fmt.Sprintf("Foreign key %s of table %s: column %s references column %s.%s, but %s", fk.Name, table.Name, col.Name, refTable.Name, fk.ReferencedColumnNames[n], problem)
+					results = append(results, foreignKeyAnnotation(table, fk, logicalSchema, "Foreign key column mismatch", message))
+				}
+			}
+		}
+	}
+	return results
+}
+
+func fkUnindexedDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			refTable := referencedTable(schema, fk)
+			if refTable == nil || hasLeftPrefixIndex(refTable, fk.ReferencedColumnNames) {
+				continue
+			}
+			message := fmt.Sprintf("Foreign key %s of table %s references columns (%s) of table %s, but no index on table %s begins with those columns", fk.Name, table.Name, strings.Join(fk.ReferencedColumnNames, ", "), refTable.Name, refTable.Name)
+			results = append(results, foreignKeyAnnotation(table, fk, logicalSchema, "Referenced columns not indexed", message))
+		}
+	}
+	return results
+}
+
+// hasLeftPrefixIndex returns true if table has an index whose leftmost columns
+// are the supplied column names, in the same order.
+func hasLeftPrefixIndex(table *tengo.Table, colNames []string) bool {
+	indexes := table.SecondaryIndexes
+	if table.PrimaryKey != nil {
+		indexes = append([]*tengo.Index{table.PrimaryKey}, indexes...)
+	}
+	for _, idx := range indexes {
+		if len(idx.Columns) < len(colNames) {
+			continue
+		}
+		matches := true
+		for n, name := range colNames {
+			if idx.Columns[n].Name != name {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFKDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			message := fmt.Sprintf("Table %s has foreign key %s referencing table %s. Foreign keys are not permitted by this directory's linter configuration.", table.Name, fk.Name, fk.ReferencedTableName)
+			results = append(results, foreignKeyAnnotation(table, fk, logicalSchema, "Foreign key", message))
+		}
+	}
+	return results
+}
@@ -0,0 +1,92 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func fkTestSchema() (*tengo.Table, *tengo.Table) {
+	parentCols := []*tengo.Column{
+		{Name: "id", TypeInDB: "int(10) unsigned"},
+		{Name: "code", TypeInDB: "varchar(20)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+		{Name: "region", TypeInDB: "char(2)", CharSet: "latin1", Collation: "latin1_swedish_ci"},
+	}
+	parent := &tengo.Table{
+		Name:    "parent",
+		Columns: parentCols,
+		PrimaryKey: &tengo.Index{
+			Name:       "PRIMARY",
+			Columns:    []*tengo.Column{parentCols[0]},
+			SubParts:   []uint16{0},
+			PrimaryKey: true,
+		},
+		CreateStatement: "CREATE TABLE `parent` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `code` varchar(20) CHARACTER SET utf8mb4 NOT NULL,\n" +
+			"  `region` char(2) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	childCols := []*tengo.Column{
+		{Name: "parent_id", TypeInDB: "int(11)"},
+		{Name: "parent_code", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: "utf8mb4_bin"},
+		{Name: "parent_id2", TypeInDB: "int(11) unsigned"},
+	}
+	child := &tengo.Table{
+		Name:    "child",
+		Columns: childCols,
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "child_ibfk_1", Columns: []*tengo.Column{childCols[0]}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"id"}},
+			{Name: "child_ibfk_2", Columns: []*tengo.Column{childCols[1]}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"code"}},
+			{Name: "child_ibfk_3", Columns: []*tengo.Column{childCols[2]}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"id"}},
+			{Name: "child_ibfk_4", Columns: []*tengo.Column{childCols[0]}, ReferencedSchemaName: "other", ReferencedTableName: "parent", ReferencedColumnNames: []string{"region"}},
+		},
+		CreateStatement: "CREATE TABLE `child` (\n" +
+			"  `parent_id` int(11) NOT NULL,\n" +
+			"  `parent_code` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,\n" +
+			"  `parent_id2` int(11) unsigned NOT NULL,\n" +
+			"  CONSTRAINT `child_ibfk_1` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`),\n" +
+			"  CONSTRAINT `child_ibfk_2` FOREIGN KEY (`parent_code`) REFERENCES `parent` (`code`),\n" +
+			"  CONSTRAINT `child_ibfk_3` FOREIGN KEY (`parent_id2`) REFERENCES `parent` (`id`),\n" +
+			"  CONSTRAINT `child_ibfk_4` FOREIGN KEY (`parent_id`) REFERENCES `other`.`parent` (`region`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	return parent, child
+}
+
+func TestFKMismatchDetector(t *testing.T) {
+	schema, logicalSchema := testSchema(fkTestSchema())
+	annotations := fkMismatchDetector(schema, logicalSchema, Options{})
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, instead found %d", len(annotations))
+	}
+	if annotations[0].LineOffset != 4 || !strings.Contains(annotations[0].Message, "signedness") {
+		t.Errorf("Unexpected first annotation: %+v", annotations[0])
+	}
+	if annotations[1].LineOffset != 5 || !strings.Contains(annotations[1].Message, "collation utf8mb4_bin does not match collation utf8mb4_general_ci") {
+		t.Errorf("Unexpected second annotation: %+v", annotations[1])
+	}
+}
+
+func TestFKUnindexedDetector(t *testing.T) {
+	schema, logicalSchema := testSchema(fkTestSchema())
+	annotations := fkUnindexedDetector(schema, logicalSchema, Options{})
+	if len(annotations) != 1 || annotations[0].LineOffset != 5 || !strings.Contains(annotations[0].Message, "(code)") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+}
+
+func TestHasFKDetector(t *testing.T) {
+	schema, logicalSchema := testSchema(fkTestSchema())
+	annotations := hasFKDetector(schema, logicalSchema, Options{})
+	if len(annotations) != 4 {
+		t.Fatalf("Expected 4 annotations, instead found %d", len(annotations))
+	}
+	for n, a := range annotations {
+		if a.LineOffset != n+4 {
+			t.Errorf("Expected annotation %d to have line offset %d, instead found %d", n, n+4, a.LineOffset)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func hasEnumDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		for _, col := range table.Columns {
+			lowerType := strings.ToLower(col.TypeInDB)
+			var typeName string
+			if strings.HasPrefix(lowerType, "enum(") {
+				typeName = "ENUM"
+			} else if strings.HasPrefix(lowerType, "set(") {
+				typeName = "SET"
+			} else {
+				continue
+			}
+			values := col.TypeInDB[strings.IndexByte(col.TypeInDB, '(')+1 : strings.LastIndexByte(col.TypeInDB, ')')]
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+				Summary:    "Column using ENUM or SET",
+				Message:    fmt.Sprintf("Column %s of table %s is using type %s with values %s. Changing the list of values later will require an ALTER TABLE.", col.Name, table.Name, typeName, values),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,37 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestHasEnumDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name: "posts",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "status", TypeInDB: "enum('draft','published')"},
+			{Name: "flags", TypeInDB: "set('a','b')"},
+			{Name: "enumerated", TypeInDB: "varchar(20)"},
+		},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `status` enum('draft','published') NOT NULL,\n" +
+			"  `flags` set('a','b') NOT NULL,\n" +
+			"  `enumerated` varchar(20) NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+	annotations := hasEnumDetector(schema, logicalSchema, Options{})
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, instead found %d", len(annotations))
+	}
+	if annotations[0].LineOffset != 2 || !strings.Contains(annotations[0].Message, "ENUM with values 'draft','published'") {
+		t.Errorf("Unexpected first annotation: %+v", annotations[0])
+	}
+	if annotations[1].LineOffset != 3 || !strings.Contains(annotations[1].Message, "SET with values 'a','b'") {
+		t.Errorf("Unexpected second annotation: %+v", annotations[1])
+	}
+}
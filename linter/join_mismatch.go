@@ -0,0 +1,84 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// JoinKey represents a declared relationship between two columns which are
+// expected to be joined in queries, as configured by the join-keys option.
+type JoinKey struct {
+	Table1  string
+	Column1 string
+	Table2  string
+	Column2 string
+}
+
+// parseJoinKey parses a join-keys value of format "table1.col1=table2.col2".
+func parseJoinKey(value string) (JoinKey, error) {
+	parts := strings.Split(value, "=")
+	if len(parts) == 2 {
+		left := strings.Split(strings.TrimSpace(parts[0]), ".")
+		right := strings.Split(strings.TrimSpace(parts[1]), ".")
+		if len(left) == 2 && len(right) == 2 && left[0] != "" && left[1] != "" && right[0] != "" && right[1] != "" {
+			return JoinKey{Table1: left[0], Column1: left[1], Table2: right[0], Column2: right[1]}, nil
+		}
+	}
+	return JoinKey{}, fmt.Errorf("Option join-keys: value %s is not of format table1.col1=table2.col2", value)
+}
+
+func joinMismatchDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	compared := make(map[string]bool)
+	check := func(table *tengo.Table, col *tengo.Column, refTable *tengo.Table, refCol *tengo.Column) {
+		pair := fmt.Sprintf("%s.%s=%s.%s", table.Name, col.Name, refTable.Name, refCol.Name)
+		reversePair := fmt.Sprintf("%s.%s=%s.%s", refTable.Name, refCol.Name, table.Name, col.Name)
+		if compared[pair] || compared[reversePair] {
+			return
+		}
+		compared[pair] = true
+		problem := columnTypeMismatch(col, refCol)
+		if problem == "" {
+			return
+		}
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+			Summary:    "Join column mismatch",
+			Message:    fmt.Sprintf("Column %s of table %s may be joined with column %s of table %s, but %s. Joins between these columns may require implicit conversion, preventing efficient use of indexes.", col.Name, table.Name, refCol.Name, refTable.Name, problem),
+		})
+	}
+
+	// Compare columns with the same name across tables, using the first table
+	// (in schema order) with each column name as the reference
+	firstSeen := make(map[string]*tengo.Table)
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			if opts.JoinIgnoreColumns != nil && opts.JoinIgnoreColumns.MatchString(col.Name) {
+				continue
+			}
+			if refTable, ok := firstSeen[col.Name]; !ok {
+				firstSeen[col.Name] = table
+			} else {
+				check(table, col, refTable, refTable.ColumnsByName()[col.Name])
+			}
+		}
+	}
+
+	// Compare explicitly-declared join relationships, if both sides exist
+	for _, jk := range opts.JoinKeys {
+		table1, table2 := schema.Table(jk.Table1), schema.Table(jk.Table2)
+		if table1 == nil || table2 == nil {
+			continue
+		}
+		col1, col2 := table1.ColumnsByName()[jk.Column1], table2.ColumnsByName()[jk.Column2]
+		if col1 != nil && col2 != nil {
+			check(table2, col2, table1, col1)
+		}
+	}
+	return results
+}
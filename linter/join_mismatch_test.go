@@ -0,0 +1,72 @@
+package linter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestParseJoinKey(t *testing.T) {
+	jk, err := parseJoinKey("users.id = posts.author_id")
+	expected := JoinKey{Table1: "users", Column1: "id", Table2: "posts", Column2: "author_id"}
+	if err != nil || jk != expected {
+		t.Errorf("Unexpected result from parseJoinKey: %+v, %v", jk, err)
+	}
+	for _, bad := range []string{"users.id", "users.id=posts", "users=posts.id", "a.b.c=d.e", ".id=posts.id", "a.b=c.d=e.f"} {
+		if _, err := parseJoinKey(bad); err == nil {
+			t.Errorf("Expected error from parseJoinKey(%q), but err was nil", bad)
+		}
+	}
+}
+
+func TestJoinMismatchDetector(t *testing.T) {
+	users := &tengo.Table{
+		Name: "users",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "bigint(20) unsigned"},
+			{Name: "email", TypeInDB: "varchar(100)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+		},
+		CreateStatement: "CREATE TABLE `users` (\n" +
+			"  `id` bigint(20) unsigned NOT NULL,\n" +
+			"  `email` varchar(100) NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	}
+	posts := &tengo.Table{
+		Name: "posts",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "author_id", TypeInDB: "int(10) unsigned"},
+			{Name: "email", TypeInDB: "varchar(100)", CharSet: "latin1", Collation: "latin1_swedish_ci"},
+		},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `author_id` int(10) unsigned NOT NULL,\n" +
+			"  `email` varchar(100) NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(users, posts)
+
+	// By default, the id columns are excluded from same-name comparison
+	opts := Options{JoinIgnoreColumns: regexp.MustCompile(`^id$`)}
+	annotations := joinMismatchDetector(schema, logicalSchema, opts)
+	if len(annotations) != 1 || annotations[0].LineOffset != 3 || !strings.Contains(annotations[0].Message, "character set latin1 does not match character set utf8mb4") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+
+	// Declared join relationships are also checked
+	opts.JoinKeys = []JoinKey{{Table1: "users", Column1: "id", Table2: "posts", Column2: "author_id"}}
+	annotations = joinMismatchDetector(schema, logicalSchema, opts)
+	if len(annotations) != 2 || annotations[1].LineOffset != 2 || !strings.Contains(annotations[1].Message, "column id of table users") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+
+	// Without any ignored columns, id is compared too, but declaring the same
+	// pair doesn't result in a duplicate annotation
+	opts = Options{JoinKeys: []JoinKey{{Table1: "users", Column1: "id", Table2: "posts", Column2: "id"}}}
+	annotations = joinMismatchDetector(schema, logicalSchema, opts)
+	if len(annotations) != 2 || annotations[0].LineOffset != 1 {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+}
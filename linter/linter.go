@@ -1,10 +1,14 @@
 // Package linter handles logic around linting schemas and returning results.
+// Programs embedding the linter may add their own problems by calling
+// RegisterProblem with a Detector function.
 package linter
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -12,24 +16,39 @@ import (
 // Annotation is an error, warning, or notice from linting a single SQL
 // statement.
 type Annotation struct {
-	Statement  *fs.Statement
-	LineOffset int
-	Summary    string
-	Message    string
-	Problem    string
+	Statement    *fs.Statement
+	LineOffset   int
+	Summary      string
+	Message      string
+	Problem      string
+	Guidance     string                            // custom text from lint-guidance for Problem, if any
+	OnlyGuidance bool                              // if true, Guidance replaces Message in DisplayMessage
+	Fix          func(statementText string) string // if non-nil, rewrites the statement to correct the problem
 }
 
-// MessageWithLocation prepends statement location information to a.Message,
-// if location information is available. Otherwise, it appends the full SQL
-// statement that the message refers to.
+// DisplayMessage returns a.Message combined with any custom guidance. Output
+// should use this instead of a.Message, which is always the problem's own
+// message, and is therefore stable for purposes of baselining.
+func (a *Annotation) DisplayMessage() string {
+	if a.Guidance == "" {
+		return a.Message
+	} else if a.OnlyGuidance {
+		return a.Guidance
+	}
+	return fmt.Sprintf("%s. %s", strings.TrimRight(a.Message, "."), a.Guidance)
+}
+
+// MessageWithLocation prepends statement location information to
+// a.DisplayMessage(), if location information is available. Otherwise, it
+// appends the full SQL statement that the message refers to.
 func (a *Annotation) MessageWithLocation() string {
 	if a.Statement.File == "" || a.Statement.LineNo == 0 {
-		return fmt.Sprintf("%s [Full SQL: %s]", a.Message, a.Statement.Text)
+		return fmt.Sprintf("%s [Full SQL: %s]", a.DisplayMessage(), a.Statement.Text)
 	}
 	if a.LineOffset == 0 && a.Statement.CharNo > 1 {
-		return fmt.Sprintf("%s:%d:%d: %s", a.Statement.File, a.Statement.LineNo, a.Statement.CharNo, a.Message)
+		return fmt.Sprintf("%s:%d:%d: %s", a.Statement.File, a.Statement.LineNo, a.Statement.CharNo, a.DisplayMessage())
 	}
-	return fmt.Sprintf("%s:%d: %s", a.Statement.File, a.Statement.LineNo+a.LineOffset, a.Message)
+	return fmt.Sprintf("%s:%d: %s", a.Statement.File, a.Statement.LineNo+a.LineOffset, a.DisplayMessage())
 }
 
 // Result is a combined set of linter annotations and/or Golang errors found
@@ -38,6 +57,8 @@ type Result struct {
 	Errors        []*Annotation // "Errors" in the linting sense, not in the Golang sense
 	Warnings      []*Annotation
 	FormatNotices []*Annotation
+	Fixes         []*Annotation // Errors or Warnings which were corrected by rewriting files
+	Baselined     []*Annotation // Errors or Warnings which were omitted due to being listed in a baseline
 	DebugLogs     []string
 	Exceptions    []error
 	Schemas       map[string]*tengo.Schema // Keyed by dir path and optionally schema name
@@ -51,6 +72,8 @@ func (r *Result) Merge(other *Result) {
 	r.Errors = append(r.Errors, other.Errors...)
 	r.Warnings = append(r.Warnings, other.Warnings...)
 	r.FormatNotices = append(r.FormatNotices, other.FormatNotices...)
+	r.Fixes = append(r.Fixes, other.Fixes...)
+	r.Baselined = append(r.Baselined, other.Baselined...)
 	r.DebugLogs = append(r.DebugLogs, other.DebugLogs...)
 	r.Exceptions = append(r.Exceptions, other.Exceptions...)
 	if r.Schemas == nil {
@@ -80,6 +103,21 @@ func LintDir(dir *fs.Dir, wsOpts workspace.Options) *Result {
 	if err != nil && len(dir.LogicalSchemas) > 0 {
 		return BadConfigResult(err)
 	}
+	if !opts.Flavor.Known() {
+		if wsOpts.Flavor.Known() {
+			opts.Flavor = wsOpts.Flavor
+		} else if wsOpts.Instance != nil {
+			opts.Flavor = wsOpts.Instance.Flavor()
+		}
+	}
+	if wsOpts.Instance != nil && opts.Flavor.Known() {
+		if major, minor, patch := wsOpts.Instance.Version(); major == opts.Flavor.Major && minor == opts.Flavor.Minor {
+			opts.FlavorPatch = patch
+		}
+	}
+	if !opts.TiDB.Known() && wsOpts.Instance != nil {
+		opts.TiDB = util.InstanceTiDBFlavor(wsOpts.Instance)
+	}
 
 	result := &Result{}
 	for _, logicalSchema := range dir.LogicalSchemas {
@@ -140,7 +178,7 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options
 	}
 	for _, stmtErr := range statementErrors {
 		if opts.ShouldIgnore(stmtErr.ObjectKey()) {
-			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", stmtErr.ObjectKey(), opts.IgnoreTable))
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because it matches an ignore option", stmtErr.ObjectKey()))
 			continue
 		}
 		result.Errors = append(result.Errors, &Annotation{
@@ -151,11 +189,20 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options
 	}
 
 	for problemName, severity := range opts.ProblemSeverity {
-		annotations := problems[problemName](schema, logicalSchema, opts)
+		var annotations []*Annotation
+		if command, ok := opts.Plugins[problemName]; ok {
+			if annotations, err = runPlugin(problemName, command, schema, logicalSchema, opts); err != nil {
+				result.Exceptions = append(result.Exceptions, err)
+				continue
+			}
+		} else {
+			annotations = problems[problemName](schema, logicalSchema, opts)
+		}
 		for _, a := range annotations {
 			a.Problem = problemName
+			opts.applyGuidance(a)
 			if opts.ShouldIgnore(a.Statement.ObjectKey()) {
-				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", a.Statement.ObjectKey(), opts.IgnoreTable))
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because it matches an ignore option", a.Statement.ObjectKey()))
 			} else if severity == SeverityWarning {
 				result.Warnings = append(result.Warnings, a)
 			} else {
@@ -172,7 +219,7 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options
 		fsBody, fsSuffix := fsStmt.SplitTextBody()
 		if instCreateText != fsBody {
 			if opts.ShouldIgnore(key) {
-				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", key, opts.IgnoreTable))
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because it matches an ignore option", key))
 			} else {
 				result.FormatNotices = append(result.FormatNotices, &Annotation{
 					Statement: fsStmt,
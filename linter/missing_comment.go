@@ -0,0 +1,52 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func missingCommentDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	checkTables := (opts.CommentScope == "table" || opts.CommentScope == "all")
+	checkColumns := (opts.CommentScope == "column" || opts.CommentScope == "all")
+
+	// problemWith returns a description of what is wrong with comment, or an
+	// empty string if the comment is acceptable.
+	problemWith := func(comment string) string {
+		if comment == "" {
+			return "does not have a COMMENT"
+		} else if opts.CommentPattern != nil && !opts.CommentPattern.MatchString(comment) {
+			return "has a COMMENT which does not match the pattern specified by option comment-pattern"
+		}
+		return ""
+	}
+
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if checkTables {
+			if problem := problemWith(table.Comment); problem != "" {
+				results = append(results, &Annotation{
+					Statement: stmt,
+					Summary:   "Missing or invalid comment",
+					Message:   fmt.Sprintf("Table %s %s", table.Name, problem),
+				})
+			}
+		}
+		if checkColumns {
+			for _, col := range table.Columns {
+				if problem := problemWith(col.Comment); problem != "" {
+					results = append(results, &Annotation{
+						Statement:  stmt,
+						LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+						Summary:    "Missing or invalid comment",
+						Message:    fmt.Sprintf("Column %s of table %s %s", col.Name, table.Name, problem),
+					})
+				}
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,51 @@
+package linter
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestMissingCommentDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name: "users",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned", Comment: "[public] user id"},
+			{Name: "email", TypeInDB: "varchar(100)", Comment: "email address"},
+			{Name: "name", TypeInDB: "varchar(100)"},
+		},
+		Comment: "[pii] user accounts",
+		CreateStatement: "CREATE TABLE `users` (\n" +
+			"  `id` int(10) unsigned NOT NULL COMMENT '[public] user id',\n" +
+			"  `email` varchar(100) NOT NULL COMMENT 'email address',\n" +
+			"  `name` varchar(100) NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1 COMMENT='[pii] user accounts'",
+	}
+	schema, logicalSchema := testSchema(table)
+	const summary = "Missing or invalid comment"
+
+	cases := []struct {
+		scope    string
+		pattern  string
+		expected []string
+	}{
+		{"table", "", []string{}},
+		{"column", "", []string{summary + ":3"}},
+		{"all", "", []string{summary + ":3"}},
+		{"table", `^\[(public|internal|pii)\]`, []string{}},
+		{"all", `^\[(public|internal|pii)\]`, []string{summary + ":2", summary + ":3"}},
+		{"table", `^\[public\]`, []string{summary + ":0"}},
+	}
+	for _, c := range cases {
+		opts := Options{CommentScope: c.scope}
+		if c.pattern != "" {
+			opts.CommentPattern = regexp.MustCompile(c.pattern)
+		}
+		actual := annotationSummaries(missingCommentDetector(schema, logicalSchema, opts))
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("With scope=%s pattern=%s: expected annotations %v, instead found %v", c.scope, c.pattern, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func noEncryptionDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		if !util.TableEncrypted(table) {
+			key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+			results = append(results, &Annotation{
+				Statement: logicalSchema.Creates[key],
+				Summary:   "Table not encrypted",
+				Message:   fmt.Sprintf("Table %s does not use ENCRYPTION='Y'. If your environments differ in encryption support, consider using table-encryption=ignore for the environments that lack it.", table.Name),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,28 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestNoEncryptionDetector(t *testing.T) {
+	plain := &tengo.Table{
+		Name:            "plain",
+		CreateStatement: "CREATE TABLE `plain` (\n  `id` int NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	disabled := &tengo.Table{
+		Name:            "disabled",
+		CreateStatement: "CREATE TABLE `disabled` (\n  `id` int NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1 ENCRYPTION='N'",
+	}
+	encrypted := &tengo.Table{
+		Name:            "encrypted",
+		CreateStatement: "CREATE TABLE `encrypted` (\n  `id` int NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1 ENCRYPTION='Y'",
+	}
+	schema, logicalSchema := testSchema(plain, disabled, encrypted)
+	expected := []string{"Table not encrypted:0", "Table not encrypted:0"}
+	if actual := annotationSummaries(noEncryptionDetector(schema, logicalSchema, Options{})); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+}
@@ -0,0 +1,54 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// baseTypeFamily returns the base type of a column, with any tiny, medium, or
+// long size prefix removed from BLOB and TEXT types. For example, both
+// "mediumtext" and "text" return "text".
+func baseTypeFamily(typeInDB string) string {
+	base, _ := parseColumnType(typeInDB)
+	if strings.HasSuffix(base, "blob") || strings.HasSuffix(base, "text") {
+		return base[len(base)-4:]
+	}
+	return base
+}
+
+func nullableColumnDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		pkCols := make(map[string]bool)
+		if table.PrimaryKey != nil {
+			for _, col := range table.PrimaryKey.Columns {
+				pkCols[col.Name] = true
+			}
+		}
+		for _, col := range table.Columns {
+			if col.AutoIncrement || pkCols[col.Name] || isAllowed(baseTypeFamily(col.TypeInDB), opts.NullableExemptTypes) {
+				continue
+			}
+			var message string
+			if col.Nullable {
+				message = fmt.Sprintf("Column %s of table %s permits NULL values", col.Name, table.Name)
+			} else if col.Default.Null {
+				message = fmt.Sprintf("Column %s of table %s is NOT NULL but lacks an explicit DEFAULT", col.Name, table.Name)
+			} else {
+				continue
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+				Summary:    "Column nullability policy",
+				Message:    message,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,40 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestNullableColumnDetector(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true, Default: tengo.ColumnDefaultNull}
+	table := &tengo.Table{
+		Name: "posts",
+		Columns: []*tengo.Column{
+			idCol,
+			{Name: "title", TypeInDB: "varchar(80)", Default: tengo.ColumnDefaultValue("")},
+			{Name: "subtitle", TypeInDB: "varchar(80)", Nullable: true, Default: tengo.ColumnDefaultNull},
+			{Name: "author_id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull},
+			{Name: "body", TypeInDB: "mediumtext", Nullable: true, Default: tengo.ColumnDefaultNull},
+		},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+			"  `title` varchar(80) NOT NULL DEFAULT '',\n" +
+			"  `subtitle` varchar(80) DEFAULT NULL,\n" +
+			"  `author_id` int(10) unsigned NOT NULL,\n" +
+			"  `body` mediumtext,\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+
+	annotations := nullableColumnDetector(schema, logicalSchema, Options{NullableExemptTypes: []string{"text", "blob"}})
+	if actual := annotationSummaries(annotations); len(actual) != 2 || actual[0] != "Column nullability policy:3" || actual[1] != "Column nullability policy:4" {
+		t.Errorf("Unexpected annotations: %v", actual)
+	}
+	annotations = nullableColumnDetector(schema, logicalSchema, Options{})
+	if len(annotations) != 3 {
+		t.Errorf("Expected 3 annotations, instead found %d", len(annotations))
+	}
+}
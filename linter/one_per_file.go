@@ -0,0 +1,49 @@
+package linter
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// onePerFileDetector flags objects whose CREATE statement shares a file with
+// other objects, or is in a file not named for the object. Objects of different
+// types but with the same name may share a file, since their canonical
+// filenames are the same.
+func onePerFileDetector(_ *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	namesByFile := make(map[string]map[string]bool)
+	keys := make([]tengo.ObjectKey, 0, len(logicalSchema.Creates))
+	for key, stmt := range logicalSchema.Creates {
+		if namesByFile[stmt.File] == nil {
+			namesByFile[stmt.File] = make(map[string]bool)
+		}
+		namesByFile[stmt.File][key.Name] = true
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	results := make([]*Annotation, 0)
+	for _, key := range keys {
+		stmt := logicalSchema.Creates[key]
+		expectFileName := filepath.Base(fs.PathForObject("", key.Name))
+		if count := len(namesByFile[stmt.File]); count > 1 {
+			results = append(results, &Annotation{
+				Statement: stmt,
+				Summary:   "Multiple objects in one file",
+				Message:   fmt.Sprintf("%s is defined in a file containing %d other objects. Each object should have its own file, named %s. Use `skeema format --split` to correct this automatically.", key, count-1, expectFileName),
+			})
+		} else if fileName := filepath.Base(stmt.File); fileName != expectFileName {
+			results = append(results, &Annotation{
+				Statement: stmt,
+				Summary:   "File name does not match object name",
+				Message:   fmt.Sprintf("%s is defined in file %s, but its file should be named %s. Use `skeema format --split` to correct this automatically.", key, fileName, expectFileName),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,41 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestOnePerFileDetector(t *testing.T) {
+	logicalSchema := &fs.LogicalSchema{Creates: make(map[tengo.ObjectKey]*fs.Statement)}
+	add := func(objectType tengo.ObjectType, name, file string) {
+		key := tengo.ObjectKey{Type: objectType, Name: name}
+		logicalSchema.Creates[key] = &fs.Statement{File: file, Type: fs.StatementTypeCreate, ObjectType: objectType, ObjectName: name}
+	}
+	add(tengo.ObjectTypeTable, "users", "/schemas/product/users.sql")
+	add(tengo.ObjectTypeProc, "users", "/schemas/product/users.sql") // same name may share file
+	add(tengo.ObjectTypeTable, "posts", "/schemas/product/tables/posts.sql")
+	add(tengo.ObjectTypeTable, "comments", "/schemas/product/misc.sql")
+	add(tengo.ObjectTypeTable, "subscriptions", "/schemas/product/multi.sql")
+	add(tengo.ObjectTypeFunc, "total", "/schemas/product/multi.sql")
+
+	annotations := onePerFileDetector(nil, logicalSchema, Options{})
+	expected := []string{
+		"Multiple objects in one file:0",
+		"File name does not match object name:0",
+		"Multiple objects in one file:0",
+	}
+	if actual := annotationSummaries(annotations); len(actual) != len(expected) {
+		t.Fatalf("Expected %d annotations, instead found %d: %v", len(expected), len(actual), actual)
+	} else {
+		for n := range expected {
+			if actual[n] != expected[n] {
+				t.Errorf("Expected annotation[%d] to be %q, instead found %q", n, expected[n], actual[n])
+			}
+		}
+	}
+	if annotations[0].Statement.ObjectName != "total" || annotations[1].Statement.ObjectName != "comments" || annotations[2].Statement.ObjectName != "subscriptions" {
+		t.Errorf("Annotations not for expected objects: %s, %s, %s", annotations[0].Statement.ObjectName, annotations[1].Statement.ObjectName, annotations[2].Statement.ObjectName)
+	}
+}
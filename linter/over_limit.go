@@ -0,0 +1,60 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func overLimitDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if opts.MaxColumns > 0 && len(table.Columns) > opts.MaxColumns {
+			results = append(results, &Annotation{
+				Statement: stmt,
+				Summary:   "Too many columns",
+				Message:   fmt.Sprintf("Table %s has %d columns, which exceeds option max-columns=%d", table.Name, len(table.Columns), opts.MaxColumns),
+			})
+		}
+		indexes := table.SecondaryIndexes
+		if table.PrimaryKey != nil {
+			indexes = append([]*tengo.Index{table.PrimaryKey}, indexes...)
+		}
+		if opts.MaxIndexes > 0 && len(indexes) > opts.MaxIndexes {
+			results = append(results, &Annotation{
+				Statement: stmt,
+				Summary:   "Too many indexes",
+				Message:   fmt.Sprintf("Table %s has %d indexes, which exceeds option max-indexes=%d", table.Name, len(indexes), opts.MaxIndexes),
+			})
+		}
+		for _, idx := range indexes {
+			re := indexLineRegexp(idx)
+			if opts.MaxIndexColumns > 0 && len(idx.Columns) > opts.MaxIndexColumns {
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: findFirstLineOffset(re, stmt.Text),
+					Summary:    "Too many columns in index",
+					Message:    fmt.Sprintf("Index %s of table %s has %d columns, which exceeds option max-index-columns=%d", idx.Name, table.Name, len(idx.Columns), opts.MaxIndexColumns),
+				})
+			}
+			if opts.MaxIndexBytes > 0 {
+				var size int
+				for n, col := range idx.Columns {
+					size += columnIndexBytes(col, idx.SubParts[n])
+				}
+				if size > opts.MaxIndexBytes {
+					results = append(results, &Annotation{
+						Statement:  stmt,
+						LineOffset: findFirstLineOffset(re, stmt.Text),
+						Summary:    "Index too wide",
+						Message:    fmt.Sprintf("Index %s of table %s has a maximum key size of %d bytes, which exceeds option max-index-bytes=%d", idx.Name, table.Name, size, opts.MaxIndexBytes),
+					})
+				}
+			}
+		}
+	}
+	return results
+}
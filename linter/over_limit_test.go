@@ -0,0 +1,49 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestOverLimitDetector(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20) unsigned"}
+	emailCol := &tengo.Column{Name: "email", TypeInDB: "varchar(255)", CharSet: "utf8mb4"}
+	nameCol := &tengo.Column{Name: "name", TypeInDB: "varchar(100)", CharSet: "utf8mb4"}
+	table := &tengo.Table{
+		Name:       "users",
+		Columns:    []*tengo.Column{idCol, emailCol, nameCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "email", Columns: []*tengo.Column{emailCol}, SubParts: []uint16{0}, Unique: true},
+			{Name: "name_email", Columns: []*tengo.Column{nameCol, emailCol}, SubParts: []uint16{0, 20}},
+		},
+		CreateStatement: "CREATE TABLE `users` (\n" +
+			"  `id` bigint(20) unsigned NOT NULL,\n" +
+			"  `email` varchar(255) NOT NULL,\n" +
+			"  `name` varchar(100) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  UNIQUE KEY `email` (`email`),\n" +
+			"  KEY `name_email` (`name`,`email`(20))\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	}
+	schema, logicalSchema := testSchema(table)
+
+	// email index: 255*4+2 = 1022 bytes. name_email index: 100*4+2 + 20*4+2 = 484.
+	opts := Options{MaxColumns: 2, MaxIndexes: 2, MaxIndexColumns: 1, MaxIndexBytes: 1000}
+	annotations := annotationSummaries(overLimitDetector(schema, logicalSchema, opts))
+	expected := []string{"Too many columns:0", "Too many indexes:0", "Index too wide:5", "Too many columns in index:6"}
+	if len(annotations) != len(expected) {
+		t.Fatalf("Expected %d annotations, instead found %v", len(expected), annotations)
+	}
+	for n := range expected {
+		if annotations[n] != expected[n] {
+			t.Errorf("Expected annotation[%d] to be %q, instead found %q", n, expected[n], annotations[n])
+		}
+	}
+
+	opts = Options{MaxColumns: 3, MaxIndexes: 3, MaxIndexColumns: 2, MaxIndexBytes: 1022}
+	if annotations := overLimitDetector(schema, logicalSchema, opts); len(annotations) != 0 {
+		t.Errorf("Expected no annotations, instead found %d", len(annotations))
+	}
+}
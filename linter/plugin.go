@@ -0,0 +1,87 @@
+package linter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// pluginInput is the JSON document supplied on STDIN to an external lint
+// plugin. The plugin is invoked once per object.
+type pluginInput struct {
+	Type    tengo.ObjectType `json:"type"`
+	Name    string           `json:"name"`
+	File    string           `json:"file"`
+	LineNo  int              `json:"line"`
+	Create  string           `json:"create"`
+	Table   *tengo.Table     `json:"table,omitempty"`
+	Routine *tengo.Routine   `json:"routine,omitempty"`
+}
+
+// pluginFinding is a single problem reported by an external lint plugin. The
+// plugin should emit one finding per line on STDOUT, each as a JSON object.
+type pluginFinding struct {
+	LineOffset int    `json:"line_offset"`
+	Summary    string `json:"summary"`
+	Message    string `json:"message"`
+}
+
+// runPlugin executes the external lint plugin command once for each table and
+// routine in schema, returning annotations for any findings. A non-nil error
+// is returned if the command fails or emits output that cannot be parsed.
+func runPlugin(name, command string, schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) ([]*Annotation, error) {
+	inputs := make([]pluginInput, 0, len(schema.Tables)+len(schema.Routines))
+	for _, table := range schema.Tables {
+		inputs = append(inputs, pluginInput{Type: tengo.ObjectTypeTable, Name: table.Name, Table: table})
+	}
+	for _, routine := range schema.Routines {
+		inputs = append(inputs, pluginInput{Type: routine.Type, Name: routine.Name, Routine: routine})
+	}
+
+	results := make([]*Annotation, 0)
+	s := &util.ShellOut{Command: command}
+	for _, input := range inputs {
+		key := tengo.ObjectKey{Type: input.Type, Name: input.Name}
+		stmt := logicalSchema.Creates[key]
+		if stmt == nil || opts.ShouldIgnore(key) {
+			continue
+		}
+		input.File = stmt.File
+		input.LineNo = stmt.LineNo
+		input.Create, _ = stmt.SplitTextBody()
+		encoded, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		output, err := s.RunCaptureWithInput(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("Lint plugin %s failed on %s: %s", name, key, err)
+		}
+		scanner := bufio.NewScanner(strings.NewReader(output))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var finding pluginFinding
+			if err := json.Unmarshal([]byte(line), &finding); err != nil {
+				return nil, fmt.Errorf("Lint plugin %s returned invalid output for %s: %s", name, key, err)
+			}
+			if finding.Summary == "" {
+				finding.Summary = name
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: finding.LineOffset,
+				Summary:    finding.Summary,
+				Message:    finding.Message,
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,49 @@
+package linter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestRunPlugin(t *testing.T) {
+	schema := &tengo.Schema{
+		Tables: []*tengo.Table{
+			{Name: "good"},
+			{Name: "bad_name"},
+			{Name: "_ignored"},
+		},
+		Routines: []*tengo.Routine{
+			{Name: "bad_proc", Type: tengo.ObjectTypeProc},
+		},
+	}
+	logicalSchema := &fs.LogicalSchema{Creates: make(map[tengo.ObjectKey]*fs.Statement)}
+	for _, table := range schema.Tables {
+		logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}] = &fs.Statement{Text: "CREATE TABLE " + table.Name + " (\n  id int\n);\n", File: table.Name + ".sql", LineNo: 1, ObjectName: table.Name}
+	}
+	logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "bad_proc"}] = &fs.Statement{Text: "CREATE PROCEDURE bad_proc() BEGIN END;\n", File: "bad_proc.sql", LineNo: 1, ObjectName: "bad_proc"}
+	opts := Options{IgnoreTable: regexp.MustCompile("^_")}
+
+	command := `grep -q '"name":"bad_' && printf '{"line_offset":1,"message":"name begins with bad_"}\n\n' || true`
+	annotations, err := runPlugin("house-naming", command, schema, logicalSchema, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from runPlugin: %s", err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, instead found %d", len(annotations))
+	}
+	for _, a := range annotations {
+		if (a.Statement.ObjectName != "bad_name" && a.Statement.ObjectName != "bad_proc") || a.LineOffset != 1 || a.Summary != "house-naming" || a.Message != "name begins with bad_" {
+			t.Errorf("Unexpected annotation values: %+v", a)
+		}
+	}
+
+	// Confirm errors for failing commands and unparseable output
+	for _, command := range []string{"false", "echo not json"} {
+		if _, err := runPlugin("house-naming", command, schema, logicalSchema, opts); err == nil {
+			t.Errorf("Expected error from runPlugin with command %q, but err was nil", command)
+		}
+	}
+}
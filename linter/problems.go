@@ -11,21 +11,65 @@ import (
 )
 
 // A Detector function analyzes a schema for a particular problem, returning
-// annotations for cases of the problem found.
+// annotations for cases of the problem found. The *tengo.Schema has been
+// introspected from a workspace, so it reflects the server's interpretation of
+// the CREATE statements. The *fs.LogicalSchema supplies the corresponding
+// statements from the filesystem, keyed by tengo.ObjectKey; each returned
+// Annotation should set its Statement field to one of these. The Problem field
+// of returned annotations is populated automatically.
 type Detector func(*tengo.Schema, *fs.LogicalSchema, Options) []*Annotation
 
 var problems map[string]Detector
 
 // RegisterProblem adds a new named problem, along with its detector function.
+// Once registered, the problem name may be used as a value in the warnings and
+// errors options. Names are case-insensitive. RegisterProblem is intended to be
+// called from an init function of a package which embeds the linter, and is
+// not safe to call concurrently with linting. It panics if name is empty or
+// is already registered.
 func RegisterProblem(name string, fn Detector) {
+	name = strings.ToLower(name)
+	if name == "" {
+		panic(fmt.Errorf("RegisterProblem: problem name cannot be empty"))
+	} else if _, already := problems[name]; already {
+		panic(fmt.Errorf("RegisterProblem: problem %s is already registered", name))
+	}
 	problems[name] = fn
 }
 
+// ProblemNames returns a sorted list of all registered problem names, including
+// both built-in problems and ones added via RegisterProblem.
+func ProblemNames() []string {
+	return allProblemNames()
+}
+
 func init() {
 	problems = map[string]Detector{
-		"no-pk":       noPKDetector,
-		"bad-charset": badCharsetDetector,
-		"bad-engine":  badEngineDetector,
+		"no-pk":           noPKDetector,
+		"auto-inc":        autoIncDetector,
+		"bad-charset":     badCharsetDetector,
+		"bad-collation":   badCollationDetector,
+		"bad-engine":      badEngineDetector,
+		"bad-name":        badNameDetector,
+		"deprecated-type": deprecatedTypeDetector,
+		"fk-mismatch":     fkMismatchDetector,
+		"fk-unindexed":    fkUnindexedDetector,
+		"has-enum":        hasEnumDetector,
+		"has-fk":          hasFKDetector,
+		"join-mismatch":   joinMismatchDetector,
+		"missing-comment": missingCommentDetector,
+		"no-encryption":   noEncryptionDetector,
+		"nullable-column": nullableColumnDetector,
+		"one-per-file":    onePerFileDetector,
+		"over-limit":      overLimitDetector,
+		"redundant-index": redundantIndexDetector,
+		"reserved-word":   reservedWordDetector,
+		"row-size":        rowSizeDetector,
+		"string-pk":       stringPKDetector,
+		"temporal-column": temporalColumnDetector,
+		"tidb-compat":     tidbCompatDetector,
+		"vitess-compat":   vitessCompatDetector,
+		"wide-index":      wideIndexDetector,
 	}
 }
 
@@ -57,6 +101,7 @@ func badCharsetDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, o
 				LineOffset: findLastLineOffset(re, stmt.Text),
 				Summary:    "Character set not permitted",
 				Message:    fmt.Sprintf("Table %s is using default character set %s, which is not listed in option allow-charset", table.Name, table.CharSet),
+				Fix:        charsetFixer(`(?i)(default\s+)?(character\s+set|charset)\s*=?\s*`, " DEFAULT CHARSET=", table.CharSet, table.Collation, opts.AllowedCharSets[0], true),
 			})
 			continue // if a table's default charset isn't allowed, don't generate col-level annotations too
 		}
@@ -72,6 +117,7 @@ func badCharsetDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, o
 					LineOffset: findFirstLineOffset(re, stmt.Text),
 					Summary:    "Character set not permitted",
 					Message:    fmt.Sprintf("Column %s of table %s is using character set %s, which is not listed in option allow-charset", col.Name, table.Name, table.CharSet),
+					Fix:        charsetFixer(`(?i)(character\s+set|charset)\s+`, " CHARACTER SET ", col.CharSet, col.Collation, opts.AllowedCharSets[0], false),
 				})
 				break // stop after the first disallowed charset col per table
 			}
@@ -92,6 +138,7 @@ func badEngineDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, op
 				LineOffset: findFirstLineOffset(re, stmt.Text),
 				Summary:    "Storage engine not permitted",
 				Message:    fmt.Sprintf("Table %s is using storage engine %s, which is not listed in option allow-engine", table.Name, table.Engine),
+				Fix:        engineFixer(table.Engine, opts.AllowedEngines[0]),
 			})
 		}
 	}
@@ -99,6 +146,86 @@ func badEngineDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, op
 	return results
 }
 
+// charsetFixer returns a function suitable for use as Annotation.Fix, which
+// replaces character set clauses matching clausePrefix and oldCharSet with
+// newPrefix and newCharSet. Any COLLATE clauses referencing oldCollation are
+// removed, so that the new character set's default collation is used. If
+// tableLevel is true, only the table options following the table body's
+// closing paren are changed; otherwise only the column definitions within the
+// table body are changed.
+func charsetFixer(clausePrefix, newPrefix, oldCharSet, oldCollation, newCharSet string, tableLevel bool) func(string) string {
+	charsetRe := regexp.MustCompile(fmt.Sprintf(`\s*%s%s\b`, clausePrefix, regexp.QuoteMeta(oldCharSet)))
+	collateRe := regexp.MustCompile(fmt.Sprintf(`(?i)\s*collate\s*=?\s*%s\b`, regexp.QuoteMeta(oldCollation)))
+	return func(statementText string) string {
+		start, end := tableBodyBounds(statementText)
+		if start < 0 {
+			return statementText
+		}
+		if tableLevel {
+			start, end = end+1, len(statementText)
+		}
+		part := charsetRe.ReplaceAllLiteralString(statementText[start:end], newPrefix+newCharSet)
+		part = collateRe.ReplaceAllLiteralString(part, "")
+		return statementText[:start] + part + statementText[end:]
+	}
+}
+
+// engineFixer returns a function suitable for use as Annotation.Fix, which
+// replaces an ENGINE clause referencing oldEngine in the table options with
+// newEngine. If the table options have no ENGINE clause at all, meaning the
+// server's default engine was used, an ENGINE clause is added instead.
+func engineFixer(oldEngine, newEngine string) func(string) string {
+	engineRe := regexp.MustCompile(fmt.Sprintf(`(?i)\bENGINE\s*=?\s*%s\b`, regexp.QuoteMeta(oldEngine)))
+	anyEngineRe := regexp.MustCompile(`(?i)\bENGINE\b`)
+	return func(statementText string) string {
+		_, end := tableBodyBounds(statementText)
+		if end < 0 {
+			return statementText
+		}
+		options := statementText[end+1:]
+		if anyEngineRe.MatchString(options) {
+			options = engineRe.ReplaceAllLiteralString(options, "ENGINE="+newEngine)
+		} else {
+			options = " ENGINE=" + newEngine + options
+		}
+		return statementText[:end+1] + options
+	}
+}
+
+// tableBodyBounds returns the positions of the opening and closing parens
+// surrounding the column and index definitions of a CREATE TABLE statement.
+// Parens within quoted strings or identifiers are ignored. If the body cannot
+// be located, -1, -1 is returned.
+func tableBodyBounds(createStatement string) (start, end int) {
+	start = -1
+	var depth int
+	var quote byte
+	for n := 0; n < len(createStatement); n++ {
+		c := createStatement[n]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				n++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			if depth == 0 && start < 0 {
+				start = n
+			}
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+			if depth == 0 {
+				return start, n
+			}
+		}
+	}
+	return -1, -1
+}
+
 func problemExists(name string) bool {
 	_, ok := problems[strings.ToLower(name)]
 	return ok
@@ -150,3 +277,30 @@ func findLastLineOffset(re *regexp.Regexp, createStatement string) int {
 	lastLoc := locs[len(locs)-1]
 	return strings.Count(createStatement[0:lastLoc[0]], "\n")
 }
+
+// identifierRegexp returns a regexp for locating name within a statement, as
+// either a backtick-quoted or bare identifier. Bare occurrences which are just
+// part of a longer identifier are not matched. The optional prefix and suffix
+// are regexp fragments which must immediately precede or follow the name; a
+// non-empty suffix should begin with a non-identifier character. Matches never
+// begin with a newline, so the regexp is safe for use with findFirstLineOffset
+// and findLastLineOffset.
+func identifierRegexp(prefix, name, suffix string) *regexp.Regexp {
+	if suffix == "" {
+		suffix = "(?:[^0-9a-z$_`]|$)"
+	}
+	return regexp.MustCompile("(?im)(?:^|[^0-9a-z$_`\\n])" + prefix + "(?:" + regexp.QuoteMeta(tengo.EscapeIdentifier(name)) + "|" + regexp.QuoteMeta(name) + ")" + suffix)
+}
+
+// findIdentifierLine returns the line offset (i.e. line number starting at 0)
+// and text of the first line of createStatement containing name as an
+// identifier, quoted or not. If name cannot be found, 0 and an empty string are
+// returned.
+func findIdentifierLine(name, createStatement string) (int, string) {
+	loc := identifierRegexp("", name, "").FindStringIndex(createStatement)
+	if loc == nil {
+		return 0, ""
+	}
+	offset := strings.Count(createStatement[0:loc[0]], "\n")
+	return offset, strings.Split(createStatement, "\n")[offset]
+}
@@ -1,11 +1,13 @@
 package linter
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
 	"testing"
 
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
 )
 
 func TestProblemExists(t *testing.T) {
@@ -18,7 +20,7 @@ func TestProblemExists(t *testing.T) {
 }
 
 func TestAllProblemNames(t *testing.T) {
-	expected := []string{"bad-charset", "bad-engine", "no-pk"}
+	expected := []string{"auto-inc", "bad-charset", "bad-collation", "bad-engine", "bad-name", "deprecated-type", "fk-mismatch", "fk-unindexed", "has-enum", "has-fk", "join-mismatch", "missing-comment", "no-encryption", "no-pk", "nullable-column", "one-per-file", "over-limit", "redundant-index", "reserved-word", "row-size", "string-pk", "temporal-column", "tidb-compat", "vitess-compat", "wide-index"}
 	actual := allProblemNames()
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("allProblemNames returned %+v, did not match expectation %+v", actual, expected)
@@ -30,11 +32,29 @@ func TestAllProblemNames(t *testing.T) {
 		// Clean up the global state
 		delete(problems, "new-prob")
 	}()
-	expected = []string{"bad-charset", "bad-engine", "new-prob", "no-pk"}
+	expected = []string{"auto-inc", "bad-charset", "bad-collation", "bad-engine", "bad-name", "deprecated-type", "fk-mismatch", "fk-unindexed", "has-enum", "has-fk", "join-mismatch", "missing-comment", "new-prob", "no-encryption", "no-pk", "nullable-column", "one-per-file", "over-limit", "redundant-index", "reserved-word", "row-size", "string-pk", "temporal-column", "tidb-compat", "vitess-compat", "wide-index"}
 	actual = allProblemNames()
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("allProblemNames returned %+v, did not match expectation %+v", actual, expected)
 	}
+	if exported := ProblemNames(); !reflect.DeepEqual(exported, expected) {
+		t.Errorf("ProblemNames returned %+v, did not match expectation %+v", exported, expected)
+	}
+}
+
+func TestRegisterProblemPanics(t *testing.T) {
+	assertPanic := func(name string) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected RegisterProblem(%q) to panic, but it did not", name)
+			}
+		}()
+		RegisterProblem(name, noPKDetector)
+	}
+	assertPanic("")
+	assertPanic("no-pk")
+	assertPanic("No-PK")
 }
 
 func TestIsAllowed(t *testing.T) {
@@ -69,3 +89,141 @@ func TestFindLastLineOffset(t *testing.T) {
 		t.Errorf("Expected last line offset to be 0, instead found %d", actual)
 	}
 }
+
+func TestIdentifierRegexp(t *testing.T) {
+	stmt := "CREATE TABLE orders (\n" +
+		"  order_id int NOT NULL,\n" +
+		"  `id` int NOT NULL,\n" +
+		"  total_id int,\n" +
+		"  id2 int,\n" +
+		"  Status varchar(10),\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY idx_status (Status),\n" +
+		"  CONSTRAINT fk_thing FOREIGN KEY (total_id) REFERENCES things (id)\n" +
+		")"
+	cases := []struct {
+		prefix, name, suffix string
+		expected             int
+	}{
+		{"", "id", "", 2},
+		{"", "order_id", "", 1},
+		{"", "id2", "", 4},
+		{"", "status", "", 5},
+		{"", "idx_status", `\s*\(`, 7},
+		{`CONSTRAINT\s+`, "fk_thing", "", 8},
+		{"", "missing", "", 0},
+	}
+	for _, c := range cases {
+		if actual := findFirstLineOffset(identifierRegexp(c.prefix, c.name, c.suffix), stmt); actual != c.expected {
+			t.Errorf("Expected identifier %s to be found at line offset %d, instead found %d", c.name, c.expected, actual)
+		}
+	}
+	if offset, line := findIdentifierLine("total_id", stmt); offset != 3 || line != "  total_id int," {
+		t.Errorf("Unexpected result from findIdentifierLine: %d, %q", offset, line)
+	}
+	if offset, line := findIdentifierLine("total", stmt); offset != 0 || line != "" {
+		t.Errorf("Unexpected result from findIdentifierLine: %d, %q", offset, line)
+	}
+}
+
+func TestCharsetFixer(t *testing.T) {
+	tableLevel := charsetFixer(`(?i)(default\s+)?(character\s+set|charset)\s*=?\s*`, " DEFAULT CHARSET=", "latin1", "latin1_swedish_ci", "utf8mb4", true)
+	input := "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB DEFAULT CHARSET=latin1 COLLATE=latin1_swedish_ci;\n"
+	expected := "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"
+	if actual := tableLevel(input); actual != expected {
+		t.Errorf("Unexpected result from table-level fixer: %q", actual)
+	}
+
+	// Table-level fixer must not touch column definitions, even if they use the
+	// same character set
+	input = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET latin1 COLLATE latin1_swedish_ci DEFAULT ')'\n) ENGINE=InnoDB DEFAULT CHARSET=latin1;\n"
+	expected = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET latin1 COLLATE latin1_swedish_ci DEFAULT ')'\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"
+	if actual := tableLevel(input); actual != expected {
+		t.Errorf("Unexpected result from table-level fixer: %q", actual)
+	}
+
+	colLevel := charsetFixer(`(?i)(character\s+set|charset)\s+`, " CHARACTER SET ", "latin1", "latin1_bin", "utf8mb4", false)
+	input = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET latin1 COLLATE latin1_bin DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"
+	expected = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET utf8mb4 DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n"
+	if actual := colLevel(input); actual != expected {
+		t.Errorf("Unexpected result from column-level fixer: %q", actual)
+	}
+
+	// Column-level fixer must not touch the table options
+	input = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET latin1 COLLATE latin1_bin\n) DEFAULT CHARSET latin1 COLLATE latin1_bin;\n"
+	expected = "CREATE TABLE foo (\n  name varchar(20) CHARACTER SET utf8mb4\n) DEFAULT CHARSET latin1 COLLATE latin1_bin;\n"
+	if actual := colLevel(input); actual != expected {
+		t.Errorf("Unexpected result from column-level fixer: %q", actual)
+	}
+
+	// Statements without a recognizable body are left alone
+	input = "CREATE TABLE foo LIKE bar;\n"
+	if actual := tableLevel(input); actual != input {
+		t.Errorf("Unexpected result from table-level fixer: %q", actual)
+	}
+}
+
+func TestEngineFixer(t *testing.T) {
+	fixer := engineFixer("MyISAM", "InnoDB")
+	cases := map[string]string{
+		"CREATE TABLE foo (\n  id int\n) ENGINE=MyISAM DEFAULT CHARSET=utf8mb4;\n":      "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n",
+		"CREATE TABLE foo (\n  id int\n) engine myisam;\n":                              "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB;\n",
+		"CREATE TABLE foo (\n  id int\n);\n":                                            "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB;\n",
+		"CREATE TABLE foo (\n  id int\n) DEFAULT CHARSET=utf8mb4;\n":                    "CREATE TABLE foo (\n  id int\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n",
+		"CREATE TABLE foo (\n  engine varchar(10) DEFAULT 'MyISAM'\n) ENGINE=MyISAM;\n": "CREATE TABLE foo (\n  engine varchar(10) DEFAULT 'MyISAM'\n) ENGINE=InnoDB;\n",
+		"CREATE TABLE foo LIKE bar;\n":                                                  "CREATE TABLE foo LIKE bar;\n",
+	}
+	for input, expected := range cases {
+		if actual := fixer(input); actual != expected {
+			t.Errorf("Unexpected result from engine fixer on %q: %q", input, actual)
+		}
+	}
+}
+
+func TestTableBodyBounds(t *testing.T) {
+	cases := []struct {
+		input      string
+		start, end int
+	}{
+		{"CREATE TABLE foo (id int)", 17, 24},
+		{"CREATE TABLE `a(b` (id int, d decimal(5,2))", 19, 42},
+		{"CREATE TABLE foo (c char(1) DEFAULT ')') ENGINE=InnoDB", 17, 39},
+		{"CREATE TABLE foo (c char(1) DEFAULT '\\')') ENGINE=InnoDB", 17, 41},
+		{"CREATE TABLE foo LIKE bar", -1, -1},
+	}
+	for _, c := range cases {
+		if start, end := tableBodyBounds(c.input); start != c.start || end != c.end {
+			t.Errorf("Expected tableBodyBounds(%q) to return %d, %d; instead found %d, %d", c.input, c.start, c.end, start, end)
+		}
+	}
+}
+
+// testSchema returns a *tengo.Schema and corresponding *fs.LogicalSchema
+// containing the supplied tables, for use in testing detectors without a
+// workspace. Each table's CreateStatement is used as its statement text.
+func testSchema(tables ...*tengo.Table) (*tengo.Schema, *fs.LogicalSchema) {
+	schema := &tengo.Schema{Name: "testing", Tables: tables}
+	logicalSchema := &fs.LogicalSchema{Creates: make(map[tengo.ObjectKey]*fs.Statement)}
+	for _, table := range tables {
+		logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}] = &fs.Statement{
+			File:       table.Name + ".sql",
+			LineNo:     1,
+			CharNo:     1,
+			Text:       table.CreateStatement + ";\n",
+			Type:       fs.StatementTypeCreate,
+			ObjectType: tengo.ObjectTypeTable,
+			ObjectName: table.Name,
+		}
+	}
+	return schema, logicalSchema
+}
+
+// annotationSummaries returns a slice of "summary:lineoffset" strings for
+// the supplied annotations, to simplify comparisons in detector tests.
+func annotationSummaries(annotations []*Annotation) []string {
+	result := make([]string, len(annotations))
+	for n, a := range annotations {
+		result[n] = fmt.Sprintf("%s:%d", a.Summary, a.LineOffset)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// indexCovers returns true if the columns of idx are a left-prefix of the
+// columns of other, or identical to them, including any prefix lengths.
+func indexCovers(other, idx *tengo.Index) bool {
+	if len(idx.Columns) > len(other.Columns) {
+		return false
+	}
+	for n, col := range idx.Columns {
+		if col.Name != other.Columns[n].Name || idx.SubParts[n] != other.SubParts[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// redundantTo returns an index of table that makes idx redundant, or nil if
+// there is no such index. In the case of two indexes with identical columns,
+// only the later one is considered redundant, unless the earlier one is not
+// unique while the later one is.
+func redundantTo(table *tengo.Table, idx *tengo.Index) *tengo.Index {
+	candidates := table.SecondaryIndexes
+	if table.PrimaryKey != nil {
+		candidates = append([]*tengo.Index{table.PrimaryKey}, candidates...)
+	}
+	var seenSelf bool
+	for _, other := range candidates {
+		if other == idx {
+			seenSelf = true
+			continue
+		}
+		if !indexCovers(other, idx) {
+			continue
+		}
+		if len(idx.Columns) < len(other.Columns) {
+			if !idx.Unique {
+				return other
+			}
+		} else if other.Unique && !idx.Unique {
+			return other
+		} else if other.Unique == idx.Unique && !seenSelf {
+			return other
+		}
+	}
+	return nil
+}
+
+func redundantIndexDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		for _, idx := range table.SecondaryIndexes {
+			other := redundantTo(table, idx)
+			if other == nil {
+				continue
+			}
+			var message string
+			if len(idx.Columns) == len(other.Columns) {
+				message = fmt.Sprintf("Index %s of table %s is a duplicate of index %s", idx.Name, table.Name, other.Name)
+			} else {
+				message = fmt.Sprintf("Index %s of table %s is redundant, since its columns are a prefix of index %s", idx.Name, table.Name, other.Name)
+			}
+			re := identifierRegexp("", idx.Name, `\s*\(`)
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(re, stmt.Text),
+				Summary:    "Redundant index",
+				Message:    message,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,67 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestRedundantIndexDetector(t *testing.T) {
+	cols := []*tengo.Column{
+		{Name: "id", TypeInDB: "int(10) unsigned"},
+		{Name: "a", TypeInDB: "int(10) unsigned"},
+		{Name: "b", TypeInDB: "int(10) unsigned"},
+		{Name: "c", TypeInDB: "varchar(20)"},
+	}
+	makeIndex := func(name string, unique bool, colIndexes ...int) *tengo.Index {
+		idx := &tengo.Index{Name: name, Unique: unique}
+		for _, n := range colIndexes {
+			idx.Columns = append(idx.Columns, cols[n])
+			idx.SubParts = append(idx.SubParts, 0)
+		}
+		return idx
+	}
+	prefixed := makeIndex("c_prefix", false, 3)
+	prefixed.SubParts[0] = 10
+	table := &tengo.Table{
+		Name:       "things",
+		Columns:    cols,
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			makeIndex("a_b", false, 1, 2),  // not redundant
+			makeIndex("a", false, 1),       // redundant to a_b
+			makeIndex("a_uniq", true, 1),   // not redundant: unique
+			makeIndex("b_a", false, 2, 1),  // not redundant
+			makeIndex("b_a2", false, 2, 1), // duplicate of b_a
+			makeIndex("id", false, 0),      // duplicate of PRIMARY
+			makeIndex("c", false, 3),       // not redundant: prefix length differs
+			prefixed,                       // not redundant: prefix length differs
+		},
+		CreateStatement: "CREATE TABLE `things` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `a` int(10) unsigned NOT NULL,\n" +
+			"  `b` int(10) unsigned NOT NULL,\n" +
+			"  `c` varchar(20) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  KEY `a_b` (`a`,`b`),\n" +
+			"  KEY `a` (`a`),\n" +
+			"  UNIQUE KEY `a_uniq` (`a`),\n" +
+			"  KEY `b_a` (`b`,`a`),\n" +
+			"  KEY `b_a2` (`b`,`a`),\n" +
+			"  KEY `id` (`id`),\n" +
+			"  KEY `c` (`c`),\n" +
+			"  KEY `c_prefix` (`c`(10))\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+	annotations := redundantIndexDetector(schema, logicalSchema, Options{})
+	expectedOffsets := []int{7, 10, 11}
+	if len(annotations) != len(expectedOffsets) {
+		t.Fatalf("Expected %d annotations, instead found %d: %v", len(expectedOffsets), len(annotations), annotationSummaries(annotations))
+	}
+	for n, a := range annotations {
+		if a.LineOffset != expectedOffsets[n] {
+			t.Errorf("Expected annotation[%d] to have line offset %d, instead found %d: %s", n, expectedOffsets[n], a.LineOffset, a.Message)
+		}
+	}
+}
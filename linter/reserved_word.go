@@ -0,0 +1,138 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// reservedWordsBase lists words reserved in all supported flavors, based on
+// MySQL 5.5 and MariaDB 10.1.
+var reservedWordsBase = strings.Fields(`
+ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN BIGINT
+BINARY BLOB BOTH BY CALL CASCADE CASE CHANGE CHAR CHARACTER CHECK COLLATE
+COLUMN CONDITION CONSTRAINT CONTINUE CONVERT CREATE CROSS CURRENT_DATE
+CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATABASE DATABASES DAY_HOUR
+DAY_MICROSECOND DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT DELAYED DELETE
+DESC DESCRIBE DETERMINISTIC DISTINCT DISTINCTROW DIV DOUBLE DROP DUAL EACH ELSE
+ELSEIF ENCLOSED ESCAPED EXISTS EXIT EXPLAIN FALSE FETCH FLOAT FLOAT4 FLOAT8 FOR
+FORCE FOREIGN FROM FULLTEXT GRANT GROUP HAVING HIGH_PRIORITY HOUR_MICROSECOND
+HOUR_MINUTE HOUR_SECOND IF IGNORE IN INDEX INFILE INNER INOUT INSENSITIVE INSERT
+INT INT1 INT2 INT3 INT4 INT8 INTEGER INTERVAL INTO IS ITERATE JOIN KEY KEYS KILL
+LEADING LEAVE LEFT LIKE LIMIT LINEAR LINES LOAD LOCALTIME LOCALTIMESTAMP LOCK
+LONG LONGBLOB LONGTEXT LOOP LOW_PRIORITY MASTER_SSL_VERIFY_SERVER_CERT MATCH
+MAXVALUE MEDIUMBLOB MEDIUMINT MEDIUMTEXT MIDDLEINT MINUTE_MICROSECOND
+MINUTE_SECOND MOD MODIFIES NATURAL NOT NO_WRITE_TO_BINLOG NULL NUMERIC ON
+OPTIMIZE OPTION OPTIONALLY OR ORDER OUT OUTER OUTFILE PARTITION PRECISION
+PRIMARY PROCEDURE PURGE RANGE READ READS READ_WRITE REAL REFERENCES REGEXP
+RELEASE RENAME REPEAT REPLACE REQUIRE RESIGNAL RESTRICT RETURN REVOKE RIGHT RLIKE
+SCHEMA SCHEMAS SECOND_MICROSECOND SELECT SENSITIVE SEPARATOR SET SHOW SIGNAL
+SMALLINT SPATIAL SPECIFIC SQL SQLEXCEPTION SQLSTATE SQLWARNING SQL_BIG_RESULT
+SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL STARTING STRAIGHT_JOIN TABLE TERMINATED
+THEN TINYBLOB TINYINT TINYTEXT TO TRAILING TRIGGER TRUE UNDO UNION UNIQUE UNLOCK
+UNSIGNED UPDATE USAGE USE USING UTC_DATE UTC_TIME UTC_TIMESTAMP VALUES VARBINARY
+VARCHAR VARCHARACTER VARYING WHEN WHERE WHILE WITH WRITE XOR YEAR_MONTH ZEROFILL
+`)
+
+// reservedWordsAdded lists words which became reserved in a specific version of
+// a vendor. Entries for VendorMySQL also apply to Percona Server and to unknown
+// vendors.
+var reservedWordsAdded = []struct {
+	vendor tengo.Vendor
+	major  int
+	minor  int
+	words  []string
+}{
+	{tengo.VendorMySQL, 5, 6, strings.Fields("GET IO_AFTER_GTIDS IO_BEFORE_GTIDS MASTER_BIND")},
+	{tengo.VendorMySQL, 5, 7, strings.Fields("GENERATED OPTIMIZER_COSTS STORED VIRTUAL")},
+	{tengo.VendorMySQL, 8, 0, strings.Fields(`
+		CUBE CUME_DIST DENSE_RANK EMPTY EXCEPT FIRST_VALUE GROUPING GROUPS JSON_TABLE
+		LAG LAST_VALUE LATERAL LEAD NTH_VALUE NTILE OF OVER PERCENT_RANK RANK
+		RECURSIVE ROW ROWS ROW_NUMBER SYSTEM WINDOW`)},
+	{tengo.VendorMariaDB, 10, 0, strings.Fields(`
+		DO_DOMAIN_IDS GENERAL GET IGNORE_DOMAIN_IDS IGNORE_SERVER_IDS
+		MASTER_HEARTBEAT_PERIOD RETURNING SLOW`)},
+	{tengo.VendorMariaDB, 10, 2, strings.Fields("OVER RECURSIVE ROWS")},
+	{tengo.VendorMariaDB, 10, 3, strings.Fields("EXCEPT INTERSECT")},
+}
+
+// reservedWords returns a set of uppercase words which are reserved in flavor.
+// If the flavor is unknown, only the words reserved in all flavors are
+// returned.
+func reservedWords(flavor tengo.Flavor) map[string]bool {
+	result := make(map[string]bool, len(reservedWordsBase)+50)
+	for _, word := range reservedWordsBase {
+		result[word] = true
+	}
+	if !flavor.Known() {
+		return result
+	}
+	for _, added := range reservedWordsAdded {
+		var applies bool
+		if added.vendor == tengo.VendorMariaDB {
+			applies = flavor.VendorMinVersion(tengo.VendorMariaDB, added.major, added.minor)
+		} else {
+			applies = flavor.MySQLishMinVersion(added.major, added.minor)
+		}
+		if applies {
+			for _, word := range added.words {
+				result[word] = true
+			}
+		}
+	}
+	return result
+}
+
+// NewReservedWords returns a set of uppercase words which are reserved in flavor
+// to, but not in flavor from. This is useful when planning an upgrade.
+func NewReservedWords(from, to tengo.Flavor) map[string]bool {
+	result := reservedWords(to)
+	for word := range reservedWords(from) {
+		delete(result, word)
+	}
+	return result
+}
+
+func reservedWordDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	// Build a map of reserved word => description of flavors reserving it. The
+	// flavor in use takes precedence in the description.
+	flavors := append([]tengo.Flavor{opts.Flavor}, opts.ReservedWordFlavors...)
+	reservedIn := make(map[string]string)
+	for _, flavor := range flavors {
+		desc := flavor.String()
+		if !flavor.Known() {
+			desc = "all database flavors"
+		}
+		for word := range reservedWords(flavor) {
+			if _, already := reservedIn[word]; !already {
+				reservedIn[word] = desc
+			}
+		}
+	}
+
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if desc, ok := reservedIn[strings.ToUpper(table.Name)]; ok {
+			results = append(results, &Annotation{
+				Statement: stmt,
+				Summary:   "Name is a reserved word",
+				Message:   fmt.Sprintf("Table name %s is a reserved word in %s", table.Name, desc),
+			})
+		}
+		for _, col := range table.Columns {
+			if desc, ok := reservedIn[strings.ToUpper(col.Name)]; ok {
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+					Summary:    "Name is a reserved word",
+					Message:    fmt.Sprintf("Column name %s of table %s is a reserved word in %s", col.Name, table.Name, desc),
+				})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,75 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestReservedWords(t *testing.T) {
+	cases := []struct {
+		flavor   tengo.Flavor
+		word     string
+		reserved bool
+	}{
+		{tengo.FlavorUnknown, "SELECT", true},
+		{tengo.FlavorUnknown, "RANK", false},
+		{tengo.FlavorMySQL57, "VIRTUAL", true},
+		{tengo.FlavorMySQL57, "RANK", false},
+		{tengo.FlavorPercona80, "RANK", true},
+		{tengo.FlavorMySQL80, "INTERSECT", false},
+		{tengo.FlavorMariaDB102, "RECURSIVE", true},
+		{tengo.FlavorMariaDB102, "EXCEPT", false},
+		{tengo.FlavorMariaDB103, "EXCEPT", true},
+		{tengo.FlavorMariaDB103, "VIRTUAL", false},
+	}
+	for _, c := range cases {
+		if actual := reservedWords(c.flavor)[c.word]; actual != c.reserved {
+			t.Errorf("Expected reserved status of %s in %s to be %t, instead found %t", c.word, c.flavor, c.reserved, actual)
+		}
+	}
+}
+
+func TestNewReservedWords(t *testing.T) {
+	words := NewReservedWords(tengo.FlavorMySQL57, tengo.FlavorMySQL80)
+	if !words["RANK"] || !words["LATERAL"] || words["SELECT"] || words["VIRTUAL"] {
+		t.Errorf("Unexpected result from NewReservedWords: %v", words)
+	}
+	if words := NewReservedWords(tengo.FlavorMySQL80, tengo.FlavorMySQL80); len(words) != 0 {
+		t.Errorf("Expected no new reserved words when flavors are identical, instead found %v", words)
+	}
+}
+
+func TestReservedWordDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name: "rank",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "key", TypeInDB: "varchar(20)"},
+			{Name: "rows", TypeInDB: "int(10) unsigned"},
+		},
+		CreateStatement: "CREATE TABLE `rank` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `key` varchar(20) NOT NULL,\n" +
+			"  `rows` int(10) unsigned NOT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+
+	// With 5.7, only `key` is reserved
+	annotations := reservedWordDetector(schema, logicalSchema, Options{Flavor: tengo.FlavorMySQL57})
+	if len(annotations) != 1 || annotations[0].LineOffset != 2 {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+
+	// Also checking 8.0 should flag the table name and `rows`
+	opts := Options{Flavor: tengo.FlavorMySQL57, ReservedWordFlavors: []tengo.Flavor{tengo.FlavorMySQL80}}
+	annotations = reservedWordDetector(schema, logicalSchema, opts)
+	if len(annotations) != 3 {
+		t.Fatalf("Expected 3 annotations, instead found %d", len(annotations))
+	}
+	if !strings.Contains(annotations[0].Message, "mysql:8.0") || !strings.Contains(annotations[1].Message, "mysql:5.7") {
+		t.Errorf("Unexpected annotation messages: %s / %s", annotations[0].Message, annotations[1].Message)
+	}
+}
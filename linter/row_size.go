@@ -0,0 +1,66 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+const (
+	// maxRowBytes is the maximum row size permitted by the server, regardless of
+	// storage engine. BLOB, TEXT, and JSON columns only count their pointer size.
+	maxRowBytes = 65535
+
+	// maxInnoDBInRowBytes is the maximum amount of data InnoDB can store on a
+	// row's own page, with the default innodb_page_size of 16KB.
+	maxInnoDBInRowBytes = 8126
+)
+
+var rowFormatRegexp = regexp.MustCompile(`(?i)row_format=(\w+)`)
+
+// tableRowBytes returns the maximum size of a row in table, as well as the
+// minimum number of bytes InnoDB must store in-page for a row when all
+// eligible columns have been moved off-page.
+func tableRowBytes(table *tengo.Table) (maxSize, inRowSize int) {
+	rowFormat := "dynamic"
+	if matches := rowFormatRegexp.FindStringSubmatch(table.CreateOptions); matches != nil {
+		rowFormat = strings.ToLower(matches[1])
+	}
+	var nullable int
+	for _, col := range table.Columns {
+		maxSize += columnStorageBytes(col)
+		inRowSize += columnInRowBytes(col, rowFormat)
+		if col.Nullable {
+			nullable++
+		}
+	}
+	nullBytes := (nullable + 7) / 8
+	return maxSize + nullBytes, inRowSize + nullBytes
+}
+
+func rowSizeDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		maxSize, inRowSize := tableRowBytes(table)
+		var message string
+		if maxSize > maxRowBytes {
+			message = fmt.Sprintf("Table %s has a maximum row size of %d bytes, which exceeds the server's limit of %d bytes", table.Name, maxSize, maxRowBytes)
+		} else if strings.EqualFold(table.Engine, "InnoDB") && inRowSize > maxInnoDBInRowBytes {
+			message = fmt.Sprintf("Table %s requires at least %d bytes per row to be stored in-page, which exceeds InnoDB's limit of %d bytes with the default 16KB page size", table.Name, inRowSize, maxInnoDBInRowBytes)
+		} else if opts.MaxRowBytes > 0 && maxSize > opts.MaxRowBytes {
+			message = fmt.Sprintf("Table %s has a maximum row size of %d bytes, which exceeds option max-row-bytes=%d", table.Name, maxSize, opts.MaxRowBytes)
+		} else {
+			continue
+		}
+		results = append(results, &Annotation{
+			Statement: logicalSchema.Creates[key],
+			Summary:   "Row size too large",
+			Message:   message,
+		})
+	}
+	return results
+}
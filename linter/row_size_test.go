@@ -0,0 +1,52 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestRowSizeDetector(t *testing.T) {
+	makeTable := func(name, createOptions string, colTypes ...string) *tengo.Table {
+		table := &tengo.Table{
+			Name:          name,
+			Engine:        "InnoDB",
+			CreateOptions: createOptions,
+		}
+		colDefs := make([]string, len(colTypes))
+		for n, colType := range colTypes {
+			col := &tengo.Column{Name: fmt.Sprintf("c%d", n), TypeInDB: colType, CharSet: "utf8mb4", Nullable: true}
+			table.Columns = append(table.Columns, col)
+			colDefs[n] = fmt.Sprintf("  `%s` %s DEFAULT NULL", col.Name, colType)
+		}
+		table.CreateStatement = fmt.Sprintf("CREATE TABLE `%s` (\n%s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", name, strings.Join(colDefs, ",\n"))
+		return table
+	}
+	small := makeTable("small", "", "int(11)", "varchar(100)", "text")
+	wide := makeTable("wide", "", "varchar(10000)", "varchar(10000)")
+	manyChars := makeTable("many_chars", "", strings.Split(strings.Repeat("char(50),", 45)+"int(11)", ",")...)
+	compact := makeTable("compact", " ROW_FORMAT=COMPACT", "text", "text", "text", "text", "text", "text", "text", "text", "text", "text", "text")
+	dynamic := makeTable("dynamic", "", "text", "text", "text", "text", "text", "text", "text", "text", "text", "text", "text")
+
+	if maxSize, inRowSize := tableRowBytes(small); maxSize != 4+402+10+1 || inRowSize != 4+20+20+1 {
+		t.Errorf("Unexpected row sizes for table small: max=%d, in-row=%d", maxSize, inRowSize)
+	}
+
+	schema, logicalSchema := testSchema(small, wide, manyChars, compact, dynamic)
+	annotations := rowSizeDetector(schema, logicalSchema, Options{})
+	flagged := make([]string, len(annotations))
+	for n, a := range annotations {
+		flagged[n] = a.Statement.ObjectName
+	}
+	if strings.Join(flagged, ",") != "wide,many_chars,compact" {
+		t.Errorf("Unexpected tables flagged: %v", flagged)
+	}
+
+	// Configurable threshold also flags the small table
+	annotations = rowSizeDetector(schema, logicalSchema, Options{MaxRowBytes: 400})
+	if len(annotations) != 4 || annotations[0].Statement.ObjectName != "small" || !strings.Contains(annotations[0].Message, "max-row-bytes=400") {
+		t.Errorf("Unexpected annotations with MaxRowBytes: %+v", annotations)
+	}
+}
@@ -0,0 +1,53 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+var onUpdateRegexp = regexp.MustCompile(`(?i)\bon\s+update\b`)
+
+func temporalColumnDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		for _, col := range table.Columns {
+			base, args := parseColumnType(col.TypeInDB)
+			if base != "datetime" && base != "timestamp" && base != "time" && base != "date" {
+				continue
+			}
+			lineOffset, line := findIdentifierLine(col.Name, stmt.Text)
+			messages := make([]string, 0)
+			if opts.TemporalType != "any" && (base == "datetime" || base == "timestamp") && base != opts.TemporalType {
+				messages = append(messages, fmt.Sprintf("Column %s of table %s is using type %s, but option temporal-type requires %s", col.Name, table.Name, strings.ToUpper(base), strings.ToUpper(opts.TemporalType)))
+			}
+			if col.Default.Quoted && strings.HasPrefix(col.Default.Value, "0000-00-00") {
+				messages = append(messages, fmt.Sprintf("Column %s of table %s has a zero-date default value, which is rejected when sql_mode includes NO_ZERO_DATE in strict mode", col.Name, table.Name))
+			}
+			// An ON UPDATE clause that isn't present in the column's line of the
+			// original statement was added implicitly by the server, typically due to
+			// explicit_defaults_for_timestamp being disabled. If the column's line
+			// can't be located, there's no way to tell, so don't flag it.
+			if col.OnUpdate != "" && line != "" && !onUpdateRegexp.MatchString(line) {
+				messages = append(messages, fmt.Sprintf("Column %s of table %s has an implicit ON UPDATE %s clause; consider enabling explicit_defaults_for_timestamp, or specifying the clause explicitly if it is intentional", col.Name, table.Name, col.OnUpdate))
+			}
+			if base != "date" && typeArg(args, 0) < opts.TemporalPrecision {
+				messages = append(messages, fmt.Sprintf("Column %s of table %s has fractional seconds precision of %d, but option temporal-precision requires at least %d", col.Name, table.Name, typeArg(args, 0), opts.TemporalPrecision))
+			}
+			for _, message := range messages {
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: lineOffset,
+					Summary:    "Temporal column policy",
+					Message:    message,
+				})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,54 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestTemporalColumnDetector(t *testing.T) {
+	table := &tengo.Table{
+		Name: "events",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "created_at", TypeInDB: "timestamp", Default: tengo.ColumnDefaultExpression("CURRENT_TIMESTAMP"), OnUpdate: "CURRENT_TIMESTAMP"},
+			{Name: "updated_at", TypeInDB: "datetime(6)", Default: tengo.ColumnDefaultExpression("CURRENT_TIMESTAMP(6)"), OnUpdate: "CURRENT_TIMESTAMP(6)"},
+			{Name: "birthday", TypeInDB: "date", Default: tengo.ColumnDefaultValue("0000-00-00")},
+			{Name: "duration", TypeInDB: "time(3)", Nullable: true, Default: tengo.ColumnDefaultNull},
+		},
+		CreateStatement: "CREATE TABLE `events` (\n" +
+			"  `id` int(10) unsigned NOT NULL,\n" +
+			"  `created_at` timestamp NOT NULL,\n" +
+			"  `updated_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),\n" +
+			"  `birthday` date NOT NULL DEFAULT '0000-00-00',\n" +
+			"  `duration` time(3) DEFAULT NULL\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	schema, logicalSchema := testSchema(table)
+
+	// With default options, only the zero date and implicit ON UPDATE are flagged
+	opts := Options{TemporalType: "any"}
+	annotations := temporalColumnDetector(schema, logicalSchema, opts)
+	expected := []string{"Temporal column policy:2", "Temporal column policy:4"}
+	if actual := annotationSummaries(annotations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+
+	// Requiring DATETIME(6) flags the TIMESTAMP column's type and precision, as
+	// well as the TIME(3) column's precision
+	opts = Options{TemporalType: "datetime", TemporalPrecision: 6}
+	annotations = temporalColumnDetector(schema, logicalSchema, opts)
+	expected = []string{"Temporal column policy:2", "Temporal column policy:2", "Temporal column policy:2", "Temporal column policy:4", "Temporal column policy:5"}
+	if actual := annotationSummaries(annotations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+
+	// Requiring TIMESTAMP flags the DATETIME column's type instead
+	opts = Options{TemporalType: "timestamp"}
+	annotations = temporalColumnDetector(schema, logicalSchema, opts)
+	expected = []string{"Temporal column policy:2", "Temporal column policy:3", "Temporal column policy:4"}
+	if actual := annotationSummaries(annotations); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+}
@@ -0,0 +1,41 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// tidbCompatDetector flags schema design choices which behave differently or
+// poorly on TiDB. It has no effect unless the flavor is TiDB.
+func tidbCompatDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	if !opts.TiDB.Known() {
+		return results
+	}
+	for _, table := range schema.Tables {
+		if !opts.TiDB.EnforcesForeignKeys() {
+			for _, fk := range table.ForeignKeys {
+				message := fmt.Sprintf("Table %s has foreign key %s, but %s does not enforce foreign key constraints. Foreign keys are only enforced in TiDB 6.6 and later.", table.Name, fk.Name, opts.TiDB)
+				results = append(results, foreignKeyAnnotation(table, fk, logicalSchema, "Foreign key not enforced", message))
+			}
+		}
+
+		// With a clustered primary key, rows are stored in primary key order, so
+		// sequential values concentrate all inserts on a single region
+		if table.PrimaryKey == nil || len(table.PrimaryKey.Columns) != 1 || !table.PrimaryKey.Columns[0].AutoIncrement || !util.TiDBClusteredIndex(table) {
+			continue
+		}
+		col := table.PrimaryKey.Columns[0]
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+			Summary:    "Auto-increment clustered primary key",
+			Message:    fmt.Sprintf("Table %s uses auto_increment column %s as a clustered primary key. On TiDB, this causes all inserts to be written to a single region, creating a hotspot. Consider using AUTO_RANDOM instead.", table.Name, col.Name),
+		})
+	}
+	return results
+}
@@ -0,0 +1,57 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestTiDBCompatDetector(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20)", AutoIncrement: true}
+	userIDCol := &tengo.Column{Name: "user_id", TypeInDB: "bigint(20)"}
+	posts := &tengo.Table{
+		Name:       "posts",
+		Columns:    []*tengo.Column{idCol, userIDCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "posts_ibfk_1", Columns: []*tengo.Column{userIDCol}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+		},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n" +
+			"  `user_id` bigint(20) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */,\n" +
+			"  CONSTRAINT `posts_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+	}
+	randIDCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20)"}
+	users := &tengo.Table{
+		Name:       "users",
+		Columns:    []*tengo.Column{randIDCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{randIDCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		CreateStatement: "CREATE TABLE `users` (\n" +
+			"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+			"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+	}
+	schema, logicalSchema := testSchema(posts, users)
+
+	// No effect unless the flavor is TiDB
+	if annotations := tidbCompatDetector(schema, logicalSchema, Options{}); len(annotations) > 0 {
+		t.Errorf("Expected no annotations for non-TiDB flavor, instead found %d", len(annotations))
+	}
+
+	annotations := tidbCompatDetector(schema, logicalSchema, Options{TiDB: util.ParseTiDBFlavor("tidb:6.5")})
+	expected := []string{"Foreign key not enforced:4", "Auto-increment clustered primary key:1"}
+	if actual := annotationSummaries(annotations); strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+
+	// Foreign keys are enforced in 6.6+; nonclustered primary keys are not a
+	// hotspot concern
+	posts.CreateStatement = strings.Replace(posts.CreateStatement, "CLUSTERED", "NONCLUSTERED", 1)
+	if annotations := tidbCompatDetector(schema, logicalSchema, Options{TiDB: util.ParseTiDBFlavor("tidb:7.1")}); len(annotations) > 0 {
+		t.Errorf("Expected no annotations for TiDB 7.1 with nonclustered primary key, instead found %v", annotationSummaries(annotations))
+	}
+}
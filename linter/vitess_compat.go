@@ -0,0 +1,59 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// vitessCompatDetector flags schema design choices which are incompatible with
+// Vitess, or which Vitess cannot shard well. It has no effect unless the vitess
+// option is enabled.
+func vitessCompatDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	if !opts.Vitess.Enabled() {
+		return results
+	}
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		if util.IsVitessSequenceTable(table) {
+			if problem := util.VitessSequenceProblem(table); problem != "" {
+				results = append(results, &Annotation{
+					Statement: stmt,
+					Summary:   "Malformed sequence table",
+					Message:   fmt.Sprintf("Table %s is marked as a Vitess sequence table, but %s. Sequence tables require columns id, next_id, and cache, with id as the primary key.", table.Name, problem),
+				})
+			}
+			if opts.Vitess.Sharded() {
+				results = append(results, &Annotation{
+					Statement: stmt,
+					Summary:   "Sequence table in sharded keyspace",
+					Message:   fmt.Sprintf("Table %s is marked as a Vitess sequence table, but sequence tables must reside in an unsharded keyspace.", table.Name),
+				})
+			}
+			continue
+		}
+		if !opts.Vitess.Sharded() {
+			continue
+		}
+		for _, fk := range table.ForeignKeys {
+			message := fmt.Sprintf("Table %s has foreign key %s, but Vitess cannot enforce foreign key constraints in a sharded keyspace, since the referenced rows may reside on a different shard.", table.Name, fk.Name)
+			results = append(results, foreignKeyAnnotation(table, fk, logicalSchema, "Foreign key in sharded keyspace", message))
+		}
+		for _, col := range table.Columns {
+			if !col.AutoIncrement {
+				continue
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(identifierRegexp("", col.Name, ""), stmt.Text),
+				Summary:    "Auto-increment in sharded keyspace",
+				Message:    fmt.Sprintf("Table %s has auto_increment column %s, but each shard generates auto_increment values independently, so values will collide across shards. Use a Vitess sequence in the VSchema instead.", table.Name, col.Name),
+			})
+		}
+	}
+	return results
+}
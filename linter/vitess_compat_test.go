@@ -0,0 +1,58 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestVitessCompatDetector(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20)", AutoIncrement: true}
+	userIDCol := &tengo.Column{Name: "user_id", TypeInDB: "bigint(20)"}
+	posts := &tengo.Table{
+		Name:       "posts",
+		Columns:    []*tengo.Column{idCol, userIDCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "posts_ibfk_1", Columns: []*tengo.Column{userIDCol}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+		},
+		CreateStatement: "CREATE TABLE `posts` (\n" +
+			"  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n" +
+			"  `user_id` bigint(20) NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  CONSTRAINT `posts_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	}
+	seqIDCol := &tengo.Column{Name: "id", TypeInDB: "int(11)"}
+	seq := &tengo.Table{
+		Name:       "posts_seq",
+		Comment:    util.VitessSequenceComment,
+		Columns:    []*tengo.Column{seqIDCol, {Name: "next_id", TypeInDB: "bigint(20)"}},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{seqIDCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		CreateStatement: "CREATE TABLE `posts_seq` (\n" +
+			"  `id` int(11) NOT NULL,\n" +
+			"  `next_id` bigint(20) DEFAULT NULL,\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='vitess_sequence'",
+	}
+	schema, logicalSchema := testSchema(posts, seq)
+
+	// No effect unless the vitess option is enabled
+	if annotations := vitessCompatDetector(schema, logicalSchema, Options{Vitess: util.VitessOff}); len(annotations) > 0 {
+		t.Errorf("Expected no annotations with vitess=off, instead found %d", len(annotations))
+	}
+
+	annotations := vitessCompatDetector(schema, logicalSchema, Options{Vitess: util.VitessUnsharded})
+	expected := []string{"Malformed sequence table:0"}
+	if actual := annotationSummaries(annotations); strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+
+	annotations = vitessCompatDetector(schema, logicalSchema, Options{Vitess: util.VitessSharded})
+	expected = []string{"Foreign key in sharded keyspace:4", "Auto-increment in sharded keyspace:1", "Malformed sequence table:0", "Sequence table in sharded keyspace:0"}
+	if actual := annotationSummaries(annotations); strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected annotations %v, instead found %v", expected, actual)
+	}
+}
@@ -0,0 +1,89 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// isStringType returns true if the supplied base type stores character or
+// binary strings.
+func isStringType(base string) bool {
+	switch base {
+	case "char", "varchar", "binary", "varbinary":
+		return true
+	}
+	return strings.HasSuffix(base, "blob") || strings.HasSuffix(base, "text")
+}
+
+// indexLineRegexp returns a regexp for locating idx's definition within a
+// CREATE TABLE statement.
+func indexLineRegexp(idx *tengo.Index) *regexp.Regexp {
+	if idx.PrimaryKey {
+		return regexp.MustCompile(`PRIMARY KEY`)
+	}
+	return identifierRegexp("", idx.Name, `\s*\(`)
+}
+
+func wideIndexDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		indexes := table.SecondaryIndexes
+		if table.PrimaryKey != nil {
+			indexes = append([]*tengo.Index{table.PrimaryKey}, indexes...)
+		}
+		for _, idx := range indexes {
+			for n, col := range idx.Columns {
+				base, _ := parseColumnType(col.TypeInDB)
+				var message string
+				if idx.PrimaryKey && (baseTypeFamily(base) == "blob" || baseTypeFamily(base) == "text") {
+					message = fmt.Sprintf("Primary key of table %s includes %s column %s", table.Name, strings.ToUpper(base), col.Name)
+				} else if idx.SubParts[n] == 0 && isStringType(base) && columnIndexBytes(col, 0) > opts.MaxIndexedStringBytes {
+					message = fmt.Sprintf("Index %s of table %s includes column %s without a prefix length, using up to %d bytes per key, which exceeds option max-indexed-string-bytes=%d", idx.Name, table.Name, col.Name, columnIndexBytes(col, 0), opts.MaxIndexedStringBytes)
+				} else {
+					continue
+				}
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: findFirstLineOffset(indexLineRegexp(idx), stmt.Text),
+					Summary:    "Wide string column in index",
+					Message:    message,
+				})
+			}
+		}
+	}
+	return results
+}
+
+func stringPKDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		if table.PrimaryKey == nil {
+			continue
+		}
+		var wideCols []string
+		for n, col := range table.PrimaryKey.Columns {
+			base, _ := parseColumnType(col.TypeInDB)
+			if isStringType(base) && columnIndexBytes(col, table.PrimaryKey.SubParts[n]) > opts.MaxIndexedStringBytes {
+				wideCols = append(wideCols, col.Name)
+			}
+		}
+		if len(wideCols) == 0 {
+			continue
+		}
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: findFirstLineOffset(indexLineRegexp(table.PrimaryKey), stmt.Text),
+			Summary:    "Primary key on wide string columns",
+			Message:    fmt.Sprintf("Primary key of table %s is built on wide string columns (%s). Consider using a surrogate key, such as an auto_increment integer, and a separate unique index.", table.Name, strings.Join(wideCols, ", ")),
+		})
+	}
+	return results
+}
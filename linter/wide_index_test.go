@@ -0,0 +1,76 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func wideIndexTestTable() *tengo.Table {
+	cols := []*tengo.Column{
+		{Name: "code", TypeInDB: "varchar(40)", CharSet: "utf8mb4"},
+		{Name: "body", TypeInDB: "text", CharSet: "utf8mb4"},
+		{Name: "title", TypeInDB: "varchar(200)", CharSet: "utf8mb4"},
+		{Name: "region", TypeInDB: "char(2)", CharSet: "latin1"},
+	}
+	return &tengo.Table{
+		Name:    "articles",
+		Columns: cols,
+		PrimaryKey: &tengo.Index{
+			Name:       "PRIMARY",
+			Columns:    []*tengo.Column{cols[3], cols[0]},
+			SubParts:   []uint16{0, 0},
+			PrimaryKey: true,
+			Unique:     true,
+		},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "body", Columns: []*tengo.Column{cols[1]}, SubParts: []uint16{50}},
+			{Name: "title", Columns: []*tengo.Column{cols[2]}, SubParts: []uint16{0}},
+			{Name: "title_prefix", Columns: []*tengo.Column{cols[2], cols[3]}, SubParts: []uint16{20, 0}},
+		},
+		CreateStatement: "CREATE TABLE `articles` (\n" +
+			"  `code` varchar(40) NOT NULL,\n" +
+			"  `body` text NOT NULL,\n" +
+			"  `title` varchar(200) NOT NULL,\n" +
+			"  `region` char(2) CHARACTER SET latin1 NOT NULL,\n" +
+			"  PRIMARY KEY (`region`,`code`),\n" +
+			"  KEY `body` (`body`(50)),\n" +
+			"  KEY `title` (`title`),\n" +
+			"  KEY `title_prefix` (`title`(20),`region`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	}
+}
+
+func TestWideIndexDetector(t *testing.T) {
+	table := wideIndexTestTable()
+	schema, logicalSchema := testSchema(table)
+	annotations := wideIndexDetector(schema, logicalSchema, Options{MaxIndexedStringBytes: 255})
+	if len(annotations) != 1 || annotations[0].LineOffset != 7 || !strings.Contains(annotations[0].Message, "up to 802 bytes") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+
+	// BLOB or TEXT in the primary key is always flagged
+	table.PrimaryKey.Columns[1] = table.Columns[1]
+	table.PrimaryKey.SubParts[1] = 10
+	annotations = wideIndexDetector(schema, logicalSchema, Options{MaxIndexedStringBytes: 1000})
+	if len(annotations) != 1 || annotations[0].LineOffset != 5 || !strings.Contains(annotations[0].Message, "TEXT column body") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+}
+
+func TestStringPKDetector(t *testing.T) {
+	schema, logicalSchema := testSchema(wideIndexTestTable())
+	annotations := stringPKDetector(schema, logicalSchema, Options{MaxIndexedStringBytes: 255})
+	if len(annotations) != 0 {
+		t.Errorf("Expected no annotations, instead found %+v", annotations)
+	}
+	annotations = stringPKDetector(schema, logicalSchema, Options{MaxIndexedStringBytes: 100})
+	if len(annotations) != 1 || annotations[0].LineOffset != 5 || !strings.Contains(annotations[0].Message, "(code)") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+	annotations = stringPKDetector(schema, logicalSchema, Options{MaxIndexedStringBytes: 1})
+	if len(annotations) != 1 || !strings.Contains(annotations[0].Message, "(region, code)") {
+		t.Errorf("Unexpected annotations: %+v", annotations)
+	}
+}
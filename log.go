@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -56,3 +57,41 @@ func (f *customFormatter) Format(entry *log.Entry) ([]byte, error) {
 	fmt.Fprintf(b, "%s %s %s\n", entry.Time.Format("2006-01-02 15:04:05"), levelText, entry.Message)
 	return b.Bytes(), nil
 }
+
+// jsonFormatter emits each log entry as a single-line JSON object, suitable
+// for ingestion by log pipelines. Fields added via log.WithFields, such as host
+// and schema, are included as top-level keys.
+type jsonFormatter struct {
+	log.JSONFormatter
+}
+
+func newJSONFormatter() *jsonFormatter {
+	return &jsonFormatter{
+		JSONFormatter: log.JSONFormatter{
+			TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+			FieldMap: log.FieldMap{
+				log.FieldKeyTime: "timestamp",
+				log.FieldKeyMsg:  "message",
+			},
+		},
+	}
+}
+
+func (f *jsonFormatter) Format(entry *log.Entry) ([]byte, error) {
+	// Some messages have trailing newlines for spacing in text output, which
+	// aren't meaningful in JSON
+	entry.Message = strings.TrimSpace(entry.Message)
+	return f.JSONFormatter.Format(entry)
+}
+
+// setLogFormat configures the log formatter based on the log-format option.
+func setLogFormat(cfg *mybase.Config) error {
+	format, err := cfg.GetEnum("log-format", "text", "json")
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		log.SetFormatter(newJSONFormatter())
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	entry := log.NewEntry(log.StandardLogger()).WithFields(log.Fields{
+		"host":         "db1.example.com:3306",
+		"schema":       "product",
+		"object":       "table `posts`",
+		"statement_id": 2,
+	})
+	entry.Time = time.Date(2020, 3, 1, 12, 30, 0, 0, time.UTC)
+	entry.Level = log.ErrorLevel
+	entry.Message = "Error running DDL on db1.example.com:3306 product: Error 1062\n"
+
+	b, err := newJSONFormatter().Format(entry)
+	if err != nil {
+		t.Fatalf("Unexpected error from Format: %s", err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatalf("Unable to decode formatted entry: %s\n%s", err, b)
+	}
+	expected := map[string]interface{}{
+		"level":        "error",
+		"timestamp":    "2020-03-01T12:30:00.000Z",
+		"message":      "Error running DDL on db1.example.com:3306 product: Error 1062",
+		"host":         "db1.example.com:3306",
+		"schema":       "product",
+		"object":       "table `posts`",
+		"statement_id": float64(2),
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("Expected %s to be %v, instead found %v", k, v, record[k])
+		}
+	}
+}
+
+func TestSetLogFormat(t *testing.T) {
+	defer log.SetFormatter(log.StandardLogger().Formatter)
+
+	cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema push --log-format=xml")
+	if err := setLogFormat(cfg); err == nil {
+		t.Error("Expected error from invalid log-format, but err was nil")
+	}
+	cfg = mybase.ParseFakeCLI(t, CommandSuite, "skeema push --log-format=json")
+	if err := setLogFormat(cfg); err != nil {
+		t.Fatalf("Unexpected error from setLogFormat: %s", err)
+	}
+	if _, ok := log.StandardLogger().Formatter.(*jsonFormatter); !ok {
+		t.Errorf("Expected formatter to be *jsonFormatter, instead found %T", log.StandardLogger().Formatter)
+	}
+}
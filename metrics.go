@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/skeema/skeema/util"
+)
+
+// startMetricsServer exposes util.Metrics in Prometheus text format at path
+// /metrics on addr, for long-running commands. The server runs in a background
+// goroutine; the caller should close it when done. Unlike the API of `skeema
+// serve`, no authorization is required, since metrics contain no schema
+// details.
+func startMetricsServer(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	util.Metrics.WritePrometheus(w, "skeema")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/util"
+)
+
+// multiEnvironmentHandler wraps handler so that the command's environment arg
+// may be a comma-separated list of environment names. If multiple environments
+// are supplied, handler is called once per environment, in order, with a
+// config selecting that environment. Each environment's output is preceded by
+// a log line identifying the environment, and any error message is logged
+// once that environment finishes. The combined exit code is the highest exit
+// code returned for any environment. The options named in singleEnvOptions,
+// such as ones writing a single document to a file or STDOUT, must be left at
+// their default values when multiple environments are supplied.
+func multiEnvironmentHandler(handler mybase.CommandHandler, singleEnvOptions ...string) mybase.CommandHandler {
+	return func(cfg *mybase.Config) error {
+		environments := cfg.GetSlice("environment", ',', true)
+		if len(environments) < 2 {
+			return handler(cfg)
+		}
+		seen := make(map[string]bool, len(environments))
+		for _, env := range environments {
+			if seen[env] {
+				return NewExitValue(CodeBadUsage, "Environment %q supplied more than once", env)
+			}
+			seen[env] = true
+		}
+		for _, name := range singleEnvOptions {
+			if cfg.Changed(name) && !strings.EqualFold(cfg.Get(name), cfg.FindOption(name).Default) {
+				return NewExitValue(CodeBadConfig, "Option %s cannot be used with multiple environments", name)
+			}
+		}
+
+		var maxCode int
+		codes := make([]string, len(environments))
+		for n, env := range environments {
+			log.Infof("==== Environment %s (%d of %d) ====", env, n+1, len(environments))
+			err := handler(configWithArgs(cfg, env))
+			code := ExitCode(err)
+			if err != nil && err.Error() != "" {
+				if code >= CodeFatalError {
+					log.Errorf("Environment %s: %s", env, err)
+				} else {
+					log.Warnf("Environment %s: %s", env, err)
+				}
+			}
+			if code > maxCode {
+				maxCode = code
+			}
+			codes[n] = fmt.Sprintf("%s=%d", env, code)
+		}
+		if maxCode == CodeSuccess {
+			return nil
+		}
+		return NewExitValue(maxCode, "Exit codes by environment: %s", strings.Join(codes, ", "))
+	}
+}
+
+// configWithArgs returns a config equivalent to cfg, but with its positional
+// args replaced by argValues. Global option files are re-read, so that their
+// sections are selected according to the new environment arg.
+func configWithArgs(cfg *mybase.Config, argValues ...string) *mybase.Config {
+	cli := *cfg.CLI
+	cli.OptionValues = make(map[string]string, len(cfg.CLI.OptionValues))
+	for name, value := range cfg.CLI.OptionValues {
+		cli.OptionValues[name] = value
+	}
+	cli.ArgValues = argValues
+	newConfig := mybase.NewConfig(&cli)
+	newConfig.IsTest = cfg.IsTest
+	util.AddGlobalConfigFiles(newConfig)
+	return newConfig
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skeema/mybase"
+)
+
+func TestMultiEnvironmentHandler(t *testing.T) {
+	var seen []string
+	codes := map[string]int{"staging": CodeDifferencesFound, "production": CodeSuccess, "dev": CodeFatalError}
+	handler := multiEnvironmentHandler(func(cfg *mybase.Config) error {
+		env := cfg.Get("environment")
+		seen = append(seen, env)
+		if codes[env] == CodeSuccess {
+			return nil
+		}
+		return NewExitValue(codes[env], "")
+	}, "format")
+
+	cases := []struct {
+		args         string
+		expectedEnvs []string
+		expectedCode int
+	}{
+		{"skeema lint", []string{"production"}, CodeSuccess},
+		{"skeema lint staging", []string{"staging"}, CodeDifferencesFound},
+		{"skeema lint staging,production", []string{"staging", "production"}, CodeDifferencesFound},
+		{"skeema lint 'production, staging, dev'", []string{"production", "staging", "dev"}, CodeFatalError},
+		{"skeema lint production,production", nil, CodeBadUsage},
+		{"skeema lint --format=json staging,production", nil, CodeBadConfig},
+		{"skeema lint --format=text staging,production", []string{"staging", "production"}, CodeDifferencesFound},
+		{"skeema lint --format=json staging", []string{"staging"}, CodeDifferencesFound},
+	}
+	for _, c := range cases {
+		seen = nil
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, c.args)
+		if actual := ExitCode(handler(cfg)); actual != c.expectedCode {
+			t.Errorf("Expected exit code %d from `%s`, instead found %d", c.expectedCode, c.args, actual)
+		}
+		if len(seen) != len(c.expectedEnvs) {
+			t.Errorf("Expected `%s` to process environments %v, instead found %v", c.args, c.expectedEnvs, seen)
+			continue
+		}
+		for n := range seen {
+			if seen[n] != c.expectedEnvs[n] {
+				t.Errorf("Expected `%s` to process environments %v, instead found %v", c.args, c.expectedEnvs, seen)
+				break
+			}
+		}
+	}
+}
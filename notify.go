@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+)
+
+// Events which may be sent to the notify-webhook URL
+const (
+	notifyEventStart   = "start"
+	notifyEventSuccess = "success"
+	notifyEventFailure = "failure"
+	notifyEventUnsafe  = "unsafe"
+)
+
+// pushNotification is the body of a request sent to the notify-webhook URL
+// with notify-format=json.
+type pushNotification struct {
+	Event       string       `json:"event"`
+	Command     string       `json:"command"`
+	Environment string       `json:"environment"`
+	GitSHA      string       `json:"git_sha,omitempty"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Error       string       `json:"error,omitempty"`
+	Diff        *diffSummary `json:"diff,omitempty"` // omitted for start events
+}
+
+// pushNotifier sends notifications about the progress of a push to the URL in
+// the notify-webhook option. All methods of pushNotifier may safely be called
+// on a nil receiver, which is used when notifications are disabled.
+type pushNotifier struct {
+	url    string
+	slack  bool
+	events map[string]bool
+	base   pushNotification
+}
+
+// newPushNotifier returns a pushNotifier configured by cfg, or nil if the
+// notify-webhook option is not set or dry-run is enabled. dirPath is used for
+// determining the current git commit, if any.
+func newPushNotifier(cfg *mybase.Config, dirPath string) (*pushNotifier, error) {
+	url := cfg.Get("notify-webhook")
+	if url == "" || cfg.GetBool("dry-run") {
+		return nil, nil
+	}
+	format, err := cfg.GetEnum("notify-format", "json", "slack")
+	if err != nil {
+		return nil, err
+	}
+	pn := &pushNotifier{
+		url:    url,
+		slack:  (format == "slack"),
+		events: make(map[string]bool),
+		base: pushNotification{
+			Command:     cfg.CLI.Command.Name,
+			Environment: cfg.Get("environment"),
+		},
+	}
+	for _, event := range cfg.GetSlice("notify-events", ',', true) {
+		event = strings.ToLower(event)
+		switch event {
+		case notifyEventStart, notifyEventSuccess, notifyEventFailure, notifyEventUnsafe:
+			pn.events[event] = true
+		default:
+			return nil, fmt.Errorf("Option notify-events contains invalid event %q; valid values are %q, %q, %q, %q", event, notifyEventStart, notifyEventSuccess, notifyEventFailure, notifyEventUnsafe)
+		}
+	}
+	if sha, err := gitCapture(dirPath, "git rev-parse HEAD 2>/dev/null"); err == nil {
+		pn.base.GitSHA = strings.TrimSpace(sha)
+	}
+	return pn, nil
+}
+
+// start sends the start event.
+func (pn *pushNotifier) start() {
+	if pn == nil {
+		return
+	}
+	pn.send(pn.base, notifyEventStart)
+}
+
+// setApplierResult records the combined result of the push, for use in the
+// final notification. The unsafe event is sent if any destructive changes were
+// not permitted by the configuration.
+func (pn *pushNotifier) setApplierResult(sum applier.Result) {
+	if pn == nil {
+		return
+	}
+	pn.base.Diff = newDiffSummary(sum)
+	if sum.UnsafeCount > 0 {
+		pn.send(pn.base, notifyEventUnsafe)
+	}
+}
+
+// finish sends the success or failure event, depending on err.
+func (pn *pushNotifier) finish(err error) {
+	if pn == nil {
+		return
+	}
+	n := pn.base
+	if ExitCode(err) == CodeSuccess {
+		pn.send(n, notifyEventSuccess)
+	} else {
+		n.Error = err.Error()
+		pn.send(n, notifyEventFailure)
+	}
+}
+
+// send POSTs n to the webhook URL, if event is enabled by the notify-events
+// option. Failures are logged, but otherwise do not affect the push.
+func (pn *pushNotifier) send(n pushNotification, event string) {
+	if !pn.events[event] {
+		return
+	}
+	n.Event = event
+	n.Timestamp = time.Now().UTC()
+	var body interface{} = n
+	if pn.slack {
+		body = map[string]string{"text": n.slackText()}
+	}
+	encoded, err := json.Marshal(body)
+	if err == nil {
+		err = postNotification(pn.url, encoded)
+	}
+	if err != nil {
+		log.Warnf("Unable to send %s notification to notify-webhook: %s", event, err)
+	}
+}
+
+func postNotification(url string, body []byte) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// slackText returns a human-readable description of n, using Slack's mrkdwn
+// syntax.
+func (n pushNotification) slackText() string {
+	subject := fmt.Sprintf("`skeema %s` to *%s*", n.Command, n.Environment)
+	if n.GitSHA != "" {
+		sha := n.GitSHA
+		if len(sha) > 12 {
+			sha = sha[:12]
+		}
+		subject += fmt.Sprintf(" (commit `%s`)", sha)
+	}
+	var stats string
+	if n.Diff != nil {
+		stats = fmt.Sprintf("%d of %d statement%s executed across %d target%s",
+			n.Diff.Statements.Executed, n.Diff.Statements.Generated, plural(n.Diff.Statements.Generated),
+			n.Diff.Targets, plural(n.Diff.Targets))
+		if n.Diff.Statements.Failed > 0 {
+			stats += fmt.Sprintf(", %d failed", n.Diff.Statements.Failed)
+		}
+	}
+	switch n.Event {
+	case notifyEventStart:
+		return fmt.Sprintf(":arrow_forward: %s started", subject)
+	case notifyEventSuccess:
+		return fmt.Sprintf(":white_check_mark: %s succeeded: %s", subject, stats)
+	case notifyEventUnsafe:
+		return fmt.Sprintf(":warning: %s encountered %d unsafe change%s, which were not run. Use allow-unsafe or safe-below-size to permit them.", subject, n.Diff.Statements.Unsafe, plural(n.Diff.Statements.Unsafe))
+	default:
+		text := fmt.Sprintf(":x: %s failed: %s", subject, n.Error)
+		if stats != "" {
+			text += "\n" + stats
+		}
+		return text
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+)
+
+func TestPushNotifier(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("Unable to decode notification: %s\n%s", err, data)
+		}
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	// Notifications are disabled without notify-webhook, or with dry-run
+	for _, commandLine := range []string{"skeema push", "skeema push --dry-run --notify-webhook=" + server.URL} {
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, commandLine)
+		if notifier, err := newPushNotifier(cfg, "."); notifier != nil || err != nil {
+			t.Errorf("Expected nil notifier and nil error for %q, instead found %+v, %v", commandLine, notifier, err)
+		}
+	}
+
+	// Invalid format or event
+	for _, commandLine := range []string{"skeema push --notify-format=xml", "skeema push --notify-events=start,finish"} {
+		cfg := mybase.ParseFakeCLI(t, CommandSuite, commandLine+" --notify-webhook="+server.URL)
+		if _, err := newPushNotifier(cfg, "."); err == nil {
+			t.Errorf("Expected error for %q, but err was nil", commandLine)
+		}
+	}
+
+	cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema push staging --notify-webhook="+server.URL)
+	notifier, err := newPushNotifier(cfg, ".")
+	if err != nil {
+		t.Fatalf("Unexpected error from newPushNotifier: %s", err)
+	}
+	notifier.start()
+	notifier.setApplierResult(applier.Result{TargetCount: 2, StatementCount: 3, ExecutedCount: 1, SkipCount: 2, UnsafeCount: 2})
+	notifier.finish(NewExitValue(CodeFatalError, "Skipped 2 operations due to error"))
+	if len(bodies) != 3 {
+		t.Fatalf("Expected 3 notifications, instead found %d", len(bodies))
+	}
+	for n, event := range []string{"start", "unsafe", "failure"} {
+		if bodies[n]["event"] != event || bodies[n]["command"] != "push" || bodies[n]["environment"] != "staging" {
+			t.Errorf("Unexpected notification %d: %v", n, bodies[n])
+		}
+	}
+	if _, ok := bodies[0]["diff"]; ok {
+		t.Errorf("Expected start notification to omit diff, but it was present: %v", bodies[0])
+	}
+	if bodies[2]["error"] != "Skipped 2 operations due to error" {
+		t.Errorf("Unexpected error in failure notification: %v", bodies[2]["error"])
+	}
+	diff, _ := bodies[2]["diff"].(map[string]interface{})
+	statements, _ := diff["statements"].(map[string]interface{})
+	if diff["targets"] != float64(2) || statements["executed"] != float64(1) || statements["unsafe"] != float64(2) {
+		t.Errorf("Unexpected diff in failure notification: %v", diff)
+	}
+
+	// Slack format, limited to success and failure events
+	bodies = nil
+	cfg = mybase.ParseFakeCLI(t, CommandSuite, "skeema push --notify-format=slack --notify-events=success,failure --notify-webhook="+server.URL)
+	notifier, err = newPushNotifier(cfg, ".")
+	if err != nil {
+		t.Fatalf("Unexpected error from newPushNotifier: %s", err)
+	}
+	notifier.start()
+	notifier.setApplierResult(applier.Result{TargetCount: 1, StatementCount: 2, ExecutedCount: 2})
+	notifier.finish(nil)
+	if len(bodies) != 1 {
+		t.Fatalf("Expected 1 notification, instead found %d", len(bodies))
+	}
+	text, _ := bodies[0]["text"].(string)
+	if !strings.HasPrefix(text, ":white_check_mark: `skeema push` to *production*") || !strings.HasSuffix(text, "succeeded: 2 of 2 statements executed across 1 target") {
+		t.Errorf("Unexpected Slack text: %q", text)
+	}
+
+	// Server errors are logged but otherwise ignored
+	cfg = mybase.ParseFakeCLI(t, CommandSuite, "skeema push --notify-webhook="+server.URL+"/missing")
+	server.Config.Handler = http.NotFoundHandler()
+	notifier, _ = newPushNotifier(cfg, ".")
+	notifier.finish(nil)
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skeema/skeema/util"
+)
+
+// serveCommands lists the commands which may be run via the API of
+// `skeema serve`.
+var serveCommands = map[string]bool{
+	"lint": true,
+	"diff": true,
+	"push": true,
+}
+
+// serveJobOptions lists the options which may be supplied in the request body
+// of a job. Options which run external commands, load plugins, write files, or
+// change which servers or credentials are used may only be set in option
+// files, since otherwise any API client could use them to run arbitrary code
+// on the server host or send credentials elsewhere. Options beginning with
+// "lint-" are also permitted, other than lint-plugins.
+var serveJobOptions = map[string]bool{
+	"allow-auto-inc":           true,
+	"allow-charset":            true,
+	"allow-collation":          true,
+	"allow-engine":             true,
+	"allow-unsafe":             true,
+	"alter-algorithm":          true,
+	"alter-lock":               true,
+	"brief":                    true,
+	"check-target-state":       true,
+	"comment-pattern":          true,
+	"comment-scope":            true,
+	"compare-metadata":         true,
+	"concurrent-instances":     true,
+	"debug":                    true,
+	"dry-run":                  true,
+	"errors":                   true,
+	"exact-match":              true,
+	"first-only":               true,
+	"foreign-key-checks":       true,
+	"ignore-func":              true,
+	"ignore-object-type":       true,
+	"ignore-proc":              true,
+	"ignore-schema":            true,
+	"ignore-table":             true,
+	"max-columns":              true,
+	"max-index-bytes":          true,
+	"max-index-columns":        true,
+	"max-indexed-string-bytes": true,
+	"max-indexes":              true,
+	"max-row-bytes":            true,
+	"naming-column":            true,
+	"naming-foreign-key":       true,
+	"naming-index":             true,
+	"naming-table":             true,
+	"nullable-exempt-types":    true,
+	"reserved-word-flavors":    true,
+	"safe-below-size":          true,
+	"strict-flavor":            true,
+	"temporal-precision":       true,
+	"temporal-type":            true,
+	"verify":                   true,
+	"warnings":                 true,
+}
+
+// serveMaxJobs is the number of jobs retained in memory by `skeema serve`.
+// Once exceeded, the oldest finished jobs are discarded.
+const serveMaxJobs = 100
+
+// serveJobRequest is the JSON body of a request to start a job.
+type serveJobRequest struct {
+	Command     string            `json:"command"`
+	Environment string            `json:"environment"`
+	Options     map[string]string `json:"options"`
+}
+
+// serveJobStatus is the JSON representation of a job's state.
+type serveJobStatus struct {
+	ID          string          `json:"id"`
+	Command     string          `json:"command"`
+	Environment string          `json:"environment"`
+	Status      string          `json:"status"` // "running" or "finished"
+	ExitCode    *int            `json:"exit_code,omitempty"`
+	Started     time.Time       `json:"started"`
+	Finished    *time.Time      `json:"finished,omitempty"`
+	Output      string          `json:"output,omitempty"` // STDOUT of the command, such as DDL from diff
+	Result      json.RawMessage `json:"result,omitempty"` // JSON report from lint
+}
+
+// serveJob tracks one invocation of a command requested via the API. Log
+// output of the command is buffered, so that it can be streamed to clients.
+type serveJob struct {
+	ID          string
+	Command     string
+	Environment string
+	Args        []string
+
+	mu       sync.Mutex
+	log      bytes.Buffer
+	stdout   bytes.Buffer
+	updated  chan struct{} // closed and replaced whenever log is written to or the job finishes
+	started  time.Time
+	finished time.Time
+	done     bool
+	exitCode int
+}
+
+func newServeJob(id string, req serveJobRequest) *serveJob {
+	job := &serveJob{
+		ID:          id,
+		Command:     req.Command,
+		Environment: req.Environment,
+		Args:        []string{req.Command},
+		updated:     make(chan struct{}),
+		started:     time.Now(),
+	}
+	names := make([]string, 0, len(req.Options))
+	for name := range req.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		job.Args = append(job.Args, fmt.Sprintf("--%s=%s", name, req.Options[name]))
+	}
+	if req.Command == "lint" {
+		job.Args = append(job.Args, "--format=json")
+	}
+	job.Args = append(job.Args, req.Environment)
+	return job
+}
+
+// Write appends p to the job's log output, satisfying io.Writer.
+func (job *serveJob) Write(p []byte) (int, error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	n, err := job.log.Write(p)
+	close(job.updated)
+	job.updated = make(chan struct{})
+	return n, err
+}
+
+// stdoutWriter returns an io.Writer which captures the job's STDOUT.
+func (job *serveJob) stdoutWriter() io.Writer {
+	return serveJobStdout{job}
+}
+
+type serveJobStdout struct {
+	job *serveJob
+}
+
+func (w serveJobStdout) Write(p []byte) (int, error) {
+	w.job.mu.Lock()
+	defer w.job.mu.Unlock()
+	return w.job.stdout.Write(p)
+}
+
+// finish marks the job as complete, with the supplied exit code.
+func (job *serveJob) finish(exitCode int) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	job.done = true
+	job.exitCode = exitCode
+	job.finished = time.Now()
+	close(job.updated)
+	job.updated = make(chan struct{})
+}
+
+// Done returns true if the job has finished.
+func (job *serveJob) Done() bool {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return job.done
+}
+
+// Status returns the JSON representation of the job. Output and results are
+// only included if withOutput is true.
+func (job *serveJob) Status(withOutput bool) serveJobStatus {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	status := serveJobStatus{
+		ID:          job.ID,
+		Command:     job.Command,
+		Environment: job.Environment,
+		Status:      "running",
+		Started:     job.started,
+	}
+	if job.done {
+		exitCode, finished := job.exitCode, job.finished
+		status.Status = "finished"
+		status.ExitCode = &exitCode
+		status.Finished = &finished
+		if withOutput {
+			if job.Command == "lint" && json.Valid(job.stdout.Bytes()) {
+				status.Result = json.RawMessage(job.stdout.String())
+			} else {
+				status.Output = job.stdout.String()
+			}
+		}
+	}
+	return status
+}
+
+// logSince returns any log output beyond the supplied offset, along with a
+// channel which will be closed upon the next update, and whether the job has
+// finished.
+func (job *serveJob) logSince(offset int) ([]byte, <-chan struct{}, bool) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	var b []byte
+	if offset < job.log.Len() {
+		b = append(b, job.log.Bytes()[offset:]...)
+	}
+	return b, job.updated, job.done
+}
+
+// jobRunner executes a job's command, writing its output to the job, and
+// returns the exit code.
+type jobRunner func(job *serveJob) int
+
+// execJobRunner returns a jobRunner which runs jobs by executing the skeema
+// binary in dirPath, with the supplied additional environment variables.
+func execJobRunner(dirPath string, env []string) jobRunner {
+	return func(job *serveJob) int {
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(job, "Unable to determine path of executable: %s\n", err)
+			return CodeFatalError
+		}
+		cmd := exec.Command(exe, job.Args...)
+		cmd.Dir = dirPath
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = job.stdoutWriter()
+		cmd.Stderr = job
+		err = cmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		} else if err != nil {
+			fmt.Fprintf(job, "Unable to run command: %s\n", err)
+			return CodeFatalError
+		}
+		return CodeSuccess
+	}
+}
+
+// jobServer implements the HTTP API of `skeema serve`. Only one job may run at
+// a time, since jobs may modify the same files or database instances.
+type jobServer struct {
+	token   string
+	run     jobRunner
+	mu      sync.Mutex
+	jobs    map[string]*serveJob
+	order   []string // job IDs from oldest to newest
+	nextID  int
+	running *serveJob
+}
+
+func newJobServer(token string, run jobRunner) *jobServer {
+	return &jobServer{
+		token: token,
+		run:   run,
+		jobs:  make(map[string]*serveJob),
+	}
+}
+
+// ServeHTTP satisfies http.Handler, routing requests to the appropriate
+// method after checking authorization.
+func (srv *jobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(auth[7:]), []byte(srv.token)) != 1 {
+		writeServeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "v1" || parts[1] != "jobs" || len(parts) > 4 || (len(parts) == 4 && parts[3] != "log") {
+		writeServeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		srv.createJob(w, r)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		srv.listJobs(w)
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		if job := srv.job(parts[2]); job == nil {
+			writeServeError(w, http.StatusNotFound, "job not found")
+		} else {
+			writeServeJSON(w, http.StatusOK, job.Status(true))
+		}
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		if job := srv.job(parts[2]); job == nil {
+			writeServeError(w, http.StatusNotFound, "job not found")
+		} else {
+			streamJobLog(r.Context(), w, job)
+		}
+	default:
+		writeServeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (srv *jobServer) job(id string) *serveJob {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.jobs[id]
+}
+
+func (srv *jobServer) createJob(w http.ResponseWriter, r *http.Request) {
+	var req serveJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeServeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := validateServeJobRequest(&req); err != nil {
+		writeServeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	srv.mu.Lock()
+	if srv.running != nil && !srv.running.Done() {
+		srv.mu.Unlock()
+		writeServeError(w, http.StatusConflict, "job "+srv.running.ID+" is still running")
+		return
+	}
+	srv.nextID++
+	job := newServeJob(strconv.Itoa(srv.nextID), req)
+	srv.jobs[job.ID] = job
+	srv.order = append(srv.order, job.ID)
+	for len(srv.order) > serveMaxJobs {
+		delete(srv.jobs, srv.order[0])
+		srv.order = srv.order[1:]
+	}
+	srv.running = job
+	srv.mu.Unlock()
+
+	util.Metrics.Count("serve_jobs_started", 1)
+	go func() {
+		exitCode := srv.run(job)
+		util.Metrics.TimeSince("serve_job_duration", job.started)
+		if exitCode >= CodeFatalError {
+			util.Metrics.Count("serve_jobs_failed", 1)
+		}
+		job.finish(exitCode)
+	}()
+	writeServeJSON(w, http.StatusAccepted, job.Status(false))
+}
+
+func (srv *jobServer) listJobs(w http.ResponseWriter) {
+	srv.mu.Lock()
+	statuses := make([]serveJobStatus, 0, len(srv.order))
+	for n := len(srv.order) - 1; n >= 0; n-- { // newest first
+		statuses = append(statuses, srv.jobs[srv.order[n]].Status(false))
+	}
+	srv.mu.Unlock()
+	writeServeJSON(w, http.StatusOK, statuses)
+}
+
+// validateServeJobRequest confirms that req refers to a permitted command and
+// only uses options that the command supports and that are permitted in
+// serveJobOptions. A blank environment is replaced with the default of
+// "production".
+func validateServeJobRequest(req *serveJobRequest) error {
+	if !serveCommands[req.Command] {
+		return fmt.Errorf("command %q is not supported; must be one of lint, diff, push", req.Command)
+	}
+	if req.Environment == "" {
+		req.Environment = "production"
+	} else if strings.HasPrefix(req.Environment, "-") || strings.ContainsAny(req.Environment, "[]\n\r") {
+		return fmt.Errorf("environment name %q is invalid", req.Environment)
+	}
+	cmdOptions := CommandSuite.SubCommands[req.Command].Options()
+	for name := range req.Options {
+		if _, ok := cmdOptions[name]; !ok {
+			return fmt.Errorf("option %q is not supported by command %s", name, req.Command)
+		}
+		if !serveJobOptions[name] && (!strings.HasPrefix(name, "lint-") || name == "lint-plugins") {
+			return fmt.Errorf("option %q may not be set via the API; configure it in an option file instead", name)
+		}
+	}
+	return nil
+}
+
+// streamJobLog writes the job's log output to w as it is generated, until the
+// job finishes or the client disconnects.
+func streamJobLog(ctx context.Context, w http.ResponseWriter, job *serveJob) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	var offset int
+	for {
+		b, updated, done := job.logSince(offset)
+		if len(b) > 0 {
+			if _, err := w.Write(b); err != nil {
+				return
+			}
+			offset += len(b)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if done {
+			return
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeServeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeServeError(w http.ResponseWriter, code int, message string) {
+	writeServeJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJobServer(t *testing.T) {
+	release := make(chan struct{})
+	var lastArgs []string
+	run := func(job *serveJob) int {
+		lastArgs = job.Args
+		fmt.Fprintf(job, "Starting %s\n", job.Command)
+		<-release
+		if job.Command == "lint" {
+			fmt.Fprint(job.stdoutWriter(), `{"findings": []}`)
+			return CodeSuccess
+		}
+		fmt.Fprint(job.stdoutWriter(), "ALTER TABLE foo ADD COLUMN bar int;\n")
+		fmt.Fprintf(job, "Finished %s\n", job.Command)
+		return CodeDifferencesFound
+	}
+	ts := httptest.NewServer(newJobServer("s3cret", run))
+	defer ts.Close()
+
+	request := func(method, path, token, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Unexpected error creating request: %s", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error from %s %s: %s", method, path, err)
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error reading response: %s", err)
+		}
+		return resp.StatusCode, string(b)
+	}
+
+	// Requests without the correct token should be rejected
+	if code, _ := request("GET", "/v1/jobs", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without token, instead found %d", http.StatusUnauthorized, code)
+	}
+	if code, _ := request("GET", "/v1/jobs", "wrong", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d with wrong token, instead found %d", http.StatusUnauthorized, code)
+	}
+
+	// Invalid job requests
+	for _, body := range []string{
+		`not json`,
+		`{"command": "init"}`,
+		`{"command": "diff", "environment": "--help"}`,
+		`{"command": "diff", "options": {"not-an-option": "1"}}`,
+		`{"command": "lint", "options": {"allow-unsafe": "1"}}`,
+		`{"command": "push", "options": {"alter-wrapper": "touch /tmp/pwned"}}`,
+		`{"command": "diff", "options": {"host-wrapper": "echo"}}`,
+		`{"command": "push", "options": {"password-command": "id"}}`,
+		`{"command": "lint", "options": {"lint-plugins": "/tmp/evil.so"}}`,
+		`{"command": "push", "options": {"ddl-export-dir": "/etc"}}`,
+		`{"command": "diff", "options": {"host": "attacker.example.com"}}`,
+	} {
+		if code, resp := request("POST", "/v1/jobs", "s3cret", body); code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for body %s, instead found %d: %s", http.StatusBadRequest, body, code, resp)
+		}
+	}
+
+	// Start a job, and confirm a second one cannot start while it is running
+	code, resp := request("POST", "/v1/jobs", "s3cret", `{"command": "diff", "environment": "staging", "options": {"allow-unsafe": "1", "alter-algorithm": "inplace"}}`)
+	if code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, instead found %d: %s", http.StatusAccepted, code, resp)
+	}
+	var status serveJobStatus
+	if err := json.Unmarshal([]byte(resp), &status); err != nil {
+		t.Fatalf("Unable to decode response: %s", err)
+	}
+	if status.ID != "1" || status.Status != "running" || status.ExitCode != nil {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if code, resp := request("POST", "/v1/jobs", "s3cret", `{"command": "lint"}`); code != http.StatusConflict {
+		t.Errorf("Expected status %d, instead found %d: %s", http.StatusConflict, code, resp)
+	}
+
+	// Streaming the log should block until the job finishes
+	close(release)
+	if code, resp := request("GET", "/v1/jobs/1/log", "s3cret", ""); code != http.StatusOK || resp != "Starting diff\nFinished diff\n" {
+		t.Errorf("Unexpected log response %d: %q", code, resp)
+	}
+	expectedArgs := []string{"diff", "--allow-unsafe=1", "--alter-algorithm=inplace", "staging"}
+	if !reflect.DeepEqual(lastArgs, expectedArgs) {
+		t.Errorf("Expected args %v, instead found %v", expectedArgs, lastArgs)
+	}
+	code, resp = request("GET", "/v1/jobs/1", "s3cret", "")
+	status = serveJobStatus{}
+	if err := json.Unmarshal([]byte(resp), &status); err != nil || code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", code, resp)
+	}
+	if status.Status != "finished" || status.ExitCode == nil || *status.ExitCode != CodeDifferencesFound || status.Output != "ALTER TABLE foo ADD COLUMN bar int;\n" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	// Once finished, another job may start. Lint jobs report JSON results.
+	if code, resp := request("POST", "/v1/jobs", "s3cret", `{"command": "lint"}`); code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, instead found %d: %s", http.StatusAccepted, code, resp)
+	}
+	request("GET", "/v1/jobs/2/log", "s3cret", "")
+	if expectedArgs = []string{"lint", "--format=json", "production"}; !reflect.DeepEqual(lastArgs, expectedArgs) {
+		t.Errorf("Expected args %v, instead found %v", expectedArgs, lastArgs)
+	}
+	if _, resp := request("GET", "/v1/jobs/2", "s3cret", ""); !strings.Contains(resp, `"result": {`) || strings.Contains(resp, `"output"`) {
+		t.Errorf("Expected lint job to include JSON result, instead found %s", resp)
+	}
+
+	var statuses []serveJobStatus
+	if _, resp := request("GET", "/v1/jobs", "s3cret", ""); json.Unmarshal([]byte(resp), &statuses) != nil || len(statuses) != 2 || statuses[0].ID != "2" {
+		t.Errorf("Unexpected job list: %s", resp)
+	}
+	if code, _ := request("GET", "/v1/jobs/3", "s3cret", ""); code != http.StatusNotFound {
+		t.Errorf("Expected status %d for nonexistent job, instead found %d", http.StatusNotFound, code)
+	}
+	if code, _ := request("DELETE", "/v1/jobs/1", "s3cret", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for DELETE, instead found %d", http.StatusMethodNotAllowed, code)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	cases := map[string]bool{
+		"localhost:8080": true,
+		"127.0.0.1:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.1.2.3:8080":  false,
+		"example.com:80": false,
+		"localhost":      false,
+	}
+	for addr, expected := range cases {
+		if actual := isLoopbackAddr(addr); actual != expected {
+			t.Errorf("Expected isLoopbackAddr(%q) to return %t, instead found %t", addr, expected, actual)
+		}
+	}
+}
@@ -46,12 +46,24 @@ func main() {
 	}
 
 	util.AddGlobalConfigFiles(cfg)
+	if err := setLogFormat(cfg); err != nil {
+		Exit(NewExitValue(CodeBadConfig, err.Error()))
+	}
 	if err := util.ProcessSpecialGlobalOptions(cfg); err != nil {
 		Exit(NewExitValue(CodeBadConfig, err.Error()))
 	}
 
+	root := util.Tracing.StartRoot("skeema " + cfg.CLI.Command.Name)
 	err = cfg.HandleCommand()
 	workspace.Shutdown()
+	if ExitCode(err) >= CodeFatalError {
+		root.End(err)
+	} else {
+		root.End(nil)
+	}
+	if err := util.Tracing.Flush(); err != nil {
+		log.Warnf("Unable to export trace spans to otlp-endpoint: %s", err)
+	}
 	Exit(err)
 }
 
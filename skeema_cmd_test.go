@@ -257,6 +257,103 @@ func (s SkeemaIntegrationSuite) TestPullHandler(t *testing.T) {
 	}
 }
 
+func (s SkeemaIntegrationSuite) TestPullFilters(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.sourceSQL(t, "pull1.sql")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema pull --only-new --only-changed")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema pull --tables='[oops'")
+
+	// With --tables, only matching tables are updated. Dropped tables matching
+	// the pattern are left in place, and other changes are not made.
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull --tables=post*,widget_counts")
+	if contents := fs.ReadTestFile(t, "mydb/product/posts.sql"); !strings.Contains(contents, "status") {
+		t.Error("Expected mydb/product/posts.sql to be updated, but it was not")
+	}
+	if _, err := os.Stat("mydb/product/comments.sql"); err != nil {
+		t.Errorf("Expected mydb/product/comments.sql to remain in place; instead err=%v", err)
+	}
+	if _, err := os.Stat("mydb/analytics/widget_counts.sql"); err != nil {
+		t.Errorf("Expected mydb/analytics/widget_counts.sql to be written; instead err=%v", err)
+	}
+	if contents := fs.ReadTestFile(t, "mydb/analytics/.skeema"); strings.Contains(contents, "utf8_swedish_ci") {
+		t.Error("Expected mydb/analytics/.skeema to remain unchanged, but collation was updated")
+	}
+	if _, err := os.Stat("mydb/archives"); !os.IsNotExist(err) {
+		t.Errorf("Expected os.Stat to return IsNotExist error for mydb/archives; instead err=%v", err)
+	}
+
+	// With --only-changed, new tables are not written; with --only-new, existing
+	// definitions are not updated
+	s.cleanData(t, "setup.sql")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	s.sourceSQL(t, "pull1.sql")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull --only-changed")
+	if contents := fs.ReadTestFile(t, "mydb/product/posts.sql"); !strings.Contains(contents, "status") {
+		t.Error("Expected mydb/product/posts.sql to be updated, but it was not")
+	}
+	if _, err := os.Stat("mydb/analytics/widget_counts.sql"); !os.IsNotExist(err) {
+		t.Errorf("Expected os.Stat to return IsNotExist error for mydb/analytics/widget_counts.sql; instead err=%v", err)
+	}
+	s.dbExec(t, "product", "ALTER TABLE posts DROP COLUMN status")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull --only-new")
+	if contents := fs.ReadTestFile(t, "mydb/product/posts.sql"); !strings.Contains(contents, "status") {
+		t.Error("Expected mydb/product/posts.sql to remain unchanged, but it was updated")
+	}
+	if _, err := os.Stat("mydb/analytics/widget_counts.sql"); err != nil {
+		t.Errorf("Expected mydb/analytics/widget_counts.sql to be written; instead err=%v", err)
+	}
+	if _, err := os.Stat("mydb/product/comments.sql"); err != nil {
+		t.Errorf("Expected mydb/product/comments.sql to remain in place; instead err=%v", err)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestCompare(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir before -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir after -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema compare before after")
+
+	// Add a column, add a table, and remove a table on the "after" side
+	contents := fs.ReadTestFile(t, "after/product/posts.sql")
+	contents = strings.Replace(contents, "  PRIMARY KEY", "  `status` tinyint NOT NULL DEFAULT 0,\n  PRIMARY KEY", 1)
+	fs.WriteTestFile(t, "after/product/posts.sql", contents)
+	fs.WriteTestFile(t, "after/product/tags.sql", "CREATE TABLE tags (id int unsigned NOT NULL PRIMARY KEY);\n")
+	if err := os.Remove("after/product/comments.sql"); err != nil {
+		t.Fatalf("Unable to remove comments.sql: %s", err)
+	}
+	s.handleCommand(t, CodeFatalError, ".", "skeema compare before after")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema compare before after --allow-unsafe")
+
+	// Removing a column is only permitted with allow-unsafe
+	s.handleCommand(t, CodeFatalError, ".", "skeema compare after/product before/product")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema compare after/product before/product --allow-unsafe")
+
+	// Invalid SQL on either side is an error, as are nonexistent dirs
+	fs.WriteTestFile(t, "after/product/bad.sql", "CREATE TABLE bad (id int unsigned NOT NULL PRIMARY KEY, id int);\n")
+	s.handleCommand(t, CodeFatalError, ".", "skeema compare before after --allow-unsafe")
+	s.handleCommand(t, CodeNoInput, ".", "skeema compare before doesnt-exist")
+
+	// The live database is not affected by compare
+	s.handleCommand(t, CodeSuccess, "before", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestDiffServers(t *testing.T) {
+	spec := fmt.Sprintf("%s:%d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadUsage, ".", "skeema diff-servers %s/product %s", spec, spec)
+	s.handleCommand(t, CodeBadUsage, ".", "skeema diff-servers :3306 %s/product", spec)
+
+	// A nonexistent target schema should be created, along with its tables
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff-servers %s/product %s/product_copy", spec, spec)
+	s.dbExec(t, "", "CREATE DATABASE product_copy")
+	s.dbExec(t, "product_copy", "CREATE TABLE posts LIKE product.posts")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff-servers %s/product %s/product_copy", spec, spec)
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff-servers %s/product %s/product_copy --ignore-table='^(comments|subscriptions|users)$'", spec, spec)
+
+	// Dropping tables is only permitted with allow-unsafe
+	s.handleCommand(t, CodeFatalError, ".", "skeema diff-servers %s/product_copy %s/product", spec, spec)
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff-servers %s/product_copy %s/product --allow-unsafe", spec, spec)
+	s.handleCommand(t, CodeFatalError, ".", "skeema diff-servers %s/doesnt_exist %s/product", spec, spec)
+}
+
 func (s SkeemaIntegrationSuite) TestLintHandler(t *testing.T) {
 	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
 
@@ -324,6 +421,48 @@ func (s SkeemaIntegrationSuite) TestLintHandler(t *testing.T) {
 	s.handleCommand(t, CodeFatalError, ".", "skeema lint")
 }
 
+func (s SkeemaIntegrationSuite) TestLintFix(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+
+	// Add a table using a disallowed storage engine. Without --fix, this should
+	// be an error; with --fix, the file should be rewritten and subsequent lint
+	// should succeed.
+	contents := "CREATE TABLE widgets (\n  id int unsigned NOT NULL,\n  PRIMARY KEY (id)\n) ENGINE=MyISAM DEFAULT CHARSET=latin1;\n"
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", contents)
+	s.handleCommand(t, CodeFatalError, ".", "skeema lint --errors=bad-engine")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema lint --errors=bad-engine --fix")
+	if contents = fs.ReadTestFile(t, "mydb/product/widgets.sql"); !strings.Contains(contents, "ENGINE=InnoDB") {
+		t.Errorf("Expected --fix to rewrite storage engine, but file contents are:\n%s", contents)
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint --errors=bad-engine")
+}
+
+func (s SkeemaIntegrationSuite) TestValidateHandler(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	cfg := s.handleCommand(t, CodeSuccess, ".", "skeema validate")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema validate --workspace=doesnt-exist")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema validate --format=xml")
+
+	// Valid SQL that doesn't match the canonical format should not be rewritten
+	contents := fs.ReadTestFile(t, "mydb/product/users.sql")
+	fs.WriteTestFile(t, "mydb/product/users.sql", strings.ToLower(contents))
+	s.handleCommand(t, CodeSuccess, ".", "skeema validate")
+	if newContents := fs.ReadTestFile(t, "mydb/product/users.sql"); newContents != strings.ToLower(contents) {
+		t.Error("Expected validate to leave file contents as-is, but file was modified")
+	}
+	fs.WriteTestFile(t, "mydb/product/users.sql", contents)
+	s.verifyFiles(t, cfg, "../golden/init")
+
+	// Invalid SQL should be an error, unless the object is ignored
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "CREATE TABLE widgets (id int unsigned NOT NULL, PIRMARY KEY (id));\n")
+	s.handleCommand(t, CodeFatalError, ".", "skeema validate")
+	s.handleCommand(t, CodeSuccess, ".", "skeema validate --ignore-table=widgets")
+
+	// Unparseable statements should be a warning
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "INSERT INTO foo (col1, col2) VALUES (123, 456)")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema validate")
+}
+
 func (s SkeemaIntegrationSuite) TestDiffHandler(t *testing.T) {
 	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
 
@@ -448,6 +587,48 @@ func (s SkeemaIntegrationSuite) TestPushHandler(t *testing.T) {
 	s.assertTableExists(t, "bonus", "table2", "")
 }
 
+func (s SkeemaIntegrationSuite) TestCloneHandler(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema add-environment --dir mydb -h %s -P %d staging", s.d.Instance.Host, s.d.Instance.Port)
+	contents := fs.ReadTestFile(t, "mydb/product/.skeema")
+	fs.WriteTestFile(t, "mydb/product/.skeema", contents+"[staging]\nschema=product_copy\n")
+
+	s.handleCommand(t, CodeBadUsage, ".", "skeema clone production production")
+
+	// The source environment's config is built separately from the test's fake
+	// option source, so the password must be supplied on the command-line
+	password := s.d.Instance.Password
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema clone production staging --dry-run --password=%s", password)
+	s.assertTableMissing(t, "product_copy", "", "")
+	s.handleCommand(t, CodeSuccess, ".", "skeema clone production staging --password=%s", password)
+	s.assertTableExists(t, "product_copy", "users", "credits")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff staging")
+
+	// Changes to the source should be cloned, without affecting the *.sql files
+	s.dbExec(t, "product", "ALTER TABLE users ADD COLUMN nickname varchar(30)")
+	s.handleCommand(t, CodeSuccess, ".", "skeema clone production staging --password=%s", password)
+	s.assertTableExists(t, "product_copy", "users", "nickname")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff staging")
+
+	// Destructive changes require --allow-unsafe, same as push
+	s.dbExec(t, "product", "ALTER TABLE users DROP COLUMN nickname")
+	s.handleCommand(t, CodeFatalError, ".", "skeema clone production staging --password=%s", password)
+	s.assertTableExists(t, "product_copy", "users", "nickname")
+	s.handleCommand(t, CodeSuccess, ".", "skeema clone production staging --allow-unsafe --password=%s", password)
+	s.assertTableMissing(t, "product_copy", "users", "nickname")
+
+	// With --via-dir, the *.sql files are updated to match the source, and then
+	// pushed to the target
+	s.dbExec(t, "product", "ALTER TABLE users ADD COLUMN nickname varchar(30)")
+	s.handleCommand(t, CodeSuccess, ".", "skeema clone production staging --via-dir --skip-new-schemas --password=%s", password)
+	s.assertTableExists(t, "product_copy", "users", "nickname")
+	if contents := fs.ReadTestFile(t, "mydb/product/users.sql"); !strings.Contains(contents, "nickname") {
+		t.Errorf("Expected --via-dir to update users.sql, but it did not. Contents:\n%s", contents)
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff staging")
+}
+
 func (s SkeemaIntegrationSuite) TestHelpHandler(t *testing.T) {
 	// Simple tests just to confirm the commands don't error
 	fs.WriteTestFile(t, "fake-etc/skeema", "# hello world")
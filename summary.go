@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/linter"
+)
+
+// runSummary is a machine-readable overview of a single command invocation,
+// written to the path specified by the summary-json option. Its content does
+// not depend on logging verbosity.
+type runSummary struct {
+	Command     string       `json:"command"`
+	Environment string       `json:"environment"`
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  time.Time    `json:"finished_at"`
+	DurationMs  int64        `json:"duration_ms"`
+	ExitCode    int          `json:"exit_code"`
+	Error       string       `json:"error,omitempty"`
+	Diff        *diffSummary `json:"diff,omitempty"`
+	Lint        *lintSummary `json:"lint,omitempty"`
+
+	path string
+}
+
+// diffSummary describes the outcome of diff, push, or clone.
+type diffSummary struct {
+	Targets        int `json:"targets"`
+	SkippedTargets int `json:"skipped_targets"`
+	ObjectsChanged int `json:"objects_changed"`
+	Statements     struct {
+		Generated   int `json:"generated"`
+		Executed    int `json:"executed"`
+		Skipped     int `json:"skipped"`
+		Failed      int `json:"failed"`
+		Unsupported int `json:"unsupported"`
+		Unsafe      int `json:"unsafe"`
+		Declined    int `json:"declined"`
+	} `json:"statements"`
+}
+
+// lintSummary describes the outcome of lint.
+type lintSummary struct {
+	Errors        int `json:"errors"`
+	Warnings      int `json:"warnings"`
+	FormatNotices int `json:"format_notices"`
+	Fixes         int `json:"fixes"`
+	Exceptions    int `json:"exceptions"`
+}
+
+// newRunSummary returns a runSummary for the command being run, or nil if the
+// summary-json option is not set. All methods of runSummary may safely be
+// called on a nil receiver.
+func newRunSummary(cfg *mybase.Config) *runSummary {
+	path := cfg.Get("summary-json")
+	if path == "" {
+		return nil
+	}
+	return &runSummary{
+		Command:     cfg.CLI.Command.Name,
+		Environment: cfg.Get("environment"),
+		StartedAt:   time.Now().UTC(),
+		path:        path,
+	}
+}
+
+// setApplierResult records the combined result of diff, push, or clone.
+func (rs *runSummary) setApplierResult(sum applier.Result) {
+	if rs == nil {
+		return
+	}
+	rs.Diff = newDiffSummary(sum)
+}
+
+// newDiffSummary converts the combined result of diff, push, or clone into a
+// diffSummary.
+func newDiffSummary(sum applier.Result) *diffSummary {
+	ds := &diffSummary{
+		Targets:        sum.TargetCount,
+		SkippedTargets: sum.SkippedTargetCount,
+		ObjectsChanged: sum.ObjectDiffCount,
+	}
+	ds.Statements.Generated = sum.StatementCount
+	ds.Statements.Executed = sum.ExecutedCount
+	ds.Statements.Skipped = sum.SkipCount
+	ds.Statements.Failed = sum.FailedCount
+	ds.Statements.Unsupported = sum.UnsupportedCount
+	ds.Statements.Unsafe = sum.UnsafeCount
+	ds.Statements.Declined = sum.DeclinedCount
+	return ds
+}
+
+// setLintResult records the combined result of lint.
+func (rs *runSummary) setLintResult(result *linter.Result) {
+	if rs == nil {
+		return
+	}
+	rs.Lint = &lintSummary{
+		Errors:        len(result.Errors),
+		Warnings:      len(result.Warnings),
+		FormatNotices: len(result.FormatNotices),
+		Fixes:         len(result.Fixes),
+		Exceptions:    len(result.Exceptions),
+	}
+}
+
+// finish records the command's outcome and writes the summary file. Failure to
+// write the file is logged, but does not affect the command's exit code.
+func (rs *runSummary) finish(err error) {
+	if rs == nil {
+		return
+	}
+	rs.FinishedAt = time.Now().UTC()
+	rs.DurationMs = int64(rs.FinishedAt.Sub(rs.StartedAt) / time.Millisecond)
+	rs.ExitCode = ExitCode(err)
+	if err != nil {
+		rs.Error = err.Error()
+	}
+	data, jsonErr := json.MarshalIndent(rs, "", "  ")
+	if jsonErr == nil {
+		jsonErr = ioutil.WriteFile(rs.path, append(data, '\n'), 0666)
+	}
+	if jsonErr != nil {
+		log.Errorf("Unable to write summary-json %s: %s", rs.path, jsonErr)
+	}
+}
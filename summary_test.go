@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+)
+
+func TestRunSummary(t *testing.T) {
+	// Without summary-json, a nil summary is returned and its methods are noops
+	cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema diff")
+	summary := newRunSummary(cfg)
+	if summary != nil {
+		t.Fatalf("Expected nil summary without summary-json, instead found %+v", summary)
+	}
+	summary.setApplierResult(applier.Result{})
+	summary.finish(nil)
+
+	dir, err := ioutil.TempDir("", "skeematest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "summary.json")
+	cfg = mybase.ParseFakeCLI(t, CommandSuite, "skeema push staging --summary-json="+path)
+	summary = newRunSummary(cfg)
+	summary.setApplierResult(applier.Result{
+		TargetCount:      3,
+		ObjectDiffCount:  4,
+		StatementCount:   3,
+		ExecutedCount:    1,
+		SkipCount:        2,
+		FailedCount:      1,
+		UnsupportedCount: 1,
+	})
+	summary.finish(NewExitValue(CodeFatalError, "Skipped 3 operations due to error"))
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read summary file: %s", err)
+	}
+	var actual map[string]interface{}
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatalf("Unable to decode summary file: %s\n%s", err, data)
+	}
+	if actual["command"] != "push" || actual["environment"] != "staging" || actual["exit_code"] != float64(CodeFatalError) || actual["error"] != "Skipped 3 operations due to error" {
+		t.Errorf("Unexpected summary contents: %s", data)
+	}
+	if _, ok := actual["lint"]; ok {
+		t.Errorf("Expected lint section to be omitted, but it was present: %s", data)
+	}
+	diff, _ := actual["diff"].(map[string]interface{})
+	statements, _ := diff["statements"].(map[string]interface{})
+	if diff["targets"] != float64(3) || diff["objects_changed"] != float64(4) || statements["executed"] != float64(1) || statements["skipped"] != float64(2) || statements["unsafe"] != float64(0) {
+		t.Errorf("Unexpected diff section in summary: %s", data)
+	}
+
+	// Failure to write the file should not panic
+	cfg = mybase.ParseFakeCLI(t, CommandSuite, "skeema lint --summary-json="+filepath.Join(dir, "missing", "summary.json"))
+	newRunSummary(cfg).finish(nil)
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/skeema/tengo"
+)
+
+// AuroraVersion represents a release of Amazon Aurora MySQL. Since Aurora
+// reports the @@version of the MySQL release it is based on, package tengo
+// treats it as stock MySQL, so Skeema tracks Aurora releases separately for
+// purposes of feature gating. The zero value represents a database which is
+// not Aurora.
+type AuroraVersion struct {
+	Major   int
+	Minor   int
+	Patch   int
+	LabMode bool // true if aurora_lab_mode is enabled (Aurora 2 and earlier)
+}
+
+var auroraVersionRegexp = regexp.MustCompile(`(?:^|mysql_aurora\.)(\d+)\.(\d+)(?:\.(\d+))?$`)
+
+// ParseAuroraVersion parses either an @@aurora_version value such as "3.04.0",
+// or an Aurora engine version such as "8.0.mysql_aurora.3.04.0". The zero
+// value is returned if s cannot be parsed.
+func ParseAuroraVersion(s string) AuroraVersion {
+	matches := auroraVersionRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return AuroraVersion{}
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	patch, _ := strconv.Atoi(matches[3]) // 0 if missing, which is fine
+	return AuroraVersion{Major: major, Minor: minor, Patch: patch}
+}
+
+// Known returns true if av represents Aurora.
+func (av AuroraVersion) Known() bool {
+	return av.Major > 0
+}
+
+func (av AuroraVersion) String() string {
+	return fmt.Sprintf("Aurora MySQL %d.%02d.%d", av.Major, av.Minor, av.Patch)
+}
+
+// SupportsInstantDDL returns true if av permits ALGORITHM=INSTANT. Aurora 3
+// inherits this from MySQL 8.0; prior releases are based on MySQL 5.6 or 5.7,
+// which lack it.
+func (av AuroraVersion) SupportsInstantDDL() bool {
+	return av.Major >= 3
+}
+
+// FastDDL returns true if av performs eligible ADD COLUMN operations using
+// Aurora's own Fast DDL feature, which requires lab mode on Aurora 2 and
+// earlier. Fast DDL is only used when the ALTER TABLE lacks an ALGORITHM
+// clause.
+func (av AuroraVersion) FastDDL() bool {
+	return av.Known() && av.Major < 3 && av.LabMode
+}
+
+var auroraInstanceCache struct {
+	versions map[string]AuroraVersion
+	sync.Mutex
+}
+
+// InstanceAuroraVersion returns the Aurora release of inst, or the zero value
+// if inst is not Aurora or cannot be queried. Results are cached per instance.
+func InstanceAuroraVersion(inst *tengo.Instance) AuroraVersion {
+	auroraInstanceCache.Lock()
+	defer auroraInstanceCache.Unlock()
+	if av, ok := auroraInstanceCache.versions[inst.String()]; ok {
+		return av
+	}
+	if auroraInstanceCache.versions == nil {
+		auroraInstanceCache.versions = make(map[string]AuroraVersion)
+	}
+
+	// Aurora always reports a MySQL vendor, and only Aurora has an
+	// aurora_version variable; querying it on stock MySQL is an error
+	var av AuroraVersion
+	if inst.Flavor().Vendor == tengo.VendorMySQL {
+		if db, err := inst.Connect("", ""); err == nil {
+			var version string
+			if err := db.QueryRow("SELECT @@aurora_version").Scan(&version); err == nil {
+				av = ParseAuroraVersion(version)
+			}
+			if av.Known() && av.Major < 3 {
+				var labMode bool
+				if err := db.QueryRow("SELECT @@aurora_lab_mode").Scan(&labMode); err == nil {
+					av.LabMode = labMode
+				}
+			}
+		}
+	}
+	auroraInstanceCache.versions[inst.String()] = av
+	return av
+}
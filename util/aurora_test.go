@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestParseAuroraVersion(t *testing.T) {
+	cases := map[string]AuroraVersion{
+		"3.04.0":                  {3, 4, 0, false},
+		"2.11.2":                  {2, 11, 2, false},
+		"8.0.mysql_aurora.3.05.2": {3, 5, 2, false},
+		"5.7.mysql_aurora.2.07":   {2, 7, 0, false},
+		"mysql:8.0":               {},
+		"":                        {},
+	}
+	for input, expected := range cases {
+		if actual := ParseAuroraVersion(input); actual != expected {
+			t.Errorf("Expected ParseAuroraVersion(%q) to return %+v, instead found %+v", input, expected, actual)
+		}
+	}
+
+	av := ParseAuroraVersion("3.04.0")
+	if !av.Known() || av.String() != "Aurora MySQL 3.04.0" || !av.SupportsInstantDDL() || av.FastDDL() {
+		t.Errorf("Unexpected result from methods of %+v", av)
+	}
+	av = ParseAuroraVersion("2.11.2")
+	if av.SupportsInstantDDL() || av.FastDDL() {
+		t.Errorf("Unexpected result from methods of %+v", av)
+	}
+	if av.LabMode = true; !av.FastDDL() {
+		t.Errorf("Expected %s with lab mode to support Fast DDL", av)
+	}
+	if av = (AuroraVersion{}); av.Known() || av.SupportsInstantDDL() || av.FastDDL() {
+		t.Error("Unexpected result from methods of zero value")
+	}
+}
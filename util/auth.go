@@ -0,0 +1,81 @@
+package util
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var serverPubKeyCache struct {
+	sync.Mutex
+	names map[string]string
+}
+
+func init() {
+	serverPubKeyCache.names = make(map[string]string)
+}
+
+// RegisterServerPublicKey reads a PEM-encoded RSA public key from filePath,
+// and registers it with the MySQL driver. The returned name should be used as
+// the value of the driver's serverPubKey param. This permits password exchange
+// for the sha256_password and caching_sha2_password auth plugins over non-TLS
+// TCP connections, without the driver needing to request the key from the
+// server, which would be vulnerable to a man-in-the-middle attack.
+func RegisterServerPublicKey(filePath string) (string, error) {
+	serverPubKeyCache.Lock()
+	defer serverPubKeyCache.Unlock()
+	if name, already := serverPubKeyCache.names[filePath]; already {
+		return name, nil
+	}
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read server-public-key: %s", err)
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return "", fmt.Errorf("No PEM data found in server-public-key file %s", filePath)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse server-public-key file %s: %s", filePath, err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("File %s does not contain an RSA public key", filePath)
+	}
+	sum := sha256.Sum256(block.Bytes)
+	name := "skeema-" + hex.EncodeToString(sum[:8])
+	mysql.RegisterServerPubKey(name, rsaPub)
+	serverPubKeyCache.names[filePath] = name
+	return name, nil
+}
+
+// AuthErrorHint returns additional explanatory text for connection errors
+// caused by the server requesting an auth plugin which the client cannot or
+// will not use. An empty string is returned for other errors. Note that this
+// only explains the failure: plugins which the driver does not implement, such
+// as MariaDB's client_ed25519, still cannot be used to connect.
+func AuthErrorHint(err error) string {
+	switch err {
+	case mysql.ErrCleartextPassword:
+		return "The database user's auth plugin requires sending the password in cleartext, for example with LDAP or PAM authentication. To permit this, enable the enable-cleartext-plugin option, ideally in combination with TLS."
+	case mysql.ErrNativePassword:
+		return "The database user uses the mysql_native_password auth plugin, which has been disabled via connect-options."
+	case mysql.ErrOldPassword:
+		return "The database user uses the insecure pre-MySQL-4.1 password hashing format. Update the user's password, or permit this via allowOldPasswords=true in connect-options."
+	case mysql.ErrUnknownPlugin:
+		return "The database user's auth plugin is not supported. Unsupported plugins include MariaDB's client_ed25519 and MySQL's authentication_ldap_sasl_client; consider using a different auth plugin for the user that Skeema connects as."
+	}
+	if err != nil && strings.Contains(err.Error(), "this authentication plugin is not supported") {
+		return AuthErrorHint(mysql.ErrUnknownPlugin)
+	}
+	return ""
+}
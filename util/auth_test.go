@@ -0,0 +1,52 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRegisterServerPublicKey(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	ioutil.WriteFile("server-pubkey-test.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0666)
+	ioutil.WriteFile("server-pubkey-bad.pem", []byte("not a key"), 0666)
+	defer os.Remove("server-pubkey-test.pem")
+	defer os.Remove("server-pubkey-bad.pem")
+
+	name, err := RegisterServerPublicKey("server-pubkey-test.pem")
+	if err != nil || name == "" {
+		t.Fatalf("Unexpected result from RegisterServerPublicKey: %q, %v", name, err)
+	}
+	if name2, _ := RegisterServerPublicKey("server-pubkey-test.pem"); name2 != name {
+		t.Errorf("Expected repeated call to return same name %q, instead found %q", name, name2)
+	}
+	if _, err := mysql.ParseDSN("root@tcp(127.0.0.1:3306)/?serverPubKey=" + name); err != nil {
+		t.Errorf("Expected registered key to be usable in DSN, instead found error %s", err)
+	}
+	for _, filePath := range []string{"server-pubkey-bad.pem", "/does/not/exist.pem"} {
+		if _, err := RegisterServerPublicKey(filePath); err == nil {
+			t.Errorf("Expected error from RegisterServerPublicKey(%q), but err was nil", filePath)
+		}
+	}
+}
+
+func TestAuthErrorHint(t *testing.T) {
+	for _, err := range []error{mysql.ErrCleartextPassword, mysql.ErrUnknownPlugin, errors.New("this authentication plugin is not supported")} {
+		if AuthErrorHint(err) == "" {
+			t.Errorf("Expected non-empty hint for error %v", err)
+		}
+	}
+	for _, err := range []error{nil, errors.New("Access denied for user")} {
+		if hint := AuthErrorHint(err); hint != "" {
+			t.Errorf("Expected empty hint for error %v, instead found %q", err, hint)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package util
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/skeema/tengo"
+)
+
+// AWSCredentials represents an AWS access key, obtained by
+// LoadAWSCredentials from one of the standard credential sources.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time // zero value if the credentials do not expire
+}
+
+// AWSCredentialsFromEnv returns AWS credentials from the environment, or an
+// error if none are set.
+func AWSCredentialsFromEnv() (*AWSCredentials, error) {
+	creds := &AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("AWS credentials not found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables")
+	}
+	return creds, nil
+}
+
+// rdsHostRegexp is a regexp for extracting the region from an RDS endpoint
+// hostname, such as mydb.abcdefghijkl.us-east-1.rds.amazonaws.com.
+var rdsHostRegexp = regexp.MustCompile(`\.([a-z]{2}(?:-[a-z]+)+-\d+)\.rds\.amazonaws\.com(?:\.cn)?$`)
+
+// awsARNRegexp is a regexp for extracting the region from an AWS ARN.
+var awsARNRegexp = regexp.MustCompile(`^arn:aws[a-z-]*:[a-z0-9-]+:([a-z0-9-]+):`)
+
+// AWSRegion determines which AWS region to use. If region is non-empty, it is
+// returned as-is. Otherwise, the region is extracted from hint (an RDS
+// endpoint hostname or an ARN) if possible, or else the AWS_REGION or
+// AWS_DEFAULT_REGION environment variables are used.
+func AWSRegion(region, hint string) (string, error) {
+	if region != "" {
+		return region, nil
+	}
+	if matches := rdsHostRegexp.FindStringSubmatch(hint); matches != nil {
+		return matches[1], nil
+	} else if matches := awsARNRegexp.FindStringSubmatch(hint); matches != nil && matches[1] != "" {
+		return matches[1], nil
+	}
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region = os.Getenv(name); region != "" {
+			return region, nil
+		}
+	}
+	return "", errors.New("Unable to determine AWS region: set aws-region option or AWS_REGION environment variable")
+}
+
+// awsEscape percent-encodes s as required by AWS Signature Version 4.
+func awsEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// awsCanonicalQuery returns the query string of v in canonical form, sorted by
+// key and encoded using awsEscape.
+func awsCanonicalQuery(v url.Values) string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, value := range v[k] {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsScope returns the credential scope for a request made at time t.
+func awsScope(t time.Time, region, service string) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", t.Format("20060102"), region, service)
+}
+
+// signature computes the AWS Signature Version 4 signature of
+// canonicalRequest.
+func (creds *AWSCredentials) signature(canonicalRequest string, t time.Time, region, service string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format("20060102T150405Z"),
+		awsScope(t, region, service),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	return hex.EncodeToString(hmacSHA256(creds.signingKey(t, region, service), stringToSign))
+}
+
+// signingKey derives the AWS Signature Version 4 signing key for the supplied
+// date, region, and service.
+func (creds *AWSCredentials) signingKey(t time.Time, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// RDSAuthToken generates an RDS IAM database authentication token for user on
+// the supplied host and port. The token is used in place of a password, and is
+// valid for establishing new connections for 15 minutes after t.
+func (creds *AWSCredentials) RDSAuthToken(host string, port int, user, region string, t time.Time) string {
+	t = t.UTC()
+	endpoint := fmt.Sprintf("%s:%d", host, port)
+	v := url.Values{}
+	v.Set("Action", "connect")
+	v.Set("DBUser", user)
+	v.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	v.Set("X-Amz-Credential", creds.AccessKeyID+"/"+awsScope(t, region, "rds-db"))
+	v.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	v.Set("X-Amz-Expires", "900")
+	v.Set("X-Amz-SignedHeaders", "host")
+	if creds.SessionToken != "" {
+		v.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	query := awsCanonicalQuery(v)
+	canonicalRequest := strings.Join([]string{"GET", "/", query, "host:" + endpoint, "", "host", sha256Hex(nil)}, "\n")
+	return fmt.Sprintf("%s/?%s&X-Amz-Signature=%s", endpoint, query, creds.signature(canonicalRequest, t, region, "rds-db"))
+}
+
+// signRequest adds AWS Signature Version 4 headers to req, which must have
+// a body of payload.
+func (creds *AWSCredentials) signRequest(req *http.Request, payload []byte, region, service string, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, awsScope(t, region, service), signedHeaders, creds.signature(canonicalRequest, t, region, service)))
+}
+
+// getSecretValue calls the Secrets Manager GetSecretValue API at endpoint,
+// returning the secret's username and password. The secret must be stored as
+// a JSON string containing a "password" key, as is the case for secrets
+// created for RDS databases.
+func (creds *AWSCredentials) getSecretValue(endpoint, region, secretID string) (username, password string, err error) {
+	payload, _ := json.Marshal(map[string]string{"SecretId": secretID})
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	creds.signRequest(req, payload, region, "secretsmanager", time.Now())
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode >= 400 {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &awsErr)
+		return "", "", fmt.Errorf("Secrets Manager returned HTTP %d for %s: %s %s", resp.StatusCode, secretID, awsErr.Type, awsErr.Message)
+	}
+	var result struct {
+		SecretString string
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", "", fmt.Errorf("Unable to parse Secrets Manager response for %s: %s", secretID, err)
+	}
+	var secret map[string]interface{}
+	if err := json.Unmarshal([]byte(result.SecretString), &secret); err != nil {
+		return "", "", fmt.Errorf("Secret %s is not a JSON object: %s", secretID, err)
+	}
+	username, _ = secret["username"].(string)
+	password, _ = secret["password"].(string)
+	if password == "" {
+		return "", "", fmt.Errorf("Secret %s does not contain a password", secretID)
+	}
+	return username, password, nil
+}
+
+var awsSecretCache struct {
+	sync.Mutex
+	secrets map[string][2]string
+}
+
+func init() {
+	awsSecretCache.secrets = make(map[string][2]string)
+}
+
+// AWSSecretCredentials returns the username and password stored in the AWS
+// Secrets Manager secret identified by secretID, which may be a full ARN or a
+// secret name. Results are cached, so that each secret is only retrieved once.
+func AWSSecretCredentials(secretID, region string) (username, password string, err error) {
+	if region, err = AWSRegion(region, secretID); err != nil {
+		return "", "", err
+	}
+	key := fmt.Sprintf("%s:%s", region, secretID)
+	awsSecretCache.Lock()
+	defer awsSecretCache.Unlock()
+	if cached, already := awsSecretCache.secrets[key]; already {
+		return cached[0], cached[1], nil
+	}
+	creds, err := LoadAWSCredentials()
+	if err != nil {
+		return "", "", err
+	}
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	if username, password, err = creds.getSecretValue(endpoint, region, secretID); err != nil {
+		return "", "", err
+	}
+	awsSecretCache.secrets[key] = [2]string{username, password}
+	return username, password, nil
+}
+
+// rdsTokenLifetime is how long a generated RDS IAM auth token is reused by
+// RDSAuthTokenForHost. This is less than the token's actual validity of 15
+// minutes, to leave time for connections to be established.
+const rdsTokenLifetime = 10 * time.Minute
+
+var rdsTokenCache struct {
+	sync.Mutex
+	tokens    map[string]string
+	generated map[string]time.Time
+}
+
+func init() {
+	rdsTokenCache.tokens = make(map[string]string)
+	rdsTokenCache.generated = make(map[string]time.Time)
+}
+
+// RDSAuthTokenForHost returns an RDS IAM auth token for user on host:port,
+// using AWS credentials from LoadAWSCredentials. Tokens are reused for up to
+// rdsTokenLifetime, so that repeated calls (for example once per directory)
+// yield an identical token, and therefore share a single connection pool.
+func RDSAuthTokenForHost(host string, port int, user, region string) (string, error) {
+	region, err := AWSRegion(region, host)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s:%s:%d:%s", region, host, port, user)
+	rdsTokenCache.Lock()
+	defer rdsTokenCache.Unlock()
+	if generated, already := rdsTokenCache.generated[key]; already && time.Since(generated) < rdsTokenLifetime {
+		return rdsTokenCache.tokens[key], nil
+	}
+	creds, err := LoadAWSCredentials()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	rdsTokenCache.tokens[key] = creds.RDSAuthToken(host, port, user, region, now)
+	rdsTokenCache.generated[key] = now
+	return rdsTokenCache.tokens[key], nil
+}
+
+// RDSIAMAuthDriver returns the name of a database/sql driver which behaves
+// like the "mysql" driver, except that each new connection uses an RDS IAM
+// auth token from RDSAuthTokenForHost as its password, instead of whatever
+// password is in the DSN. This way, connections established after the DSN's
+// original token expires still succeed. region may be blank to determine the
+// region from each host. The returned name may be supplied to NewInstance.
+func RDSIAMAuthDriver(region string) string {
+	name := "mysql-rds-iam"
+	if region != "" {
+		name += "-" + region
+	}
+	return registerTokenAuthDriver(name, func(cfg *mysql.Config) (string, error) {
+		host, port, err := tengo.SplitHostOptionalPort(cfg.Addr)
+		if err != nil {
+			return "", err
+		}
+		if port == 0 {
+			port = 3306
+		}
+		return RDSAuthTokenForHost(host, port, cfg.User, region)
+	})
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSRegion(t *testing.T) {
+	os.Unsetenv("AWS_REGION")
+	os.Unsetenv("AWS_DEFAULT_REGION")
+	cases := map[[2]string]string{
+		{"eu-west-1", "mydb.abc123.us-east-1.rds.amazonaws.com"}:                   "eu-west-1",
+		{"", "mydb.abc123.us-east-1.rds.amazonaws.com"}:                            "us-east-1",
+		{"", "mydb.cluster-abc123.ap-southeast-2.rds.amazonaws.com"}:               "ap-southeast-2",
+		{"", "arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/db-AbCdEf"}: "us-west-2",
+	}
+	for input, expected := range cases {
+		if actual, err := AWSRegion(input[0], input[1]); err != nil || actual != expected {
+			t.Errorf("Expected AWSRegion(%q, %q) to return %q, instead found %q, err=%v", input[0], input[1], expected, actual, err)
+		}
+	}
+	if _, err := AWSRegion("", "some.db.host"); err == nil {
+		t.Error("Expected error from AWSRegion with no region available, but err was nil")
+	}
+	os.Setenv("AWS_DEFAULT_REGION", "sa-east-1")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	if actual, err := AWSRegion("", "some.db.host"); err != nil || actual != "sa-east-1" {
+		t.Errorf("Expected AWSRegion to fall back to AWS_DEFAULT_REGION, instead found %q, err=%v", actual, err)
+	}
+}
+
+func TestAWSSignature(t *testing.T) {
+	// Signing key example from the AWS Signature Version 4 documentation
+	creds := &AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	expected := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if actual := hex.EncodeToString(creds.signingKey(time.Date(2012, 2, 15, 0, 0, 0, 0, time.UTC), "us-east-1", "iam")); actual != expected {
+		t.Errorf("Expected signing key %s, instead found %s", expected, actual)
+	}
+
+	ts := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	token := creds.RDSAuthToken("mydb.abc123.us-east-1.rds.amazonaws.com", 3306, "skeema", "us-east-1", ts)
+	expectPrefix := "mydb.abc123.us-east-1.rds.amazonaws.com:3306/?Action=connect&DBUser=skeema&X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIDEXAMPLE%2F20150830%2Fus-east-1%2Frds-db%2Faws4_request&X-Amz-Date=20150830T123600Z&X-Amz-Expires=900&X-Amz-SignedHeaders=host&X-Amz-Signature="
+	if !strings.HasPrefix(token, expectPrefix) || len(token) != len(expectPrefix)+64 {
+		t.Errorf("Unexpected RDS auth token %s", token)
+	}
+	creds.SessionToken = "sessiontoken"
+	if token2 := creds.RDSAuthToken("mydb.abc123.us-east-1.rds.amazonaws.com", 3306, "skeema", "us-east-1", ts); !strings.Contains(token2, "&X-Amz-Security-Token=sessiontoken&") {
+		t.Errorf("Expected RDS auth token to contain session token, instead found %s", token2)
+	}
+}
+
+func TestAWSGetSecretValue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		buf := make([]byte, 1024)
+		n, _ := r.Body.Read(buf)
+		switch string(buf[:n]) {
+		case `{"SecretId":"prod/db"}`:
+			w.Write([]byte(`{"Name":"prod/db","SecretString":"{\"username\":\"admin\",\"password\":\"hunter2\",\"engine\":\"mysql\"}"}`))
+		case `{"SecretId":"prod/nopass"}`:
+			w.Write([]byte(`{"Name":"prod/nopass","SecretString":"{\"username\":\"admin\"}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	creds := &AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	if user, pass, err := creds.getSecretValue(server.URL, "us-east-1", "prod/db"); err != nil {
+		t.Errorf("Unexpected error from getSecretValue: %s", err)
+	} else if user != "admin" || pass != "hunter2" {
+		t.Errorf("Unexpected result from getSecretValue: user=%q pass=%q", user, pass)
+	}
+	for _, secretID := range []string{"prod/nopass", "prod/missing"} {
+		if _, _, err := creds.getSecretValue(server.URL, "us-east-1", secretID); err == nil {
+			t.Errorf("Expected error from getSecretValue for %s, but err was nil", secretID)
+		}
+	}
+}
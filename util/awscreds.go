@@ -0,0 +1,217 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// awsIMDSURL is the base URL of the EC2 instance metadata service. It is a var
+// only to permit overriding in tests.
+var awsIMDSURL = "http://169.254.169.254"
+
+// awsECSCredentialsURL is the base URL of the ECS container credentials
+// endpoint, used with AWS_CONTAINER_CREDENTIALS_RELATIVE_URI. It is a var only
+// to permit overriding in tests.
+var awsECSCredentialsURL = "http://169.254.170.2"
+
+var awsCredentialsCache struct {
+	sync.Mutex
+	creds *AWSCredentials
+}
+
+// LoadAWSCredentials returns AWS credentials from the first available source,
+// in the same order used by the AWS CLI and SDKs: environment variables; the
+// shared credentials file; the ECS task role; the EC2 instance profile.
+// Credentials are cached, and only re-obtained once they expire within 5
+// minutes.
+func LoadAWSCredentials() (*AWSCredentials, error) {
+	awsCredentialsCache.Lock()
+	defer awsCredentialsCache.Unlock()
+	if creds := awsCredentialsCache.creds; creds != nil && (creds.Expiration.IsZero() || time.Until(creds.Expiration) > 5*time.Minute) {
+		return creds, nil
+	}
+	creds, err := AWSCredentialsFromEnv()
+	if err != nil {
+		creds, err = AWSCredentialsFromFile()
+	}
+	if err != nil {
+		creds, err = AWSCredentialsFromContainer()
+	}
+	if err != nil {
+		creds, err = AWSCredentialsFromInstanceProfile()
+	}
+	if err != nil {
+		return nil, errors.New("AWS credentials not found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, configure a shared credentials file, or use an ECS task role or EC2 instance profile")
+	}
+	awsCredentialsCache.creds = creds
+	return creds, nil
+}
+
+// AWSCredentialsFromFile returns AWS credentials from the shared credentials
+// file, located at AWS_SHARED_CREDENTIALS_FILE or else ~/.aws/credentials. The
+// profile is determined by AWS_PROFILE, or "default" if not set.
+func AWSCredentialsFromFile() (*AWSCredentials, error) {
+	filePath := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if filePath == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return nil, errors.New("Unable to locate AWS shared credentials file: HOME is not set")
+		}
+		filePath = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	creds := &AWSCredentials{}
+	var inProfile bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		} else if line[0] == '[' && line[len(line)-1] == ']' {
+			inProfile = (strings.TrimSpace(line[1:len(line)-1]) == profile)
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if !inProfile || len(parts) < 2 {
+			continue
+		}
+		name, value := parts[0], parts[1]
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials for profile %s not found in %s", profile, filePath)
+	}
+	return creds, nil
+}
+
+// AWSCredentialsFromContainer returns the credentials of an ECS task role,
+// from the endpoint indicated by AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or
+// AWS_CONTAINER_CREDENTIALS_FULL_URI.
+func AWSCredentialsFromContainer() (*AWSCredentials, error) {
+	var endpoint string
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		endpoint = awsECSCredentialsURL + uri
+	} else if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		endpoint = uri
+	} else {
+		return nil, errors.New("Not running in an ECS task with a task role")
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return fetchAWSTemporaryCredentials(req)
+}
+
+// AWSCredentialsFromInstanceProfile returns the credentials of the EC2
+// instance profile's role, from the instance metadata service (IMDSv2).
+func AWSCredentialsFromInstanceProfile() (*AWSCredentials, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	req, _ := http.NewRequest("PUT", awsIMDSURL+"/latest/api/token", nil)
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	token, err := readAWSResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	credsPath := awsIMDSURL + "/latest/meta-data/iam/security-credentials/"
+	req, _ = http.NewRequest("GET", credsPath, nil)
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err = client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	role, err := readAWSResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	role = strings.TrimSpace(strings.SplitN(role, "\n", 2)[0])
+	if role == "" {
+		return nil, errors.New("EC2 instance does not have an instance profile")
+	}
+	req, _ = http.NewRequest("GET", credsPath+role, nil)
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return fetchAWSTemporaryCredentials(req)
+}
+
+// readAWSResponse returns the body of resp as a string, or an error if the response
+// status indicates failure.
+func readAWSResponse(resp *http.Response) (string, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, resp.Request.URL)
+	}
+	return string(body), nil
+}
+
+// fetchAWSTemporaryCredentials performs req, which must be a request to the
+// ECS container credentials endpoint or the EC2 instance metadata service, and
+// returns the temporary credentials in the response.
+func fetchAWSTemporaryCredentials(req *http.Request) (*AWSCredentials, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readAWSResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		return nil, fmt.Errorf("Unable to parse AWS credentials from %s: %s", req.URL, err)
+	}
+	if result.AccessKeyID == "" || result.SecretAccessKey == "" {
+		return nil, fmt.Errorf("No AWS credentials returned by %s", req.URL)
+	}
+	return &AWSCredentials{
+		AccessKeyID:     result.AccessKeyID,
+		SecretAccessKey: result.SecretAccessKey,
+		SessionToken:    result.Token,
+		Expiration:      result.Expiration,
+	}, nil
+}
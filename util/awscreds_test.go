@@ -0,0 +1,87 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAWSCredentialsFromFile(t *testing.T) {
+	filePath := "../testdata/.scratch/aws-credentials"
+	contents := "[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = secretdefault\n\n# comment\n[other]\naws_access_key_id=AKIDOTHER\naws_secret_access_key=secretother\naws_session_token=tokenother\n"
+	if err := os.MkdirAll("../testdata/.scratch", 0777); err != nil {
+		t.Fatalf("Unable to create scratch dir: %s", err)
+	}
+	if err := ioutil.WriteFile(filePath, []byte(contents), 0600); err != nil {
+		t.Fatalf("Unable to write %s: %s", filePath, err)
+	}
+	defer os.Remove(filePath)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", filePath)
+	defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+
+	os.Unsetenv("AWS_PROFILE")
+	if creds, err := AWSCredentialsFromFile(); err != nil || creds.AccessKeyID != "AKIDDEFAULT" || creds.SecretAccessKey != "secretdefault" || creds.SessionToken != "" {
+		t.Errorf("Unexpected return from AWSCredentialsFromFile: %+v, %v", creds, err)
+	}
+	os.Setenv("AWS_PROFILE", "other")
+	defer os.Unsetenv("AWS_PROFILE")
+	if creds, err := AWSCredentialsFromFile(); err != nil || creds.AccessKeyID != "AKIDOTHER" || creds.SessionToken != "tokenother" {
+		t.Errorf("Unexpected return from AWSCredentialsFromFile: %+v, %v", creds, err)
+	}
+	os.Setenv("AWS_PROFILE", "missing")
+	if creds, err := AWSCredentialsFromFile(); err == nil {
+		t.Errorf("Expected error from AWSCredentialsFromFile with missing profile, instead found %+v", creds)
+	}
+}
+
+func TestAWSTemporaryCredentials(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	credsJSON := fmt.Sprintf(`{"AccessKeyId": "ASIATEMP", "SecretAccessKey": "secrettemp", "Token": "tokentemp", "Expiration": "%s"}`, expiration.Format(time.RFC3339))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("imdstoken"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "imdstoken" && r.Header.Get("Authorization") != "ecstoken":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("myrole\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/myrole", r.URL.Path == "/v2/credentials/abc":
+			w.Write([]byte(credsJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	origIMDSURL, origECSURL := awsIMDSURL, awsECSCredentialsURL
+	awsIMDSURL, awsECSCredentialsURL = server.URL, server.URL
+	defer func() {
+		awsIMDSURL, awsECSCredentialsURL = origIMDSURL, origECSURL
+	}()
+
+	check := func(creds *AWSCredentials, err error) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		} else if creds.AccessKeyID != "ASIATEMP" || creds.SecretAccessKey != "secrettemp" || creds.SessionToken != "tokentemp" || !creds.Expiration.Equal(expiration) {
+			t.Errorf("Unexpected credentials: %+v", creds)
+		}
+	}
+	check(AWSCredentialsFromInstanceProfile())
+
+	if _, err := AWSCredentialsFromContainer(); err == nil {
+		t.Error("Expected error from AWSCredentialsFromContainer outside of ECS, but err was nil")
+	}
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/abc")
+	os.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "ecstoken")
+	defer os.Unsetenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	defer os.Unsetenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	check(AWSCredentialsFromContainer())
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/wrong")
+	if _, err := AWSCredentialsFromContainer(); err == nil {
+		t.Error("Expected error from AWSCredentialsFromContainer with bad URI, but err was nil")
+	}
+}
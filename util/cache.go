@@ -18,7 +18,9 @@ func init() {
 
 // NewInstance wraps tengo.NewInstance such that two identical requests will
 // return the same *tengo.Instance. This helps reduce excessive creation of
-// redundant connections.
+// redundant connections. In addition to "mysql", driver may be the name of a
+// driver returned by RDSIAMAuthDriver or CloudSQLIAMAuthDriver, which wrap the
+// MySQL driver.
 func NewInstance(driver, dsn string) (*tengo.Instance, error) {
 	key := fmt.Sprintf("%s:%s", driver, dsn)
 	instanceCache.Lock()
@@ -27,10 +29,15 @@ func NewInstance(driver, dsn string) (*tengo.Instance, error) {
 	if already {
 		return instance, nil
 	}
-	instance, err := tengo.NewInstance(driver, dsn)
+	baseDriver := driver
+	if isTokenAuthDriver(driver) {
+		baseDriver = "mysql"
+	}
+	instance, err := tengo.NewInstance(baseDriver, dsn)
 	if err != nil {
 		return nil, err
 	}
+	instance.Driver = driver
 	instanceCache.instanceMap[key] = instance
 	return instance, nil
 }
@@ -25,6 +25,14 @@ func TestNewInstance(t *testing.T) {
 		t.Error("Expected inst1 and inst3 to point to different instances, but they do not")
 	}
 
+	// RDS IAM auth driver should be used for connections, but otherwise behave
+	// like the mysql driver
+	driver := RDSIAMAuthDriver("us-east-1")
+	inst4 := getNewInstance(driver, "username:password@tcp(1.2.3.4:3306)/?readTimeout=5s&interpolateParams=0")
+	if inst4 == inst3 || inst4.Driver != driver || inst4.Host != "1.2.3.4" {
+		t.Errorf("Unexpected instance from NewInstance with driver %s: %+v", driver, inst4)
+	}
+
 	if _, err := NewInstance("btrieve", "username:password@tcp(some.host)/dbname?param=value"); err == nil {
 		t.Error("Expected bad driver to return error, but it did not")
 	}
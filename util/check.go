@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/skeema/tengo"
+)
+
+// CheckConstraint represents a CHECK constraint, as shown in SHOW CREATE TABLE
+// output on MySQL 8.0.16+.
+type CheckConstraint struct {
+	Name       string
+	Expression string // as shown inside CHECK (...), e.g. "(`price` > 0)"
+	Enforced   bool
+}
+
+// Definition returns the clause used to add the constraint in an ALTER TABLE.
+func (cc CheckConstraint) Definition() string {
+	def := fmt.Sprintf("ADD CONSTRAINT %s CHECK (%s)", tengo.EscapeIdentifier(cc.Name), cc.Expression)
+	if !cc.Enforced {
+		def += " NOT ENFORCED"
+	}
+	return def
+}
+
+// CHECK constraints are always shown after all other definitions in SHOW
+// CREATE TABLE, so each one is preceded by a comma. NOT ENFORCED is displayed
+// in a version-gated comment.
+var checkConstraintRegexp = regexp.MustCompile(",\n  CONSTRAINT `((?:[^`]|``)+)` CHECK \\((.*)\\)( /\\*!80016 NOT ENFORCED \\*/)?")
+
+// CheckConstraints returns the CHECK constraints of table, in the order they
+// appear in its CreateStatement.
+func CheckConstraints(table *tengo.Table) (checks []CheckConstraint) {
+	for _, matches := range checkConstraintRegexp.FindAllStringSubmatch(table.CreateStatement, -1) {
+		checks = append(checks, CheckConstraint{
+			Name:       unescapeIdentifier("`" + matches[1] + "`"),
+			Expression: matches[2],
+			Enforced:   matches[3] == "",
+		})
+	}
+	return checks
+}
+
+// StripCheckConstraints returns a shallow copy of table, with all CHECK
+// constraints removed from its CreateStatement.
+func StripCheckConstraints(table *tengo.Table) *tengo.Table {
+	stripped := *table
+	stripped.CreateStatement = checkConstraintRegexp.ReplaceAllString(table.CreateStatement, "")
+	return &stripped
+}
+
+// NormalizeCheckConstraints adjusts tables introspected from MySQL 8, so that
+// they may be diff'ed. Tables which were marked as unsupported solely due to
+// CHECK constraints are marked as supported. Each table's CreateStatement is
+// left as-is, so that the constraints are included when creating new tables.
+func NormalizeCheckConstraints(schema *tengo.Schema, flavor tengo.Flavor) {
+	if schema == nil {
+		return
+	}
+	for _, table := range schema.Tables {
+		if !table.UnsupportedDDL {
+			continue
+		}
+		stripped := StripCheckConstraints(table)
+		if stripped.CreateStatement == stripped.GeneratedCreateStatement(flavor) {
+			table.UnsupportedDDL = false
+		}
+	}
+}
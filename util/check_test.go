@@ -0,0 +1,47 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestCheckConstraints(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int unsigned", Default: tengo.ColumnDefaultNull}
+	priceCol := &tengo.Column{Name: "price", TypeInDB: "decimal(10,2)", Default: tengo.ColumnDefaultNull}
+	table := &tengo.Table{
+		Name:               "products",
+		Engine:             "InnoDB",
+		CharSet:            "utf8mb4",
+		Collation:          "utf8mb4_0900_ai_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{idCol, priceCol},
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		UnsupportedDDL:     true,
+	}
+	generated := table.GeneratedCreateStatement(tengo.FlavorMySQL80)
+	table.CreateStatement = strings.Replace(generated, "\n) ENGINE",
+		",\n  CONSTRAINT `chk_price` CHECK ((`price` > 0)),\n"+
+			"  CONSTRAINT `chk_id` CHECK ((`id` <> 13)) /*!80016 NOT ENFORCED */\n) ENGINE", 1)
+
+	expected := []CheckConstraint{
+		{Name: "chk_price", Expression: "(`price` > 0)", Enforced: true},
+		{Name: "chk_id", Expression: "(`id` <> 13)", Enforced: false},
+	}
+	if checks := CheckConstraints(table); !reflect.DeepEqual(checks, expected) {
+		t.Errorf("Unexpected result from CheckConstraints: %+v", checks)
+	}
+	if def := expected[1].Definition(); def != "ADD CONSTRAINT `chk_id` CHECK ((`id` <> 13)) NOT ENFORCED" {
+		t.Errorf("Unexpected result from Definition: %s", def)
+	}
+	if stripped := StripCheckConstraints(table); stripped.CreateStatement != generated {
+		t.Errorf("Unexpected result from StripCheckConstraints:\n%s", stripped.CreateStatement)
+	}
+
+	NormalizeCheckConstraints(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorMySQL80)
+	if table.UnsupportedDDL {
+		t.Error("Expected table to be supported after normalization, but it was not")
+	}
+}
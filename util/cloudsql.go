@@ -0,0 +1,324 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// cloudSQLConnNameRegexp is a regexp for detecting Cloud SQL instance
+// connection names, of format "project:region:instance". Legacy domain-scoped
+// project IDs ("example.com:project") are also permitted.
+var cloudSQLConnNameRegexp = regexp.MustCompile(`^((?:[a-z0-9.-]+:)?[a-z][a-z0-9-]*):[a-z]+-[a-z]+\d+:([a-z][a-z0-9-]*)$`)
+
+// IsCloudSQLConnectionName returns true if host is a Cloud SQL instance
+// connection name, rather than a hostname or address.
+func IsCloudSQLConnectionName(host string) bool {
+	return cloudSQLConnNameRegexp.MatchString(host)
+}
+
+// cloudSQLAdminURL is the base URL of the Cloud SQL Admin API. It is a var
+// only to permit overriding in tests.
+var cloudSQLAdminURL = "https://sqladmin.googleapis.com/sql/v1beta4"
+
+// cloudSQLPort is the port used by Cloud SQL's server-side proxy, which
+// accepts TLS connections authenticated by an ephemeral client certificate.
+const cloudSQLPort = 3307
+
+func init() {
+	mysql.RegisterDial("cloudsql", DialCloudSQL)
+}
+
+// googleMetadataURL is the base URL of the GCE metadata server. It is a var
+// only to permit overriding in tests.
+var googleMetadataURL = "http://metadata.google.internal"
+
+// googleTokenLifetime is the lifetime assumed for access tokens obtained from
+// gcloud, which does not report their expiration. Google Cloud access tokens
+// are valid for one hour by default.
+const googleTokenLifetime = time.Hour
+
+var googleTokenCache struct {
+	sync.Mutex
+	token      string
+	expiration time.Time
+}
+
+// GoogleAccessToken returns an OAuth2 access token for Google Cloud APIs. The
+// token is obtained from the GOOGLE_OAUTH_ACCESS_TOKEN environment variable if
+// set; otherwise from `gcloud auth print-access-token` if gcloud is installed;
+// otherwise from the GCE metadata server, which is available on Compute
+// Engine, GKE, and Cloud Build. Tokens from gcloud or the metadata server are
+// cached, and only re-obtained once they expire within 5 minutes.
+func GoogleAccessToken() (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	googleTokenCache.Lock()
+	defer googleTokenCache.Unlock()
+	if googleTokenCache.token != "" && time.Until(googleTokenCache.expiration) > 5*time.Minute {
+		return googleTokenCache.token, nil
+	}
+	token, lifetime, err := fetchGoogleAccessToken()
+	if err != nil {
+		return "", err
+	}
+	googleTokenCache.token = token
+	googleTokenCache.expiration = time.Now().Add(lifetime)
+	return token, nil
+}
+
+// fetchGoogleAccessToken obtains a new access token from gcloud or the GCE
+// metadata server, returning the token along with its lifetime.
+func fetchGoogleAccessToken() (string, time.Duration, error) {
+	if _, err := exec.LookPath("gcloud"); err == nil {
+		output, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if token := strings.TrimSpace(string(output)); err == nil && token != "" {
+			return token, googleTokenLifetime, nil
+		}
+	}
+	req, _ := http.NewRequest("GET", googleMetadataURL+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	req.Header.Set("Metadata-Flavor", "Google")
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, errors.New("Unable to obtain Google Cloud access token: set GOOGLE_OAUTH_ACCESS_TOKEN environment variable, or install and authenticate gcloud")
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.AccessToken == "" {
+		return "", 0, fmt.Errorf("Unable to obtain Google Cloud access token from metadata server (HTTP %d)", resp.StatusCode)
+	}
+	return result.AccessToken, time.Duration(result.ExpiresIn) * time.Second, nil
+}
+
+// CloudSQLIAMAuthDriver returns the name of a database/sql driver which
+// behaves like the "mysql" driver, except that each new connection uses an
+// access token from GoogleAccessToken as its password, instead of whatever
+// password is in the DSN. This way, connections established after the DSN's
+// original token expires still succeed. The returned name may be supplied to
+// NewInstance.
+func CloudSQLIAMAuthDriver() string {
+	return registerTokenAuthDriver("mysql-cloudsql-iam", func(*mysql.Config) (string, error) {
+		return GoogleAccessToken()
+	})
+}
+
+// cloudSQLConnInfo holds the information needed to connect to a Cloud SQL
+// instance: its address, the CA which signed its server certificate, and an
+// ephemeral client certificate.
+type cloudSQLConnInfo struct {
+	address    string
+	serverName string
+	rootCAs    *x509.CertPool
+	clientCert tls.Certificate
+	expiration time.Time
+}
+
+var cloudSQLCache struct {
+	sync.Mutex
+	key   *rsa.PrivateKey
+	infos map[string]*cloudSQLConnInfo
+}
+
+func init() {
+	cloudSQLCache.infos = make(map[string]*cloudSQLConnInfo)
+}
+
+// cloudSQLAdminRequest performs a request to the Cloud SQL Admin API, decoding
+// the JSON response into result.
+func cloudSQLAdminRequest(method, path, token string, body, result interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, cloudSQLAdminURL+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("Cloud SQL Admin API returned HTTP %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// fetchCloudSQLConnInfo uses the Cloud SQL Admin API to look up the address
+// and server CA of the instance identified by connName, and to obtain an
+// ephemeral client certificate for key. Including the access token in the
+// certificate request permits use of IAM database authentication.
+func fetchCloudSQLConnInfo(connName string, key *rsa.PrivateKey) (*cloudSQLConnInfo, error) {
+	matches := cloudSQLConnNameRegexp.FindStringSubmatch(connName)
+	if matches == nil {
+		return nil, fmt.Errorf("Invalid Cloud SQL instance connection name %s", connName)
+	}
+	project, instance := matches[1], matches[2]
+	token, err := GoogleAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var settings struct {
+		ServerCACert struct {
+			Cert string `json:"cert"`
+		} `json:"serverCaCert"`
+		IPAddresses []struct {
+			Type      string `json:"type"`
+			IPAddress string `json:"ipAddress"`
+		} `json:"ipAddresses"`
+	}
+	path := fmt.Sprintf("/projects/%s/instances/%s", project, instance)
+	if err := cloudSQLAdminRequest("GET", path+"/connectSettings", token, nil, &settings); err != nil {
+		return nil, err
+	}
+	info := &cloudSQLConnInfo{
+		serverName: project + ":" + instance,
+		rootCAs:    x509.NewCertPool(),
+	}
+	if !info.rootCAs.AppendCertsFromPEM([]byte(settings.ServerCACert.Cert)) {
+		return nil, fmt.Errorf("Unable to parse server CA certificate for Cloud SQL instance %s", connName)
+	}
+	// Prefer the public IP, falling back to the private IP if there is none
+	for _, ipType := range []string{"PRIMARY", "PRIVATE"} {
+		for _, addr := range settings.IPAddresses {
+			if addr.Type == ipType && info.address == "" {
+				info.address = net.JoinHostPort(addr.IPAddress, fmt.Sprint(cloudSQLPort))
+			}
+		}
+	}
+	if info.address == "" {
+		return nil, fmt.Errorf("Cloud SQL instance %s has no IP address", connName)
+	}
+
+	pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	certRequest := map[string]string{
+		"public_key":   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubKey})),
+		"access_token": token,
+	}
+	var certResponse struct {
+		EphemeralCert struct {
+			Cert string `json:"cert"`
+		} `json:"ephemeralCert"`
+	}
+	if err := cloudSQLAdminRequest("POST", path+":generateEphemeralCert", token, certRequest, &certResponse); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(certResponse.EphemeralCert.Cert))
+	if block == nil {
+		return nil, fmt.Errorf("Unable to parse ephemeral certificate for Cloud SQL instance %s", connName)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	info.clientCert = tls.Certificate{Certificate: [][]byte{block.Bytes}, PrivateKey: key, Leaf: cert}
+	info.expiration = cert.NotAfter
+	return info, nil
+}
+
+// cloudSQLConnInfoFor returns cached connection info for connName, fetching it
+// if not yet cached or if the ephemeral certificate expires within 5 minutes.
+func cloudSQLConnInfoFor(connName string) (*cloudSQLConnInfo, error) {
+	cloudSQLCache.Lock()
+	defer cloudSQLCache.Unlock()
+	if info := cloudSQLCache.infos[connName]; info != nil && time.Until(info.expiration) > 5*time.Minute {
+		return info, nil
+	}
+	if cloudSQLCache.key == nil {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		cloudSQLCache.key = key
+	}
+	info, err := fetchCloudSQLConnInfo(connName, cloudSQLCache.key)
+	if err != nil {
+		return nil, err
+	}
+	cloudSQLCache.infos[connName] = info
+	return info, nil
+}
+
+// tlsConfig returns a TLS configuration which presents the ephemeral client
+// certificate, and verifies the server certificate against the instance's CA.
+// Cloud SQL server certificates use the instance's "project:instance" name as
+// their common name, rather than a hostname, so standard hostname verification
+// is replaced with a check of the common name.
+func (info *cloudSQLConnInfo) tlsConfig() *tls.Config {
+	return &tls.Config{
+		Certificates:       []tls.Certificate{info.clientCert},
+		InsecureSkipVerify: true, // verification performed in VerifyPeerCertificate instead
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("No server certificate presented")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			if _, err := cert.Verify(x509.VerifyOptions{Roots: info.rootCAs}); err != nil {
+				return err
+			}
+			if cert.Subject.CommonName != info.serverName {
+				return fmt.Errorf("Server certificate common name %s does not match expected %s", cert.Subject.CommonName, info.serverName)
+			}
+			return nil
+		},
+	}
+}
+
+// DialCloudSQL establishes a TLS connection to the Cloud SQL instance with the
+// supplied connection name. It is registered with the MySQL driver as the dial
+// function for the "cloudsql" network, permitting DSNs of form
+// "user:pass@cloudsql(project:region:instance)/" without needing to run the
+// Cloud SQL Auth Proxy.
+func DialCloudSQL(connName string) (net.Conn, error) {
+	info, err := cloudSQLConnInfoFor(connName)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: time.Minute}
+	return tls.DialWithDialer(dialer, "tcp", info.address, info.tlsConfig())
+}
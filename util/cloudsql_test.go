@@ -0,0 +1,191 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIsCloudSQLConnectionName(t *testing.T) {
+	cases := map[string]bool{
+		"my-project:us-central1:my-instance":         true,
+		"example.com:my-project:europe-west2:db1":    true,
+		"my-project:northamerica-northeast1:db-prod": true,
+		"some.db.host":                               false,
+		"some.db.host:3306":                          false,
+		"localhost":                                  false,
+		"[::1]:3306":                                 false,
+		"my-project:my-instance":                     false,
+	}
+	for input, expected := range cases {
+		if actual := IsCloudSQLConnectionName(input); actual != expected {
+			t.Errorf("Expected IsCloudSQLConnectionName(%q) to return %t, instead found %t", input, expected, actual)
+		}
+	}
+}
+
+// testCert generates a certificate with the supplied common name, signed by
+// parent (or self-signed if parent is nil).
+func testCert(t *testing.T, commonName string, pub *rsa.PublicKey, parent *x509.Certificate, parentKey *rsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		t.Fatalf("Unable to create certificate: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func TestCloudSQLConnInfo(t *testing.T) {
+	caKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	caCert := testCert(t, "Test CA", &caKey.PublicKey, nil, caKey)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer testtoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"bad token"}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/projects/proj/instances/db1/connectSettings":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"serverCaCert": map[string]string{"cert": caPEM},
+				"ipAddresses": []map[string]string{
+					{"type": "PRIVATE", "ipAddress": "10.0.0.5"},
+					{"type": "PRIMARY", "ipAddress": "203.0.113.7"},
+				},
+			})
+		case "/projects/proj/instances/db1:generateEphemeralCert":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			block, _ := pem.Decode([]byte(req["public_key"]))
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil || req["access_token"] != "testtoken" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			cert := testCert(t, "ephemeral", pub.(*rsa.PublicKey), caCert, caKey)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ephemeralCert": map[string]string{"cert": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+	origURL := cloudSQLAdminURL
+	cloudSQLAdminURL = server.URL
+	defer func() { cloudSQLAdminURL = origURL }()
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "testtoken")
+	defer os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+
+	clientKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	info, err := fetchCloudSQLConnInfo("proj:us-central1:db1", clientKey)
+	if err != nil {
+		t.Fatalf("Unexpected error from fetchCloudSQLConnInfo: %s", err)
+	}
+	if info.address != "203.0.113.7:3307" || info.serverName != "proj:db1" {
+		t.Errorf("Unexpected connection info: address=%s serverName=%s", info.address, info.serverName)
+	}
+	if _, err := fetchCloudSQLConnInfo("proj:us-central1:db2", clientKey); err == nil {
+		t.Error("Expected error from fetchCloudSQLConnInfo for nonexistent instance, but err was nil")
+	}
+
+	// Confirm the TLS config accepts a server cert with the correct common name
+	// signed by the CA, and rejects one with the wrong common name
+	for _, serverName := range []string{"proj:db1", "proj:db2"} {
+		serverKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		serverCert := testCert(t, serverName, &serverKey.PublicKey, caCert, caKey)
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		})
+		if err != nil {
+			t.Fatalf("Unable to listen: %s", err)
+		}
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		conn, err := tls.Dial("tcp", listener.Addr().String(), info.tlsConfig())
+		if serverName == info.serverName && err != nil {
+			t.Errorf("Unexpected error connecting to server with correct certificate: %s", err)
+		} else if serverName != info.serverName && err == nil {
+			t.Error("Expected error connecting to server with incorrect certificate, but err was nil")
+		}
+		if conn != nil {
+			conn.Close()
+		}
+		listener.Close()
+	}
+}
+
+func TestGoogleAccessTokenCache(t *testing.T) {
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "metadatatoken", "expires_in": 3599})
+	}))
+	defer server.Close()
+	origURL, origPath := googleMetadataURL, os.Getenv("PATH")
+	googleMetadataURL = server.URL
+	os.Setenv("PATH", "") // ensure gcloud is not found
+	os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	defer func() {
+		googleMetadataURL = origURL
+		os.Setenv("PATH", origPath)
+		googleTokenCache.token = ""
+	}()
+
+	for n := 0; n < 2; n++ {
+		if token, err := GoogleAccessToken(); err != nil || token != "metadatatoken" {
+			t.Errorf("Unexpected return from GoogleAccessToken: %q, %v", token, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected token to be fetched once, instead found %d", fetches)
+	}
+
+	// A token that is about to expire should be re-fetched
+	googleTokenCache.expiration = time.Now().Add(time.Minute)
+	if _, err := GoogleAccessToken(); err != nil || fetches != 2 {
+		t.Errorf("Expected expiring token to be re-fetched; instead found err=%v, fetches=%d", err, fetches)
+	}
+
+	// The environment variable takes precedence, and is never cached
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "envtoken")
+	defer os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token, err := GoogleAccessToken(); err != nil || token != "envtoken" || fetches != 2 {
+		t.Errorf("Unexpected return from GoogleAccessToken: %q, %v", token, err)
+	}
+}
@@ -24,21 +24,50 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("socket", 'S', "/tmp/mysql.sock", "Absolute path to Unix socket file used if host is localhost").Hidden())
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Database schema name").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("schema-map", 0, "", "Comma-separated regex=subdir rules for mapping many schemas onto a single dir").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("ignore-proc", 0, "", "Ignore stored procedures that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("ignore-func", 0, "", "Ignore functions that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("ignore-object-type", 0, "", "Ignore all objects of these types (comma-separated list)").Hidden())
 	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "Schema-level default character set").Hidden())
 	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "Schema-level default collation").Hidden())
+	cmd.AddOption(mybase.StringOption("include", 0, "", "Comma-separated list of option files to include, relative to the including file").Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
+	cmd.AddOption(mybase.StringOption("history-schema", 0, "", "Schema containing the skeema_history table, which is excluded from all other operations").Hidden())
 
 	// Visible global options
 	cmd.AddOption(mybase.StringOption("user", 'u', "root", "Username to connect to database host"))
 	cmd.AddOption(mybase.StringOption("password", 'p', "<no password>", "Password for database user; supply with no value to prompt").ValueOptional())
+	cmd.AddOption(mybase.StringOption("password-command", 0, "", "External bin to shell out to for obtaining password; see manual for template vars"))
+	cmd.AddOption(mybase.StringOption("vault-addr", 0, "", "Address of HashiCorp Vault server; defaults to VAULT_ADDR env var"))
+	cmd.AddOption(mybase.StringOption("vault-path", 0, "", "Vault path to read database user and password from, if password is not supplied"))
+	cmd.AddOption(mybase.BoolOption("aws-iam-auth", 0, false, "Generate RDS IAM auth token for each host, instead of using a password"))
+	cmd.AddOption(mybase.StringOption("aws-secret", 0, "", "AWS Secrets Manager secret ARN or name to read database user and password from, if password is not supplied"))
+	cmd.AddOption(mybase.StringOption("aws-region", 0, "", "AWS region for aws-iam-auth and aws-secret; defaults to region of host or AWS_REGION env var"))
+	cmd.AddOption(mybase.BoolOption("cloudsql-iam-auth", 0, false, "Use Google Cloud access token as password for Cloud SQL IAM database authentication"))
+	cmd.AddOption(mybase.StringOption("ssl-mode", 0, "", `Security state of connection to database host (valid values: "DISABLED", "REQUIRED", "VERIFY_CA", "VERIFY_IDENTITY")`))
+	cmd.AddOption(mybase.StringOption("ssl-ca", 0, "", "Path to file containing PEM-encoded CA certificate(s) for verifying database host"))
+	cmd.AddOption(mybase.StringOption("ssl-cert", 0, "", "Path to file containing PEM-encoded client certificate"))
+	cmd.AddOption(mybase.StringOption("ssl-key", 0, "", "Path to file containing PEM-encoded client private key"))
+	cmd.AddOption(mybase.StringOption("ssl-server-name", 0, "", "Server name to verify in database host's certificate, if different than host"))
+	cmd.AddOption(mybase.StringOption("tls-min-version", 0, "", `Minimum TLS protocol version (valid values: "TLSv1.0", "TLSv1.1", "TLSv1.2", "TLSv1.3")`))
+	cmd.AddOption(mybase.BoolOption("enable-cleartext-plugin", 0, false, "Permit sending password in cleartext if required by auth plugin, e.g. for LDAP or PAM"))
+	cmd.AddOption(mybase.StringOption("server-public-key", 0, "", "Path to file containing database host's RSA public key, for password exchange without TLS"))
+	cmd.AddOption(mybase.BoolOption("type-subdirs", 0, false, "Store *.sql files in a subdir per object type (tables, procedures, functions) within each schema dir"))
 	cmd.AddOption(mybase.StringOption("host-wrapper", 'H', "", "External bin to shell out to for host lookup; see manual for template vars"))
+	cmd.AddOption(mybase.StringOption("consul-addr", 0, "", "Address of Consul HTTP API, for hosts of format consul:SERVICE; defaults to CONSUL_HTTP_ADDR env var"))
 	cmd.AddOption(mybase.StringOption("temp-schema", 't', "_skeema_tmp", "Name of temporary schema for intermediate operations, created and dropped each run unless --reuse-temp-schema"))
 	cmd.AddOption(mybase.StringOption("connect-options", 'o', "", "Comma-separated session options to set upon connecting to each database instance"))
 	cmd.AddOption(mybase.StringOption("workspace", 'w', "TEMP-SCHEMA", `Specifies where to run intermediate operations (valid values: "TEMP-SCHEMA", "DOCKER")`))
 	cmd.AddOption(mybase.StringOption("docker-cleanup", 0, "NONE", `With --workspace=docker, specifies how to clean up containers (valid values: "NONE", "STOP", "DESTROY")`))
 	cmd.AddOption(mybase.BoolOption("reuse-temp-schema", 0, false, "Do not drop temp-schema when done"))
+	cmd.AddOption(mybase.StringOption("vitess", 0, "OFF", `Account for Vitess limitations in a keyspace (valid values: "OFF", "UNSHARDED", "SHARDED")`))
+	cmd.AddOption(mybase.BoolOption("seeds", 0, false, "Load INSERT statements from each schema dir's seeds subdir into workspaces"))
+	cmd.AddOption(mybase.StringOption("statsd-addr", 0, "", "Send operational metrics to the StatsD server at this host:port via UDP"))
+	cmd.AddOption(mybase.StringOption("statsd-prefix", 0, "skeema", "Prefix for names of metrics sent to statsd-addr").Hidden())
+	cmd.AddOption(mybase.StringOption("otlp-endpoint", 0, "", "Export OpenTelemetry trace spans to this OTLP/HTTP collector URL"))
 	cmd.AddOption(mybase.BoolOption("debug", 0, false, "Enable debug logging"))
+	cmd.AddOption(mybase.StringOption("log-format", 0, "text", `Format of log output to STDERR (valid values: "text", "json")`))
 }
 
 // AddGlobalConfigFiles takes the mybase.Config generated from the CLI and adds
@@ -82,13 +111,16 @@ func AddGlobalConfigFiles(cfg *mybase.Config) {
 			_ = f.UseSection(cfg.Get("environment")) // safe to ignore error (doesn't matter if section doesn't exist)
 		}
 
-		cfg.AddSource(f)
+		if err := AddOptionFile(cfg, f); err != nil {
+			log.Warnf("Ignoring global option file due to error: %s", err)
+		}
 	}
 }
 
 // ProcessSpecialGlobalOptions performs special handling of global options with
 // unusual semantics -- handling restricted placement of host and schema;
-// obtaining a password from MYSQL_PWD or STDIN; enable debug logging.
+// obtaining a password from MYSQL_PWD or STDIN; enable debug logging; enable
+// metrics and tracing exporters.
 func ProcessSpecialGlobalOptions(cfg *mybase.Config) error {
 	// The host and schema options are special -- most commands only expect
 	// to find them when recursively crawling directory configs. So if these
@@ -124,6 +156,24 @@ func ProcessSpecialGlobalOptions(cfg *mybase.Config) error {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if addr := cfg.Get("statsd-addr"); addr != "" {
+		if err := Metrics.EnableStatsD(addr, cfg.Get("statsd-prefix")); err != nil {
+			return fmt.Errorf("Option statsd-addr is invalid: %s", err)
+		}
+	}
+
+	// Tracing may also be enabled using the standard OpenTelemetry env var, if
+	// the otlp-endpoint option was not supplied
+	endpoint := cfg.Get("otlp-endpoint")
+	if !cfg.Supplied("otlp-endpoint") && endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint != "" {
+		if err := Tracing.EnableOTLP(endpoint); err != nil {
+			return fmt.Errorf("Option otlp-endpoint is invalid: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -208,32 +258,39 @@ func SplitConnectOptions(connectOpts string) (map[string]string, error) {
 	return result, nil
 }
 
+// driverParams is a set of lowercased names of all go-sql-driver/mysql special
+// params, which are not actually MySQL session variables.
+var driverParams = map[string]bool{
+	"allowallfiles":           true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"allowcleartextpasswords": true,
+	"allownativepasswords":    true,
+	"allowoldpasswords":       true,
+	"charset":                 true,
+	"clientfoundrows":         true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"collation":               true,
+	"columnswithalias":        true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"interpolateparams":       true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"loc":                     true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"maxallowedpacket":        true,
+	"multistatements":         true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"parsetime":               true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"readtimeout":             true,
+	"strict":                  true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
+	"timeout":                 true,
+	"tls":                     true,
+	"writetimeout":            true,
+}
+
+// IsDriverParam returns true if the supplied option name is a special param
+// of the Go MySQL driver, rather than a MySQL session variable.
+func IsDriverParam(name string) bool {
+	return driverParams[strings.ToLower(name)]
+}
+
 // RealConnectOptions takes a comma-separated string of connection options,
 // strips any Go driver-specific ones, and then returns the new string which
 // is now suitable for passing to an external tool.
 func RealConnectOptions(connectOpts string) (string, error) {
-	// list of lowercased versions of all go-sql-driver/mysql special params
-	ignored := map[string]bool{
-		"allowallfiles":           true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"allowcleartextpasswords": true,
-		"allownativepasswords":    true,
-		"allowoldpasswords":       true,
-		"charset":                 true,
-		"clientfoundrows":         true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"collation":               true,
-		"columnswithalias":        true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"interpolateparams":       true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"loc":                     true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"maxallowedpacket":        true,
-		"multistatements":         true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"parsetime":               true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"readtimeout":             true,
-		"strict":                  true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
-		"timeout":                 true,
-		"tls":                     true,
-		"writetimeout":            true,
-	}
-
 	options, err := SplitConnectOptions(connectOpts)
 	if err != nil {
 		return "", err
@@ -243,7 +300,7 @@ func RealConnectOptions(connectOpts string) (string, error) {
 	// This is done via regular expressions substitution in order to keep the
 	// string in its original order.
 	for name, value := range options {
-		if ignored[strings.ToLower(name)] {
+		if IsDriverParam(name) {
 			re, err := regexp.Compile(fmt.Sprintf(`%s=%s(,|$)`, regexp.QuoteMeta(name), regexp.QuoteMeta(value)))
 			if err != nil {
 				return "", err
@@ -0,0 +1,163 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefixes of host values which are resolved via service discovery
+const (
+	srvHostPrefix    = "srv:"
+	consulHostPrefix = "consul:"
+)
+
+// lookupSRV performs DNS SRV lookups. It is a var only to permit overriding in
+// tests.
+var lookupSRV = net.LookupSRV
+
+var discoveryCache struct {
+	sync.Mutex
+	hosts map[string][]string
+}
+
+func init() {
+	discoveryCache.hosts = make(map[string][]string)
+}
+
+// IsDiscoveryHost returns true if host refers to a DNS SRV record or Consul
+// service, rather than a hostname or address.
+func IsDiscoveryHost(host string) bool {
+	return strings.HasPrefix(host, srvHostPrefix) || strings.HasPrefix(host, consulHostPrefix)
+}
+
+// DiscoverHosts resolves host into a list of "address:port" strings, if host
+// is of format "srv:NAME" or "consul:SERVICE[?tag=TAG&...]". Any other host
+// value is returned as-is in a single-element slice. Results are cached, so
+// that each distinct host is only resolved once per run, even when many
+// directories share it. consulAddr is the address of the Consul HTTP API; if
+// empty, the CONSUL_HTTP_ADDR environment variable is used, or the local
+// Consul agent if that is also unset.
+func DiscoverHosts(host, consulAddr string) ([]string, error) {
+	if !IsDiscoveryHost(host) {
+		return []string{host}, nil
+	}
+	key := fmt.Sprintf("%s|%s", host, consulAddr)
+	discoveryCache.Lock()
+	defer discoveryCache.Unlock()
+	if hosts, already := discoveryCache.hosts[key]; already {
+		return hosts, nil
+	}
+	var hosts []string
+	var err error
+	if strings.HasPrefix(host, srvHostPrefix) {
+		hosts, err = srvHosts(strings.TrimPrefix(host, srvHostPrefix))
+	} else {
+		hosts, err = consulHosts(strings.TrimPrefix(host, consulHostPrefix), consulAddr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to resolve host %s: %s", host, err)
+	} else if len(hosts) == 0 {
+		return nil, fmt.Errorf("Unable to resolve host %s: no addresses found", host)
+	}
+	discoveryCache.hosts[key] = hosts
+	return hosts, nil
+}
+
+// srvHosts looks up the DNS SRV record name, returning its targets in order of
+// priority.
+func srvHosts(name string) ([]string, error) {
+	_, records, err := lookupSRV("", "", name)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(records))
+	for _, rec := range records {
+		target := strings.TrimSuffix(rec.Target, ".")
+		hosts = append(hosts, net.JoinHostPort(target, strconv.Itoa(int(rec.Port))))
+	}
+	return hosts, nil
+}
+
+// consulServiceEntry is the subset of the JSON response format of Consul's
+// health service endpoint used by consulHosts.
+type consulServiceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+// consulHosts queries the Consul HTTP API for healthy instances of a service.
+// spec is a service name, optionally followed by a query string of tags to
+// filter on, for example "mysql-shard?tag=primary".
+func consulHosts(spec, consulAddr string) ([]string, error) {
+	service, query := spec, ""
+	if pos := strings.IndexByte(spec, '?'); pos >= 0 {
+		service, query = spec[:pos], spec[pos+1:]
+	}
+	if service == "" {
+		return nil, errors.New("no Consul service name supplied")
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Consul tag filter: %s", err)
+	}
+	for name := range params {
+		if name != "tag" {
+			return nil, fmt.Errorf("invalid Consul tag filter: unsupported parameter %s", name)
+		}
+	}
+	params.Set("passing", "true")
+
+	if consulAddr == "" {
+		consulAddr = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if consulAddr == "" {
+		consulAddr = "127.0.0.1:8500"
+	}
+	if !strings.Contains(consulAddr, "://") {
+		consulAddr = "http://" + consulAddr
+	}
+	reqURL := fmt.Sprintf("%s/v1/health/service/%s?%s", strings.TrimRight(consulAddr, "/"), url.PathEscape(service), params.Encode())
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Consul returned HTTP %d", resp.StatusCode)
+	}
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("Unable to parse Consul response: %s", err)
+	}
+	hosts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address
+		}
+		hosts = append(hosts, net.JoinHostPort(address, strconv.Itoa(entry.Service.Port)))
+	}
+	return hosts, nil
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestIsDiscoveryHost(t *testing.T) {
+	cases := map[string]bool{
+		"srv:_mysql._tcp.db.example.com": true,
+		"consul:mysql-shard1":            true,
+		"consul:mysql?tag=primary":       true,
+		"db.example.com":                 false,
+		"127.0.0.1":                      false,
+		"/var/run/mysqld/mysqld.sock":    false,
+		"":                               false,
+	}
+	for host, expected := range cases {
+		if actual := IsDiscoveryHost(host); actual != expected {
+			t.Errorf("Expected IsDiscoveryHost(%q) to return %t, instead found %t", host, expected, actual)
+		}
+	}
+}
+
+func TestDiscoverHostsSRV(t *testing.T) {
+	origLookup := lookupSRV
+	defer func() { lookupSRV = origLookup }()
+	var lookupCount int
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		lookupCount++
+		switch name {
+		case "_mysql._tcp.db.example.com":
+			return "", []*net.SRV{
+				{Target: "db1.example.com.", Port: 3306},
+				{Target: "db2.example.com.", Port: 3307},
+			}, nil
+		case "_mysql._tcp.empty.example.com":
+			return "", []*net.SRV{}, nil
+		}
+		return "", nil, errors.New("no such host")
+	}
+
+	expected := []string{"db1.example.com:3306", "db2.example.com:3307"}
+	for n := 0; n < 2; n++ {
+		hosts, err := DiscoverHosts("srv:_mysql._tcp.db.example.com", "")
+		if err != nil {
+			t.Fatalf("Unexpected error from DiscoverHosts: %v", err)
+		} else if !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("Expected DiscoverHosts to return %v, instead found %v", expected, hosts)
+		}
+	}
+	if lookupCount != 1 {
+		t.Errorf("Expected repeated lookups to be cached, but lookupSRV was called %d times", lookupCount)
+	}
+
+	for _, host := range []string{"srv:_mysql._tcp.empty.example.com", "srv:_mysql._tcp.missing.example.com"} {
+		if hosts, err := DiscoverHosts(host, ""); err == nil {
+			t.Errorf("Expected DiscoverHosts(%q) to return an error, instead found %v", host, hosts)
+		}
+	}
+
+	// Non-discovery hosts should be returned as-is, without any lookup
+	lookupCount = 0
+	if hosts, err := DiscoverHosts("db.example.com", ""); err != nil || !reflect.DeepEqual(hosts, []string{"db.example.com"}) {
+		t.Errorf("Unexpected return from DiscoverHosts on plain host: %v, %v", hosts, err)
+	} else if lookupCount > 0 {
+		t.Error("Expected plain host to not be looked up, but lookupSRV was called")
+	}
+}
+
+func TestDiscoverHostsConsul(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("passing") != "true" {
+			http.Error(w, "expected passing filter", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/health/service/mysql-shard1":
+			if r.URL.Query().Get("tag") == "primary" {
+				fmt.Fprint(w, `[{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "", "Port": 3306}}]`)
+			} else {
+				fmt.Fprint(w, `[{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "", "Port": 3306}},
+					{"Node": {"Address": "10.0.0.2"}, "Service": {"Address": "10.0.1.2", "Port": 3307}}]`)
+			}
+		case "/v1/health/service/empty":
+			fmt.Fprint(w, `[]`)
+		case "/v1/health/service/garbage":
+			fmt.Fprint(w, `{not json`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cases := map[string][]string{
+		"consul:mysql-shard1":             {"10.0.0.1:3306", "10.0.1.2:3307"},
+		"consul:mysql-shard1?tag=primary": {"10.0.0.1:3306"},
+	}
+	for host, expected := range cases {
+		hosts, err := DiscoverHosts(host, server.URL)
+		if err != nil {
+			t.Errorf("Unexpected error from DiscoverHosts(%q): %v", host, err)
+		} else if !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("Expected DiscoverHosts(%q) to return %v, instead found %v", host, expected, hosts)
+		}
+	}
+
+	// Address without scheme should have http:// added
+	if hosts, err := DiscoverHosts("consul:mysql-shard1?tag=primary", server.Listener.Addr().String()); err != nil || len(hosts) != 1 {
+		t.Errorf("Unexpected return from DiscoverHosts with scheme-less Consul address: %v, %v", hosts, err)
+	}
+
+	for _, host := range []string{"consul:empty", "consul:garbage", "consul:missing", "consul:", "consul:mysql-shard1?dc=east", "consul:mysql-shard1?tag=%zz"} {
+		if hosts, err := DiscoverHosts(host, server.URL); err == nil {
+			t.Errorf("Expected DiscoverHosts(%q) to return an error, instead found %v", host, hosts)
+		}
+	}
+}
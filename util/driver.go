@@ -0,0 +1,75 @@
+package util
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tokenAuthDriver wraps the MySQL driver, replacing the password of each new
+// connection with a short-lived token obtained at the time of connecting.
+// tengo replaces pooled connections frequently, so a token embedded once in a
+// DSN would cause new connections to fail once the token expires.
+type tokenAuthDriver struct {
+	tokenFunc func(cfg *mysql.Config) (string, error)
+}
+
+// Open obtains a token for the DSN's user and address, substitutes it for the
+// DSN's password, and then opens a connection using the MySQL driver.
+func (d tokenAuthDriver) Open(dsn string) (driver.Conn, error) {
+	dsn, err := d.dsnWithToken(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return mysql.MySQLDriver{}.Open(dsn)
+}
+
+// dsnWithToken returns dsn with its password replaced by a token from
+// d.tokenFunc.
+func (d tokenAuthDriver) dsnWithToken(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	prefix := cfg.User + ":" + cfg.Passwd + "@"
+	if !strings.HasPrefix(dsn, prefix) {
+		return dsn, nil
+	}
+	token, err := d.tokenFunc(cfg)
+	if err != nil {
+		return "", err
+	}
+	return cfg.User + ":" + token + "@" + dsn[len(prefix):], nil
+}
+
+var tokenAuthDrivers struct {
+	sync.Mutex
+	names map[string]bool
+}
+
+func init() {
+	tokenAuthDrivers.names = make(map[string]bool)
+}
+
+// registerTokenAuthDriver registers a tokenAuthDriver under the supplied
+// name, if not already registered, and returns the name.
+func registerTokenAuthDriver(name string, tokenFunc func(cfg *mysql.Config) (string, error)) string {
+	tokenAuthDrivers.Lock()
+	defer tokenAuthDrivers.Unlock()
+	if !tokenAuthDrivers.names[name] {
+		sql.Register(name, tokenAuthDriver{tokenFunc: tokenFunc})
+		tokenAuthDrivers.names[name] = true
+	}
+	return name
+}
+
+// isTokenAuthDriver returns true if name was registered by
+// registerTokenAuthDriver.
+func isTokenAuthDriver(name string) bool {
+	tokenAuthDrivers.Lock()
+	defer tokenAuthDrivers.Unlock()
+	return tokenAuthDrivers.names[name]
+}
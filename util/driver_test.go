@@ -0,0 +1,38 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTokenAuthDriverDSN(t *testing.T) {
+	var calls int
+	d := tokenAuthDriver{tokenFunc: func(cfg *mysql.Config) (string, error) {
+		calls++
+		if cfg.Addr != "some.host:3306" {
+			return "", errors.New("unexpected addr " + cfg.Addr)
+		}
+		return "token" + cfg.User, nil
+	}}
+	dsn, err := d.dsnWithToken("bob:oldtoken@tcp(some.host:3306)/?tls=true&allowCleartextPasswords=true")
+	if err != nil || dsn != "bob:tokenbob@tcp(some.host:3306)/?tls=true&allowCleartextPasswords=true" {
+		t.Errorf("Unexpected return from dsnWithToken: %q, %v", dsn, err)
+	}
+	if _, err := d.dsnWithToken("bob:oldtoken@tcp(other.host:3306)/"); err == nil {
+		t.Error("Expected error from tokenFunc to be returned, but it was not")
+	}
+	if calls != 2 {
+		t.Errorf("Expected tokenFunc to be called twice, instead found %d", calls)
+	}
+
+	// Driver names are only registered once, and are recognized afterwards
+	name := registerTokenAuthDriver("mysql-test-token", d.tokenFunc)
+	if name2 := registerTokenAuthDriver("mysql-test-token", d.tokenFunc); name2 != name || !isTokenAuthDriver(name) {
+		t.Errorf("Unexpected driver registration behavior: %q %q %t", name, name2, isTokenAuthDriver(name))
+	}
+	if isTokenAuthDriver("mysql") {
+		t.Error("Expected mysql driver to not be a tokenAuthDriver")
+	}
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// MySQL 8 and Percona Server display the ENCRYPTION table option in the table
+// options of SHOW CREATE TABLE, and it may also be present in
+// information_schema.tables.create_options. Percona Server additionally
+// supports ENCRYPTION_KEY_ID.
+var (
+	encryptionRegexp      = regexp.MustCompile(` ?ENCRYPTION=(?:'([^']*)'|"([^"]*)")`)
+	encryptionKeyIDRegexp = regexp.MustCompile(` ?ENCRYPTION_KEY_ID=(\d+)`)
+)
+
+// TableEncryption returns the values of the ENCRYPTION and ENCRYPTION_KEY_ID
+// table options of table, or empty strings for any option which is not
+// present.
+func TableEncryption(table *tengo.Table) (encryption, keyID string) {
+	options := tableOptionsText(table.CreateStatement)
+	if matches := encryptionRegexp.FindStringSubmatch(options); matches != nil {
+		encryption = matches[1] + matches[2]
+	}
+	if matches := encryptionKeyIDRegexp.FindStringSubmatch(options); matches != nil {
+		keyID = matches[1]
+	}
+	return encryption, keyID
+}
+
+// TableEncrypted returns true if table's ENCRYPTION option enables encryption.
+// Besides 'Y', Percona Server permits 'KEYRING' to use keyring encryption.
+// MariaDB's ENCRYPTED=YES option is also recognized.
+func TableEncrypted(table *tengo.Table) bool {
+	encryption, _ := TableEncryption(table)
+	encryption = strings.ToUpper(encryption)
+	return encryption == "Y" || encryption == "KEYRING" || MariaDBTableOptions(table)[MariaDBEncrypted] == "YES"
+}
+
+// StripTableEncryption returns a shallow copy of table, with the ENCRYPTION and
+// ENCRYPTION_KEY_ID table options removed from its CreateStatement and
+// CreateOptions.
+func StripTableEncryption(table *tengo.Table) *tengo.Table {
+	stripped := *table
+	stripped.CreateStatement = StripEncryptionOptions(table.CreateStatement)
+	options := encryptionRegexp.ReplaceAllString(table.CreateOptions, "")
+	stripped.CreateOptions = strings.TrimSpace(encryptionKeyIDRegexp.ReplaceAllString(options, ""))
+	return &stripped
+}
+
+// StripEncryptionOptions returns createStatement with the ENCRYPTION and
+// ENCRYPTION_KEY_ID table options removed. Only the table options are
+// affected, so a column definition containing similar text is left as-is.
+func StripEncryptionOptions(createStatement string) string {
+	pos := strings.LastIndex(createStatement, "\n)")
+	if pos < 0 {
+		return createStatement
+	}
+	options := encryptionRegexp.ReplaceAllString(createStatement[pos:], "")
+	return createStatement[:pos] + encryptionKeyIDRegexp.ReplaceAllString(options, "")
+}
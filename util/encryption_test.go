@@ -0,0 +1,51 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestTableEncryption(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+	table := &tengo.Table{
+		Name:               "secrets",
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{idCol},
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		Comment:            "Secrets",
+	}
+	plain := table.GeneratedCreateStatement(tengo.FlavorMySQL80)
+	table.CreateStatement = plain
+	if encryption, keyID := TableEncryption(table); encryption != "" || keyID != "" {
+		t.Errorf("Unexpected result from TableEncryption: %q, %q", encryption, keyID)
+	}
+	if TableEncrypted(table) {
+		t.Error("Expected table without ENCRYPTION option to not be considered encrypted")
+	}
+
+	table.CreateOptions = `ENCRYPTION="Y"`
+	table.CreateStatement = strings.Replace(plain, " COMMENT=", " ENCRYPTION='Y' ENCRYPTION_KEY_ID=2 COMMENT=", 1)
+	if encryption, keyID := TableEncryption(table); encryption != "Y" || keyID != "2" {
+		t.Errorf("Unexpected result from TableEncryption: %q, %q", encryption, keyID)
+	}
+	if !TableEncrypted(table) {
+		t.Error("Expected table with ENCRYPTION='Y' to be considered encrypted")
+	}
+	stripped := StripTableEncryption(table)
+	if stripped.CreateStatement != plain || stripped.CreateOptions != "" {
+		t.Errorf("StripTableEncryption did not remove exactly the encryption options: %s", stripped.CreateStatement)
+	}
+	if table.CreateStatement == plain {
+		t.Error("StripTableEncryption unexpectedly modified the original table")
+	}
+
+	table.CreateStatement = strings.Replace(plain, " COMMENT=", " ENCRYPTION='N' COMMENT=", 1)
+	if TableEncrypted(table) {
+		t.Error("Expected table with ENCRYPTION='N' to not be considered encrypted")
+	}
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/mybase"
+)
+
+// envVarRef is a regexp for detecting references in ExpandEnv(): either an
+// environment variable reference of format ${VAR}, ${VAR:-default}, or
+// ${VAR:?message}; or an OS keyring reference of format ${keyring:ITEM}; or an
+// escaped literal $${.
+var envVarRef = regexp.MustCompile(`\$\$\{|\$\{(?:keyring:([^}]+)|([A-Za-z_][A-Za-z0-9_]*)(?:(:[-?])([^}]*))?)\}`)
+
+// unexpandedOptions lists options which are never subject to ExpandEnv in
+// option files. Their values are shell command templates, so any ${VAR}
+// references are left for the shell to expand when the command is run.
+var unexpandedOptions = map[string]bool{
+	"alter-wrapper":    true,
+	"ddl-wrapper":      true,
+	"host-wrapper":     true,
+	"lint-plugins":     true,
+	"password-command": true,
+}
+
+// keyringExpansionDisabled controls whether ExpandEnv leaves ${keyring:ITEM}
+// references as-is, instead of querying the OS keyring.
+var keyringExpansionDisabled bool
+
+// DisableKeyringExpansion causes subsequent calls to ExpandEnv to leave
+// ${keyring:ITEM} references unexpanded, so that the OS keyring is never
+// queried. This is intended for commands which validate option files without
+// using their values to connect to anything.
+func DisableKeyringExpansion() {
+	keyringExpansionDisabled = true
+}
+
+// ExpandEnv replaces environment variable references in value. The following
+// forms are supported, with the same semantics as in a POSIX shell:
+//
+//	${VAR}            the value of VAR, or an empty string if unset
+//	${VAR:-default}   the value of VAR, or default if VAR is unset or empty
+//	${VAR:?message}   the value of VAR; returns an error including message if
+//	                  VAR is unset or empty
+//
+// A bare $VAR without braces is not expanded, nor is a $ character which is
+// not followed by a well-formed reference. $${ is replaced with a literal ${,
+// permitting text such as $${VAR} to be used without expansion.
+//
+// Additionally, ${keyring:ITEM} is replaced with the secret stored for ITEM in
+// the OS keyring; see KeyringSecret. An error is returned if the secret cannot
+// be obtained. All references are expanded in a single pass over value, so
+// text substituted from an environment variable or keyring secret is never
+// itself expanded. In particular, a ${keyring:ITEM} reference inside an
+// environment variable's value does not cause a keyring lookup.
+func ExpandEnv(value string) (string, error) {
+	var err error
+	result := envVarRef.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		matches := envVarRef.FindStringSubmatch(ref)
+		if item := matches[1]; item != "" {
+			if keyringExpansionDisabled {
+				return ref
+			}
+			secret, keyringErr := KeyringSecret(item)
+			if keyringErr != nil && err == nil {
+				err = keyringErr
+			}
+			return secret
+		}
+		name, operator, arg := matches[2], matches[3], matches[4]
+		envValue := os.Getenv(name)
+		if envValue != "" {
+			return envValue
+		} else if operator == ":-" {
+			return arg
+		} else if operator == ":?" && err == nil {
+			if arg == "" {
+				arg = "required but not set"
+			}
+			err = fmt.Errorf("Environment variable %s: %s", name, arg)
+		}
+		return envValue
+	})
+	return result, err
+}
+
+// EnvExpandedFile wraps a *mybase.File, expanding environment variable
+// references in its option values using ExpandEnv, aside from options listed
+// in unexpandedOptions. The underlying File is left unmodified, so that
+// writing it back to disk preserves the references.
+type EnvExpandedFile struct {
+	*mybase.File
+}
+
+// NewEnvExpandedFile returns an EnvExpandedFile wrapping f, which must already
+// be parsed. The values of all options relevant to cfg's command are expanded
+// up-front, so that an error can be returned for any missing required
+// variables in the file's currently-selected sections.
+func NewEnvExpandedFile(f *mybase.File, cfg *mybase.Config) (*EnvExpandedFile, error) {
+	for name := range cfg.CLI.Command.Options() {
+		if unexpandedOptions[name] {
+			continue
+		}
+		if value, ok := f.OptionValue(name); ok {
+			if _, err := ExpandEnv(value); err != nil {
+				return nil, fmt.Errorf("%s: option %s: %s", f.Path(), name, err)
+			}
+		}
+	}
+	return &EnvExpandedFile{File: f}, nil
+}
+
+// OptionValue returns the expanded value for the requested option. This
+// satisfies the mybase.OptionValuer interface, allowing EnvExpandedFile to be
+// used as an option source in a mybase.Config.
+func (ef *EnvExpandedFile) OptionValue(optionName string) (string, bool) {
+	value, ok := ef.File.OptionValue(optionName)
+	if ok && strings.Contains(value, "${") && !unexpandedOptions[optionName] {
+		value, _ = ExpandEnv(value) // errors were already reported by NewEnvExpandedFile
+	}
+	return value, ok
+}
@@ -0,0 +1,152 @@
+package util
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skeema/mybase"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("SKEEMA_TEST_HOST", "db1.example.com")
+	os.Setenv("SKEEMA_TEST_EMPTY", "")
+	os.Unsetenv("SKEEMA_TEST_UNSET")
+	defer func() {
+		os.Unsetenv("SKEEMA_TEST_HOST")
+		os.Unsetenv("SKEEMA_TEST_EMPTY")
+	}()
+
+	cases := map[string]string{
+		"":                                        "",
+		"no refs here":                            "no refs here",
+		"${SKEEMA_TEST_HOST}":                     "db1.example.com",
+		"${SKEEMA_TEST_HOST}:3306":                "db1.example.com:3306",
+		"${SKEEMA_TEST_UNSET}":                    "",
+		"${SKEEMA_TEST_EMPTY:-fallback}":          "fallback",
+		"${SKEEMA_TEST_UNSET:-}":                  "",
+		"${SKEEMA_TEST_HOST:-fallback}":           "db1.example.com",
+		"${SKEEMA_TEST_HOST:?must be set}":        "db1.example.com",
+		"$SKEEMA_TEST_HOST":                       "$SKEEMA_TEST_HOST",
+		"${not valid}":                            "${not valid}",
+		"{SCHEMA} on ${SKEEMA_TEST_UNSET:-local}": "{SCHEMA} on local",
+		"$${SKEEMA_TEST_HOST}":                    "${SKEEMA_TEST_HOST}",
+		"$${SKEEMA_TEST_UNSET:?}":                 "${SKEEMA_TEST_UNSET:?}",
+		"$$${SKEEMA_TEST_HOST}":                   "$${SKEEMA_TEST_HOST}",
+		"cost: $$5":                               "cost: $$5",
+	}
+	for input, expected := range cases {
+		if actual, err := ExpandEnv(input); err != nil {
+			t.Errorf("Unexpected error from ExpandEnv(%q): %s", input, err)
+		} else if actual != expected {
+			t.Errorf("Expected ExpandEnv(%q) to return %q, instead found %q", input, expected, actual)
+		}
+	}
+
+	for _, input := range []string{"${SKEEMA_TEST_UNSET:?}", "${SKEEMA_TEST_EMPTY:?must be set}", "x${SKEEMA_TEST_HOST}${SKEEMA_TEST_UNSET:?}"} {
+		if _, err := ExpandEnv(input); err == nil {
+			t.Errorf("Expected ExpandEnv(%q) to return an error, but it did not", input)
+		}
+	}
+}
+
+func TestEnvExpandedFile(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmd := mybase.NewCommand("diff", "", "", nil)
+	cmd.AddArg("environment", "production", false)
+	cmdSuite.AddSubCommand(cmd)
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+
+	os.Setenv("SKEEMA_TEST_USER", "ci")
+	defer os.Unsetenv("SKEEMA_TEST_USER")
+	os.Unsetenv("SKEEMA_TEST_UNSET")
+
+	contents := "user=${SKEEMA_TEST_USER}\nhost=${SKEEMA_TEST_UNSET:-localhost}\npassword-command=vault read ${SKEEMA_TEST_UNSET:?}\n\n[staging]\npassword=${SKEEMA_TEST_UNSET:?password required}\n"
+	ioutil.WriteFile("envexpand.cnf", []byte(contents), 0777)
+	defer os.Remove("envexpand.cnf")
+	f := mybase.NewFile("envexpand.cnf")
+	if err := f.Read(); err != nil {
+		t.Fatalf("Unexpected error reading file: %s", err)
+	}
+	if err := f.Parse(cfg); err != nil {
+		t.Fatalf("Unexpected error parsing file: %s", err)
+	}
+
+	// Required var in an unselected section should not cause an error
+	f.UseSection("production")
+	ef, err := NewEnvExpandedFile(f, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewEnvExpandedFile: %s", err)
+	}
+	cfg.AddSource(ef)
+	if actual := cfg.Get("user"); actual != "ci" {
+		t.Errorf("Expected user to be expanded to %q, instead found %q", "ci", actual)
+	}
+	if actual := cfg.Get("host"); actual != "localhost" {
+		t.Errorf("Expected host to be expanded to %q, instead found %q", "localhost", actual)
+	}
+	if actual := cfg.Get("password-command"); actual != "vault read ${SKEEMA_TEST_UNSET:?}" {
+		t.Errorf("Expected password-command to be left unexpanded, instead found %q", actual)
+	}
+	if raw, _ := f.OptionValue("user"); raw != "${SKEEMA_TEST_USER}" {
+		t.Errorf("Expected underlying file to be unmodified, instead found user=%q", raw)
+	}
+
+	// Once the section containing the required var is selected, expect an error
+	f.UseSection("staging")
+	if _, err := NewEnvExpandedFile(f, cfg); err == nil {
+		t.Error("Expected error from NewEnvExpandedFile for missing required var, but err was nil")
+	}
+}
+
+func TestExpandEnvKeyring(t *testing.T) {
+	origLookup := keyringLookup
+	defer func() {
+		keyringLookup = origLookup
+	}()
+	var lookupCount int
+	keyringLookup = func(item string) (string, error) {
+		lookupCount++
+		switch item {
+		case "prod-db":
+			return "s3cr3t${HOME}", nil
+		case "empty":
+			return "", nil
+		}
+		return "", errors.New("item not found")
+	}
+
+	for n := 0; n < 2; n++ {
+		if actual, err := ExpandEnv("${keyring:prod-db}"); err != nil || actual != "s3cr3t${HOME}" {
+			t.Errorf("Unexpected result from ExpandEnv: %q / %v", actual, err)
+		}
+	}
+	if lookupCount != 1 {
+		t.Errorf("Expected keyring lookups to be cached, but lookup was called %d times", lookupCount)
+	}
+	for _, input := range []string{"${keyring:bogus}", "${keyring:empty}"} {
+		if _, err := ExpandEnv(input); err == nil {
+			t.Errorf("Expected ExpandEnv(%q) to return an error, but it did not", input)
+		}
+	}
+
+	// Keyring references are only expanded in literal text, not in the values of
+	// environment variables
+	os.Setenv("SKEEMA_TEST_KEYRING_REF", "${keyring:bogus}")
+	defer os.Unsetenv("SKEEMA_TEST_KEYRING_REF")
+	lookupCount = 0
+	if actual, err := ExpandEnv("x${SKEEMA_TEST_KEYRING_REF}"); err != nil || actual != "x${keyring:bogus}" || lookupCount != 0 {
+		t.Errorf("Unexpected result from ExpandEnv with keyring reference in env var: %q / %v / %d lookups", actual, err, lookupCount)
+	}
+
+	// Keyring references are left as-is once expansion is disabled
+	keyringExpansionDisabled = true
+	defer func() {
+		keyringExpansionDisabled = false
+	}()
+	if actual, err := ExpandEnv("${keyring:bogus}"); err != nil || actual != "${keyring:bogus}" || lookupCount != 0 {
+		t.Errorf("Unexpected result from ExpandEnv with keyring expansion disabled: %q / %v / %d lookups", actual, err, lookupCount)
+	}
+}
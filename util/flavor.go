@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/skeema/tengo"
+)
+
+// flavorFeature describes a flavor-dependent behavior which affects how
+// Skeema introspects, diffs, or generates DDL for schemas. describe returns a
+// description of the behavior in the supplied flavor.
+type flavorFeature struct {
+	name     string
+	describe func(tengo.Flavor) string
+}
+
+func supportedIf(supported bool) string {
+	if supported {
+		return "supported"
+	}
+	return "not supported"
+}
+
+var flavorFeatures = []flavorFeature{
+	{"database vendor", func(fl tengo.Flavor) string {
+		return fl.Vendor.String()
+	}},
+	{"data dictionary", func(fl tengo.Flavor) string {
+		if fl.HasDataDictionary() {
+			return "transactional data dictionary"
+		}
+		return "frm files"
+	}},
+	{"default utf8mb4 collation", func(fl tengo.Flavor) string {
+		return fl.DefaultUtf8mb4Collation()
+	}},
+	{"default ROW_FORMAT", func(fl tengo.Flavor) string {
+		return DefaultRowFormat(fl)
+	}},
+	{"innodb_file_format", func(fl tengo.Flavor) string {
+		return supportedIf(fl.HasInnoFileFormat())
+	}},
+	{"fractional-second temporal columns", func(fl tengo.Flavor) string {
+		return supportedIf(fl.FractionalTimestamps())
+	}},
+	{"default expressions and blob defaults", func(fl tengo.Flavor) string {
+		return supportedIf(fl.AllowDefaultExpression())
+	}},
+	{"generated columns", func(fl tengo.Flavor) string {
+		return supportedIf(fl.MySQLishMinVersion(5, 7) || fl.VendorMinVersion(tengo.VendorMariaDB, 10, 2))
+	}},
+	{"JSON columns", func(fl tengo.Flavor) string {
+		if fl.MySQLishMinVersion(5, 7) {
+			return "native type"
+		} else if fl.VendorMinVersion(tengo.VendorMariaDB, 10, 2) {
+			return "alias for LONGTEXT"
+		}
+		return "not supported"
+	}},
+	{"CHECK constraints", func(fl tengo.Flavor) string {
+		return supportedIf(fl.MySQLishMinVersion(8, 0) || fl.VendorMinVersion(tengo.VendorMariaDB, 10, 2))
+	}},
+	{"table encryption options", func(fl tengo.Flavor) string {
+		if fl.Vendor == tengo.VendorMariaDB {
+			return "ENCRYPTED and ENCRYPTION_KEY_ID"
+		} else if fl.MySQLishMinVersion(5, 7) {
+			return "ENCRYPTION"
+		}
+		return "not supported"
+	}},
+	{"Percona column compression", func(fl tengo.Flavor) string {
+		return supportedIf(fl.VendorMinVersion(tengo.VendorPercona, 5, 6))
+	}},
+}
+
+// FlavorDifferences returns a description of each flavor-dependent behavior
+// which differs between the configured flavor and the actual flavor, for
+// example "default ROW_FORMAT: COMPACT (configured) vs DYNAMIC (actual)". The
+// result is empty if either flavor is unknown, or if the flavors differ only
+// in ways that do not affect Skeema's handling of schemas.
+func FlavorDifferences(configured, actual tengo.Flavor) (diffs []string) {
+	if !configured.Known() || !actual.Known() || configured == actual {
+		return nil
+	}
+	for _, feature := range flavorFeatures {
+		if conf, act := feature.describe(configured), feature.describe(actual); conf != act {
+			diffs = append(diffs, fmt.Sprintf("%s: %s (configured) vs %s (actual)", feature.name, conf, act))
+		}
+	}
+	return diffs
+}
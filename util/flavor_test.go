@@ -0,0 +1,40 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestFlavorDifferences(t *testing.T) {
+	if diffs := FlavorDifferences(tengo.FlavorMySQL57, tengo.FlavorMySQL57); len(diffs) != 0 {
+		t.Errorf("Expected no differences between identical flavors, instead found %v", diffs)
+	}
+	if diffs := FlavorDifferences(tengo.FlavorUnknown, tengo.FlavorMySQL80); len(diffs) != 0 {
+		t.Errorf("Expected no differences when a flavor is unknown, instead found %v", diffs)
+	}
+
+	diffs := FlavorDifferences(tengo.FlavorMySQL57, tengo.FlavorMySQL80)
+	expected := []string{
+		"data dictionary: frm files (configured) vs transactional data dictionary (actual)",
+		"default utf8mb4 collation: utf8mb4_general_ci (configured) vs utf8mb4_0900_ai_ci (actual)",
+		"innodb_file_format: supported (configured) vs not supported (actual)",
+		"CHECK constraints: not supported (configured) vs supported (actual)",
+	}
+	if strings.Join(diffs, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected differences between MySQL 5.7 and 8.0:\n%s", strings.Join(diffs, "\n"))
+	}
+
+	diffs = FlavorDifferences(tengo.FlavorPercona57, tengo.FlavorMySQL57)
+	if len(diffs) != 2 || !strings.HasPrefix(diffs[0], "database vendor:") || !strings.HasPrefix(diffs[1], "Percona column compression:") {
+		t.Errorf("Unexpected differences between Percona Server 5.7 and MySQL 5.7: %v", diffs)
+	}
+
+	diffs = FlavorDifferences(tengo.FlavorMySQL56, tengo.FlavorMariaDB102)
+	for _, diff := range diffs {
+		if strings.HasPrefix(diff, "JSON columns:") && diff != "JSON columns: not supported (configured) vs alias for LONGTEXT (actual)" {
+			t.Errorf("Unexpected JSON difference: %s", diff)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skeema/mybase"
+)
+
+// AddOptionFile adds f as an option source to cfg, expanding any environment
+// variable references in its values. If f uses the include option, the
+// included files are added first, so that f's own values take precedence over
+// included ones. Included files may themselves include other files.
+// f must already be parsed, and have its section(s) selected.
+func AddOptionFile(cfg *mybase.Config, f *mybase.File) error {
+	return addOptionFile(cfg, f, make(map[string]bool))
+}
+
+func addOptionFile(cfg *mybase.Config, f *mybase.File, including map[string]bool) error {
+	expandedFile, err := NewEnvExpandedFile(f, cfg)
+	if err != nil {
+		return err
+	}
+	includeValue, _ := expandedFile.OptionValue("include")
+	if includeValue != "" {
+		including[f.Path()] = true
+		defer delete(including, f.Path())
+	}
+	for _, includePath := range strings.Split(includeValue, ",") {
+		if includePath = strings.TrimSpace(includePath); includePath == "" {
+			continue
+		}
+		includePath = resolveIncludePath(includePath, filepath.Dir(f.Path()))
+		if including[includePath] {
+			return fmt.Errorf("%s: include cycle detected, since %s is already being included", f.Path(), includePath)
+		}
+		included := mybase.NewFile(includePath)
+		if err := included.Read(); err != nil {
+			return fmt.Errorf("%s: Unable to include %s: %s", f.Path(), includePath, err)
+		}
+		if err := included.Parse(cfg); err != nil {
+			return err
+		}
+		if cfg.CLI.Command.HasArg("environment") {
+			_ = included.UseSection(cfg.Get("environment")) // doesn't matter if section doesn't exist
+		}
+		if err := addOptionFile(cfg, included, including); err != nil {
+			return err
+		}
+	}
+	cfg.AddSource(expandedFile)
+	return nil
+}
+
+// resolveIncludePath returns an absolute path for includePath. A leading ~/
+// is interpreted as the user's home directory; other relative paths are
+// interpreted relative to baseDir, the directory of the including file.
+func resolveIncludePath(includePath, baseDir string) string {
+	if strings.HasPrefix(includePath, "~/") {
+		if home := os.Getenv("HOME"); home != "" {
+			return filepath.Join(home, includePath[2:])
+		}
+	}
+	if !filepath.IsAbs(includePath) {
+		includePath = filepath.Join(baseDir, includePath)
+	}
+	return filepath.Clean(includePath)
+}
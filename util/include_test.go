@@ -0,0 +1,73 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skeema/mybase"
+)
+
+func TestAddOptionFile(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmd := mybase.NewCommand("diff", "", "", nil)
+	cmd.AddArg("environment", "production", false)
+	cmdSuite.AddSubCommand(cmd)
+
+	os.MkdirAll("include-test/shared", 0777)
+	defer os.RemoveAll("include-test")
+	writeFile := func(name, contents string) {
+		t.Helper()
+		if err := ioutil.WriteFile("include-test/"+name, []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write file: %s", err)
+		}
+	}
+	readFile := func(name string) *mybase.File {
+		t.Helper()
+		cwd, _ := os.Getwd()
+		f := mybase.NewFile(cwd, "include-test", name)
+		if err := f.Read(); err != nil {
+			t.Fatalf("Unable to read file: %s", err)
+		}
+		return f
+	}
+	writeFile("shared/defaults", "user=shared\ntemp-schema=_shared_tmp\ninclude=hosts\n\n[production]\nconnect-options=\"innodb_lock_wait_timeout=60\"\n")
+	writeFile("shared/hosts", "host-wrapper=/usr/local/bin/lookup {HOST}\n")
+	writeFile(".skeema", "include=shared/defaults\nuser=mine\n")
+
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+	f := readFile(".skeema")
+	f.Parse(cfg)
+	f.UseSection("production")
+	if err := AddOptionFile(cfg, f); err != nil {
+		t.Fatalf("Unexpected error from AddOptionFile: %s", err)
+	}
+	expected := map[string]string{
+		"user":            "mine",        // including file takes precedence over included
+		"temp-schema":     "_shared_tmp", // from included file
+		"host-wrapper":    "/usr/local/bin/lookup {HOST}",
+		"connect-options": "innodb_lock_wait_timeout=60", // from environment section of included file
+	}
+	for name, value := range expected {
+		if actual := cfg.Get(name); actual != value {
+			t.Errorf("Expected %s to be %q, instead found %q", name, value, actual)
+		}
+	}
+
+	// Include cycles and missing files should be errors
+	writeFile("shared/hosts", "include=defaults\n")
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+	f = readFile(".skeema")
+	f.Parse(cfg)
+	if err := AddOptionFile(cfg, f); err == nil {
+		t.Error("Expected error from AddOptionFile with include cycle, but err was nil")
+	}
+	writeFile(".skeema", "include=shared/missing\n")
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+	f = readFile(".skeema")
+	f.Parse(cfg)
+	if err := AddOptionFile(cfg, f); err == nil {
+		t.Error("Expected error from AddOptionFile with missing included file, but err was nil")
+	}
+}
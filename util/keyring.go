@@ -0,0 +1,43 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+)
+
+// keyringService is the service name under which Skeema's secrets are stored
+// in the OS keyring.
+const keyringService = "skeema"
+
+// keyringLookup obtains a secret from the OS keyring. It is a variable so that
+// tests may replace it.
+var keyringLookup = osKeyringLookup
+
+var keyringCache struct {
+	sync.Mutex
+	secrets map[string]string
+}
+
+func init() {
+	keyringCache.secrets = make(map[string]string)
+}
+
+// KeyringSecret returns the secret stored for item in the OS keyring: macOS
+// Keychain, libsecret (via secret-tool) on Linux and other Unix systems, or
+// Windows Credential Manager. Results are cached, so that the keyring is only
+// queried once per item, even when many directories reference it.
+func KeyringSecret(item string) (string, error) {
+	keyringCache.Lock()
+	defer keyringCache.Unlock()
+	if secret, already := keyringCache.secrets[item]; already {
+		return secret, nil
+	}
+	secret, err := keyringLookup(item)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read item %s from OS keyring: %s", item, err)
+	} else if secret == "" {
+		return "", fmt.Errorf("Item %s in OS keyring is empty", item)
+	}
+	keyringCache.secrets[item] = secret
+	return secret, nil
+}
@@ -0,0 +1,23 @@
+// +build !windows
+
+package util
+
+import (
+	"runtime"
+	"strings"
+)
+
+// osKeyringLookup shells out to the platform's keyring client: security on
+// macOS, or secret-tool (libsecret) elsewhere.
+func osKeyringLookup(item string) (string, error) {
+	command := "secret-tool lookup service {SERVICE} account {ITEM}"
+	if runtime.GOOS == "darwin" {
+		command = "security find-generic-password -s {SERVICE} -a {ITEM} -w"
+	}
+	s, err := NewInterpolatedShellOut(command, map[string]string{"SERVICE": keyringService, "ITEM": item})
+	if err != nil {
+		return "", err
+	}
+	output, err := s.RunCapture()
+	return strings.TrimRight(output, "\r\n"), err
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	advapi32      = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW = advapi32.NewProc("CredReadW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+const credTypeGeneric = 1
+
+// credential mirrors the CREDENTIALW struct of the Windows API.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// osKeyringLookup reads a generic credential from Windows Credential Manager,
+// with target name "skeema:<item>".
+func osKeyringLookup(item string) (string, error) {
+	target, err := windows.UTF16PtrFromString(keyringService + ":" + item)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	blob := (*[1 << 20]byte)(unsafe.Pointer(cred.CredentialBlob))[:cred.CredentialBlobSize:cred.CredentialBlobSize]
+
+	// Credentials stored via cmdkey or the Credential Manager UI are UTF-16
+	if len(blob)%2 != 0 {
+		return string(blob), nil
+	}
+	chars := make([]uint16, len(blob)/2)
+	for n := range chars {
+		chars[n] = uint16(blob[2*n]) | uint16(blob[2*n+1])<<8
+	}
+	return string(utf16.Decode(chars)), nil
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// MariaDB implements JSON as an alias for LONGTEXT with a json_valid CHECK
+// constraint. In MariaDB 10.4.3+ the constraint is shown inline in the column
+// definition; in prior releases it is a separate table-level constraint.
+var (
+	mariaJSONCheckRegexp      = regexp.MustCompile("CHECK \\(json_valid\\(`((?:[^`]|``)+)`\\)\\)")
+	mariaJSONConstraintRegexp = regexp.MustCompile(",\n  CONSTRAINT `(?:[^`]|``)+` CHECK \\(json_valid\\(`(?:[^`]|``)+`\\)\\)")
+	mariaLongtextRegexp       = regexp.MustCompile("^(  `(?:[^`]|``)+` )longtext(?: CHARACTER SET \\w+)?(?: COLLATE \\w+)?")
+)
+
+// mariaNativeTypes are MariaDB 10.7+ data types which are stored in a binary
+// format, despite being displayed as strings. MariaDB may report a character
+// set for these in information_schema, but never shows one in SHOW CREATE
+// TABLE.
+var mariaNativeTypes = map[string]bool{
+	"uuid":  true,
+	"inet4": true,
+	"inet6": true,
+}
+
+// MariaDB's InnoDB page compression and encryption options are engine-defined
+// table options. SHOW CREATE TABLE displays them wrapped in backticks, and
+// their values may be quoted, e.g. `PAGE_COMPRESSED`='ON' or `ENCRYPTED`=YES.
+var mariaTableOptionRegexp = regexp.MustCompile("(?i) ?`?(PAGE_COMPRESSED|PAGE_COMPRESSION_LEVEL|ENCRYPTED|ENCRYPTION_KEY_ID)`?=('[^']*'|\\w+)")
+
+// MariaDB table option names, as keys of the map returned by
+// MariaDBTableOptions.
+const (
+	MariaDBPageCompressed       = "PAGE_COMPRESSED"
+	MariaDBPageCompressionLevel = "PAGE_COMPRESSION_LEVEL"
+	MariaDBEncrypted            = "ENCRYPTED"
+	MariaDBEncryptionKeyID      = "ENCRYPTION_KEY_ID"
+)
+
+// MariaDBTableOptions returns a map of option name to value for each MariaDB
+// page compression or encryption table option of table. Values are returned
+// unquoted and uppercased, and boolean values of PAGE_COMPRESSED are
+// normalized to "ON" or "OFF".
+func MariaDBTableOptions(table *tengo.Table) map[string]string {
+	options := make(map[string]string)
+	for _, matches := range mariaTableOptionRegexp.FindAllStringSubmatch(tableOptionsText(table.CreateStatement), -1) {
+		name := strings.ToUpper(matches[1])
+		value := strings.ToUpper(strings.Trim(matches[2], "'"))
+		if name == MariaDBPageCompressed {
+			if value == "1" {
+				value = "ON"
+			} else if value == "0" {
+				value = "OFF"
+			}
+		}
+		options[name] = value
+	}
+	return options
+}
+
+// StripMariaDBTableOptions returns a shallow copy of table, with the supplied
+// MariaDB table options removed from its CreateStatement and CreateOptions. If
+// no names are supplied, all page compression and encryption options are
+// removed.
+func StripMariaDBTableOptions(table *tengo.Table, names ...string) *tengo.Table {
+	strip := func(text string) string {
+		return mariaTableOptionRegexp.ReplaceAllStringFunc(text, func(match string) string {
+			if len(names) == 0 {
+				return ""
+			}
+			name := mariaTableOptionRegexp.FindStringSubmatch(match)[1]
+			for _, n := range names {
+				if strings.EqualFold(n, name) {
+					return ""
+				}
+			}
+			return match
+		})
+	}
+	stripped := *table
+	if pos := strings.LastIndex(table.CreateStatement, "\n)"); pos >= 0 {
+		stripped.CreateStatement = table.CreateStatement[:pos] + strip(table.CreateStatement[pos:])
+	}
+	stripped.CreateOptions = strings.TrimSpace(strip(table.CreateOptions))
+	return &stripped
+}
+
+// NormalizeMariaDBSchema adjusts tables introspected from MariaDB, so that
+// they may be diff'ed. Columns using the JSON alias are represented with type
+// "json", so that any ALTER TABLE modifying them retains the json_valid
+// constraint, and any character set reported for UUID or INET columns is
+// removed. Tables which were marked as unsupported solely due to these types,
+// or due to page compression or encryption table options, are marked as
+// supported. Each table's CreateStatement is left as-is.
+func NormalizeMariaDBSchema(schema *tengo.Schema, flavor tengo.Flavor) {
+	if schema == nil {
+		return
+	}
+	for _, table := range schema.Tables {
+		jsonCols := make(map[string]bool)
+		for _, matches := range mariaJSONCheckRegexp.FindAllStringSubmatch(table.CreateStatement, -1) {
+			jsonCols[strings.Replace(matches[1], "``", "`", -1)] = true
+		}
+		for _, col := range table.Columns {
+			base := strings.ToLower(col.TypeInDB)
+			if mariaNativeTypes[base] {
+				col.CharSet, col.Collation, col.CollationIsDefault = "", "", false
+			} else if jsonCols[col.Name] && base == "longtext" {
+				col.TypeInDB = "json"
+				col.CharSet, col.Collation, col.CollationIsDefault = "", "", false
+			}
+		}
+		if !table.UnsupportedDDL {
+			continue
+		}
+		stripped := StripMariaDBTableOptions(table)
+		if mariaJSONAsType(stripped) == stripped.GeneratedCreateStatement(flavor) {
+			table.UnsupportedDDL = false
+		}
+	}
+}
+
+// mariaJSONAsType returns table.CreateStatement with each JSON alias column
+// expressed using type "json", without its json_valid constraint.
+func mariaJSONAsType(table *tengo.Table) string {
+	stmt := mariaJSONConstraintRegexp.ReplaceAllString(table.CreateStatement, "")
+	lines := strings.Split(stmt, "\n")
+	for _, col := range table.Columns {
+		if col.TypeInDB != "json" {
+			continue
+		}
+		prefix := fmt.Sprintf("  %s longtext", tengo.EscapeIdentifier(col.Name))
+		for n, line := range lines {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			if check := mariaJSONCheckRegexp.FindString(line); check != "" {
+				line = strings.Replace(line, " "+check, "", 1)
+			}
+			lines[n] = mariaLongtextRegexp.ReplaceAllString(line, "${1}json")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
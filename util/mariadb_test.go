@@ -0,0 +1,115 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestNormalizeMariaDBSchema(t *testing.T) {
+	makeTable := func(createStatement string) *tengo.Table {
+		idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+		return &tengo.Table{
+			Name:               "docs",
+			Engine:             "InnoDB",
+			CharSet:            "latin1",
+			Collation:          "latin1_swedish_ci",
+			CollationIsDefault: true,
+			Columns: []*tengo.Column{
+				idCol,
+				{Name: "uid", TypeInDB: "uuid", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true},
+				{Name: "doc", TypeInDB: "longtext", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "utf8mb4", Collation: "utf8mb4_bin"},
+			},
+			PrimaryKey:      &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+			CreateStatement: createStatement,
+			UnsupportedDDL:  true,
+		}
+	}
+
+	// JSON constraint inline (MariaDB 10.4.3+) or as a separate constraint
+	inline := makeTable("CREATE TABLE `docs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `uid` uuid DEFAULT NULL,\n" +
+		"  `doc` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL CHECK (json_valid(`doc`)),\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1")
+	separate := makeTable("CREATE TABLE `docs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `uid` uuid DEFAULT NULL,\n" +
+		"  `doc` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `CONSTRAINT_1` CHECK (json_valid(`doc`))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1")
+	for _, table := range []*tengo.Table{inline, separate} {
+		origCreate := table.CreateStatement
+		NormalizeMariaDBSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorMariaDB103)
+		if table.UnsupportedDDL {
+			t.Errorf("Expected table to be supported after normalization, but it was not. Generated statement:\n%s", table.GeneratedCreateStatement(tengo.FlavorMariaDB103))
+		}
+		if table.CreateStatement != origCreate {
+			t.Errorf("Expected CreateStatement to be unchanged by normalization, instead found:\n%s", table.CreateStatement)
+		}
+		if uid, doc := table.Columns[1], table.Columns[2]; uid.CharSet != "" || doc.TypeInDB != "json" || doc.CharSet != "" {
+			t.Errorf("Unexpected columns after normalization: %+v, %+v", *uid, *doc)
+		}
+	}
+
+	// A json_valid constraint on some other column type is left alone
+	other := makeTable("CREATE TABLE `docs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `uid` uuid DEFAULT NULL,\n" +
+		"  `doc` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL CHECK (json_valid(`id`)),\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1")
+	NormalizeMariaDBSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{other}}, tengo.FlavorMariaDB103)
+	if !other.UnsupportedDDL || other.Columns[0].TypeInDB != "int(10) unsigned" || other.Columns[2].TypeInDB != "longtext" {
+		t.Error("Expected table with json_valid constraint on non-longtext column to remain unsupported")
+	}
+}
+
+func TestMariaDBTableOptions(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+	table := &tengo.Table{
+		Name:               "logs",
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{idCol},
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+	}
+	plain := table.GeneratedCreateStatement(tengo.FlavorMariaDB103)
+	table.CreateOptions = "`PAGE_COMPRESSED`='ON' `PAGE_COMPRESSION_LEVEL`=9 `ENCRYPTED`=YES `ENCRYPTION_KEY_ID`=2"
+	table.CreateStatement = plain + " `PAGE_COMPRESSED`=1 `page_compression_level`=9 `ENCRYPTED`=YES `ENCRYPTION_KEY_ID`=2"
+	table.UnsupportedDDL = true
+
+	expected := map[string]string{
+		MariaDBPageCompressed:       "ON",
+		MariaDBPageCompressionLevel: "9",
+		MariaDBEncrypted:            "YES",
+		MariaDBEncryptionKeyID:      "2",
+	}
+	if actual := MariaDBTableOptions(table); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from MariaDBTableOptions: %v", actual)
+	}
+	if !TableEncrypted(table) {
+		t.Error("Expected table with ENCRYPTED=YES to be considered encrypted")
+	}
+	if stripped := StripMariaDBTableOptions(table); stripped.CreateStatement != plain || stripped.CreateOptions != "" {
+		t.Errorf("StripMariaDBTableOptions did not remove all options: %s / %s", stripped.CreateStatement, stripped.CreateOptions)
+	}
+	stripped := StripMariaDBTableOptions(table, MariaDBEncrypted, MariaDBEncryptionKeyID)
+	if expected := plain + " `PAGE_COMPRESSED`=1 `page_compression_level`=9"; stripped.CreateStatement != expected {
+		t.Errorf("StripMariaDBTableOptions did not remove exactly the supplied options: %s", stripped.CreateStatement)
+	}
+
+	origCreate := table.CreateStatement
+	NormalizeMariaDBSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorMariaDB103)
+	if table.UnsupportedDDL {
+		t.Error("Expected table to be supported after normalization, but it was not")
+	}
+	if table.CreateStatement != origCreate {
+		t.Errorf("Expected CreateStatement to be unchanged by normalization, instead found:\n%s", table.CreateStatement)
+	}
+}
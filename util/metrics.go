@@ -0,0 +1,135 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics is the process-wide registry of operational metrics. Counters and
+// timings recorded here are sent to StatsD immediately if EnableStatsD has been
+// called, and are also retained in memory for exposition in Prometheus format
+// by long-running commands.
+var Metrics = NewMetricsRegistry()
+
+// MetricsRegistry tracks counters and timings by name. Names should consist of
+// lowercase letters, digits, and underscores, such as "statements_executed".
+// All methods are safe for concurrent use.
+type MetricsRegistry struct {
+	mu           sync.Mutex
+	counters     map[string]int64
+	timings      map[string]*timingStats
+	statsdConn   net.Conn
+	statsdPrefix string
+}
+
+// NewMetricsRegistry returns an empty MetricsRegistry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		counters: make(map[string]int64),
+		timings:  make(map[string]*timingStats),
+	}
+}
+
+type timingStats struct {
+	sum   time.Duration
+	count int64
+}
+
+// EnableStatsD causes all subsequently recorded metrics to also be sent to
+// the StatsD server at addr via UDP, with the supplied prefix prepended to
+// metric names (separated by a dot). Since UDP is connectionless, an error is
+// only returned if addr cannot be resolved.
+func (mr *MetricsRegistry) EnableStatsD(addr, prefix string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if mr.statsdConn != nil {
+		mr.statsdConn.Close()
+	}
+	mr.statsdConn = conn
+	if prefix != "" {
+		prefix += "."
+	}
+	mr.statsdPrefix = prefix
+	return nil
+}
+
+// Count increments the named counter by n.
+func (mr *MetricsRegistry) Count(name string, n int64) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.counters[name] += n
+	mr.sendStatsD(fmt.Sprintf("%s:%d|c", name, n))
+}
+
+// Time records one observation of the named timing.
+func (mr *MetricsRegistry) Time(name string, d time.Duration) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	stats := mr.timings[name]
+	if stats == nil {
+		stats = &timingStats{}
+		mr.timings[name] = stats
+	}
+	stats.sum += d
+	stats.count++
+	mr.sendStatsD(fmt.Sprintf("%s:%d|ms", name, d/time.Millisecond))
+}
+
+// TimeSince records the elapsed time since start for the named timing. This is
+// convenient for use with defer.
+func (mr *MetricsRegistry) TimeSince(name string, start time.Time) {
+	mr.Time(name, time.Since(start))
+}
+
+// sendStatsD writes a single metric line to the StatsD server, if enabled.
+// Errors are ignored, as is customary for StatsD clients. The caller must hold
+// mr.mu.
+func (mr *MetricsRegistry) sendStatsD(line string) {
+	if mr.statsdConn != nil {
+		mr.statsdConn.Write([]byte(mr.statsdPrefix + line))
+	}
+}
+
+// WritePrometheus writes all metrics recorded so far to w, in the Prometheus
+// text exposition format. Metric names are prefixed with prefix and an
+// underscore. Counters are suffixed with "_total", and timings are exposed as
+// summaries in seconds, without quantiles.
+func (mr *MetricsRegistry) WritePrometheus(w io.Writer, prefix string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if prefix != "" {
+		prefix += "_"
+	}
+	names := make([]string, 0, len(mr.counters))
+	for name := range mr.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fullName := prefix + name + "_total"
+		if _, err := fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", fullName, fullName, mr.counters[name]); err != nil {
+			return err
+		}
+	}
+	names = names[:0]
+	for name := range mr.timings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fullName := prefix + name + "_seconds"
+		stats := mr.timings[name]
+		if _, err := fmt.Fprintf(w, "# TYPE %s summary\n%s_sum %g\n%s_count %d\n", fullName, fullName, stats.sum.Seconds(), fullName, stats.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen on UDP: %s", err)
+	}
+	defer listener.Close()
+
+	mr := NewMetricsRegistry()
+	mr.Count("before_statsd", 1) // not sent, since StatsD not enabled yet
+	if err := mr.EnableStatsD(listener.LocalAddr().String(), "skeema"); err != nil {
+		t.Fatalf("Unexpected error from EnableStatsD: %s", err)
+	}
+	mr.Count("statements_executed", 2)
+	mr.Count("statements_executed", 1)
+	mr.Time("statement_duration", 1500*time.Millisecond)
+	mr.TimeSince("statement_duration", time.Now().Add(-500*time.Millisecond))
+
+	expectedPackets := []string{
+		"skeema.statements_executed:2|c",
+		"skeema.statements_executed:1|c",
+		"skeema.statement_duration:1500|ms",
+		"skeema.statement_duration:500|ms",
+	}
+	buf := make([]byte, 1024)
+	for _, expected := range expectedPackets {
+		listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := listener.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Unable to read StatsD packet: %s", err)
+		}
+		if actual := string(buf[:n]); actual != expected {
+			t.Errorf("Expected StatsD packet %q, instead found %q", expected, actual)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := mr.WritePrometheus(&b, "skeema"); err != nil {
+		t.Fatalf("Unexpected error from WritePrometheus: %s", err)
+	}
+	out := b.String()
+	expected := []string{
+		"# TYPE skeema_before_statsd_total counter\nskeema_before_statsd_total 1\n",
+		"# TYPE skeema_statements_executed_total counter\nskeema_statements_executed_total 3\n",
+		"# TYPE skeema_statement_duration_seconds summary\nskeema_statement_duration_seconds_sum 2", // sum may have fractional ms from TimeSince
+		"skeema_statement_duration_seconds_count 2\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected Prometheus output to contain %q, but it did not. Full output:\n%s", exp, out)
+		}
+	}
+
+	if err := mr.EnableStatsD("not a valid address", ""); err == nil {
+		t.Error("Expected error from EnableStatsD with invalid address, but err was nil")
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// IsRocksDB returns true if table uses the MyRocks storage engine.
+func IsRocksDB(table *tengo.Table) bool {
+	return table != nil && strings.EqualFold(table.Engine, "ROCKSDB")
+}
+
+var rocksDBColumnFamilyRegexp = regexp.MustCompile(`(?:^|;)\s*cfname=([^;]*)`)
+
+// RocksDBColumnFamily returns the MyRocks column family used by idx, along with
+// whether the column family is reverse-ordered. MyRocks obtains the column
+// family from the index comment, which may either be a "cfname=name;" pair, or
+// in its legacy form, just the column family name. A "rev:" prefix on the name
+// indicates a reverse-ordered column family. If the comment does not specify a
+// column family, "default" is returned.
+func RocksDBColumnFamily(idx *tengo.Index) (name string, reverse bool) {
+	if matches := rocksDBColumnFamilyRegexp.FindStringSubmatch(idx.Comment); matches != nil {
+		name = matches[1]
+	} else if !strings.Contains(idx.Comment, "=") {
+		name = idx.Comment
+	}
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "rev:") {
+		name, reverse = name[4:], true
+	}
+	if name == "" {
+		name = "default"
+	}
+	return name, reverse
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestRocksDBColumnFamily(t *testing.T) {
+	cases := []struct {
+		comment string
+		name    string
+		reverse bool
+	}{
+		{"", "default", false},
+		{"cf_users", "cf_users", false},
+		{"rev:cf_users", "cf_users", true},
+		{"cfname=cf_users;", "cf_users", false},
+		{"ttl_duration=3600;cfname=rev:cf_events;", "cf_events", true},
+		{"p0_cfname=cf_p0;", "default", false},
+	}
+	for _, c := range cases {
+		idx := &tengo.Index{Name: "idx", Comment: c.comment}
+		if name, reverse := RocksDBColumnFamily(idx); name != c.name || reverse != c.reverse {
+			t.Errorf("Unexpected result from RocksDBColumnFamily with comment %q: %q, %t", c.comment, name, reverse)
+		}
+	}
+
+	if IsRocksDB(nil) || IsRocksDB(&tengo.Table{Engine: "InnoDB"}) || !IsRocksDB(&tengo.Table{Engine: "ROCKSDB"}) {
+		t.Error("Unexpected result from IsRocksDB")
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var passwordCache struct {
+	sync.Mutex
+	passwords map[string]string
+}
+
+func init() {
+	passwordCache.passwords = make(map[string]string)
+}
+
+// CommandPassword obtains a password by shelling out to command, after
+// interpolating variables. The command's STDOUT, minus any trailing newline, is
+// returned as the password. Results are cached per interpolated command-line,
+// so that the command is only executed once per distinct host, user, and
+// environment combination, even when many directories share those values.
+func CommandPassword(command string, variables map[string]string) (string, error) {
+	shellOut, err := NewInterpolatedShellOut(command, variables)
+	if err != nil {
+		return "", err
+	}
+	key := shellOut.Command
+	passwordCache.Lock()
+	defer passwordCache.Unlock()
+	if password, already := passwordCache.passwords[key]; already {
+		return password, nil
+	}
+	output, err := shellOut.RunCapture()
+	if err != nil {
+		return "", fmt.Errorf("password-command failed: %s", err)
+	}
+	password := strings.TrimRight(output, "\r\n")
+	if password == "" {
+		return "", errors.New("password-command returned an empty password")
+	}
+	passwordCache.passwords[key] = password
+	return password, nil
+}
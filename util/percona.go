@@ -0,0 +1,78 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// Percona Server supports column compression, which SHOW CREATE TABLE displays
+// as a version-gated comment after the column definition, optionally
+// referencing a compression dictionary.
+var (
+	perconaColumnNameRegexp = regexp.MustCompile("^  (`(?:[^`]|``)+`) ")
+	perconaCompressedRegexp = regexp.MustCompile(` ?/\*!50633 COLUMN_FORMAT COMPRESSED[^*]*\*/`)
+)
+
+// PerconaColumnAttributes returns a map of column name to Percona Server
+// column compression attribute, for each compressed column in table. The
+// attribute is returned as it appears in SHOW CREATE TABLE, for example
+// "/*!50633 COLUMN_FORMAT COMPRESSED */".
+func PerconaColumnAttributes(table *tengo.Table) map[string]string {
+	attrs := make(map[string]string)
+	for _, line := range strings.Split(table.CreateStatement, "\n") {
+		nameMatch := perconaColumnNameRegexp.FindStringSubmatch(line)
+		if nameMatch == nil {
+			continue
+		}
+		if attr := perconaCompressedRegexp.FindString(line); attr != "" {
+			attrs[unescapeIdentifier(nameMatch[1])] = strings.TrimSpace(attr)
+		}
+	}
+	return attrs
+}
+
+// StripPerconaAttributes returns a shallow copy of table, with all column
+// compression attributes removed from its CreateStatement.
+func StripPerconaAttributes(table *tengo.Table) *tengo.Table {
+	stripped := *table
+	stripped.CreateStatement = perconaCompressedRegexp.ReplaceAllString(table.CreateStatement, "")
+	return &stripped
+}
+
+// NormalizePerconaSchema adjusts tables introspected from Percona Server, so
+// that they may be diff'ed. Tables which were marked as unsupported solely due
+// to column compression attributes, table encryption options, or CHECK
+// constraints are marked as supported. Their CreateStatement is left as-is, so
+// that these clauses are included when creating new tables.
+func NormalizePerconaSchema(schema *tengo.Schema, flavor tengo.Flavor) {
+	if schema == nil {
+		return
+	}
+	for _, table := range schema.Tables {
+		if !table.UnsupportedDDL {
+			continue
+		}
+		stripped := StripCheckConstraints(StripTableEncryption(StripPerconaAttributes(table)))
+		if stripped.CreateStatement == stripped.GeneratedCreateStatement(flavor) {
+			table.UnsupportedDDL = false
+		}
+	}
+}
+
+// tableOptionsText returns the portion of a CREATE TABLE statement after the
+// closing parenthesis of its column and index definitions.
+func tableOptionsText(createStatement string) string {
+	if pos := strings.LastIndex(createStatement, "\n)"); pos >= 0 {
+		return createStatement[pos:]
+	}
+	return ""
+}
+
+// unescapeIdentifier removes the backticks surrounding name, and unescapes
+// any backticks within it.
+func unescapeIdentifier(name string) string {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "`"), "`")
+	return strings.Replace(name, "``", "`", -1)
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestNormalizePerconaSchema(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+	docCol := &tengo.Column{Name: "doc", TypeInDB: "text", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true}
+	table := &tengo.Table{
+		Name:               "docs",
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{idCol, docCol},
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		CreateOptions:      `ENCRYPTION="Y"`,
+		UnsupportedDDL:     true,
+	}
+	table.CreateStatement = strings.Replace(table.GeneratedCreateStatement(tengo.FlavorPercona57), "`doc` text", "`doc` text /*!50633 COLUMN_FORMAT COMPRESSED WITH COMPRESSION_DICTIONARY `words` */", 1)
+	table.CreateStatement = strings.Replace(table.CreateStatement, `ENCRYPTION="Y"`, "ENCRYPTION='Y' ENCRYPTION_KEY_ID=2", 1)
+
+	expectedAttrs := map[string]string{"doc": "/*!50633 COLUMN_FORMAT COMPRESSED WITH COMPRESSION_DICTIONARY `words` */"}
+	if attrs := PerconaColumnAttributes(table); !reflect.DeepEqual(attrs, expectedAttrs) {
+		t.Errorf("Unexpected result from PerconaColumnAttributes: %v", attrs)
+	}
+	stripped := StripPerconaAttributes(table)
+	if strings.Contains(stripped.CreateStatement, "COMPRESSED") || !strings.Contains(stripped.CreateStatement, "ENCRYPTION") {
+		t.Errorf("StripPerconaAttributes did not remove exactly the compression attributes: %s", stripped.CreateStatement)
+	}
+
+	origCreate := table.CreateStatement
+	NormalizePerconaSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorPercona57)
+	if table.UnsupportedDDL {
+		t.Error("Expected table to be supported after normalization, but it was not")
+	}
+	if table.CreateStatement != origCreate {
+		t.Errorf("Expected CreateStatement to be unchanged by normalization, instead found:\n%s", table.CreateStatement)
+	}
+
+	// Tables which are unsupported for other reasons remain unsupported
+	table.UnsupportedDDL = true
+	table.CreateStatement = strings.Replace(table.CreateStatement, "ENGINE=InnoDB", "ENGINE=InnoDB /*!50100 TABLESPACE `ts1` */", 1)
+	NormalizePerconaSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorPercona57)
+	if !table.UnsupportedDDL {
+		t.Error("Expected table to remain unsupported, but it was marked as supported")
+	}
+}
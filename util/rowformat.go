@@ -0,0 +1,90 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/skeema/tengo"
+)
+
+// DefaultRowFormat returns the InnoDB row format used for tables which do not
+// specify ROW_FORMAT, in flavor's default configuration. MySQL 5.7+ and
+// MariaDB 10.2+ default to DYNAMIC via innodb_default_row_format; earlier
+// releases use COMPACT. An empty string is returned for unknown flavors.
+func DefaultRowFormat(flavor tengo.Flavor) string {
+	if !flavor.Known() {
+		return ""
+	} else if flavor.MySQLishMinVersion(5, 7) || flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 2) {
+		return "DYNAMIC"
+	}
+	return "COMPACT"
+}
+
+var rowFormatInstanceCache struct {
+	formats map[string]string
+	sync.Mutex
+}
+
+// InstanceDefaultRowFormat returns the InnoDB row format used by inst for
+// tables which do not specify ROW_FORMAT. This is obtained from
+// innodb_default_row_format if inst has this variable, or from
+// DefaultRowFormat otherwise. The result is cached per instance. An empty
+// string is returned if inst is nil.
+func InstanceDefaultRowFormat(inst *tengo.Instance) string {
+	if inst == nil {
+		return ""
+	}
+	rowFormatInstanceCache.Lock()
+	defer rowFormatInstanceCache.Unlock()
+	if format, ok := rowFormatInstanceCache.formats[inst.String()]; ok {
+		return format
+	}
+	if rowFormatInstanceCache.formats == nil {
+		rowFormatInstanceCache.formats = make(map[string]string)
+	}
+
+	format := DefaultRowFormat(inst.Flavor())
+	if db, err := inst.Connect("", ""); err == nil {
+		var value string
+		if err := db.QueryRow("SELECT @@innodb_default_row_format").Scan(&value); err == nil {
+			format = strings.ToUpper(value)
+		}
+	}
+	rowFormatInstanceCache.formats[inst.String()] = format
+	return format
+}
+
+var rowFormatRegexp = regexp.MustCompile(`(?i) ?ROW_FORMAT=(\w+)`)
+
+// WithoutDefaultRowFormat returns a shallow copy of schema, in which any InnoDB
+// table explicitly specifying ROW_FORMAT=rowFormat is replaced by a copy with
+// that option removed from its CreateStatement and CreateOptions. Since
+// rowFormat should be the server's default, such tables are equivalent to ones
+// omitting ROW_FORMAT, and will compare equal to them in a diff. Tables using
+// any other row format are left as-is, so changes to or from a non-default
+// row format are still detected. If schema is nil or rowFormat is empty,
+// schema is returned as-is.
+func WithoutDefaultRowFormat(schema *tengo.Schema, rowFormat string) *tengo.Schema {
+	if schema == nil || rowFormat == "" {
+		return schema
+	}
+	normalized := *schema
+	normalized.Tables = make([]*tengo.Table, len(schema.Tables))
+	for n, table := range schema.Tables {
+		normalized.Tables[n] = table
+		if table.Engine != "InnoDB" {
+			continue
+		}
+		matches := rowFormatRegexp.FindStringSubmatch(tableOptionsText(table.CreateStatement))
+		if matches == nil || !strings.EqualFold(matches[1], rowFormat) {
+			continue
+		}
+		stripped := *table
+		pos := strings.LastIndex(table.CreateStatement, "\n)")
+		stripped.CreateStatement = table.CreateStatement[:pos] + rowFormatRegexp.ReplaceAllString(table.CreateStatement[pos:], "")
+		stripped.CreateOptions = strings.TrimSpace(rowFormatRegexp.ReplaceAllString(table.CreateOptions, ""))
+		normalized.Tables[n] = &stripped
+	}
+	return &normalized
+}
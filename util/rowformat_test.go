@@ -0,0 +1,75 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestDefaultRowFormat(t *testing.T) {
+	cases := map[tengo.Flavor]string{
+		tengo.FlavorUnknown:    "",
+		tengo.FlavorMySQL55:    "COMPACT",
+		tengo.FlavorMySQL56:    "COMPACT",
+		tengo.FlavorMySQL57:    "DYNAMIC",
+		tengo.FlavorPercona80:  "DYNAMIC",
+		tengo.FlavorMariaDB101: "COMPACT",
+		tengo.FlavorMariaDB102: "DYNAMIC",
+	}
+	for flavor, expected := range cases {
+		if actual := DefaultRowFormat(flavor); actual != expected {
+			t.Errorf("Expected DefaultRowFormat(%s) to return %q, instead found %q", flavor, expected, actual)
+		}
+	}
+}
+
+func TestWithoutDefaultRowFormat(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull}
+	newTable := func(name string) *tengo.Table {
+		return &tengo.Table{
+			Name:               name,
+			Engine:             "InnoDB",
+			CharSet:            "latin1",
+			Collation:          "latin1_swedish_ci",
+			CollationIsDefault: true,
+			Columns:            []*tengo.Column{idCol},
+			PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+	}
+	plain, dynamic, compressed := newTable("plain"), newTable("dynamic"), newTable("compressed")
+	plain.CreateStatement = plain.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	dynamic.CreateOptions = "ROW_FORMAT=DYNAMIC"
+	dynamic.CreateStatement = dynamic.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	compressed.CreateOptions = "ROW_FORMAT=COMPRESSED"
+	compressed.CreateStatement = compressed.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	schema := &tengo.Schema{Name: "rf", Tables: []*tengo.Table{plain, dynamic, compressed}}
+
+	if WithoutDefaultRowFormat(nil, "DYNAMIC") != nil {
+		t.Error("Expected nil schema to be returned as-is")
+	}
+	if WithoutDefaultRowFormat(schema, "") != schema {
+		t.Error("Expected schema to be returned as-is for an empty row format")
+	}
+
+	normalized := WithoutDefaultRowFormat(schema, "DYNAMIC")
+	if normalized == schema || normalized.Tables[0] != plain || normalized.Tables[2] != compressed {
+		t.Error("Expected a copy of schema, retaining tables which do not use the default row format")
+	}
+	if stripped := normalized.Tables[1]; stripped == dynamic || strings.Contains(stripped.CreateStatement, "ROW_FORMAT") || stripped.CreateOptions != "" {
+		t.Errorf("Expected ROW_FORMAT to be stripped from a copy of the table, instead found %+v", *stripped)
+	} else if !strings.Contains(dynamic.CreateStatement, "ROW_FORMAT=DYNAMIC") || dynamic.CreateOptions != "ROW_FORMAT=DYNAMIC" {
+		t.Error("Original table was unexpectedly modified")
+	}
+
+	// Omitting the default row format should not be a difference, but changing
+	// to a non-default row format should be
+	other := &tengo.Schema{Name: "rf", Tables: []*tengo.Table{newTable("plain"), newTable("dynamic"), newTable("compressed")}}
+	for _, table := range other.Tables {
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	}
+	diff := tengo.NewSchemaDiff(WithoutDefaultRowFormat(other, "DYNAMIC"), normalized)
+	if tableDiffs := diff.FilteredTableDiffs(tengo.DiffTypeAlter); len(tableDiffs) != 1 || tableDiffs[0].To.Name != "compressed" {
+		t.Errorf("Expected only table compressed to be altered, instead found %d table diffs", len(tableDiffs))
+	}
+}
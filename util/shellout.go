@@ -53,6 +53,20 @@ func (s *ShellOut) RunCapture() (string, error) {
 	return string(out), err
 }
 
+// RunCaptureWithInput behaves like RunCapture, except the supplied input is
+// fed to the command's STDIN instead of the parent process's STDIN.
+func (s *ShellOut) RunCaptureWithInput(input string) (string, error) {
+	if s.Command == "" {
+		return "", errors.New("Attempted to shell out to an empty command string")
+	}
+	cmd := exec.Command("/bin/sh", "-c", s.Command)
+	cmd.Dir = s.Dir
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	return string(out), err
+}
+
 // RunCaptureSplit behaves like RunCapture, except the STDOUT will be tokenized.
 // If newlines are present in the output, it will be split on newlines; else if
 // commas are present, it will be split on commas; else ditto for tabs; else
@@ -133,6 +147,24 @@ func NewInterpolatedShellOut(command string, variables map[string]string) (*Shel
 	return s, err
 }
 
+// InterpolateVariables replaces any {VARNAME} placeholders in value with the
+// corresponding entry of variables. Variable names are case-insensitive, and
+// should be supplied in all uppercase in variables. Unlike
+// NewInterpolatedShellOut, values are not escaped. An error is returned if
+// value references a variable not present in variables.
+func InterpolateVariables(value string, variables map[string]string) (string, error) {
+	var err error
+	replacer := func(input string) string {
+		varName := strings.ToUpper(input[1 : len(input)-1])
+		if varValue, ok := variables[varName]; ok {
+			return varValue
+		}
+		err = fmt.Errorf("Unknown variable %s", input)
+		return input
+	}
+	return varPlaceholder.ReplaceAllStringFunc(value, replacer), err
+}
+
 // noQuotesNeeded is a regexp for detecting which variable values do not require
 // escaping and quote-wrapping in escapeVarValue()
 var noQuotesNeeded = regexp.MustCompile(`^[\w/@%=:.,+-]*$`)
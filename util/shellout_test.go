@@ -54,6 +54,19 @@ func TestRunCaptureSplit(t *testing.T) {
 	}
 }
 
+func TestRunCaptureWithInput(t *testing.T) {
+	s := &ShellOut{Command: "tr a-z A-Z"}
+	if result, err := s.RunCaptureWithInput("hello world\n"); err != nil {
+		t.Errorf("Unexpected error from RunCaptureWithInput: %s", err)
+	} else if result != "HELLO WORLD\n" {
+		t.Errorf("Unexpected result from RunCaptureWithInput: %q", result)
+	}
+	s = &ShellOut{}
+	if _, err := s.RunCaptureWithInput("hello"); err == nil {
+		t.Error("Expected error from RunCaptureWithInput with empty command, but err was nil")
+	}
+}
+
 func TestNewInterpolatedShellOut(t *testing.T) {
 	variables := map[string]string{
 		"HOST":     "ahost",
@@ -102,6 +115,22 @@ func TestNewInterpolatedShellOut(t *testing.T) {
 	assertShellOutError("/bin/echo {HOST} {X} {SCHEMA}", "/bin/echo ahost {X} aschema")
 }
 
+func TestInterpolateVariables(t *testing.T) {
+	variables := map[string]string{
+		"HOST":        "ahost",
+		"ENVIRONMENT": "staging",
+	}
+	if actual, err := InterpolateVariables("myapp_{environment}_{HOST}", variables); err != nil || actual != "myapp_staging_ahost" {
+		t.Errorf("Unexpected result from InterpolateVariables: %q / %v", actual, err)
+	}
+	if actual, err := InterpolateVariables("no variables", variables); err != nil || actual != "no variables" {
+		t.Errorf("Unexpected result from InterpolateVariables: %q / %v", actual, err)
+	}
+	if _, err := InterpolateVariables("myapp_{invalid}", variables); err == nil {
+		t.Error("Expected InterpolateVariables to return an error for an unknown variable, but it did not")
+	}
+}
+
 func TestEscapeVarValue(t *testing.T) {
 	values := map[string]string{
 		`has space`:           `'has space'`,
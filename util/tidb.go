@@ -0,0 +1,144 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/skeema/tengo"
+)
+
+// TiDBFlavor represents a release of TiDB. Since TiDB reports a MySQL-
+// compatible @@version_comment, package tengo treats it as MySQL 5.7 or 8.0,
+// so Skeema tracks TiDB releases separately for purposes of feature gating.
+// The zero value represents a database which is not TiDB.
+type TiDBFlavor struct {
+	Major int
+	Minor int
+}
+
+var tidbVersionRegexp = regexp.MustCompile(`(?i)tidb[:-]v?(\d+)(?:\.(\d+))?`)
+
+// ParseTiDBFlavor parses either a flavor option value such as "tidb:6.5", or
+// a TiDB @@version value such as "5.7.25-TiDB-v6.5.0". The zero value is
+// returned if s does not refer to TiDB.
+func ParseTiDBFlavor(s string) TiDBFlavor {
+	matches := tidbVersionRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return TiDBFlavor{}
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2]) // 0 if missing, which is fine
+	return TiDBFlavor{Major: major, Minor: minor}
+}
+
+// Known returns true if fl represents TiDB.
+func (fl TiDBFlavor) Known() bool {
+	return fl.Major > 0
+}
+
+func (fl TiDBFlavor) String() string {
+	return fmt.Sprintf("tidb:%d.%d", fl.Major, fl.Minor)
+}
+
+// MinVersion returns true if fl is TiDB, of at least the supplied version.
+func (fl TiDBFlavor) MinVersion(major, minor int) bool {
+	return fl.Known() && (fl.Major > major || (fl.Major == major && fl.Minor >= minor))
+}
+
+// EnforcesForeignKeys returns true if fl enforces foreign key constraints.
+// Prior to TiDB 6.6, foreign keys were parsed and stored, but not enforced.
+func (fl TiDBFlavor) EnforcesForeignKeys() bool {
+	return fl.MinVersion(6, 6)
+}
+
+// MultiSchemaChange returns true if fl permits a single ALTER TABLE to
+// contain multiple changes. Prior to TiDB 6.2, most combinations of clauses
+// were rejected.
+func (fl TiDBFlavor) MultiSchemaChange() bool {
+	return fl.MinVersion(6, 2)
+}
+
+var tidbInstanceCache struct {
+	flavors map[string]TiDBFlavor
+	sync.Mutex
+}
+
+// InstanceTiDBFlavor returns the TiDB release of inst, or the zero value if
+// inst is not TiDB or cannot be queried. Results are cached per instance.
+func InstanceTiDBFlavor(inst *tengo.Instance) TiDBFlavor {
+	tidbInstanceCache.Lock()
+	defer tidbInstanceCache.Unlock()
+	if fl, ok := tidbInstanceCache.flavors[inst.String()]; ok {
+		return fl
+	}
+	if tidbInstanceCache.flavors == nil {
+		tidbInstanceCache.flavors = make(map[string]TiDBFlavor)
+	}
+
+	// TiDB always reports a MySQL vendor, so avoid an extra query for any other
+	// flavor, including ones which could not be determined due to errors
+	var fl TiDBFlavor
+	if inst.Flavor().Vendor == tengo.VendorMySQL {
+		if db, err := inst.Connect("", ""); err == nil {
+			var version string
+			if err := db.QueryRow("SELECT @@global.version").Scan(&version); err == nil {
+				fl = ParseTiDBFlavor(version)
+			}
+		}
+	}
+	tidbInstanceCache.flavors[inst.String()] = fl
+	return fl
+}
+
+// TiDB's SHOW CREATE TABLE output wraps TiDB-specific syntax in special
+// comments, such as /*T![clustered_index] CLUSTERED */ or
+// /*T![auto_rand] AUTO_RANDOM(5) */.
+var (
+	tidbCommentRegexp      = regexp.MustCompile(` ?/\*T!(?:\[[a-z_]+\])? [^*]*\*/`)
+	tidbAutoRandBaseRegexp = regexp.MustCompile(` ?/\*T!\[auto_rand_base\] [^*]*\*/`)
+)
+
+const tidbClusteredIndexMarker = "/*T![clustered_index] CLUSTERED */"
+
+// StripTiDBComments removes all TiDB-specific comments from a CREATE TABLE
+// statement.
+func StripTiDBComments(createStatement string) string {
+	return tidbCommentRegexp.ReplaceAllString(createStatement, "")
+}
+
+// TiDBClusteredIndex returns true if table's primary key is a TiDB clustered
+// index, based on its SHOW CREATE TABLE output.
+func TiDBClusteredIndex(table *tengo.Table) bool {
+	return regexp.MustCompile(`PRIMARY KEY \([^)]+\) ` + regexp.QuoteMeta(tidbClusteredIndexMarker)).MatchString(table.CreateStatement)
+}
+
+// TiDBAutoRandomColumns returns the names of any AUTO_RANDOM columns in table.
+func TiDBAutoRandomColumns(table *tengo.Table) (names []string) {
+	re := regexp.MustCompile("(?m)^\\s*`((?:[^`]|``)+)` .*/\\*T!\\[auto_rand\\] AUTO_RANDOM")
+	for _, matches := range re.FindAllStringSubmatch(table.CreateStatement, -1) {
+		names = append(names, matches[1])
+	}
+	return names
+}
+
+// NormalizeTiDBSchema adjusts tables introspected from TiDB, so that they may
+// be diff'ed. Tables which were marked as unsupported solely due to TiDB-
+// specific comments are marked as supported. The AUTO_RANDOM_BASE is removed
+// from each table's CreateStatement, since, like AUTO_INCREMENT, it changes as
+// rows are inserted. Other TiDB-specific comments are retained, so that they
+// are included when creating new tables; any difference in these between two
+// tables cannot be expressed as an ALTER TABLE, and so is treated as an
+// unsupported diff.
+func NormalizeTiDBSchema(schema *tengo.Schema, flavor tengo.Flavor) {
+	if schema == nil {
+		return
+	}
+	for _, table := range schema.Tables {
+		table.CreateStatement = tidbAutoRandBaseRegexp.ReplaceAllString(table.CreateStatement, "")
+		if table.UnsupportedDDL && StripTiDBComments(table.CreateStatement) == table.GeneratedCreateStatement(flavor) {
+			table.UnsupportedDDL = false
+		}
+	}
+}
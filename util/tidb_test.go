@@ -0,0 +1,80 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestParseTiDBFlavor(t *testing.T) {
+	cases := map[string]TiDBFlavor{
+		"tidb:6.5":                 {6, 5},
+		"TiDB:v7.1.2":              {7, 1},
+		"tidb:8":                   {8, 0},
+		"5.7.25-TiDB-v6.1.0":       {6, 1},
+		"8.0.11-TiDB-v7.5.0-alpha": {7, 5},
+		"mysql:8.0":                {},
+		"8.0.32":                   {},
+		"":                         {},
+	}
+	for input, expected := range cases {
+		if actual := ParseTiDBFlavor(input); actual != expected {
+			t.Errorf("Expected ParseTiDBFlavor(%q) to return %+v, instead found %+v", input, expected, actual)
+		}
+	}
+
+	fl := ParseTiDBFlavor("tidb:6.5")
+	if !fl.Known() || fl.String() != "tidb:6.5" || !fl.MultiSchemaChange() || fl.EnforcesForeignKeys() {
+		t.Errorf("Unexpected result from methods of %+v", fl)
+	}
+	if fl = ParseTiDBFlavor("tidb:6.1"); fl.MultiSchemaChange() {
+		t.Errorf("Expected %s to not support multi schema change", fl)
+	}
+	if fl = (TiDBFlavor{}); fl.Known() || fl.MinVersion(0, 0) || fl.EnforcesForeignKeys() {
+		t.Errorf("Unexpected result from methods of zero value")
+	}
+}
+
+func TestNormalizeTiDBSchema(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "bigint(20)", Default: tengo.ColumnDefaultNull}
+	nameCol := &tengo.Column{Name: "name", TypeInDB: "varchar(30)", Nullable: true, Default: tengo.ColumnDefaultNull, CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true}
+	table := &tengo.Table{
+		Name:               "users",
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{idCol, nameCol},
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		UnsupportedDDL:     true,
+	}
+	generated := table.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	table.CreateStatement = "CREATE TABLE `users` (\n" +
+		"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+		"  `name` varchar(30) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1 /*T![auto_rand_base] AUTO_RANDOM_BASE=30001 */"
+	if StripTiDBComments(table.CreateStatement) != generated {
+		t.Fatalf("Test setup problem: stripped statement does not match generated statement:\n%s\n%s", StripTiDBComments(table.CreateStatement), generated)
+	}
+	if !TiDBClusteredIndex(table) {
+		t.Error("Expected TiDBClusteredIndex to return true, but it did not")
+	}
+	if cols := TiDBAutoRandomColumns(table); !reflect.DeepEqual(cols, []string{"id"}) {
+		t.Errorf("Unexpected result from TiDBAutoRandomColumns: %v", cols)
+	}
+
+	NormalizeTiDBSchema(&tengo.Schema{Name: "product", Tables: []*tengo.Table{table}}, tengo.FlavorMySQL57)
+	if table.UnsupportedDDL {
+		t.Error("Expected table to be supported after normalization, but it was not")
+	}
+	expected := "CREATE TABLE `users` (\n" +
+		"  `id` bigint(20) NOT NULL /*T![auto_rand] AUTO_RANDOM(5) */,\n" +
+		"  `name` varchar(30) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	if table.CreateStatement != expected {
+		t.Errorf("Unexpected CreateStatement after normalization:\n%s", table.CreateStatement)
+	}
+}
@@ -0,0 +1,163 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/skeema/mybase"
+)
+
+// TLSOptions represents the TLS-related options for a directory.
+type TLSOptions struct {
+	Mode       string // one of "", "disabled", "required", "verify_ca", "verify_identity"
+	CAPath     string
+	CertPath   string
+	KeyPath    string
+	ServerName string
+	MinVersion string // one of "", "tlsv1.0", "tlsv1.1", "tlsv1.2", "tlsv1.3"
+}
+
+// tlsVersions maps lowercased tls-min-version option values to crypto/tls
+// version constants.
+var tlsVersions = map[string]uint16{
+	"tlsv1.0": tls.VersionTLS10,
+	"tlsv1.1": tls.VersionTLS11,
+	"tlsv1.2": tls.VersionTLS12,
+	"tlsv1.3": tls.VersionTLS13,
+}
+
+// TLSOptionsForConfig returns the TLS options configured in cfg. If ssl-mode
+// is not set explicitly, it defaults to "verify_ca" if ssl-ca is set, or
+// "required" if ssl-cert is set; otherwise Mode is left blank, indicating TLS
+// was not configured via these options.
+func TLSOptionsForConfig(cfg *mybase.Config) (opts TLSOptions, err error) {
+	opts = TLSOptions{
+		CAPath:     cfg.Get("ssl-ca"),
+		CertPath:   cfg.Get("ssl-cert"),
+		KeyPath:    cfg.Get("ssl-key"),
+		ServerName: cfg.Get("ssl-server-name"),
+	}
+	if opts.Mode, err = cfg.GetEnum("ssl-mode", "DISABLED", "REQUIRED", "VERIFY_CA", "VERIFY_IDENTITY"); err != nil {
+		return
+	}
+	if opts.MinVersion, err = cfg.GetEnum("tls-min-version", "TLSv1.0", "TLSv1.1", "TLSv1.2", "TLSv1.3"); err != nil {
+		return
+	}
+	opts.Mode = strings.ToLower(opts.Mode)
+	opts.MinVersion = strings.ToLower(opts.MinVersion)
+	if opts.Mode == "" && opts.CAPath != "" {
+		opts.Mode = "verify_ca"
+	} else if opts.Mode == "" && opts.CertPath != "" {
+		opts.Mode = "required"
+	}
+	if (opts.CertPath == "") != (opts.KeyPath == "") {
+		err = errors.New("Options ssl-cert and ssl-key must be used together")
+	} else if opts.Mode == "" && (opts.ServerName != "" || opts.MinVersion != "") {
+		err = errors.New("Options ssl-server-name and tls-min-version require TLS to be enabled via ssl-mode, ssl-ca, or ssl-cert")
+	} else if opts.ServerName != "" && opts.Mode != "verify_identity" {
+		err = errors.New("Option ssl-server-name requires ssl-mode=VERIFY_IDENTITY")
+	}
+	return
+}
+
+// tlsConfig builds a *tls.Config for opts.
+func (opts TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tlsVersions[opts.MinVersion],
+		ServerName: opts.ServerName,
+	}
+	if opts.CAPath != "" {
+		pemBytes, err := ioutil.ReadFile(opts.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read ssl-ca: %s", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("No valid PEM certificates found in ssl-ca file %s", opts.CAPath)
+		}
+	}
+	if opts.CertPath != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertPath, opts.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load ssl-cert and ssl-key: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch opts.Mode {
+	case "required":
+		config.InsecureSkipVerify = true
+	case "verify_ca":
+		// Verify the certificate chain, but not the hostname. crypto/tls does not
+		// directly support this, so normal verification is disabled and replaced
+		// with a custom chain verification.
+		config.InsecureSkipVerify = true
+		roots := config.RootCAs
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			certs := make([]*x509.Certificate, len(rawCerts))
+			for n, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs[n] = cert
+			}
+			if len(certs) == 0 {
+				return errors.New("No server certificate presented")
+			}
+			verifyOpts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+			for _, cert := range certs[1:] {
+				verifyOpts.Intermediates.AddCert(cert)
+			}
+			_, err := certs[0].Verify(verifyOpts)
+			return err
+		}
+	}
+	return config, nil
+}
+
+var tlsConfigCache struct {
+	sync.Mutex
+	names map[TLSOptions]string
+}
+
+func init() {
+	tlsConfigCache.names = make(map[TLSOptions]string)
+}
+
+// ConfigName returns the value to use for the MySQL driver's tls param, in
+// order to connect using opts. For modes requiring a custom TLS configuration,
+// the configuration is registered with the driver under a name derived from
+// opts. An empty string is returned if opts.Mode is blank.
+func (opts TLSOptions) ConfigName() (string, error) {
+	switch opts.Mode {
+	case "":
+		return "", nil
+	case "disabled":
+		return "false", nil
+	}
+	tlsConfigCache.Lock()
+	defer tlsConfigCache.Unlock()
+	if name, already := tlsConfigCache.names[opts]; already {
+		return name, nil
+	}
+	config, err := opts.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", opts)))
+	name := "skeema-" + hex.EncodeToString(sum[:8])
+	if err := mysql.RegisterTLSConfig(name, config); err != nil {
+		return "", err
+	}
+	tlsConfigCache.names[opts] = name
+	return name, nil
+}
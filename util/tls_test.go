@@ -0,0 +1,92 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skeema/mybase"
+)
+
+func TestTLSOptionsForConfig(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmd := mybase.NewCommand("diff", "", "", nil)
+	cmd.AddArg("environment", "production", false)
+	cmdSuite.AddSubCommand(cmd)
+
+	expected := map[string]TLSOptions{
+		"":                                 {},
+		"--ssl-mode=disabled":              {Mode: "disabled"},
+		"--ssl-mode=REQUIRED":              {Mode: "required"},
+		"--ssl-ca=ca.pem":                  {Mode: "verify_ca", CAPath: "ca.pem"},
+		"--ssl-cert=c.pem --ssl-key=k.pem": {Mode: "required", CertPath: "c.pem", KeyPath: "k.pem"},
+		"--ssl-mode=verify_identity --ssl-server-name=db.internal --tls-min-version=tlsv1.2": {Mode: "verify_identity", ServerName: "db.internal", MinVersion: "tlsv1.2"},
+	}
+	for cli, expectOpts := range expected {
+		cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff "+cli)
+		if opts, err := TLSOptionsForConfig(cfg); err != nil {
+			t.Errorf("Unexpected error from TLSOptionsForConfig with %q: %s", cli, err)
+		} else if opts != expectOpts {
+			t.Errorf("With %q, expected %+v, instead found %+v", cli, expectOpts, opts)
+		}
+	}
+	for _, cli := range []string{"--ssl-mode=preferred", "--ssl-cert=c.pem", "--ssl-key=k.pem", "--tls-min-version=1.2", "--tls-min-version=tlsv1.2", "--ssl-mode=required --ssl-server-name=db.internal"} {
+		cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff "+cli)
+		if _, err := TLSOptionsForConfig(cfg); err == nil {
+			t.Errorf("Expected error from TLSOptionsForConfig with %q, but err was nil", cli)
+		}
+	}
+}
+
+func TestTLSOptionsConfigName(t *testing.T) {
+	if name, err := (TLSOptions{}).ConfigName(); name != "" || err != nil {
+		t.Errorf("Unexpected result from ConfigName with no mode: %q, %v", name, err)
+	}
+	if name, err := (TLSOptions{Mode: "disabled"}).ConfigName(); name != "false" || err != nil {
+		t.Errorf("Unexpected result from ConfigName with disabled mode: %q, %v", name, err)
+	}
+	name1, err := (TLSOptions{Mode: "required"}).ConfigName()
+	if err != nil || name1 == "" {
+		t.Errorf("Unexpected result from ConfigName with required mode: %q, %v", name1, err)
+	}
+	if name2, _ := (TLSOptions{Mode: "required"}).ConfigName(); name2 != name1 {
+		t.Errorf("Expected identical options to yield same config name, instead found %q vs %q", name1, name2)
+	}
+	if name3, _ := (TLSOptions{Mode: "required", MinVersion: "tlsv1.3"}).ConfigName(); name3 == name1 {
+		t.Error("Expected different options to yield different config names, but they did not")
+	}
+	if _, err := (TLSOptions{Mode: "verify_ca", CAPath: "/does/not/exist"}).ConfigName(); err == nil {
+		t.Error("Expected error from ConfigName with nonexistent ssl-ca, but err was nil")
+	}
+}
+
+func TestTLSOptionsVerifyCA(t *testing.T) {
+	caKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	caCert := testCert(t, "Test CA", &caKey.PublicKey, nil, caKey)
+	ioutil.WriteFile("tls-test-ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0666)
+	defer os.Remove("tls-test-ca.pem")
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherCA := testCert(t, "Other CA", &otherKey.PublicKey, nil, otherKey)
+
+	config, err := (TLSOptions{Mode: "verify_ca", CAPath: "tls-test-ca.pem", MinVersion: "tlsv1.2"}).tlsConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error from tlsConfig: %s", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 || !config.InsecureSkipVerify {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+	serverKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	goodCert := testCert(t, "any.hostname", &serverKey.PublicKey, caCert, caKey)
+	badCert := testCert(t, "any.hostname", &serverKey.PublicKey, otherCA, otherKey)
+	if err := config.VerifyPeerCertificate([][]byte{goodCert.Raw}, nil); err != nil {
+		t.Errorf("Expected certificate signed by CA to pass verification, instead found %s", err)
+	}
+	if err := config.VerifyPeerCertificate([][]byte{badCert.Raw}, nil); err == nil {
+		t.Error("Expected certificate signed by different CA to fail verification, but err was nil")
+	}
+}
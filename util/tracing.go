@@ -0,0 +1,314 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracing is the process-wide span recorder. Spans are only recorded after
+// EnableOTLP has been called; otherwise StartSpan returns nil, and all methods
+// of Span are no-ops on a nil receiver. This permits instrumented code to call
+// these methods unconditionally.
+var Tracing = NewTracer()
+
+// otlpBatchSize is the maximum number of spans sent in each OTLP request.
+const otlpBatchSize = 512
+
+// Tracer records spans belonging to a single trace, for export to an
+// OpenTelemetry collector using the OTLP/HTTP protocol with JSON encoding.
+// All methods are safe for concurrent use.
+type Tracer struct {
+	mu          sync.Mutex
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	traceID     string
+	root        *Span
+	finished    []*Span
+}
+
+// NewTracer returns a Tracer which does not record spans until EnableOTLP is
+// called.
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// EnableOTLP causes spans to be recorded and exported to the OTLP/HTTP
+// collector at endpoint, for example "http://localhost:4318". The "/v1/traces"
+// path is appended if not already present. As with other OpenTelemetry
+// exporters, the OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME env vars are
+// respected if set.
+func (tr *Tracer) EnableOTLP(endpoint string) error {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return fmt.Errorf("endpoint %q must begin with http:// or https://", endpoint)
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.endpoint = endpoint
+	tr.traceID = randomHex(16)
+	tr.serviceName = os.Getenv("OTEL_SERVICE_NAME")
+	if tr.serviceName == "" {
+		tr.serviceName = "skeema"
+	}
+	tr.headers = make(map[string]string)
+	for _, kv := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if tokens := strings.SplitN(kv, "=", 2); len(tokens) == 2 {
+			tr.headers[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+		}
+	}
+	return nil
+}
+
+// StartRoot begins the root span of the trace. Subsequent calls to StartSpan
+// with a nil parent will use this span as the parent.
+func (tr *Tracer) StartRoot(name string) *Span {
+	span := tr.StartSpan(nil, name)
+	if span != nil {
+		span.parentID = ""
+		tr.mu.Lock()
+		tr.root = span
+		tr.mu.Unlock()
+	}
+	return span
+}
+
+// StartSpan begins a new span with the supplied name. If parent is nil, the
+// root span is used as the parent. The caller must call End on the returned
+// span, which will be nil if tracing is not enabled.
+func (tr *Tracer) StartSpan(parent *Span, name string) *Span {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.endpoint == "" {
+		return nil
+	}
+	span := &Span{
+		tracer: tr,
+		name:   name,
+		spanID: randomHex(8),
+		start:  time.Now(),
+	}
+	if parent == nil {
+		parent = tr.root
+	}
+	if parent != nil {
+		span.parentID = parent.spanID
+	}
+	return span
+}
+
+// Flush exports all spans which have ended since the previous call to Flush.
+// Spans which have not yet ended are not exported.
+func (tr *Tracer) Flush() error {
+	tr.mu.Lock()
+	spans := tr.finished
+	tr.finished = nil
+	endpoint, headers := tr.endpoint, tr.headers
+	tr.mu.Unlock()
+
+	for len(spans) > 0 {
+		batch := spans
+		if len(batch) > otlpBatchSize {
+			batch = batch[:otlpBatchSize]
+		}
+		spans = spans[len(batch):]
+		body, err := json.Marshal(tr.otlpRequest(batch))
+		if err != nil {
+			return err
+		}
+		if err := postOTLP(endpoint, headers, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Span represents a single timed operation within a trace.
+type Span struct {
+	tracer   *Tracer
+	name     string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    []otlpAttribute
+	errText  string
+}
+
+// SetAttribute adds an attribute to the span. value should be a string, bool,
+// or integer type; any other type is formatted as a string.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	var av otlpAnyValue
+	switch v := value.(type) {
+	case string:
+		av.StringValue = &v
+	case bool:
+		av.BoolValue = &v
+	case int:
+		str := strconv.Itoa(v)
+		av.IntValue = &str
+	case int64:
+		str := strconv.FormatInt(v, 10)
+		av.IntValue = &str
+	default:
+		str := fmt.Sprint(v)
+		av.StringValue = &str
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attrs = append(s.attrs, otlpAttribute{Key: key, Value: av})
+}
+
+// End completes the span. If err is non-nil, the span's status is set to
+// error, with err's message.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	if err != nil {
+		s.errText = err.Error()
+		if s.errText == "" {
+			s.errText = "error"
+		}
+	}
+	s.tracer.finished = append(s.tracer.finished, s)
+}
+
+// The following types implement the subset of the OTLP JSON encoding used by
+// Tracer. See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // int64 values are encoded as JSON strings
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0=unset, 1=ok, 2=error
+	Message string `json:"message,omitempty"`
+}
+
+// otlpRequest converts spans into an OTLP export request body.
+func (tr *Tracer) otlpRequest(spans []*Span) otlpTraceRequest {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	serviceName := tr.serviceName
+	scopeSpans := otlpScopeSpans{
+		Scope: otlpScope{Name: "github.com/skeema/skeema"},
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+	for _, s := range spans {
+		out := otlpSpan{
+			TraceID:           tr.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        s.attrs,
+		}
+		if s.errText != "" {
+			out.Status = otlpStatus{Code: 2, Message: s.errText}
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, out)
+	}
+	return otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{Key: "service.name", Value: otlpAnyValue{StringValue: &serviceName}}},
+			},
+			ScopeSpans: []otlpScopeSpans{scopeSpans},
+		}},
+	}
+}
+
+func postOTLP(endpoint string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// randomHex returns a random identifier of n bytes, encoded as hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	var requests []otlpTraceRequest
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		data, _ := ioutil.ReadAll(r.Body)
+		var req otlpTraceRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("Unable to decode OTLP request: %s\n%s", err, data)
+		}
+		requests = append(requests, req)
+	}))
+	defer server.Close()
+
+	// Before tracing is enabled, spans are nil, and nil spans are safe to use
+	tr := NewTracer()
+	if span := tr.StartRoot("skeema push"); span != nil {
+		t.Errorf("Expected nil span before EnableOTLP, instead found %+v", span)
+	} else {
+		span.SetAttribute("foo", "bar")
+		span.End(nil)
+	}
+	if err := tr.Flush(); err != nil || len(requests) > 0 {
+		t.Errorf("Expected Flush to be a no-op, instead err=%v, requests=%d", err, len(requests))
+	}
+
+	if err := tr.EnableOTLP("localhost:4318"); err == nil {
+		t.Error("Expected EnableOTLP to reject endpoint without scheme, but err was nil")
+	}
+	if err := tr.EnableOTLP(server.URL + "/"); err != nil {
+		t.Fatalf("Unexpected error from EnableOTLP: %s", err)
+	}
+	root := tr.StartRoot("skeema push")
+	child := tr.StartSpan(nil, "target")
+	child.SetAttribute("db.instance", "localhost:3306")
+	child.SetAttribute("skeema.dry_run", false)
+	grandchild := tr.StartSpan(child, "execute statement")
+	grandchild.End(errors.New("Error 1050: Table 'foo' already exists"))
+	grandchild.End(nil) // no effect, since already ended
+	child.End(nil)
+	unfinished := tr.StartSpan(nil, "introspection")
+	root.End(nil)
+	if err := tr.Flush(); err != nil {
+		t.Fatalf("Unexpected error from Flush: %s", err)
+	}
+	if len(requests) != 1 || paths[0] != "/v1/traces" {
+		t.Fatalf("Expected 1 request to /v1/traces, instead found %d requests to %v", len(requests), paths)
+	}
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, instead found %d", len(spans))
+	}
+	byName := make(map[string]otlpSpan)
+	for _, s := range spans {
+		if s.TraceID != spans[0].TraceID || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("Unexpected trace or span ID in %+v", s)
+		}
+		byName[s.Name] = s
+	}
+	if byName["skeema push"].ParentSpanID != "" || byName["target"].ParentSpanID != byName["skeema push"].SpanID || byName["execute statement"].ParentSpanID != byName["target"].SpanID {
+		t.Errorf("Unexpected span hierarchy: %+v", spans)
+	}
+	if status := byName["execute statement"].Status; status.Code != 2 || status.Message != "Error 1050: Table 'foo' already exists" {
+		t.Errorf("Unexpected status for failed span: %+v", status)
+	}
+	if attrs := byName["target"].Attributes; len(attrs) != 2 || *attrs[0].Value.StringValue != "localhost:3306" || *attrs[1].Value.BoolValue {
+		t.Errorf("Unexpected attributes: %+v", attrs)
+	}
+
+	// Spans ending after a flush are exported in the next flush
+	unfinished.End(nil)
+	if err := tr.Flush(); err != nil || len(requests) != 2 {
+		t.Errorf("Expected second flush to send 1 request, instead err=%v, requests=%d", err, len(requests))
+	}
+
+	// Server errors are returned
+	server.Config.Handler = http.NotFoundHandler()
+	tr.StartSpan(nil, "diff").End(nil)
+	if err := tr.Flush(); err == nil {
+		t.Error("Expected Flush to return an error, but err was nil")
+	}
+}
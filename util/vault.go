@@ -0,0 +1,217 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// VaultCredentials represents a username and password obtained from a
+// HashiCorp Vault secret. If the secret was generated dynamically (for example
+// by Vault's database secrets engine), LeaseID and LeaseDuration describe the
+// lease backing the credentials.
+type VaultCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+}
+
+// VaultClient is a minimal client for Vault's HTTP API, supporting only the
+// operations needed to obtain database credentials.
+type VaultClient struct {
+	Address    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// vaultSecret is the JSON response format of Vault's read endpoints.
+type vaultSecret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+// NewVaultClient returns a VaultClient for the server at address. If address
+// is empty, the VAULT_ADDR environment variable is used instead. The token is
+// obtained from the VAULT_TOKEN environment variable, or from ~/.vault-token
+// as written by `vault login`.
+func NewVaultClient(address string) (*VaultClient, error) {
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, errors.New("No Vault address configured: set vault-addr option or VAULT_ADDR environment variable")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home := os.Getenv("HOME"); home != "" {
+			contents, _ := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(contents))
+		}
+	}
+	if token == "" {
+		return nil, errors.New("No Vault token found: set VAULT_TOKEN environment variable or run `vault login`")
+	}
+	return &VaultClient{
+		Address:    strings.TrimRight(address, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// request performs an HTTP request against the Vault API, JSON-encoding body
+// (if non-nil) and decoding the response into a vaultSecret.
+func (vc *VaultClient) request(method, path string, body interface{}) (*vaultSecret, error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	url := fmt.Sprintf("%s/v1/%s", vc.Address, strings.TrimLeft(path, "/"))
+	req, err := http.NewRequest(method, url, &reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", vc.Token)
+	resp, err := vc.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var secret vaultSecret
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil && resp.StatusCode < 400 {
+		return nil, fmt.Errorf("Unable to parse Vault response from %s: %s", path, err)
+	}
+	if resp.StatusCode >= 400 {
+		if len(secret.Errors) > 0 {
+			return nil, fmt.Errorf("Vault returned HTTP %d for %s: %s", resp.StatusCode, path, strings.Join(secret.Errors, "; "))
+		}
+		return nil, fmt.Errorf("Vault returned HTTP %d for %s", resp.StatusCode, path)
+	}
+	return &secret, nil
+}
+
+// ReadCredentials reads the secret at path, which may be a dynamic database
+// credentials endpoint (e.g. "database/creds/myrole") or a static secret in a
+// KV secrets engine of either version. The secret must contain a "password"
+// key, and may optionally contain a "username" key.
+func (vc *VaultClient) ReadCredentials(path string) (*VaultCredentials, error) {
+	secret, err := vc.request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok { // KV version 2
+		data = nested
+	}
+	creds := &VaultCredentials{
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+		Renewable:     secret.Renewable,
+	}
+	creds.Username, _ = data["username"].(string)
+	creds.Password, _ = data["password"].(string)
+	if creds.Password == "" {
+		return nil, fmt.Errorf("Vault secret %s does not contain a password", path)
+	}
+	return creds, nil
+}
+
+// RenewLease extends the lease with the supplied ID by increment, returning
+// the new lease duration granted by Vault.
+func (vc *VaultClient) RenewLease(leaseID string, increment time.Duration) (time.Duration, error) {
+	body := map[string]interface{}{
+		"lease_id":  leaseID,
+		"increment": int(increment / time.Second),
+	}
+	secret, err := vc.request("PUT", "sys/leases/renew", body)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secret.LeaseDuration) * time.Second, nil
+}
+
+// keepRenewed renews creds' lease each time half of its duration elapses,
+// until stop is closed or renewal fails. This prevents dynamic credentials
+// from expiring in the middle of a long-running push.
+func (vc *VaultClient) keepRenewed(creds *VaultCredentials, stop chan struct{}) {
+	duration := creds.LeaseDuration
+	for duration > 0 {
+		select {
+		case <-stop:
+			return
+		case <-time.After(duration / 2):
+		}
+		newDuration, err := vc.RenewLease(creds.LeaseID, creds.LeaseDuration)
+		if err != nil {
+			log.Warnf("Unable to renew Vault lease %s: %s", creds.LeaseID, err)
+			return
+		}
+		log.Debugf("Renewed Vault lease %s for %s", creds.LeaseID, newDuration)
+		duration = newDuration
+	}
+}
+
+var vaultCache struct {
+	sync.Mutex
+	credentials map[string]*VaultCredentials
+	stop        chan struct{}
+}
+
+func init() {
+	vaultCache.credentials = make(map[string]*VaultCredentials)
+	vaultCache.stop = make(chan struct{})
+}
+
+// VaultCredentialsForPath wraps VaultClient.ReadCredentials such that two
+// identical requests will return the same *VaultCredentials. This ensures
+// that dynamic credentials are only generated once per Vault path, rather
+// than once per directory. If the credentials are backed by a renewable
+// lease, a background goroutine keeps the lease renewed until
+// StopVaultRenewals is called.
+func VaultCredentialsForPath(address, path string) (*VaultCredentials, error) {
+	key := fmt.Sprintf("%s:%s", address, path)
+	vaultCache.Lock()
+	defer vaultCache.Unlock()
+	if creds, already := vaultCache.credentials[key]; already {
+		return creds, nil
+	}
+	client, err := NewVaultClient(address)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := client.ReadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Renewable && creds.LeaseID != "" {
+		go client.keepRenewed(creds, vaultCache.stop)
+	}
+	vaultCache.credentials[key] = creds
+	return creds, nil
+}
+
+// StopVaultRenewals halts renewal of all leases for credentials obtained via
+// VaultCredentialsForPath. The leases are left to expire on their own.
+func StopVaultRenewals() {
+	vaultCache.Lock()
+	defer vaultCache.Unlock()
+	close(vaultCache.stop)
+	vaultCache.stop = make(chan struct{})
+	vaultCache.credentials = make(map[string]*VaultCredentials)
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestVaultClient(t *testing.T) {
+	var renewed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "testtoken" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/database/creds/skeema":
+			w.Write([]byte(`{"lease_id":"database/creds/skeema/abc","lease_duration":3600,"renewable":true,"data":{"username":"v-skeema-abc","password":"dynamic"}}`))
+		case "/v1/secret/data/mysql":
+			w.Write([]byte(`{"data":{"data":{"password":"kv2"},"metadata":{"version":3}}}`))
+		case "/v1/secret/nopass":
+			w.Write([]byte(`{"data":{"username":"someone"}}`))
+		case "/v1/sys/leases/renew":
+			var body struct {
+				LeaseID   string `json:"lease_id"`
+				Increment int    `json:"increment"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			renewed = body.LeaseID
+			w.Write([]byte(`{"lease_id":"database/creds/skeema/abc","lease_duration":1800,"renewable":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("VAULT_TOKEN", "testtoken")
+	defer os.Unsetenv("VAULT_TOKEN")
+	client, err := NewVaultClient(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewVaultClient: %s", err)
+	}
+
+	creds, err := client.ReadCredentials("database/creds/skeema")
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadCredentials: %s", err)
+	}
+	expected := VaultCredentials{Username: "v-skeema-abc", Password: "dynamic", LeaseID: "database/creds/skeema/abc", LeaseDuration: time.Hour, Renewable: true}
+	if *creds != expected {
+		t.Errorf("Expected credentials %+v, instead found %+v", expected, *creds)
+	}
+	if duration, err := client.RenewLease(creds.LeaseID, creds.LeaseDuration); err != nil {
+		t.Errorf("Unexpected error from RenewLease: %s", err)
+	} else if duration != 30*time.Minute || renewed != creds.LeaseID {
+		t.Errorf("Unexpected result from RenewLease: duration %s, renewed lease %q", duration, renewed)
+	}
+
+	if creds, err := client.ReadCredentials("/secret/data/mysql"); err != nil {
+		t.Errorf("Unexpected error from ReadCredentials: %s", err)
+	} else if creds.Username != "" || creds.Password != "kv2" || creds.LeaseID != "" {
+		t.Errorf("Unexpected credentials from KV v2 secret: %+v", *creds)
+	}
+
+	for _, path := range []string{"secret/nopass", "secret/missing"} {
+		if _, err := client.ReadCredentials(path); err == nil {
+			t.Errorf("Expected error from ReadCredentials(%q), but err was nil", path)
+		}
+	}
+	client.Token = "wrongtoken"
+	if _, err := client.ReadCredentials("database/creds/skeema"); err == nil {
+		t.Error("Expected error from ReadCredentials with incorrect token, but err was nil")
+	}
+
+	// VaultCredentialsForPath should only generate credentials once per path
+	defer StopVaultRenewals()
+	creds1, err := VaultCredentialsForPath(server.URL, "database/creds/skeema")
+	if err != nil {
+		t.Fatalf("Unexpected error from VaultCredentialsForPath: %s", err)
+	}
+	if creds2, _ := VaultCredentialsForPath(server.URL, "database/creds/skeema"); creds2 != creds1 {
+		t.Error("Expected VaultCredentialsForPath to return cached credentials, but it did not")
+	}
+
+	// Missing address or token should be errors
+	os.Unsetenv("VAULT_ADDR")
+	if _, err := NewVaultClient(""); err == nil {
+		t.Error("Expected error from NewVaultClient with no address, but err was nil")
+	}
+	os.Unsetenv("VAULT_TOKEN")
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", "/does/not/exist")
+	defer os.Setenv("HOME", home)
+	if _, err := NewVaultClient(server.URL); err == nil {
+		t.Error("Expected error from NewVaultClient with no token, but err was nil")
+	}
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/tengo"
+)
+
+// VitessMode indicates whether Skeema should account for the limitations of
+// Vitess, and if so, whether the keyspace is sharded.
+type VitessMode string
+
+// Constants representing valid VitessMode values
+const (
+	VitessOff       VitessMode = "off"
+	VitessUnsharded VitessMode = "unsharded"
+	VitessSharded   VitessMode = "sharded"
+)
+
+// VitessModeForConfig returns the VitessMode configured by the "vitess"
+// option. An error is returned if the option has an invalid value.
+func VitessModeForConfig(cfg *mybase.Config) (VitessMode, error) {
+	value, err := cfg.GetEnum("vitess", string(VitessOff), string(VitessUnsharded), string(VitessSharded))
+	if err != nil {
+		return VitessOff, err
+	}
+	return VitessMode(value), nil
+}
+
+// Enabled returns true if Vitess compatibility mode is in use.
+func (mode VitessMode) Enabled() bool {
+	return mode == VitessUnsharded || mode == VitessSharded
+}
+
+// Sharded returns true if the keyspace is sharded.
+func (mode VitessMode) Sharded() bool {
+	return mode == VitessSharded
+}
+
+// VitessSequenceComment is the table comment which marks a Vitess sequence
+// backing table.
+const VitessSequenceComment = "vitess_sequence"
+
+// IsVitessSequenceTable returns true if table is marked as a Vitess sequence
+// backing table.
+func IsVitessSequenceTable(table *tengo.Table) bool {
+	return table.Comment == VitessSequenceComment
+}
+
+// VitessSequenceProblem returns a description of any structural problem with
+// the supplied sequence backing table, or an empty string if it is valid.
+// Vitess requires columns id, next_id, and cache, with id as the primary key.
+func VitessSequenceProblem(table *tengo.Table) string {
+	var missing []string
+	cols := table.ColumnsByName()
+	for _, name := range []string{"id", "next_id", "cache"} {
+		if cols[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "missing required column " + strings.Join(missing, ", ")
+	}
+	if table.PrimaryKey == nil || len(table.PrimaryKey.Columns) != 1 || table.PrimaryKey.Columns[0].Name != "id" {
+		return "primary key must consist of only column id"
+	}
+	return ""
+}
+
+// Vitess creates tables with these name patterns for its own use: artifacts of
+// online DDL migrations and table lifecycle management (hold, purge, evac,
+// drop).
+var vitessInternalTableRegexp = regexp.MustCompile(`^_vt_|^_[0-9a-f]{8}_[0-9a-f]{4}_[0-9a-f]{4}_[0-9a-f]{4}_[0-9a-f]{12}_[0-9]{14}_(?:gho|ghc|del|vrepl)$`)
+
+// IsVitessInternalTable returns true if name matches a table name pattern
+// used internally by Vitess.
+func IsVitessInternalTable(name string) bool {
+	return vitessInternalTableRegexp.MatchString(name)
+}
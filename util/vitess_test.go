@@ -0,0 +1,50 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestIsVitessInternalTable(t *testing.T) {
+	cases := map[string]bool{
+		"_vt_hld_6ace8bcef73211ea87e9f875a4d24e90_20200915120410_":   true,
+		"_vt_vrp_6ace8bcef73211ea87e9f875a4d24e90_20200915120410_":   true,
+		"_84371a37_6153_11eb_9917_f875a4d24e90_20210128122816_vrepl": true,
+		"_84371a37_6153_11eb_9917_f875a4d24e90_20210128122816_gho":   true,
+		"_84371a37_6153_11eb_9917_f875a4d24e90_20210128122816_foo":   false,
+		"_vtt":  false,
+		"users": false,
+	}
+	for input, expected := range cases {
+		if actual := IsVitessInternalTable(input); actual != expected {
+			t.Errorf("Expected IsVitessInternalTable(%q) to return %t, instead found %t", input, expected, actual)
+		}
+	}
+}
+
+func TestVitessSequenceProblem(t *testing.T) {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(11)"}
+	nextIDCol := &tengo.Column{Name: "next_id", TypeInDB: "bigint(20)"}
+	cacheCol := &tengo.Column{Name: "cache", TypeInDB: "bigint(20)"}
+	table := &tengo.Table{
+		Name:       "user_seq",
+		Comment:    VitessSequenceComment,
+		Columns:    []*tengo.Column{idCol, nextIDCol, cacheCol},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{idCol}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+	}
+	if !IsVitessSequenceTable(table) {
+		t.Fatal("Expected table to be a sequence table, but it was not")
+	}
+	if problem := VitessSequenceProblem(table); problem != "" {
+		t.Errorf("Expected no problem with valid sequence table, instead found %q", problem)
+	}
+	table.PrimaryKey.Columns = []*tengo.Column{nextIDCol}
+	if problem := VitessSequenceProblem(table); problem != "primary key must consist of only column id" {
+		t.Errorf("Unexpected problem for wrong primary key: %q", problem)
+	}
+	table.Columns = []*tengo.Column{idCol}
+	if problem := VitessSequenceProblem(table); problem != "missing required column next_id, cache" {
+		t.Errorf("Unexpected problem for missing columns: %q", problem)
+	}
+}
@@ -111,6 +111,17 @@ func (ld *LocalDocker) IntrospectSchema() (*tengo.Schema, error) {
 	return ld.d.Schema(ld.schemaName)
 }
 
+// Instance returns the Dockerized instance containing the temporary workspace
+// schema.
+func (ld *LocalDocker) Instance() *tengo.Instance {
+	return ld.d.Instance
+}
+
+// SchemaName returns the name of the temporary workspace schema.
+func (ld *LocalDocker) SchemaName() string {
+	return ld.schemaName
+}
+
 // Cleanup drops the temporary schema from the Dockerized instance. If any
 // tables have any rows in the temp schema, the cleanup aborts and an error is
 // returned.
@@ -70,6 +70,16 @@ func (ts *TempSchema) IntrospectSchema() (*tengo.Schema, error) {
 	return ts.inst.Schema(ts.schemaName)
 }
 
+// Instance returns the instance containing the temporary workspace schema.
+func (ts *TempSchema) Instance() *tengo.Instance {
+	return ts.inst
+}
+
+// SchemaName returns the name of the temporary workspace schema.
+func (ts *TempSchema) SchemaName() string {
+	return ts.schemaName
+}
+
 // Cleanup either drops the temporary schema (if not using reuse-temp-schema)
 // or just drops all tables in the schema (if using reuse-temp-schema). If any
 // tables have any rows in the temp schema, the cleanup aborts and an error is
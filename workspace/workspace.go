@@ -16,6 +16,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -36,6 +37,16 @@ type Workspace interface {
 	Cleanup() error
 }
 
+// Locator is implemented by Workspaces which reside in a schema on a database
+// instance, permitting external programs to connect to the workspace directly.
+type Locator interface {
+	// Instance returns the database instance containing the workspace schema.
+	Instance() *tengo.Instance
+
+	// SchemaName returns the name of the workspace schema.
+	SchemaName() string
+}
+
 // Type represents a kind of workspace to use.
 type Type int
 
@@ -83,6 +94,7 @@ type Options struct {
 	RootPassword        string    // only TypeLocalDocker
 	PrefabWorkspace     Workspace // only TypePrefab
 	LockWaitTimeout     time.Duration
+	LoadSeeds           bool // whether to run the LogicalSchema's Seeds after its DDL
 }
 
 // New returns a pointer to a ready-to-use Workspace, using the configuration
@@ -103,8 +115,8 @@ func New(opts Options) (Workspace, error) {
 // A non-nil instance should be supplied, unless the caller already knows the
 // workspace won't be temp-schema based.
 // This method relies on option definitions from util.AddGlobalOptions(),
-// including "workspace", "temp-schema", "flavor", "docker-cleanup", and
-// "reuse-temp-schema".
+// including "workspace", "temp-schema", "flavor", "docker-cleanup",
+// "reuse-temp-schema", and "vitess".
 func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 	requestedType, err := dir.Config.GetEnum("workspace", "temp-schema", "docker")
 	if err != nil {
@@ -114,8 +126,33 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 		CleanupAction:   CleanupActionNone,
 		SchemaName:      dir.Config.Get("temp-schema"),
 		LockWaitTimeout: 30 * time.Second,
+		LoadSeeds:       dir.Config.GetBool("seeds"),
+	}
+	if vitess, err := util.VitessModeForConfig(dir.Config); err != nil {
+		return Options{}, err
+	} else if vitess.Enabled() && requestedType == "temp-schema" {
+		// VTGate does not permit creating or dropping a temporary schema
+		return Options{}, fmt.Errorf("vitess=%s requires workspace=docker, since VTGate does not permit creating a temp-schema", vitess)
+	}
+	if requestedType == "docker" && !dir.Config.OnCLI("workspace") && instance != nil {
+		// A stock MySQL container does not reflect Aurora's behavior, so unless
+		// workspace=docker was explicitly requested on the command-line, ignore
+		// option files which use it as a default
+		if aurora := util.InstanceAuroraVersion(instance); aurora.Known() {
+			log.Warnf("Instance %s is %s; using workspace=temp-schema instead of workspace=docker, since a MySQL container does not reflect Aurora behavior. Supply --workspace=docker on the command-line to override.", instance, aurora)
+			requestedType = "temp-schema"
+		}
 	}
 	if requestedType == "docker" {
+		// The official TiDB images cannot be managed like MySQL images, since they
+		// listen on a different port and do not support setting a root password
+		tidb := util.ParseTiDBFlavor(dir.Config.Get("flavor"))
+		if !tidb.Known() && instance != nil {
+			tidb = util.InstanceTiDBFlavor(instance)
+		}
+		if tidb.Known() {
+			return Options{}, fmt.Errorf("workspace=docker is not supported with flavor %s; use workspace=temp-schema instead", tidb)
+		}
 		opts.Type = TypeLocalDocker
 		opts.Flavor = tengo.NewFlavor(dir.Config.Get("flavor"))
 		if !opts.Flavor.Known() && instance != nil {
@@ -133,6 +170,11 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 			return Options{}, err
 		}
 	} else {
+		// Seed data must never be written to a real database instance, even in a
+		// temporary schema
+		if opts.LoadSeeds {
+			return Options{}, errors.New("seeds requires workspace=docker, since seed data is not permitted to be written to a real database instance")
+		}
 		opts.Type = TypeTempSchema
 		opts.Instance = instance
 		if !dir.Config.GetBool("reuse-temp-schema") {
@@ -210,20 +252,49 @@ func (se *StatementError) String() string {
 // created) are non-fatal, and are returned in the second return value. The
 // third return value represents fatal errors only.
 func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (schema *tengo.Schema, statementErrors []*StatementError, fatalErr error) {
+	var ws Workspace
+	ws, statementErrors, fatalErr = Materialize(logicalSchema, opts)
+	if fatalErr != nil {
+		return
+	}
+	defer func() {
+		if cleanupErr := ws.Cleanup(); fatalErr == nil {
+			fatalErr = cleanupErr
+		}
+	}()
+
+	schema, fatalErr = ws.IntrospectSchema()
+
+	// Workspaces refuse to drop tables containing rows, as a safety mechanism, so
+	// any seed data must be removed before cleanup.
+	if opts.LoadSeeds && len(logicalSchema.Seeds) > 0 && fatalErr == nil {
+		fatalErr = EmptyTables(ws, schema)
+	}
+	return
+}
+
+// Materialize obtains a Workspace and executes the creation DDL contained in
+// a LogicalSchema there, followed by its seed data if opts.LoadSeeds is true.
+// Unlike ExecLogicalSchema, the Workspace is returned without being cleaned
+// up; the caller must call its Cleanup method when done with it. SQL errors
+// are non-fatal, and are returned in the second return value. If a fatal error
+// occurs, the third return value is non-nil and no cleanup is necessary.
+func Materialize(logicalSchema *fs.LogicalSchema, opts Options) (ws Workspace, statementErrors []*StatementError, fatalErr error) {
+	defer util.Metrics.TimeSince("workspace_setup_duration", time.Now())
 	if logicalSchema.CharSet != "" {
 		opts.DefaultCharacterSet = logicalSchema.CharSet
 	}
 	if logicalSchema.Collation != "" {
 		opts.DefaultCollation = logicalSchema.Collation
 	}
-	var ws Workspace
 	ws, fatalErr = New(opts)
 	if fatalErr != nil {
 		return
 	}
 	defer func() {
-		if cleanupErr := ws.Cleanup(); fatalErr == nil {
-			fatalErr = cleanupErr
+		if fatalErr != nil {
+			ws.Cleanup()
+			ws = nil
 		}
 	}()
 
@@ -278,10 +349,92 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (schema *t
 		}
 	}
 
-	schema, fatalErr = ws.IntrospectSchema()
+	// Load seed data sequentially, after all DDL. This permits detection of
+	// problems that only surface once tables contain rows.
+	if opts.LoadSeeds {
+		for _, statement := range logicalSchema.Seeds {
+			if _, err := db.Exec(statement.Body()); err != nil {
+				statementErrors = append(statementErrors, &StatementError{
+					Statement: statement,
+					Err:       fmt.Errorf("Error loading seed data in workspace: %s", err),
+				})
+			}
+		}
+	}
 	return
 }
 
+// ExecStatements obtains a Workspace, executes the supplied statements there
+// sequentially in the order given, introspects the result into a
+// *tengo.Schema, and then cleans up the Workspace. This is intended for
+// replaying imperative migrations, so statements may be of any type, including
+// ALTERs and DML. Execution stops at the first SQL error, which is returned
+// as a *StatementError.
+func ExecStatements(statements []*fs.Statement, opts Options) (schema *tengo.Schema, err error) {
+	ws, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cleanupErr := ws.Cleanup(); err == nil {
+			err = cleanupErr
+		}
+	}()
+
+	// See comment in Materialize regarding the need for two connection pools.
+	// Each is limited to one connection, so that any session state set by a
+	// statement carries over to subsequent statements using the same pool.
+	db, err := ws.ConnectionPool("")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to workspace: %s", err)
+	}
+	dbRemember, err := ws.ConnectionPool("sql_mode=@@GLOBAL.sql_mode")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to workspace: %s", err)
+	}
+	defer db.SetMaxOpenConns(0)
+	defer dbRemember.SetMaxOpenConns(0)
+	db.SetMaxOpenConns(1)
+	dbRemember.SetMaxOpenConns(1)
+	for _, statement := range statements {
+		var stmtErr *StatementError
+		if statement.Type == fs.StatementTypeCreate && (statement.ObjectType == tengo.ObjectTypeFunc || statement.ObjectType == tengo.ObjectTypeProc) {
+			stmtErr = execStatement(dbRemember, statement)
+		} else {
+			stmtErr = execStatement(db, statement)
+		}
+		if stmtErr != nil {
+			// Introspect anyway, so that any rows can be removed prior to cleanup
+			if schema, introspectErr := ws.IntrospectSchema(); introspectErr == nil {
+				EmptyTables(ws, schema)
+			}
+			return nil, stmtErr
+		}
+	}
+
+	if schema, err = ws.IntrospectSchema(); err != nil {
+		return nil, err
+	}
+	// Migrations may insert rows, which must be removed before cleanup
+	return schema, EmptyTables(ws, schema)
+}
+
+// EmptyTables deletes all rows from the tables of schema, which must be the
+// introspected state of ws. Since workspaces refuse to drop tables containing
+// rows, this must be called before cleanup if any rows were inserted.
+func EmptyTables(ws Workspace, schema *tengo.Schema) error {
+	db, err := ws.ConnectionPool("foreign_key_checks=0")
+	if err != nil {
+		return fmt.Errorf("Cannot connect to workspace: %s", err)
+	}
+	for _, table := range schema.Tables {
+		if _, err := db.Exec("DELETE FROM " + tengo.EscapeIdentifier(table.Name)); err != nil {
+			return fmt.Errorf("Cannot remove data from workspace: %s", err)
+		}
+	}
+	return nil
+}
+
 func execStatement(db *sqlx.DB, statement *fs.Statement) (stmtErr *StatementError) {
 	_, err := db.Exec(statement.Body())
 	if err == nil {
@@ -342,6 +495,7 @@ func getLock(instance *tengo.Instance, lockName string, maxWait time.Duration) (
 		// query killers, spurious slow query logging, etc
 		err := lockConn.QueryRowContext(context.Background(), "SELECT GET_LOCK(?, 1)", lockName).Scan(&getLockResult)
 		if err == nil && getLockResult == 1 {
+			util.Metrics.TimeSince("workspace_lock_wait_duration", start)
 			// Launch a goroutine to keep the connection active, and release the lock
 			// once the ReleaseFunc is called
 			go connMaintainer()
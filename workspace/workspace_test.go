@@ -100,6 +100,24 @@ func (s WorkspaceIntegrationSuite) TestExecLogicalSchema(t *testing.T) {
 	}
 	dir.LogicalSchemas[0].Alters = []*fs.Statement{}
 
+	// Test with seed data: a valid INSERT, followed by one violating a unique
+	// constraint. The latter should only be executed if opts.LoadSeeds is true.
+	dir.LogicalSchemas[0].Seeds = []*fs.Statement{
+		{Text: "INSERT INTO users (name) VALUES ('alice')"},
+		{Text: "INSERT INTO users (name) VALUES ('alice')"},
+	}
+	opts.LoadSeeds = true
+	if _, stmtErrors, err = ExecLogicalSchema(dir.LogicalSchemas[0], opts); err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	} else if len(stmtErrors) != 1 || stmtErrors[0].Statement != dir.LogicalSchemas[0].Seeds[1] {
+		t.Errorf("Expected one StatementError for second seed statement, instead found %v", stmtErrors)
+	}
+	opts.LoadSeeds = false
+	if _, stmtErrors, err = ExecLogicalSchema(dir.LogicalSchemas[0], opts); err != nil || len(stmtErrors) > 0 {
+		t.Errorf("Expected seeds to be skipped, but found error %v and StatementErrors %v", err, stmtErrors)
+	}
+	dir.LogicalSchemas[0].Seeds = nil
+
 	// Introduce an intentional syntax error
 	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"}
 	stmt := dir.LogicalSchemas[0].Creates[key]
@@ -130,6 +148,59 @@ func (s WorkspaceIntegrationSuite) TestExecLogicalSchema(t *testing.T) {
 	}
 }
 
+func (s WorkspaceIntegrationSuite) TestMaterialize(t *testing.T) {
+	dirPath := "../testdata/golden/init/mydb/product"
+	if major, minor, _ := s.d.Version(); major == 5 && minor == 5 {
+		dirPath = strings.Replace(dirPath, "golden", "golden-mysql55", 1)
+	}
+	dir := s.getParsedDir(t, dirPath, "")
+	opts, err := OptionsForDir(dir, s.d.Instance)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	opts.LockWaitTimeout = 100 * time.Millisecond
+	opts.LoadSeeds = true
+	dir.LogicalSchemas[0].Seeds = []*fs.Statement{
+		{Text: "INSERT INTO users (name) VALUES ('alice')"},
+	}
+	defer func() {
+		dir.LogicalSchemas[0].Seeds = nil
+	}()
+
+	ws, stmtErrors, err := Materialize(dir.LogicalSchemas[0], opts)
+	if err != nil || len(stmtErrors) > 0 {
+		t.Fatalf("Unexpected error from Materialize: %v %v", err, stmtErrors)
+	}
+	locator, ok := ws.(Locator)
+	if !ok {
+		t.Fatalf("Expected workspace of type %T to implement Locator", ws)
+	} else if locator.Instance() != s.d.Instance || locator.SchemaName() != opts.SchemaName {
+		t.Errorf("Unexpected location of workspace: %s %s", locator.Instance(), locator.SchemaName())
+	}
+
+	// Workspace should persist, with seed data, until Cleanup is called
+	schema, err := ws.IntrospectSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error from IntrospectSchema: %s", err)
+	} else if len(schema.Tables) < 4 {
+		t.Errorf("Expected at least 4 tables, but instead found %d", len(schema.Tables))
+	}
+	db, err := ws.ConnectionPool("")
+	if err != nil {
+		t.Fatalf("Unexpected error from ConnectionPool: %s", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected seed data to be present; found count=%d err=%v", count, err)
+	}
+	if err := EmptyTables(ws, schema); err != nil {
+		t.Errorf("Unexpected error from EmptyTables: %s", err)
+	}
+	if err := ws.Cleanup(); err != nil {
+		t.Errorf("Unexpected error from Cleanup: %s", err)
+	}
+}
+
 func (s WorkspaceIntegrationSuite) TestOptionsForDir(t *testing.T) {
 	getOpts := func(cliFlags string) Options {
 		t.Helper()
@@ -152,6 +223,7 @@ func (s WorkspaceIntegrationSuite) TestOptionsForDir(t *testing.T) {
 	assertOptsError("--workspace=invalid")
 	assertOptsError("--workspace=docker --docker-cleanup=invalid")
 	assertOptsError("--workspace=docker --connect-options='autocommit=0'")
+	assertOptsError("--workspace=temp-schema --seeds")
 
 	// Test default configuration, which should use temp-schema with drop cleanup
 	if opts := getOpts(""); opts.Type != TypeTempSchema || opts.CleanupAction != CleanupActionDrop {
@@ -171,6 +243,11 @@ func (s WorkspaceIntegrationSuite) TestOptionsForDir(t *testing.T) {
 		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
 	}
 
+	// Test docker with seeds
+	if opts = getOpts("--workspace=docker --seeds"); !opts.LoadSeeds {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+
 	// Test docker with other cleanup actions
 	if opts = getOpts("--workspace=docker --docker-cleanup=STOP"); opts.CleanupAction != CleanupActionStop {
 		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)